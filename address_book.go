@@ -0,0 +1,221 @@
+package sequence
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence/contracts"
+)
+
+// AddressBookEntry is a wallet recorded in an AddressBook.
+type AddressBookEntry struct {
+	Wallet common.Address
+
+	// ImageHash is the current image hash of the wallet, as of the last event of the wallet
+	// imported.
+	ImageHash common.Hash
+
+	// InitialImageHash is the image hash the wallet was deployed with, which its address is
+	// derived from.
+	InitialImageHash common.Hash
+
+	// BlockNumber is the block of the last change of ImageHash imported, or of the
+	// deployment of the wallet.
+	BlockNumber uint64
+}
+
+// AddressBook indexes the wallets deployed by the factory of a wallet context by their
+// current image hash, so the wallets controlled by a config can be looked up.
+//
+// The factory doesn't emit events, so deployments are found by tracing the calls to it, see
+// ScanDeployments, or added with AddDeployment. The config updates of the wallets are then
+// followed with the ImageHashUpdated events they emit, see ImportLogs. Only the events of
+// wallets known to the book are imported, as any contract may emit them. It is safe for
+// concurrent use.
+type AddressBook struct {
+	walletContext WalletContext
+	wallets       map[common.Address]*AddressBookEntry
+	byImageHash   map[common.Hash]map[common.Address]struct{}
+	mu            sync.RWMutex
+}
+
+func NewAddressBook(walletContext WalletContext) *AddressBook {
+	return &AddressBook{
+		walletContext: walletContext,
+		wallets:       map[common.Address]*AddressBookEntry{},
+		byImageHash:   map[common.Hash]map[common.Address]struct{}{},
+	}
+}
+
+// AddDeployment records the wallet deployed with initialImageHash in block blockNumber, and
+// returns its address. A wallet already recorded is left as is.
+func (b *AddressBook) AddDeployment(initialImageHash common.Hash, blockNumber uint64) (common.Address, error) {
+	wallet, err := AddressFromImageHash(initialImageHash.Hex(), b.walletContext)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.wallets[wallet]; !ok {
+		b.wallets[wallet] = &AddressBookEntry{Wallet: wallet, InitialImageHash: initialImageHash, BlockNumber: blockNumber}
+		b.index(wallet, initialImageHash)
+	}
+	return wallet, nil
+}
+
+// ImportLogs follows the config updates of the wallets of the book, from their
+// ImageHashUpdated events in logs. Other logs are ignored, and logs must be in chain order.
+func (b *AddressBook) ImportLogs(logs []types.Log) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, log := range logs {
+		if len(log.Topics) == 0 || log.Topics[0] != ImageHashUpdatedEventSig || log.Removed {
+			continue
+		}
+		entry, ok := b.wallets[log.Address]
+		if !ok || log.BlockNumber < entry.BlockNumber {
+			continue
+		}
+		if len(log.Data) != 32 {
+			return fmt.Errorf("sequence: invalid ImageHashUpdated event of %v in txn %v", log.Address.Hex(), log.TxHash.Hex())
+		}
+
+		imageHash := common.BytesToHash(log.Data)
+		b.unindex(entry.Wallet, entry.ImageHash)
+		entry.ImageHash = imageHash
+		entry.BlockNumber = log.BlockNumber
+		b.index(entry.Wallet, imageHash)
+	}
+	return nil
+}
+
+// ScanDeployments records the wallets deployed from block fromBlock to toBlock, by tracing
+// the calls to the factory with trace_filter, as served by archival nodes (ie. erigon or
+// nethermind). Calls from other contracts, ie. of wallets deployed by the guest module, are
+// traced too. It returns the addresses of the wallets deployed.
+func (b *AddressBook) ScanDeployments(ctx context.Context, provider *ethrpc.Provider, fromBlock, toBlock uint64) ([]common.Address, error) {
+	var traces []deploymentTrace
+	params := traceFilterQuery{FromBlock: hexutil.Uint64(fromBlock), ToBlock: hexutil.Uint64(toBlock), ToAddress: []common.Address{b.walletContext.FactoryAddress}}
+	if err := provider.Do(ctx, ethrpc.NewCallBuilder[[]deploymentTrace]("trace_filter", nil, params).Into(&traces)); err != nil {
+		return nil, fmt.Errorf("sequence: trace_filter failed: %w", err)
+	}
+
+	deploy := contracts.WalletFactory.ABI.Methods["deploy"]
+	var wallets []common.Address
+	for _, trace := range traces {
+		input := trace.Action.Input
+		if trace.Error != "" || trace.Action.To != b.walletContext.FactoryAddress || len(input) < 4 || string(input[:4]) != string(deploy.ID) {
+			continue
+		}
+		args, err := deploy.Inputs.Unpack(input[4:])
+		if err != nil {
+			return nil, fmt.Errorf("sequence: invalid deploy call in txn %v: %w", trace.TransactionHash.Hex(), err)
+		}
+		// wallets of other wallet contexts are deployed by the same factory
+		if args[0].(common.Address) != b.walletContext.MainModuleAddress {
+			continue
+		}
+
+		wallet, err := b.AddDeployment(common.Hash(args[1].([32]byte)), trace.BlockNumber)
+		if err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, wallet)
+	}
+	return wallets, nil
+}
+
+type deploymentTrace struct {
+	Action struct {
+		To    common.Address `json:"to"`
+		Input hexutil.Bytes  `json:"input"`
+	} `json:"action"`
+	BlockNumber     uint64      `json:"blockNumber"`
+	TransactionHash common.Hash `json:"transactionHash"`
+	Error           string      `json:"error"`
+}
+
+// Wallet returns the entry of wallet, if it is recorded.
+func (b *AddressBook) Wallet(wallet common.Address) (AddressBookEntry, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	entry, ok := b.wallets[wallet]
+	if !ok {
+		return AddressBookEntry{}, false
+	}
+	return *entry, true
+}
+
+// WalletsOfImageHash returns the wallets whose current image hash is imageHash, sorted by
+// address.
+func (b *AddressBook) WalletsOfImageHash(imageHash common.Hash) []common.Address {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	wallets := make([]common.Address, 0, len(b.byImageHash[imageHash]))
+	for wallet := range b.byImageHash[imageHash] {
+		wallets = append(wallets, wallet)
+	}
+	sort.Slice(wallets, func(i, j int) bool {
+		return wallets[i].Hash().Big().Cmp(wallets[j].Hash().Big()) < 0
+	})
+	return wallets
+}
+
+// WalletsOfConfig returns the wallets whose current config is config, sorted by address.
+func (b *AddressBook) WalletsOfConfig(config WalletConfig) ([]common.Address, error) {
+	imageHash, err := ImageHashOfWalletConfigBytes32(config)
+	if err != nil {
+		return nil, err
+	}
+	return b.WalletsOfImageHash(imageHash), nil
+}
+
+// Entries returns the entries of all the wallets of the book, sorted by address.
+func (b *AddressBook) Entries() []AddressBookEntry {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	entries := make([]AddressBookEntry, 0, len(b.wallets))
+	for _, entry := range b.wallets {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Wallet.Hash().Big().Cmp(entries[j].Wallet.Hash().Big()) < 0
+	})
+	return entries
+}
+
+// Len returns the number of wallets of the book.
+func (b *AddressBook) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.wallets)
+}
+
+// index must be called with mu held.
+func (b *AddressBook) index(wallet common.Address, imageHash common.Hash) {
+	b.wallets[wallet].ImageHash = imageHash
+	if b.byImageHash[imageHash] == nil {
+		b.byImageHash[imageHash] = map[common.Address]struct{}{}
+	}
+	b.byImageHash[imageHash][wallet] = struct{}{}
+}
+
+// unindex must be called with mu held.
+func (b *AddressBook) unindex(wallet common.Address, imageHash common.Hash) {
+	delete(b.byImageHash[imageHash], wallet)
+	if len(b.byImageHash[imageHash]) == 0 {
+		delete(b.byImageHash, imageHash)
+	}
+}
@@ -0,0 +1,75 @@
+package sequence_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressBook(t *testing.T) {
+	walletContext := sequence.SequenceContext()
+
+	owner, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(owner)
+	assert.NoError(t, err)
+	imageHash, err := wallet.ImageHash()
+	assert.NoError(t, err)
+
+	deploy, err := contracts.WalletFactory.Encode("deploy", walletContext.MainModuleAddress, imageHash)
+	assert.NoError(t, err)
+	otherContext, err := contracts.WalletFactory.Encode("deploy", common.HexToAddress("0x01"), imageHash)
+	assert.NoError(t, err)
+
+	// the chain serves the traces of the calls to the factory, which memchain doesn't record
+	chain := memchain.New(memchain.Options{ChainID: big.NewInt(1)})
+	chain.Intercept(func(method string, params []json.RawMessage, next func() (interface{}, error)) (interface{}, error) {
+		if method != "trace_filter" {
+			return next()
+		}
+		call := func(input []byte, err string) map[string]interface{} {
+			return map[string]interface{}{
+				"action":          map[string]interface{}{"to": walletContext.FactoryAddress, "input": hexutil.Encode(input)},
+				"blockNumber":     10,
+				"transactionHash": common.HexToHash("0x01"),
+				"error":           err,
+			}
+		}
+		return []interface{}{call(deploy, ""), call(deploy, "Reverted"), call(otherContext, "")}, nil
+	})
+	provider := chain.Provider()
+
+	book := sequence.NewAddressBook(walletContext)
+	wallets, err := book.ScanDeployments(context.Background(), provider, 0, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []common.Address{wallet.Address()}, wallets)
+	assert.Equal(t, 1, book.Len())
+
+	found, err := book.WalletsOfConfig(wallet.GetWalletConfig())
+	assert.NoError(t, err)
+	assert.Equal(t, []common.Address{wallet.Address()}, found)
+
+	// the wallet updates its config, and an unknown contract emits the same event
+	updated := common.HexToHash("0x1234")
+	assert.NoError(t, book.ImportLogs([]types.Log{
+		{Address: wallet.Address(), Topics: []common.Hash{sequence.ImageHashUpdatedEventSig}, Data: updated.Bytes(), BlockNumber: 20},
+		{Address: common.HexToAddress("0x02"), Topics: []common.Hash{sequence.ImageHashUpdatedEventSig}, Data: updated.Bytes(), BlockNumber: 20},
+	}))
+	assert.Empty(t, book.WalletsOfImageHash(imageHash))
+	assert.Equal(t, []common.Address{wallet.Address()}, book.WalletsOfImageHash(updated))
+
+	entry, ok := book.Wallet(wallet.Address())
+	assert.True(t, ok)
+	assert.Equal(t, sequence.AddressBookEntry{Wallet: wallet.Address(), ImageHash: updated, InitialImageHash: imageHash, BlockNumber: 20}, entry)
+}
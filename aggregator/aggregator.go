@@ -0,0 +1,140 @@
+// Package aggregator lets the bundles of many wallets share one aggregated signature, where
+// the wallets and the entrypoint relaying them support it, ie. with an ERC-4337
+// aggregator. Aggregating replaces the signature of each bundle with a single signature
+// for all of them, cutting calldata costs when relaying bundles in bulk.
+//
+// EXPERIMENTAL: the Sequence wallet modules do not validate aggregated signatures, so
+// aggregated bundles are only relayable through contracts which do.
+package aggregator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+)
+
+var (
+	// ErrInvalidSignature is returned when the signature of a bundle, or an aggregated
+	// signature, is not valid.
+	ErrInvalidSignature = fmt.Errorf("aggregator: invalid signature")
+
+	// ErrUnknownSigner is returned when an Aggregator does not know the key of a wallet.
+	ErrUnknownSigner = fmt.Errorf("aggregator: unknown signer")
+)
+
+// Aggregator aggregates the signatures of bundles, as an ERC-4337 IAggregator does for
+// user operations.
+type Aggregator interface {
+	// Address is the contract validating aggregated signatures on-chain, if any.
+	Address() common.Address
+
+	// ValidateBundleSignature validates the signature of a bundle before it is aggregated.
+	ValidateBundleSignature(bundle *sequence.SignedTransactions) error
+
+	// AggregateSignatures returns the aggregated signature of the bundles.
+	AggregateSignatures(bundles []*sequence.SignedTransactions) ([]byte, error)
+
+	// ValidateSignatures validates the aggregated signature of the bundles.
+	ValidateSignatures(bundles []*sequence.SignedTransactions, signature []byte) error
+}
+
+// AggregatedBundles are bundles sharing an aggregated signature. The bundles do not carry
+// their own signatures.
+type AggregatedBundles struct {
+	Aggregator common.Address
+	Bundles    []*sequence.SignedTransactions
+	Signature  []byte
+}
+
+// Aggregate validates the signatures of bundles, and aggregates them with aggregator.
+// The bundles are copied, and their signatures removed.
+func Aggregate(aggregator Aggregator, bundles []*sequence.SignedTransactions) (*AggregatedBundles, error) {
+	if len(bundles) == 0 {
+		return nil, errors.New("aggregator: no bundles to aggregate")
+	}
+	for i, bundle := range bundles {
+		if err := bundle.Verify(); err != nil {
+			return nil, fmt.Errorf("aggregator: bundle %d: %w", i, err)
+		}
+		if err := aggregator.ValidateBundleSignature(bundle); err != nil {
+			return nil, fmt.Errorf("aggregator: bundle %d: %w", i, err)
+		}
+	}
+
+	signature, err := aggregator.AggregateSignatures(bundles)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregated := &AggregatedBundles{
+		Aggregator: aggregator.Address(),
+		Bundles:    make([]*sequence.SignedTransactions, len(bundles)),
+		Signature:  signature,
+	}
+	for i, bundle := range bundles {
+		aggregated.Bundles[i] = bundle.Clone()
+		aggregated.Bundles[i].Signature = nil
+	}
+	return aggregated, nil
+}
+
+// Validate validates the aggregated signature of the bundles with aggregator.
+func (a *AggregatedBundles) Validate(aggregator Aggregator) error {
+	if aggregator.Address() != a.Aggregator {
+		return fmt.Errorf("aggregator: bundles were aggregated by %v, not %v", a.Aggregator, aggregator.Address())
+	}
+	for i, bundle := range a.Bundles {
+		if err := bundle.Verify(); err != nil {
+			return fmt.Errorf("aggregator: bundle %d: %w", i, err)
+		}
+	}
+	return aggregator.ValidateSignatures(a.Bundles, a.Signature)
+}
+
+// SignatureBytesSaved is the number of signature bytes saved by aggregating, compared to
+// sending the bundles with their own signatures.
+func SignatureBytesSaved(bundles []*sequence.SignedTransactions, aggregated *AggregatedBundles) int {
+	saved := -len(aggregated.Signature)
+	for _, bundle := range bundles {
+		saved += len(bundle.Signature)
+	}
+	return saved
+}
+
+// walletAddress returns the address of the wallet of a bundle.
+func walletAddress(bundle *sequence.SignedTransactions) (common.Address, error) {
+	address, err := sequence.AddressFromWalletConfig(bundle.WalletConfig, bundle.WalletContext)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("aggregator: %w", err)
+	}
+	return address, nil
+}
+
+// subDigest returns the digest a wallet signs for its bundle.
+func subDigest(bundle *sequence.SignedTransactions) ([]byte, common.Address, error) {
+	address, err := walletAddress(bundle)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	digest, err := sequence.SubDigest(bundle.ChainID, address, bundle.Digest)
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("aggregator: %w", err)
+	}
+	return digest, address, nil
+}
+
+// checkDistinct rejects aggregating the same message twice, which would let an attacker
+// aggregate signatures with a rogue key.
+func checkDistinct(digests [][]byte) error {
+	for i := range digests {
+		for j := i + 1; j < len(digests); j++ {
+			if bytes.Equal(digests[i], digests[j]) {
+				return fmt.Errorf("aggregator: bundles %d and %d have the same digest", i, j)
+			}
+		}
+	}
+	return nil
+}
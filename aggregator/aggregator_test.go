@@ -0,0 +1,66 @@
+package aggregator_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/aggregator"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBundle(t *testing.T, signer common.Address, nonce int64) *sequence.SignedTransactions {
+	txns := sequence.Transactions{{To: common.HexToAddress("0x01"), Data: []byte{byte(nonce)}, RevertOnError: true}}
+	bundle := sequence.Transaction{Transactions: txns, Nonce: big.NewInt(nonce)}
+	digest, err := bundle.Digest()
+	assert.NoError(t, err)
+
+	return &sequence.SignedTransactions{
+		ChainID:       big.NewInt(1),
+		WalletConfig:  sequence.WalletConfig{Threshold: 1, Signers: sequence.WalletConfigSigners{{Weight: 1, Address: signer}}},
+		WalletContext: sequence.SequenceContext(),
+		Transactions:  txns,
+		Nonce:         big.NewInt(nonce),
+		Digest:        digest,
+	}
+}
+
+func TestBLSAggregator(t *testing.T) {
+	agg := aggregator.NewBLSAggregator(common.HexToAddress("0xa9"))
+
+	var bundles []*sequence.SignedTransactions
+	for i := 0; i < 4; i++ {
+		key, err := aggregator.GenerateBLSKey()
+		assert.NoError(t, err)
+
+		bundle := newBundle(t, common.BigToAddress(big.NewInt(int64(i+1))), int64(i))
+		assert.NoError(t, key.SignBundle(bundle))
+
+		wallet, err := sequence.AddressFromWalletConfig(bundle.WalletConfig, bundle.WalletContext)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, agg.ValidateBundleSignature(bundle), aggregator.ErrUnknownSigner)
+		assert.NoError(t, agg.RegisterKey(wallet, key.PublicKey()))
+		assert.NoError(t, agg.ValidateBundleSignature(bundle))
+
+		bundles = append(bundles, bundle)
+	}
+
+	aggregated, err := aggregator.Aggregate(agg, bundles)
+	assert.NoError(t, err)
+	assert.NoError(t, aggregated.Validate(agg))
+	for i, bundle := range aggregated.Bundles {
+		assert.Nil(t, bundle.Signature)
+		assert.NotNil(t, bundles[i].Signature)
+	}
+	assert.Equal(t, 3*192, aggregator.SignatureBytesSaved(bundles, aggregated))
+
+	// the aggregated signature is bound to every bundle
+	aggregated.Bundles = aggregated.Bundles[1:]
+	assert.ErrorIs(t, aggregated.Validate(agg), aggregator.ErrInvalidSignature)
+
+	// and each signature to its bundle
+	bundles[0].Signature, bundles[1].Signature = bundles[1].Signature, bundles[0].Signature
+	_, err = aggregator.Aggregate(agg, bundles)
+	assert.ErrorIs(t, err, aggregator.ErrInvalidSignature)
+}
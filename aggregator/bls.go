@@ -0,0 +1,235 @@
+package aggregator
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto/bls12381"
+	"github.com/0xsequence/go-sequence"
+)
+
+// BLSDomain is the domain separation tag of BLS signatures of bundles, in the format of
+// RFC 9380 for hashing to G2 of BLS12-381 with SHA-256.
+const BLSDomain = "SEQUENCE_BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_"
+
+// blsFieldModulus is the modulus of the base field of BLS12-381.
+var blsFieldModulus, _ = new(big.Int).SetString("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab", 16)
+
+// BLSSecretKey is a BLS12-381 secret key, with public keys in G1 and signatures in G2.
+type BLSSecretKey struct {
+	scalar *big.Int
+}
+
+// BLSPublicKey is the uncompressed G1 point of a public key.
+type BLSPublicKey []byte
+
+func GenerateBLSKey() (*BLSSecretKey, error) {
+	order := bls12381.NewG1().Q()
+	for {
+		scalar, err := rand.Int(rand.Reader, order)
+		if err != nil {
+			return nil, fmt.Errorf("aggregator: failed to generate key: %w", err)
+		}
+		if scalar.Sign() > 0 {
+			return &BLSSecretKey{scalar: scalar}, nil
+		}
+	}
+}
+
+func (k *BLSSecretKey) PublicKey() BLSPublicKey {
+	g1 := bls12381.NewG1()
+	return g1.ToBytes(g1.MulScalar(g1.New(), g1.One(), k.scalar))
+}
+
+// Sign returns the uncompressed G2 point signing message.
+func (k *BLSSecretKey) Sign(message []byte) ([]byte, error) {
+	g2 := bls12381.NewG2()
+	point, err := hashToG2(message, []byte(BLSDomain))
+	if err != nil {
+		return nil, err
+	}
+	return g2.ToBytes(g2.MulScalar(g2.New(), point, k.scalar)), nil
+}
+
+// SignBundle signs the bundle of a wallet, setting its signature.
+func (k *BLSSecretKey) SignBundle(bundle *sequence.SignedTransactions) error {
+	digest, _, err := subDigest(bundle)
+	if err != nil {
+		return err
+	}
+	sig, err := k.Sign(digest)
+	if err != nil {
+		return err
+	}
+	bundle.Signature = sig
+	return nil
+}
+
+// BLSAggregator aggregates the BLS signatures of the bundles of wallets with registered
+// public keys.
+type BLSAggregator struct {
+	address common.Address
+
+	keys map[common.Address]BLSPublicKey
+	mu   sync.RWMutex
+}
+
+var _ Aggregator = &BLSAggregator{}
+
+// NewBLSAggregator returns an aggregator for the contract at address validating its
+// signatures on-chain.
+func NewBLSAggregator(address common.Address) *BLSAggregator {
+	return &BLSAggregator{
+		address: address,
+		keys:    map[common.Address]BLSPublicKey{},
+	}
+}
+
+func (a *BLSAggregator) Address() common.Address {
+	return a.address
+}
+
+// RegisterKey sets the public key of wallet.
+func (a *BLSAggregator) RegisterKey(wallet common.Address, publicKey BLSPublicKey) error {
+	point, err := bls12381.NewG1().FromBytes(publicKey)
+	if err != nil {
+		return fmt.Errorf("aggregator: invalid public key: %w", err)
+	}
+	if bls12381.NewG1().IsZero(point) {
+		return fmt.Errorf("aggregator: invalid public key: zero")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.keys[wallet] = publicKey
+	return nil
+}
+
+func (a *BLSAggregator) ValidateBundleSignature(bundle *sequence.SignedTransactions) error {
+	return a.ValidateSignatures([]*sequence.SignedTransactions{bundle}, bundle.Signature)
+}
+
+func (a *BLSAggregator) AggregateSignatures(bundles []*sequence.SignedTransactions) ([]byte, error) {
+	g2 := bls12381.NewG2()
+	aggregated := g2.Zero()
+	for i, bundle := range bundles {
+		point, err := g2.FromBytes(bundle.Signature)
+		if err != nil {
+			return nil, fmt.Errorf("aggregator: bundle %d: %w: %v", i, ErrInvalidSignature, err)
+		}
+		g2.Add(aggregated, aggregated, point)
+	}
+	return g2.ToBytes(aggregated), nil
+}
+
+func (a *BLSAggregator) ValidateSignatures(bundles []*sequence.SignedTransactions, signature []byte) error {
+	g1, g2 := bls12381.NewG1(), bls12381.NewG2()
+
+	sig, err := g2.FromBytes(signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	// e(g1, sig) == e(pk_1, H(m_1)) * ... * e(pk_n, H(m_n))
+	engine := bls12381.NewPairingEngine()
+	engine.AddPairInv(g1.One(), sig)
+
+	digests := make([][]byte, len(bundles))
+	for i, bundle := range bundles {
+		digest, wallet, err := subDigest(bundle)
+		if err != nil {
+			return err
+		}
+		digests[i] = digest
+
+		a.mu.RLock()
+		publicKey, ok := a.keys[wallet]
+		a.mu.RUnlock()
+		if !ok {
+			return fmt.Errorf("%w: no key for wallet %v", ErrUnknownSigner, wallet)
+		}
+		point, err := g1.FromBytes(publicKey)
+		if err != nil {
+			return fmt.Errorf("aggregator: invalid public key of wallet %v: %w", wallet, err)
+		}
+		message, err := hashToG2(digest, []byte(BLSDomain))
+		if err != nil {
+			return err
+		}
+		engine.AddPair(point, message)
+	}
+	if err := checkDistinct(digests); err != nil {
+		return err
+	}
+
+	if !engine.Check() {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// hashToG2 hashes message to a point of G2, as hash_to_curve of RFC 9380 with the
+// BLS12381G2_XMD:SHA-256_SSWU_RO_ suite.
+func hashToG2(message, dst []byte) (*bls12381.PointG2, error) {
+	uniform, err := expandMessageXMD(message, dst, 256)
+	if err != nil {
+		return nil, err
+	}
+
+	g2 := bls12381.NewG2()
+	point := g2.Zero()
+	for i := 0; i < 2; i++ {
+		// each field element of Fp2 is encoded as c1 || c0 for MapToCurve
+		c0 := new(big.Int).Mod(new(big.Int).SetBytes(uniform[128*i:128*i+64]), blsFieldModulus)
+		c1 := new(big.Int).Mod(new(big.Int).SetBytes(uniform[128*i+64:128*i+128]), blsFieldModulus)
+		in := make([]byte, 96)
+		c1.FillBytes(in[:48])
+		c0.FillBytes(in[48:])
+
+		// MapToCurve clears the cofactor, which is linear, so clearing before or after
+		// adding the points is the same
+		mapped, err := g2.MapToCurve(in)
+		if err != nil {
+			return nil, fmt.Errorf("aggregator: hash to curve failed: %w", err)
+		}
+		g2.Add(point, point, mapped)
+	}
+	return g2.Affine(point), nil
+}
+
+// expandMessageXMD is expand_message_xmd of RFC 9380 with SHA-256.
+func expandMessageXMD(message, dst []byte, length int) ([]byte, error) {
+	ell := (length + sha256.Size - 1) / sha256.Size
+	if ell > 255 || len(dst) > 255 || length > 65535 {
+		return nil, fmt.Errorf("aggregator: invalid expand_message_xmd length")
+	}
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+
+	h := sha256.New()
+	h.Write(make([]byte, sha256.BlockSize))
+	h.Write(message)
+	h.Write([]byte{byte(length >> 8), byte(length), 0})
+	h.Write(dstPrime)
+	b0 := h.Sum(nil)
+
+	// b_1 = H(b_0 || 1 || DST'), b_i = H(b_0 ^ b_(i-1) || i || DST')
+	out := make([]byte, 0, ell*sha256.Size)
+	prev := make([]byte, sha256.Size)
+	for i := 1; i <= ell; i++ {
+		x := make([]byte, sha256.Size)
+		for j := range x {
+			x[j] = b0[j] ^ prev[j]
+		}
+		h.Reset()
+		h.Write(x)
+		h.Write([]byte{byte(i)})
+		h.Write(dstPrime)
+		prev = h.Sum(nil)
+		out = append(out, prev...)
+	}
+	return out[:length], nil
+}
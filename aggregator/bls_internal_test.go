@@ -0,0 +1,22 @@
+package aggregator
+
+import (
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto/bls12381"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHashToG2 checks the test vectors of RFC 9380, appendix K.1 and J.10.1.
+func TestHashToG2(t *testing.T) {
+	uniform, err := expandMessageXMD([]byte(""), []byte("QUUX-V01-CS02-with-expander-SHA256-128"), 32)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235", hexutil.Encode(uniform))
+
+	point, err := hashToG2([]byte(""), []byte("QUUX-V01-CS02-with-BLS12381G2_XMD:SHA-256_SSWU_RO_"))
+	assert.NoError(t, err)
+	encoded := bls12381.NewG2().ToBytes(point)
+	assert.Equal(t, "0x05cb8437535e20ecffaef7752baddf98034139c38452458baeefab379ba13dff5bf5dd71b72418717047f5b0f37da03d", hexutil.Encode(encoded[:48]))
+	assert.Equal(t, "0x0141ebfbdca40eb85b87142e130ab689c673cf60f1a3e98d69335266f30d9b8d4ac44c1038e9dcdd5393faf5c41fb78a", hexutil.Encode(encoded[48:96]))
+}
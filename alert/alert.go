@@ -0,0 +1,228 @@
+// Package alert evaluates rules over the activity of wallets, ie. to page the operators of a
+// treasury wallet when it sends more than a threshold or calls a contract it never called, and
+// sends the alerts the rules raise to sinks: webhooks, Slack channels or PagerDuty services.
+//
+//	engine := alert.NewEngine(alert.Options{})
+//	engine.AddRule(&alert.Rule{Name: "large-transfer", Severity: alert.SeverityCritical, Condition: &alert.ValueAbove{Threshold: limit}})
+//	engine.AddRule(&alert.Rule{Name: "config-change", Severity: alert.SeverityWarning, Condition: &alert.ConfigChange{}})
+//	engine.AddSink(alert.NewPagerDuty(routingKey), alert.SeverityCritical)
+//	engine.AddSink(alert.NewSlack(slackWebhookURL), alert.SeverityInfo)
+//
+//	page, _ := wallet.History(ctx, sequence.HistoryOptions{})
+//	alerts := engine.Process(ctx, webhook.Activities(chainID, wallet.Address(), page.Entries...)...)
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/webhook"
+)
+
+var (
+	ErrRuleNotFound = errors.New("alert: rule not found")
+	ErrRuleExists   = errors.New("alert: rule already exists")
+)
+
+// Severity is the urgency of an alert.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+	SeverityCritical
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Severity) UnmarshalText(text []byte) error {
+	for severity := SeverityInfo; severity <= SeverityCritical; severity++ {
+		if strings.EqualFold(string(text), severity.String()) {
+			*s = severity
+			return nil
+		}
+	}
+	return fmt.Errorf("alert: invalid severity %q", string(text))
+}
+
+// Rule raises alerts of its severity on the activities matching its condition.
+type Rule struct {
+	Name      string
+	Severity  Severity
+	Condition Condition
+}
+
+// Alert is raised by a rule on the activity of a wallet.
+type Alert struct {
+	Rule     string                 `json:"rule"`
+	Severity Severity               `json:"severity"`
+	Summary  string                 `json:"summary"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+
+	ChainID   *big.Int           `json:"chainID,omitempty"`
+	Wallet    common.Address     `json:"wallet"`
+	MetaTxnID sequence.MetaTxnID `json:"metaTxnID"`
+	TxnHash   common.Hash        `json:"txnHash"`
+
+	// Time is the time of the block of the activity, or the time it was processed if unknown.
+	Time time.Time `json:"time"`
+}
+
+// Key identifies the alert, ie. for sinks to deduplicate deliveries of the same alert.
+func (a *Alert) Key() string {
+	return fmt.Sprintf("%v/%v/%v", a.Rule, a.Wallet.Hex(), a.MetaTxnID)
+}
+
+type Options struct {
+	// OnError is called with the errors sending an alert to a sink, one at a time. Alerts
+	// aren't resent.
+	OnError func(alert *Alert, err error)
+}
+
+// Engine evaluates its rules over activities, in the order they are processed, and sends the
+// alerts raised to its sinks.
+type Engine struct {
+	options Options
+
+	rules []*Rule
+	sinks []sinkEntry
+	mu    sync.Mutex
+}
+
+type sinkEntry struct {
+	sink        Sink
+	minSeverity Severity
+}
+
+func NewEngine(options Options) *Engine {
+	return &Engine{options: options}
+}
+
+// AddRule adds rule, which is evaluated from the next activity processed.
+func (e *Engine) AddRule(rule *Rule) error {
+	if rule.Name == "" || rule.Condition == nil {
+		return fmt.Errorf("alert: rule requires a name and a condition")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range e.rules {
+		if r.Name == rule.Name {
+			return fmt.Errorf("%w: %v", ErrRuleExists, rule.Name)
+		}
+	}
+	e.rules = append(e.rules, rule)
+	return nil
+}
+
+// RemoveRule removes the rule of name.
+func (e *Engine) RemoveRule(name string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, rule := range e.rules {
+		if rule.Name == name {
+			e.rules = append(e.rules[:i], e.rules[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %v", ErrRuleNotFound, name)
+}
+
+// AddSink adds sink, which is sent the alerts of minSeverity or above.
+func (e *Engine) AddSink(sink Sink, minSeverity Severity) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sinks = append(e.sinks, sinkEntry{sink: sink, minSeverity: minSeverity})
+}
+
+// Process evaluates the rules over activities, in order, sends the alerts raised to the sinks,
+// and returns them. Errors of the sinks are reported to Options.OnError.
+func (e *Engine) Process(ctx context.Context, activities ...*webhook.Activity) []*Alert {
+	e.mu.Lock()
+	var alerts []*Alert
+	for _, activity := range activities {
+		if activity == nil || activity.Entry == nil {
+			continue
+		}
+		for _, rule := range e.rules {
+			match := rule.Condition.Match(activity)
+			if match == nil {
+				continue
+			}
+			alerts = append(alerts, newAlert(rule, activity, match))
+		}
+	}
+	sinks := append([]sinkEntry(nil), e.sinks...)
+	e.mu.Unlock()
+
+	for _, alert := range alerts {
+		for _, entry := range sinks {
+			if alert.Severity < entry.minSeverity {
+				continue
+			}
+			if err := entry.sink.Send(ctx, alert); err != nil && e.options.OnError != nil {
+				e.options.OnError(alert, err)
+			}
+		}
+	}
+	return alerts
+}
+
+// Run processes the activities received until activities is closed or ctx is done.
+func (e *Engine) Run(ctx context.Context, activities <-chan *webhook.Activity) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case activity, ok := <-activities:
+			if !ok {
+				return nil
+			}
+			e.Process(ctx, activity)
+		}
+	}
+}
+
+func newAlert(rule *Rule, activity *webhook.Activity, match *Match) *Alert {
+	entry := activity.Entry
+	alert := &Alert{
+		Rule:      rule.Name,
+		Severity:  rule.Severity,
+		Summary:   match.Summary,
+		Details:   match.Details,
+		ChainID:   activity.ChainID,
+		Wallet:    activity.Wallet,
+		MetaTxnID: entry.MetaTxnID,
+		TxnHash:   entry.TxnHash,
+		Time:      entry.Timestamp,
+	}
+	if alert.Time.IsZero() {
+		alert.Time = time.Now()
+	}
+	return alert
+}
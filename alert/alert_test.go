@@ -0,0 +1,181 @@
+package alert_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/alert"
+	"github.com/0xsequence/go-sequence/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	wallet = common.HexToAddress("0xf0ba65550f2d1dccf4b131b774844dc3d801d886")
+	usdc   = common.HexToAddress("0x70c3")
+	dex    = common.HexToAddress("0xde7")
+)
+
+func activity(id string, status sequence.MetaTxnStatus, txns sequence.Transactions, logs ...*types.Log) *webhook.Activity {
+	return &webhook.Activity{
+		ChainID: big.NewInt(137),
+		Wallet:  wallet,
+		Entry: &sequence.HistoryEntry{
+			MetaTxnID:    sequence.MetaTxnID(id),
+			Status:       status,
+			Transactions: txns,
+			Receipts:     []*sequence.Receipt{{Logs: logs}},
+		},
+	}
+}
+
+func transferLog(from, to common.Address, amount int64) *types.Log {
+	return &types.Log{
+		Address: usdc,
+		Topics:  []common.Hash{sequence.ERC20TransferEventSig, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:    common.BigToHash(big.NewInt(amount)).Bytes(),
+	}
+}
+
+func TestConditions(t *testing.T) {
+	native := &alert.ValueAbove{Threshold: big.NewInt(100)}
+	assert.Nil(t, native.Match(activity("a", sequence.MetaTxnExecuted, sequence.Transactions{{To: dex, Value: big.NewInt(100)}})))
+	assert.Nil(t, native.Match(activity("b", sequence.MetaTxnFailed, sequence.Transactions{{To: dex, Value: big.NewInt(101)}})))
+	match := native.Match(activity("c", sequence.MetaTxnExecuted, sequence.Transactions{{To: dex, Value: big.NewInt(60)}, {To: dex, Value: big.NewInt(41)}}))
+	if assert.NotNil(t, match) {
+		assert.Equal(t, "101", match.Details["value"])
+	}
+
+	// only the tokens sent by the wallet count
+	token := &alert.ValueAbove{Threshold: big.NewInt(100), Token: usdc}
+	assert.Nil(t, token.Match(activity("d", sequence.MetaTxnExecuted, nil, transferLog(dex, wallet, 500))))
+	assert.NotNil(t, token.Match(activity("e", sequence.MetaTxnExecuted, nil, transferLog(wallet, dex, 500))))
+
+	// a contract matches once, unless known
+	target := &alert.NewTarget{Known: []common.Address{usdc}}
+	assert.Nil(t, target.Match(activity("f", sequence.MetaTxnExecuted, sequence.Transactions{{To: usdc}, {To: wallet}})))
+	match = target.Match(activity("g", sequence.MetaTxnExecuted, sequence.Transactions{{To: usdc}, {To: dex}}))
+	if assert.NotNil(t, match) {
+		assert.Equal(t, []string{dex.Hex()}, match.Details["targets"])
+	}
+	assert.Nil(t, target.Match(activity("h", sequence.MetaTxnExecuted, sequence.Transactions{{To: dex}})))
+
+	// only the events of the wallet are changes of its configuration
+	imageHash := common.HexToHash("0x1234")
+	config := &alert.ConfigChange{}
+	assert.Nil(t, config.Match(activity("i", sequence.MetaTxnExecuted, nil, &types.Log{Address: dex, Topics: []common.Hash{sequence.ImageHashUpdatedEventSig}, Data: imageHash.Bytes()})))
+	match = config.Match(activity("j", sequence.MetaTxnExecuted, nil, &types.Log{Address: wallet, Topics: []common.Hash{sequence.ImageHashUpdatedEventSig}, Data: imageHash.Bytes()}))
+	if assert.NotNil(t, match) {
+		assert.Equal(t, imageHash.Hex(), match.Details["imageHash"])
+	}
+
+	// the streak restarts on an executed bundle, and once matched
+	streak := &alert.FailureStreak{Count: 2}
+	assert.Nil(t, streak.Match(activity("k", sequence.MetaTxnFailed, nil)))
+	assert.Nil(t, streak.Match(activity("l", sequence.MetaTxnExecuted, nil)))
+	assert.Nil(t, streak.Match(activity("m", sequence.MetaTxnReverted, nil)))
+	assert.Nil(t, streak.Match(activity("n", sequence.MetaTxnStatusUnknown, nil)))
+	assert.NotNil(t, streak.Match(activity("o", sequence.MetaTxnFailed, nil)))
+	assert.Nil(t, streak.Match(activity("p", sequence.MetaTxnFailed, nil)))
+}
+
+func TestEngine(t *testing.T) {
+	ctx := context.Background()
+
+	var failed []string
+	engine := alert.NewEngine(alert.Options{OnError: func(alert *alert.Alert, err error) {
+		failed = append(failed, alert.Rule)
+	}})
+	assert.NoError(t, engine.AddRule(&alert.Rule{Name: "large-transfer", Severity: alert.SeverityCritical, Condition: &alert.ValueAbove{Threshold: big.NewInt(100)}}))
+	assert.NoError(t, engine.AddRule(&alert.Rule{Name: "new-target", Severity: alert.SeverityInfo, Condition: &alert.NewTarget{}}))
+	assert.ErrorIs(t, engine.AddRule(&alert.Rule{Name: "new-target", Condition: &alert.NewTarget{}}), alert.ErrRuleExists)
+	assert.Error(t, engine.AddRule(&alert.Rule{Name: "no-condition"}))
+
+	var all, critical []*alert.Alert
+	engine.AddSink(alert.SinkFunc(func(ctx context.Context, alert *alert.Alert) error {
+		all = append(all, alert)
+		return nil
+	}), alert.SeverityInfo)
+	engine.AddSink(alert.SinkFunc(func(ctx context.Context, alert *alert.Alert) error {
+		critical = append(critical, alert)
+		return assert.AnError
+	}), alert.SeverityCritical)
+
+	alerts := engine.Process(ctx,
+		activity("a", sequence.MetaTxnExecuted, sequence.Transactions{{To: dex, Value: big.NewInt(500)}}),
+		activity("b", sequence.MetaTxnExecuted, sequence.Transactions{{To: dex, Value: big.NewInt(1)}}),
+	)
+	if assert.Len(t, alerts, 2) {
+		assert.Equal(t, "large-transfer", alerts[0].Rule)
+		assert.Equal(t, sequence.MetaTxnID("a"), alerts[0].MetaTxnID)
+		assert.Equal(t, big.NewInt(137), alerts[0].ChainID)
+		assert.False(t, alerts[0].Time.IsZero())
+		assert.Equal(t, "new-target", alerts[1].Rule)
+	}
+	assert.Len(t, all, 2)
+	assert.Len(t, critical, 1)
+	assert.Equal(t, []string{"large-transfer"}, failed)
+
+	assert.NoError(t, engine.RemoveRule("large-transfer"))
+	assert.ErrorIs(t, engine.RemoveRule("large-transfer"), alert.ErrRuleNotFound)
+
+	activities := make(chan *webhook.Activity, 1)
+	activities <- activity("c", sequence.MetaTxnExecuted, sequence.Transactions{{To: usdc}})
+	close(activities)
+	assert.NoError(t, engine.Run(ctx, activities))
+	if assert.Len(t, all, 3) {
+		assert.Equal(t, sequence.MetaTxnID("c"), all[2].MetaTxnID)
+	}
+}
+
+func TestSinks(t *testing.T) {
+	ctx := context.Background()
+
+	var bodies []map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	a := &alert.Alert{
+		Rule:      "large-transfer",
+		Severity:  alert.SeverityCritical,
+		Summary:   "sent 500",
+		Details:   map[string]interface{}{"value": "500"},
+		ChainID:   big.NewInt(137),
+		Wallet:    wallet,
+		MetaTxnID: "abc",
+	}
+
+	assert.NoError(t, alert.NewWebhook(ts.URL).Send(ctx, a))
+	assert.Equal(t, "critical", bodies[0]["severity"])
+	assert.Equal(t, "abc", bodies[0]["metaTxnID"])
+
+	assert.NoError(t, alert.NewSlack(ts.URL).Send(ctx, a))
+	assert.Contains(t, bodies[1]["text"], "*[CRITICAL] large-transfer*: sent 500")
+	assert.Contains(t, bodies[1]["text"], wallet.Hex())
+
+	assert.NoError(t, alert.NewPagerDuty("routing-key").SetURL(ts.URL).Send(ctx, a))
+	assert.Equal(t, "routing-key", bodies[2]["routing_key"])
+	assert.Equal(t, "trigger", bodies[2]["event_action"])
+	assert.Equal(t, a.Key(), bodies[2]["dedup_key"])
+	payload := bodies[2]["payload"].(map[string]interface{})
+	assert.Equal(t, "critical", payload["severity"])
+	assert.Equal(t, wallet.Hex(), payload["source"])
+	assert.Equal(t, "500", payload["custom_details"].(map[string]interface{})["value"])
+
+	assert.ErrorContains(t, alert.NewPagerDuty("routing-key").SetURL(ts.URL+"/down").Send(ctx, a), "status 503")
+}
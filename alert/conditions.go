@@ -0,0 +1,198 @@
+package alert
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/webhook"
+)
+
+// Condition matches the activities a rule raises alerts on. Conditions may keep state between
+// activities, ie. the contracts a wallet called, so a condition must not be shared by rules.
+type Condition interface {
+	// Match returns the match of activity, or nil if it doesn't match.
+	Match(activity *webhook.Activity) *Match
+}
+
+// ConditionFunc is a Condition of a func.
+type ConditionFunc func(activity *webhook.Activity) *Match
+
+func (f ConditionFunc) Match(activity *webhook.Activity) *Match {
+	return f(activity)
+}
+
+// Match is what a condition matched in an activity.
+type Match struct {
+	Summary string
+	Details map[string]interface{}
+}
+
+// ValueAbove matches the executed bundles sending more than Threshold of Token, the native
+// token if the zero address, from the wallet.
+type ValueAbove struct {
+	Threshold *big.Int
+	Token     common.Address
+}
+
+var _ Condition = &ValueAbove{}
+
+func (c *ValueAbove) Match(activity *webhook.Activity) *Match {
+	if activity.Entry.Status != sequence.MetaTxnExecuted {
+		return nil
+	}
+
+	value := new(big.Int)
+	if c.Token == (common.Address{}) {
+		value = activity.Value()
+	} else {
+		for _, log := range activity.Logs() {
+			// ERC721 transfers share the signature, but index the token id
+			if log.Address != c.Token || len(log.Topics) != 3 || log.Topics[0] != sequence.ERC20TransferEventSig || len(log.Data) != 32 {
+				continue
+			}
+			if common.BytesToAddress(log.Topics[1].Bytes()) == activity.Wallet {
+				value.Add(value, new(big.Int).SetBytes(log.Data))
+			}
+		}
+	}
+	if c.Threshold == nil || value.Cmp(c.Threshold) <= 0 {
+		return nil
+	}
+
+	return &Match{
+		Summary: fmt.Sprintf("%v sent %v of %v, above %v", activity.Wallet.Hex(), value, tokenName(c.Token), c.Threshold),
+		Details: map[string]interface{}{"token": c.Token.Hex(), "value": value.String(), "threshold": c.Threshold.String()},
+	}
+}
+
+// NewTarget matches the bundles calling contracts other than the Known ones, which the wallet
+// didn't call in the activities matched before. A contract matches once per wallet.
+type NewTarget struct {
+	Known []common.Address
+
+	seen map[walletKey]map[common.Address]bool
+}
+
+var _ Condition = &NewTarget{}
+
+func (c *NewTarget) Match(activity *webhook.Activity) *Match {
+	if c.seen == nil {
+		c.seen = map[walletKey]map[common.Address]bool{}
+	}
+	key := keyOf(activity)
+	seen := c.seen[key]
+	if seen == nil {
+		seen = map[common.Address]bool{activity.Wallet: true}
+		for _, known := range c.Known {
+			seen[known] = true
+		}
+		c.seen[key] = seen
+	}
+
+	var targets []string
+	for _, target := range activity.Targets() {
+		if !seen[target] {
+			seen[target] = true
+			targets = append(targets, target.Hex())
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	return &Match{
+		Summary: fmt.Sprintf("%v called new contracts %v", activity.Wallet.Hex(), targets),
+		Details: map[string]interface{}{"targets": targets},
+	}
+}
+
+// ConfigChange matches the bundles changing the configuration of the wallet, ie. its image hash
+// or its implementation.
+type ConfigChange struct{}
+
+var _ Condition = &ConfigChange{}
+
+func (c *ConfigChange) Match(activity *webhook.Activity) *Match {
+	details := map[string]interface{}{}
+	for _, log := range activity.Logs() {
+		if log.Address != activity.Wallet || len(log.Topics) == 0 || len(log.Data) != 32 || log.Removed {
+			continue
+		}
+		switch log.Topics[0] {
+		case sequence.ImageHashUpdatedEventSig:
+			details["imageHash"] = common.BytesToHash(log.Data).Hex()
+		case sequence.ImplementationUpdatedEventSig:
+			details["implementation"] = common.BytesToAddress(log.Data).Hex()
+		}
+	}
+	if len(details) == 0 {
+		return nil
+	}
+
+	return &Match{
+		Summary: fmt.Sprintf("configuration of %v changed", activity.Wallet.Hex()),
+		Details: details,
+	}
+}
+
+// FailureStreak matches the bundle completing a streak of Count failed or reverted bundles of
+// the wallet. The streak restarts once a bundle is executed, or after it matched.
+type FailureStreak struct {
+	Count int
+
+	streaks map[walletKey]int
+}
+
+var _ Condition = &FailureStreak{}
+
+func (c *FailureStreak) Match(activity *webhook.Activity) *Match {
+	if c.streaks == nil {
+		c.streaks = map[walletKey]int{}
+	}
+	key := keyOf(activity)
+
+	switch activity.Entry.Status {
+	case sequence.MetaTxnExecuted:
+		delete(c.streaks, key)
+		return nil
+	case sequence.MetaTxnFailed, sequence.MetaTxnReverted:
+		c.streaks[key]++
+	default:
+		return nil
+	}
+	if c.streaks[key] < c.Count {
+		return nil
+	}
+	delete(c.streaks, key)
+
+	match := &Match{
+		Summary: fmt.Sprintf("%v bundles of %v failed in a row", c.Count, activity.Wallet.Hex()),
+		Details: map[string]interface{}{"count": c.Count, "status": activity.Entry.Status.String()},
+	}
+	if activity.Entry.Reason != "" {
+		match.Details["reason"] = activity.Entry.Reason
+	}
+	return match
+}
+
+type walletKey struct {
+	chainID string
+	wallet  common.Address
+}
+
+func keyOf(activity *webhook.Activity) walletKey {
+	key := walletKey{wallet: activity.Wallet}
+	if activity.ChainID != nil {
+		key.chainID = activity.ChainID.String()
+	}
+	return key
+}
+
+func tokenName(token common.Address) string {
+	if token == (common.Address{}) {
+		return "the native token"
+	}
+	return token.Hex()
+}
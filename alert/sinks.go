@@ -0,0 +1,176 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/go-sequence/webhook"
+)
+
+// DefaultPagerDutyURL is the url of the Events API v2 of PagerDuty.
+const DefaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+
+const defaultSinkTimeout = 10 * time.Second
+
+// Sink delivers alerts, ie. to an on-call rotation.
+type Sink interface {
+	Send(ctx context.Context, alert *Alert) error
+}
+
+// SinkFunc is a Sink of a func.
+type SinkFunc func(ctx context.Context, alert *Alert) error
+
+func (f SinkFunc) Send(ctx context.Context, alert *Alert) error {
+	return f(ctx, alert)
+}
+
+// Webhook is a Sink posting alerts as JSON to a url.
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+var _ Sink = &Webhook{}
+
+func NewWebhook(url string) *Webhook {
+	return &Webhook{url: url, client: &http.Client{Timeout: defaultSinkTimeout}}
+}
+
+func (s *Webhook) SetHTTPClient(client *http.Client) *Webhook {
+	s.client = client
+	return s
+}
+
+func (s *Webhook) Send(ctx context.Context, alert *Alert) error {
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("alert: webhook: %w", err)
+	}
+	if err := webhook.Deliver(ctx, s.client, s.url, [][]byte{payload}); err != nil {
+		return fmt.Errorf("alert: %w", err)
+	}
+	return nil
+}
+
+// Slack is a Sink posting alerts as messages to a Slack incoming webhook.
+type Slack struct {
+	webhookURL string
+	client     *http.Client
+}
+
+var _ Sink = &Slack{}
+
+func NewSlack(webhookURL string) *Slack {
+	return &Slack{webhookURL: webhookURL, client: &http.Client{Timeout: defaultSinkTimeout}}
+}
+
+func (s *Slack) SetHTTPClient(client *http.Client) *Slack {
+	s.client = client
+	return s
+}
+
+func (s *Slack) Send(ctx context.Context, alert *Alert) error {
+	var text strings.Builder
+	fmt.Fprintf(&text, "*[%v] %v*: %v\n", strings.ToUpper(alert.Severity.String()), alert.Rule, alert.Summary)
+	fmt.Fprintf(&text, "wallet `%v`", alert.Wallet.Hex())
+	if alert.ChainID != nil {
+		fmt.Fprintf(&text, " on chain %v", alert.ChainID)
+	}
+	fmt.Fprintf(&text, ", meta-transaction `%v`, transaction `%v`", alert.MetaTxnID, alert.TxnHash.Hex())
+
+	payload, err := json.Marshal(map[string]string{"text": text.String()})
+	if err != nil {
+		return fmt.Errorf("alert: slack: %w", err)
+	}
+	if err := webhook.Deliver(ctx, s.client, s.webhookURL, [][]byte{payload}); err != nil {
+		return fmt.Errorf("alert: slack: %w", err)
+	}
+	return nil
+}
+
+// PagerDuty is a Sink triggering incidents of a PagerDuty service with its Events API v2.
+// Alerts are deduplicated by their Key, so an alert sent again doesn't page twice.
+type PagerDuty struct {
+	url        string
+	routingKey string
+	client     *http.Client
+}
+
+var _ Sink = &PagerDuty{}
+
+// NewPagerDuty returns a sink triggering incidents of the service of the integration key
+// routingKey.
+func NewPagerDuty(routingKey string) *PagerDuty {
+	return &PagerDuty{url: DefaultPagerDutyURL, routingKey: routingKey, client: &http.Client{Timeout: defaultSinkTimeout}}
+}
+
+// SetURL sets the url of the Events API, ie. of a proxy to it.
+func (s *PagerDuty) SetURL(url string) *PagerDuty {
+	s.url = url
+	return s
+}
+
+func (s *PagerDuty) SetHTTPClient(client *http.Client) *PagerDuty {
+	s.client = client
+	return s
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key"`
+	Payload     pagerDutyPayload `json:"payload"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Timestamp     string                 `json:"timestamp"`
+	Component     string                 `json:"component"`
+	CustomDetails map[string]interface{} `json:"custom_details"`
+}
+
+func (s *PagerDuty) Send(ctx context.Context, alert *Alert) error {
+	summary := fmt.Sprintf("[%v] %v", alert.Rule, alert.Summary)
+	if len(summary) > 1024 {
+		// the limit of PagerDuty
+		summary = summary[:1024]
+	}
+
+	details := map[string]interface{}{
+		"metaTxnID": string(alert.MetaTxnID),
+		"txnHash":   alert.TxnHash.Hex(),
+	}
+	if alert.ChainID != nil {
+		details["chainID"] = alert.ChainID.String()
+	}
+	for k, v := range alert.Details {
+		details[k] = v
+	}
+
+	payload, err := json.Marshal(pagerDutyEvent{
+		RoutingKey:  s.routingKey,
+		EventAction: "trigger",
+		DedupKey:    alert.Key(),
+		Payload: pagerDutyPayload{
+			Summary:       summary,
+			Source:        alert.Wallet.Hex(),
+			Severity:      alert.Severity.String(),
+			Timestamp:     alert.Time.UTC().Format(time.RFC3339),
+			Component:     alert.Rule,
+			CustomDetails: details,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("alert: pagerduty: %w", err)
+	}
+	if err := webhook.Deliver(ctx, s.client, s.url, [][]byte{payload}); err != nil {
+		return fmt.Errorf("alert: pagerduty: %w", err)
+	}
+	return nil
+}
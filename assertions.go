@@ -0,0 +1,244 @@
+package sequence
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/0xsequence/go-sequence/contracts/gen/walletutils"
+)
+
+// Assertions are transactions appended to a bundle which revert when a post-condition of
+// the bundle doesn't hold, so the whole bundle reverts instead of executing with an
+// unexpected outcome, ie. after a swap was front-run.
+//
+// Expiration and nonce assertions call the wallet utils contract of the wallet context.
+// Token assertions have the wallet transfer tokens to itself, which reverts when its
+// balance is insufficient or it doesn't own the token. They don't move any funds, but do
+// emit Transfer events, and the ERC-721 assertion clears the approval of the token.
+// Tokens which return false instead of reverting on failed transfers, as some early
+// ERC-20 tokens do, can't be asserted on.
+
+// RequireNonExpired returns a transaction which reverts after expiration.
+func RequireNonExpired(walletContext WalletContext, expiration time.Time) (*Transaction, error) {
+	data, err := walletutils.WalletUtilsCalldata.RequireNonExpired(big.NewInt(expiration.Unix()))
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{To: walletContext.UtilsAddress, Data: data, RevertOnError: true}, nil
+}
+
+// RequireMinNonce returns a transaction which reverts if the nonce of wallet, with its
+// encoded space, is below nonce.
+func RequireMinNonce(walletContext WalletContext, wallet common.Address, nonce *big.Int) (*Transaction, error) {
+	data, err := walletutils.WalletUtilsCalldata.RequireMinNonce(wallet, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{To: walletContext.UtilsAddress, Data: data, RevertOnError: true}, nil
+}
+
+// RequireMinERC20Balance returns a transaction of wallet which reverts if it holds less
+// than min of token.
+func RequireMinERC20Balance(token, wallet common.Address, min *big.Int) (*Transaction, error) {
+	data, err := contracts.IERC20.Encode("transfer", wallet, min)
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{To: token, Data: data, RevertOnError: true}, nil
+}
+
+// RequireMinERC1155Balance returns a transaction of wallet which reverts if it holds less
+// than min of the token id of token.
+func RequireMinERC1155Balance(token, wallet common.Address, id, min *big.Int) (*Transaction, error) {
+	data, err := contracts.IERC1155.Encode("safeTransferFrom", wallet, wallet, id, min, []byte{})
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{To: token, Data: data, RevertOnError: true}, nil
+}
+
+// RequireERC721Owner returns a transaction of wallet which reverts if it doesn't own the
+// token id of token.
+func RequireERC721Owner(token, wallet common.Address, id *big.Int) (*Transaction, error) {
+	data, err := contracts.IERC721.Encode("transferFrom", wallet, wallet, id)
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{To: token, Data: data, RevertOnError: true}, nil
+}
+
+// OutcomeKind is what an ExpectedOutcome checks.
+type OutcomeKind int
+
+const (
+	OutcomeERC20Received OutcomeKind = iota
+	OutcomeERC1155Received
+	OutcomeERC721Owned
+)
+
+// ExpectedOutcome is a post-condition of a bundle for its wallet.
+type ExpectedOutcome struct {
+	Kind  OutcomeKind
+	Token common.Address
+	ID    *big.Int // Token id of ERC-1155 and ERC-721 tokens
+}
+
+// ExpectERC20Received expects the wallet to receive token.
+func ExpectERC20Received(token common.Address) ExpectedOutcome {
+	return ExpectedOutcome{Kind: OutcomeERC20Received, Token: token}
+}
+
+// ExpectERC1155Received expects the wallet to receive the token id of token.
+func ExpectERC1155Received(token common.Address, id *big.Int) ExpectedOutcome {
+	return ExpectedOutcome{Kind: OutcomeERC1155Received, Token: token, ID: id}
+}
+
+// ExpectERC721Owned expects the wallet to own the token id of token.
+func ExpectERC721Owned(token common.Address, id *big.Int) ExpectedOutcome {
+	return ExpectedOutcome{Kind: OutcomeERC721Owned, Token: token, ID: id}
+}
+
+// Outcome is the simulated outcome of a bundle, and the assertion made on it.
+type Outcome struct {
+	ExpectedOutcome
+
+	// Before and After are the balances of the wallet before and after the bundle. For
+	// ERC-721 tokens, they are 1 if the wallet owns the token, and 0 otherwise.
+	Before *big.Int
+	After  *big.Int
+
+	// Min is the balance asserted on-chain.
+	Min *big.Int
+}
+
+// AppendOutcomeAssertions simulates txns as wallet, and returns txns followed by
+// assertions that the wallet receives at least the amounts of tokens it receives in the
+// simulation, less slippageBps basis points, and owns the tokens it owns in the
+// simulation. It fails if the simulated bundle fails, or doesn't have the expected
+// outcomes.
+func AppendOutcomeAssertions(provider *ethrpc.Provider, wallet common.Address, txns Transactions, slippageBps uint64, expected ...ExpectedOutcome) (Transactions, []*Outcome, error) {
+	if slippageBps > 10000 {
+		return nil, nil, fmt.Errorf("sequence: slippage of %d bps exceeds 100%%", slippageBps)
+	}
+
+	reads := make(Transactions, len(expected))
+	for i, outcome := range expected {
+		var (
+			data []byte
+			err  error
+		)
+		switch outcome.Kind {
+		case OutcomeERC20Received:
+			data, err = contracts.IERC20.Encode("balanceOf", wallet)
+		case OutcomeERC1155Received:
+			data, err = contracts.IERC1155.Encode("balanceOf", wallet, outcome.ID)
+		case OutcomeERC721Owned:
+			data, err = contracts.IERC721.Encode("ownerOf", outcome.ID)
+		default:
+			err = fmt.Errorf("unknown outcome kind %d", outcome.Kind)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("sequence: failed to encode outcome %d: %w", i, err)
+		}
+		reads[i] = &Transaction{To: outcome.Token, Data: data}
+	}
+
+	// the balances are read before and after the bundle in the same simulation
+	simulated := make(Transactions, 0, len(txns)+2*len(reads))
+	simulated = append(simulated, reads...)
+	simulated = append(simulated, txns...)
+	simulated = append(simulated, reads...)
+
+	results, err := Simulate(provider, wallet, simulated, "", nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sequence: failed to simulate transactions: %w", err)
+	}
+	if len(results) != len(simulated) {
+		return nil, nil, fmt.Errorf("sequence: simulation returned %d results for %d transactions", len(results), len(simulated))
+	}
+	for i, result := range results[len(reads) : len(reads)+len(txns)] {
+		if !result.Succeeded {
+			return nil, nil, fmt.Errorf("sequence: transaction %d fails in simulation", i)
+		}
+	}
+
+	assertions := make(Transactions, 0, len(expected))
+	outcomes := make([]*Outcome, 0, len(expected))
+	for i, outcome := range expected {
+		before, err := decodeOutcome(outcome, wallet, results[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		after, err := decodeOutcome(outcome, wallet, results[len(reads)+len(txns)+i])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var assertion *Transaction
+		min := new(big.Int)
+		switch outcome.Kind {
+		case OutcomeERC721Owned:
+			if after.Sign() == 0 {
+				return nil, nil, fmt.Errorf("sequence: wallet doesn't own token %v of %v in simulation", outcome.ID, outcome.Token)
+			}
+			min.SetInt64(1)
+			assertion, err = RequireERC721Owner(outcome.Token, wallet, outcome.ID)
+		default:
+			received := new(big.Int).Sub(after, before)
+			if received.Sign() <= 0 {
+				return nil, nil, fmt.Errorf("sequence: wallet doesn't receive %v in simulation", outcome.Token)
+			}
+			received.Mul(received, new(big.Int).SetUint64(10000-slippageBps))
+			received.Div(received, big.NewInt(10000))
+			min.Add(before, received)
+
+			if outcome.Kind == OutcomeERC1155Received {
+				assertion, err = RequireMinERC1155Balance(outcome.Token, wallet, outcome.ID, min)
+			} else {
+				assertion, err = RequireMinERC20Balance(outcome.Token, wallet, min)
+			}
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		assertions = append(assertions, assertion)
+		outcomes = append(outcomes, &Outcome{ExpectedOutcome: outcome, Before: before, After: after, Min: min})
+	}
+
+	return append(txns.Clone(), assertions...), outcomes, nil
+}
+
+func decodeOutcome(outcome ExpectedOutcome, wallet common.Address, result SimulateResult) (*big.Int, error) {
+	if outcome.Kind == OutcomeERC721Owned {
+		if !result.Succeeded {
+			// ownerOf reverts for tokens which are not minted
+			return big.NewInt(0), nil
+		}
+		var owner common.Address
+		if err := contracts.IERC721.Decode(&owner, "ownerOf", result.Result); err != nil {
+			return nil, fmt.Errorf("sequence: failed to decode owner of %v: %w", outcome.Token, err)
+		}
+		if owner == wallet {
+			return big.NewInt(1), nil
+		}
+		return big.NewInt(0), nil
+	}
+
+	if !result.Succeeded {
+		return nil, fmt.Errorf("sequence: failed to read balance of %v in simulation", outcome.Token)
+	}
+	token := contracts.IERC20
+	if outcome.Kind == OutcomeERC1155Received {
+		token = contracts.IERC1155
+	}
+	var balance *big.Int
+	if err := token.Decode(&balance, "balanceOf", result.Result); err != nil {
+		return nil, fmt.Errorf("sequence: failed to decode balance of %v: %w", outcome.Token, err)
+	}
+	return balance, nil
+}
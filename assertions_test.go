@@ -0,0 +1,92 @@
+package sequence_test
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/0xsequence/go-sequence/contracts/gen/walletgasestimator"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireAssertions(t *testing.T) {
+	wallet := common.HexToAddress("0xf0ba65550f2d1dccf4b131b774844dc3d801d886")
+	token := common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+	walletContext := sequence.SequenceContext()
+
+	txn, err := sequence.RequireNonExpired(walletContext, time.Unix(1700000000, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, walletContext.UtilsAddress, txn.To)
+	assert.True(t, txn.RevertOnError)
+	method, err := contracts.WalletUtils.ABI.MethodById(txn.Data)
+	assert.NoError(t, err)
+	assert.Equal(t, "requireNonExpired", method.Name)
+
+	txn, err = sequence.RequireMinERC20Balance(token, wallet, big.NewInt(5))
+	assert.NoError(t, err)
+	assert.Equal(t, token, txn.To)
+	args, err := contracts.IERC20.ABI.Methods["transfer"].Inputs.Unpack(txn.Data[4:])
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{wallet, big.NewInt(5)}, args)
+
+	txn, err = sequence.RequireERC721Owner(token, wallet, big.NewInt(7))
+	assert.NoError(t, err)
+	args, err = contracts.IERC721.ABI.Methods["transferFrom"].Inputs.Unpack(txn.Data[4:])
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{wallet, wallet, big.NewInt(7)}, args)
+}
+
+func TestAppendOutcomeAssertions(t *testing.T) {
+	wallet := common.HexToAddress("0xf0ba65550f2d1dccf4b131b774844dc3d801d886")
+	usdc := common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+	nft := common.HexToAddress("0x5555555555555555555555555555555555555555")
+
+	balance := func(n int64) []byte { return common.BigToHash(big.NewInt(n)).Bytes() }
+	swapSucceeds := true
+
+	// the simulation reads the balances before and after the swap, with the code of the wallet
+	// overridden by the gas estimator
+	chain := memchain.New(memchain.Options{ChainID: big.NewInt(1)})
+	chain.Deploy(wallet, memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		results := []walletgasestimator.MainModuleGasEstimationSimulateResult{
+			{Executed: true, Succeeded: true, Result: balance(100), GasUsed: big.NewInt(1)},
+			{Executed: true, Succeeded: false, Result: []byte{}, GasUsed: big.NewInt(1)},
+			{Executed: true, Succeeded: swapSucceeds, Result: []byte{}, GasUsed: big.NewInt(1)},
+			{Executed: true, Succeeded: true, Result: balance(1100), GasUsed: big.NewInt(1)},
+			{Executed: true, Succeeded: true, Result: common.LeftPadBytes(wallet.Bytes(), 32), GasUsed: big.NewInt(1)},
+		}
+		return contracts.WalletGasEstimator.ABI.Methods["simulateExecute"].Outputs.Pack(results)
+	}))
+	provider := chain.Provider()
+
+	swap := sequence.Transactions{{To: common.HexToAddress("0x1111111111111111111111111111111111111111"), Data: []byte{0x01}, RevertOnError: true}}
+	txns, outcomes, err := sequence.AppendOutcomeAssertions(provider, wallet, swap, 50,
+		sequence.ExpectERC20Received(usdc),
+		sequence.ExpectERC721Owned(nft, big.NewInt(7)),
+	)
+	assert.NoError(t, err)
+	assert.Len(t, txns, 3)
+	assert.True(t, txns[0].Equal(swap[0]))
+
+	// 1000 received in simulation, less 0.5% slippage
+	assert.Equal(t, big.NewInt(100), outcomes[0].Before)
+	assert.Equal(t, big.NewInt(1100), outcomes[0].After)
+	assert.Equal(t, big.NewInt(1095), outcomes[0].Min)
+	expected, err := sequence.RequireMinERC20Balance(usdc, wallet, big.NewInt(1095))
+	assert.NoError(t, err)
+	assert.True(t, txns[1].Equal(expected))
+
+	assert.Equal(t, big.NewInt(0), outcomes[1].Before)
+	assert.Equal(t, big.NewInt(1), outcomes[1].After)
+	expected, err = sequence.RequireERC721Owner(nft, wallet, big.NewInt(7))
+	assert.NoError(t, err)
+	assert.True(t, txns[2].Equal(expected))
+
+	swapSucceeds = false
+	_, _, err = sequence.AppendOutcomeAssertions(provider, wallet, swap, 50, sequence.ExpectERC20Received(usdc), sequence.ExpectERC721Owned(nft, big.NewInt(7)))
+	assert.Error(t, err)
+}
@@ -44,13 +44,13 @@ func ValidateSequenceAccountProofWith(factory, mainModule common.Address) ethaut
 		numAttempts := 4
 
 		for i := 1; i <= numAttempts; i++ {
-			valid, _ = IsValidSignature(
+			valid, _ = IsValidSignatureWith(
+				ctx,
+				provider,
+				WalletContext{FactoryAddress: factory, MainModuleAddress: mainModule},
 				common.HexToAddress(proof.Address),
 				common.BytesToHash(messageDigest),
 				sig,
-				WalletContext{FactoryAddress: factory, MainModuleAddress: mainModule},
-				chainID,
-				provider,
 			)
 			if valid {
 				break
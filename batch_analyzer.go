@@ -0,0 +1,200 @@
+package sequence
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// CallCosts are the gas costs of calls of a wallet, as measured by the estimator: the
+// overhead of a bundle, paid once per bundle whatever its calls, and the marginal cost of
+// each call in a bundle.
+type CallCosts struct {
+	// BundleOverhead is the gas of an empty bundle: the base cost of the transaction, the
+	// calldata of the signature and nonce, and the validation of the signature.
+	BundleOverhead uint64
+
+	// Calls are the marginal gas of the calls, by index.
+	Calls []uint64
+}
+
+// MeasureCallCosts measures the costs of txns as calls of the wallet at address with
+// Estimate. txns are not modified.
+func (e *Estimator) MeasureCallCosts(ctx context.Context, provider *ethrpc.Provider, address common.Address, walletConfig WalletConfig, walletContext WalletContext, txns Transactions) (*CallCosts, error) {
+	estimated := txns.Clone()
+	total, err := e.Estimate(ctx, provider, address, walletConfig, walletContext, estimated)
+	if err != nil {
+		return nil, fmt.Errorf("sequence: failed to measure call costs: %w", err)
+	}
+
+	costs := &CallCosts{BundleOverhead: total, Calls: make([]uint64, len(estimated))}
+	for i, txn := range estimated {
+		costs.Calls[i] = txn.GasLimit.Uint64()
+		costs.BundleOverhead -= costs.Calls[i]
+	}
+	return costs, nil
+}
+
+// IntendedCall is a call to batch, which arrives At a time.
+type IntendedCall struct {
+	At time.Time
+
+	// Gas is the marginal gas of the call in a bundle, see CallCosts.
+	Gas uint64
+
+	// OrderKey orders the call after the previous calls of the same key, which are batched in
+	// the same nonce space. Calls without a key are independent.
+	OrderKey string
+}
+
+type BatchingOptions struct {
+	// LatencyBudget is the longest a call waits for its batch to be submitted.
+	LatencyBudget time.Duration
+
+	// MaxBundleGas is the most gas of a bundle, ie. under the block gas limit, and MaxBatchSize
+	// its most calls. Zero doesn't limit.
+	MaxBundleGas uint64
+	MaxBatchSize int
+}
+
+// BatchingPlan is the recommended batching of a stream of calls.
+type BatchingPlan struct {
+	Batches []PlannedBatch
+
+	// Gas is the gas of the batches, and UnbatchedGas the gas of a bundle per call.
+	Gas          uint64
+	UnbatchedGas uint64
+
+	// NonceSpaces is the number of nonce spaces the batches are spread across.
+	NonceSpaces int
+}
+
+// PlannedBatch is a batch of calls submitted as a bundle.
+type PlannedBatch struct {
+	// Calls are the indexes of the calls of the batch, in order.
+	Calls []int
+
+	// NonceSpace is the index of the nonce space of the batch, and Nonce its position in the
+	// space. Batches of calls of an OrderKey share a space, in order, and the others have a
+	// space each, so they don't wait on each other.
+	NonceSpace int
+	Nonce      int
+
+	// SubmitAt is when the batch is submitted: when it is full, or at the latest when its
+	// first call runs out of latency budget.
+	SubmitAt time.Time
+
+	Gas uint64
+}
+
+// Savings returns the gas saved by the batches over a bundle per call.
+func (p *BatchingPlan) Savings() uint64 {
+	return p.UnbatchedGas - p.Gas
+}
+
+// BatchSizes returns the number of calls of each batch.
+func (p *BatchingPlan) BatchSizes() []int {
+	sizes := make([]int, len(p.Batches))
+	for i, batch := range p.Batches {
+		sizes[i] = len(batch.Calls)
+	}
+	return sizes
+}
+
+// AnalyzeBatching recommends the batches of calls which minimize their total gas, given the
+// overhead of a bundle, without any call waiting longer than the latency budget. As the gas of
+// the calls doesn't depend on their batch, the fewest batches are the cheapest: a batch takes
+// calls as they arrive until it is full or its first call runs out of budget.
+func AnalyzeBatching(calls []IntendedCall, overhead uint64, options BatchingOptions) *BatchingPlan {
+	order := make([]int, len(calls))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return calls[order[i]].At.Before(calls[order[j]].At)
+	})
+
+	plan := &BatchingPlan{}
+
+	// open batches by order key, closed as calls arrive after their submission
+	open := map[string]*PlannedBatch{}
+	spaces := map[string]int{}
+	nonces := map[int]int{}
+	var keys []string
+	known := map[string]bool{}
+
+	submit := func(key string) {
+		batch := open[key]
+		delete(open, key)
+		space, ok := spaces[key]
+		if !ok {
+			space = plan.NonceSpaces
+			plan.NonceSpaces++
+			if key != "" {
+				spaces[key] = space
+			}
+		}
+		batch.NonceSpace, batch.Nonce = space, nonces[space]
+		nonces[space]++
+		plan.Batches = append(plan.Batches, *batch)
+		plan.Gas += batch.Gas
+	}
+
+	for _, i := range order {
+		call := calls[i]
+		plan.UnbatchedGas += overhead + call.Gas
+
+		// submit the batches due before the call arrives
+		for _, key := range keys {
+			if batch := open[key]; batch != nil && call.At.After(batch.SubmitAt) {
+				submit(key)
+			}
+		}
+
+		batch := open[call.OrderKey]
+		if batch != nil && !batchFits(batch, call, options) {
+			batch.SubmitAt = call.At
+			submit(call.OrderKey)
+			batch = nil
+		}
+		if batch == nil {
+			batch = &PlannedBatch{SubmitAt: call.At.Add(options.LatencyBudget), Gas: overhead}
+			open[call.OrderKey] = batch
+			if !known[call.OrderKey] {
+				known[call.OrderKey] = true
+				keys = append(keys, call.OrderKey)
+			}
+		}
+		batch.Calls = append(batch.Calls, i)
+		batch.Gas += call.Gas
+
+		if options.MaxBatchSize > 0 && len(batch.Calls) >= options.MaxBatchSize {
+			batch.SubmitAt = call.At
+			submit(call.OrderKey)
+		}
+	}
+
+	// submit the last batches
+	for _, key := range keys {
+		if open[key] != nil {
+			submit(key)
+		}
+	}
+
+	sort.SliceStable(plan.Batches, func(i, j int) bool {
+		return plan.Batches[i].SubmitAt.Before(plan.Batches[j].SubmitAt)
+	})
+	return plan
+}
+
+// batchFits reports whether call fits in batch under the gas of a bundle.
+func batchFits(batch *PlannedBatch, call IntendedCall, options BatchingOptions) bool {
+	if options.MaxBundleGas > 0 && batch.Gas+call.Gas > options.MaxBundleGas {
+		return false
+	}
+	return true
+}
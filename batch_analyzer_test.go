@@ -0,0 +1,56 @@
+package sequence_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0xsequence/go-sequence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeBatching(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	at := func(seconds int) time.Time {
+		return start.Add(time.Duration(seconds) * time.Second)
+	}
+
+	calls := []sequence.IntendedCall{
+		{At: at(0), Gas: 30000},
+		{At: at(1), Gas: 30000},
+		{At: at(2), Gas: 30000, OrderKey: "payroll"},
+		{At: at(3), Gas: 30000},
+		{At: at(4), Gas: 30000, OrderKey: "payroll"},
+		{At: at(20), Gas: 30000, OrderKey: "payroll"},
+		{At: at(21), Gas: 30000},
+	}
+
+	// calls within 5 seconds of the first of their batch are batched together
+	plan := sequence.AnalyzeBatching(calls, 50000, sequence.BatchingOptions{LatencyBudget: 5 * time.Second})
+	assert.Equal(t, []int{3, 2, 1, 1}, plan.BatchSizes())
+	assert.Equal(t, []int{0, 1, 3}, plan.Batches[0].Calls)
+	assert.Equal(t, at(5), plan.Batches[0].SubmitAt)
+	assert.Equal(t, []int{2, 4}, plan.Batches[1].Calls)
+	assert.Equal(t, uint64(7*30000+4*50000), plan.Gas)
+	assert.Equal(t, uint64(3*50000), plan.Savings())
+
+	// ordered calls share a nonce space, in order, and the others have a space each
+	assert.Equal(t, 3, plan.NonceSpaces)
+	assert.Equal(t, plan.Batches[1].NonceSpace, plan.Batches[2].NonceSpace)
+	assert.Equal(t, []int{0, 1}, []int{plan.Batches[1].Nonce, plan.Batches[2].Nonce})
+	assert.NotEqual(t, plan.Batches[0].NonceSpace, plan.Batches[3].NonceSpace)
+
+	// a full batch is submitted as soon as it is full
+	plan = sequence.AnalyzeBatching(calls, 50000, sequence.BatchingOptions{LatencyBudget: 5 * time.Second, MaxBatchSize: 2})
+	assert.Equal(t, []int{2, 2, 1, 1, 1}, plan.BatchSizes())
+	assert.Equal(t, at(1), plan.Batches[0].SubmitAt)
+
+	plan = sequence.AnalyzeBatching(calls, 50000, sequence.BatchingOptions{LatencyBudget: 5 * time.Second, MaxBundleGas: 120000})
+	assert.Equal(t, []int{2, 2, 1, 1, 1}, plan.BatchSizes())
+	assert.Equal(t, []int{3}, plan.Batches[2].Calls)
+	assert.Equal(t, at(3), plan.Batches[0].SubmitAt)
+
+	// without budget, every call is a bundle
+	plan = sequence.AnalyzeBatching(calls, 50000, sequence.BatchingOptions{})
+	assert.Len(t, plan.Batches, len(calls))
+	assert.Zero(t, plan.Savings())
+}
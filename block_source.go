@@ -0,0 +1,229 @@
+package sequence
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence/indexer"
+)
+
+// BlockSource is where the logs of ranges of blocks are read from by the components which
+// scan the chain, ie. Wallet.History. It defaults to eth_getLogs of the provider, and can be
+// replaced on chains where eth_getLogs is unreliable or rate-limited.
+//
+// FilterLogs returns the logs matching query, ordered by block, transaction and log index,
+// as eth_getLogs does.
+type BlockSource interface {
+	FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error)
+}
+
+// NewLogsBlockSource returns a BlockSource which reads logs with eth_getLogs.
+func NewLogsBlockSource(provider *ethrpc.Provider) BlockSource {
+	return &logsBlockSource{provider: provider}
+}
+
+type logsBlockSource struct {
+	provider *ethrpc.Provider
+}
+
+func (s *logsBlockSource) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	return s.provider.FilterLogs(ctx, query)
+}
+
+// NewTraceFilterBlockSource returns a BlockSource which finds the transactions calling the
+// addresses of a query with trace_filter, as served by archival nodes (ie. erigon or
+// nethermind), and reads the logs from their receipts. The addresses of the query must be
+// set, as logs are only found through the calls to them.
+func NewTraceFilterBlockSource(provider *ethrpc.Provider) BlockSource {
+	return &traceFilterBlockSource{provider: provider}
+}
+
+type traceFilterBlockSource struct {
+	provider *ethrpc.Provider
+}
+
+type traceFilterQuery struct {
+	FromBlock hexutil.Uint64   `json:"fromBlock"`
+	ToBlock   hexutil.Uint64   `json:"toBlock"`
+	ToAddress []common.Address `json:"toAddress"`
+}
+
+type trace struct {
+	TransactionHash *common.Hash `json:"transactionHash"`
+	Error           string       `json:"error"`
+}
+
+func (s *traceFilterBlockSource) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	if len(query.Addresses) == 0 {
+		return nil, fmt.Errorf("sequence: trace_filter block source needs the addresses of the query")
+	}
+	fromBlock, toBlock, err := queryBlockRange(ctx, s.provider, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var traces []trace
+	params := traceFilterQuery{FromBlock: hexutil.Uint64(fromBlock), ToBlock: hexutil.Uint64(toBlock), ToAddress: query.Addresses}
+	if err := s.provider.Do(ctx, ethrpc.NewCallBuilder[[]trace]("trace_filter", nil, params).Into(&traces)); err != nil {
+		return nil, fmt.Errorf("sequence: trace_filter failed: %w", err)
+	}
+
+	var txnHashes []common.Hash
+	for _, trace := range traces {
+		// the logs of calls which failed are reverted
+		if trace.TransactionHash == nil || trace.Error != "" {
+			continue
+		}
+		txnHashes = append(txnHashes, *trace.TransactionHash)
+	}
+	return filterReceiptLogs(ctx, s.provider, txnHashes, query)
+}
+
+// NewIndexerBlockSource returns a BlockSource which finds the transactions of the addresses
+// of a query in the transaction history of a sequence indexer, and reads the logs from their
+// receipts fetched from provider. The addresses of the query must be set, and only the
+// transactions which the indexer records for them, ie. those transferring tokens, are found.
+func NewIndexerBlockSource(client indexer.Indexer, provider *ethrpc.Provider) BlockSource {
+	return &indexerBlockSource{client: client, provider: provider}
+}
+
+type indexerBlockSource struct {
+	client   indexer.Indexer
+	provider *ethrpc.Provider
+}
+
+func (s *indexerBlockSource) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	if len(query.Addresses) == 0 {
+		return nil, fmt.Errorf("sequence: indexer block source needs the addresses of the query")
+	}
+	fromBlock, toBlock, err := queryBlockRange(ctx, s.provider, query)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &indexer.TransactionHistoryFilter{FromBlock: &fromBlock, ToBlock: &toBlock}
+	for _, address := range query.Addresses {
+		filter.AccountAddresses = append(filter.AccountAddresses, address.Hex())
+	}
+
+	var txnHashes []common.Hash
+	page := &indexer.Page{}
+	for {
+		next, txns, err := s.client.GetTransactionHistory(ctx, filter, page, nil)
+		if err != nil {
+			return nil, fmt.Errorf("sequence: indexer transaction history failed: %w", err)
+		}
+		for _, txn := range txns {
+			txnHashes = append(txnHashes, txn.TxnHash.ToHash())
+		}
+		if next == nil || next.More == nil || !*next.More {
+			break
+		}
+		page = next
+	}
+	return filterReceiptLogs(ctx, s.provider, txnHashes, query)
+}
+
+// queryBlockRange returns the block range of query, where an unset ToBlock is the latest
+// block.
+func queryBlockRange(ctx context.Context, provider *ethrpc.Provider, query ethereum.FilterQuery) (uint64, uint64, error) {
+	var fromBlock, toBlock uint64
+	if query.FromBlock != nil {
+		fromBlock = query.FromBlock.Uint64()
+	}
+	if query.ToBlock != nil {
+		toBlock = query.ToBlock.Uint64()
+	} else {
+		latest, err := provider.BlockNumber(ctx)
+		if err != nil {
+			return 0, 0, err
+		}
+		toBlock = latest
+	}
+	return fromBlock, toBlock, nil
+}
+
+// filterReceiptLogs returns the logs of the receipts of txnHashes which match query, in the order
+// of eth_getLogs.
+func filterReceiptLogs(ctx context.Context, provider *ethrpc.Provider, txnHashes []common.Hash, query ethereum.FilterQuery) ([]types.Log, error) {
+	var logs []types.Log
+	seen := map[common.Hash]bool{}
+	for _, txnHash := range txnHashes {
+		if seen[txnHash] {
+			continue
+		}
+		seen[txnHash] = true
+
+		receipt, err := provider.TransactionReceipt(ctx, txnHash)
+		if err != nil {
+			return nil, fmt.Errorf("sequence: failed to fetch receipt %v: %w", txnHash.Hex(), err)
+		}
+		for _, log := range receipt.Logs {
+			if matchesFilterQuery(log, query) {
+				logs = append(logs, *log)
+			}
+		}
+	}
+
+	sort.Slice(logs, func(i, j int) bool {
+		if logs[i].BlockNumber != logs[j].BlockNumber {
+			return logs[i].BlockNumber < logs[j].BlockNumber
+		}
+		return logs[i].Index < logs[j].Index
+	})
+	return logs, nil
+}
+
+// matchesFilterQuery reports whether log matches the block range, addresses and topics of
+// query, as eth_getLogs matches them.
+func matchesFilterQuery(log *types.Log, query ethereum.FilterQuery) bool {
+	if query.BlockHash != nil && log.BlockHash != *query.BlockHash {
+		return false
+	}
+	if query.FromBlock != nil && new(big.Int).SetUint64(log.BlockNumber).Cmp(query.FromBlock) < 0 {
+		return false
+	}
+	if query.ToBlock != nil && new(big.Int).SetUint64(log.BlockNumber).Cmp(query.ToBlock) > 0 {
+		return false
+	}
+
+	if len(query.Addresses) > 0 {
+		found := false
+		for _, address := range query.Addresses {
+			if address == log.Address {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(query.Topics) > len(log.Topics) {
+		return false
+	}
+	for i, topics := range query.Topics {
+		if len(topics) == 0 {
+			continue
+		}
+		found := false
+		for _, topic := range topics {
+			if topic == log.Topics[i] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package sequence_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/indexer"
+	"github.com/0xsequence/go-sequence/lib/prototyp"
+	"github.com/stretchr/testify/assert"
+)
+
+// transactionHistory is an indexer serving pages of transaction hashes.
+type transactionHistory struct {
+	indexer.Indexer
+	pages [][]common.Hash
+}
+
+func (h *transactionHistory) GetTransactionHistory(ctx context.Context, filter *indexer.TransactionHistoryFilter, page *indexer.Page, includeMetadata *bool) (*indexer.Page, []*indexer.Transaction, error) {
+	n := 0
+	if page.Page != nil {
+		n = int(*page.Page)
+	}
+	var txns []*indexer.Transaction
+	for _, txnHash := range h.pages[n] {
+		txns = append(txns, &indexer.Transaction{TxnHash: prototyp.HashFromString(txnHash.Hex())})
+	}
+	next, more := uint32(n+1), n+1 < len(h.pages)
+	return &indexer.Page{Page: &next, More: &more}, txns, nil
+}
+
+func TestBlockSources(t *testing.T) {
+	node := newReceiptsNode()
+	provider := node.serve()
+	wallet := node.receipts[0].Logs[0].Address
+
+	query := ethereum.FilterQuery{
+		FromBlock: big.NewInt(0),
+		ToBlock:   big.NewInt(100),
+		Addresses: []common.Address{wallet},
+		Topics:    [][]common.Hash{{sequence.NonceChangeEventSig}},
+	}
+
+	logs, err := sequence.NewTraceFilterBlockSource(provider).FilterLogs(context.Background(), query)
+	assert.NoError(t, err)
+	if assert.Len(t, logs, 3) {
+		for i, log := range logs {
+			assert.Equal(t, node.receipts[i].TxHash, log.TxHash)
+		}
+	}
+
+	history := &transactionHistory{pages: [][]common.Hash{{node.receipts[2].TxHash}, {node.receipts[0].TxHash, node.receipts[2].TxHash}}}
+	logs, err = sequence.NewIndexerBlockSource(history, provider).FilterLogs(context.Background(), query)
+	assert.NoError(t, err)
+	if assert.Len(t, logs, 2) {
+		assert.Equal(t, node.receipts[0].TxHash, logs[0].TxHash)
+		assert.Equal(t, node.receipts[2].TxHash, logs[1].TxHash)
+	}
+
+	// logs are filtered by the query
+	query.Topics = [][]common.Hash{{sequence.TxFailedEventSig}}
+	logs, err = sequence.NewTraceFilterBlockSource(provider).FilterLogs(context.Background(), query)
+	assert.NoError(t, err)
+	assert.Empty(t, logs)
+
+	query.Addresses = nil
+	_, err = sequence.NewTraceFilterBlockSource(provider).FilterLogs(context.Background(), query)
+	assert.Error(t, err)
+}
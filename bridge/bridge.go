@@ -0,0 +1,134 @@
+// Package bridge builds the transactions which send messages to other chains, through the
+// native bridges of rollups or through cross-chain messaging protocols, so a bundle of a
+// wallet on one chain can trigger calls on another.
+//
+// Sequence wallets are deployed at the same address on every chain, and anyone can submit
+// a bundle signed by their signers. A wallet acts on another chain by signing a bundle for
+// its counterpart there, and sending it with CounterpartMessage through any Bridge:
+//
+//	msg, _ := bridge.CounterpartMessage(signedForChainB, 500_000)
+//	txns, _ := optimism.Send(ctx, wallet.Address(), msg)
+//	wallet.SendTransaction(ctx, signed(txns))
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/contracts/gen/walletmain"
+)
+
+// ErrUnsupportedChain is returned when a bridge can't send messages to a chain.
+var ErrUnsupportedChain = errors.New("bridge: unsupported destination chain")
+
+// Message is a call to make on another chain.
+type Message struct {
+	ChainID  *big.Int       // Destination chain
+	To       common.Address // Target of the call on the destination chain
+	Value    *big.Int       // Native value of the call, only supported by native bridges
+	Data     []byte         // Calldata of the call
+	GasLimit uint64         // Gas forwarded to the call on the destination chain
+}
+
+// Bridge sends messages from the chain it is deployed on to other chains.
+type Bridge interface {
+	// Name identifies the bridge, ie. in logs.
+	Name() string
+
+	// Send returns the transactions which send msg, to include in a bundle of the wallet
+	// from. Fees are paid by the bundle, with the native token.
+	Send(ctx context.Context, from common.Address, msg *Message) (sequence.Transactions, error)
+}
+
+// CounterpartMessage returns the message executing signed, a bundle signed for another
+// chain, on the counterpart of its wallet there. The counterpart must already be deployed.
+func CounterpartMessage(signed *sequence.SignedTransactions, gasLimit uint64) (*Message, error) {
+	if signed.ChainID == nil || signed.ChainID.Sign() == 0 {
+		return nil, fmt.Errorf("bridge: signed transactions have no chain id")
+	}
+	if len(signed.Transactions) == 0 {
+		return nil, fmt.Errorf("bridge: signed transactions are empty")
+	}
+
+	wallet, err := sequence.AddressFromWalletConfig(signed.WalletConfig, signed.WalletContext)
+	if err != nil {
+		return nil, fmt.Errorf("bridge: failed to compute wallet address: %w", err)
+	}
+	calls, err := signed.Transactions.ModuleCalls()
+	if err != nil {
+		return nil, fmt.Errorf("bridge: failed to encode transactions: %w", err)
+	}
+	data, err := walletmain.WalletMainCalldata.Execute(calls, signed.Nonce, signed.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("bridge: failed to encode execute: %w", err)
+	}
+
+	return &Message{
+		ChainID:  new(big.Int).Set(signed.ChainID),
+		To:       wallet,
+		Data:     data,
+		GasLimit: gasLimit,
+	}, nil
+}
+
+var payloadArguments = abi.Arguments{
+	{Type: mustType("address")},
+	{Type: mustType("bytes")},
+}
+
+// EncodePayload encodes the target and calldata of msg as abi.encode(address, bytes), the
+// payload which messaging protocols deliver to the receiver contract on the destination
+// chain. The receiver is expected to authenticate the source of the message, and to call
+// the target with the calldata.
+func EncodePayload(msg *Message) ([]byte, error) {
+	payload, err := payloadArguments.Pack(msg.To, msg.Data)
+	if err != nil {
+		return nil, fmt.Errorf("bridge: failed to encode payload: %w", err)
+	}
+	return payload, nil
+}
+
+// DecodePayload decodes a payload encoded with EncodePayload.
+func DecodePayload(payload []byte) (common.Address, []byte, error) {
+	values, err := payloadArguments.Unpack(payload)
+	if err != nil {
+		return common.Address{}, nil, fmt.Errorf("bridge: failed to decode payload: %w", err)
+	}
+	return values[0].(common.Address), values[1].([]byte), nil
+}
+
+func checkChain(name string, chainID *big.Int, msg *Message) error {
+	if msg.ChainID == nil || chainID.Cmp(msg.ChainID) != 0 {
+		return fmt.Errorf("%w: %s sends to chain %v, not %v", ErrUnsupportedChain, name, chainID, msg.ChainID)
+	}
+	return nil
+}
+
+func bigOrZero(n *big.Int) *big.Int {
+	if n == nil {
+		return new(big.Int)
+	}
+	return n
+}
+
+func mustType(t string) abi.Type {
+	typ, err := abi.NewType(t, "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return typ
+}
+
+func mustParseABI(s string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(s))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
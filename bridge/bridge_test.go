@@ -0,0 +1,206 @@
+package bridge_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/bridge"
+	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	wallet = common.HexToAddress("0xf0ba65550f2d1dccf4b131b774844dc3d801d886")
+	target = common.HexToAddress("0x1111111111111111111111111111111111111111")
+)
+
+func unpackCall(t *testing.T, abiJSON string, data []byte) (string, []interface{}) {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	assert.NoError(t, err)
+	method, err := parsed.MethodById(data[:4])
+	assert.NoError(t, err)
+	args, err := method.Inputs.Unpack(data[4:])
+	assert.NoError(t, err)
+	return method.Name, args
+}
+
+func TestCounterpartMessage(t *testing.T) {
+	signed := &sequence.SignedTransactions{
+		ChainID: big.NewInt(10),
+		WalletConfig: sequence.WalletConfig{
+			Threshold: 1,
+			Signers:   sequence.WalletConfigSigners{{Weight: 1, Address: common.HexToAddress("0x2222222222222222222222222222222222222222")}},
+		},
+		WalletContext: sequence.SequenceContext(),
+		Transactions:  sequence.Transactions{{To: target, Data: []byte{0x01}, RevertOnError: true}},
+		Nonce:         big.NewInt(7),
+		Signature:     []byte{0xaa, 0xbb},
+	}
+
+	msg, err := bridge.CounterpartMessage(signed, 300_000)
+	assert.NoError(t, err)
+
+	address, err := sequence.AddressFromWalletConfig(signed.WalletConfig, signed.WalletContext)
+	assert.NoError(t, err)
+	assert.Equal(t, address, msg.To)
+	assert.Equal(t, big.NewInt(10), msg.ChainID)
+	assert.Equal(t, uint64(300_000), msg.GasLimit)
+
+	encodedTxns, err := signed.Transactions.EncodedTransactions()
+	assert.NoError(t, err)
+	execdata, err := contracts.WalletMainModule.Encode("execute", encodedTxns, signed.Nonce, signed.Signature)
+	assert.NoError(t, err)
+	assert.Equal(t, execdata, msg.Data)
+
+	signed.ChainID = nil
+	_, err = bridge.CounterpartMessage(signed, 300_000)
+	assert.Error(t, err)
+}
+
+func TestPayload(t *testing.T) {
+	payload, err := bridge.EncodePayload(&bridge.Message{To: target, Data: []byte{0xde, 0xad}})
+	assert.NoError(t, err)
+
+	to, data, err := bridge.DecodePayload(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, target, to)
+	assert.Equal(t, []byte{0xde, 0xad}, data)
+}
+
+func TestOptimismBridge(t *testing.T) {
+	messenger := common.HexToAddress("0x25ace71c97b33cc4729cf772ae268934f7ab5fa1")
+	b := bridge.NewOptimismBridge(messenger, big.NewInt(10))
+	msg := &bridge.Message{ChainID: big.NewInt(10), To: target, Value: big.NewInt(5), Data: []byte{0x01}, GasLimit: 200_000}
+
+	txns, err := b.Send(context.Background(), wallet, msg)
+	assert.NoError(t, err)
+	assert.Len(t, txns, 1)
+	assert.Equal(t, messenger, txns[0].To)
+	assert.Equal(t, big.NewInt(5), txns[0].Value)
+
+	name, args := unpackCall(t, `[{"type":"function","name":"sendMessage","inputs":[{"type":"address"},{"type":"bytes"},{"type":"uint32"}]}]`, txns[0].Data)
+	assert.Equal(t, "sendMessage", name)
+	assert.Equal(t, []interface{}{target, []byte{0x01}, uint32(200_000)}, args)
+
+	msg.ChainID = big.NewInt(8453)
+	_, err = b.Send(context.Background(), wallet, msg)
+	assert.True(t, errors.Is(err, bridge.ErrUnsupportedChain))
+}
+
+func TestArbitrumBridge(t *testing.T) {
+	inbox := common.HexToAddress("0x4dbd4fc535ac27206064b68ffcf827b0a60bab3f")
+	b := bridge.NewArbitrumBridge(inbox, big.NewInt(42161))
+	msg := &bridge.Message{ChainID: big.NewInt(42161), To: target, Value: big.NewInt(5), Data: []byte{0x01}, GasLimit: 100_000}
+
+	_, err := b.Send(context.Background(), wallet, msg)
+	assert.Error(t, err, "fees are not set")
+
+	b.SetFees(big.NewInt(1000), big.NewInt(10))
+	txns, err := b.Send(context.Background(), wallet, msg)
+	assert.NoError(t, err)
+	assert.Len(t, txns, 1)
+	assert.Equal(t, inbox, txns[0].To)
+	assert.Equal(t, big.NewInt(100_000*10+1000+5), txns[0].Value)
+
+	name, args := unpackCall(t, `[{"type":"function","name":"createRetryableTicket","inputs":[
+		{"type":"address"},{"type":"uint256"},{"type":"uint256"},{"type":"address"},{"type":"address"},
+		{"type":"uint256"},{"type":"uint256"},{"type":"bytes"}]}]`, txns[0].Data)
+	assert.Equal(t, "createRetryableTicket", name)
+	assert.Equal(t, []interface{}{
+		target, big.NewInt(5), big.NewInt(1000), wallet, wallet, big.NewInt(100_000), big.NewInt(10), []byte{0x01},
+	}, args)
+
+	assert.Equal(t, common.HexToAddress("0x01cb65550f2d1dccf4b131b774844dc3d801e997"), bridge.ApplyL1ToL2Alias(wallet))
+}
+
+func TestLayerZeroBridge(t *testing.T) {
+	endpoint := common.HexToAddress("0x1a44076050125825900e736c501f859c50fe728c")
+	receiver := common.HexToAddress("0x3333333333333333333333333333333333333333")
+
+	chain := memchain.New(memchain.Options{})
+	chain.Deploy(endpoint, memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		// quote returns (nativeFee, lzTokenFee)
+		return append(common.BigToHash(big.NewInt(12345)).Bytes(), make([]byte, 32)...), nil
+	}))
+	provider := chain.Provider()
+
+	b := bridge.NewLayerZeroBridge(provider, endpoint).AddChain(big.NewInt(137), 30109, receiver)
+	msg := &bridge.Message{ChainID: big.NewInt(137), To: target, Data: []byte{0x01}, GasLimit: 200_000}
+
+	txns, err := b.Send(context.Background(), wallet, msg)
+	assert.NoError(t, err)
+	assert.Len(t, txns, 1)
+	assert.Equal(t, endpoint, txns[0].To)
+	assert.Equal(t, big.NewInt(12345), txns[0].Value)
+
+	name, args := unpackCall(t, `[{"type":"function","name":"send","inputs":[
+		{"type":"tuple","components":[{"name":"dstEid","type":"uint32"},{"name":"receiver","type":"bytes32"},
+			{"name":"message","type":"bytes"},{"name":"options","type":"bytes"},{"name":"payInLzToken","type":"bool"}]},
+		{"type":"address"}]}]`, txns[0].Data)
+	assert.Equal(t, "send", name)
+	assert.Equal(t, wallet, args[1])
+
+	params := args[0].(struct {
+		DstEid       uint32   `json:"dstEid"`
+		Receiver     [32]byte `json:"receiver"`
+		Message      []byte   `json:"message"`
+		Options      []byte   `json:"options"`
+		PayInLzToken bool     `json:"payInLzToken"`
+	})
+	assert.Equal(t, uint32(30109), params.DstEid)
+	assert.Equal(t, common.BytesToHash(receiver[:]), common.Hash(params.Receiver))
+	assert.Equal(t, common.FromHex("0x00030100110100000000000000000000000000030d40"), params.Options)
+	to, data, err := bridge.DecodePayload(params.Message)
+	assert.NoError(t, err)
+	assert.Equal(t, target, to)
+	assert.Equal(t, []byte{0x01}, data)
+
+	msg.ChainID = big.NewInt(1)
+	_, err = b.Send(context.Background(), wallet, msg)
+	assert.True(t, errors.Is(err, bridge.ErrUnsupportedChain))
+}
+
+func TestAxelarBridge(t *testing.T) {
+	gateway := common.HexToAddress("0x4f4495243837681061c4743b74b3eedf548d56a5")
+	gasService := common.HexToAddress("0x2d5d7d31f671f86c782533cc367f14109a082712")
+	receiver := common.HexToAddress("0x3333333333333333333333333333333333333333")
+
+	b := bridge.NewAxelarBridge(gateway, gasService).AddChain(big.NewInt(137), "Polygon", receiver)
+	msg := &bridge.Message{ChainID: big.NewInt(137), To: target, Data: []byte{0x01}, GasLimit: 200_000}
+
+	// without a gas payment, only the message is sent
+	txns, err := b.Send(context.Background(), wallet, msg)
+	assert.NoError(t, err)
+	assert.Len(t, txns, 1)
+
+	b.SetGasPayment(big.NewInt(1e15))
+	txns, err = b.Send(context.Background(), wallet, msg)
+	assert.NoError(t, err)
+	assert.Len(t, txns, 2)
+	assert.Equal(t, gasService, txns[0].To)
+	assert.Equal(t, big.NewInt(1e15), txns[0].Value)
+	assert.Equal(t, gateway, txns[1].To)
+
+	payload, err := bridge.EncodePayload(msg)
+	assert.NoError(t, err)
+
+	name, args := unpackCall(t, `[{"type":"function","name":"payNativeGasForContractCall","inputs":[
+		{"type":"address"},{"type":"string"},{"type":"string"},{"type":"bytes"},{"type":"address"}]}]`, txns[0].Data)
+	assert.Equal(t, "payNativeGasForContractCall", name)
+	assert.Equal(t, []interface{}{wallet, "Polygon", receiver.Hex(), payload, wallet}, args)
+
+	name, args = unpackCall(t, `[{"type":"function","name":"callContract","inputs":[{"type":"string"},{"type":"string"},{"type":"bytes"}]}]`, txns[1].Data)
+	assert.Equal(t, "callContract", name)
+	assert.Equal(t, []interface{}{"Polygon", receiver.Hex(), payload}, args)
+
+	msg.Value = big.NewInt(1)
+	_, err = b.Send(context.Background(), wallet, msg)
+	assert.Error(t, err)
+}
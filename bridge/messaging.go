@@ -0,0 +1,281 @@
+package bridge
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+)
+
+var (
+	layerZeroEndpointABI = mustParseABI(`[
+		{"type":"function","name":"quote","stateMutability":"view","inputs":[
+			{"name":"_params","type":"tuple","components":[
+				{"name":"dstEid","type":"uint32"},{"name":"receiver","type":"bytes32"},{"name":"message","type":"bytes"},
+				{"name":"options","type":"bytes"},{"name":"payInLzToken","type":"bool"}]},
+			{"name":"_sender","type":"address"}],
+		 "outputs":[{"name":"","type":"tuple","components":[{"name":"nativeFee","type":"uint256"},{"name":"lzTokenFee","type":"uint256"}]}]},
+		{"type":"function","name":"send","stateMutability":"payable","inputs":[
+			{"name":"_params","type":"tuple","components":[
+				{"name":"dstEid","type":"uint32"},{"name":"receiver","type":"bytes32"},{"name":"message","type":"bytes"},
+				{"name":"options","type":"bytes"},{"name":"payInLzToken","type":"bool"}]},
+			{"name":"_refundAddress","type":"address"}],
+		 "outputs":[{"name":"","type":"tuple","components":[
+			{"name":"guid","type":"bytes32"},{"name":"nonce","type":"uint64"},
+			{"name":"fee","type":"tuple","components":[{"name":"nativeFee","type":"uint256"},{"name":"lzTokenFee","type":"uint256"}]}]}]}
+	]`)
+
+	axelarGatewayABI = mustParseABI(`[
+		{"type":"function","name":"callContract","stateMutability":"nonpayable","inputs":[
+			{"name":"destinationChain","type":"string"},{"name":"contractAddress","type":"string"},{"name":"payload","type":"bytes"}],"outputs":[]}
+	]`)
+
+	axelarGasServiceABI = mustParseABI(`[
+		{"type":"function","name":"payNativeGasForContractCall","stateMutability":"payable","inputs":[
+			{"name":"sender","type":"address"},{"name":"destinationChain","type":"string"},{"name":"destinationAddress","type":"string"},
+			{"name":"payload","type":"bytes"},{"name":"refundAddress","type":"address"}],"outputs":[]}
+	]`)
+)
+
+// layerZeroParams are the MessagingParams of a LayerZero v2 endpoint.
+type layerZeroParams struct {
+	DstEid       uint32
+	Receiver     [32]byte
+	Message      []byte
+	Options      []byte
+	PayInLzToken bool
+}
+
+// layerZeroFee is the MessagingFee of a LayerZero v2 endpoint.
+type layerZeroFee struct {
+	NativeFee  *big.Int
+	LzTokenFee *big.Int
+}
+
+// layerZeroReceiver is the OApp receiving the messages of the bridge on a chain.
+type layerZeroReceiver struct {
+	eid      uint32
+	receiver common.Address
+}
+
+// LayerZeroBridge sends messages through a LayerZero v2 endpoint, to a receiver OApp on
+// each destination chain which is passed the payload of the message, see EncodePayload.
+// Fees are quoted from the endpoint when sending, and paid in the native token.
+type LayerZeroBridge struct {
+	provider *ethrpc.Provider
+	endpoint common.Address
+
+	chains map[string]layerZeroReceiver
+	mu     sync.RWMutex
+}
+
+var _ Bridge = &LayerZeroBridge{}
+
+func NewLayerZeroBridge(provider *ethrpc.Provider, endpoint common.Address) *LayerZeroBridge {
+	return &LayerZeroBridge{
+		provider: provider,
+		endpoint: endpoint,
+		chains:   map[string]layerZeroReceiver{},
+	}
+}
+
+// AddChain lets the bridge send messages to the chain with chainID, which has the endpoint
+// id eid, through the receiver OApp deployed there.
+func (b *LayerZeroBridge) AddChain(chainID *big.Int, eid uint32, receiver common.Address) *LayerZeroBridge {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.chains[chainID.String()] = layerZeroReceiver{eid: eid, receiver: receiver}
+	return b
+}
+
+func (b *LayerZeroBridge) Name() string {
+	return "layerzero"
+}
+
+func (b *LayerZeroBridge) Send(ctx context.Context, from common.Address, msg *Message) (sequence.Transactions, error) {
+	params, err := b.params(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	fee, err := b.Quote(ctx, from, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := layerZeroEndpointABI.Pack("send", params, from)
+	if err != nil {
+		return nil, fmt.Errorf("bridge: failed to encode send: %w", err)
+	}
+	return sequence.Transactions{{
+		To:            b.endpoint,
+		Value:         fee,
+		Data:          data,
+		RevertOnError: true,
+	}}, nil
+}
+
+// Quote returns the native fee of sending msg from the wallet from.
+func (b *LayerZeroBridge) Quote(ctx context.Context, from common.Address, msg *Message) (*big.Int, error) {
+	params, err := b.params(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := layerZeroEndpointABI.Pack("quote", params, from)
+	if err != nil {
+		return nil, fmt.Errorf("bridge: failed to encode quote: %w", err)
+	}
+	res, err := b.provider.CallContract(ctx, ethereum.CallMsg{To: &b.endpoint, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("bridge: layerzero quote failed: %w", err)
+	}
+	values, err := layerZeroEndpointABI.Unpack("quote", res)
+	if err != nil {
+		return nil, fmt.Errorf("bridge: failed to decode layerzero quote: %w", err)
+	}
+	fee := abi.ConvertType(values[0], new(layerZeroFee)).(*layerZeroFee)
+	return fee.NativeFee, nil
+}
+
+func (b *LayerZeroBridge) params(msg *Message) (layerZeroParams, error) {
+	if msg.Value != nil && msg.Value.Sign() != 0 {
+		return layerZeroParams{}, fmt.Errorf("bridge: %s can't send value", b.Name())
+	}
+	b.mu.RLock()
+	chain, ok := b.chains[bigOrZero(msg.ChainID).String()]
+	b.mu.RUnlock()
+	if !ok {
+		return layerZeroParams{}, fmt.Errorf("%w: %s has no receiver on chain %v", ErrUnsupportedChain, b.Name(), msg.ChainID)
+	}
+
+	payload, err := EncodePayload(msg)
+	if err != nil {
+		return layerZeroParams{}, err
+	}
+
+	var receiver [32]byte
+	copy(receiver[12:], chain.receiver[:])
+	return layerZeroParams{
+		DstEid:   chain.eid,
+		Receiver: receiver,
+		Message:  payload,
+		Options:  LayerZeroGasOptions(msg.GasLimit),
+	}, nil
+}
+
+// LayerZeroGasOptions returns the type 3 executor options of a LayerZero v2 message, which
+// sets the gas of the lzReceive call of the receiver.
+func LayerZeroGasOptions(gasLimit uint64) []byte {
+	options := make([]byte, 0, 22)
+	options = append(options, 0x00, 0x03) // options type 3
+	options = append(options, 0x01)       // executor worker
+	options = append(options, 0x00, 17)   // option size, its type and a uint128
+	options = append(options, 0x01)       // lzReceive option
+	gas := make([]byte, 16)
+	binary.BigEndian.PutUint64(gas[8:], gasLimit)
+	return append(options, gas...)
+}
+
+// axelarReceiver is the contract receiving the messages of the bridge on a chain.
+type axelarReceiver struct {
+	name     string
+	receiver common.Address
+}
+
+// AxelarBridge sends messages through the Axelar gateway, to a receiver AxelarExecutable
+// contract on each destination chain which is passed the payload of the message, see
+// EncodePayload.
+//
+// Axelar doesn't quote fees on-chain, so the gas of the destination chain is prepaid with
+// the amount set with SetGasPayment, ie. from the estimate of the Axelar API. Without a
+// payment, messages are delivered once their gas is paid separately.
+type AxelarBridge struct {
+	gateway    common.Address
+	gasService common.Address
+	gasPayment *big.Int
+
+	chains map[string]axelarReceiver
+	mu     sync.RWMutex
+}
+
+var _ Bridge = &AxelarBridge{}
+
+func NewAxelarBridge(gateway, gasService common.Address) *AxelarBridge {
+	return &AxelarBridge{
+		gateway:    gateway,
+		gasService: gasService,
+		chains:     map[string]axelarReceiver{},
+	}
+}
+
+// AddChain lets the bridge send messages to the chain with chainID, which Axelar names
+// name, ie. "Polygon", through the receiver deployed there.
+func (b *AxelarBridge) AddChain(chainID *big.Int, name string, receiver common.Address) *AxelarBridge {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.chains[chainID.String()] = axelarReceiver{name: name, receiver: receiver}
+	return b
+}
+
+// SetGasPayment sets the native amount paid to the gas service for each message.
+func (b *AxelarBridge) SetGasPayment(amount *big.Int) *AxelarBridge {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.gasPayment = amount
+	return b
+}
+
+func (b *AxelarBridge) Name() string {
+	return "axelar"
+}
+
+func (b *AxelarBridge) Send(ctx context.Context, from common.Address, msg *Message) (sequence.Transactions, error) {
+	if msg.Value != nil && msg.Value.Sign() != 0 {
+		return nil, fmt.Errorf("bridge: %s can't send value", b.Name())
+	}
+	b.mu.RLock()
+	chain, ok := b.chains[bigOrZero(msg.ChainID).String()]
+	gasPayment := b.gasPayment
+	b.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s has no receiver on chain %v", ErrUnsupportedChain, b.Name(), msg.ChainID)
+	}
+
+	payload, err := EncodePayload(msg)
+	if err != nil {
+		return nil, err
+	}
+	receiver := chain.receiver.Hex()
+
+	var txns sequence.Transactions
+	if gasPayment != nil && gasPayment.Sign() > 0 {
+		data, err := axelarGasServiceABI.Pack("payNativeGasForContractCall", from, chain.name, receiver, payload, from)
+		if err != nil {
+			return nil, fmt.Errorf("bridge: failed to encode payNativeGasForContractCall: %w", err)
+		}
+		txns = append(txns, &sequence.Transaction{
+			To:            b.gasService,
+			Value:         new(big.Int).Set(gasPayment),
+			Data:          data,
+			RevertOnError: true,
+		})
+	}
+
+	data, err := axelarGatewayABI.Pack("callContract", chain.name, receiver, payload)
+	if err != nil {
+		return nil, fmt.Errorf("bridge: failed to encode callContract: %w", err)
+	}
+	return append(txns, &sequence.Transaction{
+		To:            b.gateway,
+		Data:          data,
+		RevertOnError: true,
+	}), nil
+}
@@ -0,0 +1,135 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+)
+
+var (
+	opMessengerABI = mustParseABI(`[
+		{"type":"function","name":"sendMessage","stateMutability":"payable","inputs":[
+			{"name":"_target","type":"address"},{"name":"_message","type":"bytes"},{"name":"_minGasLimit","type":"uint32"}],"outputs":[]}
+	]`)
+
+	arbitrumInboxABI = mustParseABI(`[
+		{"type":"function","name":"createRetryableTicket","stateMutability":"payable","inputs":[
+			{"name":"to","type":"address"},{"name":"l2CallValue","type":"uint256"},{"name":"maxSubmissionCost","type":"uint256"},
+			{"name":"excessFeeRefundAddress","type":"address"},{"name":"callValueRefundAddress","type":"address"},
+			{"name":"gasLimit","type":"uint256"},{"name":"maxFeePerGas","type":"uint256"},{"name":"data","type":"bytes"}],"outputs":[{"name":"","type":"uint256"}]}
+	]`)
+)
+
+// OptimismBridge sends messages from L1 to an OP Stack chain through its
+// L1CrossDomainMessenger. The target sees the L2CrossDomainMessenger as the caller, and the
+// sender on L1 as its xDomainMessageSender.
+type OptimismBridge struct {
+	Messenger common.Address // L1CrossDomainMessenger of the destination chain
+	ChainID   *big.Int       // Destination chain
+}
+
+var _ Bridge = &OptimismBridge{}
+
+func NewOptimismBridge(messenger common.Address, chainID *big.Int) *OptimismBridge {
+	return &OptimismBridge{Messenger: messenger, ChainID: chainID}
+}
+
+func (b *OptimismBridge) Name() string {
+	return fmt.Sprintf("optimism:%v", b.ChainID)
+}
+
+func (b *OptimismBridge) Send(ctx context.Context, from common.Address, msg *Message) (sequence.Transactions, error) {
+	if err := checkChain(b.Name(), b.ChainID, msg); err != nil {
+		return nil, err
+	}
+	if msg.GasLimit > math.MaxUint32 {
+		return nil, fmt.Errorf("bridge: gas limit %d exceeds uint32", msg.GasLimit)
+	}
+
+	data, err := opMessengerABI.Pack("sendMessage", msg.To, msg.Data, uint32(msg.GasLimit))
+	if err != nil {
+		return nil, fmt.Errorf("bridge: failed to encode sendMessage: %w", err)
+	}
+	return sequence.Transactions{{
+		To:            b.Messenger,
+		Value:         msg.Value,
+		Data:          data,
+		RevertOnError: true,
+	}}, nil
+}
+
+// arbitrumAliasOffset is added to the address of L1 contracts which call L2 through the
+// Arbitrum inbox.
+var arbitrumAliasOffset = new(big.Int).SetBytes(common.FromHex("0x1111000000000000000000000000000000001111"))
+
+// ApplyL1ToL2Alias returns the address which l1Address, a contract, has on Arbitrum when it
+// sends messages through the inbox, which is also where the inbox refunds its fees.
+func ApplyL1ToL2Alias(l1Address common.Address) common.Address {
+	alias := new(big.Int).Add(new(big.Int).SetBytes(l1Address[:]), arbitrumAliasOffset)
+	return common.BigToAddress(alias)
+}
+
+// ArbitrumBridge sends messages from L1 to an Arbitrum chain as retryable tickets of its
+// inbox. The target sees the alias of the sender, see ApplyL1ToL2Alias, as the caller.
+//
+// The fees of tickets are paid upfront, and are bounded by MaxSubmissionCost and
+// MaxFeePerGas, which must be set from the current fees of both chains. Excess fees are
+// refunded to the alias of the sender on L2.
+type ArbitrumBridge struct {
+	Inbox   common.Address // Inbox of the destination chain
+	ChainID *big.Int       // Destination chain
+
+	MaxSubmissionCost *big.Int // Fee for submitting the ticket, paid on L1
+	MaxFeePerGas      *big.Int // Gas price of the call on L2
+}
+
+var _ Bridge = &ArbitrumBridge{}
+
+func NewArbitrumBridge(inbox common.Address, chainID *big.Int) *ArbitrumBridge {
+	return &ArbitrumBridge{Inbox: inbox, ChainID: chainID}
+}
+
+// SetFees sets the maximum fees of the tickets sent by the bridge.
+func (b *ArbitrumBridge) SetFees(maxSubmissionCost, maxFeePerGas *big.Int) *ArbitrumBridge {
+	b.MaxSubmissionCost = maxSubmissionCost
+	b.MaxFeePerGas = maxFeePerGas
+	return b
+}
+
+func (b *ArbitrumBridge) Name() string {
+	return fmt.Sprintf("arbitrum:%v", b.ChainID)
+}
+
+func (b *ArbitrumBridge) Send(ctx context.Context, from common.Address, msg *Message) (sequence.Transactions, error) {
+	if err := checkChain(b.Name(), b.ChainID, msg); err != nil {
+		return nil, err
+	}
+	if b.MaxSubmissionCost == nil || b.MaxFeePerGas == nil {
+		return nil, fmt.Errorf("bridge: %s fees are not set", b.Name())
+	}
+
+	gasLimit := new(big.Int).SetUint64(msg.GasLimit)
+	callValue := bigOrZero(msg.Value)
+	data, err := arbitrumInboxABI.Pack("createRetryableTicket",
+		msg.To, callValue, b.MaxSubmissionCost, from, from, gasLimit, b.MaxFeePerGas, msg.Data,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("bridge: failed to encode createRetryableTicket: %w", err)
+	}
+
+	// the ticket is funded with its fees and the value of the call
+	value := new(big.Int).Mul(gasLimit, b.MaxFeePerGas)
+	value.Add(value, b.MaxSubmissionCost)
+	value.Add(value, callValue)
+
+	return sequence.Transactions{{
+		To:            b.Inbox,
+		Value:         value,
+		Data:          data,
+		RevertOnError: true,
+	}}, nil
+}
@@ -0,0 +1,113 @@
+package bulk
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+)
+
+// limiter is a token bucket of rate tokens per second, holding up to burst tokens. A nil
+// limiter doesn't limit.
+type limiter struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	mu     sync.Mutex
+}
+
+func newLimiter(rate float64, burst int) *limiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &limiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait takes a token, waiting for one if the bucket is empty.
+func (l *limiter) wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// gasBudget limits the gas of the bundles relayed per block.
+type gasBudget struct {
+	provider     *ethrpc.Provider
+	limiter      *limiter
+	budget       uint64
+	pollInterval time.Duration
+
+	block uint64
+	used  uint64
+	mu    sync.Mutex
+}
+
+// acquire takes gas from the budget of the current block, waiting for the next block if the
+// budget is spent. A bundle of more gas than the budget is given a block of its own.
+func (b *gasBudget) acquire(ctx context.Context, gas uint64) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for b.used > 0 && b.used+gas > b.budget {
+		block, err := b.blockNumber(ctx)
+		if err != nil {
+			return err
+		}
+		if b.block == 0 {
+			b.block = block
+		}
+		if block > b.block {
+			b.block, b.used = block, 0
+			break
+		}
+
+		timer := time.NewTimer(b.pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	b.used += gas
+	return nil
+}
+
+func (b *gasBudget) blockNumber(ctx context.Context) (uint64, error) {
+	if err := b.limiter.wait(ctx); err != nil {
+		return 0, err
+	}
+	return b.provider.BlockNumber(ctx)
+}
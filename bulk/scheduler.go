@@ -0,0 +1,305 @@
+// Package bulk relays large numbers of bundles, ie. rotations, payouts or sweeps, paced against
+// the rate limits of the provider and the relayers, within a gas budget per block, and spread
+// across the sender accounts of several relayers and the nonce spaces of the wallets.
+//
+//	scheduler := bulk.NewScheduler(provider, bulk.Options{RelayRate: 5, BlockGasBudget: 5_000_000})
+//	results := scheduler.Run(ctx, jobs)
+package bulk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/go-sequence"
+)
+
+// DefaultTransactionGas is the gas counted against the block gas budget for a transaction
+// without a gas limit.
+const DefaultTransactionGas uint64 = 100_000
+
+// ErrPreviousJobFailed is the error of a job not relayed because a previous job of its wallet
+// failed, as its nonce would never be executed.
+var ErrPreviousJobFailed = errors.New("bulk: previous job of the wallet failed")
+
+// Job is a bundle of transactions of a wallet to relay. The wallet must be connected to a
+// provider and a relayer, which read its nonce and estimate the gas of its transactions.
+type Job struct {
+	ID           string
+	Wallet       *sequence.Wallet
+	Transactions sequence.Transactions
+
+	// Gas is the gas counted against the block gas budget, optional. It defaults to the sum of
+	// the gas limits of the transactions.
+	Gas uint64
+
+	// IdempotencyKey signs the bundle in the nonce space of the key, see
+	// sequence.IdempotencyNonce, so the job is executed once however many times it's run. A job
+	// already executed fails with sequence.ErrAlreadyExecuted, and isn't relayed again.
+	IdempotencyKey string
+}
+
+func (j *Job) gas() uint64 {
+	if j.Gas != 0 {
+		return j.Gas
+	}
+	var gas uint64
+	for _, txn := range j.Transactions {
+		if txn.GasLimit != nil && txn.GasLimit.Sign() > 0 {
+			gas += txn.GasLimit.Uint64()
+		} else {
+			gas += DefaultTransactionGas
+		}
+	}
+	return gas
+}
+
+// Result is the outcome of a job.
+type Result struct {
+	Job *Job
+
+	// Relayer is the index in Options.Relayers of the relayer which relayed the job, or -1 if
+	// it was relayed by the relayer of its wallet.
+	Relayer int
+
+	MetaTxnID sequence.MetaTxnID
+
+	// Status is the status of the bundle once executed, if Options.Wait is set.
+	Status sequence.MetaTxnStatus
+
+	Err error
+}
+
+type Options struct {
+	// RelayRate is the number of bundles relayed per second, and ProviderRate the number of
+	// provider requests per second, up to Burst at once. Zero doesn't limit.
+	RelayRate    float64
+	ProviderRate float64
+	Burst        int
+
+	// BlockGasBudget is the gas of the bundles relayed per block. Zero doesn't limit.
+	BlockGasBudget uint64
+
+	// Concurrency is the number of jobs relayed at once, 1 by default.
+	Concurrency int
+
+	// Relayers are relayers of distinct sender accounts the bundles are spread across, round
+	// robin. By default the bundles are relayed by the relayers of their wallets.
+	Relayers []sequence.Relayer
+
+	// NonceSpaces signs each bundle in a random nonce space, so the bundles of a wallet are
+	// independent and can be relayed at once and by distinct relayers. Otherwise the bundles of
+	// a wallet are signed with consecutive nonces and relayed in order by the same relayer.
+	NonceSpaces bool
+
+	// Wait waits for each bundle to be executed, and reports its status, before the next bundle
+	// of its wallet is relayed.
+	Wait bool
+
+	// OnResult is called with the result of each job as it completes, one at a time, optional.
+	OnResult func(Result)
+
+	// PollInterval is the interval the block number is polled at while the block gas budget is
+	// spent, 1 second by default.
+	PollInterval time.Duration
+}
+
+// Scheduler relays jobs paced by its Options.
+type Scheduler struct {
+	options      Options
+	relayLimiter *limiter
+	provLimiter  *limiter
+	gasBudget    *gasBudget
+	nextRelayer  uint64
+	resultsMu    sync.Mutex
+}
+
+func NewScheduler(provider *ethrpc.Provider, options Options) *Scheduler {
+	if options.Concurrency < 1 {
+		options.Concurrency = 1
+	}
+	if options.PollInterval == 0 {
+		options.PollInterval = time.Second
+	}
+
+	s := &Scheduler{
+		options:      options,
+		relayLimiter: newLimiter(options.RelayRate, options.Burst),
+		provLimiter:  newLimiter(options.ProviderRate, options.Burst),
+	}
+	if options.BlockGasBudget > 0 {
+		s.gasBudget = &gasBudget{
+			provider:     provider,
+			limiter:      s.provLimiter,
+			budget:       options.BlockGasBudget,
+			pollInterval: options.PollInterval,
+		}
+	}
+	return s
+}
+
+// Run relays jobs and returns their results in the same order. Jobs not relayed once ctx is
+// done fail with its error.
+func (s *Scheduler) Run(ctx context.Context, jobs []*Job) []Result {
+	results := make([]Result, len(jobs))
+
+	// a lane is a sequence of jobs relayed in order
+	var lanes [][]int
+	byWallet := map[*sequence.Wallet]int{}
+	for i, job := range jobs {
+		// jobs of distinct nonce spaces are independent
+		if s.options.NonceSpaces || job.IdempotencyKey != "" {
+			lanes = append(lanes, []int{i})
+		} else {
+			lane, ok := byWallet[job.Wallet]
+			if !ok {
+				lane = len(lanes)
+				byWallet[job.Wallet] = lane
+				lanes = append(lanes, nil)
+			}
+			lanes[lane] = append(lanes[lane], i)
+		}
+	}
+
+	queue := make(chan []int, len(lanes))
+	for _, lane := range lanes {
+		queue <- lane
+	}
+	close(queue)
+
+	var wg sync.WaitGroup
+	for w := 0; w < s.options.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for lane := range queue {
+				s.runLane(ctx, jobs, lane, results)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (s *Scheduler) runLane(ctx context.Context, jobs []*Job, lane []int, results []Result) {
+	relayer, relayerIndex := s.relayer()
+
+	var nonce *big.Int
+	var failed bool
+	for _, i := range lane {
+		job := jobs[i]
+		result := Result{Job: job, Relayer: relayerIndex}
+
+		if failed {
+			result.Err = ErrPreviousJobFailed
+			s.report(results, i, result)
+			continue
+		}
+
+		if relayer == nil {
+			relayer = job.Wallet.GetRelayer()
+		}
+
+		var err error
+		nonce, err = s.nonce(ctx, job, nonce)
+		if err == nil {
+			err = s.relay(ctx, job, relayer, nonce, &result)
+		}
+		if err != nil {
+			result.Err = err
+			failed = true
+		}
+		s.report(results, i, result)
+	}
+}
+
+// nonce returns the nonce of job, following the nonce of the previous job of its wallet.
+func (s *Scheduler) nonce(ctx context.Context, job *Job, previous *big.Int) (*big.Int, error) {
+	if job.IdempotencyKey != "" {
+		if err := s.provLimiter.wait(ctx); err != nil {
+			return nil, err
+		}
+		nonce, err := job.Wallet.IdempotentNonce(job.IdempotencyKey, 0)
+		if err != nil {
+			return nil, fmt.Errorf("bulk: job %v: %w", job.ID, err)
+		}
+		return nonce, nil
+	}
+	if s.options.NonceSpaces {
+		return sequence.GenerateRandomNonce()
+	}
+	if previous != nil {
+		return new(big.Int).Add(previous, big.NewInt(1)), nil
+	}
+	if err := s.provLimiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	nonce, err := job.Wallet.GetNonce()
+	if err != nil {
+		return nil, fmt.Errorf("bulk: failed to get nonce of %v: %w", job.Wallet.Address().Hex(), err)
+	}
+	return nonce, nil
+}
+
+func (s *Scheduler) relay(ctx context.Context, job *Job, relayer sequence.Relayer, nonce *big.Int, result *Result) error {
+	if relayer == nil {
+		return fmt.Errorf("bulk: %w", sequence.ErrRelayerNotSet)
+	}
+	if err := s.gasBudget.acquire(ctx, job.gas()); err != nil {
+		return err
+	}
+
+	// gas estimation queries the provider
+	if err := s.provLimiter.wait(ctx); err != nil {
+		return err
+	}
+	signed, err := job.Wallet.SignTransactionsWithNonce(ctx, job.Transactions, nonce)
+	if err != nil {
+		return fmt.Errorf("bulk: failed to sign job %v: %w", job.ID, err)
+	}
+
+	if err := s.relayLimiter.wait(ctx); err != nil {
+		return err
+	}
+	result.MetaTxnID, _, _, err = relayer.Relay(ctx, signed)
+	if err != nil {
+		return fmt.Errorf("bulk: failed to relay job %v: %w", job.ID, err)
+	}
+
+	if !s.options.Wait {
+		return nil
+	}
+	result.Status, _, err = relayer.Wait(ctx, result.MetaTxnID)
+	if err != nil {
+		return fmt.Errorf("bulk: failed to wait for job %v: %w", job.ID, err)
+	}
+	if result.Status != sequence.MetaTxnExecuted {
+		return fmt.Errorf("bulk: job %v %v", job.ID, result.Status)
+	}
+	return nil
+}
+
+// relayer returns the next relayer of Options.Relayers and its index, or nil and -1 if there
+// are none.
+func (s *Scheduler) relayer() (sequence.Relayer, int) {
+	if len(s.options.Relayers) == 0 {
+		return nil, -1
+	}
+	i := int((atomic.AddUint64(&s.nextRelayer, 1) - 1) % uint64(len(s.options.Relayers)))
+	return s.options.Relayers[i], i
+}
+
+func (s *Scheduler) report(results []Result, i int, result Result) {
+	s.resultsMu.Lock()
+	defer s.resultsMu.Unlock()
+	results[i] = result
+	if s.options.OnResult != nil {
+		s.options.OnResult(result)
+	}
+}
@@ -0,0 +1,210 @@
+package bulk_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/bulk"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRelayer struct {
+	provider *ethrpc.Provider
+	fail     bool
+	relayed  []*sequence.SignedTransactions
+	executed map[string]bool
+
+	mu sync.Mutex
+}
+
+func (r *fakeRelayer) GetProvider() *ethrpc.Provider { return r.provider }
+
+func (r *fakeRelayer) EstimateGasLimits(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, txns sequence.Transactions) (sequence.Transactions, error) {
+	return txns, nil
+}
+
+func (r *fakeRelayer) GetNonce(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, space *big.Int, blockNum *big.Int) (*big.Int, error) {
+	if space != nil {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.executed[space.String()] {
+			return big.NewInt(1), nil
+		}
+		return big.NewInt(0), nil
+	}
+	return big.NewInt(5), nil
+}
+
+func (r *fakeRelayer) Relay(ctx context.Context, signedTxs *sequence.SignedTransactions) (sequence.MetaTxnID, *types.Transaction, ethtxn.WaitReceipt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail {
+		return "", nil, nil, errors.New("relayer is down")
+	}
+	r.relayed = append(r.relayed, signedTxs)
+	if r.executed != nil {
+		space, _ := sequence.DecodeNonce(signedTxs.Nonce)
+		r.executed[space.String()] = true
+	}
+	return sequence.MetaTxnID(signedTxs.Digest.Hex()[2:]), nil, nil, nil
+}
+
+func (r *fakeRelayer) Wait(ctx context.Context, metaTxnID sequence.MetaTxnID, optTimeout ...time.Duration) (sequence.MetaTxnStatus, *types.Receipt, error) {
+	return sequence.MetaTxnExecuted, nil, nil
+}
+
+func (r *fakeRelayer) FeeOptions(ctx context.Context, signedTxs *sequence.SignedTransactions) ([]*sequence.FeeOption, error) {
+	return nil, nil
+}
+
+func TestScheduler(t *testing.T) {
+	ctx := context.Background()
+
+	// a chain which mines a block each time its head is read
+	chain := memchain.New(memchain.Options{ChainID: big.NewInt(1)})
+	chain.Intercept(func(method string, params []json.RawMessage, next func() (interface{}, error)) (interface{}, error) {
+		if method == "eth_blockNumber" {
+			chain.Mine()
+		}
+		return next()
+	})
+	provider := chain.Provider()
+
+	walletRelayer := &fakeRelayer{provider: provider}
+	wallets := make([]*sequence.Wallet, 2)
+	for i := range wallets {
+		key, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(uint64(i + 1)))
+		assert.NoError(t, err)
+		wallets[i], err = sequence.NewWalletSingleOwner(key)
+		assert.NoError(t, err)
+		assert.NoError(t, wallets[i].Connect(provider, walletRelayer))
+	}
+
+	newJobs := func(walletIndexes ...int) []*bulk.Job {
+		jobs := make([]*bulk.Job, len(walletIndexes))
+		for i, w := range walletIndexes {
+			jobs[i] = &bulk.Job{
+				ID:           string(rune('a' + i)),
+				Wallet:       wallets[w],
+				Transactions: sequence.Transactions{{To: common.HexToAddress("0x01"), Value: big.NewInt(int64(i + 1)), GasLimit: big.NewInt(50000)}},
+			}
+		}
+		return jobs
+	}
+
+	t.Run("consecutive nonces", func(t *testing.T) {
+		relayers := []*fakeRelayer{{provider: provider}, {provider: provider}}
+		scheduler := bulk.NewScheduler(provider, bulk.Options{
+			Concurrency: 2,
+			Relayers:    []sequence.Relayer{relayers[0], relayers[1]},
+			Wait:        true,
+		})
+		results := scheduler.Run(ctx, newJobs(0, 1, 0))
+		assert.Len(t, results, 3)
+		for _, result := range results {
+			assert.NoError(t, result.Err)
+			assert.Equal(t, sequence.MetaTxnExecuted, result.Status)
+		}
+
+		// the jobs of a wallet are relayed in order, with consecutive nonces, by one relayer
+		assert.Equal(t, results[0].Relayer, results[2].Relayer)
+		assert.NotEqual(t, results[0].Relayer, results[1].Relayer)
+		relayed := relayers[results[0].Relayer].relayed
+		assert.Len(t, relayed, 2)
+		assert.Equal(t, big.NewInt(5), relayed[0].Nonce)
+		assert.Equal(t, big.NewInt(6), relayed[1].Nonce)
+		assert.Equal(t, big.NewInt(3), relayed[1].Transactions[0].Value)
+	})
+
+	t.Run("nonce spaces", func(t *testing.T) {
+		relayers := []*fakeRelayer{{provider: provider}, {provider: provider}}
+		scheduler := bulk.NewScheduler(provider, bulk.Options{
+			Relayers:    []sequence.Relayer{relayers[0], relayers[1]},
+			NonceSpaces: true,
+			RelayRate:   1000,
+		})
+		results := scheduler.Run(ctx, newJobs(0, 0, 0))
+		assert.Equal(t, []int{0, 1, 0}, []int{results[0].Relayer, results[1].Relayer, results[2].Relayer})
+
+		spaces := map[string]bool{}
+		for _, r := range relayers {
+			for _, signed := range r.relayed {
+				space, nonce := sequence.DecodeNonce(signed.Nonce)
+				assert.Equal(t, int64(0), nonce.Int64())
+				spaces[space.String()] = true
+			}
+		}
+		assert.Len(t, spaces, 3)
+	})
+
+	t.Run("idempotency keys", func(t *testing.T) {
+		// the nonces of the wallets are read from their relayer
+		walletRelayer.executed = map[string]bool{}
+		defer func() { walletRelayer.executed = nil }()
+		relayed := len(walletRelayer.relayed)
+
+		scheduler := bulk.NewScheduler(provider, bulk.Options{})
+		newIdempotentJobs := func() []*bulk.Job {
+			jobs := newJobs(0, 0)
+			jobs[0].IdempotencyKey = "payout-1"
+			jobs[1].IdempotencyKey = "payout-2"
+			return jobs
+		}
+
+		results := scheduler.Run(ctx, newIdempotentJobs())
+		for _, result := range results {
+			assert.NoError(t, result.Err)
+		}
+		assert.Len(t, walletRelayer.relayed, relayed+2)
+		assert.Equal(t, sequence.IdempotencyNonce("payout-1", 0), walletRelayer.relayed[relayed].Nonce)
+
+		// a re-run doesn't relay the executed jobs again
+		results = scheduler.Run(ctx, newIdempotentJobs())
+		for _, result := range results {
+			assert.True(t, errors.Is(result.Err, sequence.ErrAlreadyExecuted))
+		}
+		assert.Len(t, walletRelayer.relayed, relayed+2)
+	})
+
+	t.Run("block gas budget", func(t *testing.T) {
+		start := chain.Head()
+		scheduler := bulk.NewScheduler(provider, bulk.Options{
+			BlockGasBudget: 100000,
+			PollInterval:   time.Millisecond,
+		})
+		results := scheduler.Run(ctx, newJobs(0, 0, 0, 0, 0))
+		for _, result := range results {
+			assert.NoError(t, result.Err)
+			assert.Equal(t, -1, result.Relayer)
+		}
+
+		// two jobs fit in a block: the third waits for block 2, and the fifth for block 3
+		assert.Equal(t, uint64(3), chain.Head()-start)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		relayer := &fakeRelayer{provider: provider, fail: true}
+		var reported int
+		scheduler := bulk.NewScheduler(provider, bulk.Options{
+			Relayers: []sequence.Relayer{relayer},
+			OnResult: func(bulk.Result) { reported++ },
+		})
+		results := scheduler.Run(ctx, newJobs(0, 0))
+		assert.Error(t, results[0].Err)
+		assert.True(t, errors.Is(results[1].Err, bulk.ErrPreviousJobFailed))
+		assert.Equal(t, 2, reported)
+	})
+}
@@ -0,0 +1,353 @@
+package sequence
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+)
+
+var (
+	ErrInvalidTemplate      = errors.New("sequence: invalid bundle template")
+	ErrInvalidTemplateValue = errors.New("sequence: invalid bundle template value")
+	ErrTemplateNotApproved  = errors.New("sequence: bundle template is not approved")
+)
+
+// PlaceholderType is the type of the value of a placeholder of a BundleTemplate.
+type PlaceholderType string
+
+const (
+	// PlaceholderAddress is an address, ie. a recipient, in hex.
+	PlaceholderAddress PlaceholderType = "address"
+
+	// PlaceholderUint256 is an integer, ie. an amount in wei, in decimal or 0x prefixed hex.
+	PlaceholderUint256 PlaceholderType = "uint256"
+
+	// PlaceholderDeadline is a time in the future, as a Unix timestamp in seconds or in
+	// RFC 3339, filled as a uint256 of its Unix timestamp.
+	PlaceholderDeadline PlaceholderType = "deadline"
+)
+
+// Placeholder is a typed value of a BundleTemplate, filled at send time, with optional
+// bounds approved with the template.
+type Placeholder struct {
+	Name string          `json:"name"`
+	Type PlaceholderType `json:"type"`
+
+	// Allowed are the addresses an address may be, any if empty.
+	Allowed []common.Address `json:"allowed,omitempty"`
+
+	// Min and Max bound a uint256, in decimal, unbounded if empty.
+	Min string `json:"min,omitempty"`
+	Max string `json:"max,omitempty"`
+
+	// MaxDeadlineSeconds is the furthest in the future a deadline may be, in seconds, unbounded
+	// if zero.
+	MaxDeadlineSeconds int64 `json:"maxDeadlineSeconds,omitempty"`
+}
+
+// TransactionTemplate is a transaction of a BundleTemplate. To, Value and Args are literals,
+// or a placeholder "{{name}}".
+type TransactionTemplate struct {
+	To    string `json:"to"`
+	Value string `json:"value,omitempty"`
+
+	// Method is the signature of the method called, ie. "transfer(address,uint256)", and Args
+	// its arguments, as accepted by ethcoder.AbiUnmarshalStringValues. Placeholders may only
+	// be arguments of address and uint256 types.
+	Method string   `json:"method,omitempty"`
+	Args   []string `json:"args,omitempty"`
+
+	// Data is the literal calldata in hex, if there is no Method.
+	Data string `json:"data,omitempty"`
+
+	GasLimit      uint64 `json:"gasLimit,omitempty"`
+	DelegateCall  bool   `json:"delegateCall,omitempty"`
+	RevertOnError bool   `json:"revertOnError,omitempty"`
+}
+
+// BundleTemplate is a reusable bundle whose placeholders are filled at send time, so it can be
+// reviewed and approved once, by its Digest, and sent with other values each time:
+//
+//	template := &sequence.BundleTemplate{
+//		Name:         "payout",
+//		Placeholders: []sequence.Placeholder{{Name: "recipient", Type: sequence.PlaceholderAddress}, {Name: "amount", Type: sequence.PlaceholderUint256, Max: "1000000000"}},
+//		Transactions: []sequence.TransactionTemplate{{To: usdc, Method: "transfer(address,uint256)", Args: []string{"{{recipient}}", "{{amount}}"}}},
+//	}
+//	txns, err := template.FillApproved(approvedDigest, map[string]string{"recipient": "0x...", "amount": "2500000"})
+type BundleTemplate struct {
+	Name         string                `json:"name"`
+	Placeholders []Placeholder         `json:"placeholders"`
+	Transactions []TransactionTemplate `json:"transactions"`
+}
+
+// Digest is the canonical JSON digest of the template, see CanonicalJSONDigest, which
+// identifies the template approved.
+func (t *BundleTemplate) Digest() (common.Hash, error) {
+	return CanonicalJSONDigest(t)
+}
+
+// Validate checks the placeholders are declared once, with a known type and valid bounds, and
+// that the transactions are valid and only use declared placeholders where their types fit.
+func (t *BundleTemplate) Validate() error {
+	_, err := t.placeholders()
+	if err != nil {
+		return err
+	}
+	if len(t.Transactions) == 0 {
+		return fmt.Errorf("%w: %v has no transactions", ErrInvalidTemplate, t.Name)
+	}
+	for i := range t.Transactions {
+		if _, err := t.fillTransaction(i, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Fill returns the transactions of the template, with its placeholders filled by values, by
+// name. Each placeholder must have a value within its bounds, and values must not name
+// undeclared placeholders.
+func (t *BundleTemplate) Fill(values map[string]string) (Transactions, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	placeholders, _ := t.placeholders()
+
+	filled := make(map[string]string, len(values))
+	for name := range values {
+		if _, ok := placeholders[name]; !ok {
+			return nil, fmt.Errorf("%w: %v is not a placeholder of %v", ErrInvalidTemplateValue, name, t.Name)
+		}
+	}
+	for name, placeholder := range placeholders {
+		value, ok := values[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: missing value of %v", ErrInvalidTemplateValue, name)
+		}
+		normalized, err := placeholder.normalize(value, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		filled[name] = normalized
+	}
+
+	txns := make(Transactions, len(t.Transactions))
+	for i := range t.Transactions {
+		txn, err := t.fillTransaction(i, filled)
+		if err != nil {
+			return nil, err
+		}
+		txns[i] = txn
+	}
+	return txns, nil
+}
+
+// FillApproved is Fill, which fails with ErrTemplateNotApproved if the template isn't the
+// template of approvedDigest, ie. it was changed since it was approved.
+func (t *BundleTemplate) FillApproved(approvedDigest common.Hash, values map[string]string) (Transactions, error) {
+	digest, err := t.Digest()
+	if err != nil {
+		return nil, err
+	}
+	if digest != approvedDigest {
+		return nil, fmt.Errorf("%w: %v has digest %v", ErrTemplateNotApproved, t.Name, digest.Hex())
+	}
+	return t.Fill(values)
+}
+
+func (t *BundleTemplate) placeholders() (map[string]*Placeholder, error) {
+	placeholders := make(map[string]*Placeholder, len(t.Placeholders))
+	for i := range t.Placeholders {
+		p := &t.Placeholders[i]
+		if p.Name == "" {
+			return nil, fmt.Errorf("%w: placeholder %d has no name", ErrInvalidTemplate, i)
+		}
+		if _, ok := placeholders[p.Name]; ok {
+			return nil, fmt.Errorf("%w: placeholder %v is declared twice", ErrInvalidTemplate, p.Name)
+		}
+		switch p.Type {
+		case PlaceholderAddress, PlaceholderDeadline:
+		case PlaceholderUint256:
+			for _, bound := range []string{p.Min, p.Max} {
+				if bound == "" {
+					continue
+				}
+				if _, ok := new(big.Int).SetString(bound, 10); !ok {
+					return nil, fmt.Errorf("%w: invalid bound %q of %v", ErrInvalidTemplate, bound, p.Name)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("%w: placeholder %v has unknown type %q", ErrInvalidTemplate, p.Name, p.Type)
+		}
+		placeholders[p.Name] = p
+	}
+	return placeholders, nil
+}
+
+// fillTransaction fills the transaction i of the template with the normalized values, or with
+// zero values to validate it if values is nil.
+func (t *BundleTemplate) fillTransaction(i int, values map[string]string) (*Transaction, error) {
+	tmpl := &t.Transactions[i]
+	placeholders, _ := t.placeholders()
+
+	// field resolves a field of the transaction, a literal or a placeholder of one of types
+	field := func(name, s string, types ...PlaceholderType) (string, error) {
+		placeholder, ok := placeholderName(s)
+		if !ok {
+			return s, nil
+		}
+		p, ok := placeholders[placeholder]
+		if !ok {
+			return "", fmt.Errorf("%w: %v of transaction %d uses undeclared placeholder %v", ErrInvalidTemplate, name, i, placeholder)
+		}
+		for _, typ := range types {
+			if p.Type != typ {
+				continue
+			}
+			if values == nil {
+				if typ == PlaceholderAddress {
+					return common.Address{}.Hex(), nil
+				}
+				return "0", nil
+			}
+			return values[placeholder], nil
+		}
+		return "", fmt.Errorf("%w: %v of transaction %d can't be placeholder %v of type %v", ErrInvalidTemplate, name, i, placeholder, p.Type)
+	}
+
+	txn := &Transaction{DelegateCall: tmpl.DelegateCall, RevertOnError: tmpl.RevertOnError}
+	if tmpl.GasLimit != 0 {
+		txn.GasLimit = new(big.Int).SetUint64(tmpl.GasLimit)
+	}
+
+	to, err := field("to", tmpl.To, PlaceholderAddress)
+	if err != nil {
+		return nil, err
+	}
+	if !common.IsHexAddress(to) {
+		return nil, fmt.Errorf("%w: invalid to %q of transaction %d", ErrInvalidTemplate, to, i)
+	}
+	txn.To = common.HexToAddress(to)
+
+	value, err := field("value", tmpl.Value, PlaceholderUint256)
+	if err != nil {
+		return nil, err
+	}
+	txn.Value = big.NewInt(0)
+	if value != "" {
+		if _, ok := txn.Value.SetString(value, 0); !ok {
+			return nil, fmt.Errorf("%w: invalid value %q of transaction %d", ErrInvalidTemplate, value, i)
+		}
+	}
+
+	switch {
+	case tmpl.Method != "" && tmpl.Data != "":
+		return nil, fmt.Errorf("%w: transaction %d has both a method and data", ErrInvalidTemplate, i)
+
+	case tmpl.Method != "":
+		mabi, name, err := ethcoder.ParseMethodABI(tmpl.Method, "")
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid method %q of transaction %d: %v", ErrInvalidTemplate, tmpl.Method, i, err)
+		}
+		inputs := mabi.Methods[name].Inputs
+		if len(inputs) != len(tmpl.Args) {
+			return nil, fmt.Errorf("%w: method %v of transaction %d takes %d arguments, not %d", ErrInvalidTemplate, tmpl.Method, i, len(inputs), len(tmpl.Args))
+		}
+
+		args := make([]string, len(tmpl.Args))
+		for j, arg := range tmpl.Args {
+			var types []PlaceholderType
+			switch inputs[j].Type.String() {
+			case "address":
+				types = []PlaceholderType{PlaceholderAddress}
+			case "uint256":
+				types = []PlaceholderType{PlaceholderUint256, PlaceholderDeadline}
+			}
+			if args[j], err = field(fmt.Sprintf("argument %d", j), arg, types...); err != nil {
+				return nil, err
+			}
+		}
+		txn.Data, err = ethcoder.AbiEncodeMethodCalldataFromStringValues(tmpl.Method, args)
+		if err != nil && values == nil {
+			return nil, fmt.Errorf("%w: arguments of transaction %d: %v", ErrInvalidTemplate, i, err)
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: transaction %d: %v", ErrInvalidTemplateValue, i, err)
+		}
+
+	case tmpl.Data != "":
+		txn.Data, err = hexutil.Decode(tmpl.Data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid data of transaction %d: %v", ErrInvalidTemplate, i, err)
+		}
+	}
+	return txn, nil
+}
+
+// normalize validates value and returns it as accepted by ethcoder: addresses in hex, and
+// integers and deadlines in decimal.
+func (p *Placeholder) normalize(value string, now time.Time) (string, error) {
+	switch p.Type {
+	case PlaceholderAddress:
+		if !common.IsHexAddress(value) {
+			return "", fmt.Errorf("%w: %v is not an address", ErrInvalidTemplateValue, p.Name)
+		}
+		address := common.HexToAddress(value)
+		if len(p.Allowed) > 0 {
+			allowed := false
+			for _, a := range p.Allowed {
+				allowed = allowed || a == address
+			}
+			if !allowed {
+				return "", fmt.Errorf("%w: %v %v is not allowed", ErrInvalidTemplateValue, p.Name, address.Hex())
+			}
+		}
+		return address.Hex(), nil
+
+	case PlaceholderUint256:
+		n, ok := new(big.Int).SetString(value, 0)
+		if !ok || n.Sign() < 0 || n.BitLen() > 256 {
+			return "", fmt.Errorf("%w: %v is not a uint256", ErrInvalidTemplateValue, p.Name)
+		}
+		if min, ok := new(big.Int).SetString(p.Min, 10); ok && n.Cmp(min) < 0 {
+			return "", fmt.Errorf("%w: %v %v is below the minimum of %v", ErrInvalidTemplateValue, p.Name, n, min)
+		}
+		if max, ok := new(big.Int).SetString(p.Max, 10); ok && n.Cmp(max) > 0 {
+			return "", fmt.Errorf("%w: %v %v is above the maximum of %v", ErrInvalidTemplateValue, p.Name, n, max)
+		}
+		return n.String(), nil
+
+	case PlaceholderDeadline:
+		var deadline time.Time
+		if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+			deadline = time.Unix(unix, 0)
+		} else if deadline, err = time.Parse(time.RFC3339, value); err != nil {
+			return "", fmt.Errorf("%w: %v is not a Unix timestamp or an RFC 3339 time", ErrInvalidTemplateValue, p.Name)
+		}
+		if !deadline.After(now) {
+			return "", fmt.Errorf("%w: %v %v is past", ErrInvalidTemplateValue, p.Name, deadline.UTC().Format(time.RFC3339))
+		}
+		if maxDeadline := time.Duration(p.MaxDeadlineSeconds) * time.Second; maxDeadline > 0 && deadline.After(now.Add(maxDeadline)) {
+			return "", fmt.Errorf("%w: %v %v is further than %v", ErrInvalidTemplateValue, p.Name, deadline.UTC().Format(time.RFC3339), maxDeadline)
+		}
+		return strconv.FormatInt(deadline.Unix(), 10), nil
+
+	default:
+		return "", fmt.Errorf("%w: placeholder %v has unknown type %q", ErrInvalidTemplate, p.Name, p.Type)
+	}
+}
+
+// placeholderName returns the name of the placeholder "{{name}}" of s, if it is one.
+func placeholderName(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{{") || !strings.HasSuffix(s, "}}") {
+		return "", false
+	}
+	return strings.TrimSpace(s[2 : len(s)-2]), true
+}
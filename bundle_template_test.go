@@ -0,0 +1,84 @@
+package sequence_test
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBundleTemplate(t *testing.T) {
+	token := common.HexToAddress("0x7ceb23fd6bc0add59e62ac25578270cff1b9f619")
+	recipient := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	template := &sequence.BundleTemplate{
+		Name: "payout",
+		Placeholders: []sequence.Placeholder{
+			{Name: "recipient", Type: sequence.PlaceholderAddress},
+			{Name: "amount", Type: sequence.PlaceholderUint256, Min: "1", Max: "1000000"},
+			{Name: "deadline", Type: sequence.PlaceholderDeadline, MaxDeadlineSeconds: 3600},
+		},
+		Transactions: []sequence.TransactionTemplate{
+			{To: token.Hex(), Method: "transferWithDeadline(address,uint256,uint256)", Args: []string{"{{recipient}}", "{{amount}}", "{{deadline}}"}, GasLimit: 80000},
+			{To: "{{recipient}}", Value: "{{amount}}"},
+		},
+	}
+	assert.NoError(t, template.Validate())
+	approved, err := template.Digest()
+	assert.NoError(t, err)
+
+	deadline := time.Now().Add(time.Minute).Unix()
+	values := map[string]string{"recipient": recipient.Hex(), "amount": "0x2710", "deadline": strconv.FormatInt(deadline, 10)}
+	txns, err := template.FillApproved(approved, values)
+	assert.NoError(t, err)
+	assert.Len(t, txns, 2)
+
+	expected, err := ethcoder.AbiEncodeMethodCalldata("transferWithDeadline(address,uint256,uint256)", []interface{}{recipient, big.NewInt(10000), big.NewInt(deadline)})
+	assert.NoError(t, err)
+	assert.Equal(t, token, txns[0].To)
+	assert.Equal(t, expected, txns[0].Data)
+	assert.Equal(t, big.NewInt(80000), txns[0].GasLimit)
+	assert.Equal(t, recipient, txns[1].To)
+	assert.Equal(t, big.NewInt(10000), txns[1].Value)
+
+	// values are validated against the placeholders
+	invalid := []map[string]string{
+		{"recipient": "0x1234", "amount": "10", "deadline": values["deadline"]},
+		{"recipient": recipient.Hex(), "amount": "1000001", "deadline": values["deadline"]},
+		{"recipient": recipient.Hex(), "amount": "0", "deadline": values["deadline"]},
+		{"recipient": recipient.Hex(), "amount": "10", "deadline": "2000-01-01T00:00:00Z"},
+		{"recipient": recipient.Hex(), "amount": "10", "deadline": strconv.FormatInt(time.Now().Add(2*time.Hour).Unix(), 10)},
+		{"recipient": recipient.Hex(), "amount": "10"},
+		{"recipient": recipient.Hex(), "amount": "10", "deadline": values["deadline"], "memo": "hi"},
+	}
+	for _, values := range invalid {
+		_, err := template.Fill(values)
+		assert.True(t, errors.Is(err, sequence.ErrInvalidTemplateValue), "%v: %v", values, err)
+	}
+
+	// a changed template is not approved
+	template.Placeholders[1].Max = "2000000"
+	_, err = template.FillApproved(approved, values)
+	assert.True(t, errors.Is(err, sequence.ErrTemplateNotApproved))
+
+	// placeholders must be declared, and fit the fields they fill
+	invalidTemplates := []sequence.TransactionTemplate{
+		{To: "{{other}}"},
+		{To: "{{amount}}"},
+		{To: token.Hex(), Value: "{{recipient}}"},
+		{To: token.Hex(), Method: "transfer(address,uint256)", Args: []string{"{{amount}}", "{{recipient}}"}},
+		{To: token.Hex(), Method: "transfer(address,uint256)", Args: []string{"{{recipient}}"}},
+		{To: token.Hex(), Method: "transfer(address,uint256)", Args: []string{"nope", "{{amount}}"}},
+		{To: token.Hex(), Method: "transfer(address,uint256)", Data: "0x"},
+	}
+	for _, txn := range invalidTemplates {
+		template.Transactions = []sequence.TransactionTemplate{txn}
+		assert.True(t, errors.Is(template.Validate(), sequence.ErrInvalidTemplate), "%+v", txn)
+	}
+}
@@ -0,0 +1,74 @@
+package sequence
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+)
+
+// CallOpts override, for a single read or relay, the provider, relayer and defaults of a
+// wallet, ie. of a wallet shared across requests, without mutating it. They are passed with
+// the context of the call, see WithCallOpts.
+type CallOpts struct {
+	// BlockNumber pins the reads of the call to a block, nil for the latest block.
+	BlockNumber *big.Int
+
+	// MaxGasPrice caps the gas price of the native transactions of relays, nil for no cap.
+	// It is applied by relayers which price their transactions, ie. the local relayer.
+	MaxGasPrice *big.Int
+
+	// Provider replaces the provider of the wallet, nil for the provider of the wallet.
+	Provider *ethrpc.Provider
+
+	// Relayer replaces the relayer of the wallet, nil for the relayer of the wallet.
+	Relayer Relayer
+
+	// Timeout bounds the call, 0 for no timeout but the deadline of the context.
+	Timeout time.Duration
+}
+
+type callOptsCtxKey struct{}
+
+// WithCallOpts returns a copy of ctx carrying opts, which the wallet methods and relayers
+// called with it apply.
+func WithCallOpts(ctx context.Context, opts CallOpts) context.Context {
+	return context.WithValue(ctx, callOptsCtxKey{}, opts)
+}
+
+// CallOptsFromContext returns the call options carried by ctx, see WithCallOpts.
+func CallOptsFromContext(ctx context.Context) (CallOpts, bool) {
+	opts, ok := ctx.Value(callOptsCtxKey{}).(CallOpts)
+	return opts, ok
+}
+
+// callContext returns ctx bounded by the timeout of its call options, if any.
+func callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if opts, ok := CallOptsFromContext(ctx); ok && opts.Timeout > 0 {
+		return context.WithTimeout(ctx, opts.Timeout)
+	}
+	return ctx, func() {}
+}
+
+// callBlockNumber returns the block the reads of ctx are pinned to, nil for the latest block.
+func callBlockNumber(ctx context.Context) *big.Int {
+	opts, _ := CallOptsFromContext(ctx)
+	return opts.BlockNumber
+}
+
+// providerOf returns the provider of the calls of w with ctx.
+func (w *Wallet) providerOf(ctx context.Context) *ethrpc.Provider {
+	if opts, ok := CallOptsFromContext(ctx); ok && opts.Provider != nil {
+		return opts.Provider
+	}
+	return w.provider
+}
+
+// relayerOf returns the relayer of the calls of w with ctx.
+func (w *Wallet) relayerOf(ctx context.Context) Relayer {
+	if opts, ok := CallOptsFromContext(ctx); ok && opts.Relayer != nil {
+		return opts.Relayer
+	}
+	return w.relayer
+}
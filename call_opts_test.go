@@ -0,0 +1,71 @@
+package sequence_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallOpts(t *testing.T) {
+	ctx := context.Background()
+	owner, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(owner)
+	assert.NoError(t, err)
+	wallet.SetChainID(big.NewInt(1))
+
+	// a chain which records the blocks of the reads, and is slow when told to
+	chain := memchain.New(memchain.Options{ChainID: big.NewInt(1)})
+	chain.Deploy(wallet.GetWalletContext().UtilsAddress, memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		return common.HexToHash("0x1234").Bytes(), nil
+	}))
+	var blocks []string
+	var delay time.Duration
+	chain.Intercept(func(method string, params []json.RawMessage, next func() (interface{}, error)) (interface{}, error) {
+		time.Sleep(delay)
+		if method == "eth_call" {
+			var block string
+			_ = json.Unmarshal(params[1], &block)
+			blocks = append(blocks, block)
+		}
+		return next()
+	})
+	provider := chain.Provider()
+
+	// the provider of a call is not the provider of the wallet
+	_, err = wallet.PublishedImageHash(ctx)
+	assert.ErrorIs(t, err, sequence.ErrProviderNotSet)
+
+	imageHash, err := wallet.PublishedImageHash(sequence.WithCallOpts(ctx, sequence.CallOpts{Provider: provider}))
+	assert.NoError(t, err)
+	assert.Equal(t, common.HexToHash("0x1234"), imageHash)
+	assert.Nil(t, wallet.GetProvider())
+
+	_, err = wallet.PublishedImageHash(sequence.WithCallOpts(ctx, sequence.CallOpts{Provider: provider, BlockNumber: big.NewInt(100)}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"latest", "0x64"}, blocks)
+
+	// nor is its relayer, which signs the bundle with its nonce
+	txns := sequence.Transactions{{To: common.HexToAddress("0x7a7"), RevertOnError: true}}
+	_, err = wallet.SignTransactions(ctx, txns)
+	assert.ErrorIs(t, err, sequence.ErrRelayerNotSet)
+
+	signed, err := wallet.SignTransactions(sequence.WithCallOpts(ctx, sequence.CallOpts{Relayer: &nonceRelayer{nonce: big.NewInt(5)}}), txns)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(5), signed.Nonce)
+	assert.Nil(t, wallet.GetRelayer())
+
+	// a call is bounded by its timeout
+	delay = 100 * time.Millisecond
+	_, err = wallet.PublishedImageHash(sequence.WithCallOpts(ctx, sequence.CallOpts{Provider: provider, Timeout: 10 * time.Millisecond}))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,243 @@
+// Package campaign runs NFT minting campaigns, which mint tokens to the recipients of large
+// allowlists from a Sequence wallet allowed to mint on the token contract.
+//
+// A Runner splits the allowlist into batches, each minted by one bundle of the wallet, and
+// holds the batches back while the base fee of the chain is above a threshold. Once the
+// bundles are executed, their receipts are reconciled with the allowlist, reporting which
+// recipients received their tokens and which are still missing them, so they can be minted by
+// another run:
+//
+//	runner := campaign.NewRunner(wallet, contract, campaign.MintEach(mint), campaign.Options{BatchSize: 200, MaxBaseFee: maxBaseFee})
+//	report, err := runner.Run(ctx, allowlist)
+//	...
+//	report, err = runner.Run(ctx, report.Missing)
+package campaign
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+)
+
+// DefaultBatchSize is the number of recipients minted per bundle by default.
+const DefaultBatchSize = 100
+
+// ErrDuplicateRecipient is returned when a recipient is listed more than once.
+var ErrDuplicateRecipient = errors.New("campaign: recipient is listed more than once")
+
+// Recipient is an address of the allowlist, and the amount of tokens it receives.
+type Recipient struct {
+	Address common.Address `json:"address"`
+
+	// Amount is the number of tokens minted, 1 if nil, ie. the number of ERC-721 tokens or
+	// the value of an ERC-1155 token.
+	Amount *big.Int `json:"amount"`
+}
+
+func (r Recipient) amount() *big.Int {
+	if r.Amount == nil {
+		return big.NewInt(1)
+	}
+	return r.Amount
+}
+
+// Minter encodes the calls minting tokens to recipients.
+type Minter interface {
+	// Mint returns the transactions minting to a batch of recipients, which are sent in one
+	// bundle, ie. one call to a batch mint function of the contract, or a call per recipient.
+	Mint(ctx context.Context, recipients []Recipient) (sequence.Transactions, error)
+}
+
+// MinterFunc adapts a function to a Minter.
+type MinterFunc func(ctx context.Context, recipients []Recipient) (sequence.Transactions, error)
+
+func (f MinterFunc) Mint(ctx context.Context, recipients []Recipient) (sequence.Transactions, error) {
+	return f(ctx, recipients)
+}
+
+// MintEach returns a Minter calling mint for each recipient, for contracts which mint to one
+// recipient per call.
+func MintEach(mint func(recipient Recipient) (*sequence.Transaction, error)) Minter {
+	return MinterFunc(func(ctx context.Context, recipients []Recipient) (sequence.Transactions, error) {
+		txns := make(sequence.Transactions, 0, len(recipients))
+		for _, recipient := range recipients {
+			txn, err := mint(recipient)
+			if err != nil {
+				return nil, err
+			}
+			txns = append(txns, txn)
+		}
+		return txns, nil
+	})
+}
+
+type Options struct {
+	// BatchSize is the number of recipients minted per bundle, DefaultBatchSize by default.
+	BatchSize int
+
+	// MaxBundleGas and MintGas cap the batches to the number of mints of MintGas which fit
+	// in MaxBundleGas, if both are set.
+	MaxBundleGas uint64
+	MintGas      uint64
+
+	// MaxBaseFee holds back batches while the base fee of the latest block is above it. Nil
+	// doesn't throttle.
+	MaxBaseFee *big.Int
+
+	// PollInterval is the interval the base fee is polled at while it's above MaxBaseFee,
+	// 5 seconds by default.
+	PollInterval time.Duration
+
+	// OnThrottle is called with the base fee each time a batch is held back, optional.
+	OnThrottle func(baseFee *big.Int)
+
+	// OnBatch is called with each batch once it's executed or failed, optional.
+	OnBatch func(batch *Batch)
+}
+
+// Batch is a batch of recipients minted by one bundle.
+type Batch struct {
+	Recipients []Recipient
+
+	MetaTxnID sequence.MetaTxnID
+	Status    sequence.MetaTxnStatus
+	Receipt   *types.Receipt
+
+	// Err is why the batch failed to be relayed or executed.
+	Err error
+}
+
+// Report is the outcome of a run.
+type Report struct {
+	Batches []*Batch
+
+	// Received are the recipients which received tokens, with the amount they received, and
+	// Missing those which received less than listed, with the amount they're missing.
+	Received []Recipient
+	Missing  []Recipient
+}
+
+// Runner mints the tokens of a campaign with a wallet.
+type Runner struct {
+	wallet   *sequence.Wallet
+	contract common.Address
+	minter   Minter
+	options  Options
+}
+
+// NewRunner returns a runner minting the tokens of contract with wallet, which must be
+// connected to a provider and a relayer.
+func NewRunner(wallet *sequence.Wallet, contract common.Address, minter Minter, options Options) *Runner {
+	if options.BatchSize <= 0 {
+		options.BatchSize = DefaultBatchSize
+	}
+	if options.MaxBundleGas > 0 && options.MintGas > 0 {
+		if fit := int(options.MaxBundleGas / options.MintGas); fit < options.BatchSize {
+			options.BatchSize = fit
+		}
+		if options.BatchSize < 1 {
+			options.BatchSize = 1
+		}
+	}
+	if options.PollInterval == 0 {
+		options.PollInterval = 5 * time.Second
+	}
+	return &Runner{wallet: wallet, contract: contract, minter: minter, options: options}
+}
+
+// Run mints to recipients, one batch at a time, waiting for each bundle to be executed before
+// relaying the next. A batch which fails doesn't stop the run, and its recipients are reported
+// missing. Batches not relayed once ctx is done fail with its error.
+func (r *Runner) Run(ctx context.Context, recipients []Recipient) (*Report, error) {
+	listed := make(map[common.Address]bool, len(recipients))
+	for _, recipient := range recipients {
+		if listed[recipient.Address] {
+			return nil, fmt.Errorf("%w: %v", ErrDuplicateRecipient, recipient.Address.Hex())
+		}
+		listed[recipient.Address] = true
+	}
+
+	report := &Report{}
+	var receipts []*types.Receipt
+	for start := 0; start < len(recipients); start += r.options.BatchSize {
+		end := start + r.options.BatchSize
+		if end > len(recipients) {
+			end = len(recipients)
+		}
+		batch := &Batch{Recipients: recipients[start:end]}
+		batch.Err = r.mint(ctx, batch)
+		if batch.Receipt != nil {
+			receipts = append(receipts, batch.Receipt)
+		}
+		report.Batches = append(report.Batches, batch)
+		if r.options.OnBatch != nil {
+			r.options.OnBatch(batch)
+		}
+	}
+
+	report.Received, report.Missing = Reconcile(r.contract, recipients, receipts)
+	return report, nil
+}
+
+func (r *Runner) mint(ctx context.Context, batch *Batch) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := r.throttle(ctx); err != nil {
+		return err
+	}
+
+	txns, err := r.minter.Mint(ctx, batch.Recipients)
+	if err != nil {
+		return fmt.Errorf("campaign: failed to encode mints: %w", err)
+	}
+	signed, err := r.wallet.SignTransactions(ctx, txns)
+	if err != nil {
+		return fmt.Errorf("campaign: failed to sign bundle: %w", err)
+	}
+	batch.MetaTxnID, _, _, err = r.wallet.SendTransactions(ctx, signed)
+	if err != nil {
+		return fmt.Errorf("campaign: failed to relay bundle: %w", err)
+	}
+
+	batch.Status, batch.Receipt, err = r.wallet.GetRelayer().Wait(ctx, batch.MetaTxnID)
+	if err != nil {
+		return fmt.Errorf("campaign: failed to wait for %v: %w", batch.MetaTxnID, err)
+	}
+	if batch.Status != sequence.MetaTxnExecuted {
+		return fmt.Errorf("campaign: bundle %v %v", batch.MetaTxnID, batch.Status)
+	}
+	return nil
+}
+
+// throttle waits until the base fee of the latest block is at most MaxBaseFee. Chains without
+// a base fee aren't throttled.
+func (r *Runner) throttle(ctx context.Context) error {
+	if r.options.MaxBaseFee == nil {
+		return nil
+	}
+	for {
+		header, err := r.wallet.GetProvider().HeaderByNumber(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("campaign: failed to get base fee: %w", err)
+		}
+		if header.BaseFee == nil || header.BaseFee.Cmp(r.options.MaxBaseFee) <= 0 {
+			return nil
+		}
+		if r.options.OnThrottle != nil {
+			r.options.OnThrottle(header.BaseFee)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.options.PollInterval):
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package campaign_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/campaign"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+var nft = common.HexToAddress("0x4e4e")
+
+// mintRelayer executes the bundles it relays, logging an ERC-721 mint to the recipient in the
+// data of each transaction, except to skipped recipients.
+type mintRelayer struct {
+	provider *ethrpc.Provider
+	skip     map[common.Address]bool
+	bundles  map[sequence.MetaTxnID]sequence.Transactions
+	mu       sync.Mutex
+}
+
+func (r *mintRelayer) GetProvider() *ethrpc.Provider { return r.provider }
+
+func (r *mintRelayer) EstimateGasLimits(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, txns sequence.Transactions) (sequence.Transactions, error) {
+	return txns, nil
+}
+
+func (r *mintRelayer) GetNonce(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, space *big.Int, blockNum *big.Int) (*big.Int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return big.NewInt(int64(len(r.bundles))), nil
+}
+
+func (r *mintRelayer) Relay(ctx context.Context, signedTxs *sequence.SignedTransactions) (sequence.MetaTxnID, *types.Transaction, ethtxn.WaitReceipt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	metaTxnID := sequence.MetaTxnID(signedTxs.Digest.Hex()[2:])
+	r.bundles[metaTxnID] = signedTxs.Transactions
+	return metaTxnID, nil, nil, nil
+}
+
+func (r *mintRelayer) Wait(ctx context.Context, metaTxnID sequence.MetaTxnID, optTimeout ...time.Duration) (sequence.MetaTxnStatus, *types.Receipt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	receipt := &types.Receipt{Status: types.ReceiptStatusSuccessful}
+	for i, txn := range r.bundles[metaTxnID] {
+		to := common.BytesToAddress(txn.Data)
+		if r.skip[to] {
+			continue
+		}
+		receipt.Logs = append(receipt.Logs, &types.Log{
+			Address: nft,
+			Topics:  []common.Hash{campaign.TransferEventSig, {}, common.BytesToHash(to.Bytes()), common.BigToHash(big.NewInt(int64(i)))},
+		})
+	}
+	return sequence.MetaTxnExecuted, receipt, nil
+}
+
+func (r *mintRelayer) FeeOptions(ctx context.Context, signedTxs *sequence.SignedTransactions) ([]*sequence.FeeOption, error) {
+	return nil, nil
+}
+
+func TestRunner(t *testing.T) {
+	ctx := context.Background()
+
+	chain := memchain.New(memchain.Options{ChainID: big.NewInt(1), BaseFee: big.NewInt(200)})
+	provider := chain.Provider()
+
+	var recipients []campaign.Recipient
+	for i := 1; i <= 5; i++ {
+		recipients = append(recipients, campaign.Recipient{Address: common.BigToAddress(big.NewInt(int64(0xa0 + i)))})
+	}
+	relayer := &mintRelayer{provider: provider, skip: map[common.Address]bool{recipients[3].Address: true}, bundles: map[sequence.MetaTxnID]sequence.Transactions{}}
+
+	key, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(key)
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.Connect(provider, relayer))
+
+	mint := campaign.MintEach(func(recipient campaign.Recipient) (*sequence.Transaction, error) {
+		return &sequence.Transaction{To: nft, Data: recipient.Address.Bytes(), GasLimit: big.NewInt(100000)}, nil
+	})
+
+	// the batches are held back while the base fee is above the threshold
+	var throttled []*big.Int
+	runner := campaign.NewRunner(wallet, nft, mint, campaign.Options{
+		BatchSize:    10,
+		MaxBundleGas: 250000,
+		MintGas:      100000,
+		MaxBaseFee:   big.NewInt(100),
+		PollInterval: time.Millisecond,
+		OnThrottle: func(baseFee *big.Int) {
+			throttled = append(throttled, baseFee)
+			chain.SetBaseFee(big.NewInt(50))
+			chain.Mine()
+		},
+	})
+
+	report, err := runner.Run(ctx, recipients)
+	assert.NoError(t, err)
+	assert.Equal(t, []*big.Int{big.NewInt(200)}, throttled)
+
+	// the recipients are minted in bundles of at most 2 mints, which fit the bundle gas
+	if assert.Len(t, report.Batches, 3) {
+		assert.Len(t, report.Batches[0].Recipients, 2)
+		assert.Len(t, report.Batches[2].Recipients, 1)
+		for _, batch := range report.Batches {
+			assert.NoError(t, batch.Err)
+			assert.Equal(t, sequence.MetaTxnExecuted, batch.Status)
+		}
+	}
+	assert.Len(t, relayer.bundles, 3)
+
+	// the recipient skipped by the contract is missing its token
+	assert.Len(t, report.Received, 4)
+	assert.Equal(t, []campaign.Recipient{{Address: recipients[3].Address, Amount: big.NewInt(1)}}, report.Missing)
+
+	// the missing recipients are minted by another run
+	delete(relayer.skip, recipients[3].Address)
+	report, err = runner.Run(ctx, report.Missing)
+	assert.NoError(t, err)
+	assert.Len(t, report.Batches, 1)
+	assert.Empty(t, report.Missing)
+	assert.Equal(t, []campaign.Recipient{{Address: recipients[3].Address, Amount: big.NewInt(1)}}, report.Received)
+
+	_, err = runner.Run(ctx, []campaign.Recipient{recipients[0], recipients[0]})
+	assert.True(t, errors.Is(err, campaign.ErrDuplicateRecipient), err)
+}
+
+func TestReconcile(t *testing.T) {
+	alice, bob := common.HexToAddress("0xa11ce"), common.HexToAddress("0xb0b")
+	operator := common.BytesToHash(common.HexToAddress("0x0b").Bytes())
+
+	single := &types.Log{
+		Address: nft,
+		Topics:  []common.Hash{campaign.TransferSingleEventSig, operator, {}, common.BytesToHash(alice.Bytes())},
+		Data:    append(common.BigToHash(big.NewInt(1)).Bytes(), common.BigToHash(big.NewInt(3)).Bytes()...),
+	}
+	// TransferBatch(ids [1, 2], values [2, 4]) to bob
+	batchData := common.FromHex("0x" +
+		"0000000000000000000000000000000000000000000000000000000000000040" +
+		"00000000000000000000000000000000000000000000000000000000000000a0" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000004")
+	batch := &types.Log{
+		Address: nft,
+		Topics:  []common.Hash{campaign.TransferBatchEventSig, operator, {}, common.BytesToHash(bob.Bytes())},
+		Data:    batchData,
+	}
+	// a transfer, not a mint, and a mint by another contract
+	transfer := &types.Log{
+		Address: nft,
+		Topics:  []common.Hash{campaign.TransferEventSig, common.BytesToHash(bob.Bytes()), common.BytesToHash(alice.Bytes()), {}},
+	}
+	other := &types.Log{
+		Address: common.HexToAddress("0x07e7"),
+		Topics:  []common.Hash{campaign.TransferEventSig, {}, common.BytesToHash(bob.Bytes()), {}},
+	}
+
+	received, missing := campaign.Reconcile(nft, []campaign.Recipient{
+		{Address: alice, Amount: big.NewInt(5)},
+		{Address: bob, Amount: big.NewInt(6)},
+	}, []*types.Receipt{{Logs: []*types.Log{single, batch, transfer, other}}})
+
+	assert.Equal(t, []campaign.Recipient{{Address: alice, Amount: big.NewInt(3)}, {Address: bob, Amount: big.NewInt(6)}}, received)
+	assert.Equal(t, []campaign.Recipient{{Address: alice, Amount: big.NewInt(2)}}, missing)
+}
@@ -0,0 +1,98 @@
+package campaign
+
+import (
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+var (
+	// TransferEventSig is the topic of ERC-721 transfers, which mint from the zero address.
+	TransferEventSig = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+	// TransferSingleEventSig and TransferBatchEventSig are the topics of ERC-1155 transfers.
+	TransferSingleEventSig = crypto.Keccak256Hash([]byte("TransferSingle(address,address,address,uint256,uint256)"))
+	TransferBatchEventSig  = crypto.Keccak256Hash([]byte("TransferBatch(address,address,address,uint256[],uint256[])"))
+)
+
+var uint256Array, _ = abi.NewType("uint256[]", "", nil)
+
+// Reconcile returns the recipients which received tokens minted by contract in receipts, with
+// the amounts they received, and those which received less than listed, with the amounts
+// they're missing. Both keep the order of recipients, and addresses minted to which are not
+// recipients are ignored.
+func Reconcile(contract common.Address, recipients []Recipient, receipts []*types.Receipt) (received []Recipient, missing []Recipient) {
+	minted := map[common.Address]*big.Int{}
+	for _, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			if log.Address != contract || log.Removed {
+				continue
+			}
+			to, amount := mintOf(log)
+			if amount == nil {
+				continue
+			}
+			if total, ok := minted[to]; ok {
+				total.Add(total, amount)
+			} else {
+				minted[to] = amount
+			}
+		}
+	}
+
+	for _, recipient := range recipients {
+		amount := minted[recipient.Address]
+		if amount == nil {
+			amount = new(big.Int)
+		}
+		if amount.Sign() > 0 {
+			received = append(received, Recipient{Address: recipient.Address, Amount: amount})
+		}
+		if owed := new(big.Int).Sub(recipient.amount(), amount); owed.Sign() > 0 {
+			missing = append(missing, Recipient{Address: recipient.Address, Amount: owed})
+		}
+	}
+	return received, missing
+}
+
+// mintOf returns the recipient and amount of a mint log of ERC-721 or ERC-1155, or a nil
+// amount if log is not a mint.
+func mintOf(log *types.Log) (common.Address, *big.Int) {
+	switch {
+	case len(log.Topics) == 4 && log.Topics[0] == TransferEventSig:
+		if log.Topics[1] != (common.Hash{}) {
+			return common.Address{}, nil
+		}
+		return common.BytesToAddress(log.Topics[2][:]), big.NewInt(1)
+
+	case len(log.Topics) == 4 && log.Topics[0] == TransferSingleEventSig:
+		if log.Topics[2] != (common.Hash{}) || len(log.Data) != 64 {
+			return common.Address{}, nil
+		}
+		return common.BytesToAddress(log.Topics[3][:]), new(big.Int).SetBytes(log.Data[32:])
+
+	case len(log.Topics) == 4 && log.Topics[0] == TransferBatchEventSig:
+		if log.Topics[2] != (common.Hash{}) {
+			return common.Address{}, nil
+		}
+		values, err := abi.Arguments{{Type: uint256Array}, {Type: uint256Array}}.Unpack(log.Data)
+		if err != nil || len(values) != 2 {
+			return common.Address{}, nil
+		}
+		amounts, ok := values[1].([]*big.Int)
+		if !ok {
+			return common.Address{}, nil
+		}
+		total := new(big.Int)
+		for _, amount := range amounts {
+			total.Add(total, amount)
+		}
+		return common.BytesToAddress(log.Topics[3][:]), total
+
+	default:
+		return common.Address{}, nil
+	}
+}
@@ -0,0 +1,212 @@
+package sequence
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// maxSafeJSONInteger is the largest integer which all JSON implementations decode exactly,
+// as numbers are IEEE 754 doubles in JavaScript.
+const maxSafeJSONInteger = 1<<53 - 1
+
+// CanonicalJSON encodes v as canonical JSON, following RFC 8785 (JCS): object keys are
+// sorted, numbers use the shortest ECMAScript encoding, and there is no insignificant
+// whitespace. Payloads signed or hashed off-chain (intents, webhooks, approvals) must be
+// canonicalized, so their signatures verify in any language or library version.
+//
+// v is first encoded with encoding/json, so its json tags and MarshalJSON methods apply.
+// Integers which cannot be represented exactly by a double, such as wei amounts, are
+// rejected and must be encoded as strings.
+func CanonicalJSON(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("sequence: canonical json: %w", err)
+	}
+	return CanonicalizeJSON(data)
+}
+
+// CanonicalizeJSON re-encodes the JSON document data as canonical JSON.
+func CanonicalizeJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, fmt.Errorf("sequence: canonical json: %w", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("sequence: canonical json: unexpected data after document")
+	}
+
+	var buf bytes.Buffer
+	if err := writeCanonicalJSON(&buf, value); err != nil {
+		return nil, fmt.Errorf("sequence: canonical json: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// CanonicalJSONDigest is the keccak256 hash of the canonical JSON encoding of v.
+func CanonicalJSONDigest(v interface{}) (common.Hash, error) {
+	data, err := CanonicalJSON(v)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(data), nil
+}
+
+// SignJSON signs the canonical JSON encoding of v as a message.
+func (w *Wallet) SignJSON(v interface{}) ([]byte, *Signature, error) {
+	data, err := CanonicalJSON(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	return w.SignMessage(data)
+}
+
+func writeCanonicalJSON(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case string:
+		writeCanonicalString(buf, v)
+	case json.Number:
+		n, err := canonicalNumber(v)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(n)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonicalJSON(buf, elem); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		// keys are sorted by their UTF-16 code units, as in JavaScript
+		sort.Slice(keys, func(i, j int) bool {
+			return lessUTF16(keys[i], keys[j])
+		})
+
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, key)
+			buf.WriteByte(':')
+			if err := writeCanonicalJSON(buf, v[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("unexpected value of type %T", value)
+	}
+	return nil
+}
+
+// canonicalNumber formats n as ECMAScript's Number.prototype.toString does.
+func canonicalNumber(n json.Number) (string, error) {
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", fmt.Errorf("number %s is out of range", n)
+	}
+	if !strings.ContainsAny(string(n), ".eE") && math.Abs(f) > maxSafeJSONInteger {
+		return "", fmt.Errorf("integer %s cannot be represented exactly, encode it as a string", n)
+	}
+	if f == 0 {
+		return "0", nil
+	}
+
+	sign := ""
+	if f < 0 {
+		sign, f = "-", -f
+	}
+
+	// shortest round-tripping digits, and the decimal exponent of the first digit
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	e, _ := strconv.Atoi(exp)
+	k, point := len(digits), e+1
+
+	switch {
+	case k <= point && point <= 21:
+		return sign + digits + strings.Repeat("0", point-k), nil
+	case 0 < point && point <= 21:
+		return sign + digits[:point] + "." + digits[point:], nil
+	case -6 < point && point <= 0:
+		return sign + "0." + strings.Repeat("0", -point) + digits, nil
+	}
+
+	s := digits[:1]
+	if k > 1 {
+		s += "." + digits[1:]
+	}
+	if e >= 0 {
+		return sign + s + "e+" + strconv.Itoa(e), nil
+	}
+	return sign + s + "e" + strconv.Itoa(e), nil
+}
+
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hex[r>>4])
+				buf.WriteByte(hex[r&0xf])
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
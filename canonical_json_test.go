@@ -0,0 +1,69 @@
+package sequence_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{`{"b": 1, "a": [true, null, "x"]}`, `{"a":[true,null,"x"],"b":1}`},
+		{`{"\u20ac": 1, "\r": 2, "1": 3, "\ud83d\ude00": 4, "\ufb33": 5}`, "{\"\\r\":2,\"1\":3,\"\u20ac\":1,\"\U0001f600\":4,\"\ufb33\":5}"},
+		{`[1.0, -0, 1e21, 1e20, 0.000001, 1e-7, 333333333.33333329, 1E30, 4.50, 2e-3, -1.5e-10]`, `[1,0,1e+21,100000000000000000000,0.000001,1e-7,333333333.3333333,1e+30,4.5,0.002,-1.5e-10]`},
+		{`"<a href=\"x\">\u0001\t\u007f </a>"`, "\"<a href=\\\"x\\\">\\u0001\\t\u007f </a>\""},
+	}
+	for _, test := range tests {
+		out, err := sequence.CanonicalizeJSON([]byte(test.in))
+		assert.NoError(t, err)
+		assert.Equal(t, test.out, string(out))
+	}
+
+	// integers beyond 2^53 are ambiguous across languages, and must be encoded as strings
+	_, err := sequence.CanonicalizeJSON([]byte(`{"value": 9007199254740993}`))
+	assert.Error(t, err)
+	_, err = sequence.CanonicalizeJSON([]byte(`{} {}`))
+	assert.Error(t, err)
+
+	type intent struct {
+		Wallet common.Address `json:"wallet"`
+		Value  *big.Int       `json:"value,string"`
+		Nonce  uint64         `json:"nonce"`
+	}
+	out, err := sequence.CanonicalJSON(map[string]interface{}{
+		"intent":  intent{Wallet: common.HexToAddress("0x01"), Value: big.NewInt(10), Nonce: 7},
+		"expires": json.Number("1700000000"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"expires":1700000000,"intent":{"nonce":7,"value":10,"wallet":"0x0000000000000000000000000000000000000001"}}`, string(out))
+}
+
+func TestSignJSON(t *testing.T) {
+	eoa, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+
+	wallet, err := sequence.NewWalletSingleOwner(eoa)
+	assert.NoError(t, err)
+	wallet.SetChainID(big.NewInt(1))
+
+	// payloads with the same content sign the same, regardless of key order
+	a, _, err := wallet.SignJSON(map[string]interface{}{"a": 1, "b": "2"})
+	assert.NoError(t, err)
+	b, _, err := wallet.SignJSON(json.RawMessage(`{ "b": "2", "a": 1.0 }`))
+	assert.NoError(t, err)
+	assert.Equal(t, a, b)
+
+	digest, err := sequence.CanonicalJSONDigest(json.RawMessage(`{ "b": "2", "a": 1.0 }`))
+	assert.NoError(t, err)
+	expected, err := sequence.CanonicalJSONDigest(map[string]interface{}{"b": "2", "a": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, digest)
+}
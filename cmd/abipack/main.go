@@ -0,0 +1,197 @@
+// Command abipack generates typed calldata packers for the methods of a contract artifact,
+// next to its abigen bindings: a method execute(Transaction[],uint256,bytes) of WalletMain
+// is packed by WalletMainCalldata.Execute(_txs []IModuleCallsTransaction, _nonce *big.Int,
+// _signature []byte), instead of by name with WalletMainModule.Encode("execute", ...).
+//
+// The packers use the MetaData and tuple structs of the abigen bindings of the same type, so
+// they must be generated in the same package, see contracts/gen.
+//
+//	abipack -pkg=walletmain -type=WalletMain -outFile=./walletmain/wallet_main_module.calldata.gen.go -artifactsFile=...
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/0xsequence/ethkit/ethartifact"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+)
+
+func main() {
+	artifactsFile := flag.String("artifactsFile", "", "path to the contract artifacts file")
+	pkg := flag.String("pkg", "", "package of the abigen bindings")
+	typ := flag.String("type", "", "type of the abigen bindings")
+	outFile := flag.String("outFile", "", "path to write the packers to, defaults to stdout")
+	flag.Parse()
+
+	if err := run(*artifactsFile, *pkg, *typ, *outFile); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(artifactsFile, pkg, typ, outFile string) error {
+	if artifactsFile == "" || pkg == "" || typ == "" {
+		return fmt.Errorf("abipack: -artifactsFile, -pkg and -type are required")
+	}
+
+	artifact, err := ethartifact.ParseArtifactFile(artifactsFile)
+	if err != nil {
+		return fmt.Errorf("abipack: %w", err)
+	}
+	contractABI, err := abi.JSON(bytes.NewReader(artifact.ABI))
+	if err != nil {
+		return fmt.Errorf("abipack: %w", err)
+	}
+
+	code, err := generate(contractABI, pkg, typ)
+	if err != nil {
+		return err
+	}
+
+	if outFile == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(outFile, code, 0644)
+}
+
+func generate(contractABI abi.ABI, pkg, typ string) ([]byte, error) {
+	names := make([]string, 0, len(contractABI.Methods))
+	for name := range contractABI.Methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	imports := map[string]bool{}
+	var methods bytes.Buffer
+	for _, name := range names {
+		method := contractABI.Methods[name]
+
+		var params, args []string
+		for i, input := range method.Inputs {
+			param := paramName(input.Name, i)
+			goType, err := goTypeOf(input.Type, imports)
+			if err != nil {
+				return nil, fmt.Errorf("abipack: %s: %w", method.Sig, err)
+			}
+			params = append(params, param+" "+goType)
+			args = append(args, param)
+		}
+
+		fmt.Fprintf(&methods, "\n// %s packs the calldata of %s.\n", abi.ToCamelCase(name), method.Sig)
+		fmt.Fprintf(&methods, "func (%sCalldataPacker) %s(%s) ([]byte, error) {\n", typ, abi.ToCamelCase(name), strings.Join(params, ", "))
+		fmt.Fprintf(&methods, "\treturn pack%sCalldata(%q%s)\n}\n", typ, name, prefixEach(", ", args))
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by abipack - DO NOT EDIT.\n")
+	fmt.Fprintf(&out, "// This file is a generated calldata packer and any manual changes will be lost.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", pkg)
+	if len(imports) > 0 {
+		fmt.Fprintf(&out, "import (\n")
+		for _, stdlib := range []bool{true, false} {
+			for _, path := range sortedKeys(imports) {
+				if isStdlib(path) == stdlib {
+					fmt.Fprintf(&out, "\t%q\n", path)
+				}
+			}
+			fmt.Fprintf(&out, "\n")
+		}
+		fmt.Fprintf(&out, ")\n\n")
+	}
+	fmt.Fprintf(&out, "// %sCalldata packs the calldata of the methods of the %s contract.\n", typ, typ)
+	fmt.Fprintf(&out, "var %sCalldata %sCalldataPacker\n\n", typ, typ)
+	fmt.Fprintf(&out, "// %sCalldataPacker is the type of %sCalldata.\n", typ, typ)
+	fmt.Fprintf(&out, "type %sCalldataPacker struct{}\n\n", typ)
+	fmt.Fprintf(&out, "func pack%sCalldata(method string, args ...interface{}) ([]byte, error) {\n", typ)
+	fmt.Fprintf(&out, "\tparsed, err := %sMetaData.GetAbi()\n", typ)
+	fmt.Fprintf(&out, "\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	fmt.Fprintf(&out, "\treturn parsed.Pack(method, args...)\n}\n")
+	out.Write(methods.Bytes())
+
+	code, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("abipack: generated invalid code: %w", err)
+	}
+	return code, nil
+}
+
+// goTypeOf returns the go type of an abi type, as bound by abigen.
+func goTypeOf(t abi.Type, imports map[string]bool) (string, error) {
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		prefix := "int"
+		if t.T == abi.UintTy {
+			prefix = "uint"
+		}
+		switch t.Size {
+		case 8, 16, 32, 64:
+			return fmt.Sprintf("%s%d", prefix, t.Size), nil
+		}
+		imports["math/big"] = true
+		return "*big.Int", nil
+	case abi.BoolTy:
+		return "bool", nil
+	case abi.StringTy:
+		return "string", nil
+	case abi.AddressTy:
+		imports["github.com/0xsequence/ethkit/go-ethereum/common"] = true
+		return "common.Address", nil
+	case abi.BytesTy:
+		return "[]byte", nil
+	case abi.FixedBytesTy:
+		return fmt.Sprintf("[%d]byte", t.Size), nil
+	case abi.FunctionTy:
+		return "[24]byte", nil
+	case abi.SliceTy:
+		elem, err := goTypeOf(*t.Elem, imports)
+		return "[]" + elem, err
+	case abi.ArrayTy:
+		elem, err := goTypeOf(*t.Elem, imports)
+		return fmt.Sprintf("[%d]%s", t.Size, elem), err
+	case abi.TupleTy:
+		if t.TupleRawName == "" {
+			return "", fmt.Errorf("tuple %s has no struct name", t.String())
+		}
+		return abi.ToCamelCase(t.TupleRawName), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", t.String())
+}
+
+func paramName(name string, index int) string {
+	if name == "" {
+		return fmt.Sprintf("arg%d", index)
+	}
+	if token.IsKeyword(name) {
+		return "_" + name
+	}
+	return name
+}
+
+func prefixEach(prefix string, values []string) string {
+	var s string
+	for _, v := range values {
+		s += prefix + v
+	}
+	return s
+}
+
+func isStdlib(path string) bool {
+	return !strings.Contains(strings.SplitN(path, "/", 2)[0], ".")
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
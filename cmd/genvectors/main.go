@@ -0,0 +1,81 @@
+// Command genvectors emits the canonical test vectors of go-sequence (see package
+// conformance): wallet configs, digests, meta-transaction IDs and signatures, generated
+// from fixed keys so they are reproducible. Run it after upgrading the wallet contracts
+// to regenerate the vectors of the conformance suite.
+//
+//	genvectors -out conformance/testdata/vectors.json
+//	genvectors -check conformance/testdata/vectors.json
+//
+// With -gas, it emits the gas vectors instead: the calldata and intrinsic gas of canonical
+// operations, see conformance.GenerateGas.
+//
+//	genvectors -gas -out conformance/testdata/gas.json
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/conformance"
+)
+
+func main() {
+	out := flag.String("out", "", "path to write the vectors to, defaults to stdout")
+	check := flag.String("check", "", "path of vectors to verify are reproduced, instead of generating")
+	gas := flag.Bool("gas", false, "emit the gas vectors instead of the conformance vectors")
+	flag.Parse()
+
+	if err := run(*out, *check, *gas); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(out, check string, gas bool) error {
+	var vectors interface{}
+	if gas {
+		gasVectors, err := conformance.GenerateGas(sequence.SequenceContext())
+		if err != nil {
+			return err
+		}
+		if err := conformance.VerifyGas(gasVectors); err != nil {
+			return fmt.Errorf("genvectors: generated gas vectors do not verify: %w", err)
+		}
+		vectors = gasVectors
+	} else {
+		conformanceVectors, err := conformance.Generate(sequence.SequenceContext())
+		if err != nil {
+			return err
+		}
+		if err := conformance.Verify(conformanceVectors); err != nil {
+			return fmt.Errorf("genvectors: generated vectors do not verify: %w", err)
+		}
+		vectors = conformanceVectors
+	}
+	data, err := json.MarshalIndent(vectors, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if check != "" {
+		existing, err := os.ReadFile(check)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(existing, data) {
+			return fmt.Errorf("genvectors: %s is out of date, regenerate it with -out", check)
+		}
+		return nil
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(out, data, 0644)
+}
@@ -0,0 +1,261 @@
+// Command relayerd is a reference relayer server. It dispatches the meta-transactions of
+// its tenants with a LocalRelayer, and serves them over the same webrpc protocol as the
+// hosted Sequence relayer, so clients use it through relayer.RpcRelayer.
+//
+// It is configured by a config file (see package config), which is reloaded on SIGHUP or
+// when it changes, and is meant both as a starting point for production deployments and as
+// a target for end-to-end tests.
+//
+//	relayerd -config relayerd.yml
+package main
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"flag"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethmonitor"
+	"github.com/0xsequence/ethkit/ethreceipts"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/config"
+	"github.com/0xsequence/go-sequence/relayer"
+	"github.com/0xsequence/go-sequence/relayer/proto"
+	"github.com/0xsequence/go-sequence/relayer/server"
+	"github.com/goware/logger"
+)
+
+const (
+	defaultListen      = ":8080"
+	defaultAdminListen = "127.0.0.1:8081"
+
+	// shutdownTimeout bounds how long in-flight requests are waited for on shutdown.
+	shutdownTimeout = 30 * time.Second
+)
+
+func main() {
+	configPath := flag.String("config", "relayerd.yml", "path to the config file")
+	flag.Parse()
+
+	log := logger.NewLogger(logger.LogLevel_INFO)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, *configPath, log); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, configPath string, log logger.Logger) error {
+	metrics := newExpvarMetrics()
+	opts := []sequence.Option{sequence.WithLogger(log), sequence.WithMetrics(metrics)}
+
+	watcher, err := config.NewWatcher(configPath, opts...)
+	if err != nil {
+		return err
+	}
+	cfg := watcher.Config()
+	if cfg.Relayer.Mode == "rpc" {
+		return fmt.Errorf("relayerd: relayer.mode must be local")
+	}
+	opts = append(cfg.Options(), opts...)
+
+	provider, err := ethrpc.NewProvider(cfg.Provider.URL)
+	if err != nil {
+		return fmt.Errorf("relayerd: invalid provider: %w", err)
+	}
+	if cfg.Provider.ChainID != 0 {
+		chainID, err := provider.ChainID(ctx)
+		if err != nil {
+			return fmt.Errorf("relayerd: failed to get chain id: %w", err)
+		}
+		if chainID.Uint64() != cfg.Provider.ChainID {
+			return fmt.Errorf("relayerd: provider is on chain %v, expected %d", chainID, cfg.Provider.ChainID)
+		}
+	}
+
+	monitorOptions := ethmonitor.DefaultOptions
+	monitorOptions.Logger = log
+	monitorOptions.WithLogs = true // receipts listener needs logs from monitor
+	if cfg.Provider.PollingInterval > 0 {
+		monitorOptions.PollingInterval = cfg.Provider.PollingInterval
+	}
+	if cfg.Provider.BlockRetentionLimit > 0 {
+		monitorOptions.BlockRetentionLimit = cfg.Provider.BlockRetentionLimit
+	}
+	monitor, err := ethmonitor.NewMonitor(provider, monitorOptions)
+	if err != nil {
+		return fmt.Errorf("relayerd: failed to create monitor: %w", err)
+	}
+
+	receipts, err := ethreceipts.NewReceiptsListener(log, provider, monitor, ethreceipts.DefaultOptions)
+	if err != nil {
+		return fmt.Errorf("relayerd: failed to create receipts listener: %w", err)
+	}
+
+	senders, err := cfg.Senders()
+	if err != nil {
+		return err
+	}
+	for _, sender := range senders {
+		sender.SetProvider(provider)
+	}
+
+	var sender *ethwallet.Wallet
+	if cfg.Relayer.SenderKey != "" {
+		sender, err = ethwallet.NewWalletFromPrivateKey(strings.TrimPrefix(cfg.Relayer.SenderKey.Value(), "0x"))
+		if err != nil {
+			return fmt.Errorf("relayerd: invalid relayer.sender_key: %w", err)
+		}
+		sender.SetProvider(provider)
+	} else {
+		sender = senders[0]
+	}
+
+	relayers, err := relayer.NewMultiTenantRelayer(sender, receipts, opts...)
+	if err != nil {
+		return err
+	}
+	relayers.SetAccountant(relayer.NewAccountant(relayer.NewMemoryUsageStore()))
+
+	if len(senders) > 0 {
+		pool, err := relayer.NewSenderPool(senders, opts...)
+		if err != nil {
+			return err
+		}
+		if cfg.Relayer.MinSenderBalance > 0 {
+			pool.SetLowBalance(new(big.Int).SetUint64(cfg.Relayer.MinSenderBalance), nil)
+		}
+		if cfg.Relayer.TreasuryKey != "" {
+			treasury, err := ethwallet.NewWalletFromPrivateKey(strings.TrimPrefix(cfg.Relayer.TreasuryKey.Value(), "0x"))
+			if err != nil {
+				return fmt.Errorf("relayerd: invalid relayer.treasury_key: %w", err)
+			}
+			treasury.SetProvider(provider)
+			pool.SetTopUp(relayer.TreasuryTopUp(treasury, new(big.Int).SetUint64(cfg.Relayer.TopUpAmount)))
+		}
+		relayers.SetSenderPool(pool)
+	}
+
+	private, err := cfg.PrivateSubmission()
+	if err != nil {
+		return err
+	}
+	relayers.SetPrivateSubmission(private)
+
+	tenants, err := cfg.Tenants()
+	if err != nil {
+		return err
+	}
+	for _, tenant := range tenants {
+		if err := relayers.AddTenant(tenant); err != nil {
+			return fmt.Errorf("relayerd: failed to add tenant %q: %w", tenant.ID, err)
+		}
+	}
+	log.Infof("relayerd: serving %d tenants with sender %v and %d pooled senders", len(tenants), sender.Address(), len(senders))
+
+	// stuck, dropped and superseded transactions of each tenant are healed in the background
+	components := []sequence.Runnable{monitor, receipts, watcher}
+	for _, tenantID := range relayers.Tenants() {
+		r, err := relayers.Relayer(tenantID)
+		if err != nil {
+			return err
+		}
+		components = append(components, relayer.NewNonceGapMonitor(r, opts...))
+	}
+
+	// policies and limits are hot-reloaded, tenants and keys require a restart
+	watcher.OnReload(func(cfg *config.Config) {
+		tenants, err := cfg.Tenants()
+		if err != nil {
+			log.Warnf("relayerd: ignoring reloaded tenants: %v", err)
+			return
+		}
+		for _, tenant := range tenants {
+			r, err := relayers.Relayer(tenant.ID)
+			if err != nil {
+				log.Warnf("relayerd: tenant %q was added to the config, restart to serve it", tenant.ID)
+				continue
+			}
+			r.SetPolicy(tenant.Policy)
+			relayers.Accountant().SetProjectLimits(tenant.ID, tenant.Limits)
+		}
+	})
+
+	verifier := server.NewRequestVerifier(relayers, cfg.Server.RequireSignedRequests)
+
+	mux := http.NewServeMux()
+	mux.Handle(proto.RelayerPathPrefix, verifier.Middleware(server.NewServer(relayers, cfg.WalletContext(), opts...)))
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	listen := cfg.Server.Listen
+	if listen == "" {
+		listen = defaultListen
+	}
+	httpServer := &http.Server{
+		Addr:              listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	httpServers := []*http.Server{httpServer}
+	if cfg.Server.AdminToken != "" {
+		adminListen := cfg.Server.AdminListen
+		if adminListen == "" {
+			adminListen = defaultAdminListen
+		}
+		httpServers = append(httpServers, &http.Server{
+			Addr:              adminListen,
+			Handler:           server.NewAdminServer(relayers, cfg.Server.AdminToken.Value(), opts...),
+			ReadHeaderTimeout: 10 * time.Second,
+		})
+	}
+
+	errCh := make(chan error, len(components)+len(httpServers))
+	for _, component := range components {
+		go func(component sequence.Runnable) {
+			if err := component.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				errCh <- fmt.Errorf("relayerd: %T stopped: %w", component, err)
+			}
+		}(component)
+	}
+	for _, httpServer := range httpServers {
+		go func(httpServer *http.Server) {
+			log.Infof("relayerd: listening on %s", httpServer.Addr)
+			if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errCh <- fmt.Errorf("relayerd: http server: %w", err)
+			}
+		}(httpServer)
+	}
+
+	select {
+	case <-ctx.Done():
+		err = nil
+	case err = <-errCh:
+	}
+
+	log.Info("relayerd: shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	for _, httpServer := range httpServers {
+		if shutdownErr := httpServer.Shutdown(shutdownCtx); shutdownErr != nil && err == nil {
+			err = shutdownErr
+		}
+	}
+	if _, shutdownErr := sequence.Shutdown(shutdownCtx, components...); shutdownErr != nil && err == nil {
+		err = shutdownErr
+	}
+	return err
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"expvar"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/go-sequence"
+)
+
+// expvarMetrics publishes the metrics of the relayer at /debug/vars. Counters are published
+// under "counters", and durations as their count and total milliseconds under "durations".
+// Labels are folded into the metric name, ie. `relayer.relay{tenant=app-1}`.
+type expvarMetrics struct {
+	counters  *expvar.Map
+	durations *expvar.Map
+	labels    string
+}
+
+var _ sequence.LabeledMetrics = &expvarMetrics{}
+
+func newExpvarMetrics() *expvarMetrics {
+	return &expvarMetrics{
+		counters:  expvar.NewMap("counters"),
+		durations: expvar.NewMap("durations"),
+	}
+}
+
+func (m *expvarMetrics) IncCounter(name string) {
+	m.counters.Add(name+m.labels, 1)
+}
+
+func (m *expvarMetrics) ObserveDuration(name string, d time.Duration) {
+	m.durations.Add(name+m.labels+".count", 1)
+	m.durations.Add(name+m.labels+".ms", d.Milliseconds())
+}
+
+func (m *expvarMetrics) WithLabels(labels map[string]string) sequence.Metrics {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return &expvarMetrics{
+		counters:  m.counters,
+		durations: m.durations,
+		labels:    "{" + strings.Join(pairs, ",") + "}",
+	}
+}
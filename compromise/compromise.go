@@ -0,0 +1,167 @@
+// Package compromise responds to the compromise of a signer key: it finds the wallets the
+// key is a signer of, plans the rotation of the key out of their configs, prioritized by the
+// balance at risk, and relays the rotations with the remaining signers, reporting progress.
+//
+//	affected, err := compromise.FindAffected(ctx, book, configs, key)
+//	err = compromise.Prioritize(ctx, affected, compromise.NativeBalance(provider))
+//	compromise.Plan(affected, key, replacement)
+//	results := compromise.Rotate(ctx, affected, compromise.RotateOptions{...})
+package compromise
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/tracker"
+)
+
+// ErrCannotRotate is the error of a wallet whose rotation can't be planned or relayed.
+var ErrCannotRotate = errors.New("compromise: cannot rotate")
+
+// Affected is a wallet which the compromised key is a signer of.
+type Affected struct {
+	Wallet common.Address
+
+	// Config is the current config of the wallet, and ImageHash its image hash.
+	Config    sequence.WalletConfig
+	ImageHash common.Hash
+
+	// Updated is set if the wallet has a config other than the one it was deployed with.
+	Updated bool
+
+	// Power is the power of the compromised key over the wallet.
+	Power sequence.SignerPower
+
+	// Balance is the balance at risk in the wallet, set by Prioritize.
+	Balance *big.Int
+
+	// Rotated is the config the wallet is rotated to, set by Plan, and Err the reason it
+	// can't be rotated.
+	Rotated *sequence.WalletConfig
+	Err     error
+}
+
+// FindAffected returns the wallets of book which the compromised key is a signer of, as of
+// their current configs resolved by configs. Wallets whose config is unknown to configs are
+// skipped, and the most exposed wallets are first: those the key signs for alone, then those
+// which can't sign without it.
+func FindAffected(ctx context.Context, book *sequence.AddressBook, configs tracker.ConfigTracker, compromised common.Address) ([]*Affected, error) {
+	entries := book.Entries()
+	walletConfigs := make(map[common.Address]sequence.WalletConfig, len(entries))
+	affected := map[common.Address]*Affected{}
+
+	for _, entry := range entries {
+		config, err := configs.ConfigOfImageHash(ctx, entry.ImageHash)
+		if errors.Is(err, tracker.ErrConfigNotFound) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("compromise: config of %v: %w", entry.Wallet.Hex(), err)
+		}
+		if _, ok := config.Signers.GetWeightByAddress(compromised); !ok {
+			continue
+		}
+		walletConfigs[entry.Wallet] = *config
+		affected[entry.Wallet] = &Affected{
+			Wallet:    entry.Wallet,
+			Config:    *config,
+			ImageHash: entry.ImageHash,
+			Updated:   entry.ImageHash != entry.InitialImageHash,
+		}
+	}
+
+	exposure := sequence.AnalyzeSigners(walletConfigs).Signer(compromised)
+	if exposure == nil {
+		return nil, nil
+	}
+	results := make([]*Affected, 0, len(exposure.Wallets))
+	for _, power := range exposure.Wallets {
+		affected[power.Wallet].Power = power
+		results = append(results, affected[power.Wallet])
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i].Power, results[j].Power
+		if a.SoleSigner != b.SoleSigner {
+			return a.SoleSigner
+		}
+		return a.Critical && !b.Critical
+	})
+	return results, nil
+}
+
+// BalanceFunc returns the balance at risk in a wallet, in a unit common to all wallets.
+type BalanceFunc func(ctx context.Context, wallet common.Address) (*big.Int, error)
+
+// NativeBalance returns a BalanceFunc of the balance of the native token of wallets.
+func NativeBalance(provider *ethrpc.Provider) BalanceFunc {
+	return func(ctx context.Context, wallet common.Address) (*big.Int, error) {
+		return provider.BalanceAt(ctx, wallet, nil)
+	}
+}
+
+// Prioritize sets the balances at risk of affected, and sorts them by balance, the highest
+// first. Wallets of equal balance keep their order.
+func Prioritize(ctx context.Context, affected []*Affected, balance BalanceFunc) error {
+	for _, a := range affected {
+		var err error
+		a.Balance, err = balance(ctx, a.Wallet)
+		if err != nil {
+			return fmt.Errorf("compromise: balance of %v: %w", a.Wallet.Hex(), err)
+		}
+	}
+	sort.SliceStable(affected, func(i, j int) bool {
+		return affected[i].Balance.Cmp(affected[j].Balance) > 0
+	})
+	return nil
+}
+
+// Plan sets the configs affected are rotated to: the compromised key is replaced by
+// replacement with the same weight, or removed if replacement is the zero address. A wallet
+// whose other signers don't reach its threshold once the key is removed can't be rotated, and
+// its Err is set.
+func Plan(affected []*Affected, compromised common.Address, replacement common.Address) {
+	for _, a := range affected {
+		rotated, err := RotatedConfig(a.Config, compromised, replacement)
+		if err != nil {
+			a.Err = err
+			continue
+		}
+		a.Rotated = &rotated
+	}
+}
+
+// RotatedConfig returns config with compromised replaced by replacement, or removed if
+// replacement is the zero address.
+func RotatedConfig(config sequence.WalletConfig, compromised common.Address, replacement common.Address) (sequence.WalletConfig, error) {
+	rotated := sequence.WalletConfig{Threshold: config.Threshold}
+	var total uint64
+	for _, signer := range config.Signers {
+		if signer.Address == compromised {
+			if replacement == (common.Address{}) {
+				continue
+			}
+			signer.Address = replacement
+		}
+		rotated.Signers = append(rotated.Signers, signer)
+		total += uint64(signer.Weight)
+	}
+	if total < uint64(rotated.Threshold) {
+		return sequence.WalletConfig{}, fmt.Errorf("%w: the other signers don't reach the threshold of %d without %v", ErrCannotRotate, config.Threshold, compromised.Hex())
+	}
+	if err := sequence.SortWalletConfig(rotated); err != nil {
+		return sequence.WalletConfig{}, err
+	}
+	return rotated, nil
+}
+
+// RotationTransactions returns the transactions of a wallet which update its config to
+// config: the wallet is upgraded to the main module upgradable of walletContext, which stores
+// its image hash, and the image hash is updated.
+func RotationTransactions(wallet common.Address, walletContext sequence.WalletContext, config sequence.WalletConfig) (sequence.Transactions, error) {
+	return sequence.UpdateConfigTransactions(wallet, walletContext, config, false)
+}
@@ -0,0 +1,128 @@
+package compromise_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/compromise"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/0xsequence/go-sequence/tracker"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRelayer struct {
+	provider *ethrpc.Provider
+	relayed  []*sequence.SignedTransactions
+}
+
+func (r *fakeRelayer) GetProvider() *ethrpc.Provider { return r.provider }
+
+func (r *fakeRelayer) EstimateGasLimits(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, txns sequence.Transactions) (sequence.Transactions, error) {
+	return txns, nil
+}
+
+func (r *fakeRelayer) GetNonce(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, space *big.Int, blockNum *big.Int) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (r *fakeRelayer) Relay(ctx context.Context, signedTxs *sequence.SignedTransactions) (sequence.MetaTxnID, *types.Transaction, ethtxn.WaitReceipt, error) {
+	r.relayed = append(r.relayed, signedTxs)
+	return sequence.MetaTxnID(signedTxs.Digest.Hex()[2:]), nil, nil, nil
+}
+
+func (r *fakeRelayer) Wait(ctx context.Context, metaTxnID sequence.MetaTxnID, optTimeout ...time.Duration) (sequence.MetaTxnStatus, *types.Receipt, error) {
+	return sequence.MetaTxnExecuted, nil, nil
+}
+
+func (r *fakeRelayer) FeeOptions(ctx context.Context, signedTxs *sequence.SignedTransactions) ([]*sequence.FeeOption, error) {
+	return nil, nil
+}
+
+func TestKeyCompromise(t *testing.T) {
+	ctx := context.Background()
+	walletContext := sequence.SequenceContext()
+
+	keys := make([]*ethwallet.Wallet, 3)
+	for i := range keys {
+		var err error
+		keys[i], err = ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(uint64(i + 1)))
+		assert.NoError(t, err)
+	}
+	compromised, trusted, replacement := keys[0], keys[1], keys[2]
+
+	// a wallet the key signs alone, a 1 of 2 with a trusted key, and a wallet without the key
+	configs := []sequence.WalletConfig{
+		{Threshold: 1, Signers: sequence.WalletConfigSigners{{Weight: 1, Address: compromised.Address()}}},
+		{Threshold: 1, Signers: sequence.WalletConfigSigners{{Weight: 1, Address: compromised.Address()}, {Weight: 1, Address: trusted.Address()}}},
+		{Threshold: 1, Signers: sequence.WalletConfigSigners{{Weight: 1, Address: trusted.Address()}}},
+	}
+	book := sequence.NewAddressBook(walletContext)
+	local := tracker.NewLocal(nil)
+	wallets := make([]common.Address, len(configs))
+	for i, config := range configs {
+		assert.NoError(t, sequence.SortWalletConfig(config))
+		imageHash, err := local.ImportConfig(ctx, config)
+		assert.NoError(t, err)
+		wallets[i], err = book.AddDeployment(imageHash, 1)
+		assert.NoError(t, err)
+	}
+
+	affected, err := compromise.FindAffected(ctx, book, local, compromised.Address())
+	assert.NoError(t, err)
+	assert.Len(t, affected, 2)
+	assert.Equal(t, wallets[0], affected[0].Wallet)
+	assert.True(t, affected[0].Power.SoleSigner)
+
+	// the 1 of 2 holds more at risk
+	balances := map[common.Address]int64{wallets[0]: 1, wallets[1]: 100}
+	err = compromise.Prioritize(ctx, affected, func(ctx context.Context, wallet common.Address) (*big.Int, error) {
+		return big.NewInt(balances[wallet]), nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, wallets[1], affected[0].Wallet)
+
+	// removing the key leaves the first wallet without signers
+	compromise.Plan(affected, compromised.Address(), common.Address{})
+	assert.NoError(t, affected[0].Err)
+	assert.Equal(t, sequence.WalletConfigSigners{{Weight: 1, Address: trusted.Address()}}, affected[0].Rotated.Signers)
+	assert.True(t, errors.Is(affected[1].Err, compromise.ErrCannotRotate))
+
+	provider := memchain.New(memchain.Options{ChainID: big.NewInt(1)}).Provider()
+	relayer := &fakeRelayer{provider: provider}
+
+	var progress []compromise.Stage
+	results := compromise.Rotate(ctx, affected, compromise.RotateOptions{
+		Provider:      provider,
+		Relayer:       relayer,
+		WalletContext: walletContext,
+		Signers:       []*ethwallet.Wallet{trusted},
+		Wait:          true,
+		OnProgress: func(p compromise.Progress) {
+			progress = append(progress, p.Stage)
+		},
+	})
+	assert.Equal(t, []compromise.Stage{compromise.StageRelayed, compromise.StageExecuted, compromise.StageSkipped}, progress)
+	assert.Equal(t, compromise.StageExecuted, results[0].Stage)
+	assert.Equal(t, compromise.StageSkipped, results[1].Stage)
+
+	// the rotation is signed by the trusted key and updates the image hash
+	assert.Len(t, relayer.relayed, 1)
+	rotation, err := compromise.RotationTransactions(wallets[1], walletContext, *affected[0].Rotated)
+	assert.NoError(t, err)
+	assert.True(t, rotation.Equal(relayer.relayed[0].Transactions))
+
+	// with a replacement key, the first wallet can be rotated too
+	rotated, err := compromise.RotatedConfig(configs[0], compromised.Address(), replacement.Address())
+	assert.NoError(t, err)
+	assert.Equal(t, sequence.WalletConfigSigners{{Weight: 1, Address: replacement.Address()}}, rotated.Signers)
+}
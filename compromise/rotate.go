@@ -0,0 +1,156 @@
+package compromise
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+)
+
+// Stage is the stage of the rotation of a wallet reported by Rotate.
+type Stage uint8
+
+const (
+	StageSkipped Stage = iota
+	StageRelayed
+	StageExecuted
+	StageFailed
+)
+
+func (s Stage) String() string {
+	switch s {
+	case StageSkipped:
+		return "skipped"
+	case StageRelayed:
+		return "relayed"
+	case StageExecuted:
+		return "executed"
+	case StageFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// Progress is the progress of Rotate, reported as each wallet reaches a stage.
+type Progress struct {
+	Wallet common.Address
+
+	// Index is the index of the wallet in the wallets rotated, out of Total.
+	Index int
+	Total int
+
+	Stage     Stage
+	MetaTxnID sequence.MetaTxnID
+	Err       error
+}
+
+// Result is the outcome of the rotation of a wallet, the last Progress reported for it.
+type Result = Progress
+
+type RotateOptions struct {
+	Provider      *ethrpc.Provider
+	Relayer       sequence.Relayer
+	WalletContext sequence.WalletContext
+
+	// Signers are the keys of the signers of the wallets still trusted, which sign the
+	// rotations. The compromised key may be one of them, ie. if it was leaked but not lost.
+	Signers []*ethwallet.Wallet
+
+	// Wait waits for each rotation to be executed before the next one is relayed, so a
+	// failure is reported before it is repeated across the wallets.
+	Wait bool
+
+	// OnProgress is called as each wallet reaches a stage, optional.
+	OnProgress func(Progress)
+}
+
+// Rotate relays the rotations planned for affected, in order, see Plan, and returns their
+// results in the same order. Wallets which can't be rotated are skipped:
+//
+//   - whose rotation couldn't be planned,
+//   - whose signers in opts.Signers don't reach their threshold,
+//   - whose config was updated since they were deployed, as bundles are relayed to the
+//     address of the config which signs them.
+func Rotate(ctx context.Context, affected []*Affected, opts RotateOptions) []Result {
+	results := make([]Result, len(affected))
+	report := func(i int, progress Progress) {
+		progress.Wallet, progress.Index, progress.Total = affected[i].Wallet, i, len(affected)
+		results[i] = progress
+		if opts.OnProgress != nil {
+			opts.OnProgress(progress)
+		}
+	}
+
+	for i, a := range affected {
+		if ctx.Err() != nil {
+			report(i, Progress{Stage: StageSkipped, Err: ctx.Err()})
+			continue
+		}
+
+		wallet, txns, err := rotation(a, opts)
+		if err != nil {
+			report(i, Progress{Stage: StageSkipped, Err: err})
+			continue
+		}
+
+		signed, err := wallet.SignTransactions(ctx, txns)
+		if err != nil {
+			report(i, Progress{Stage: StageFailed, Err: fmt.Errorf("compromise: failed to sign rotation: %w", err)})
+			continue
+		}
+		metaTxnID, _, _, err := wallet.SendTransactions(ctx, signed)
+		if err != nil {
+			report(i, Progress{Stage: StageFailed, MetaTxnID: metaTxnID, Err: fmt.Errorf("compromise: failed to relay rotation: %w", err)})
+			continue
+		}
+		report(i, Progress{Stage: StageRelayed, MetaTxnID: metaTxnID})
+
+		if !opts.Wait {
+			continue
+		}
+		status, _, err := opts.Relayer.Wait(ctx, metaTxnID)
+		if err != nil {
+			report(i, Progress{Stage: StageFailed, MetaTxnID: metaTxnID, Err: fmt.Errorf("compromise: failed to wait for rotation: %w", err)})
+		} else if status != sequence.MetaTxnExecuted {
+			report(i, Progress{Stage: StageFailed, MetaTxnID: metaTxnID, Err: fmt.Errorf("compromise: rotation %v", status)})
+		} else {
+			report(i, Progress{Stage: StageExecuted, MetaTxnID: metaTxnID})
+		}
+	}
+	return results
+}
+
+// rotation returns the wallet signing the rotation of a, and its transactions.
+func rotation(a *Affected, opts RotateOptions) (*sequence.Wallet, sequence.Transactions, error) {
+	if a.Err != nil {
+		return nil, nil, a.Err
+	}
+	if a.Rotated == nil {
+		return nil, nil, fmt.Errorf("%w: no rotation planned", ErrCannotRotate)
+	}
+	if a.Updated {
+		return nil, nil, fmt.Errorf("%w: the config of the wallet was updated since it was deployed", ErrCannotRotate)
+	}
+
+	wallet, err := sequence.NewWallet(sequence.WalletOptions{Config: a.Config, Context: &opts.WalletContext, Address: a.Wallet}, opts.Signers...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if wallet.GetSignerWeight().Cmp(big.NewInt(int64(a.Config.Threshold))) < 0 {
+		return nil, nil, fmt.Errorf("%w: the signers don't reach the threshold of %d", ErrCannotRotate, a.Config.Threshold)
+	}
+	if err := wallet.Connect(opts.Provider, opts.Relayer); err != nil {
+		return nil, nil, err
+	}
+
+	txns, err := RotationTransactions(a.Wallet, opts.WalletContext, *a.Rotated)
+	if err != nil {
+		return nil, nil, err
+	}
+	return wallet, txns, nil
+}
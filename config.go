@@ -21,6 +21,8 @@ type WalletConfigSigner struct {
 
 type WalletConfigSigners []WalletConfigSigner
 
+// Clone returns a copy of the wallet config, whose signers can be mutated or sorted
+// without affecting s.
 func (s WalletConfig) Clone() WalletConfig {
 	signers := make(WalletConfigSigners, len(s.Signers))
 	copy(signers, s.Signers)
@@ -30,6 +32,20 @@ func (s WalletConfig) Clone() WalletConfig {
 	}
 }
 
+// Equal reports whether s and other have the same threshold and signers, in the same
+// order. Unlike IsWalletConfigEqual, it does not compute image hashes.
+func (s WalletConfig) Equal(other WalletConfig) bool {
+	if s.Threshold != other.Threshold || len(s.Signers) != len(other.Signers) {
+		return false
+	}
+	for i := range s.Signers {
+		if s.Signers[i] != other.Signers[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func (s WalletConfigSigners) Len() int { return len(s) }
 func (s WalletConfigSigners) Less(i, j int) bool {
 	return s[i].Address.Hash().Big().Cmp(s[j].Address.Hash().Big()) < 0
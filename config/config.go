@@ -0,0 +1,419 @@
+// Package config loads the settings of services embedding go-sequence (provider, relayer,
+// wallet context and relaying policy) from a YAML file, with overrides from the environment.
+package config
+
+import (
+	"fmt"
+	"math/big"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/relayer"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of the environment variables which override values of the
+// YAML config, ie. SEQUENCE_PROVIDER_URL overrides `provider.url`.
+const EnvPrefix = "SEQUENCE"
+
+type Config struct {
+	Provider ProviderConfig `yaml:"provider"`
+	Relayer  RelayerConfig  `yaml:"relayer"`
+	Wallet   WalletConfig   `yaml:"wallet"`
+	Policy   PolicyConfig   `yaml:"policy"`
+	Fees     FeeConfig      `yaml:"fees"`
+	Chains   []ChainConfig  `yaml:"chains"`
+	Server   ServerConfig   `yaml:"server"`
+}
+
+type ProviderConfig struct {
+	// URL is the node rpc endpoint.
+	URL string `yaml:"url"`
+
+	// ChainID is the expected chain id of the node, optional.
+	ChainID uint64 `yaml:"chain_id"`
+
+	// PollingInterval is the interval the monitor polls for new blocks at.
+	PollingInterval time.Duration `yaml:"polling_interval"`
+
+	// BlockRetentionLimit is the number of blocks the monitor keeps in memory.
+	BlockRetentionLimit int `yaml:"block_retention_limit"`
+}
+
+type RelayerConfig struct {
+	// Mode is either "local", to dispatch meta-transactions with the SenderKey, or "rpc"
+	// to dispatch them through a hosted relayer at URL.
+	Mode string `yaml:"mode"`
+
+	// URL is the hosted relayer endpoint, required in "rpc" mode.
+	URL string `yaml:"url"`
+
+	// SenderKey is the hex-encoded private key of the EOA sending transactions in "local" mode.
+	SenderKey Secret `yaml:"sender_key"`
+
+	// WaitTimeout is the default time to wait for meta-transaction receipts.
+	WaitTimeout time.Duration `yaml:"wait_timeout"`
+
+	// WaitConfirmations is the number of blocks mined on top of a receipt before it is
+	// returned, re-validating it wasn't reorged out, ie. on chains with fast reorgs.
+	WaitConfirmations uint64 `yaml:"wait_confirmations"`
+
+	// SenderKeys are the private keys of a pool of senders used in place of SenderKey, so
+	// bundles are not sent one at a time, optional.
+	SenderKeys []Secret `yaml:"sender_keys"`
+
+	// MinSenderBalance is the balance in wei under which a sender of the pool is low on
+	// funds, and is topped up from the TreasuryKey if set.
+	MinSenderBalance uint64 `yaml:"min_sender_balance"`
+
+	// TreasuryKey is the private key of the wallet topping up senders of the pool, optional.
+	TreasuryKey Secret `yaml:"treasury_key"`
+
+	// TopUpAmount is the amount in wei sent to a sender low on funds.
+	TopUpAmount uint64 `yaml:"top_up_amount"`
+
+	// PrivateRelays are the rpc endpoints of private transaction relays (ie. Flashbots
+	// Protect) bundles are submitted through instead of the public mempool, optional.
+	PrivateRelays []string `yaml:"private_relays"`
+
+	// PrivateMinValue submits only bundles transferring at least this value in wei privately.
+	PrivateMinValue uint64 `yaml:"private_min_value"`
+
+	// PrivateFallback sends bundles not included by the private relays to the public mempool.
+	PrivateFallback bool `yaml:"private_fallback"`
+}
+
+type WalletConfig struct {
+	// OwnerKey is the hex-encoded private key of the wallet owner, optional.
+	OwnerKey Secret `yaml:"owner_key"`
+
+	// Context overrides the default sequence wallet context, optional.
+	Context *ContextConfig `yaml:"context"`
+}
+
+type ContextConfig struct {
+	FactoryAddress              common.Address `yaml:"factory_address"`
+	MainModuleAddress           common.Address `yaml:"main_module_address"`
+	MainModuleUpgradableAddress common.Address `yaml:"main_module_upgradable_address"`
+	GuestModuleAddress          common.Address `yaml:"guest_module_address"`
+	UtilsAddress                common.Address `yaml:"utils_address"`
+}
+
+type PolicyConfig struct {
+	// MaxGasLimit is the maximum gas limit of a relayed bundle, 0 means no limit.
+	MaxGasLimit uint64 `yaml:"max_gas_limit"`
+
+	// MaxTransactions is the maximum number of transactions in a relayed bundle, 0 means no limit.
+	MaxTransactions int `yaml:"max_transactions"`
+
+	// AllowedTargets restricts the contracts which may be called, empty means any.
+	AllowedTargets []common.Address `yaml:"allowed_targets"`
+}
+
+type FeeConfig struct {
+	// GasPriceMultiplier is applied to the gas price suggested by the node, 0 leaves it as-is.
+	GasPriceMultiplier float64 `yaml:"gas_price_multiplier"`
+
+	// MaxGasPrice caps the gas price in wei, 0 means no cap.
+	MaxGasPrice uint64 `yaml:"max_gas_price"`
+}
+
+// ChainConfig overrides the settings of a network in the chain registry.
+type ChainConfig struct {
+	Name       string `yaml:"name"`
+	ChainID    uint64 `yaml:"chain_id"`
+	RpcURL     string `yaml:"rpc_url"`
+	RelayerURL string `yaml:"relayer_url"`
+
+	// GasToken is the symbol of the gas token of the chain, optional, and GasTokenDecimals
+	// its decimals, 18 by default.
+	GasToken         string `yaml:"gas_token"`
+	GasTokenDecimals uint8  `yaml:"gas_token_decimals"`
+}
+
+// ServerConfig configures a relayer server, such as cmd/relayerd.
+type ServerConfig struct {
+	// Listen is the address the server listens on, ie. ":8080".
+	Listen string `yaml:"listen"`
+
+	// RequireSignedRequests rejects requests which are not signed by a RequestSigner.
+	RequireSignedRequests bool `yaml:"require_signed_requests"`
+
+	// Tenants are the applications served by the relayer.
+	Tenants []TenantConfig `yaml:"tenants"`
+
+	// AdminListen is the address the admin api listens on, which should not be public.
+	AdminListen string `yaml:"admin_listen"`
+
+	// AdminToken is the bearer token of the admin api, which is disabled if not set.
+	AdminToken Secret `yaml:"admin_token"`
+}
+
+type TenantConfig struct {
+	ID     string `yaml:"id"`
+	APIKey Secret `yaml:"api_key"`
+
+	// SenderKey is the private key of the tenant's own sender EOA, optional.
+	SenderKey Secret `yaml:"sender_key"`
+
+	// NonceSpace is the default meta-transaction nonce space of the tenant's wallets, optional.
+	NonceSpace uint64 `yaml:"nonce_space"`
+
+	// Signers are the EOAs which may sign relay requests of the tenant.
+	Signers []common.Address `yaml:"signers"`
+
+	// MaxTransactions caps the number of bundles relayed for the tenant, 0 means no limit.
+	MaxTransactions int64 `yaml:"max_transactions"`
+
+	// MaxGasSpent caps the gas spent by the tenant in wei, 0 means no limit.
+	MaxGasSpent uint64 `yaml:"max_gas_spent"`
+}
+
+// Load reads the YAML config at path, and returns it after applying overrides from the
+// environment, resolving secrets and validating it.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse is like Load, but reads the YAML config from data.
+func Parse(data []byte) (*Config, error) {
+	return parse(data, os.LookupEnv)
+}
+
+func parse(data []byte, lookupEnv func(string) (string, bool)) (*Config, error) {
+	config := &Config{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("config: invalid yaml: %w", err)
+	}
+	if err := applyEnv(config, EnvPrefix, lookupEnv); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	if err := resolveSecrets(config, lookupEnv); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (c *Config) Validate() error {
+	if c.Provider.URL == "" {
+		return fmt.Errorf("config: provider.url is required")
+	}
+	if _, err := url.ParseRequestURI(c.Provider.URL); err != nil {
+		return fmt.Errorf("config: provider.url is invalid: %w", err)
+	}
+
+	switch c.Relayer.Mode {
+	case "", "local":
+		if c.Relayer.SenderKey == "" && len(c.Relayer.SenderKeys) == 0 {
+			return fmt.Errorf("config: relayer.sender_key or relayer.sender_keys is required in local mode")
+		}
+		if c.Relayer.TreasuryKey != "" && (c.Relayer.MinSenderBalance == 0 || c.Relayer.TopUpAmount == 0) {
+			return fmt.Errorf("config: relayer.treasury_key requires min_sender_balance and top_up_amount")
+		}
+	case "rpc":
+		if c.Relayer.URL == "" {
+			return fmt.Errorf("config: relayer.url is required in rpc mode")
+		}
+		if _, err := url.ParseRequestURI(c.Relayer.URL); err != nil {
+			return fmt.Errorf("config: relayer.url is invalid: %w", err)
+		}
+	default:
+		return fmt.Errorf("config: relayer.mode %q is invalid, must be local or rpc", c.Relayer.Mode)
+	}
+
+	for i, relay := range c.Relayer.PrivateRelays {
+		if _, err := url.ParseRequestURI(relay); err != nil {
+			return fmt.Errorf("config: relayer.private_relays[%d] is invalid: %w", i, err)
+		}
+	}
+
+	if c.Relayer.WaitTimeout < 0 {
+		return fmt.Errorf("config: relayer.wait_timeout must not be negative")
+	}
+	if c.Policy.MaxTransactions < 0 {
+		return fmt.Errorf("config: policy.max_transactions must not be negative")
+	}
+
+	if c.Fees.GasPriceMultiplier < 0 {
+		return fmt.Errorf("config: fees.gas_price_multiplier must not be negative")
+	}
+
+	chainIDs := map[uint64]struct{}{}
+	for i, chain := range c.Chains {
+		if chain.ChainID == 0 {
+			return fmt.Errorf("config: chains[%d].chain_id is required", i)
+		}
+		if _, ok := chainIDs[chain.ChainID]; ok {
+			return fmt.Errorf("config: chains[%d].chain_id %d is duplicated", i, chain.ChainID)
+		}
+		chainIDs[chain.ChainID] = struct{}{}
+
+		if chain.RpcURL != "" {
+			if _, err := url.ParseRequestURI(chain.RpcURL); err != nil {
+				return fmt.Errorf("config: chains[%d].rpc_url is invalid: %w", i, err)
+			}
+		}
+	}
+
+	tenantIDs := map[string]struct{}{}
+	for i, tenant := range c.Server.Tenants {
+		if tenant.ID == "" || tenant.APIKey == "" {
+			return fmt.Errorf("config: server.tenants[%d] requires id and api_key", i)
+		}
+		if _, ok := tenantIDs[tenant.ID]; ok {
+			return fmt.Errorf("config: server.tenants[%d].id %q is duplicated", i, tenant.ID)
+		}
+		tenantIDs[tenant.ID] = struct{}{}
+		if tenant.MaxTransactions < 0 {
+			return fmt.Errorf("config: server.tenants[%d].max_transactions must not be negative", i)
+		}
+	}
+
+	if ctx := c.Wallet.Context; ctx != nil {
+		if ctx.FactoryAddress == (common.Address{}) || ctx.MainModuleAddress == (common.Address{}) {
+			return fmt.Errorf("config: wallet.context requires factory_address and main_module_address")
+		}
+	}
+
+	return nil
+}
+
+// WalletContext returns the configured wallet context, or the default sequence context
+// if none was configured.
+func (c *Config) WalletContext() sequence.WalletContext {
+	if c.Wallet.Context == nil {
+		return sequence.SequenceContext()
+	}
+	return sequence.WalletContext{
+		FactoryAddress:              c.Wallet.Context.FactoryAddress,
+		MainModuleAddress:           c.Wallet.Context.MainModuleAddress,
+		MainModuleUpgradableAddress: c.Wallet.Context.MainModuleUpgradableAddress,
+		GuestModuleAddress:          c.Wallet.Context.GuestModuleAddress,
+		UtilsAddress:                c.Wallet.Context.UtilsAddress,
+	}
+}
+
+// RelayerPolicy returns the sponsorship policy and fee strategy described by the config.
+func (c *Config) RelayerPolicy() *relayer.Policy {
+	policy := &relayer.Policy{
+		MaxGasLimit:     c.Policy.MaxGasLimit,
+		MaxTransactions: c.Policy.MaxTransactions,
+		AllowedTargets:  c.Policy.AllowedTargets,
+	}
+	if c.Fees.GasPriceMultiplier > 0 || c.Fees.MaxGasPrice > 0 {
+		policy.Fees = &relayer.FeeStrategy{GasPriceMultiplier: c.Fees.GasPriceMultiplier}
+		if c.Fees.MaxGasPrice > 0 {
+			policy.Fees.MaxGasPrice = new(big.Int).SetUint64(c.Fees.MaxGasPrice)
+		}
+	}
+	return policy
+}
+
+// Networks returns the chain registry overrides of the config.
+func (c *Config) Networks() sequence.Networks {
+	networks := make(sequence.Networks, 0, len(c.Chains))
+	for _, chain := range c.Chains {
+		network := sequence.NetworkConfig{
+			Name:   chain.Name,
+			RpcURL: chain.RpcURL,
+		}
+		network.ChainID.SetUint64(chain.ChainID)
+		if chain.RelayerURL != "" {
+			relayerURL := chain.RelayerURL
+			network.RelayerURL = &relayerURL
+		}
+		if chain.GasToken != "" {
+			decimals := chain.GasTokenDecimals
+			if decimals == 0 {
+				decimals = sequence.NativeTokenDecimals
+			}
+			network.GasToken = &sequence.FeeToken{ChainID: chain.ChainID, Symbol: chain.GasToken, Decimals: decimals}
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// Options returns the go-sequence options described by the config.
+func (c *Config) Options() []sequence.Option {
+	return []sequence.Option{
+		sequence.WithWaitOptions(sequence.WaitOptions{
+			Timeout:       c.Relayer.WaitTimeout,
+			Confirmations: c.Relayer.WaitConfirmations,
+		}),
+	}
+}
+
+// Senders returns the wallets of the sender pool of the relayer, without a provider.
+func (c *Config) Senders() ([]*ethwallet.Wallet, error) {
+	senders := make([]*ethwallet.Wallet, 0, len(c.Relayer.SenderKeys))
+	for i, key := range c.Relayer.SenderKeys {
+		sender, err := ethwallet.NewWalletFromPrivateKey(strings.TrimPrefix(key.Value(), "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("config: relayer.sender_keys[%d] is invalid: %w", i, err)
+		}
+		senders = append(senders, sender)
+	}
+	return senders, nil
+}
+
+// PrivateSubmission returns the private relays submission of the relayer, or nil if no
+// private relays are configured.
+func (c *Config) PrivateSubmission() (*relayer.PrivateSubmission, error) {
+	if len(c.Relayer.PrivateRelays) == 0 {
+		return nil, nil
+	}
+	private, err := relayer.NewPrivateSubmission(c.Relayer.PrivateRelays...)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	if c.Relayer.PrivateMinValue > 0 {
+		private.Sensitive = relayer.MinValueSensitive(new(big.Int).SetUint64(c.Relayer.PrivateMinValue))
+	}
+	private.PublicFallback = c.Relayer.PrivateFallback
+	return private, nil
+}
+
+// Tenants returns the tenants of the relayer server, each with the relaying policy of the
+// config. Tenants without a sender key share the default sender of the relayer.
+func (c *Config) Tenants() ([]relayer.Tenant, error) {
+	tenants := make([]relayer.Tenant, 0, len(c.Server.Tenants))
+	for _, t := range c.Server.Tenants {
+		tenant := relayer.Tenant{
+			ID:      t.ID,
+			APIKey:  t.APIKey.Value(),
+			Policy:  c.RelayerPolicy(),
+			Signers: t.Signers,
+		}
+		if t.SenderKey != "" {
+			sender, err := ethwallet.NewWalletFromPrivateKey(strings.TrimPrefix(t.SenderKey.Value(), "0x"))
+			if err != nil {
+				return nil, fmt.Errorf("config: server.tenants %q has an invalid sender_key: %w", t.ID, err)
+			}
+			tenant.Sender = sender
+		}
+		if t.NonceSpace != 0 {
+			tenant.NonceSpace = new(big.Int).SetUint64(t.NonceSpace)
+		}
+		if t.MaxTransactions > 0 || t.MaxGasSpent > 0 {
+			tenant.Limits = &relayer.UsageLimits{MaxTransactions: t.MaxTransactions}
+			if t.MaxGasSpent > 0 {
+				tenant.Limits.MaxGasSpent = new(big.Int).SetUint64(t.MaxGasSpent)
+			}
+		}
+		tenants = append(tenants, tenant)
+	}
+	return tenants, nil
+}
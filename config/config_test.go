@@ -0,0 +1,116 @@
+package config_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `
+provider:
+  url: http://localhost:8545
+  chain_id: 1337
+  polling_interval: 500ms
+relayer:
+  mode: local
+  sender_key: env:TEST_SENDER_KEY
+  wait_timeout: 1m
+  wait_confirmations: 12
+wallet:
+  owner_key: file:%s
+policy:
+  max_transactions: 10
+  allowed_targets:
+    - 0x1111111111111111111111111111111111111111
+`
+
+func TestParse(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "owner.key")
+	assert.NoError(t, os.WriteFile(keyFile, []byte("0xowner\n"), 0600))
+
+	data := []byte(fmt.Sprintf(testConfig, keyFile))
+
+	t.Setenv("TEST_SENDER_KEY", "0xsender")
+	t.Setenv("SEQUENCE_PROVIDER_CHAIN_ID", "31337")
+	t.Setenv("SEQUENCE_RELAYER_WAIT_TIMEOUT", "30s")
+	t.Setenv("SEQUENCE_WALLET_CONTEXT_UTILS_ADDRESS", "0x2222222222222222222222222222222222222222")
+
+	// a partial wallet context set from the environment is invalid
+	_, err := config.Parse(data)
+	assert.ErrorContains(t, err, "wallet.context requires")
+
+	os.Unsetenv("SEQUENCE_WALLET_CONTEXT_UTILS_ADDRESS")
+	cfg, err := config.Parse(data)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "http://localhost:8545", cfg.Provider.URL)
+	assert.Equal(t, uint64(31337), cfg.Provider.ChainID)
+	assert.Equal(t, 500*time.Millisecond, cfg.Provider.PollingInterval)
+	assert.Equal(t, 30*time.Second, cfg.Relayer.WaitTimeout)
+	assert.Equal(t, uint64(12), cfg.Relayer.WaitConfirmations)
+	assert.Equal(t, "0xsender", cfg.Relayer.SenderKey.Value())
+	assert.Equal(t, "[redacted]", cfg.Relayer.SenderKey.String())
+	assert.Equal(t, "0xowner", cfg.Wallet.OwnerKey.Value())
+	assert.Nil(t, cfg.Wallet.Context)
+	assert.Equal(t, []common.Address{common.HexToAddress("0x1111111111111111111111111111111111111111")}, cfg.Policy.AllowedTargets)
+}
+
+func TestValidate(t *testing.T) {
+	_, err := config.Parse([]byte("relayer:\n  mode: rpc\n"))
+	assert.ErrorContains(t, err, "provider.url is required")
+
+	_, err = config.Parse([]byte("provider:\n  url: http://localhost:8545\nrelayer:\n  mode: rpc\n"))
+	assert.ErrorContains(t, err, "relayer.url is required")
+
+	_, err = config.Parse([]byte("provider:\n  url: http://localhost:8545\nrelayer:\n  mode: other\n"))
+	assert.ErrorContains(t, err, "relayer.mode")
+
+	_, err = config.Parse([]byte("provider:\n  url: http://localhost:8545\nrelayer:\n  sender_key: env:MISSING\n"))
+	assert.ErrorContains(t, err, "MISSING is not set")
+
+	_, err = config.Parse([]byte("provider:\n  url: http://localhost:8545\nrelayer:\n  sender_keys: [a, env:MISSING]\n"))
+	assert.ErrorContains(t, err, "MISSING is not set")
+
+	_, err = config.Parse([]byte("provider:\n  url: http://localhost:8545\nrelayer:\n  sender_keys: [a]\n  treasury_key: b\n"))
+	assert.ErrorContains(t, err, "requires min_sender_balance")
+}
+
+func TestTenants(t *testing.T) {
+	t.Setenv("TEST_TENANT_KEY", "key-1")
+	t.Setenv("SEQUENCE_SERVER_TENANTS_1_MAX_TRANSACTIONS", "5")
+
+	cfg, err := config.Parse([]byte(`
+provider:
+  url: http://localhost:8545
+relayer:
+  sender_key: 0xsender
+server:
+  listen: :9090
+  tenants:
+    - id: app-1
+      api_key: env:TEST_TENANT_KEY
+      nonce_space: 7
+    - id: app-2
+      api_key: key-2
+      max_gas_spent: 1000
+`))
+	assert.NoError(t, err)
+
+	tenants, err := cfg.Tenants()
+	assert.NoError(t, err)
+	assert.Len(t, tenants, 2)
+	assert.Equal(t, "key-1", tenants[0].APIKey)
+	assert.Equal(t, uint64(7), tenants[0].NonceSpace.Uint64())
+	assert.Nil(t, tenants[0].Limits)
+	assert.Equal(t, int64(5), tenants[1].Limits.MaxTransactions)
+	assert.Equal(t, uint64(1000), tenants[1].Limits.MaxGasSpent.Uint64())
+
+	_, err = config.Parse([]byte("provider:\n  url: http://localhost:8545\nrelayer:\n  sender_key: 0xsender\nserver:\n  tenants:\n    - id: app-1\n      api_key: a\n    - id: app-1\n      api_key: b\n"))
+	assert.ErrorContains(t, err, "is duplicated")
+}
@@ -0,0 +1,204 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Secret is a config value which is either set inline, or is a reference to where the
+// value is stored: "env:NAME" reads the environment variable NAME, and "file:/path"
+// reads the file at /path. References are resolved when the config is loaded.
+type Secret string
+
+// String redacts the secret, so it is not leaked when a config is logged.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return "[redacted]"
+}
+
+// Value returns the resolved secret.
+func (s Secret) Value() string {
+	return string(s)
+}
+
+func (s Secret) resolve(lookupEnv func(string) (string, bool)) (Secret, error) {
+	ref := string(s)
+	switch {
+	case strings.HasPrefix(ref, "env:"):
+		name := strings.TrimPrefix(ref, "env:")
+		value, ok := lookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("secret env var %s is not set", name)
+		}
+		return Secret(strings.TrimSpace(value)), nil
+	case strings.HasPrefix(ref, "file:"):
+		data, err := os.ReadFile(strings.TrimPrefix(ref, "file:"))
+		if err != nil {
+			return "", fmt.Errorf("secret file: %w", err)
+		}
+		return Secret(strings.TrimSpace(string(data))), nil
+	default:
+		return s, nil
+	}
+}
+
+var (
+	secretType          = reflect.TypeOf(Secret(""))
+	secretsType         = reflect.TypeOf([]Secret{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// applyEnv overrides the fields of v from environment variables named after their yaml
+// path, ie. `relayer.wait_timeout` is overridden by PREFIX_RELAYER_WAIT_TIMEOUT.
+func applyEnv(v interface{}, prefix string, lookupEnv func(string) (string, bool)) error {
+	return walkFields(reflect.ValueOf(v).Elem(), prefix, func(name string, field reflect.Value) error {
+		value, ok := lookupEnv(name)
+		if !ok {
+			return nil
+		}
+		if err := setField(field, value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+		return nil
+	})
+}
+
+func resolveSecrets(v interface{}, lookupEnv func(string) (string, bool)) error {
+	return walkFields(reflect.ValueOf(v).Elem(), "", func(name string, field reflect.Value) error {
+		switch field.Type() {
+		case secretType:
+			secret, err := field.Interface().(Secret).resolve(lookupEnv)
+			if err != nil {
+				return err
+			}
+			field.Set(reflect.ValueOf(secret))
+		case secretsType:
+			for i := 0; i < field.Len(); i++ {
+				secret, err := field.Index(i).Interface().(Secret).resolve(lookupEnv)
+				if err != nil {
+					return err
+				}
+				field.Index(i).Set(reflect.ValueOf(secret))
+			}
+		}
+		return nil
+	})
+}
+
+// walkFields calls fn on each leaf field of the struct v with its env var name. Nil struct
+// pointers are allocated only if one of their fields is set from the environment.
+func walkFields(v reflect.Value, name string, fn func(name string, field reflect.Value) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+		fieldName := strings.ToUpper(tag)
+		if name != "" {
+			fieldName = name + "_" + fieldName
+		}
+
+		field := v.Field(i)
+		switch {
+		case field.Kind() == reflect.Struct && !isLeaf(field.Type()):
+			if err := walkFields(field, fieldName, fn); err != nil {
+				return err
+			}
+		case field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct && !isLeaf(field.Type()):
+			elem := field
+			if field.IsNil() {
+				elem = reflect.New(field.Type().Elem())
+			}
+			if err := walkFields(elem.Elem(), fieldName, fn); err != nil {
+				return err
+			}
+			if field.IsNil() && !elem.Elem().IsZero() {
+				field.Set(elem)
+			}
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Struct && !isLeaf(field.Type().Elem()):
+			// slices of structs are walked per element, ie. PREFIX_SERVER_TENANTS_0_ID
+			for j := 0; j < field.Len(); j++ {
+				if err := walkFields(field.Index(j), fmt.Sprintf("%s_%d", fieldName, j), fn); err != nil {
+					return err
+				}
+			}
+		default:
+			if err := fn(fieldName, field); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func isLeaf(t reflect.Type) bool {
+	return t.Implements(textUnmarshalerType) || reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+func setField(field reflect.Value, value string) error {
+	if field.CanAddr() {
+		if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(value))
+		}
+	}
+
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		// comma-separated list
+		parts := strings.Split(value, ",")
+		slice := reflect.MakeSlice(field.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setField(slice.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
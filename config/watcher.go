@@ -0,0 +1,165 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/0xsequence/go-sequence"
+)
+
+// DefaultPollInterval is how often a Watcher checks its config file for changes.
+const DefaultPollInterval = 5 * time.Second
+
+// Watcher reloads a config file at runtime, when the process receives a SIGHUP or the file is
+// modified. A reloaded config is only swapped in if it is valid, otherwise the current config
+// is kept, so services may pick up new policies and chain settings without restarting.
+type Watcher struct {
+	// PollInterval is how often the config file is checked for changes, a negative value
+	// disables polling, leaving SIGHUP as the only trigger.
+	PollInterval time.Duration
+
+	path    string
+	config  atomic.Value // *Config
+	modTime time.Time
+	options sequence.Options
+
+	onReload   []func(config *Config)
+	muOnReload sync.Mutex
+	muReload   sync.Mutex
+
+	ctx     context.Context
+	ctxStop context.CancelFunc
+	running int32
+}
+
+var _ sequence.Runnable = &Watcher{}
+
+// NewWatcher loads the config at path, which must be valid.
+func NewWatcher(path string, opts ...sequence.Option) (*Watcher, error) {
+	w := &Watcher{
+		PollInterval: DefaultPollInterval,
+		path:         path,
+		options:      sequence.NewOptions(opts...),
+	}
+	if err := w.Reload(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Config returns the current config. The returned config must not be modified.
+func (w *Watcher) Config() *Config {
+	return w.config.Load().(*Config)
+}
+
+// OnReload registers fn to be called with the new config after each successful reload.
+func (w *Watcher) OnReload(fn func(config *Config)) {
+	w.muOnReload.Lock()
+	defer w.muOnReload.Unlock()
+	w.onReload = append(w.onReload, fn)
+}
+
+// Reload loads and validates the config file, and swaps it in if it is valid.
+func (w *Watcher) Reload() error {
+	w.muReload.Lock()
+	defer w.muReload.Unlock()
+
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+
+	// record the modification time even if the config is invalid, so polling does not
+	// retry the same file until it is modified again
+	w.modTime = info.ModTime()
+
+	config, err := Load(w.path)
+	if err != nil {
+		return err
+	}
+
+	isInitial := w.config.Load() == nil
+	w.config.Store(config)
+
+	if !isInitial {
+		w.muOnReload.Lock()
+		onReload := append([]func(*Config){}, w.onReload...)
+		w.muOnReload.Unlock()
+
+		for _, fn := range onReload {
+			fn(config)
+		}
+	}
+
+	return nil
+}
+
+func (w *Watcher) Run(ctx context.Context) error {
+	if w.IsRunning() {
+		return fmt.Errorf("config: watcher already running")
+	}
+
+	w.ctx, w.ctxStop = context.WithCancel(ctx)
+	atomic.StoreInt32(&w.running, 1)
+	defer atomic.StoreInt32(&w.running, 0)
+
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	var poll <-chan time.Time
+	if w.PollInterval > 0 {
+		ticker := time.NewTicker(w.PollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return nil
+
+		case <-sighup:
+			w.reload()
+
+		case <-poll:
+			info, err := os.Stat(w.path)
+			if err != nil {
+				w.options.Logger.Warnf("config: failed to stat %s: %v", w.path, err)
+				continue
+			}
+			w.muReload.Lock()
+			modified := !info.ModTime().Equal(w.modTime)
+			w.muReload.Unlock()
+			if modified {
+				w.reload()
+			}
+		}
+	}
+}
+
+func (w *Watcher) Stop() {
+	if w.ctxStop != nil {
+		w.ctxStop()
+	}
+}
+
+func (w *Watcher) IsRunning() bool {
+	return atomic.LoadInt32(&w.running) == 1
+}
+
+func (w *Watcher) reload() {
+	if err := w.Reload(); err != nil {
+		w.options.Metrics.IncCounter("config.reload.error")
+		w.options.Logger.Errorf("config: keeping current config, reload of %s failed: %v", w.path, err)
+		return
+	}
+	w.options.Metrics.IncCounter("config.reload")
+	w.options.Logger.Infof("config: reloaded %s", w.path)
+}
@@ -0,0 +1,53 @@
+package config_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/go-sequence/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	writeConfig := func(data string, modTime time.Time) {
+		assert.NoError(t, os.WriteFile(path, []byte(data), 0600))
+		assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	base := "provider:\n  url: http://localhost:8545\nrelayer:\n  mode: rpc\n  url: http://localhost:3000\n"
+	writeConfig(base+"policy:\n  max_transactions: 5\n", time.Now().Add(-time.Hour))
+
+	watcher, err := config.NewWatcher(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, watcher.Config().Policy.MaxTransactions)
+	assert.Equal(t, 5, watcher.Config().RelayerPolicy().MaxTransactions)
+
+	reloaded := make(chan *config.Config, 1)
+	watcher.OnReload(func(c *config.Config) {
+		reloaded <- c
+	})
+
+	watcher.PollInterval = 10 * time.Millisecond
+	go watcher.Run(context.Background())
+	defer watcher.Stop()
+
+	// an invalid config is not swapped in
+	writeConfig("provider:\n  url: \"\"\n", time.Now().Add(-30*time.Minute))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 5, watcher.Config().Policy.MaxTransactions)
+	assert.Len(t, reloaded, 0)
+
+	writeConfig(base+"policy:\n  max_transactions: 10\nfees:\n  gas_price_multiplier: 1.5\n", time.Now())
+	select {
+	case c := <-reloaded:
+		assert.Equal(t, 10, c.Policy.MaxTransactions)
+		assert.Equal(t, 1.5, c.RelayerPolicy().Fees.GasPriceMultiplier)
+	case <-time.After(2 * time.Second):
+		t.Fatal("config was not reloaded")
+	}
+	assert.Equal(t, 10, watcher.Config().Policy.MaxTransactions)
+}
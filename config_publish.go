@@ -0,0 +1,217 @@
+package sequence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/0xsequence/go-sequence/contracts/gen/walletutils"
+)
+
+// ErrWalletNotDeployed is returned when a wallet must be deployed, ie. to relay its bundles.
+var ErrWalletNotDeployed = errors.New("sequence: wallet is not deployed")
+
+// Config events of the wallet utils contract, as defined in wallet-contracts RequireUtils.sol
+var (
+	RequiredConfigEventSig = contracts.WalletUtils.ABI.Events["RequiredConfig"].ID
+	RequiredSignerEventSig = contracts.WalletUtils.ABI.Events["RequiredSigner"].ID
+)
+
+// requiredConfigMembers are the members of a config, as encoded in a RequiredConfig event.
+var requiredConfigMembers = func() abi.Arguments {
+	members, err := abi.NewType("tuple[]", "", []abi.ArgumentMarshaling{
+		{Name: "weight", Type: "uint256"},
+		{Name: "signer", Type: "address"},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return abi.Arguments{{Type: members}}
+}()
+
+// PublishConfig publishes the config of the wallet on the wallet utils contract with a bundle
+// of the wallet, so third parties can recover it from the chain, see LatestPublishedConfig. If
+// index is set, the signers of the config are also indexed, see PublishedWalletsOfSigner.
+//
+// The wallet must be deployed, as its bundles can't be relayed otherwise. The signers of a
+// wallet which isn't deployed yet can be published by any account, with the transaction of
+// PublishInitialSignersTransaction.
+func (w *Wallet) PublishConfig(ctx context.Context, index bool) (MetaTxnID, *types.Transaction, ethtxn.WaitReceipt, error) {
+	deployed, err := w.IsDeployed()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("sequence.Wallet#PublishConfig: %w", err)
+	}
+	if !deployed {
+		return "", nil, nil, fmt.Errorf("sequence.Wallet#PublishConfig: %w", ErrWalletNotDeployed)
+	}
+
+	txn, err := w.PublishConfigTransaction(index)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	signed, err := w.SignTransaction(ctx, txn)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("sequence.Wallet#PublishConfig: %w", err)
+	}
+	return w.SendTransaction(ctx, signed)
+}
+
+// PublishedConfig is a wallet config published on the wallet utils contract.
+type PublishedConfig struct {
+	Wallet      common.Address
+	ImageHash   common.Hash
+	Config      WalletConfig
+	BlockNumber uint64
+	TxHash      common.Hash
+}
+
+// LatestPublishedConfig returns the last config of the wallet published on the wallet utils
+// contract, or nil if none was.
+func (w *Wallet) LatestPublishedConfig(ctx context.Context) (*PublishedConfig, error) {
+	provider := w.providerOf(ctx)
+	if provider == nil {
+		return nil, ErrProviderNotSet
+	}
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+	return LatestPublishedConfig(ctx, provider, w.context, w.address)
+}
+
+// LatestPublishedConfig returns the last config of wallet published on the wallet utils
+// contract of walletContext, or nil if none was. It is looked up in the block of the last
+// update of the wallet recorded by the contract, so no range of blocks is scanned.
+func LatestPublishedConfig(ctx context.Context, provider *ethrpc.Provider, walletContext WalletContext, wallet common.Address) (*PublishedConfig, error) {
+	data, err := walletutils.WalletUtilsCalldata.LastWalletUpdate(wallet)
+	if err != nil {
+		return nil, err
+	}
+	var block *big.Int
+	if err := callWalletUtils(ctx, provider, walletContext, data, &block, "lastWalletUpdate"); err != nil {
+		return nil, fmt.Errorf("sequence.LatestPublishedConfig: %w", err)
+	}
+	if block.Sign() == 0 {
+		return nil, nil
+	}
+
+	configs, err := PublishedConfigs(ctx, provider, walletContext, wallet, block, block)
+	if err != nil {
+		return nil, err
+	}
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("sequence.LatestPublishedConfig: no config of %v in block %v", wallet.Hex(), block)
+	}
+	return configs[len(configs)-1], nil
+}
+
+// PublishedConfigs returns the configs of the wallet published on the wallet utils contract of
+// walletContext from block fromBlock to toBlock, oldest first. A nil toBlock is the latest
+// block.
+func PublishedConfigs(ctx context.Context, provider *ethrpc.Provider, walletContext WalletContext, wallet common.Address, fromBlock, toBlock *big.Int) ([]*PublishedConfig, error) {
+	logs, err := provider.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		Addresses: []common.Address{walletContext.UtilsAddress},
+		Topics:    [][]common.Hash{{RequiredConfigEventSig}, {common.BytesToHash(wallet.Bytes())}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sequence.PublishedConfigs: %w", err)
+	}
+
+	configs := make([]*PublishedConfig, 0, len(logs))
+	for i := range logs {
+		config, err := DecodeRequiredConfigEvent(&logs[i])
+		if err != nil {
+			return nil, fmt.Errorf("sequence.PublishedConfigs: %w", err)
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
+
+// DecodeRequiredConfigEvent decodes the config published by a RequiredConfig event, and
+// verifies it matches the image hash of the event.
+func DecodeRequiredConfigEvent(log *types.Log) (*PublishedConfig, error) {
+	if len(log.Topics) != 3 || log.Topics[0] != RequiredConfigEventSig {
+		return nil, fmt.Errorf("not a RequiredConfig event")
+	}
+
+	values, err := contracts.WalletUtils.ABI.Events["RequiredConfig"].Inputs.NonIndexed().Unpack(log.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RequiredConfig event: %w", err)
+	}
+	threshold := values[0].(*big.Int)
+	signers := values[1].([]byte)
+
+	values, err = requiredConfigMembers.Unpack(signers)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RequiredConfig signers: %w", err)
+	}
+	members := values[0].([]struct {
+		Weight *big.Int       `json:"weight"`
+		Signer common.Address `json:"signer"`
+	})
+
+	if !threshold.IsUint64() || threshold.Uint64() > 0xffff {
+		return nil, fmt.Errorf("invalid RequiredConfig threshold %v", threshold)
+	}
+	config := WalletConfig{Threshold: uint16(threshold.Uint64())}
+	for _, member := range members {
+		if !member.Weight.IsUint64() || member.Weight.Uint64() > 0xff {
+			return nil, fmt.Errorf("invalid RequiredConfig weight %v of %v", member.Weight, member.Signer.Hex())
+		}
+		config.Signers = append(config.Signers, WalletConfigSigner{Weight: uint8(member.Weight.Uint64()), Address: member.Signer})
+	}
+
+	imageHash, err := ImageHashOfWalletConfigBytes32(config)
+	if err != nil {
+		return nil, err
+	}
+	if common.Hash(imageHash) != log.Topics[2] {
+		return nil, fmt.Errorf("RequiredConfig signers don't match image hash %v", log.Topics[2].Hex())
+	}
+
+	return &PublishedConfig{
+		Wallet:      common.BytesToAddress(log.Topics[1].Bytes()),
+		ImageHash:   log.Topics[2],
+		Config:      config,
+		BlockNumber: log.BlockNumber,
+		TxHash:      log.TxHash,
+	}, nil
+}
+
+// PublishedWalletsOfSigner returns the wallets which signer was published as an indexed
+// signer of, on the wallet utils contract of walletContext from block fromBlock to toBlock. A
+// wallet may no longer have signer in its latest config.
+func PublishedWalletsOfSigner(ctx context.Context, provider *ethrpc.Provider, walletContext WalletContext, signer common.Address, fromBlock, toBlock *big.Int) ([]common.Address, error) {
+	logs, err := provider.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		Addresses: []common.Address{walletContext.UtilsAddress},
+		Topics:    [][]common.Hash{{RequiredSignerEventSig}, nil, {common.BytesToHash(signer.Bytes())}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sequence.PublishedWalletsOfSigner: %w", err)
+	}
+
+	seen := map[common.Address]bool{}
+	var wallets []common.Address
+	for _, log := range logs {
+		if len(log.Topics) != 3 {
+			continue
+		}
+		wallet := common.BytesToAddress(log.Topics[1].Bytes())
+		if !seen[wallet] {
+			seen[wallet] = true
+			wallets = append(wallets, wallet)
+		}
+	}
+	return wallets, nil
+}
@@ -0,0 +1,85 @@
+package sequence_test
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishedConfigs(t *testing.T) {
+	ctx := context.Background()
+	owner, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(owner)
+	assert.NoError(t, err)
+	imageHash, err := wallet.ImageHash()
+	assert.NoError(t, err)
+
+	// the RequiredConfig event of publishConfig for the wallet
+	members, err := abi.NewType("tuple[]", "", []abi.ArgumentMarshaling{
+		{Name: "weight", Type: "uint256"},
+		{Name: "signer", Type: "address"},
+	})
+	assert.NoError(t, err)
+	signers, err := abi.Arguments{{Type: members}}.Pack([]struct {
+		Weight *big.Int
+		Signer common.Address
+	}{{Weight: big.NewInt(1), Signer: owner.Address()}})
+	assert.NoError(t, err)
+	data, err := contracts.WalletUtils.ABI.Events["RequiredConfig"].Inputs.NonIndexed().Pack(big.NewInt(1), signers)
+	assert.NoError(t, err)
+	log := types.Log{
+		Address: wallet.GetWalletContext().UtilsAddress,
+		Topics:  []common.Hash{sequence.RequiredConfigEventSig, common.BytesToHash(wallet.Address().Bytes()), imageHash},
+		Data:    data,
+	}
+
+	// the wallet utils, on which the config of the wallet is published at block 42
+	chain := memchain.New(memchain.Options{ChainID: big.NewInt(1)})
+	for chain.Head() < 41 {
+		chain.Mine()
+	}
+	lastWalletUpdate := contracts.WalletUtils.ABI.Methods["lastWalletUpdate"]
+	chain.Deploy(log.Address, memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		if bytes.HasPrefix(input, lastWalletUpdate.ID) {
+			return env.Load(common.Hash{}).Bytes(), nil
+		}
+		env.Log(log.Topics, log.Data)
+		env.Store(common.Hash{}, common.BigToHash(new(big.Int).SetUint64(env.BlockNumber)))
+		return nil, nil
+	}))
+	publish, err := wallet.PublishConfigTransaction(true)
+	assert.NoError(t, err)
+	eoa, err := testutil.MemChainEOA(chain, 2, big.NewInt(1e18))
+	assert.NoError(t, err)
+	txn, err := eoa.NewTransaction(ctx, &ethtxn.TransactionRequest{To: &publish.To, Data: publish.Data})
+	assert.NoError(t, err)
+	_, _, err = eoa.SendTransaction(ctx, txn)
+	assert.NoError(t, err)
+	provider := chain.Provider()
+	assert.NoError(t, wallet.SetProvider(provider))
+
+	published, err := wallet.LatestPublishedConfig(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.Address(), published.Wallet)
+	assert.Equal(t, imageHash, published.ImageHash)
+	assert.Equal(t, wallet.GetWalletConfig(), published.Config)
+	assert.Equal(t, uint64(42), published.BlockNumber)
+
+	// a config which doesn't match the image hash of the event
+	log.Topics[2] = common.HexToHash("0x1234")
+	_, err = sequence.DecodeRequiredConfigEvent(&log)
+	assert.Error(t, err)
+}
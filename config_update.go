@@ -0,0 +1,137 @@
+package sequence
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence/contracts/gen/walletmain"
+	"github.com/0xsequence/go-sequence/contracts/gen/walletupgradable"
+)
+
+// ErrConfigUnchanged is returned when updating the config of a wallet to the config it has.
+var ErrConfigUnchanged = fmt.Errorf("wallet config is unchanged")
+
+// UpdateConfigTransactions returns the transactions of the wallet at walletAddress which update
+// its config to config. A wallet which never updated its config is on the main module, which
+// has no storage for it: unless upgraded, the wallet is first upgraded to the main module
+// upgradable of walletContext, which stores the image hash of its config.
+func UpdateConfigTransactions(walletAddress common.Address, walletContext WalletContext, config WalletConfig, upgraded bool) (Transactions, error) {
+	imageHash, err := ImageHashOfWalletConfigBytes32(config)
+	if err != nil {
+		return nil, err
+	}
+	update, err := walletupgradable.WalletUpgradableCalldata.UpdateImageHash(imageHash)
+	if err != nil {
+		return nil, err
+	}
+	if upgraded {
+		return Transactions{{To: walletAddress, Data: update, RevertOnError: true}}, nil
+	}
+
+	upgrade, err := walletmain.WalletMainCalldata.UpdateImplementation(walletContext.MainModuleUpgradableAddress)
+	if err != nil {
+		return nil, err
+	}
+	return Transactions{
+		{To: walletAddress, Data: upgrade, RevertOnError: true},
+		{To: walletAddress, Data: update, RevertOnError: true},
+	}, nil
+}
+
+// ImageHashOfWallet returns the image hash in the storage of the wallet at walletAddress, or
+// the zero hash if it isn't deployed or never updated its config.
+func ImageHashOfWallet(ctx context.Context, provider *ethrpc.Provider, walletAddress common.Address) (common.Hash, error) {
+	data, err := walletupgradable.WalletUpgradableCalldata.ImageHash()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	output, err := provider.CallContract(ctx, ethereum.CallMsg{To: &walletAddress, Data: data}, callBlockNumber(ctx))
+	if err != nil {
+		// the main module of a wallet which never updated its config reverts
+		return common.Hash{}, nil
+	}
+	if len(output) != 32 {
+		return common.Hash{}, nil
+	}
+	return common.BytesToHash(output), nil
+}
+
+// UpdateConfigTransactions returns the transactions updating the config of the wallet to
+// config, once checked that the current config of w is the config of the wallet on chain,
+// which signs them.
+func (w *Wallet) UpdateConfigTransactions(ctx context.Context, config WalletConfig) (Transactions, error) {
+	provider := w.providerOf(ctx)
+	if provider == nil {
+		return nil, ErrProviderNotSet
+	}
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	config = config.Clone()
+	if !w.skipSortSigners {
+		if err := SortWalletConfig(config); err != nil {
+			return nil, fmt.Errorf("sequence.Wallet#UpdateConfigTransactions: %w", err)
+		}
+	}
+	if _, err := IsWalletConfigUsable(config); err != nil {
+		return nil, fmt.Errorf("sequence.Wallet#UpdateConfigTransactions: %w", err)
+	}
+
+	imageHash, err := w.ImageHash()
+	if err != nil {
+		return nil, fmt.Errorf("sequence.Wallet#UpdateConfigTransactions: %w", err)
+	}
+	newImageHash, err := ImageHashOfWalletConfigBytes32(config)
+	if err != nil {
+		return nil, fmt.Errorf("sequence.Wallet#UpdateConfigTransactions: %w", err)
+	}
+	if common.Hash(newImageHash) == imageHash {
+		return nil, fmt.Errorf("sequence.Wallet#UpdateConfigTransactions: %w", ErrConfigUnchanged)
+	}
+
+	stored, err := ImageHashOfWallet(ctx, provider, w.address)
+	if err != nil {
+		return nil, fmt.Errorf("sequence.Wallet#UpdateConfigTransactions: %w", err)
+	}
+	upgraded := stored != (common.Hash{})
+	if upgraded && stored != imageHash {
+		return nil, fmt.Errorf("sequence.Wallet#UpdateConfigTransactions: the config of the wallet on chain is %v, not %v", stored.Hex(), imageHash.Hex())
+	}
+	if !upgraded {
+		initial, err := AddressFromWalletConfig(w.config, w.context)
+		if err != nil {
+			return nil, fmt.Errorf("sequence.Wallet#UpdateConfigTransactions: %w", err)
+		}
+		if initial != w.address {
+			return nil, fmt.Errorf("sequence.Wallet#UpdateConfigTransactions: the config of the wallet is not its initial config, and was never updated on chain")
+		}
+	}
+
+	return UpdateConfigTransactions(w.address, w.context, config, upgraded)
+}
+
+// SignConfigUpdate signs the transactions updating the config of the wallet to config with
+// its current config, see UpdateConfigTransactions.
+func (w *Wallet) SignConfigUpdate(ctx context.Context, config WalletConfig) (*SignedTransactions, error) {
+	txns, err := w.UpdateConfigTransactions(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	return w.SignTransactions(ctx, txns)
+}
+
+// UpdateConfig relays the update of the config of the wallet to config, ie. to rotate its
+// signers. The wallet must be deployed, or be deployed by the relayer. Once the update is
+// executed, the wallet signs with config, see UseConfig.
+func (w *Wallet) UpdateConfig(ctx context.Context, config WalletConfig) (MetaTxnID, *types.Transaction, ethtxn.WaitReceipt, error) {
+	signed, err := w.SignConfigUpdate(ctx, config)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return w.SendTransactions(ctx, signed)
+}
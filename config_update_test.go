@@ -0,0 +1,97 @@
+package sequence_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+// relayedRelayer is a relayer which records the bundles it relays.
+type relayedRelayer struct {
+	nonceRelayer
+	relayed []*sequence.SignedTransactions
+}
+
+func (r *relayedRelayer) Relay(ctx context.Context, signedTxs *sequence.SignedTransactions) (sequence.MetaTxnID, *types.Transaction, ethtxn.WaitReceipt, error) {
+	r.relayed = append(r.relayed, signedTxs)
+	return sequence.MetaTxnID(signedTxs.Digest.Hex()[2:]), nil, nil, nil
+}
+
+func TestWalletUpdateConfig(t *testing.T) {
+	ctx := context.Background()
+	owners := make([]*ethwallet.Wallet, 3)
+	configs := make([]sequence.WalletConfig, 3)
+	for i := range owners {
+		var err error
+		owners[i], err = ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(uint64(i + 1)))
+		assert.NoError(t, err)
+		configs[i] = sequence.WalletConfig{Threshold: 1, Signers: sequence.WalletConfigSigners{{Weight: 1, Address: owners[i].Address()}}}
+	}
+
+	relayer := &relayedRelayer{nonceRelayer: nonceRelayer{nonce: big.NewInt(0)}}
+
+	wallet, err := sequence.NewWalletSingleOwner(owners[0])
+	assert.NoError(t, err)
+
+	// the image hash in the storage of the wallet, none until its config is updated, when the
+	// main module of the wallet reverts
+	var stored common.Hash
+	chain := memchain.New(memchain.Options{ChainID: big.NewInt(1)})
+	chain.Deploy(wallet.Address(), memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		if stored == (common.Hash{}) {
+			return nil, memchain.Revert("")
+		}
+		return stored.Bytes(), nil
+	}))
+	assert.NoError(t, wallet.Connect(chain.Provider(), relayer))
+
+	_, err = wallet.UpdateConfigTransactions(ctx, configs[0])
+	assert.True(t, errors.Is(err, sequence.ErrConfigUnchanged))
+	_, err = wallet.UpdateConfigTransactions(ctx, sequence.WalletConfig{Threshold: 2, Signers: configs[1].Signers})
+	assert.Error(t, err)
+
+	// the first update of the wallet upgrades it, and is signed by its initial config
+	_, _, _, err = wallet.UpdateConfig(ctx, configs[1])
+	assert.NoError(t, err)
+	assert.Len(t, relayer.relayed, 1)
+	expected, err := sequence.UpdateConfigTransactions(wallet.Address(), wallet.GetWalletContext(), configs[1], false)
+	assert.NoError(t, err)
+	assert.Len(t, expected, 2)
+	assert.True(t, expected.Equal(relayer.relayed[0].Transactions))
+	assert.Equal(t, configs[0], relayer.relayed[0].WalletConfig)
+
+	// once updated, the wallet signs with its new config, and only updates its image hash
+	imageHash, err := sequence.ImageHashOfWalletConfigBytes32(configs[1])
+	assert.NoError(t, err)
+	stored = imageHash
+	updated, err := wallet.UseConfig(configs[1])
+	assert.NoError(t, err)
+	updated, err = updated.UseSigners(owners[1])
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.Address(), updated.Address())
+
+	signed, err := updated.SignConfigUpdate(ctx, configs[2])
+	assert.NoError(t, err)
+	expected, err = sequence.UpdateConfigTransactions(wallet.Address(), wallet.GetWalletContext(), configs[2], true)
+	assert.NoError(t, err)
+	assert.Len(t, expected, 1)
+	assert.True(t, expected.Equal(signed.Transactions))
+	assert.Equal(t, configs[1], signed.WalletConfig)
+
+	// a config which is not the config on chain can't sign the update
+	_, err = wallet.UpdateConfigTransactions(ctx, configs[2])
+	assert.ErrorContains(t, err, "on chain")
+	stored = common.Hash{}
+	_, err = updated.UpdateConfigTransactions(ctx, configs[2])
+	assert.ErrorContains(t, err, "initial config")
+}
@@ -0,0 +1,232 @@
+// Package conformance defines the canonical test vectors of go-sequence: wallet configs
+// with their image hashes and addresses, bundle digests and meta-transaction IDs, and
+// signatures of bundles and messages. Other implementations of Sequence check their
+// results against the vectors, and go-sequence checks it still reproduces them.
+//
+// The vectors are generated by cmd/genvectors from fixed keys, and are regenerated when
+// the wallet contracts change, ie.
+//
+//	go run ./cmd/genvectors -out conformance/testdata/vectors.json
+//
+// The gas vectors are the calldata and intrinsic gas of canonical operations of a wallet,
+// from its deployment to the update of its config, see GenerateGas. They are regenerated
+// with
+//
+//	go run ./cmd/genvectors -gas -out conformance/testdata/gas.json
+package conformance
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/core/digest"
+)
+
+// Version is the version of the format of the vectors.
+const Version = 1
+
+// Vectors are the test vectors of a wallet context.
+type Vectors struct {
+	Version  int                    `json:"version"`
+	Context  sequence.WalletContext `json:"context"`
+	Signers  []Signer               `json:"signers"`
+	Configs  []ConfigVector         `json:"configs"`
+	Bundles  []BundleVector         `json:"bundles"`
+	Messages []MessageVector        `json:"messages"`
+}
+
+// Signer is a key signing the vectors. The keys are derived from their index, and must
+// never hold funds.
+type Signer struct {
+	PrivateKey hexutil.Bytes  `json:"privateKey"`
+	Address    common.Address `json:"address"`
+}
+
+// ConfigVector is a wallet config, with its image hash and counterfactual address.
+type ConfigVector struct {
+	Name      string                `json:"name"`
+	Config    sequence.WalletConfig `json:"config"`
+	ImageHash common.Hash           `json:"imageHash"`
+	Address   common.Address        `json:"address"`
+
+	// SignedBy are the indexes of the signers which sign for the config in the bundle and
+	// message vectors.
+	SignedBy []int `json:"signedBy"`
+}
+
+// TransactionVector is a transaction of a bundle.
+type TransactionVector struct {
+	DelegateCall  bool           `json:"delegateCall"`
+	RevertOnError bool           `json:"revertOnError"`
+	GasLimit      *hexutil.Big   `json:"gasLimit"`
+	To            common.Address `json:"to"`
+	Value         *hexutil.Big   `json:"value"`
+	Data          hexutil.Bytes  `json:"data"`
+}
+
+// BundleVector is a bundle signed by a wallet of the config vectors.
+type BundleVector struct {
+	Name         string              `json:"name"`
+	Config       int                 `json:"config"`
+	ChainID      *hexutil.Big        `json:"chainId"`
+	Nonce        *hexutil.Big        `json:"nonce"`
+	Transactions []TransactionVector `json:"transactions"`
+
+	Digest    common.Hash   `json:"digest"`
+	SubDigest common.Hash   `json:"subDigest"`
+	MetaTxnID string        `json:"metaTxnId"`
+	Signature hexutil.Bytes `json:"signature"`
+	Execdata  hexutil.Bytes `json:"execdata"`
+}
+
+// MessageVector is a message signed by a wallet of the config vectors, with the digest of
+// eth_sign.
+type MessageVector struct {
+	Name    string        `json:"name"`
+	Config  int           `json:"config"`
+	ChainID *hexutil.Big  `json:"chainId"`
+	Message hexutil.Bytes `json:"message"`
+
+	Digest    common.Hash   `json:"digest"`
+	SubDigest common.Hash   `json:"subDigest"`
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+// SignerKey returns the private key of the signer at index.
+func SignerKey(index int) []byte {
+	return crypto.Keccak256([]byte(fmt.Sprintf("go-sequence conformance signer %d", index)))
+}
+
+func (t TransactionVector) transaction() *sequence.Transaction {
+	return &sequence.Transaction{
+		DelegateCall:  t.DelegateCall,
+		RevertOnError: t.RevertOnError,
+		GasLimit:      t.GasLimit.ToInt(),
+		To:            t.To,
+		Value:         t.Value.ToInt(),
+		Data:          t.Data,
+	}
+}
+
+func (b *BundleVector) bundle() *sequence.Transaction {
+	txns := make(sequence.Transactions, len(b.Transactions))
+	for i, txn := range b.Transactions {
+		txns[i] = txn.transaction()
+	}
+	return &sequence.Transaction{Transactions: txns, Nonce: b.Nonce.ToInt()}
+}
+
+// Verify recomputes the vectors from their inputs, and returns the first which does not
+// match. Signatures are checked by recovering the config which signed them.
+func Verify(v *Vectors) error {
+	if v.Version != Version {
+		return fmt.Errorf("conformance: unsupported version %d", v.Version)
+	}
+
+	for i, signer := range v.Signers {
+		wallet, err := ethwallet.NewWalletFromPrivateKey(common.Bytes2Hex(signer.PrivateKey))
+		if err != nil {
+			return fmt.Errorf("conformance: signer %d: %w", i, err)
+		}
+		if wallet.Address() != signer.Address {
+			return fmt.Errorf("conformance: signer %d: address is %v, expected %v", i, wallet.Address(), signer.Address)
+		}
+	}
+
+	for _, vector := range v.Configs {
+		imageHash, err := sequence.ImageHashOfWalletConfigBytes32(vector.Config)
+		if err != nil {
+			return fmt.Errorf("conformance: config %s: %w", vector.Name, err)
+		}
+		if imageHash != vector.ImageHash {
+			return fmt.Errorf("conformance: config %s: image hash is %v, expected %v", vector.Name, common.Hash(imageHash), vector.ImageHash)
+		}
+		address, err := sequence.AddressFromWalletConfig(vector.Config, v.Context)
+		if err != nil {
+			return fmt.Errorf("conformance: config %s: %w", vector.Name, err)
+		}
+		if address != vector.Address {
+			return fmt.Errorf("conformance: config %s: address is %v, expected %v", vector.Name, address, vector.Address)
+		}
+	}
+
+	for _, vector := range v.Bundles {
+		if vector.Config < 0 || vector.Config >= len(v.Configs) {
+			return fmt.Errorf("conformance: bundle %s: unknown config %d", vector.Name, vector.Config)
+		}
+		config := v.Configs[vector.Config]
+
+		bundle := vector.bundle()
+		bundleDigest, err := bundle.Digest()
+		if err != nil {
+			return fmt.Errorf("conformance: bundle %s: %w", vector.Name, err)
+		}
+		if bundleDigest != vector.Digest {
+			return fmt.Errorf("conformance: bundle %s: digest is %v, expected %v", vector.Name, bundleDigest, vector.Digest)
+		}
+		metaTxnID, subDigest, err := sequence.ComputeMetaTxnIDFromDigest(vector.ChainID.ToInt(), config.Address, bundleDigest)
+		if err != nil {
+			return fmt.Errorf("conformance: bundle %s: %w", vector.Name, err)
+		}
+		if subDigest != vector.SubDigest || string(metaTxnID) != vector.MetaTxnID {
+			return fmt.Errorf("conformance: bundle %s: meta-transaction id is %s, expected %s", vector.Name, metaTxnID, vector.MetaTxnID)
+		}
+		if err := verifySignature(v, config, vector.ChainID.ToInt(), subDigest, vector.Signature); err != nil {
+			return fmt.Errorf("conformance: bundle %s: %w", vector.Name, err)
+		}
+
+		bundle.Signature = vector.Signature
+		execdata, err := bundle.Execdata()
+		if err != nil {
+			return fmt.Errorf("conformance: bundle %s: %w", vector.Name, err)
+		}
+		if !bytes.Equal(execdata, vector.Execdata) {
+			return fmt.Errorf("conformance: bundle %s: execdata does not match", vector.Name)
+		}
+	}
+
+	for _, vector := range v.Messages {
+		if vector.Config < 0 || vector.Config >= len(v.Configs) {
+			return fmt.Errorf("conformance: message %s: unknown config %d", vector.Name, vector.Config)
+		}
+		config := v.Configs[vector.Config]
+
+		messageDigest := digest.EthSignDigest(vector.Message)
+		if messageDigest != vector.Digest {
+			return fmt.Errorf("conformance: message %s: digest is %v, expected %v", vector.Name, messageDigest, vector.Digest)
+		}
+		subDigest, err := sequence.SubDigest(vector.ChainID.ToInt(), config.Address, messageDigest)
+		if err != nil {
+			return fmt.Errorf("conformance: message %s: %w", vector.Name, err)
+		}
+		if common.BytesToHash(subDigest) != vector.SubDigest {
+			return fmt.Errorf("conformance: message %s: sub-digest is %x, expected %v", vector.Name, subDigest, vector.SubDigest)
+		}
+		if err := verifySignature(v, config, vector.ChainID.ToInt(), common.BytesToHash(subDigest), vector.Signature); err != nil {
+			return fmt.Errorf("conformance: message %s: %w", vector.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func verifySignature(v *Vectors, config ConfigVector, chainID *big.Int, subDigest common.Hash, signature []byte) error {
+	recovered, err := sequence.RecoverWalletConfigFromDigest(subDigest[:], signature, v.Context, chainID, nil)
+	if err != nil {
+		return err
+	}
+	imageHash, err := sequence.ImageHashOfWalletConfigBytes32(recovered)
+	if err != nil {
+		return err
+	}
+	if imageHash != config.ImageHash {
+		return fmt.Errorf("signature recovers image hash %v, expected %v", common.Hash(imageHash), config.ImageHash)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package conformance_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/conformance"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVectors(t *testing.T) {
+	data, err := os.ReadFile("testdata/vectors.json")
+	assert.NoError(t, err)
+
+	var vectors conformance.Vectors
+	assert.NoError(t, json.Unmarshal(data, &vectors))
+	assert.NoError(t, conformance.Verify(&vectors))
+
+	// the committed vectors are the ones generated, see cmd/genvectors
+	generated, err := conformance.Generate(sequence.SequenceContext())
+	assert.NoError(t, err)
+	generatedData, err := json.MarshalIndent(generated, "", "  ")
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(data), string(generatedData))
+
+	// tampered vectors are rejected
+	vectors.Bundles[1].Transactions[0].Data[0] ^= 1
+	assert.Error(t, conformance.Verify(&vectors))
+	vectors.Bundles[1].Transactions[0].Data[0] ^= 1
+
+	vectors.Messages[0].Signature, vectors.Messages[1].Signature = vectors.Messages[1].Signature, vectors.Messages[0].Signature
+	assert.Error(t, conformance.Verify(&vectors))
+}
+
+func TestGasVectors(t *testing.T) {
+	data, err := os.ReadFile("testdata/gas.json")
+	assert.NoError(t, err)
+
+	var vectors conformance.GasVectors
+	assert.NoError(t, json.Unmarshal(data, &vectors))
+	assert.NoError(t, conformance.VerifyGas(&vectors))
+
+	// the committed calldata is the one encoded, see cmd/genvectors -gas
+	generated, err := conformance.GenerateGas(sequence.SequenceContext())
+	assert.NoError(t, err)
+	assert.Len(t, generated.Operations, 4)
+	for i, operation := range generated.Operations {
+		assert.Equal(t, vectors.Operations[i].Name, operation.Name)
+		assert.Equal(t, vectors.Operations[i].Calldata.String(), operation.Calldata.String(), "calldata of %s changed", operation.Name)
+		assert.Equal(t, vectors.Operations[i].IntrinsicGas, operation.IntrinsicGas, "intrinsic gas of %s changed", operation.Name)
+	}
+
+	// tampered vectors are rejected
+	vectors.Operations[0].Calldata[4] = 1
+	assert.Error(t, conformance.VerifyGas(&vectors))
+}
@@ -0,0 +1,177 @@
+package conformance
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/0xsequence/go-sequence/contracts/gen/walletmain"
+	"github.com/0xsequence/go-sequence/contracts/gen/walletupgradable"
+)
+
+// GasVersion is the version of the format of the gas vectors.
+const GasVersion = 1
+
+var gasEstimator = sequence.NewEstimator()
+
+// GasVectors are the calldata of the canonical operations of a wallet context, with their
+// intrinsic gas. A change to the calldata of an operation changes its vector, so encoding
+// regressions are caught before they reach a chain.
+//
+// The execution gas of the operations depends on the state of the chain they run on, and is
+// not part of the vectors.
+type GasVectors struct {
+	Version    int                    `json:"version"`
+	Context    sequence.WalletContext `json:"context"`
+	Operations []GasVector            `json:"operations"`
+}
+
+// GasVector is the calldata of an operation sent to To, with its size and intrinsic gas: the
+// base cost of a transaction and the cost of its calldata.
+type GasVector struct {
+	Name         string         `json:"name"`
+	To           common.Address `json:"to"`
+	Calldata     hexutil.Bytes  `json:"calldata"`
+	CalldataSize int            `json:"calldataSize"`
+	IntrinsicGas uint64         `json:"intrinsicGas"`
+}
+
+// GenerateGas generates the gas vectors of walletContext, for a wallet of a single signer of
+// the conformance vectors:
+//
+//   - deploy: the deployment of the wallet by the factory,
+//   - transfer: the execution of a transfer of the native token,
+//   - batch-10: the execution of a batch of 10 token transfers,
+//   - config-update: the execution of an update of the config of the wallet to a 2 of 3.
+func GenerateGas(walletContext sequence.WalletContext) (*GasVectors, error) {
+	v := &GasVectors{Version: GasVersion, Context: walletContext}
+
+	keys := make([]*ethwallet.Wallet, signerCount)
+	for i := range keys {
+		key, err := ethwallet.NewWalletFromPrivateKey(common.Bytes2Hex(SignerKey(i)))
+		if err != nil {
+			return nil, fmt.Errorf("conformance: signer %d: %w", i, err)
+		}
+		keys[i] = key
+	}
+
+	config := sequence.WalletConfig{Threshold: 1, Signers: sequence.WalletConfigSigners{{Weight: 1, Address: keys[0].Address()}}}
+	wallet, err := sequence.NewWallet(sequence.WalletOptions{Config: config, Context: &walletContext}, keys[0])
+	if err != nil {
+		return nil, fmt.Errorf("conformance: wallet: %w", err)
+	}
+	wallet.SetChainID(big.NewInt(1))
+
+	_, factory, deployData, err := sequence.EncodeWalletDeployment(config, walletContext)
+	if err != nil {
+		return nil, fmt.Errorf("conformance: deploy: %w", err)
+	}
+	v.add("deploy", factory, deployData)
+
+	execute := func(name string, nonce int64, txns sequence.Transactions) error {
+		for _, txn := range txns {
+			if txn.GasLimit == nil {
+				txn.GasLimit = new(big.Int)
+			}
+			if txn.Value == nil {
+				txn.Value = new(big.Int)
+			}
+		}
+		bundle := &sequence.Transaction{Transactions: txns, Nonce: big.NewInt(nonce)}
+		bundleDigest, err := bundle.Digest()
+		if err != nil {
+			return fmt.Errorf("conformance: %s: %w", name, err)
+		}
+		bundle.Signature, _, err = wallet.SignDigest(bundleDigest)
+		if err != nil {
+			return fmt.Errorf("conformance: %s: %w", name, err)
+		}
+		execdata, err := bundle.Execdata()
+		if err != nil {
+			return fmt.Errorf("conformance: %s: %w", name, err)
+		}
+		v.add(name, wallet.Address(), execdata)
+		return nil
+	}
+
+	err = execute("transfer", 0, sequence.Transactions{
+		{RevertOnError: true, To: common.HexToAddress("0x0000000000000000000000000000000000000001"), Value: big.NewInt(1e18)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var batch sequence.Transactions
+	for i := 1; i <= 10; i++ {
+		data, err := contracts.IERC20.Encode("transfer", common.BigToAddress(big.NewInt(int64(i))), big.NewInt(int64(i)*1e6))
+		if err != nil {
+			return nil, fmt.Errorf("conformance: batch-10: %w", err)
+		}
+		batch = append(batch, &sequence.Transaction{RevertOnError: true, To: common.HexToAddress("0x000000000000000000000000000000000000dead"), Data: data})
+	}
+	if err := execute("batch-10", 1, batch); err != nil {
+		return nil, err
+	}
+
+	updated := sequence.WalletConfig{Threshold: 2}
+	for _, key := range keys {
+		updated.Signers = append(updated.Signers, sequence.WalletConfigSigner{Weight: 1, Address: key.Address()})
+	}
+	if err := sequence.SortWalletConfig(updated); err != nil {
+		return nil, fmt.Errorf("conformance: config-update: %w", err)
+	}
+	imageHash, err := sequence.ImageHashOfWalletConfigBytes32(updated)
+	if err != nil {
+		return nil, fmt.Errorf("conformance: config-update: %w", err)
+	}
+	upgrade, err := walletmain.WalletMainCalldata.UpdateImplementation(walletContext.MainModuleUpgradableAddress)
+	if err != nil {
+		return nil, fmt.Errorf("conformance: config-update: %w", err)
+	}
+	update, err := walletupgradable.WalletUpgradableCalldata.UpdateImageHash(imageHash)
+	if err != nil {
+		return nil, fmt.Errorf("conformance: config-update: %w", err)
+	}
+	err = execute("config-update", 2, sequence.Transactions{
+		{RevertOnError: true, To: wallet.Address(), Data: upgrade},
+		{RevertOnError: true, To: wallet.Address(), Data: update},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func (v *GasVectors) add(name string, to common.Address, calldata []byte) {
+	v.Operations = append(v.Operations, GasVector{
+		Name:         name,
+		To:           to,
+		Calldata:     calldata,
+		CalldataSize: len(calldata),
+		IntrinsicGas: gasEstimator.CalldataCost(calldata),
+	})
+}
+
+// VerifyGas checks the sizes and intrinsic gas of the gas vectors match their calldata, and
+// returns the first which does not. Whether the calldata is still the one generated is
+// checked against GenerateGas.
+func VerifyGas(v *GasVectors) error {
+	if v.Version != GasVersion {
+		return fmt.Errorf("conformance: unsupported gas version %d", v.Version)
+	}
+	for _, vector := range v.Operations {
+		if len(vector.Calldata) != vector.CalldataSize {
+			return fmt.Errorf("conformance: operation %s: calldata size is %d, expected %d", vector.Name, len(vector.Calldata), vector.CalldataSize)
+		}
+		gas := gasEstimator.CalldataCost(vector.Calldata)
+		if gas != vector.IntrinsicGas {
+			return fmt.Errorf("conformance: operation %s: intrinsic gas is %d, expected %d", vector.Name, gas, vector.IntrinsicGas)
+		}
+	}
+	return nil
+}
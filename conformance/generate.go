@@ -0,0 +1,179 @@
+package conformance
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/core/digest"
+)
+
+// signerCount is the number of keys signing the vectors.
+const signerCount = 3
+
+// Generate generates the vectors of walletContext. Generating is deterministic, so the
+// same vectors are generated until the encoding of configs, bundles or signatures
+// changes.
+func Generate(walletContext sequence.WalletContext) (*Vectors, error) {
+	v := &Vectors{Version: Version, Context: walletContext}
+
+	wallets := make([]*ethwallet.Wallet, signerCount)
+	for i := range wallets {
+		key := SignerKey(i)
+		wallet, err := ethwallet.NewWalletFromPrivateKey(common.Bytes2Hex(key))
+		if err != nil {
+			return nil, fmt.Errorf("conformance: signer %d: %w", i, err)
+		}
+		wallets[i] = wallet
+		v.Signers = append(v.Signers, Signer{PrivateKey: key, Address: wallet.Address()})
+	}
+
+	configs := []struct {
+		name      string
+		threshold uint16
+		weights   []uint8
+		signedBy  []int
+	}{
+		{"single-signer", 1, []uint8{1}, []int{0}},
+		{"2-of-3", 2, []uint8{1, 1, 1}, []int{0, 2}},
+		{"weighted", 3, []uint8{2, 1, 1}, []int{0, 1}},
+		{"full-weight-signer", 2, []uint8{1, 2, 1}, []int{1}},
+	}
+	for _, c := range configs {
+		config := sequence.WalletConfig{Threshold: c.threshold}
+		for i, weight := range c.weights {
+			config.Signers = append(config.Signers, sequence.WalletConfigSigner{Weight: weight, Address: wallets[i].Address()})
+		}
+		if err := sequence.SortWalletConfig(config); err != nil {
+			return nil, fmt.Errorf("conformance: config %s: %w", c.name, err)
+		}
+
+		imageHash, err := sequence.ImageHashOfWalletConfigBytes32(config)
+		if err != nil {
+			return nil, fmt.Errorf("conformance: config %s: %w", c.name, err)
+		}
+		address, err := sequence.AddressFromWalletConfig(config, walletContext)
+		if err != nil {
+			return nil, fmt.Errorf("conformance: config %s: %w", c.name, err)
+		}
+		v.Configs = append(v.Configs, ConfigVector{Name: c.name, Config: config, ImageHash: imageHash, Address: address, SignedBy: c.signedBy})
+	}
+
+	bundles := []struct {
+		name    string
+		config  int
+		chainID int64
+		nonce   *big.Int
+		txns    []TransactionVector
+	}{
+		{"transfer", 0, 1, big.NewInt(0), []TransactionVector{
+			{RevertOnError: true, To: common.HexToAddress("0x0000000000000000000000000000000000000001"), Value: hexBig(big.NewInt(1e18))},
+		}},
+		{"batch", 1, 137, big.NewInt(7), []TransactionVector{
+			{RevertOnError: true, GasLimit: hexBig(big.NewInt(100000)), To: common.HexToAddress("0x000000000000000000000000000000000000dead"), Data: hexutil.MustDecode("0xa9059cbb0000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000000a")},
+			{DelegateCall: true, To: common.HexToAddress("0x000000000000000000000000000000000000beef"), Data: hexutil.MustDecode("0x12345678")},
+			{To: common.HexToAddress("0x0000000000000000000000000000000000000002"), Value: hexBig(big.NewInt(5))},
+		}},
+		{"nonce-space", 2, 42161, new(big.Int).Or(new(big.Int).Lsh(big.NewInt(1), 96), big.NewInt(3)), []TransactionVector{
+			{RevertOnError: true, To: common.HexToAddress("0x0000000000000000000000000000000000000003"), Data: hexutil.MustDecode("0x")},
+		}},
+		{"full-weight-signer", 3, 10, big.NewInt(1), []TransactionVector{
+			{RevertOnError: true, To: common.HexToAddress("0x0000000000000000000000000000000000000004"), Value: hexBig(big.NewInt(1))},
+		}},
+	}
+	for _, b := range bundles {
+		vector := BundleVector{Name: b.name, Config: b.config, ChainID: hexBig(big.NewInt(b.chainID)), Nonce: hexBig(b.nonce), Transactions: b.txns}
+		for i := range vector.Transactions {
+			if vector.Transactions[i].GasLimit == nil {
+				vector.Transactions[i].GasLimit = hexBig(new(big.Int))
+			}
+			if vector.Transactions[i].Value == nil {
+				vector.Transactions[i].Value = hexBig(new(big.Int))
+			}
+		}
+
+		bundle := vector.bundle()
+		bundleDigest, err := bundle.Digest()
+		if err != nil {
+			return nil, fmt.Errorf("conformance: bundle %s: %w", b.name, err)
+		}
+		config := v.Configs[b.config]
+		metaTxnID, subDigest, err := sequence.ComputeMetaTxnIDFromDigest(big.NewInt(b.chainID), config.Address, bundleDigest)
+		if err != nil {
+			return nil, fmt.Errorf("conformance: bundle %s: %w", b.name, err)
+		}
+		signature, err := sign(v, wallets, config, big.NewInt(b.chainID), bundleDigest)
+		if err != nil {
+			return nil, fmt.Errorf("conformance: bundle %s: %w", b.name, err)
+		}
+		bundle.Signature = signature
+		execdata, err := bundle.Execdata()
+		if err != nil {
+			return nil, fmt.Errorf("conformance: bundle %s: %w", b.name, err)
+		}
+
+		vector.Digest = bundleDigest
+		vector.SubDigest = subDigest
+		vector.MetaTxnID = string(metaTxnID)
+		vector.Signature = signature
+		vector.Execdata = execdata
+		v.Bundles = append(v.Bundles, vector)
+	}
+
+	messages := []struct {
+		name    string
+		config  int
+		chainID int64
+		message []byte
+	}{
+		{"hello", 0, 1, []byte("hello sequence")},
+		{"empty", 1, 137, []byte{}},
+		{"binary", 2, 1, hexutil.MustDecode("0x00ff1901")},
+	}
+	for _, m := range messages {
+		config := v.Configs[m.config]
+		messageDigest := digest.EthSignDigest(m.message)
+		subDigest, err := sequence.SubDigest(big.NewInt(m.chainID), config.Address, messageDigest)
+		if err != nil {
+			return nil, fmt.Errorf("conformance: message %s: %w", m.name, err)
+		}
+		signature, err := sign(v, wallets, config, big.NewInt(m.chainID), messageDigest)
+		if err != nil {
+			return nil, fmt.Errorf("conformance: message %s: %w", m.name, err)
+		}
+		v.Messages = append(v.Messages, MessageVector{
+			Name:      m.name,
+			Config:    m.config,
+			ChainID:   hexBig(big.NewInt(m.chainID)),
+			Message:   m.message,
+			Digest:    messageDigest,
+			SubDigest: common.BytesToHash(subDigest),
+			Signature: signature,
+		})
+	}
+
+	return v, nil
+}
+
+// sign signs digest with the wallet of config, by its signers of the vectors.
+func sign(v *Vectors, wallets []*ethwallet.Wallet, config ConfigVector, chainID *big.Int, digest common.Hash) ([]byte, error) {
+	signers := make([]*ethwallet.Wallet, len(config.SignedBy))
+	for i, index := range config.SignedBy {
+		signers[i] = wallets[index]
+	}
+	walletContext := v.Context
+	wallet, err := sequence.NewWallet(sequence.WalletOptions{Config: config.Config, Context: &walletContext}, signers...)
+	if err != nil {
+		return nil, err
+	}
+	wallet.SetChainID(chainID)
+	signature, _, err := wallet.SignDigest(digest)
+	return signature, err
+}
+
+func hexBig(n *big.Int) *hexutil.Big {
+	return (*hexutil.Big)(n)
+}
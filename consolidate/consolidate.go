@@ -0,0 +1,411 @@
+// Package consolidate moves the funds a wallet has spread across chains to its
+// counterpart on a single target chain.
+//
+// A Planner inventories the balances of the wallet on each source chain, and plans a
+// bundle per balance which sends it to the target chain through a Route, such as a bridge.
+// Balances which are dust, or which cost more in fees to move than allowed, are left where
+// they are. Plans are executed by the wallet of each chain, reporting the progress of each
+// step.
+package consolidate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/bridge"
+	"github.com/0xsequence/go-sequence/contracts"
+)
+
+// NativeToken is the token address of the native token of a chain.
+var NativeToken = common.Address{}
+
+// ErrUnsupportedToken is returned by routes which can't move a token.
+var ErrUnsupportedToken = errors.New("consolidate: unsupported token")
+
+// Route moves funds of a wallet to its counterpart on the target chain.
+type Route interface {
+	// Transfer returns the transactions, to include in a bundle of the wallet from, which
+	// move amount of token to the target chain. Fees are paid with the native token, as the
+	// value of the transactions.
+	Transfer(ctx context.Context, from, token common.Address, amount *big.Int) (sequence.Transactions, error)
+}
+
+// RouteFunc adapts a function to a Route.
+type RouteFunc func(ctx context.Context, from, token common.Address, amount *big.Int) (sequence.Transactions, error)
+
+func (f RouteFunc) Transfer(ctx context.Context, from, token common.Address, amount *big.Int) (sequence.Transactions, error) {
+	return f(ctx, from, token, amount)
+}
+
+// BridgeRoute moves the native token to the counterpart of the wallet on targetChainID
+// through b, which must support sending value, ie. the native bridge of a rollup.
+func BridgeRoute(b bridge.Bridge, targetChainID *big.Int, gasLimit uint64) Route {
+	return RouteFunc(func(ctx context.Context, from, token common.Address, amount *big.Int) (sequence.Transactions, error) {
+		if token != NativeToken {
+			return nil, fmt.Errorf("%w: %s only routes the native token", ErrUnsupportedToken, b.Name())
+		}
+		return b.Send(ctx, from, &bridge.Message{
+			ChainID:  targetChainID,
+			To:       from,
+			Value:    amount,
+			GasLimit: gasLimit,
+		})
+	})
+}
+
+// Source is a chain which funds are consolidated from.
+type Source struct {
+	// Wallet is connected to the chain, and signs and sends the bundles of its steps.
+	Wallet *sequence.Wallet
+
+	// Routes move each token to the target chain, by token address. Only the balances of
+	// tokens with a route are inventoried.
+	Routes map[common.Address]Route
+
+	// Reserve is the amount of the native token left on the chain, ie. to pay the relayer.
+	Reserve *big.Int
+}
+
+// Constraints bound which balances are worth consolidating.
+type Constraints struct {
+	// MinAmount is the smallest amount of each token which is moved, by token address.
+	// Smaller balances are dust, and are left where they are.
+	MinAmount map[common.Address]*big.Int
+
+	// MaxFee is the most native token which a step may pay in fees. Zero is unbounded.
+	MaxFee *big.Int
+
+	// MaxFeeBps is the most a step moving the native token may pay in fees, in basis
+	// points of the amount moved. Zero is unbounded.
+	MaxFeeBps uint64
+}
+
+// Balance is an amount of a token of the wallet on a chain.
+type Balance struct {
+	ChainID *big.Int
+	Token   common.Address
+	Amount  *big.Int
+}
+
+// StepStatus is the progress of a step of a plan.
+type StepStatus int
+
+const (
+	StepPending StepStatus = iota
+	StepSent
+	StepExecuted
+	StepFailed
+)
+
+func (s StepStatus) String() string {
+	switch s {
+	case StepPending:
+		return "pending"
+	case StepSent:
+		return "sent"
+	case StepExecuted:
+		return "executed"
+	case StepFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// Step moves a balance of a source chain to the target chain, in a single bundle.
+type Step struct {
+	ChainID      *big.Int
+	Token        common.Address
+	Amount       *big.Int // Amount of the token moved
+	Fee          *big.Int // Native token paid to the route, besides the amount moved
+	Transactions sequence.Transactions
+
+	Status    StepStatus
+	MetaTxnID sequence.MetaTxnID
+	Err       error
+
+	source *Source
+}
+
+// Skipped is a balance which is not consolidated, and why.
+type Skipped struct {
+	Balance *Balance
+	Reason  string
+}
+
+// Plan are the steps consolidating the balances of a wallet to the target chain.
+type Plan struct {
+	TargetChainID *big.Int
+	Steps         []*Step
+	Skipped       []*Skipped
+}
+
+// Planner plans and executes the consolidation of the balances of a wallet to its
+// counterpart on the target chain.
+type Planner struct {
+	target      *big.Int
+	sources     []*Source
+	constraints Constraints
+	options     sequence.Options
+	mu          sync.RWMutex
+}
+
+func NewPlanner(targetChainID *big.Int, opts ...sequence.Option) *Planner {
+	return &Planner{
+		target:  targetChainID,
+		options: sequence.NewOptions(opts...),
+	}
+}
+
+// AddSource adds a chain which funds are consolidated from.
+func (p *Planner) AddSource(source *Source) *Planner {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sources = append(p.sources, source)
+	return p
+}
+
+// SetConstraints sets which balances are worth consolidating.
+func (p *Planner) SetConstraints(constraints Constraints) *Planner {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.constraints = constraints
+	return p
+}
+
+// Inventory fetches the balances of the wallet on each source chain, of the tokens which
+// have a route.
+func (p *Planner) Inventory(ctx context.Context) ([]*Balance, error) {
+	p.mu.RLock()
+	sources := p.sources
+	p.mu.RUnlock()
+
+	var balances []*Balance
+	for _, source := range sources {
+		chainID := source.Wallet.GetChainID()
+		for _, token := range sortedTokens(source.Routes) {
+			amount, err := balanceOf(ctx, source.Wallet, token)
+			if err != nil {
+				return nil, fmt.Errorf("consolidate: failed to get balance of %v on chain %v: %w", token, chainID, err)
+			}
+			balances = append(balances, &Balance{ChainID: chainID, Token: token, Amount: amount})
+		}
+	}
+	return balances, nil
+}
+
+// Plan inventories the balances of the wallet, and plans a step for each balance which is
+// worth consolidating.
+func (p *Planner) Plan(ctx context.Context) (*Plan, error) {
+	p.mu.RLock()
+	sources, constraints := p.sources, p.constraints
+	p.mu.RUnlock()
+
+	plan := &Plan{TargetChainID: p.target}
+	for _, source := range sources {
+		chainID := source.Wallet.GetChainID()
+		if chainID.Cmp(p.target) == 0 {
+			continue
+		}
+
+		for _, token := range sortedTokens(source.Routes) {
+			route := source.Routes[token]
+			amount, err := balanceOf(ctx, source.Wallet, token)
+			if err != nil {
+				return nil, fmt.Errorf("consolidate: failed to get balance of %v on chain %v: %w", token, chainID, err)
+			}
+			balance := &Balance{ChainID: chainID, Token: token, Amount: amount}
+
+			step, reason, err := planStep(ctx, source, route, balance, constraints)
+			if err != nil {
+				return nil, err
+			}
+			if step == nil {
+				plan.Skipped = append(plan.Skipped, &Skipped{Balance: balance, Reason: reason})
+				continue
+			}
+			plan.Steps = append(plan.Steps, step)
+		}
+	}
+
+	p.options.Logger.Debugf("consolidate: planned %d steps to chain %v, skipped %d balances", len(plan.Steps), p.target, len(plan.Skipped))
+	return plan, nil
+}
+
+func planStep(ctx context.Context, source *Source, route Route, balance *Balance, constraints Constraints) (*Step, string, error) {
+	from := source.Wallet.Address()
+	amount := new(big.Int).Set(balance.Amount)
+	if balance.Token == NativeToken && source.Reserve != nil {
+		amount.Sub(amount, source.Reserve)
+	}
+	if amount.Sign() <= 0 {
+		return nil, "no balance", nil
+	}
+	if min := constraints.MinAmount[balance.Token]; min != nil && amount.Cmp(min) < 0 {
+		return nil, fmt.Sprintf("amount %v is below the minimum of %v", amount, min), nil
+	}
+
+	txns, err := route.Transfer(ctx, from, balance.Token, amount)
+	if err != nil {
+		if errors.Is(err, ErrUnsupportedToken) {
+			return nil, err.Error(), nil
+		}
+		return nil, "", fmt.Errorf("consolidate: failed to route %v on chain %v: %w", balance.Token, balance.ChainID, err)
+	}
+	fee := transactionsValue(txns)
+
+	if balance.Token == NativeToken {
+		// the fee is paid from the balance moved, so the amount is what is left after fees
+		fee.Sub(fee, amount)
+		amount.Sub(amount, fee)
+		if amount.Sign() <= 0 {
+			return nil, fmt.Sprintf("fee %v exceeds the balance", fee), nil
+		}
+		txns, err = route.Transfer(ctx, from, balance.Token, amount)
+		if err != nil {
+			return nil, "", fmt.Errorf("consolidate: failed to route %v on chain %v: %w", balance.Token, balance.ChainID, err)
+		}
+		if spent := transactionsValue(txns); spent.Cmp(new(big.Int).Add(amount, fee)) > 0 {
+			return nil, fmt.Sprintf("route spends %v, more than the balance", spent), nil
+		}
+		if constraints.MaxFeeBps > 0 {
+			maxFee := new(big.Int).Mul(amount, new(big.Int).SetUint64(constraints.MaxFeeBps))
+			maxFee.Div(maxFee, big.NewInt(10000))
+			if fee.Cmp(maxFee) > 0 {
+				return nil, fmt.Sprintf("fee %v exceeds %d bps of %v", fee, constraints.MaxFeeBps, amount), nil
+			}
+		}
+	}
+	if constraints.MaxFee != nil && constraints.MaxFee.Sign() > 0 && fee.Cmp(constraints.MaxFee) > 0 {
+		return nil, fmt.Sprintf("fee %v exceeds the maximum of %v", fee, constraints.MaxFee), nil
+	}
+
+	return &Step{
+		ChainID:      balance.ChainID,
+		Token:        balance.Token,
+		Amount:       amount,
+		Fee:          fee,
+		Transactions: txns,
+		source:       source,
+	}, "", nil
+}
+
+// Execute sends the bundle of each pending step with the wallet of its chain, and waits
+// for it to be executed. onProgress, if not nil, is called each time the status of a step
+// changes. A failed step doesn't stop the others, and the error lists how many failed.
+func (p *Planner) Execute(ctx context.Context, plan *Plan, onProgress func(step *Step)) error {
+	failed := 0
+	for _, step := range plan.Steps {
+		if step.Status != StepPending {
+			continue
+		}
+		if err := p.executeStep(ctx, step, onProgress); err != nil {
+			step.Status, step.Err = StepFailed, err
+			p.options.Metrics.IncCounter("consolidate.step.error")
+			p.options.Logger.Warnf("consolidate: step moving %v of %v on chain %v failed: %v", step.Amount, step.Token, step.ChainID, err)
+			failed++
+		} else {
+			step.Status = StepExecuted
+			p.options.Metrics.IncCounter("consolidate.step")
+		}
+		if onProgress != nil {
+			onProgress(step)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("consolidate: %d of %d steps failed", failed, len(plan.Steps))
+	}
+	return nil
+}
+
+func (p *Planner) executeStep(ctx context.Context, step *Step, onProgress func(step *Step)) error {
+	if step.source == nil {
+		return fmt.Errorf("consolidate: step was not planned by this planner")
+	}
+	wallet := step.source.Wallet
+
+	signed, err := wallet.SignTransactions(ctx, step.Transactions)
+	if err != nil {
+		return fmt.Errorf("consolidate: failed to sign: %w", err)
+	}
+	metaTxnID, _, _, err := wallet.SendTransactions(ctx, signed)
+	if err != nil {
+		return fmt.Errorf("consolidate: failed to send: %w", err)
+	}
+	step.Status, step.MetaTxnID = StepSent, metaTxnID
+	if onProgress != nil {
+		onProgress(step)
+	}
+
+	var timeout []time.Duration
+	if t := p.options.WaitTimeout(0); t > 0 {
+		timeout = append(timeout, t)
+	}
+	status, _, err := wallet.GetRelayer().Wait(ctx, metaTxnID, timeout...)
+	if err != nil {
+		return fmt.Errorf("consolidate: failed to wait for %v: %w", metaTxnID, err)
+	}
+	if status != sequence.MetaTxnExecuted {
+		return fmt.Errorf("consolidate: %v was %v", metaTxnID, status)
+	}
+	return nil
+}
+
+func balanceOf(ctx context.Context, wallet *sequence.Wallet, token common.Address) (*big.Int, error) {
+	provider := wallet.GetProvider()
+	if provider == nil {
+		return nil, sequence.ErrProviderNotSet
+	}
+	if token == NativeToken {
+		return provider.BalanceAt(ctx, wallet.Address(), nil)
+	}
+
+	data, err := contracts.IERC20.Encode("balanceOf", wallet.Address())
+	if err != nil {
+		return nil, err
+	}
+	res, err := provider.CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	values, err := contracts.IERC20.ABI.Unpack("balanceOf", res)
+	if err != nil {
+		return nil, err
+	}
+	return values[0].(*big.Int), nil
+}
+
+func transactionsValue(txns sequence.Transactions) *big.Int {
+	value := new(big.Int)
+	for _, txn := range txns {
+		if txn.Value != nil {
+			value.Add(value, txn.Value)
+		}
+	}
+	return value
+}
+
+// sortedTokens returns the tokens of routes in order, so plans are deterministic.
+func sortedTokens(routes map[common.Address]Route) []common.Address {
+	tokens := make([]common.Address, 0, len(routes))
+	for token := range routes {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return bytes.Compare(tokens[i][:], tokens[j][:]) < 0
+	})
+	return tokens
+}
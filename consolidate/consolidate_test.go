@@ -0,0 +1,153 @@
+package consolidate_test
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/bridge"
+	"github.com/0xsequence/go-sequence/consolidate"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+var usdc = common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+
+// fakeRelayer relays bundles without sending them, and reports them with status.
+type fakeRelayer struct {
+	status  sequence.MetaTxnStatus
+	relayed []*sequence.SignedTransactions
+}
+
+func (r *fakeRelayer) GetProvider() *ethrpc.Provider { return nil }
+
+func (r *fakeRelayer) EstimateGasLimits(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, txns sequence.Transactions) (sequence.Transactions, error) {
+	return txns, nil
+}
+
+func (r *fakeRelayer) GetNonce(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, space *big.Int, blockNum *big.Int) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (r *fakeRelayer) Relay(ctx context.Context, signedTxs *sequence.SignedTransactions) (sequence.MetaTxnID, *types.Transaction, ethtxn.WaitReceipt, error) {
+	r.relayed = append(r.relayed, signedTxs)
+	return sequence.MetaTxnID(signedTxs.Digest.Hex()[2:]), nil, nil, nil
+}
+
+func (r *fakeRelayer) Wait(ctx context.Context, metaTxnID sequence.MetaTxnID, optTimeout ...time.Duration) (sequence.MetaTxnStatus, *types.Receipt, error) {
+	return r.status, nil, nil
+}
+
+func (r *fakeRelayer) FeeOptions(ctx context.Context, signedTxs *sequence.SignedTransactions) ([]*sequence.FeeOption, error) {
+	return nil, nil
+}
+
+// newWallet returns a wallet on a chain of chainID, holding native tokens and token usdc.
+func newWallet(t *testing.T, chainID int64, native, token *big.Int, relayer sequence.Relayer) *sequence.Wallet {
+	owner, err := ethwallet.NewWalletFromPrivateKey("3c121e5b2c2b2426f386bfc0257820846d77610c20e0fd4144417fb8fd79bfb6")
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(owner)
+	assert.NoError(t, err)
+
+	chain := memchain.New(memchain.Options{ChainID: big.NewInt(chainID)})
+	chain.Fund(wallet.Address(), native)
+	chain.Deploy(usdc, memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		return common.BigToHash(token).Bytes(), nil
+	}))
+	assert.NoError(t, wallet.Connect(chain.Provider(), relayer))
+	return wallet
+}
+
+func TestPlanner(t *testing.T) {
+	ctx := context.Background()
+	target := big.NewInt(1)
+	ether := big.NewInt(1e18)
+
+	// arbitrum has 1 ether, of which 0.01 is kept to pay the relayer
+	arbitrumRelayer := &fakeRelayer{status: sequence.MetaTxnExecuted}
+	arbitrum := newWallet(t, 42161, ether, big.NewInt(0), arbitrumRelayer)
+	arbitrumBridge := bridge.NewArbitrumBridge(common.HexToAddress("0x4dbd4fc535ac27206064b68ffcf827b0a60bab3f"), target).
+		SetFees(big.NewInt(1e12), big.NewInt(1e9))
+
+	// polygon has dust of the native token, and 50 usdc which are bridged for a fee
+	polygonRelayer := &fakeRelayer{status: sequence.MetaTxnFailed}
+	polygon := newWallet(t, 137, big.NewInt(1000), big.NewInt(50e6), polygonRelayer)
+	usdcRoute := consolidate.RouteFunc(func(ctx context.Context, from, token common.Address, amount *big.Int) (sequence.Transactions, error) {
+		return sequence.Transactions{{To: common.HexToAddress("0xb0b0"), Value: big.NewInt(500), Data: amount.Bytes(), RevertOnError: true}}, nil
+	})
+
+	planner := consolidate.NewPlanner(target).
+		AddSource(&consolidate.Source{
+			Wallet:  arbitrum,
+			Routes:  map[common.Address]consolidate.Route{consolidate.NativeToken: consolidate.BridgeRoute(arbitrumBridge, target, 100_000)},
+			Reserve: big.NewInt(1e16),
+		}).
+		AddSource(&consolidate.Source{
+			Wallet: polygon,
+			Routes: map[common.Address]consolidate.Route{
+				consolidate.NativeToken: consolidate.BridgeRoute(arbitrumBridge, target, 100_000),
+				usdc:                    usdcRoute,
+			},
+		}).
+		SetConstraints(consolidate.Constraints{
+			MinAmount: map[common.Address]*big.Int{consolidate.NativeToken: big.NewInt(1e15)},
+			MaxFeeBps: 100,
+		})
+
+	balances, err := planner.Inventory(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, balances, 3)
+	assert.Equal(t, ether, balances[0].Amount)
+
+	plan, err := planner.Plan(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, plan.Steps, 2)
+	assert.Len(t, plan.Skipped, 1)
+	assert.Equal(t, consolidate.NativeToken, plan.Skipped[0].Balance.Token)
+	assert.Contains(t, plan.Skipped[0].Reason, "below the minimum")
+
+	// the bridge fee is paid from the balance moved
+	native := plan.Steps[0]
+	fee := big.NewInt(1e12 + 100_000*1e9)
+	assert.Equal(t, fee, native.Fee)
+	expected := new(big.Int).Sub(new(big.Int).Sub(ether, big.NewInt(1e16)), fee)
+	assert.Equal(t, expected, native.Amount)
+	assert.Equal(t, new(big.Int).Add(expected, fee), native.Transactions[0].Value)
+
+	token := plan.Steps[1]
+	assert.Equal(t, usdc, token.Token)
+	assert.Equal(t, big.NewInt(50e6), token.Amount)
+	assert.Equal(t, big.NewInt(500), token.Fee)
+
+	var progress []string
+	err = planner.Execute(ctx, plan, func(step *consolidate.Step) {
+		progress = append(progress, fmt.Sprintf("%v:%v", step.ChainID, step.Status))
+	})
+	assert.EqualError(t, err, "consolidate: 1 of 2 steps failed")
+	assert.Equal(t, []string{"42161:sent", "42161:executed", "137:sent", "137:failed"}, progress)
+	assert.Len(t, arbitrumRelayer.relayed, 1)
+	assert.Len(t, polygonRelayer.relayed, 1)
+	assert.Equal(t, native.Transactions, arbitrumRelayer.relayed[0].Transactions)
+
+	// executed steps are not sent again
+	polygonRelayer.status = sequence.MetaTxnExecuted
+	token.Status = consolidate.StepPending
+	assert.NoError(t, planner.Execute(ctx, plan, nil))
+	assert.Len(t, arbitrumRelayer.relayed, 1)
+	assert.Len(t, polygonRelayer.relayed, 2)
+
+	// fees above the constraints skip the balance
+	planner.SetConstraints(consolidate.Constraints{MaxFee: big.NewInt(100)})
+	plan, err = planner.Plan(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, plan.Steps, 0)
+	assert.Len(t, plan.Skipped, 3)
+}
@@ -13,6 +13,16 @@
 //go:generate go run github.com/0xsequence/ethkit/cmd/ethkit abigen --pkg=walletgasestimator --type=WalletGasEstimator --outFile=./walletgasestimator/wallet_gas_estimator.gen.go --artifactsFile=../artifacts/wallet-contracts/modules/MainModuleGasEstimation.sol/MainModuleGasEstimation.json --includeDeployed=true
 //go:generate go run github.com/0xsequence/ethkit/cmd/ethkit abigen --pkg=gasestimator --type=GasEstimator --outFile=./gasestimator/gas_estimator.gen.go --artifactsFile=../artifacts/wallet-contracts/modules/utils/GasEstimator.sol/GasEstimator.json --includeDeployed=true
 
+//
+// sequence wallet-contracts calldata packers, ie. walletmain.WalletMainCalldata.Execute
+//
+//go:generate go run ../../cmd/abipack -pkg=walletfactory -type=WalletFactory -outFile=./walletfactory/wallet_factory.calldata.gen.go -artifactsFile=../artifacts/wallet-contracts/Factory.sol/Factory.json
+//go:generate go run ../../cmd/abipack -pkg=walletmain -type=WalletMain -outFile=./walletmain/wallet_main_module.calldata.gen.go -artifactsFile=../artifacts/wallet-contracts/modules/MainModule.sol/MainModule.json
+//go:generate go run ../../cmd/abipack -pkg=walletupgradable -type=WalletUpgradable -outFile=./walletupgradable/wallet_main_module_upgradable.calldata.gen.go -artifactsFile=../artifacts/wallet-contracts/modules/MainModuleUpgradable.sol/MainModuleUpgradable.json
+//go:generate go run ../../cmd/abipack -pkg=walletguest -type=WalletGuest -outFile=./walletguest/wallet_guest_module.calldata.gen.go -artifactsFile=../artifacts/wallet-contracts/modules/GuestModule.sol/GuestModule.json
+//go:generate go run ../../cmd/abipack -pkg=walletutils -type=WalletUtils -outFile=./walletutils/wallet_utils.calldata.gen.go -artifactsFile=../artifacts/wallet-contracts/modules/utils/SequenceUtils.sol/SequenceUtils.json
+//go:generate go run ../../cmd/abipack -pkg=walletgasestimator -type=WalletGasEstimator -outFile=./walletgasestimator/wallet_gas_estimator.calldata.gen.go -artifactsFile=../artifacts/wallet-contracts/modules/MainModuleGasEstimation.sol/MainModuleGasEstimation.json
+
 //
 // tokens
 //
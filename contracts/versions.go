@@ -0,0 +1,30 @@
+package contracts
+
+import (
+	_ "embed"
+	"encoding/json"
+)
+
+//go:embed package.json
+var packageJSON []byte
+
+var (
+	// ArtifactVersions are the tagged versions of the npm packages which the artifacts and
+	// bindings of this package were generated from, by package, ie. "@0xsequence/wallet-contracts".
+	ArtifactVersions map[string]string
+
+	// WalletContractsVersion is the version of the sequence wallet-contracts of the wallet
+	// artifacts and bindings.
+	WalletContractsVersion string
+)
+
+func init() {
+	var pkg struct {
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(packageJSON, &pkg); err != nil {
+		panic(err)
+	}
+	ArtifactVersions = pkg.DevDependencies
+	WalletContractsVersion = ArtifactVersions["@0xsequence/wallet-contracts"]
+}
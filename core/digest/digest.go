@@ -0,0 +1,135 @@
+// Package digest exposes the low-level hashing rules of Sequence wallets: the subDigest
+// binding a digest to a wallet and chain, the eth_sign message prefix, and EIP-712 domain
+// separators. They are what signers and verifiers in other languages must reproduce, and
+// do not depend on the rest of the SDK.
+//
+//	subDigest      = keccak256("\x19\x01" ++ chainID ++ wallet ++ digest)
+//	eth_sign       = keccak256("\x19Ethereum Signed Message:\n" ++ len(message) ++ message)
+//	EIP-712 digest = keccak256("\x19\x01" ++ domainSeparator ++ structHash)
+package digest
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/math"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence/internal/keccak"
+)
+
+// EthSignPrefix is prepended to messages signed with eth_sign, as in EIP-191 version 0x45.
+const EthSignPrefix = "\x19Ethereum Signed Message:\n"
+
+// ErrNoChainID is returned when a digest bound to a chain is computed without a chain id.
+var ErrNoChainID = fmt.Errorf("digest: chain id is required")
+
+// SubDigest binds digest to the wallet at address on chainID. It is the digest which the
+// signers of a Sequence wallet sign, and the meta-transaction id of executed transactions.
+func SubDigest(chainID *big.Int, address common.Address, digest common.Hash) (common.Hash, error) {
+	if err := checkChainID(chainID); err != nil {
+		return common.Hash{}, err
+	}
+
+	// hashed without packing the preimage, as meta-transaction ids are computed in bulk
+	h := keccak.Get()
+	defer keccak.Put(h)
+	h.Write(subDigestPrefix)
+	h.WriteUint256(chainID)
+	h.WritePackedAddress(address)
+	h.WriteWord(digest)
+	return h.Sum(), nil
+}
+
+var subDigestPrefix = []byte{0x19, 0x01}
+
+func checkChainID(chainID *big.Int) error {
+	if chainID == nil {
+		return ErrNoChainID
+	}
+	if chainID.Sign() < 0 || chainID.BitLen() > 256 {
+		return fmt.Errorf("digest: chain id %v is not a uint256", chainID)
+	}
+	return nil
+}
+
+// PackSubDigest returns the preimage of the subDigest: "\x19\x01", the chain id as a uint256,
+// the wallet address and the digest, tightly packed.
+func PackSubDigest(chainID *big.Int, address common.Address, digest common.Hash) ([]byte, error) {
+	if err := checkChainID(chainID); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 0, 2+32+common.AddressLength+common.HashLength)
+	data = append(data, 0x19, 0x01)
+	data = append(data, math.U256Bytes(new(big.Int).Set(chainID))...)
+	data = append(data, address.Bytes()...)
+	data = append(data, digest.Bytes()...)
+	return data, nil
+}
+
+// PrefixMessage prepends the eth_sign prefix and the decimal length of message to message.
+func PrefixMessage(message []byte) []byte {
+	prefix := EthSignPrefix + strconv.Itoa(len(message))
+	return append([]byte(prefix), message...)
+}
+
+// EthSignDigest is the digest which an EOA signs when it signs message with eth_sign.
+// Sequence wallet signers eth_sign the 32 bytes of the subDigest.
+func EthSignDigest(message []byte) common.Hash {
+	return keccak.Sum256(PrefixMessage(message))
+}
+
+// MessageDigest is the digest of an arbitrary message signed by a Sequence wallet, before
+// it is bound to the wallet with SubDigest.
+func MessageDigest(message []byte) common.Hash {
+	return keccak.Sum256(message)
+}
+
+// DomainSeparator is the EIP-712 hashStruct of domain. Only the fields which are set are
+// part of the EIP712Domain type, in the order defined by EIP-712.
+func DomainSeparator(domain ethcoder.TypedDataDomain) (common.Hash, error) {
+	var fields []string
+	var values [][]byte
+
+	if domain.Name != "" {
+		fields = append(fields, "string name")
+		values = append(values, crypto.Keccak256([]byte(domain.Name)))
+	}
+	if domain.Version != "" {
+		fields = append(fields, "string version")
+		values = append(values, crypto.Keccak256([]byte(domain.Version)))
+	}
+	if domain.ChainID != nil {
+		if domain.ChainID.Sign() < 0 || domain.ChainID.BitLen() > 256 {
+			return common.Hash{}, fmt.Errorf("digest: chain id %v is not a uint256", domain.ChainID)
+		}
+		fields = append(fields, "uint256 chainId")
+		values = append(values, math.U256Bytes(new(big.Int).Set(domain.ChainID)))
+	}
+	if domain.VerifyingContract != nil && *domain.VerifyingContract != (common.Address{}) {
+		fields = append(fields, "address verifyingContract")
+		values = append(values, common.LeftPadBytes(domain.VerifyingContract.Bytes(), 32))
+	}
+	if domain.Salt != nil {
+		fields = append(fields, "bytes32 salt")
+		values = append(values, domain.Salt[:])
+	}
+	if len(fields) == 0 {
+		return common.Hash{}, fmt.Errorf("digest: domain has no fields")
+	}
+
+	typeHash := crypto.Keccak256([]byte("EIP712Domain(" + strings.Join(fields, ",") + ")"))
+	return crypto.Keccak256Hash(append([][]byte{typeHash}, values...)...), nil
+}
+
+var eip712Prefix = []byte{0x19, 0x01}
+
+// TypedDataDigest is the EIP-712 digest of the struct with hash structHash in the domain
+// with separator domainSeparator.
+func TypedDataDigest(domainSeparator, structHash common.Hash) common.Hash {
+	return keccak.Sum256(eip712Prefix, domainSeparator[:], structHash[:])
+}
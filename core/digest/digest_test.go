@@ -0,0 +1,74 @@
+package digest_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence/core/digest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubDigest(t *testing.T) {
+	// same vector as sequence.js, wallet 0xF0BA... is owned by the key below
+	key, err := crypto.HexToECDSA("87306d4b9fe56c2af23c7cc3bc69914eba8f7c8fc1d35b4c9a7dd7ea198a428b")
+	assert.NoError(t, err)
+	wallet := common.HexToAddress("0xF0BA65550F2d1DCCf4B131B774844DC3d801D886")
+	message := ethcoder.MustHexDecode("0x1901f0ba65550f2d1dccf4b131b774844dc3d801d886bbd4edcf660f395f21fe94792f7c1da94638270a049646e541004312b3ec1ac5")
+
+	packed, err := digest.PackSubDigest(big.NewInt(1), wallet, digest.MessageDigest(message))
+	assert.NoError(t, err)
+	assert.Len(t, packed, 86)
+	assert.Equal(t, []byte{0x19, 0x01}, packed[:2])
+	assert.Equal(t, byte(1), packed[33])
+	assert.Equal(t, wallet.Bytes(), packed[34:54])
+
+	subDigest, err := digest.SubDigest(big.NewInt(1), wallet, digest.MessageDigest(message))
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.Keccak256Hash(packed), subDigest)
+
+	// the signer eth_signs the subDigest
+	sig, err := crypto.Sign(digest.EthSignDigest(subDigest.Bytes()).Bytes(), key)
+	assert.NoError(t, err)
+	sig[64] += 27
+	assert.Equal(t, "0xa0fb306480bc3027c04d33a16370f4618b29f2d5b89464f526045c94802bc9d1525389c364b75daf58e859ed0d6105aac6b3718e4659814c7793c626653edb871b", ethcoder.HexEncode(sig))
+
+	_, err = digest.SubDigest(nil, wallet, common.Hash{})
+	assert.ErrorIs(t, err, digest.ErrNoChainID)
+}
+
+func TestEthSignDigest(t *testing.T) {
+	assert.Equal(t, "\x19Ethereum Signed Message:\n5hello", string(digest.PrefixMessage([]byte("hello"))))
+	assert.Equal(t, "0x50b2c43fd39106bafbba0da34fc430e1f91e3c96ea2acee2bc34119f92b37750", digest.EthSignDigest([]byte("hello")).Hex())
+}
+
+func TestDomainSeparator(t *testing.T) {
+	// example from EIP-712
+	verifyingContract := common.HexToAddress("0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC")
+	domainSeparator, err := digest.DomainSeparator(ethcoder.TypedDataDomain{
+		Name:              "Ether Mail",
+		Version:           "1",
+		ChainID:           big.NewInt(1),
+		VerifyingContract: &verifyingContract,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f", domainSeparator.Hex())
+
+	structHash := common.HexToHash("0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e")
+	assert.Equal(t, "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2", digest.TypedDataDigest(domainSeparator, structHash).Hex())
+
+	_, err = digest.DomainSeparator(ethcoder.TypedDataDomain{})
+	assert.Error(t, err)
+}
+
+func BenchmarkSubDigest(b *testing.B) {
+	chainID, address, hash := big.NewInt(137), common.HexToAddress("0x7a7"), common.HexToHash("0x1")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := digest.SubDigest(chainID, address, hash); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
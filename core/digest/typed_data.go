@@ -0,0 +1,308 @@
+package digest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/common/math"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// TypedData is an EIP-712 typed data payload, as passed to eth_signTypedData_v4. Structs,
+// arrays and all atomic types are supported. Numbers may be JSON numbers, or decimal or hex
+// strings, and bytes are hex strings.
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      map[string]interface{}      `json:"domain"`
+	Message     map[string]interface{}      `json:"message"`
+}
+
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// ParseTypedData decodes the JSON of a typed data payload, keeping numbers exact.
+func ParseTypedData(data []byte) (*TypedData, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var typedData TypedData
+	if err := dec.Decode(&typedData); err != nil {
+		return nil, fmt.Errorf("digest: invalid typed data: %w", err)
+	}
+	return &typedData, nil
+}
+
+// Digest is the EIP-712 digest of the message, which is signed.
+func (t *TypedData) Digest() (common.Hash, error) {
+	domainSeparator, err := t.HashStruct("EIP712Domain", t.Domain)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	structHash, err := t.HashStruct(t.PrimaryType, t.Message)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return TypedDataDigest(domainSeparator, structHash), nil
+}
+
+// EncodeType returns the encoding of the struct type name and the types it references.
+func (t *TypedData) EncodeType(name string) (string, error) {
+	deps := map[string]bool{}
+	if err := t.dependencies(name, deps); err != nil {
+		return "", err
+	}
+	delete(deps, name)
+
+	names := make([]string, 0, len(deps))
+	for dep := range deps {
+		names = append(names, dep)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, typeName := range append([]string{name}, names...) {
+		fields := make([]string, 0, len(t.types(typeName)))
+		for _, field := range t.types(typeName) {
+			fields = append(fields, field.Type+" "+field.Name)
+		}
+		sb.WriteString(typeName + "(" + strings.Join(fields, ",") + ")")
+	}
+	return sb.String(), nil
+}
+
+// HashStruct returns the hashStruct of data, a struct of type name.
+func (t *TypedData) HashStruct(name string, data map[string]interface{}) (common.Hash, error) {
+	encoded, err := t.encodeData(name, data)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(encoded), nil
+}
+
+func (t *TypedData) types(name string) []TypedDataField {
+	if fields, ok := t.Types[name]; ok || name != "EIP712Domain" {
+		return fields
+	}
+
+	// the domain type is derived from the fields of the domain, if it is not declared
+	var fields []TypedDataField
+	for _, field := range []TypedDataField{
+		{Name: "name", Type: "string"},
+		{Name: "version", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+		{Name: "salt", Type: "bytes32"},
+	} {
+		if _, ok := t.Domain[field.Name]; ok {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func (t *TypedData) isStruct(name string) bool {
+	_, ok := t.Types[name]
+	return ok || name == "EIP712Domain"
+}
+
+func (t *TypedData) dependencies(name string, deps map[string]bool) error {
+	if deps[name] {
+		return nil
+	}
+	if !t.isStruct(name) {
+		return fmt.Errorf("digest: type %s is not defined", name)
+	}
+	deps[name] = true
+	for _, field := range t.types(name) {
+		fieldType := elementType(field.Type)
+		if t.isStruct(fieldType) {
+			if err := t.dependencies(fieldType, deps); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t *TypedData) encodeData(name string, data map[string]interface{}) ([]byte, error) {
+	encodedType, err := t.EncodeType(name)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := crypto.Keccak256([]byte(encodedType))
+	for _, field := range t.types(name) {
+		value, ok := data[field.Name]
+		if !ok {
+			return nil, fmt.Errorf("digest: %s.%s is missing", name, field.Name)
+		}
+		word, err := t.encodeValue(field.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("digest: %s.%s: %w", name, field.Name, err)
+		}
+		encoded = append(encoded, word...)
+	}
+	return encoded, nil
+}
+
+func (t *TypedData) encodeValue(typ string, value interface{}) ([]byte, error) {
+	// arrays are the hash of the concatenated encodings of their elements
+	if strings.HasSuffix(typ, "]") {
+		elemType := typ[:strings.LastIndex(typ, "[")]
+		values, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected an array of %s", elemType)
+		}
+		var encoded []byte
+		for _, elem := range values {
+			word, err := t.encodeValue(elemType, elem)
+			if err != nil {
+				return nil, err
+			}
+			encoded = append(encoded, word...)
+		}
+		return crypto.Keccak256(encoded), nil
+	}
+
+	if t.isStruct(typ) {
+		data, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected a %s struct", typ)
+		}
+		hash, err := t.HashStruct(typ, data)
+		return hash.Bytes(), err
+	}
+
+	switch {
+	case typ == "string":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string")
+		}
+		return crypto.Keccak256([]byte(s)), nil
+
+	case typ == "bytes":
+		b, err := typedDataBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(b), nil
+
+	case typ == "bool":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected a bool")
+		}
+		word := make([]byte, 32)
+		if b {
+			word[31] = 1
+		}
+		return word, nil
+
+	case typ == "address":
+		s, ok := value.(string)
+		if !ok || !common.IsHexAddress(s) {
+			return nil, fmt.Errorf("expected an address")
+		}
+		return common.LeftPadBytes(common.HexToAddress(s).Bytes(), 32), nil
+
+	case strings.HasPrefix(typ, "bytes"):
+		size, err := strconv.Atoi(strings.TrimPrefix(typ, "bytes"))
+		if err != nil || size < 1 || size > 32 {
+			return nil, fmt.Errorf("invalid type %s", typ)
+		}
+		b, err := typedDataBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != size {
+			return nil, fmt.Errorf("expected %d bytes, got %d", size, len(b))
+		}
+		return common.RightPadBytes(b, 32), nil
+
+	case strings.HasPrefix(typ, "uint") || strings.HasPrefix(typ, "int"):
+		signed := strings.HasPrefix(typ, "int")
+		bits, err := strconv.Atoi(strings.TrimPrefix(strings.TrimPrefix(typ, "u"), "int"))
+		if err != nil || bits < 8 || bits > 256 || bits%8 != 0 {
+			return nil, fmt.Errorf("invalid type %s", typ)
+		}
+		n, err := typedDataInteger(value)
+		if err != nil {
+			return nil, err
+		}
+		if signed {
+			limit := new(big.Int).Lsh(big.NewInt(1), uint(bits-1))
+			if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+				return nil, fmt.Errorf("%v overflows %s", n, typ)
+			}
+			return math.U256Bytes(new(big.Int).Set(n)), nil
+		}
+		if n.Sign() < 0 || n.BitLen() > bits {
+			return nil, fmt.Errorf("%v overflows %s", n, typ)
+		}
+		return common.LeftPadBytes(n.Bytes(), 32), nil
+
+	default:
+		return nil, fmt.Errorf("type %s is not defined", typ)
+	}
+}
+
+func elementType(typ string) string {
+	if i := strings.Index(typ, "["); i >= 0 {
+		return typ[:i]
+	}
+	return typ
+}
+
+func typedDataBytes(value interface{}) ([]byte, error) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected hex encoded bytes")
+	}
+	if s == "0x" {
+		return []byte{}, nil
+	}
+	return hexutil.Decode(s)
+}
+
+func typedDataInteger(value interface{}) (*big.Int, error) {
+	var s string
+	switch v := value.(type) {
+	case json.Number:
+		s = v.String()
+	case string:
+		s = v
+	case float64:
+		if v != float64(int64(v)) {
+			return nil, fmt.Errorf("expected an integer")
+		}
+		return big.NewInt(int64(v)), nil
+	default:
+		return nil, fmt.Errorf("expected an integer")
+	}
+
+	base := 10
+	neg := strings.HasPrefix(s, "-")
+	digits := strings.TrimPrefix(s, "-")
+	if strings.HasPrefix(digits, "0x") {
+		digits, base = digits[2:], 16
+	}
+	n, ok := new(big.Int).SetString(digits, base)
+	if !ok {
+		return nil, fmt.Errorf("expected an integer, got %q", s)
+	}
+	if neg {
+		n.Neg(n)
+	}
+	return n, nil
+}
@@ -0,0 +1,83 @@
+package digest_test
+
+import (
+	"testing"
+
+	"github.com/0xsequence/go-sequence/core/digest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypedData(t *testing.T) {
+	// example from EIP-712
+	typedData, err := digest.ParseTypedData([]byte(`{
+		"types": {
+			"EIP712Domain": [
+				{"name": "name", "type": "string"},
+				{"name": "version", "type": "string"},
+				{"name": "chainId", "type": "uint256"},
+				{"name": "verifyingContract", "type": "address"}
+			],
+			"Person": [{"name": "name", "type": "string"}, {"name": "wallet", "type": "address"}],
+			"Mail": [{"name": "from", "type": "Person"}, {"name": "to", "type": "Person"}, {"name": "contents", "type": "string"}]
+		},
+		"primaryType": "Mail",
+		"domain": {"name": "Ether Mail", "version": "1", "chainId": 1, "verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"},
+		"message": {
+			"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+			"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+			"contents": "Hello, Bob!"
+		}
+	}`))
+	assert.NoError(t, err)
+
+	encodedType, err := typedData.EncodeType("Mail")
+	assert.NoError(t, err)
+	assert.Equal(t, "Mail(Person from,Person to,string contents)Person(string name,address wallet)", encodedType)
+
+	structHash, err := typedData.HashStruct("Mail", typedData.Message)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e", structHash.Hex())
+
+	hash, err := typedData.Digest()
+	assert.NoError(t, err)
+	assert.Equal(t, "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2", hash.Hex())
+
+	// the domain type is derived from the domain when it is not declared
+	delete(typedData.Types, "EIP712Domain")
+	hash, err = typedData.Digest()
+	assert.NoError(t, err)
+	assert.Equal(t, "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2", hash.Hex())
+}
+
+func TestTypedDataArrays(t *testing.T) {
+	// example of eth_signTypedData_v4 from MetaMask's eth-sig-util
+	typedData, err := digest.ParseTypedData([]byte(`{
+		"types": {
+			"EIP712Domain": [
+				{"name": "name", "type": "string"},
+				{"name": "version", "type": "string"},
+				{"name": "chainId", "type": "uint256"},
+				{"name": "verifyingContract", "type": "address"}
+			],
+			"Group": [{"name": "name", "type": "string"}, {"name": "members", "type": "Person[]"}],
+			"Mail": [{"name": "from", "type": "Person"}, {"name": "to", "type": "Person[]"}, {"name": "contents", "type": "string"}],
+			"Person": [{"name": "name", "type": "string"}, {"name": "wallets", "type": "address[]"}]
+		},
+		"primaryType": "Mail",
+		"domain": {"name": "Ether Mail", "version": "1", "chainId": 1, "verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"},
+		"message": {
+			"from": {"name": "Cow", "wallets": ["0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826", "0xDeaDbeefdEAdbeefdEadbEEFdeadbeEFdEaDbeeF"]},
+			"to": [{"name": "Bob", "wallets": ["0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB", "0xB0BdaBea57B0BDABeA57b0bdABEA57b0BDabEa57", "0xB0B0b0b0b0b0B000000000000000000000000000"]}],
+			"contents": "Hello, Bob!"
+		}
+	}`))
+	assert.NoError(t, err)
+
+	hash, err := typedData.Digest()
+	assert.NoError(t, err)
+	assert.Equal(t, "0xa85c2e2b118698e88db68a8105b794a8cc7cec074e89ef991cb4f5f533819cc2", hash.Hex())
+
+	typedData.Message["contents"] = 1
+	_, err = typedData.Digest()
+	assert.Error(t, err)
+}
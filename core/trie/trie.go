@@ -0,0 +1,160 @@
+// Package trie computes the roots of Merkle-Patricia tries, such as the transactions and
+// receipts roots committed to by block headers. It only hashes a complete set of keys and
+// values in memory, and does not store nodes nor build proofs.
+package trie
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/ethkit/go-ethereum/rlp"
+)
+
+// EmptyRoot is the root of a trie without keys.
+var EmptyRoot = common.HexToHash("0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+// Hasher computes the root of the trie of the keys and values passed to Update. It
+// implements types.TrieHasher, so it can be passed to types.DeriveSha.
+type Hasher struct {
+	entries map[string][]byte
+}
+
+func NewHasher() *Hasher {
+	return &Hasher{entries: map[string][]byte{}}
+}
+
+// Reset removes all keys from the trie.
+func (h *Hasher) Reset() {
+	h.entries = map[string][]byte{}
+}
+
+// Update sets key to value. An empty value removes key from the trie.
+func (h *Hasher) Update(key, value []byte) {
+	if len(value) == 0 {
+		delete(h.entries, string(key))
+		return
+	}
+	h.entries[string(key)] = common.CopyBytes(value)
+}
+
+// Hash returns the root of the trie.
+func (h *Hasher) Hash() common.Hash {
+	if len(h.entries) == 0 {
+		return EmptyRoot
+	}
+
+	entries := make([]entry, 0, len(h.entries))
+	for key, value := range h.entries {
+		entries = append(entries, entry{key: keyNibbles([]byte(key)), value: value})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].key, entries[j].key) < 0
+	})
+
+	return crypto.Keccak256Hash(encodeNode(entries))
+}
+
+type entry struct {
+	key   []byte // one nibble per byte
+	value []byte
+}
+
+// encodeNode returns the RLP encoding of the node holding entries, which are sorted and
+// have their keys relative to the node.
+func encodeNode(entries []entry) []byte {
+	if len(entries) == 1 {
+		return mustEncode([]interface{}{compactKey(entries[0].key, true), entries[0].value})
+	}
+
+	if prefix := commonPrefix(entries); prefix > 0 {
+		children := make([]entry, len(entries))
+		for i, e := range entries {
+			children[i] = entry{key: e.key[prefix:], value: e.value}
+		}
+		return mustEncode([]interface{}{compactKey(entries[0].key[:prefix], false), nodeRef(encodeNode(children))})
+	}
+
+	branch := make([]interface{}, 17)
+	for i := range branch {
+		branch[i] = []byte{}
+	}
+	for len(entries) > 0 {
+		if len(entries[0].key) == 0 {
+			branch[16] = entries[0].value
+			entries = entries[1:]
+			continue
+		}
+
+		nibble := entries[0].key[0]
+		n := 1
+		for n < len(entries) && entries[n].key[0] == nibble {
+			n++
+		}
+		children := make([]entry, n)
+		for i, e := range entries[:n] {
+			children[i] = entry{key: e.key[1:], value: e.value}
+		}
+		branch[nibble] = nodeRef(encodeNode(children))
+		entries = entries[n:]
+	}
+	return mustEncode(branch)
+}
+
+// nodeRef is how a node is referenced by its parent: nodes shorter than a hash are
+// embedded, and larger ones are referenced by their hash.
+func nodeRef(encoded []byte) interface{} {
+	if len(encoded) < 32 {
+		return rlp.RawValue(encoded)
+	}
+	return crypto.Keccak256(encoded)
+}
+
+func commonPrefix(entries []entry) int {
+	first, last := entries[0].key, entries[len(entries)-1].key
+	n := 0
+	for n < len(first) && n < len(last) && first[n] == last[n] {
+		n++
+	}
+	return n
+}
+
+func keyNibbles(key []byte) []byte {
+	nibbles := make([]byte, 0, len(key)*2)
+	for _, b := range key {
+		nibbles = append(nibbles, b>>4, b&0x0f)
+	}
+	return nibbles
+}
+
+// compactKey is the hex-prefix encoding of nibbles, flagging leaf keys and odd lengths.
+func compactKey(nibbles []byte, leaf bool) []byte {
+	var flag byte
+	if leaf {
+		flag = 2
+	}
+	if len(nibbles)%2 == 1 {
+		flag++
+	}
+
+	compact := make([]byte, 0, len(nibbles)/2+1)
+	if flag&1 == 1 {
+		compact = append(compact, flag<<4|nibbles[0])
+		nibbles = nibbles[1:]
+	} else {
+		compact = append(compact, flag<<4)
+	}
+	for i := 0; i < len(nibbles); i += 2 {
+		compact = append(compact, nibbles[i]<<4|nibbles[i+1])
+	}
+	return compact
+}
+
+func mustEncode(v interface{}) []byte {
+	data, err := rlp.EncodeToBytes(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
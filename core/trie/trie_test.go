@@ -0,0 +1,62 @@
+package trie_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence/core/trie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasher(t *testing.T) {
+	// vectors from ethereum/tests TrieTests/trieanyorder.json
+	vectors := []struct {
+		entries map[string]string
+		root    string
+	}{
+		{
+			entries: map[string]string{"A": strings.Repeat("a", 50)},
+			root:    "0xd23786fb4a010da3ce639d66d5e904a11dbc02746d1ce25029e53290cabf28ab",
+		},
+		{
+			entries: map[string]string{"doe": "reindeer", "dog": "puppy", "dogglesworth": "cat"},
+			root:    "0x8aad789dff2f538bca5d8ea56e8abe10f4c7ba3a5dea95fea4cd6e7c3a1168d3",
+		},
+		{
+			entries: map[string]string{"do": "verb", "horse": "stallion", "doge": "coin", "dog": "puppy"},
+			root:    "0x5991bb8c6514148a29db676a14ac506cd2cd5775ace63c30a4fe457715e9ac84",
+		},
+		{
+			entries: map[string]string{"foo": "bar", "food": "bass"},
+			root:    "0x17beaa1648bafa633cda809c90c04af50fc8aed3cb40d16efbddee6fdf63c4c3",
+		},
+		{
+			entries: map[string]string{"be": "e", "dog": "puppy", "bed": "d"},
+			root:    "0x3f67c7a47520f79faa29255d2d3c084a7a6df0453116ed7232ff10277a8be68b",
+		},
+		{
+			entries: map[string]string{"test": "test", "te": "testy"},
+			root:    "0x8452568af70d8d140f58d941338542f645fcca50094b20f3c3d8c3df49337928",
+		},
+	}
+
+	hasher := trie.NewHasher()
+	assert.Equal(t, trie.EmptyRoot, hasher.Hash())
+
+	for _, v := range vectors {
+		hasher.Reset()
+		for key, value := range v.entries {
+			hasher.Update([]byte(key), []byte(value))
+		}
+		assert.Equal(t, common.HexToHash(v.root), hasher.Hash(), v.entries)
+	}
+
+	// empty values remove keys
+	hasher.Reset()
+	hasher.Update([]byte("foo"), []byte("bar"))
+	hasher.Update([]byte("food"), []byte("bass"))
+	hasher.Update([]byte("dog"), []byte("puppy"))
+	hasher.Update([]byte("dog"), nil)
+	assert.Equal(t, common.HexToHash(vectors[3].root), hasher.Hash())
+}
@@ -0,0 +1,297 @@
+// Package deadman implements dead-man switches for wallets: a succession bundle, ie. sweeping
+// the funds of a wallet to an heir, pre-signed by the signers of the wallet and relayed by a
+// Monitor once the wallet has been inactive for a period:
+//
+//	sw, err := deadman.Arm(ctx, wallet, "succession", sweep, 180*24*time.Hour, expiration)
+//	monitor := deadman.NewMonitor(relayer, deadman.Options{OnEvent: notify})
+//	err = monitor.Add(sw)
+//	go monitor.Run(ctx)
+//
+// The bundle is signed with the current nonce of the wallet, so any bundle the wallet executes
+// afterwards invalidates it on-chain: the wallet is inactive as long as its nonce is unchanged.
+// The signers re-arm the switch after using the wallet, and the monitor drops the switches the
+// wallet superseded. Only the main nonce space counts, bundles executed in other nonce spaces
+// aren't activity.
+//
+// The expiration of a switch is enforced on-chain, by an assertion of the bundle, but its
+// inactivity period is only enforced by the monitor: whoever holds the bundle can relay it
+// before, so a switch must only be handed to a trusted monitor.
+package deadman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+)
+
+var (
+	ErrSwitchNotFound = errors.New("deadman: switch not found")
+	ErrSwitchExists   = errors.New("deadman: switch already exists")
+)
+
+// DefaultPollInterval is the default interval the nonces of the wallets are polled at.
+const DefaultPollInterval = time.Minute
+
+// Switch is a succession bundle of a wallet, relayable once the wallet was inactive for
+// InactivePeriod since it was armed.
+type Switch struct {
+	ID     string
+	Wallet common.Address
+	Bundle *sequence.SignedTransactions
+
+	// ArmedAt is when the bundle was signed, which the inactivity of the wallet counts from.
+	ArmedAt        time.Time
+	InactivePeriod time.Duration
+
+	// Expiration is when the bundle expires, zero if it doesn't.
+	Expiration time.Time
+}
+
+// EligibleAt returns when the switch becomes relayable, if the wallet stays inactive.
+func (s *Switch) EligibleAt() time.Time {
+	return s.ArmedAt.Add(s.InactivePeriod)
+}
+
+// Arm signs txns as the succession bundle of wallet, relayable once the wallet was inactive
+// for inactivePeriod, and until expiration, unless zero. The bundle is signed with the current
+// nonce of the wallet, so wallet must be connected to a relayer.
+func Arm(ctx context.Context, wallet *sequence.Wallet, id string, txns sequence.Transactions, inactivePeriod time.Duration, expiration time.Time) (*Switch, error) {
+	if id == "" || len(txns) == 0 || inactivePeriod <= 0 {
+		return nil, fmt.Errorf("deadman: switch requires an ID, transactions and an inactive period")
+	}
+
+	armedAt := time.Now()
+	if !expiration.IsZero() {
+		if !expiration.After(armedAt.Add(inactivePeriod)) {
+			return nil, fmt.Errorf("deadman: switch %v expires at %v, before it's eligible", id, expiration.UTC().Format(time.RFC3339))
+		}
+		assertion, err := sequence.RequireNonExpired(wallet.GetWalletContext(), expiration)
+		if err != nil {
+			return nil, fmt.Errorf("deadman: %w", err)
+		}
+		txns = append(sequence.Transactions{assertion}, txns...)
+	}
+
+	nonce, err := wallet.GetNonce()
+	if err != nil {
+		return nil, fmt.Errorf("deadman: failed to get nonce of %v: %w", wallet.Address().Hex(), err)
+	}
+	bundle, err := wallet.SignTransactionsWithNonce(ctx, txns, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("deadman: failed to sign switch %v: %w", id, err)
+	}
+
+	return &Switch{
+		ID:             id,
+		Wallet:         wallet.Address(),
+		Bundle:         bundle,
+		ArmedAt:        armedAt,
+		InactivePeriod: inactivePeriod,
+		Expiration:     expiration,
+	}, nil
+}
+
+// State is the outcome of a switch reported by a Monitor.
+type State int
+
+const (
+	// Armed is a switch waiting for its inactivity period, reported with the errors polling
+	// or relaying it, which are retried on the next poll.
+	Armed State = iota
+
+	// Relayed is a switch whose bundle was relayed.
+	Relayed
+
+	// Superseded is a switch invalidated by a bundle the wallet executed after it was armed.
+	Superseded
+
+	// Expired is a switch which expired before it was relayed.
+	Expired
+)
+
+func (s State) String() string {
+	switch s {
+	case Armed:
+		return "armed"
+	case Relayed:
+		return "relayed"
+	case Superseded:
+		return "superseded"
+	case Expired:
+		return "expired"
+	default:
+		return fmt.Sprintf("state(%d)", int(s))
+	}
+}
+
+// Event reports a switch which left the monitor, or an error polling or relaying it.
+type Event struct {
+	SwitchID string
+	State    State
+
+	// MetaTxnID is the meta-transaction of the bundle of a relayed switch.
+	MetaTxnID sequence.MetaTxnID
+
+	Err error
+}
+
+type Options struct {
+	// PollInterval is the interval the nonces of the wallets are polled at,
+	// DefaultPollInterval by default.
+	PollInterval time.Duration
+
+	// OnEvent is called with each event, one at a time.
+	OnEvent func(Event)
+}
+
+// Monitor polls the nonces of the wallets of its switches, and relays the bundles of the
+// switches whose wallets were inactive for their period with its relayer. Switches leave the
+// monitor once relayed, superseded or expired.
+type Monitor struct {
+	relayer sequence.Relayer
+	options Options
+	now     func() time.Time
+
+	switches map[string]*Switch
+	mu       sync.Mutex
+}
+
+func NewMonitor(relayer sequence.Relayer, options Options) *Monitor {
+	if options.PollInterval == 0 {
+		options.PollInterval = DefaultPollInterval
+	}
+	return &Monitor{
+		relayer:  relayer,
+		options:  options,
+		now:      time.Now,
+		switches: map[string]*Switch{},
+	}
+}
+
+// SetClock replaces the clock switches become eligible and expire by, ie. in tests.
+func (m *Monitor) SetClock(now func() time.Time) *Monitor {
+	m.now = now
+	return m
+}
+
+// Add adds sw, which is checked on the next poll.
+func (m *Monitor) Add(sw *Switch) error {
+	if sw.ID == "" || sw.Bundle == nil || sw.Bundle.Nonce == nil {
+		return fmt.Errorf("deadman: switch requires an ID and a signed bundle")
+	}
+	if err := sw.Bundle.Verify(); err != nil {
+		return fmt.Errorf("deadman: bundle of switch %v: %w", sw.ID, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.switches[sw.ID]; ok {
+		return fmt.Errorf("%w: %v", ErrSwitchExists, sw.ID)
+	}
+	m.switches[sw.ID] = sw
+	return nil
+}
+
+// Remove disarms the switch of id. Its bundle remains valid until the wallet executes another
+// bundle, so the wallet should be used after it's disarmed.
+func (m *Monitor) Remove(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.switches[id]; !ok {
+		return fmt.Errorf("%w: %v", ErrSwitchNotFound, id)
+	}
+	delete(m.switches, id)
+	return nil
+}
+
+// Armed returns the IDs of the switches in the monitor.
+func (m *Monitor) Armed() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]string, 0, len(m.switches))
+	for id := range m.switches {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Run polls the switches until ctx is done.
+func (m *Monitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.options.PollInterval)
+	defer ticker.Stop()
+	for {
+		m.Poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll checks each switch once: drops it if expired or superseded, and relays its bundle if
+// its wallet was inactive for its period.
+func (m *Monitor) Poll(ctx context.Context) {
+	m.mu.Lock()
+	switches := make([]*Switch, 0, len(m.switches))
+	for _, sw := range m.switches {
+		switches = append(switches, sw)
+	}
+	m.mu.Unlock()
+
+	for _, sw := range switches {
+		if ctx.Err() != nil {
+			return
+		}
+		m.check(ctx, sw)
+	}
+}
+
+func (m *Monitor) check(ctx context.Context, sw *Switch) {
+	now := m.now()
+	if !sw.Expiration.IsZero() && !now.Before(sw.Expiration) {
+		m.done(Event{SwitchID: sw.ID, State: Expired})
+		return
+	}
+
+	space, nonce := sequence.DecodeNonce(sw.Bundle.Nonce)
+	current, err := m.relayer.GetNonce(ctx, sw.Bundle.WalletConfig, sw.Bundle.WalletContext, space, nil)
+	if err != nil {
+		m.event(Event{SwitchID: sw.ID, State: Armed, Err: fmt.Errorf("deadman: failed to get nonce of %v: %w", sw.Wallet.Hex(), err)})
+		return
+	}
+	if _, current = sequence.DecodeNonce(current); current.Cmp(nonce) > 0 {
+		m.done(Event{SwitchID: sw.ID, State: Superseded})
+		return
+	}
+
+	if now.Before(sw.EligibleAt()) {
+		return
+	}
+	metaTxnID, _, _, err := m.relayer.Relay(ctx, sw.Bundle)
+	if err != nil {
+		m.event(Event{SwitchID: sw.ID, State: Armed, Err: fmt.Errorf("deadman: failed to relay switch %v: %w", sw.ID, err)})
+		return
+	}
+	m.done(Event{SwitchID: sw.ID, State: Relayed, MetaTxnID: metaTxnID})
+}
+
+// done removes the switch of event, and reports it.
+func (m *Monitor) done(event Event) {
+	m.mu.Lock()
+	delete(m.switches, event.SwitchID)
+	m.mu.Unlock()
+	m.event(event)
+}
+
+func (m *Monitor) event(event Event) {
+	if m.options.OnEvent != nil {
+		m.options.OnEvent(event)
+	}
+}
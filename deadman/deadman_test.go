@@ -0,0 +1,154 @@
+package deadman_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/deadman"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRelayer struct {
+	provider *ethrpc.Provider
+	nonce    int64
+	relayErr error
+	relayed  []*sequence.SignedTransactions
+	mu       sync.Mutex
+}
+
+func (r *fakeRelayer) GetProvider() *ethrpc.Provider { return r.provider }
+
+func (r *fakeRelayer) EstimateGasLimits(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, txns sequence.Transactions) (sequence.Transactions, error) {
+	return txns, nil
+}
+
+func (r *fakeRelayer) GetNonce(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, space *big.Int, blockNum *big.Int) (*big.Int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return big.NewInt(r.nonce), nil
+}
+
+func (r *fakeRelayer) Relay(ctx context.Context, signedTxs *sequence.SignedTransactions) (sequence.MetaTxnID, *types.Transaction, ethtxn.WaitReceipt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.relayErr != nil {
+		return "", nil, nil, r.relayErr
+	}
+	r.relayed = append(r.relayed, signedTxs)
+	return sequence.MetaTxnID(signedTxs.Digest.Hex()[2:]), nil, nil, nil
+}
+
+func (r *fakeRelayer) Wait(ctx context.Context, metaTxnID sequence.MetaTxnID, optTimeout ...time.Duration) (sequence.MetaTxnStatus, *types.Receipt, error) {
+	return sequence.MetaTxnExecuted, nil, nil
+}
+
+func (r *fakeRelayer) FeeOptions(ctx context.Context, signedTxs *sequence.SignedTransactions) ([]*sequence.FeeOption, error) {
+	return nil, nil
+}
+
+func (r *fakeRelayer) setNonce(nonce int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nonce = nonce
+}
+
+func TestMonitor(t *testing.T) {
+	ctx := context.Background()
+
+	provider := memchain.New(memchain.Options{ChainID: big.NewInt(1)}).Provider()
+	relayer := &fakeRelayer{provider: provider, nonce: 3}
+	key, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(key)
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.Connect(provider, relayer))
+
+	heir := common.HexToAddress("0x4e1")
+	sweep := sequence.Transactions{{To: heir, Value: big.NewInt(1e18), RevertOnError: true}}
+	period := 30 * 24 * time.Hour
+
+	_, err = deadman.Arm(ctx, wallet, "succession", sweep, period, time.Now().Add(period/2))
+	assert.Error(t, err)
+
+	// the bundle is signed at the current nonce, and asserts its expiration
+	expiration := time.Now().Add(2 * period)
+	sw, err := deadman.Arm(ctx, wallet, "succession", sweep, period, expiration)
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.Address(), sw.Wallet)
+	assert.Equal(t, big.NewInt(3), sw.Bundle.Nonce)
+	if assert.Len(t, sw.Bundle.Transactions, 2) {
+		assert.Equal(t, wallet.GetWalletContext().UtilsAddress, sw.Bundle.Transactions[0].To)
+		assert.Equal(t, heir, sw.Bundle.Transactions[1].To)
+	}
+
+	var events []deadman.Event
+	now := time.Now()
+	monitor := deadman.NewMonitor(relayer, deadman.Options{OnEvent: func(event deadman.Event) {
+		events = append(events, event)
+	}}).SetClock(func() time.Time { return now })
+	assert.NoError(t, monitor.Add(sw))
+	assert.ErrorIs(t, monitor.Add(sw), deadman.ErrSwitchExists)
+
+	// not relayed while the wallet wasn't inactive for the period
+	monitor.Poll(ctx)
+	assert.Empty(t, relayer.relayed)
+	assert.Empty(t, events)
+
+	// relay errors are retried
+	now = sw.EligibleAt()
+	relayer.relayErr = errors.New("relayer unavailable")
+	monitor.Poll(ctx)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, deadman.Armed, events[0].State)
+		assert.ErrorContains(t, events[0].Err, "relayer unavailable")
+	}
+	assert.Equal(t, []string{"succession"}, monitor.Armed())
+
+	relayer.relayErr = nil
+	monitor.Poll(ctx)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, deadman.Relayed, events[1].State)
+		assert.NotEmpty(t, events[1].MetaTxnID)
+	}
+	assert.Len(t, relayer.relayed, 1)
+	assert.Empty(t, monitor.Armed())
+
+	// a wallet used after the switch was armed supersedes it
+	events = nil
+	superseded, err := deadman.Arm(ctx, wallet, "superseded", sweep, period, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, superseded.Bundle.Transactions, 1)
+	assert.NoError(t, monitor.Add(superseded))
+	relayer.setNonce(4)
+	monitor.Poll(ctx)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, deadman.Superseded, events[0].State)
+	}
+	assert.Len(t, relayer.relayed, 1)
+
+	// an expired switch isn't relayed
+	events = nil
+	expiring, err := deadman.Arm(ctx, wallet, "expiring", sweep, period, expiration)
+	assert.NoError(t, err)
+	assert.NoError(t, monitor.Add(expiring))
+	now = expiration
+	monitor.Poll(ctx)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, deadman.Expired, events[0].State)
+	}
+	assert.Len(t, relayer.relayed, 1)
+
+	assert.ErrorIs(t, monitor.Remove("expiring"), deadman.ErrSwitchNotFound)
+}
@@ -0,0 +1,95 @@
+package deployer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethartifact"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// Salt is the CREATE2 salt of a contract deployed by the universal deployer. The same
+// artifact, constructor args and salt are deployed at the same address on every chain.
+type Salt [32]byte
+
+// InstanceSalt is the salt of the instance number of a contract, as deployed by Deploy.
+func InstanceSalt(instance uint) Salt {
+	var salt Salt
+	new(big.Int).SetUint64(uint64(instance)).FillBytes(salt[:])
+	return salt
+}
+
+// NamedSalt is the salt of a name, ie. "acme/treasury/v2", so deployments can be told
+// apart by names instead of instance numbers.
+func NamedSalt(name string) Salt {
+	return Salt(crypto.Keccak256Hash([]byte(name)))
+}
+
+// Big returns the salt as the uint256 instance of the universal deployer.
+func (s Salt) Big() *big.Int {
+	return new(big.Int).SetBytes(s[:])
+}
+
+func (s Salt) Hex() string {
+	return common.Hash(s).Hex()
+}
+
+// ComputeAddress returns the address of artifact deployed with salt and constructorArgs by
+// the universal deployer, and its deploy data.
+func ComputeAddress(artifact ethartifact.Artifact, salt Salt, constructorArgs ...interface{}) (common.Address, []byte, error) {
+	var input []byte
+	var err error
+
+	if len(constructorArgs) > 0 && len(artifact.ABI.Constructor.Inputs) > 0 {
+		input, err = artifact.ABI.Pack("", constructorArgs...)
+	} else {
+		input, err = artifact.ABI.Pack("")
+	}
+	if err != nil {
+		return common.Address{}, nil, fmt.Errorf("ComputeAddress pack: %w", err)
+	}
+
+	deployData := make([]byte, 0, len(artifact.Bin)+len(input))
+	deployData = append(deployData, artifact.Bin...)
+	deployData = append(deployData, input...)
+
+	address := crypto.CreateAddress2(UNIVERSAL_DEPLOYER_2_ADDRESS, salt, crypto.Keccak256(deployData))
+	return address, deployData, nil
+}
+
+// Deployment is the outcome of DeployArtifact.
+type Deployment struct {
+	Address common.Address
+	Salt    Salt
+
+	// AlreadyDeployed is whether the contract was already deployed, in which case there is
+	// no Receipt.
+	AlreadyDeployed bool
+	Receipt         *types.Receipt
+}
+
+// SetGasLimit sets the gas limit of the transactions of DeployArtifact, which is estimated
+// if zero.
+func (u *UniversalDeployer) SetGasLimit(gasLimit uint64) *UniversalDeployer {
+	u.gasLimit = gasLimit
+	return u
+}
+
+// IsDeployed returns whether there is a contract at address.
+func (u *UniversalDeployer) IsDeployed(ctx context.Context, address common.Address) (bool, error) {
+	code, err := u.provider.CodeAt(ctx, address, nil)
+	if err != nil {
+		return false, fmt.Errorf("deployer getCode: %w", err)
+	}
+	return len(code) > 0, nil
+}
+
+// DeployArtifact deploys artifact with salt and constructorArgs at the address of
+// ComputeAddress, unless it is already deployed. The universal deployer is deployed first if
+// the chain doesn't have it yet.
+func (u *UniversalDeployer) DeployArtifact(ctx context.Context, artifact ethartifact.Artifact, salt Salt, constructorArgs ...interface{}) (*Deployment, error) {
+	return u.deploy(ctx, artifact, salt, nil, u.gasLimit, constructorArgs...)
+}
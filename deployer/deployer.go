@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"math/big"
 
-	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethartifact"
 	"github.com/0xsequence/ethkit/ethcontract"
 	"github.com/0xsequence/ethkit/ethrpc"
 	"github.com/0xsequence/ethkit/ethtxn"
@@ -13,7 +13,6 @@ import (
 	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
 	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/0xsequence/ethkit/go-ethereum/core/types"
-	"github.com/0xsequence/ethkit/go-ethereum/crypto"
 )
 
 // Sequence Universal Deployer constant values, do not change these.
@@ -76,6 +75,7 @@ var (
 type UniversalDeployer struct {
 	Wallet   *ethwallet.Wallet
 	provider *ethrpc.Provider
+	gasLimit uint64
 }
 
 func NewUniversalDeployer(wallet *ethwallet.Wallet) (*UniversalDeployer, error) {
@@ -89,72 +89,95 @@ func NewUniversalDeployer(wallet *ethwallet.Wallet) (*UniversalDeployer, error)
 	return ud, nil
 }
 
+// Deploy deploys contractBin with contractArgs as instance contractInstance, ie. at the
+// address of ComputeCreate2Address, unless it is already deployed.
 func (u *UniversalDeployer) Deploy(ctx context.Context, contractABI abi.ABI, contractBin []byte, contractInstance uint, txParams interface{}, gasLimit uint, contractArgs ...interface{}) (common.Address, error) {
+	artifact := ethartifact.Artifact{ABI: contractABI, Bin: contractBin}
+	deployment, err := u.deploy(ctx, artifact, InstanceSalt(contractInstance), txParams, uint64(gasLimit), contractArgs...)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return deployment.Address, nil
+}
+
+func (u *UniversalDeployer) deploy(ctx context.Context, artifact ethartifact.Artifact, salt Salt, txParams interface{}, gasLimit uint64, contractArgs ...interface{}) (*Deployment, error) {
 	// Deploy universal deployer 2 if not yet deployed
 	code, err := u.provider.CodeAt(ctx, UNIVERSAL_DEPLOYER_2_ADDRESS, nil)
 	if err != nil {
-		return common.Address{}, fmt.Errorf("deployer: %w", err)
+		return nil, fmt.Errorf("deployer: %w", err)
 	}
 	if len(code) == 0 {
 		err = u.deployUniversalDeployer2(ctx, txParams)
 		if err != nil {
-			return common.Address{}, err
+			return nil, err
 		}
 
 		code, err = u.provider.CodeAt(ctx, UNIVERSAL_DEPLOYER_2_ADDRESS, nil)
+		if err != nil {
+			return nil, fmt.Errorf("deployer: %w", err)
+		}
 		if len(code) == 0 {
-			return common.Address{}, fmt.Errorf("can't deploy universal deployer")
+			return nil, fmt.Errorf("can't deploy universal deployer")
 		}
 	}
 
 	// Deploying contract
 
 	// first compute the deterministic address of the contract to-be
-	contractAddress, deployData, err := ComputeCreate2Address(contractABI, contractBin, contractInstance, contractArgs...)
+	contractAddress, deployData, err := ComputeAddress(artifact, salt, contractArgs...)
 	if err != nil {
-		return common.Address{}, fmt.Errorf("deployer: %w", err)
+		return nil, fmt.Errorf("deployer: %w", err)
 	}
+	deployment := &Deployment{Address: contractAddress, Salt: salt}
 
-	code, err = u.provider.CodeAt(ctx, contractAddress, nil)
+	deployed, err := u.IsDeployed(ctx, contractAddress)
 	if err != nil {
-		return common.Address{}, fmt.Errorf("deployer getCode: %w", err)
+		return nil, err
 	}
-	if len(code) > 0 {
+	if deployed {
 		// contract is already deployed, we done
-		return contractAddress, nil
+		deployment.AlreadyDeployed = true
+		return deployment, nil
 	}
 
 	// Deploy the contract via UniversalDeployer2 by calling the deploy contract method
-	input, err := UNIVERSAL_DEPLOYER_2_ABI.Pack("deploy", deployData, big.NewInt(int64(contractInstance)))
+	input, err := UNIVERSAL_DEPLOYER_2_ABI.Pack("deploy", deployData, salt.Big())
 	if err != nil {
-		return common.Address{}, fmt.Errorf("deployer: deploy pack: %w", err)
+		return nil, fmt.Errorf("deployer: deploy pack: %w", err)
 	}
 
 	tx, err := u.Wallet.NewTransaction(ctx, &ethtxn.TransactionRequest{
 		To:       &UNIVERSAL_DEPLOYER_2_ADDRESS,
 		Data:     input,
-		GasLimit: uint64(gasLimit),
+		GasLimit: gasLimit,
 	})
+	if err != nil {
+		return nil, fmt.Errorf("deployer: deploy txn: %w", err)
+	}
 
 	_, waitTx, err := u.Wallet.SendTransaction(ctx, tx)
 	if err != nil {
-		return common.Address{}, fmt.Errorf("deployer: deploy call: %w", err)
+		return nil, fmt.Errorf("deployer: deploy call: %w", err)
 	}
 
 	receipt, err := waitTx(ctx)
 	if err != nil {
-		return common.Address{}, fmt.Errorf("deployer: deploy tx receipt wait: %w", err)
+		return nil, fmt.Errorf("deployer: deploy tx receipt wait: %w", err)
 	}
 	if receipt.Status != types.ReceiptStatusSuccessful {
-		return common.Address{}, fmt.Errorf("deployer: tx failed: %w", err)
+		return nil, fmt.Errorf("deployer: tx %v failed", receipt.TxHash.Hex())
 	}
+	deployment.Receipt = receipt
 
-	code, err = u.provider.CodeAt(ctx, contractAddress, nil)
-	if len(code) == 0 {
-		return common.Address{}, fmt.Errorf("can't deploy contract")
+	deployed, err = u.IsDeployed(ctx, contractAddress)
+	if err != nil {
+		return nil, err
+	}
+	if !deployed {
+		return nil, fmt.Errorf("can't deploy contract")
 	}
 
-	return contractAddress, nil
+	return deployment, nil
 }
 
 // TODO: perhaps update txParams to *ethwallet.TransactionRequest or *ethtx.TransactionRequest,
@@ -258,35 +281,9 @@ func (u *UniversalDeployer) deployUniversalDeployer1(ctx context.Context, txPara
 	return nil
 }
 
+// ComputeCreate2Address returns the address of instance contractInstance of contractBin
+// deployed by the universal deployer with contractArgs, and its deploy data.
 func ComputeCreate2Address(contractABI abi.ABI, contractBin []byte, contractInstance uint, contractArgs ...interface{}) (common.Address, []byte, error) {
-	var input []byte
-	var err error
-
-	if len(contractArgs) > 0 && len(contractABI.Constructor.Inputs) > 0 {
-		input, err = contractABI.Pack("", contractArgs...)
-	} else {
-		input, err = contractABI.Pack("")
-	}
-	if err != nil {
-		return common.Address{}, nil, fmt.Errorf("ComputeCreate2Address pack: %w", err)
-	}
-
-	deployData := append(contractBin, input...)
-	codeHash := crypto.Keccak256(deployData)
-
-	salt, err := ethcoder.SolidityPack([]string{"uint256"}, []interface{}{big.NewInt(int64(contractInstance))})
-	if err != nil {
-		return common.Address{}, nil, fmt.Errorf("ComputeCreate2Address salt: %w", err)
-	}
-
-	hashPack, err := ethcoder.SolidityPack(
-		[]string{"bytes1", "address", "bytes32", "bytes32"},
-		[]interface{}{[1]byte{255}, UNIVERSAL_DEPLOYER_2_ADDRESS, salt, codeHash},
-	)
-	if err != nil {
-		return common.Address{}, nil, fmt.Errorf("ComputeCreate2Address hashPack: %w", err)
-	}
-	hash := crypto.Keccak256(hashPack)[12:]
-
-	return common.BytesToAddress(hash), deployData, nil
+	artifact := ethartifact.Artifact{ABI: contractABI, Bin: contractBin}
+	return ComputeAddress(artifact, InstanceSalt(contractInstance), contractArgs...)
 }
@@ -40,3 +40,30 @@ func TestDeployer(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, testSequenceContext.FactoryAddress, walletFactoryAddress)
 }
+
+func TestDeployArtifact(t *testing.T) {
+	ud, err := deployer.NewUniversalDeployer(testChain.MustWallet(5))
+	assert.NoError(t, err)
+
+	artifact, ok := testutil.Contracts.Get("WALLET_CALL_RECV_MOCK")
+	assert.True(t, ok)
+	salt := deployer.NamedSalt("deployer/TestDeployArtifact")
+
+	address, _, err := deployer.ComputeAddress(artifact, salt)
+	assert.NoError(t, err)
+
+	deployment, err := ud.DeployArtifact(context.Background(), artifact, salt)
+	assert.NoError(t, err)
+	assert.Equal(t, address, deployment.Address)
+
+	deployed, err := ud.IsDeployed(context.Background(), address)
+	assert.NoError(t, err)
+	assert.True(t, deployed)
+
+	// deploying it again is a no-op
+	deployment, err = ud.DeployArtifact(context.Background(), artifact, salt)
+	assert.NoError(t, err)
+	assert.Equal(t, address, deployment.Address)
+	assert.True(t, deployment.AlreadyDeployed)
+	assert.Nil(t, deployment.Receipt)
+}
@@ -1,11 +1,13 @@
 package sequence
 
 import (
+	"encoding/hex"
 	"fmt"
 	"math/big"
 
-	"github.com/0xsequence/ethkit/ethcoder"
 	"github.com/0xsequence/ethkit/go-ethereum/common"
+	coredigest "github.com/0xsequence/go-sequence/core/digest"
+	"github.com/0xsequence/go-sequence/internal/keccak"
 )
 
 /*
@@ -41,39 +43,91 @@ func ComputeWalletExecDigest(nonce *big.Int, txns Transactions) (common.Hash, er
 	if nonce == nil {
 		return common.Hash{}, fmt.Errorf("nonce is required for wallet execute")
 	}
-	encodedTxns, err := txns.EncodedTransactions()
-	if err != nil {
+
+	// abi.encode(nonce, transactions) is hashed as it is encoded, see writeTransactions
+	h := keccak.Get()
+	defer keccak.Put(h)
+	h.WriteUint256(nonce)
+	h.WriteUint64(0x40)
+	if err := writeTransactions(h, txns); err != nil {
 		return common.Hash{}, err
 	}
-	message, err := abiTransactionsDigestType.Pack(nonce, encodedTxns)
-	if err != nil {
-		return common.Hash{}, fmt.Errorf("failed to pack nonce and transactions: %w", err)
-	}
-	return common.BytesToHash(ethcoder.Keccak256(message)), nil
+	return h.Sum(), nil
 }
 
 func ComputeSelfExecDigest(txns Transactions) (common.Hash, error) {
-	encodedTxns, err := txns.EncodedTransactions()
-	if err != nil {
-		return common.Hash{}, err
-	}
-	message, err := abiTransactionsStringDigestType.Pack("self:", encodedTxns)
-	if err != nil {
-		return common.Hash{}, fmt.Errorf("failed to pack \"self:\" and transactions: %w", err)
-	}
-	return common.BytesToHash(ethcoder.Keccak256(message)), nil
+	return computePrefixedExecDigest(selfExecPrefix, txns)
 }
 
 func ComputeGuestExecDigest(txns Transactions) (common.Hash, error) {
-	encodedTxns, err := txns.EncodedTransactions()
-	if err != nil {
+	return computePrefixedExecDigest(guestExecPrefix, txns)
+}
+
+var (
+	selfExecPrefix  = []byte("self:")
+	guestExecPrefix = []byte("guest:")
+)
+
+// computePrefixedExecDigest hashes abi.encode(prefix, transactions).
+func computePrefixedExecDigest(prefix []byte, txns Transactions) (common.Hash, error) {
+	h := keccak.Get()
+	defer keccak.Put(h)
+	h.WriteUint64(0x40)
+	h.WriteUint64(0x40 + 0x20 + paddedLen(len(prefix)))
+	h.WriteUint64(uint64(len(prefix)))
+	h.WritePadded(prefix)
+	if err := writeTransactions(h, txns); err != nil {
 		return common.Hash{}, err
 	}
-	message, err := abiTransactionsStringDigestType.Pack("guest:", encodedTxns)
-	if err != nil {
-		return common.Hash{}, fmt.Errorf("failed to pack \"guest:\" and transactions: %w", err)
+	return h.Sum(), nil
+}
+
+// writeTransactions hashes the abi encoding of txns as a Transaction[] tail, the same as
+// abi.Pack of txns.EncodedTransactions(), but without copying the transactions nor packing
+// the pre-image in memory.
+func writeTransactions(h *keccak.Hasher, txns Transactions) error {
+	// the offsets of the transactions depend on the length of their data, which for bundles
+	// is their execdata, so it is resolved first
+	var buf [16][]byte
+	data := buf[:0]
+	for _, txn := range txns {
+		if txn == nil {
+			return fmt.Errorf("cannot sign a nil transaction")
+		}
+		if !txn.encoded && txn.IsBundle() {
+			execdata, err := txn.Execdata()
+			if err != nil {
+				return err
+			}
+			data = append(data, execdata)
+		} else {
+			data = append(data, txn.Data)
+		}
+	}
+
+	h.WriteUint64(uint64(len(txns)))
+	offset := uint64(len(txns)) * 0x20
+	for i := range txns {
+		h.WriteUint64(offset)
+		offset += 7*0x20 + paddedLen(len(data[i]))
+	}
+
+	for i, txn := range txns {
+		h.WriteBool(txn.DelegateCall)
+		h.WriteBool(txn.RevertOnError)
+		h.WriteUint256(txn.GasLimit)
+		h.WriteAddress(txn.To)
+		h.WriteUint256(txn.Value)
+		h.WriteUint64(0xc0)
+		h.WriteUint64(uint64(len(data[i])))
+		h.WritePadded(data[i])
 	}
-	return common.BytesToHash(ethcoder.Keccak256(message)), nil
+	return nil
+}
+
+// paddedLen is the length of n bytes padded to a multiple of 32 bytes.
+func paddedLen(n int) uint64 {
+	return uint64(n+31) / 32 * 32
 }
 
 func ComputeMetaTxnID(chainID *big.Int, address common.Address, txns Transactions, nonce *big.Int, execType MetaTxnExecType) (MetaTxnID, common.Hash, error) {
@@ -104,31 +158,32 @@ func ComputeMetaTxnID(chainID *big.Int, address common.Address, txns Transaction
 }
 
 func ComputeMetaTxnIDFromDigest(chainID *big.Int, address common.Address, digest common.Hash) (MetaTxnID, common.Hash, error) {
-	subDigest, err := SubDigest(chainID, address, digest)
-	if err != nil {
-		return "", common.Hash{}, nil
+	if chainID == nil {
+		return "", common.Hash{}, ErrUnknownChainID
 	}
-
-	metaTxnIDHex := ethcoder.HexEncode(subDigest)
-	if len(metaTxnIDHex) != 66 {
-		return "", common.Hash{}, fmt.Errorf("computed meta txn id is invalid length")
+	subDigest, err := coredigest.SubDigest(chainID, address, digest)
+	if err != nil {
+		return "", common.Hash{}, fmt.Errorf("subDigest failed: %w", err)
 	}
-	return MetaTxnID(metaTxnIDHex[2:]), common.BytesToHash(subDigest), nil
+	var metaTxnID [2 * common.HashLength]byte
+	hex.Encode(metaTxnID[:], subDigest[:])
+	return MetaTxnID(metaTxnID[:]), subDigest, nil
 }
 
+// SubDigest binds digest to the wallet at address on chainID, see digest.SubDigest.
 func SubDigest(chainID *big.Int, address common.Address, digest common.Hash) ([]byte, error) {
 	if chainID == nil {
 		return nil, ErrUnknownChainID
 	}
 
 	// sequence smart wallet uses additional encoding of the digest in IsValidSignature()
-	packedData, err := PackMessageData(chainID, address, digest)
+	subDigest, err := coredigest.SubDigest(chainID, address, digest)
 	if err != nil {
-		return nil, fmt.Errorf("subDigest, packageMessageData failed: %w", err)
+		return nil, fmt.Errorf("subDigest failed: %w", err)
 	}
 
 	// returns subdigest
-	return ethcoder.Keccak256(packedData), nil
+	return subDigest.Bytes(), nil
 }
 
 // PackMessageData encodes a Sequence contract "message"
@@ -136,11 +191,5 @@ func PackMessageData(chainID *big.Int, address common.Address, digest common.Has
 	if chainID == nil {
 		return nil, ErrUnknownChainID
 	}
-	output, err := ethcoder.SolidityPack([]string{"string", "uint256", "address", "bytes"}, []interface{}{
-		"\x19\x01", chainID, address, digest[:],
-	})
-	if err != nil {
-		return nil, err
-	}
-	return output, nil
+	return coredigest.PackSubDigest(chainID, address, digest)
 }
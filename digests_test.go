@@ -0,0 +1,105 @@
+package sequence_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence"
+	"github.com/stretchr/testify/assert"
+)
+
+var abiTransactionsType = ethcoder.MustNewArrayTypeTuple([]abi.ArgumentMarshaling{
+	{Name: "delegateCall", Type: "bool"},
+	{Name: "revertOnError", Type: "bool"},
+	{Name: "gasLimit", Type: "uint256"},
+	{Name: "target", Type: "address"},
+	{Name: "value", Type: "uint256"},
+	{Name: "data", Type: "bytes"},
+})
+
+func digestTransactions() sequence.Transactions {
+	return sequence.Transactions{
+		{RevertOnError: true, GasLimit: big.NewInt(100000), To: common.HexToAddress("0xdead"), Data: common.FromHex("0xa9059cbb0000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000000a")},
+		{DelegateCall: true, To: common.HexToAddress("0xbeef"), Data: common.FromHex("0x12345678")},
+		{To: common.HexToAddress("0x2"), Value: big.NewInt(5)},
+		{To: common.HexToAddress("0x3"), Value: new(big.Int).Lsh(big.NewInt(1), 255), Data: make([]byte, 64)},
+		{
+			To:           common.HexToAddress("0x4"),
+			Transactions: sequence.Transactions{{To: common.HexToAddress("0x5"), Data: []byte{1}}},
+			Nonce:        big.NewInt(2),
+			Signature:    []byte{0, 1, 2},
+		},
+	}
+}
+
+func TestTransactionDigests(t *testing.T) {
+	txns := digestTransactions()
+	encoded, err := txns.EncodedTransactions()
+	assert.NoError(t, err)
+
+	// the digests hashed as they are encoded match the digests of the abi encoder
+	nonce := big.NewInt(7)
+	packed, err := abi.Arguments{{Type: ethcoder.MustNewType("uint256")}, {Type: abiTransactionsType}}.Pack(nonce, encoded)
+	assert.NoError(t, err)
+	walletExecDigest := crypto.Keccak256Hash(packed)
+	digest, err := sequence.ComputeWalletExecDigest(nonce, txns)
+	assert.NoError(t, err)
+	assert.Equal(t, walletExecDigest, digest)
+
+	for prefix, compute := range map[string]func(sequence.Transactions) (common.Hash, error){
+		"self:":  sequence.ComputeSelfExecDigest,
+		"guest:": sequence.ComputeGuestExecDigest,
+	} {
+		packed, err := abi.Arguments{{Type: ethcoder.MustNewType("string")}, {Type: abiTransactionsType}}.Pack(prefix, encoded)
+		assert.NoError(t, err)
+		digest, err := compute(txns)
+		assert.NoError(t, err)
+		assert.Equal(t, crypto.Keccak256Hash(packed), digest, prefix)
+	}
+
+	// as do the digests of encoded transactions, and of no transactions
+	digest, err = sequence.ComputeWalletExecDigest(nonce, sequence.NewTransactionsFromValues(encoded))
+	assert.NoError(t, err)
+	assert.Equal(t, walletExecDigest, digest)
+
+	packed, err = abi.Arguments{{Type: ethcoder.MustNewType("uint256")}, {Type: abiTransactionsType}}.Pack(nonce, []sequence.Transaction{})
+	assert.NoError(t, err)
+	digest, err = sequence.ComputeWalletExecDigest(nonce, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.Keccak256Hash(packed), digest)
+
+	_, err = sequence.ComputeSelfExecDigest(sequence.Transactions{nil})
+	assert.Error(t, err)
+
+	// meta-transaction ids are the hex of the sub-digest
+	metaTxnID, subDigest, err := sequence.ComputeMetaTxnIDFromDigest(big.NewInt(1), common.HexToAddress("0x7a7"), digest)
+	assert.NoError(t, err)
+	assert.Equal(t, subDigest.Hex()[2:], string(metaTxnID))
+	_, _, err = sequence.ComputeMetaTxnIDFromDigest(big.NewInt(-1), common.HexToAddress("0x7a7"), digest)
+	assert.Error(t, err)
+}
+
+func BenchmarkTransactionDigest(b *testing.B) {
+	bundle := &sequence.Transaction{Transactions: digestTransactions()[:4], Nonce: big.NewInt(7)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := bundle.Digest(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkComputeMetaTxnID(b *testing.B) {
+	txns := digestTransactions()[:4]
+	chainID, address, nonce := big.NewInt(137), common.HexToAddress("0x7a7"), big.NewInt(7)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := sequence.ComputeMetaTxnID(chainID, address, txns, nonce, sequence.MetaTxnWalletExec); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
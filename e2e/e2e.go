@@ -0,0 +1,217 @@
+// Package e2e encrypts the requests and responses of HTTP transports end to end, ie. of
+// remote signers and approval services such as a walletrpc.Handler, so the digests and
+// bundles they exchange are not exposed to the proxies, load balancers and gateways in
+// between.
+//
+// The server has a static X25519 key, which clients pin. Each request is sealed with a
+// ChaCha20-Poly1305 key agreed with an ephemeral key of the client, and optionally its
+// static key, which servers may pin in turn, and its response is sealed with a key of the
+// same exchange:
+//
+//	handler := e2e.NewHandler(serverKey, walletrpc.NewHandler(wallet))
+//	client := &http.Client{Transport: e2e.NewTransport(serverPublicKey, nil)}
+package e2e
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// ContentType is the content type of sealed requests and responses.
+const ContentType = "application/vnd.sequence.e2e"
+
+// version is the version of the envelope format.
+const version = 1
+
+// headerSize is the size of the header of a sealed request: its version, timestamp,
+// ephemeral key and client key.
+const headerSize = 1 + 8 + 32 + 32
+
+var (
+	ErrInvalidEnvelope  = errors.New("e2e: invalid envelope")
+	ErrDecryption       = errors.New("e2e: decryption failed")
+	ErrUnpinnedClient   = errors.New("e2e: client key is not pinned")
+	ErrExpiredRequest   = errors.New("e2e: request is expired")
+	ErrReplayedRequest  = errors.New("e2e: request is replayed")
+	ErrUnsealedResponse = errors.New("e2e: response is not sealed")
+)
+
+// PublicKey is an X25519 public key.
+type PublicKey [32]byte
+
+// ParsePublicKey parses a hex encoded public key, with or without 0x prefix.
+func ParsePublicKey(s string) (PublicKey, error) {
+	var key PublicKey
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil || len(b) != len(key) {
+		return key, fmt.Errorf("e2e: invalid public key %q", s)
+	}
+	copy(key[:], b)
+	return key, nil
+}
+
+func (k PublicKey) String() string {
+	return "0x" + hex.EncodeToString(k[:])
+}
+
+func (k PublicKey) isZero() bool {
+	return k == PublicKey{}
+}
+
+// PrivateKey is an X25519 private key.
+type PrivateKey struct {
+	key    [32]byte
+	public PublicKey
+}
+
+// GenerateKey generates a private key.
+func GenerateKey() (*PrivateKey, error) {
+	var key [32]byte
+	if _, err := io.ReadFull(rand.Reader, key[:]); err != nil {
+		return nil, err
+	}
+	return NewPrivateKey(key[:])
+}
+
+// NewPrivateKey returns the private key of 32 bytes key.
+func NewPrivateKey(key []byte) (*PrivateKey, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("e2e: private key must be 32 bytes, not %d", len(key))
+	}
+	k := &PrivateKey{}
+	copy(k.key[:], key)
+	public, err := curve25519.X25519(k.key[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, fmt.Errorf("e2e: invalid private key: %w", err)
+	}
+	copy(k.public[:], public)
+	return k, nil
+}
+
+func (k *PrivateKey) PublicKey() PublicKey {
+	return k.public
+}
+
+func (k *PrivateKey) sharedSecret(peer PublicKey) ([]byte, error) {
+	secret, err := curve25519.X25519(k.key[:], peer[:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	return secret, nil
+}
+
+// header is the header of a sealed request, sent in the clear and authenticated.
+type header struct {
+	timestamp time.Time
+	ephemeral PublicKey
+	client    PublicKey
+}
+
+func (h *header) bytes() []byte {
+	b := make([]byte, headerSize)
+	b[0] = version
+	binary.BigEndian.PutUint64(b[1:9], uint64(h.timestamp.Unix()))
+	copy(b[9:41], h.ephemeral[:])
+	copy(b[41:73], h.client[:])
+	return b
+}
+
+func parseHeader(b []byte) (*header, error) {
+	if len(b) < headerSize || b[0] != version {
+		return nil, ErrInvalidEnvelope
+	}
+	h := &header{timestamp: time.Unix(int64(binary.BigEndian.Uint64(b[1:9])), 0)}
+	copy(h.ephemeral[:], b[9:41])
+	copy(h.client[:], b[41:73])
+	return h, nil
+}
+
+// exchangeKeys are the keys of a request and its response.
+type exchangeKeys struct {
+	request  []byte
+	response []byte
+}
+
+// deriveKeys derives the keys of an exchange from the secrets agreed by the ephemeral key of
+// the client, and its static key if any, with the server key.
+func deriveKeys(h *header, server PublicKey, secrets ...[]byte) (*exchangeKeys, error) {
+	salt := append(h.bytes(), server[:]...)
+	kdf := hkdf.New(sha256.New, bytes.Join(secrets, nil), salt, []byte("go-sequence e2e v1"))
+	keys := &exchangeKeys{request: make([]byte, chacha20poly1305.KeySize), response: make([]byte, chacha20poly1305.KeySize)}
+	if _, err := io.ReadFull(kdf, keys.request); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(kdf, keys.response); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// seal encrypts plaintext with key, authenticating aad, as a random nonce followed by the
+// ciphertext.
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(key, sealed, aad []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrInvalidEnvelope
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], aad)
+	if err != nil {
+		return nil, ErrDecryption
+	}
+	return plaintext, nil
+}
+
+// requestAAD binds a sealed request to its method and path, so it can't be replayed to
+// another endpoint.
+func requestAAD(headerBytes []byte, method, path string) []byte {
+	return append(append([]byte{}, headerBytes...), method+" "+path...)
+}
+
+// encodeMessage encodes the status, of a response, and the content type of a request or
+// response with its body, as they are sealed with it.
+func encodeMessage(status int, contentType string, body []byte) []byte {
+	b := make([]byte, 4, 4+len(contentType)+len(body))
+	binary.BigEndian.PutUint16(b[0:2], uint16(status))
+	binary.BigEndian.PutUint16(b[2:4], uint16(len(contentType)))
+	b = append(b, contentType...)
+	return append(b, body...)
+}
+
+func decodeMessage(b []byte) (int, string, []byte, error) {
+	if len(b) < 4 {
+		return 0, "", nil, ErrInvalidEnvelope
+	}
+	status := int(binary.BigEndian.Uint16(b[0:2]))
+	n := int(binary.BigEndian.Uint16(b[2:4]))
+	if len(b) < 4+n {
+		return 0, "", nil, ErrInvalidEnvelope
+	}
+	return status, string(b[4 : 4+n]), b[4+n:], nil
+}
@@ -0,0 +1,80 @@
+package e2e_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0xsequence/go-sequence/e2e"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannel(t *testing.T) {
+	serverKey, err := e2e.GenerateKey()
+	assert.NoError(t, err)
+	clientKey, err := e2e.GenerateKey()
+	assert.NoError(t, err)
+
+	secret := []byte(`{"method":"sign","digest":"0x1234"}`)
+	handler := e2e.NewHandler(serverKey, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write(append([]byte("signed "), body...))
+	}))
+
+	// the intermediary sees neither the request nor the response
+	var relayed [][]byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		relayed = append(relayed, body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		handler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	post := func(transport *e2e.Transport) (*http.Response, error) {
+		client := &http.Client{Transport: transport}
+		return client.Post(ts.URL+"/sign", "application/json", bytes.NewReader(secret))
+	}
+
+	serverPublicKey, err := e2e.ParsePublicKey(handler.PublicKey().String())
+	assert.NoError(t, err)
+	res, err := post(e2e.NewTransport(serverPublicKey, nil))
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(res.Body)
+	assert.Equal(t, http.StatusAccepted, res.StatusCode)
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+	assert.Equal(t, "signed "+string(secret), string(body))
+	assert.False(t, bytes.Contains(relayed[0], []byte("digest")))
+
+	// a client which doesn't pin the server key can't be served
+	otherKey, err := e2e.GenerateKey()
+	assert.NoError(t, err)
+	_, err = post(e2e.NewTransport(otherKey.PublicKey(), nil))
+	assert.True(t, errors.Is(err, e2e.ErrUnsealedResponse))
+
+	// a server which pins its clients rejects anonymous clients
+	handler.PinClients(clientKey.PublicKey())
+	_, err = post(e2e.NewTransport(serverPublicKey, nil))
+	assert.True(t, errors.Is(err, e2e.ErrUnsealedResponse))
+	res, err = post(e2e.NewTransport(serverPublicKey, nil).SetClientKey(clientKey))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, res.StatusCode)
+
+	// replayed requests are rejected
+	replay, err := http.Post(ts.URL+"/sign", e2e.ContentType, bytes.NewReader(relayed[len(relayed)-1]))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, replay.StatusCode)
+	replayBody, _ := io.ReadAll(replay.Body)
+	assert.Contains(t, string(replayBody), e2e.ErrReplayedRequest.Error())
+
+	// as are requests to another path
+	replay, err = http.Post(ts.URL+"/other", e2e.ContentType, bytes.NewReader(relayed[len(relayed)-1]))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, replay.StatusCode)
+}
@@ -0,0 +1,194 @@
+package e2e
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultMaxSkew is the default of how old or early a request may be.
+const DefaultMaxSkew = 2 * time.Minute
+
+// Handler is an http.Handler which opens sealed requests for the next handler, and seals its
+// responses. Requests which are not sealed for its key are rejected, as are requests older
+// than its max skew and requests replayed within it. Requests are bound to their method and
+// path, so the handler must see the path the client requested, ie. be mounted outside of
+// http.StripPrefix.
+type Handler struct {
+	key     *PrivateKey
+	next    http.Handler
+	clients map[PublicKey]bool
+	maxSkew time.Duration
+
+	seen   map[PublicKey]time.Time
+	seenMu sync.Mutex
+}
+
+var _ http.Handler = &Handler{}
+
+func NewHandler(key *PrivateKey, next http.Handler) *Handler {
+	return &Handler{key: key, next: next, maxSkew: DefaultMaxSkew, seen: map[PublicKey]time.Time{}}
+}
+
+// PinClients only accepts requests authenticated by the static keys of clients, see
+// Transport.SetClientKey. Anonymous requests are accepted otherwise.
+func (h *Handler) PinClients(keys ...PublicKey) *Handler {
+	h.clients = make(map[PublicKey]bool, len(keys))
+	for _, key := range keys {
+		h.clients[key] = true
+	}
+	return h
+}
+
+func (h *Handler) SetMaxSkew(maxSkew time.Duration) *Handler {
+	h.maxSkew = maxSkew
+	return h
+}
+
+// PublicKey returns the key clients pin, see NewTransport.
+func (h *Handler) PublicKey() PublicKey {
+	return h.key.PublicKey()
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sealed, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(sealed) > maxBodySize {
+		http.Error(w, "request is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	keys, headerBytes, contentType, body, err := h.open(r, sealed)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrUnpinnedClient) || errors.Is(err, ErrDecryption) {
+			status = http.StatusUnauthorized
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	opened := r.Clone(r.Context())
+	opened.Body = io.NopCloser(bytes.NewReader(body))
+	opened.ContentLength = int64(len(body))
+	opened.Header.Del("Content-Type")
+	if contentType != "" {
+		opened.Header.Set("Content-Type", contentType)
+	}
+
+	rec := &responseRecorder{header: http.Header{}}
+	h.next.ServeHTTP(rec, opened)
+
+	sealedRes, err := seal(keys.response, encodeMessage(rec.statusCode(), rec.header.Get("Content-Type"), rec.body.Bytes()), headerBytes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(sealedRes)
+}
+
+func (h *Handler) open(r *http.Request, sealed []byte) (*exchangeKeys, []byte, string, []byte, error) {
+	if r.Header.Get("Content-Type") != ContentType {
+		return nil, nil, "", nil, fmt.Errorf("%w: content type must be %s", ErrInvalidEnvelope, ContentType)
+	}
+	hdr, err := parseHeader(sealed)
+	if err != nil {
+		return nil, nil, "", nil, err
+	}
+	if h.clients != nil && !h.clients[hdr.client] {
+		return nil, nil, "", nil, ErrUnpinnedClient
+	}
+	now := time.Now()
+	if hdr.timestamp.Before(now.Add(-h.maxSkew)) || hdr.timestamp.After(now.Add(h.maxSkew)) {
+		return nil, nil, "", nil, ErrExpiredRequest
+	}
+
+	secret, err := h.key.sharedSecret(hdr.ephemeral)
+	if err != nil {
+		return nil, nil, "", nil, err
+	}
+	secrets := [][]byte{secret}
+	if !hdr.client.isZero() {
+		secret, err := h.key.sharedSecret(hdr.client)
+		if err != nil {
+			return nil, nil, "", nil, err
+		}
+		secrets = append(secrets, secret)
+	}
+	keys, err := deriveKeys(hdr, h.key.PublicKey(), secrets...)
+	if err != nil {
+		return nil, nil, "", nil, err
+	}
+
+	headerBytes := sealed[:headerSize]
+	plaintext, err := open(keys.request, sealed[headerSize:], requestAAD(headerBytes, r.Method, r.URL.Path))
+	if err != nil {
+		return nil, nil, "", nil, err
+	}
+	if !h.markSeen(hdr.ephemeral, now) {
+		return nil, nil, "", nil, ErrReplayedRequest
+	}
+
+	_, contentType, body, err := decodeMessage(plaintext)
+	if err != nil {
+		return nil, nil, "", nil, err
+	}
+	return keys, headerBytes, contentType, body, nil
+}
+
+// markSeen records the ephemeral key of a request, and reports whether it wasn't seen within
+// the max skew.
+func (h *Handler) markSeen(ephemeral PublicKey, now time.Time) bool {
+	h.seenMu.Lock()
+	defer h.seenMu.Unlock()
+	for key, at := range h.seen {
+		if now.Sub(at) > 2*h.maxSkew {
+			delete(h.seen, key)
+		}
+	}
+	if _, ok := h.seen[ephemeral]; ok {
+		return false
+	}
+	h.seen[ephemeral] = now
+	return true
+}
+
+// responseRecorder records the response of the next handler to seal it.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+func (r *responseRecorder) statusCode() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
@@ -0,0 +1,132 @@
+package e2e
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxBodySize limits the size of sealed bodies.
+const maxBodySize = 1 << 20
+
+// Transport is an http.RoundTripper which seals the requests it sends to a server of a
+// pinned key, and opens their responses. Responses which are not sealed by the server fail
+// with ErrUnsealedResponse, as they may come from an intermediary.
+type Transport struct {
+	base      http.RoundTripper
+	serverKey PublicKey
+	clientKey *PrivateKey
+}
+
+var _ http.RoundTripper = &Transport{}
+
+// NewTransport returns a Transport to the server of serverKey over base, or
+// http.DefaultTransport if base is nil.
+func NewTransport(serverKey PublicKey, base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{base: base, serverKey: serverKey}
+}
+
+// SetClientKey authenticates the requests with the static key of the client, which servers
+// may pin, see Handler.PinClients. Requests are anonymous otherwise.
+func (t *Transport) SetClientKey(key *PrivateKey) *Transport {
+	t.clientKey = key
+	return t
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ephemeral, err := GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	h := &header{timestamp: time.Now(), ephemeral: ephemeral.PublicKey()}
+	secret, err := ephemeral.sharedSecret(t.serverKey)
+	if err != nil {
+		return nil, err
+	}
+	secrets := [][]byte{secret}
+	if t.clientKey != nil {
+		h.client = t.clientKey.PublicKey()
+		secret, err := t.clientKey.sharedSecret(t.serverKey)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, secret)
+	}
+	keys, err := deriveKeys(h, t.serverKey, secrets...)
+	if err != nil {
+		return nil, err
+	}
+
+	headerBytes := h.bytes()
+	sealed, err := seal(keys.request, encodeMessage(0, req.Header.Get("Content-Type"), body), requestAAD(headerBytes, req.Method, req.URL.Path))
+	if err != nil {
+		return nil, err
+	}
+	sealed = append(headerBytes, sealed...)
+
+	sealedReq := req.Clone(req.Context())
+	sealedReq.Body = io.NopCloser(bytes.NewReader(sealed))
+	sealedReq.ContentLength = int64(len(sealed))
+	sealedReq.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(sealed)), nil
+	}
+	sealedReq.Header.Set("Content-Type", ContentType)
+	sealedReq.Header.Del("Content-Encoding")
+
+	res, err := t.base.RoundTrip(sealedReq)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.Header.Get("Content-Type") != ContentType {
+		return nil, fmt.Errorf("%w: %s", ErrUnsealedResponse, res.Status)
+	}
+	sealedRes, err := io.ReadAll(io.LimitReader(res.Body, maxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(sealedRes) > maxBodySize {
+		return nil, fmt.Errorf("%w: response is too large", ErrInvalidEnvelope)
+	}
+	plaintext, err := open(keys.response, sealedRes, headerBytes)
+	if err != nil {
+		return nil, err
+	}
+	status, contentType, resBody, err := decodeMessage(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	opened := &http.Response{
+		Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+		StatusCode:    status,
+		Proto:         res.Proto,
+		ProtoMajor:    res.ProtoMajor,
+		ProtoMinor:    res.ProtoMinor,
+		Header:        http.Header{},
+		Body:          io.NopCloser(bytes.NewReader(resBody)),
+		ContentLength: int64(len(resBody)),
+		Request:       req,
+	}
+	if contentType != "" {
+		opened.Header.Set("Content-Type", contentType)
+	}
+	return opened, nil
+}
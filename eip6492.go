@@ -0,0 +1,106 @@
+package sequence
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/go-sequence/contracts/gen/ierc1271"
+	"github.com/0xsequence/go-sequence/contracts/gen/walletmain"
+)
+
+// EIP6492MagicSuffix ends the signatures wrapped as of EIP-6492, ie. of wallets which are not
+// deployed yet.
+var EIP6492MagicSuffix = common.FromHex("0x6492649264926492649264926492649264926492649264926492649264926492")
+
+// EncodeEIP6492Signature wraps signature as of EIP-6492, with the call of factory deploying
+// the wallet it is of, ie. abi.encode(factory, factoryCalldata, signature) ++ magic suffix.
+func EncodeEIP6492Signature(factory common.Address, factoryCalldata []byte, signature []byte) ([]byte, error) {
+	encoded, err := ethcoder.AbiCoder([]string{"address", "bytes", "bytes"}, []interface{}{factory, factoryCalldata, signature})
+	if err != nil {
+		return nil, fmt.Errorf("sequence: EIP-6492 signature: %w", err)
+	}
+	return append(encoded, EIP6492MagicSuffix...), nil
+}
+
+// DecodeEIP6492Signature unwraps a signature wrapped as of EIP-6492, into the call of the
+// factory deploying its wallet and the signature of the wallet.
+func DecodeEIP6492Signature(signature []byte) (common.Address, []byte, []byte, error) {
+	if !IsEIP6492Signature(signature) {
+		return common.Address{}, nil, nil, fmt.Errorf("sequence: not an EIP-6492 signature")
+	}
+
+	var factory common.Address
+	var factoryCalldata, wrapped []byte
+	err := ethcoder.AbiDecoder([]string{"address", "bytes", "bytes"}, signature[:len(signature)-len(EIP6492MagicSuffix)], []interface{}{&factory, &factoryCalldata, &wrapped})
+	if err != nil {
+		return common.Address{}, nil, nil, fmt.Errorf("sequence: invalid EIP-6492 signature: %w", err)
+	}
+	return factory, factoryCalldata, wrapped, nil
+}
+
+// IsEIP6492Signature reports whether signature is wrapped as of EIP-6492.
+func IsEIP6492Signature(signature []byte) bool {
+	return bytes.HasSuffix(signature, EIP6492MagicSuffix)
+}
+
+// SignMessageEIP6492 signs msg as SignMessage does, and wraps the signature as of EIP-6492
+// with the deployment of the wallet, so it can be validated before the wallet is deployed, by
+// dapps simulating the deployment, see IsValidSignature.
+func (w *Wallet) SignMessageEIP6492(msg []byte) ([]byte, *Signature, error) {
+	sig, decoded, err := w.SignMessage(msg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, factory, deployData, err := EncodeWalletDeployment(w.config, w.context)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sequence.Wallet#SignMessageEIP6492: %w", err)
+	}
+	wrapped, err := EncodeEIP6492Signature(factory, deployData, sig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sequence.Wallet#SignMessageEIP6492: %w", err)
+	}
+	return wrapped, decoded, nil
+}
+
+// isValidEIP6492Signature validates a signature wrapped as of EIP-6492. The signature of a
+// deployed wallet is validated with ERC-1271. Otherwise, the wallet is deployed and validates
+// the signature in a single eth_call, with the multiCall method of the wallet utils contract.
+func isValidEIP6492Signature(ctx context.Context, provider *ethrpc.Provider, walletContext WalletContext, walletAddress common.Address, digest common.Hash, signature []byte) (bool, error) {
+	factory, factoryCalldata, signature, err := DecodeEIP6492Signature(signature)
+	if err != nil {
+		return false, err
+	}
+
+	code, err := provider.CodeAt(ctx, walletAddress, nil)
+	if err != nil {
+		return false, fmt.Errorf("sequence: IsValidSignature: %w", err)
+	}
+	if len(code) != 0 {
+		return callIsValidSignature(ctx, provider, walletAddress, digest, signature)
+	}
+
+	isValidSignature, err := walletmain.WalletMainCalldata.IsValidSignature(digest, signature)
+	if err != nil {
+		return false, err
+	}
+	results, err := MultiCall(ctx, provider, walletContext, Transactions{
+		{To: factory, Data: factoryCalldata},
+		{To: walletAddress, Data: isValidSignature},
+	})
+	if err != nil {
+		return false, fmt.Errorf("sequence: IsValidSignature: simulating deployment of %v: %w", walletAddress.Hex(), err)
+	}
+	if !results[0].Success {
+		return false, fmt.Errorf("sequence: IsValidSignature: deployment of %v with factory %v failed", walletAddress.Hex(), factory.Hex())
+	}
+	if !results[1].Success || len(results[1].Result) < 4 {
+		return false, nil
+	}
+	return ierc1271.IsValidSignatureBytes32_MagicReturnValue == hexutil.Encode(results[1].Result[:4]), nil
+}
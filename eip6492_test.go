@@ -0,0 +1,94 @@
+package sequence_test
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEIP6492(t *testing.T) {
+	ctx := context.Background()
+	message := []byte("sign in with sequence")
+
+	owner, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(owner)
+	assert.NoError(t, err)
+	walletContext := wallet.GetWalletContext()
+	_, _, deployData, err := sequence.EncodeWalletDeployment(wallet.GetWalletConfig(), walletContext)
+	assert.NoError(t, err)
+
+	// the chain, on which the wallet is deployed and validates its signatures once deployed,
+	// or in the multiCall simulating its deployment
+	chain := memchain.New(memchain.Options{ChainID: big.NewInt(1)})
+	var simulations int
+	magic := common.RightPadBytes(common.FromHex("0x1626ba7e"), 32)
+	isValidSignature := func(data []byte) []byte {
+		var digest [32]byte
+		var signature []byte
+		assert.NoError(t, ethcoder.AbiDecoder([]string{"bytes32", "bytes"}, data[4:], []interface{}{&digest, &signature}))
+		if sequence.IsEIP6492Signature(signature) || digest != sequence.MessageDigest(message) {
+			return make([]byte, 32)
+		}
+		return magic
+	}
+	chain.Deploy(walletContext.UtilsAddress, memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		simulations++
+		txns, err := sequence.DecodeRawTransactions(input[4:])
+		assert.NoError(t, err)
+		assert.Len(t, txns, 2)
+		deploys := txns[0].To == walletContext.FactoryAddress && bytes.Equal(txns[0].Data, deployData)
+		return ethcoder.AbiCoder([]string{"bool[]", "bytes[]"}, []interface{}{
+			[]bool{deploys, deploys}, [][]byte{nil, isValidSignature(txns[1].Data)},
+		})
+	}))
+	provider := chain.Provider()
+	assert.NoError(t, wallet.SetProvider(provider))
+
+	// signatures are wrapped with the deployment of the wallet
+	sig, _, err := wallet.SignMessageEIP6492(message)
+	assert.NoError(t, err)
+	assert.True(t, sequence.IsEIP6492Signature(sig))
+	factory, factoryCalldata, unwrapped, err := sequence.DecodeEIP6492Signature(sig)
+	assert.NoError(t, err)
+	assert.Equal(t, walletContext.FactoryAddress, factory)
+	assert.Equal(t, deployData, factoryCalldata)
+	expected, _, err := wallet.SignMessage(message)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, unwrapped)
+
+	_, _, _, err = sequence.DecodeEIP6492Signature(unwrapped)
+	assert.Error(t, err)
+
+	// and validated by simulating the deployment of the wallet
+	ok, err := sequence.IsValidSignature(ctx, provider, wallet.Address(), sequence.MessageDigest(message), sig)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = sequence.IsValidSignature(ctx, provider, wallet.Address(), sequence.MessageDigest([]byte("other")), sig)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 2, simulations)
+
+	wrongFactory, err := sequence.EncodeEIP6492Signature(common.HexToAddress("0x7a7"), deployData, unwrapped)
+	assert.NoError(t, err)
+	_, err = sequence.IsValidSignature(ctx, provider, wallet.Address(), sequence.MessageDigest(message), wrongFactory)
+	assert.ErrorContains(t, err, "failed")
+
+	// once deployed, the wallet validates the unwrapped signature
+	chain.Deploy(wallet.Address(), memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		return isValidSignature(input), nil
+	}))
+	ok, err = wallet.IsValidSignature(sequence.MessageDigest(message), sig)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 3, simulations)
+}
@@ -0,0 +1,232 @@
+// Package eip7702 lets an EOA delegate its code to the Sequence wallet modules with EIP-7702,
+// so the EOA address itself becomes a Sequence wallet, which signs and relays bundles like
+// a deployed wallet.
+//
+// EXPERIMENTAL: this may only be used on chains where EIP-7702 is live.
+//
+// A delegated EOA differs from a counterfactual Sequence wallet in that:
+//
+//   - the wallet address is the EOA address, not derived from its config and context;
+//   - it is delegated to MainModuleUpgradable, which validates signatures against the image
+//     hash in storage, as MainModule only validates against its counterfactual address;
+//   - the image hash is initialized by the EOA calling the wallet itself, so the EOA must
+//     pay for its own delegation transaction;
+//   - the EOA key keeps full control of the account, and can send transactions or replace
+//     the delegation at any time, whatever the wallet config is.
+package eip7702
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/ethkit/go-ethereum/rlp"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/contracts/gen/walletmain"
+	"github.com/0xsequence/go-sequence/contracts/gen/walletupgradable"
+	"github.com/0xsequence/go-sequence/relayer"
+)
+
+// authorizationMagic prefixes the payload of EIP-7702 authorization digests.
+const authorizationMagic = 0x05
+
+// delegationPrefix prefixes the code of a delegated EOA, followed by the delegate address.
+var delegationPrefix = []byte{0xef, 0x01, 0x00}
+
+// AuthorizationDigest returns the digest an EOA signs to delegate its code to delegate. A
+// chainID of 0 authorizes the delegation on any chain.
+func AuthorizationDigest(chainID *big.Int, delegate common.Address, nonce uint64) common.Hash {
+	if chainID == nil {
+		chainID = new(big.Int)
+	}
+	payload, _ := rlp.EncodeToBytes([]interface{}{chainID, delegate, nonce})
+	return crypto.Keccak256Hash([]byte{authorizationMagic}, payload)
+}
+
+// SignAuthorization signs the delegation of the code of eoa to delegate. nonce must be the
+// account nonce of eoa when the authorization is processed, which is one more than its
+// current nonce if eoa also sends the transaction carrying it.
+func SignAuthorization(eoa *ethwallet.Wallet, chainID *big.Int, delegate common.Address, nonce uint64) (relayer.SetCodeAuthorization, error) {
+	digest := AuthorizationDigest(chainID, delegate, nonce)
+	sig, err := crypto.Sign(digest[:], eoa.PrivateKey())
+	if err != nil {
+		return relayer.SetCodeAuthorization{}, fmt.Errorf("eip7702: failed to sign authorization: %w", err)
+	}
+	if chainID == nil {
+		chainID = new(big.Int)
+	}
+	return relayer.SetCodeAuthorization{
+		ChainID: chainID,
+		Address: delegate,
+		Nonce:   nonce,
+		V:       sig[64],
+		R:       new(big.Int).SetBytes(sig[:32]),
+		S:       new(big.Int).SetBytes(sig[32:64]),
+	}, nil
+}
+
+// RecoverAuthority returns the EOA which signed auth.
+func RecoverAuthority(auth relayer.SetCodeAuthorization) (common.Address, error) {
+	digest := AuthorizationDigest(auth.ChainID, auth.Address, auth.Nonce)
+	sig := make([]byte, 65)
+	auth.R.FillBytes(sig[:32])
+	auth.S.FillBytes(sig[32:64])
+	sig[64] = auth.V
+
+	pub, err := crypto.SigToPub(digest[:], sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("eip7702: invalid authorization signature: %w", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// Delegation is an EOA delegated, or to be delegated, to the MainModuleUpgradable of a
+// wallet context, and owned by itself as the single signer of its wallet config.
+type Delegation struct {
+	EOA     *ethwallet.Wallet
+	Context sequence.WalletContext
+}
+
+func NewDelegation(eoa *ethwallet.Wallet, walletContext sequence.WalletContext) *Delegation {
+	return &Delegation{EOA: eoa, Context: walletContext}
+}
+
+// Address is the address of the wallet, which is the EOA address.
+func (d *Delegation) Address() common.Address {
+	return d.EOA.Address()
+}
+
+// Delegate is the module the EOA delegates its code to.
+func (d *Delegation) Delegate() common.Address {
+	return d.Context.MainModuleUpgradableAddress
+}
+
+// WalletConfig is the config of the wallet, with the EOA as its single signer.
+func (d *Delegation) WalletConfig() sequence.WalletConfig {
+	return sequence.WalletConfig{
+		Threshold: 1,
+		Signers:   sequence.WalletConfigSigners{{Weight: 1, Address: d.EOA.Address()}},
+	}
+}
+
+func (d *Delegation) ImageHash() (common.Hash, error) {
+	imageHash, err := sequence.ImageHashOfWalletConfigBytes32(d.WalletConfig())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return imageHash, nil
+}
+
+// Wallet returns the Sequence wallet of the delegated EOA, which signs for the EOA address.
+func (d *Delegation) Wallet(opts ...sequence.Option) (*sequence.Wallet, error) {
+	return sequence.NewWallet(sequence.WalletOptions{
+		Config:  d.WalletConfig(),
+		Context: &d.Context,
+		Address: d.EOA.Address(),
+		Options: opts,
+	}, d.EOA)
+}
+
+// IsDelegated returns true if the code of the EOA is delegated to the Delegate module.
+func (d *Delegation) IsDelegated(ctx context.Context, provider *ethrpc.Provider) (bool, error) {
+	code, err := provider.CodeAt(ctx, d.EOA.Address(), nil)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(code, append(append([]byte{}, delegationPrefix...), d.Delegate().Bytes()...)), nil
+}
+
+// DelegationTx returns the set-code transaction, sent by the EOA itself, which delegates
+// its code to the Delegate module and initializes the image hash of the wallet. The image
+// hash can only be set by a call of the wallet to itself, so it cannot be sponsored.
+func (d *Delegation) DelegationTx(ctx context.Context, provider *ethrpc.Provider) (*relayer.SetCodeTx, error) {
+	chainID, err := provider.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := provider.PendingNonceAt(ctx, d.EOA.Address())
+	if err != nil {
+		return nil, err
+	}
+
+	// the sender's nonce is incremented before authorizations are processed
+	auth, err := SignAuthorization(d.EOA, chainID, d.Delegate(), nonce+1)
+	if err != nil {
+		return nil, err
+	}
+
+	imageHash, err := d.ImageHash()
+	if err != nil {
+		return nil, err
+	}
+	data, err := walletupgradable.WalletUpgradableCalldata.UpdateImageHash(imageHash)
+	if err != nil {
+		return nil, err
+	}
+
+	eoa := d.EOA.Address()
+	builder := &relayer.SetCodeTxBuilder{Authorizations: []relayer.SetCodeAuthorization{auth}}
+
+	sender := d.EOA
+	if sender.GetProvider() == nil {
+		sender.SetProvider(provider)
+	}
+	tx, err := builder.BuildTx(ctx, sender, chainID, &ethtxn.TransactionRequest{
+		To: &eoa, Nonce: new(big.Int).SetUint64(nonce), Data: data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tx.(*relayer.SetCodeTx), nil
+}
+
+// Nonce returns the meta-transaction nonce of the wallet in space.
+func (d *Delegation) Nonce(provider *ethrpc.Provider, space *big.Int) (*big.Int, error) {
+	return sequence.GetWalletAddressNonce(provider, d.EOA.Address(), space, nil)
+}
+
+// SignTransactions signs a bundle of the wallet with nonce, the gas limits of txns must be
+// set as they are not estimated.
+func (d *Delegation) SignTransactions(chainID *big.Int, txns sequence.Transactions, nonce *big.Int) (*sequence.SignedTransactions, error) {
+	wallet, err := d.Wallet()
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := sequence.Transaction{Transactions: txns, Nonce: nonce}
+	digest, err := bundle.Digest()
+	if err != nil {
+		return nil, err
+	}
+	sig, _, err := wallet.SignDigest(digest, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sequence.SignedTransactions{
+		ChainID:       chainID,
+		WalletConfig:  wallet.GetWalletConfig(),
+		WalletContext: d.Context,
+		Transactions:  txns.Clone(),
+		Nonce:         nonce,
+		Digest:        digest,
+		Signature:     sig,
+	}, nil
+}
+
+// Execdata encodes a signed bundle of the wallet as the `execute` call relayed to the EOA
+// address, ie. with relayer.LocalRelayer.RelayExecdata. Relay cannot be used, as it derives
+// the wallet address from the config.
+func Execdata(signedTxs *sequence.SignedTransactions) ([]byte, error) {
+	calls, err := signedTxs.Transactions.ModuleCalls()
+	if err != nil {
+		return nil, err
+	}
+	return walletmain.WalletMainCalldata.Execute(calls, signedTxs.Nonce, signedTxs.Signature)
+}
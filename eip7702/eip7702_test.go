@@ -0,0 +1,73 @@
+package eip7702_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/eip7702"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorization(t *testing.T) {
+	eoa, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+
+	delegate := sequence.SequenceContext().MainModuleUpgradableAddress
+	auth, err := eip7702.SignAuthorization(eoa, big.NewInt(1), delegate, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, delegate, auth.Address)
+
+	authority, err := eip7702.RecoverAuthority(auth)
+	assert.NoError(t, err)
+	assert.Equal(t, eoa.Address(), authority)
+
+	// the authorization is bound to its nonce
+	auth.Nonce++
+	authority, err = eip7702.RecoverAuthority(auth)
+	assert.NoError(t, err)
+	assert.NotEqual(t, eoa.Address(), authority)
+}
+
+func TestDelegationSignTransactions(t *testing.T) {
+	eoa, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+
+	delegation := eip7702.NewDelegation(eoa, sequence.SequenceContext())
+
+	wallet, err := delegation.Wallet()
+	assert.NoError(t, err)
+	assert.Equal(t, eoa.Address(), wallet.Address())
+
+	txns := sequence.Transactions{{
+		To:       common.HexToAddress("0x1111111111111111111111111111111111111111"),
+		Value:    big.NewInt(1),
+		GasLimit: big.NewInt(50_000),
+	}}
+	signed, err := delegation.SignTransactions(big.NewInt(1), txns, big.NewInt(0))
+	assert.NoError(t, err)
+
+	execdata, err := eip7702.Execdata(signed)
+	assert.NoError(t, err)
+
+	decoded, nonce, sig, err := sequence.DecodeExecdata(execdata)
+	assert.NoError(t, err)
+	assert.Len(t, decoded, 1)
+	assert.Equal(t, txns[0].To, decoded[0].To)
+	assert.Equal(t, int64(0), nonce.Int64())
+	assert.Equal(t, signed.Signature, sig)
+
+	// the signature is bound to the EOA address, and recovers to the config of the delegation
+	subDigest, err := sequence.SubDigest(big.NewInt(1), eoa.Address(), signed.Digest)
+	assert.NoError(t, err)
+	config, err := sequence.RecoverWalletConfigFromDigest(subDigest, sig, sequence.SequenceContext(), big.NewInt(1), nil)
+	assert.NoError(t, err)
+
+	imageHash, err := delegation.ImageHash()
+	assert.NoError(t, err)
+	recovered, err := sequence.ImageHashOfWalletConfigBytes32(config)
+	assert.NoError(t, err)
+	assert.Equal(t, imageHash, common.Hash(recovered))
+}
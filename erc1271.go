@@ -0,0 +1,103 @@
+package sequence
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi/bind"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/go-sequence/contracts/gen/ierc1271"
+)
+
+// IsValidSignature reports whether signature is a valid signature of digest by the wallet at
+// walletAddress, as ERC-1271 isValidSignature(bytes32,bytes) would, for wallets of the default
+// Sequence context. See IsValidSignatureWith.
+func IsValidSignature(ctx context.Context, provider *ethrpc.Provider, walletAddress common.Address, digest common.Hash, signature []byte) (bool, error) {
+	return IsValidSignatureWith(ctx, provider, sequenceContext, walletAddress, digest, signature)
+}
+
+// IsValidSignatureWith reports whether signature is a valid signature of digest by the wallet
+// at walletAddress of walletContext, or by the EOA at walletAddress.
+//
+// Signatures are validated off-chain when possible, ie. signed by an EOA, or by the signers of
+// a counterfactual wallet, which must be of the config the wallet address is derived from.
+// Deployed wallets may have updated their config since, so their isValidSignature method is
+// called instead. Signatures wrapped as of EIP-6492 are validated by simulating the deployment
+// of their wallet, see SignMessageEIP6492. An invalid signature is not an error, only failing
+// to validate it is.
+func IsValidSignatureWith(ctx context.Context, provider *ethrpc.Provider, walletContext WalletContext, walletAddress common.Address, digest common.Hash, signature []byte) (bool, error) {
+	if provider == nil {
+		return false, ErrProviderNotSet
+	}
+
+	if IsEIP6492Signature(signature) {
+		return isValidEIP6492Signature(ctx, provider, walletContext, walletAddress, digest, signature)
+	}
+
+	if len(signature) == 65 {
+		if ok, err := ethwallet.IsValid191Signature(walletAddress, digest[:], signature); err == nil && ok {
+			return true, nil
+		}
+	}
+
+	code, err := provider.CodeAt(ctx, walletAddress, nil)
+	if err != nil {
+		return false, fmt.Errorf("sequence: IsValidSignature: %w", err)
+	}
+	if len(code) == 0 {
+		chainID, err := provider.ChainID(ctx)
+		if err != nil {
+			return false, fmt.Errorf("sequence: IsValidSignature: %w", err)
+		}
+		return isValidCounterfactualSignature(provider, walletContext, chainID, walletAddress, digest, signature), nil
+	}
+
+	return callIsValidSignature(ctx, provider, walletAddress, digest, signature)
+}
+
+// callIsValidSignature calls the ERC-1271 isValidSignature method of the wallet deployed at
+// walletAddress.
+func callIsValidSignature(ctx context.Context, provider *ethrpc.Provider, walletAddress common.Address, digest common.Hash, signature []byte) (bool, error) {
+	erc1271, err := ierc1271.NewIERC1271(walletAddress, provider)
+	if err != nil {
+		return false, err
+	}
+	// NOTE: we expect digest to be ready for ERC1271 call, so digest must be fully encoded as expected
+	res, err := erc1271.IsValidSignature(&bind.CallOpts{Context: ctx, From: common.Address{0x1}}, digest, signature)
+	if err != nil {
+		return false, fmt.Errorf("sequence: IsValidSignature: wallet %v: %w", walletAddress.Hex(), err)
+	}
+	return ierc1271.IsValidSignatureBytes32_MagicReturnValue == hexutil.Encode(res[:]), nil
+}
+
+// isValidCounterfactualSignature reports whether signature is signed by signers of weight
+// meeting the threshold of a config whose counterfactual address of walletContext is
+// walletAddress.
+func isValidCounterfactualSignature(provider *ethrpc.Provider, walletContext WalletContext, chainID *big.Int, walletAddress common.Address, digest common.Hash, signature []byte) bool {
+	sig, err := DecodeSignature(signature)
+	if err != nil {
+		return false
+	}
+	subDigest, err := SubDigest(chainID, walletAddress, digest)
+	if err != nil {
+		return false
+	}
+	// the signatures of nested wallets are validated with the provider
+	if err := sig.Recover(subDigest, provider); err != nil {
+		return false
+	}
+	if weight, err := sig.Weight(); err != nil || weight < sig.Threshold {
+		return false
+	}
+
+	config := WalletConfig{Threshold: sig.Threshold}
+	for _, part := range sig.Signers {
+		config.Signers = append(config.Signers, WalletConfigSigner{Weight: part.Weight, Address: part.Address})
+	}
+	address, err := AddressFromWalletConfig(config, walletContext)
+	return err == nil && address == walletAddress
+}
@@ -0,0 +1,84 @@
+package sequence_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidSignature(t *testing.T) {
+	ctx := context.Background()
+	chain := memchain.New(memchain.Options{})
+	provider := chain.Provider()
+	digest := sequence.MessageDigest([]byte("sign in with sequence"))
+
+	// signatures of EOAs
+	eoa, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	sig, err := eoa.SignMessage(digest[:])
+	assert.NoError(t, err)
+	ok, err := sequence.IsValidSignature(ctx, provider, eoa.Address(), digest, sig)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = sequence.IsValidSignature(ctx, provider, common.HexToAddress("0x7a7"), digest, sig)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// signatures of counterfactual wallets are recovered off-chain
+	wallet, err := testutil.MemChainWallet(chain, 1)
+	assert.NoError(t, err)
+	sig, _, err = wallet.SignDigest(digest)
+	assert.NoError(t, err)
+	ok, err = sequence.IsValidSignature(ctx, provider, wallet.Address(), digest, sig)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = sequence.IsValidSignature(ctx, provider, wallet.Address(), sequence.MessageDigest([]byte("other")), sig)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// as long as their signers meet the threshold of their config
+	owners := sequence.WalletConfig{Threshold: 2}
+	for i := uint64(1); i <= 2; i++ {
+		owner, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(i))
+		assert.NoError(t, err)
+		owners.Signers = append(owners.Signers, sequence.WalletConfigSigner{Weight: 1, Address: owner.Address()})
+	}
+	multisig, err := sequence.NewWallet(sequence.WalletOptions{Config: owners}, eoa)
+	assert.NoError(t, err)
+	assert.NoError(t, multisig.SetProvider(provider))
+	sig, _, err = multisig.SignDigest(digest)
+	assert.NoError(t, err)
+	ok, err = sequence.IsValidSignature(ctx, provider, multisig.Address(), digest, sig)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// deployed wallets may have updated their config, so their contract validates signatures
+	sig, _, err = wallet.SignDigest(digest)
+	assert.NoError(t, err)
+	testutil.DeployMemChainWallet(chain, wallet)
+	ok, err = wallet.IsValidSignature(digest, sig)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	chain.Deploy(wallet.Address(), memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		return make([]byte, 32), nil
+	}))
+	ok, err = sequence.IsValidSignature(ctx, provider, wallet.Address(), digest, sig)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	chain.Deploy(wallet.Address(), memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		return nil, memchain.Revert("unknown method")
+	}))
+	_, err = sequence.IsValidSignature(ctx, provider, wallet.Address(), digest, sig)
+	assert.ErrorContains(t, err, "unknown method")
+
+	_, err = sequence.IsValidSignature(ctx, nil, wallet.Address(), digest, sig)
+	assert.ErrorIs(t, err, sequence.ErrProviderNotSet)
+}
@@ -0,0 +1,276 @@
+// Package erc2771 makes bundles interoperate with ERC-2771 contracts, which accept calls
+// relayed by a trusted forwarder on behalf of a sender appended to the calldata.
+//
+// A Sequence wallet trusted as the forwarder of a contract appends the address of the
+// sender it acts for to its calls with an Adapter. Bundles can also relay requests signed
+// by EOAs through an OpenZeppelin ERC2771Forwarder with ExecuteRequest.
+package erc2771
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/core/digest"
+	"github.com/goware/cachestore"
+	"github.com/goware/cachestore/memlru"
+)
+
+const defaultAdapterCacheSize = 1000
+
+// byte values that represent booleans in the cache.
+const (
+	cachedTrue  = byte('t')
+	cachedFalse = byte('f')
+)
+
+var (
+	isTrustedForwarderSelector = crypto.Keccak256([]byte("isTrustedForwarder(address)"))[:4]
+
+	// ForwardRequestTypeHash is the EIP-712 type of the requests of an OpenZeppelin
+	// ERC2771Forwarder.
+	ForwardRequestTypeHash = crypto.Keccak256Hash([]byte("ForwardRequest(address from,address to,uint256 value,uint256 gas,uint256 nonce,uint48 deadline,bytes data)"))
+
+	domainTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+)
+
+var forwarderABI = mustParseABI(`[
+	{"type":"function","name":"execute","stateMutability":"payable","inputs":[{"name":"request","type":"tuple","components":[
+		{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"value","type":"uint256"},
+		{"name":"gas","type":"uint256"},{"name":"deadline","type":"uint48"},{"name":"data","type":"bytes"},
+		{"name":"signature","type":"bytes"}]}],"outputs":[]},
+	{"type":"function","name":"nonces","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]}
+]`)
+
+func mustParseABI(s string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(s))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// AppendSender appends sender to data, as a trusted forwarder does when calling an
+// ERC-2771 contract for sender.
+func AppendSender(data []byte, sender common.Address) []byte {
+	out := make([]byte, 0, len(data)+common.AddressLength)
+	out = append(out, data...)
+	return append(out, sender[:]...)
+}
+
+// IsTrustedForwarder reports if target trusts forwarder with isTrustedForwarder. Targets
+// which are not contracts, or do not implement ERC-2771, do not trust any forwarder.
+func IsTrustedForwarder(ctx context.Context, provider *ethrpc.Provider, target, forwarder common.Address) (bool, error) {
+	data := append(append([]byte{}, isTrustedForwarderSelector...), common.LeftPadBytes(forwarder[:], 32)...)
+	res, err := provider.CallContract(ctx, ethereum.CallMsg{To: &target, Data: data}, nil)
+	if err != nil {
+		if strings.Contains(err.Error(), "revert") {
+			return false, nil
+		}
+		return false, fmt.Errorf("erc2771: isTrustedForwarder of %v failed: %w", target, err)
+	}
+	if len(res) != 32 {
+		return false, nil
+	}
+	return new(big.Int).SetBytes(res).Cmp(big.NewInt(1)) == 0, nil
+}
+
+// Adapter appends the sender to the calls a trusted forwarder, ie. a Sequence wallet,
+// makes to the ERC-2771 contracts which trust it. Which targets trust the forwarder is
+// detected on-chain and cached.
+type Adapter struct {
+	forwarder common.Address
+	provider  *ethrpc.Provider
+	cache     cachestore.Store[[]byte]
+}
+
+func NewAdapter(provider *ethrpc.Provider, forwarder common.Address, opts ...sequence.Option) *Adapter {
+	options := sequence.NewOptions(opts...)
+
+	cache := options.Cache
+	if cache == nil {
+		cache, _ = memlru.NewWithSize[[]byte](defaultAdapterCacheSize)
+	}
+
+	return &Adapter{
+		forwarder: forwarder,
+		provider:  provider,
+		cache:     cache,
+	}
+}
+
+// Forwarder is the address calling the targets.
+func (a *Adapter) Forwarder() common.Address {
+	return a.forwarder
+}
+
+// IsTrusted reports if target trusts the forwarder.
+func (a *Adapter) IsTrusted(ctx context.Context, target common.Address) (bool, error) {
+	chainID, err := a.provider.ChainID(ctx)
+	if err != nil {
+		return false, err
+	}
+	// keys are limited in length, so the pair of addresses is hashed
+	key := fmt.Sprintf("erc2771::%d::%x", chainID, crypto.Keccak256(target[:], a.forwarder[:])[:20])
+	if val, exists, _ := a.cache.Get(ctx, key); exists && len(val) == 1 {
+		return val[0] == cachedTrue, nil
+	}
+
+	trusted, err := IsTrustedForwarder(ctx, a.provider, target, a.forwarder)
+	if err != nil {
+		return false, err
+	}
+	if trusted {
+		_ = a.cache.Set(ctx, key, []byte{cachedTrue})
+	} else {
+		_ = a.cache.Set(ctx, key, []byte{cachedFalse})
+	}
+	return trusted, nil
+}
+
+// Adapt returns a copy of txns where the calls to targets trusting the forwarder carry
+// sender. Delegate calls and nested bundles are not called by the forwarder, and are left
+// as they are.
+func (a *Adapter) Adapt(ctx context.Context, txns sequence.Transactions, sender common.Address) (sequence.Transactions, error) {
+	adapted := txns.Clone()
+	for _, txn := range adapted {
+		if txn.DelegateCall || len(txn.Transactions) > 0 {
+			continue
+		}
+		trusted, err := a.IsTrusted(ctx, txn.To)
+		if err != nil {
+			return nil, err
+		}
+		if trusted {
+			txn.Data = AppendSender(txn.Data, sender)
+		}
+	}
+	return adapted, nil
+}
+
+// ForwardRequest is a request of an OpenZeppelin ERC2771Forwarder, to call To as From.
+type ForwardRequest struct {
+	From     common.Address
+	To       common.Address
+	Value    *big.Int
+	Gas      *big.Int
+	Nonce    *big.Int
+	Deadline uint64
+	Data     []byte
+}
+
+// Forwarder is the EIP-712 domain of an OpenZeppelin ERC2771Forwarder.
+type Forwarder struct {
+	Address common.Address
+	ChainID *big.Int
+
+	// Name is the name the forwarder was deployed with.
+	Name string
+}
+
+func (f Forwarder) domainSeparator() common.Hash {
+	return crypto.Keccak256Hash(
+		domainTypeHash[:],
+		crypto.Keccak256([]byte(f.Name)),
+		crypto.Keccak256([]byte("1")),
+		common.LeftPadBytes(f.ChainID.Bytes(), 32),
+		common.LeftPadBytes(f.Address[:], 32),
+	)
+}
+
+// Digest returns the digest From signs to authorize the request.
+func (r *ForwardRequest) Digest(forwarder Forwarder) common.Hash {
+	structHash := crypto.Keccak256Hash(
+		ForwardRequestTypeHash[:],
+		common.LeftPadBytes(r.From[:], 32),
+		common.LeftPadBytes(r.To[:], 32),
+		uint256(r.Value),
+		uint256(r.Gas),
+		uint256(r.Nonce),
+		uint256(new(big.Int).SetUint64(r.Deadline)),
+		crypto.Keccak256(r.Data),
+	)
+	return digest.TypedDataDigest(forwarder.domainSeparator(), structHash)
+}
+
+// SignRequest signs the request with the EOA it is from.
+func SignRequest(eoa *ethwallet.Wallet, forwarder Forwarder, request *ForwardRequest) ([]byte, error) {
+	if eoa.Address() != request.From {
+		return nil, fmt.Errorf("erc2771: request is from %v, not %v", request.From, eoa.Address())
+	}
+	requestDigest := request.Digest(forwarder)
+	sig, err := crypto.Sign(requestDigest[:], eoa.PrivateKey())
+	if err != nil {
+		return nil, fmt.Errorf("erc2771: failed to sign request: %w", err)
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// ExecuteRequest returns the Sequence transaction executing a signed request through the
+// forwarder, to be sent in a bundle of any wallet. The transaction sends the value of
+// the request.
+func ExecuteRequest(forwarder Forwarder, request *ForwardRequest, signature []byte) (*sequence.Transaction, error) {
+	type requestData struct {
+		From      common.Address
+		To        common.Address
+		Value     *big.Int
+		Gas       *big.Int
+		Deadline  *big.Int
+		Data      []byte
+		Signature []byte
+	}
+	data, err := forwarderABI.Pack("execute", requestData{
+		From:      request.From,
+		To:        request.To,
+		Value:     bigOrZero(request.Value),
+		Gas:       bigOrZero(request.Gas),
+		Deadline:  new(big.Int).SetUint64(request.Deadline),
+		Data:      request.Data,
+		Signature: signature,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erc2771: failed to encode execute: %w", err)
+	}
+	return &sequence.Transaction{
+		To:            forwarder.Address,
+		Value:         bigOrZero(request.Value),
+		Data:          data,
+		RevertOnError: true,
+	}, nil
+}
+
+// Nonce returns the nonce of the next request of from on the forwarder.
+func Nonce(ctx context.Context, provider *ethrpc.Provider, forwarder common.Address, from common.Address) (*big.Int, error) {
+	data, err := forwarderABI.Pack("nonces", from)
+	if err != nil {
+		return nil, err
+	}
+	res, err := provider.CallContract(ctx, ethereum.CallMsg{To: &forwarder, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erc2771: nonces failed: %w", err)
+	}
+	if len(res) != 32 {
+		return nil, fmt.Errorf("erc2771: invalid nonces result")
+	}
+	return new(big.Int).SetBytes(res), nil
+}
+
+func uint256(n *big.Int) []byte {
+	return common.LeftPadBytes(bigOrZero(n).Bytes(), 32)
+}
+
+func bigOrZero(n *big.Int) *big.Int {
+	if n == nil {
+		return new(big.Int)
+	}
+	return n
+}
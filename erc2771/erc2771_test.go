@@ -0,0 +1,114 @@
+package erc2771_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/core/digest"
+	"github.com/0xsequence/go-sequence/erc2771"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdapter(t *testing.T) {
+	forwarder := common.HexToAddress("0xf0")
+	trusting := common.HexToAddress("0x01")
+	reverting := common.HexToAddress("0x02")
+
+	chain := memchain.New(memchain.Options{ChainID: big.NewInt(1)})
+	chain.Deploy(trusting, memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		return common.BigToHash(big.NewInt(1)).Bytes(), nil
+	}))
+	chain.Deploy(reverting, memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		return nil, memchain.Revert("")
+	}))
+	var calls int32
+	chain.Intercept(func(method string, params []json.RawMessage, next func() (interface{}, error)) (interface{}, error) {
+		if method == "eth_call" {
+			atomic.AddInt32(&calls, 1)
+		}
+		return next()
+	})
+	provider := chain.Provider()
+
+	adapter := erc2771.NewAdapter(provider, forwarder)
+	sender := common.HexToAddress("0x5e")
+	txns := sequence.Transactions{
+		{To: trusting, Data: []byte{0xaa}},
+		{To: reverting, Data: []byte{0xbb}},
+		{To: common.HexToAddress("0x03"), Data: []byte{0xcc}},
+		{To: trusting, Data: []byte{0xdd}, DelegateCall: true},
+	}
+
+	adapted, err := adapter.Adapt(context.Background(), txns, sender)
+	assert.NoError(t, err)
+	assert.Equal(t, append([]byte{0xaa}, sender[:]...), adapted[0].Data)
+	assert.Equal(t, []byte{0xbb}, adapted[1].Data)
+	assert.Equal(t, []byte{0xcc}, adapted[2].Data)
+	assert.Equal(t, []byte{0xdd}, adapted[3].Data)
+	assert.Equal(t, []byte{0xaa}, txns[0].Data)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// detection is cached
+	_, err = adapter.Adapt(context.Background(), txns, sender)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestForwardRequest(t *testing.T) {
+	eoa, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+
+	forwarder := erc2771.Forwarder{Address: common.HexToAddress("0xf0"), ChainID: big.NewInt(10), Name: "Forwarder"}
+	request := &erc2771.ForwardRequest{
+		From:     eoa.Address(),
+		To:       common.HexToAddress("0x01"),
+		Value:    big.NewInt(5),
+		Gas:      big.NewInt(100000),
+		Nonce:    big.NewInt(2),
+		Deadline: 1700000000,
+		Data:     []byte{1, 2, 3},
+	}
+
+	typedData, err := digest.ParseTypedData([]byte(`{
+		"types": {
+			"EIP712Domain": [{"name": "name", "type": "string"}, {"name": "version", "type": "string"}, {"name": "chainId", "type": "uint256"}, {"name": "verifyingContract", "type": "address"}],
+			"ForwardRequest": [
+				{"name": "from", "type": "address"}, {"name": "to", "type": "address"}, {"name": "value", "type": "uint256"},
+				{"name": "gas", "type": "uint256"}, {"name": "nonce", "type": "uint256"}, {"name": "deadline", "type": "uint48"},
+				{"name": "data", "type": "bytes"}
+			]
+		},
+		"primaryType": "ForwardRequest",
+		"domain": {"name": "Forwarder", "version": "1", "chainId": 10, "verifyingContract": "0x00000000000000000000000000000000000000f0"},
+		"message": {"from": "` + eoa.Address().Hex() + `", "to": "0x0000000000000000000000000000000000000001", "value": 5, "gas": 100000, "nonce": 2, "deadline": 1700000000, "data": "0x010203"}
+	}`))
+	assert.NoError(t, err)
+	expected, err := typedData.Digest()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, request.Digest(forwarder))
+
+	sig, err := erc2771.SignRequest(eoa, forwarder, request)
+	assert.NoError(t, err)
+	signer, err := ethwallet.RecoverAddressFromDigest(expected[:], sig)
+	assert.NoError(t, err)
+	assert.Equal(t, eoa.Address(), signer)
+
+	txn, err := erc2771.ExecuteRequest(forwarder, request, sig)
+	assert.NoError(t, err)
+	assert.Equal(t, forwarder.Address, txn.To)
+	assert.Equal(t, big.NewInt(5), txn.Value)
+	assert.Equal(t, "0xdf905caf", hexutil.Encode(txn.Data[:4]))
+
+	other, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+	_, err = erc2771.SignRequest(other, forwarder, request)
+	assert.Error(t, err)
+}
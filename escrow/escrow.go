@@ -0,0 +1,438 @@
+// Package escrow implements escrow flows with Sequence wallets: the funds of an escrow are
+// held by a wallet of its own whose signers are its parties, a buyer, a seller and an optional
+// arbiter, any two of which sign each of its paths:
+//
+//	e, err := escrow.New("order-42", terms, walletContext, chainID)
+//	fund, err := e.FundTransactions()                   // sent by the buyer
+//	release, err := e.Sign(ctx, seller, escrow.Release) // cosigned by the buyer or the arbiter
+//	refund, err := e.Sign(ctx, buyer, escrow.Refund)    // cosigned at setup, held by the buyer
+//	status, err := e.Status(ctx, wallet)
+//
+// The signatures of the parties are collected with Cosign, and the bundles are relayed once
+// finalized with Wallet.FinalizeTransactions.
+//
+// The paths are enforced on-chain by the conditions of their bundles. The release pays the
+// seller until the timeout of the escrow, as its payout asserts RequireNonExpired, and lapses
+// after it: the payout is a call of the escrow wallet to itself which may fail without
+// reverting the release, so a release relayed after the timeout uses its nonce without paying
+// the seller. The refund asserts RequireMinNonce on the nonce of the release, so it only pays
+// the buyer back once a release was relayed, and as a release which paid the seller left
+// nothing to refund, once the release lapsed. Once the timeout passed, the buyer relays a
+// release signed with the arbiter, or cosigned by the seller, and then the refund.
+package escrow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/contracts"
+)
+
+// Terms are the parties of an escrow, and what it holds.
+type Terms struct {
+	Buyer  common.Address
+	Seller common.Address
+
+	// Arbiter settles disputes with either party, none if zero: the buyer and the seller then
+	// both sign each path.
+	Arbiter common.Address
+
+	// Token is the ERC-20 token escrowed, the native token if zero.
+	Token  common.Address
+	Amount *big.Int
+
+	// Timeout is when the release expires, and the escrow is refundable.
+	Timeout time.Time
+}
+
+// ReleaseGasLimit is the gas limit of the payout of a release, which is set so the bundles of
+// an escrow are the same whoever signs them.
+const ReleaseGasLimit = 150_000
+
+// Path is a way the funds of an escrow leave it.
+type Path int
+
+const (
+	// Release pays the seller, until the timeout of the escrow, and lapses after it.
+	Release Path = iota
+
+	// Refund pays the buyer back, once the release lapsed.
+	Refund
+)
+
+func (p Path) String() string {
+	switch p {
+	case Release:
+		return "release"
+	case Refund:
+		return "refund"
+	default:
+		return fmt.Sprintf("path(%d)", int(p))
+	}
+}
+
+// Escrow is an escrow between the parties of its terms, on a chain. Each escrow has a wallet of
+// its own, derived from the signers of its parties and a signer of no weight derived from its
+// ID, so a refund never pays out the funds of another escrow.
+type Escrow struct {
+	ID      string
+	Terms   Terms
+	ChainID *big.Int
+
+	WalletConfig  sequence.WalletConfig
+	WalletContext sequence.WalletContext
+
+	// Address is the address of the escrow wallet, which is funded.
+	Address common.Address
+}
+
+// New returns the escrow id between the parties of terms, on the chain of chainID.
+func New(id string, terms Terms, walletContext sequence.WalletContext, chainID *big.Int) (*Escrow, error) {
+	if id == "" || chainID == nil {
+		return nil, fmt.Errorf("escrow: escrow requires an ID and a chain ID")
+	}
+	if terms.Buyer == (common.Address{}) || terms.Seller == (common.Address{}) || terms.Buyer == terms.Seller {
+		return nil, fmt.Errorf("escrow: escrow requires a buyer and a seller, distinct")
+	}
+	if terms.Arbiter == terms.Buyer || terms.Arbiter == terms.Seller {
+		return nil, fmt.Errorf("escrow: arbiter must be a third party")
+	}
+	if terms.Amount == nil || terms.Amount.Sign() <= 0 {
+		return nil, fmt.Errorf("escrow: escrow requires a positive amount")
+	}
+	if terms.Timeout.IsZero() {
+		return nil, fmt.Errorf("escrow: escrow requires a timeout")
+	}
+
+	config := sequence.WalletConfig{
+		Threshold: 2,
+		Signers: sequence.WalletConfigSigners{
+			{Weight: 1, Address: terms.Buyer},
+			{Weight: 1, Address: terms.Seller},
+		},
+	}
+	if terms.Arbiter != (common.Address{}) {
+		config.Signers = append(config.Signers, sequence.WalletConfigSigner{Weight: 1, Address: terms.Arbiter})
+	}
+	// the signer of no weight of the ID gives each escrow a wallet of its own
+	idSigner := common.BytesToAddress(crypto.Keccak256([]byte("escrow:" + id)))
+	config.Signers = append(config.Signers, sequence.WalletConfigSigner{Weight: 0, Address: idSigner})
+	if err := sequence.SortWalletConfig(config); err != nil {
+		return nil, fmt.Errorf("escrow: %w", err)
+	}
+	imageHash, err := sequence.ImageHashOfWalletConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("escrow: %w", err)
+	}
+	address, err := sequence.AddressFromImageHash(imageHash, walletContext)
+	if err != nil {
+		return nil, fmt.Errorf("escrow: %w", err)
+	}
+
+	return &Escrow{
+		ID:            id,
+		Terms:         terms,
+		ChainID:       new(big.Int).Set(chainID),
+		WalletConfig:  config,
+		WalletContext: walletContext,
+		Address:       address,
+	}, nil
+}
+
+// Space returns the nonce space of path in the escrow wallet.
+func (e *Escrow) Space(path Path) *big.Int {
+	if path == Refund {
+		return sequence.NonceSpace("escrow:" + e.ID + ":refund")
+	}
+	return sequence.NonceSpace("escrow:" + e.ID)
+}
+
+// Nonce returns the nonce path is signed at, encoded with its space.
+func (e *Escrow) Nonce(path Path) (*big.Int, error) {
+	return sequence.EncodeNonce(e.Space(path), big.NewInt(0))
+}
+
+// FundTransactions returns the transaction funding the escrow, sent by the buyer, ie. from a
+// Sequence wallet of the buyer.
+func (e *Escrow) FundTransactions() (sequence.Transactions, error) {
+	txn, err := e.payment(e.Address)
+	if err != nil {
+		return nil, err
+	}
+	return sequence.Transactions{txn}, nil
+}
+
+// Transactions returns the bundle of path, signed at its Nonce by the parties.
+func (e *Escrow) Transactions(path Path) (sequence.Transactions, error) {
+	switch path {
+	case Release:
+		assertion, err := sequence.RequireNonExpired(e.WalletContext, e.Terms.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("escrow: %w", err)
+		}
+		payout, err := e.payment(e.Terms.Seller)
+		if err != nil {
+			return nil, err
+		}
+		// the payout is executed by the escrow wallet calling itself, so once expired it fails
+		// without reverting the release, which lapses
+		return sequence.Transactions{{
+			To:           e.Address,
+			GasLimit:     big.NewInt(ReleaseGasLimit),
+			Transactions: sequence.Transactions{assertion, payout},
+		}}, nil
+	case Refund:
+		released, err := sequence.EncodeNonce(e.Space(Release), big.NewInt(1))
+		if err != nil {
+			return nil, fmt.Errorf("escrow: %w", err)
+		}
+		assertion, err := sequence.RequireMinNonce(e.WalletContext, e.Address, released)
+		if err != nil {
+			return nil, fmt.Errorf("escrow: %w", err)
+		}
+		payout, err := e.payment(e.Terms.Buyer)
+		if err != nil {
+			return nil, err
+		}
+		return sequence.Transactions{assertion, payout}, nil
+	default:
+		return nil, fmt.Errorf("escrow: unknown %v", path)
+	}
+}
+
+// MetaTxnID returns the meta-transaction ID of path, once relayed.
+func (e *Escrow) MetaTxnID(path Path) (sequence.MetaTxnID, error) {
+	txns, err := e.Transactions(path)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := e.Nonce(path)
+	if err != nil {
+		return "", fmt.Errorf("escrow: %w", err)
+	}
+	metaTxnID, _, err := sequence.ComputeMetaTxnID(e.ChainID, e.Address, txns, nonce, sequence.MetaTxnWalletExec)
+	if err != nil {
+		return "", fmt.Errorf("escrow: %w", err)
+	}
+	return metaTxnID, nil
+}
+
+// Wallet returns the escrow wallet with the signers of a party, to sign and cosign the paths
+// of the escrow, or with none, to read its status.
+func (e *Escrow) Wallet(signers ...*ethwallet.Wallet) (*sequence.Wallet, error) {
+	walletContext := e.WalletContext
+	wallet, err := sequence.NewWallet(sequence.WalletOptions{Config: e.WalletConfig, Context: &walletContext}, signers...)
+	if err != nil {
+		return nil, fmt.Errorf("escrow: %w", err)
+	}
+	wallet.SetChainID(e.ChainID)
+	return wallet, nil
+}
+
+// Sign signs the bundle of path with the signers of wallet, the escrow wallet of a party, see
+// Wallet. The other parties cosign it with Cosign.
+func (e *Escrow) Sign(ctx context.Context, wallet *sequence.Wallet, path Path) (*sequence.SignedTransactions, error) {
+	if wallet.Address() != e.Address {
+		return nil, fmt.Errorf("escrow: %v is not the escrow wallet %v", wallet.Address().Hex(), e.Address.Hex())
+	}
+	txns, err := e.Transactions(path)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := e.Nonce(path)
+	if err != nil {
+		return nil, fmt.Errorf("escrow: %w", err)
+	}
+	signed, err := wallet.SignTransactionsWithNonce(ctx, txns, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("escrow: failed to sign %v of %v: %w", path, e.ID, err)
+	}
+	return signed, nil
+}
+
+// Cosign adds the signatures of wallet, the escrow wallet of a party, to signed, a path of the
+// escrow signed by another party, see Sign. It fails if signed isn't a path of the escrow.
+func (e *Escrow) Cosign(ctx context.Context, wallet *sequence.Wallet, signed *sequence.SignedTransactions) (*sequence.SignedTransactions, error) {
+	if wallet.Address() != e.Address {
+		return nil, fmt.Errorf("escrow: %v is not the escrow wallet %v", wallet.Address().Hex(), e.Address.Hex())
+	}
+	path, err := e.pathOf(signed)
+	if err != nil {
+		return nil, err
+	}
+	cosigned, err := wallet.CosignTransactions(ctx, signed)
+	if err != nil {
+		return nil, fmt.Errorf("escrow: failed to cosign %v of %v: %w", path, e.ID, err)
+	}
+	return cosigned, nil
+}
+
+// pathOf returns the path of the escrow signed is the bundle of.
+func (e *Escrow) pathOf(signed *sequence.SignedTransactions) (Path, error) {
+	for _, path := range []Path{Release, Refund} {
+		nonce, err := e.Nonce(path)
+		if err != nil {
+			return 0, fmt.Errorf("escrow: %w", err)
+		}
+		txns, err := e.Transactions(path)
+		if err != nil {
+			return 0, err
+		}
+		if signed.Nonce != nil && signed.Nonce.Cmp(nonce) == 0 && txns.Equal(signed.Transactions) {
+			return path, nil
+		}
+	}
+	return 0, fmt.Errorf("escrow: bundle is not a path of %v", e.ID)
+}
+
+// payment returns the transaction of the escrow wallet, or of the buyer, paying the amount of
+// the escrow to to.
+func (e *Escrow) payment(to common.Address) (*sequence.Transaction, error) {
+	if e.Terms.Token == (common.Address{}) {
+		return &sequence.Transaction{To: to, Value: new(big.Int).Set(e.Terms.Amount), RevertOnError: true}, nil
+	}
+	data, err := contracts.IERC20.Encode("transfer", to, e.Terms.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("escrow: %w", err)
+	}
+	return &sequence.Transaction{To: e.Terms.Token, Data: data, RevertOnError: true}, nil
+}
+
+// State is the state of an escrow, see Status.
+type State int
+
+const (
+	// Open is an escrow not settled yet, before its timeout.
+	Open State = iota
+
+	// Refundable is an escrow not settled yet, after its timeout: its release expired, or was
+	// relayed and lapsed.
+	Refundable
+
+	// Released is an escrow settled by its release.
+	Released
+
+	// Refunded is an escrow settled by its refund.
+	Refunded
+
+	// Settled is an escrow settled by another bundle the parties signed at its nonce, ie. a
+	// release without a timeout agreed once the escrow was refundable.
+	Settled
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case Refundable:
+		return "refundable"
+	case Released:
+		return "released"
+	case Refunded:
+		return "refunded"
+	case Settled:
+		return "settled"
+	default:
+		return fmt.Sprintf("state(%d)", int(s))
+	}
+}
+
+// Status is the state of an escrow, and the meta-transaction which settled it, if it was
+// released or refunded.
+type Status struct {
+	State State
+
+	MetaTxnID sequence.MetaTxnID
+	TxnHash   common.Hash
+}
+
+// Status reads the state of the escrow from the chain with wallet, the escrow wallet connected
+// to a provider and a relayer, see Wallet: the escrow is settled once the nonce of its release
+// was used by a release which paid the seller, or by another bundle, or once its refund was
+// relayed, and the path which settled it is found among the meta-transactions of the wallet.
+func (e *Escrow) Status(ctx context.Context, wallet *sequence.Wallet) (*Status, error) {
+	if wallet.Address() != e.Address {
+		return nil, fmt.Errorf("escrow: %v is not the escrow wallet %v", wallet.Address().Hex(), e.Address.Hex())
+	}
+
+	used, err := e.used(wallet, Release)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		// the release expires by the time of the blocks, not of the clock
+		head, err := wallet.GetProvider().HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("escrow: failed to get head of the chain: %w", err)
+		}
+		if head.Time < uint64(e.Terms.Timeout.Unix()) {
+			return &Status{State: Open}, nil
+		}
+		return &Status{State: Refundable}, nil
+	}
+
+	release, err := e.find(ctx, wallet, Release)
+	if err != nil {
+		return nil, err
+	}
+	if release != nil && release.Status == sequence.MetaTxnExecuted {
+		return &Status{State: Released, MetaTxnID: release.MetaTxnID, TxnHash: release.TxnHash}, nil
+	}
+
+	used, err = e.used(wallet, Refund)
+	if err != nil {
+		return nil, err
+	}
+	if used {
+		refund, err := e.find(ctx, wallet, Refund)
+		if err != nil {
+			return nil, err
+		}
+		if refund != nil && refund.Status == sequence.MetaTxnExecuted {
+			return &Status{State: Refunded, MetaTxnID: refund.MetaTxnID, TxnHash: refund.TxnHash}, nil
+		}
+	} else if release != nil {
+		// the release lapsed, so the refund is left
+		return &Status{State: Refundable}, nil
+	}
+	return &Status{State: Settled}, nil
+}
+
+// used returns whether the nonce of path was used.
+func (e *Escrow) used(wallet *sequence.Wallet, path Path) (bool, error) {
+	nonce, err := wallet.GetNonceInSpace(e.Space(path))
+	if err != nil {
+		return false, fmt.Errorf("escrow: failed to get nonce of %v: %w", e.Address.Hex(), err)
+	}
+	_, nonce = sequence.DecodeNonce(nonce)
+	return nonce.Sign() != 0, nil
+}
+
+type pathResult struct {
+	MetaTxnID sequence.MetaTxnID
+	Status    sequence.MetaTxnStatus
+	TxnHash   common.Hash
+}
+
+// find returns the result of path among the meta-transactions of wallet, or nil if it wasn't
+// relayed.
+func (e *Escrow) find(ctx context.Context, wallet *sequence.Wallet, path Path) (*pathResult, error) {
+	metaTxnID, err := e.MetaTxnID(path)
+	if err != nil {
+		return nil, err
+	}
+	result, receipt, err := wallet.FindMetaTxnReceipt(ctx, metaTxnID)
+	if errors.Is(err, sequence.ErrMetaTxnNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("escrow: failed to find %v of %v: %w", path, e.ID, err)
+	}
+	return &pathResult{MetaTxnID: metaTxnID, Status: result.Status, TxnHash: receipt.TxHash}, nil
+}
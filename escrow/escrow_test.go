@@ -0,0 +1,242 @@
+package escrow_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/0xsequence/go-sequence/escrow"
+	"github.com/0xsequence/go-sequence/relayer"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func parties(t *testing.T) (buyer, seller, arbiter *ethwallet.Wallet) {
+	var keys []*ethwallet.Wallet
+	for i := uint64(1); i <= 3; i++ {
+		key, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(i))
+		assert.NoError(t, err)
+		keys = append(keys, key)
+	}
+	return keys[0], keys[1], keys[2]
+}
+
+func TestEscrow(t *testing.T) {
+	ctx := context.Background()
+	walletContext := testutil.SequenceContext()
+
+	buyer, seller, arbiter := parties(t)
+	terms := escrow.Terms{
+		Buyer:   buyer.Address(),
+		Seller:  seller.Address(),
+		Arbiter: arbiter.Address(),
+		Amount:  big.NewInt(1e18),
+		Timeout: time.Now().Add(time.Hour),
+	}
+
+	_, err := escrow.New("order-42", escrow.Terms{Buyer: buyer.Address(), Amount: big.NewInt(1), Timeout: terms.Timeout}, walletContext, big.NewInt(1))
+	assert.Error(t, err)
+
+	e, err := escrow.New("order-42", terms, walletContext, big.NewInt(1))
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(2), e.WalletConfig.Threshold)
+	assert.Len(t, e.WalletConfig.Signers, 4)
+
+	// each escrow of the parties has a wallet of its own
+	other, err := escrow.New("order-43", terms, walletContext, big.NewInt(1))
+	assert.NoError(t, err)
+	assert.NotEqual(t, e.Address, other.Address)
+
+	fund, err := e.FundTransactions()
+	assert.NoError(t, err)
+	if assert.Len(t, fund, 1) {
+		assert.Equal(t, e.Address, fund[0].To)
+		assert.Equal(t, 0, fund[0].Value.Cmp(terms.Amount))
+	}
+
+	// the payout of the release asserts the timeout, in a call of the escrow wallet to itself,
+	// and the release is cosigned by a second party
+	sellerWallet, err := e.Wallet(seller)
+	assert.NoError(t, err)
+	arbiterWallet, err := e.Wallet(arbiter)
+	assert.NoError(t, err)
+	release, err := e.Sign(ctx, sellerWallet, escrow.Release)
+	assert.NoError(t, err)
+	if assert.Len(t, release.Transactions, 1) {
+		assert.Equal(t, e.Address, release.Transactions[0].To)
+		assert.False(t, release.Transactions[0].RevertOnError)
+		if assert.Len(t, release.Transactions[0].Transactions, 2) {
+			assert.Equal(t, walletContext.UtilsAddress, release.Transactions[0].Transactions[0].To)
+			assert.Equal(t, seller.Address(), release.Transactions[0].Transactions[1].To)
+		}
+	}
+	_, err = sellerWallet.FinalizeTransactions(release)
+	assert.ErrorIs(t, err, sequence.ErrThresholdNotMet)
+	release, err = e.Cosign(ctx, arbiterWallet, release)
+	assert.NoError(t, err)
+	_, err = arbiterWallet.FinalizeTransactions(release)
+	assert.NoError(t, err)
+
+	// the refund asserts the nonce of the release was used, in a nonce space of its own, so it
+	// may be signed at setup
+	buyerEscrowWallet, err := e.Wallet(buyer)
+	assert.NoError(t, err)
+	refund, err := e.Sign(ctx, buyerEscrowWallet, escrow.Refund)
+	assert.NoError(t, err)
+	space, nonce := sequence.DecodeNonce(refund.Nonce)
+	assert.Equal(t, e.Space(escrow.Refund), space)
+	assert.NotEqual(t, e.Space(escrow.Release), space)
+	assert.Zero(t, nonce.Sign())
+	if assert.Len(t, refund.Transactions, 2) {
+		assert.Equal(t, walletContext.UtilsAddress, refund.Transactions[0].To)
+		assert.Equal(t, buyer.Address(), refund.Transactions[1].To)
+	}
+	refund, err = e.Cosign(ctx, arbiterWallet, refund)
+	assert.NoError(t, err)
+	_, err = arbiterWallet.FinalizeTransactions(refund)
+	assert.NoError(t, err)
+
+	// only the paths of the escrow are cosigned
+	_, err = other.Cosign(ctx, arbiterWallet, release)
+	assert.Error(t, err)
+
+	// only the escrow wallet signs its paths
+	buyerWallet, err := sequence.NewWalletSingleOwner(buyer, walletContext)
+	assert.NoError(t, err)
+	_, err = e.Sign(ctx, buyerWallet, escrow.Refund)
+	assert.Error(t, err)
+
+	// tokens are transferred
+	terms.Token = common.HexToAddress("0x70c3")
+	tokens, err := escrow.New("order-44", terms, walletContext, big.NewInt(1))
+	assert.NoError(t, err)
+	txns, err := tokens.Transactions(escrow.Refund)
+	assert.NoError(t, err)
+	data, err := contracts.IERC20.Encode("transfer", buyer.Address(), terms.Amount)
+	assert.NoError(t, err)
+	if assert.Len(t, txns, 2) {
+		assert.Equal(t, terms.Token, txns[1].To)
+		assert.Equal(t, data, txns[1].Data)
+	}
+}
+
+func TestEscrowOnChain(t *testing.T) {
+	ctx := context.Background()
+	walletContext := testutil.SequenceContext()
+	chain := memchain.New(memchain.Options{})
+	testutil.DeployMemChainWalletUtils(chain, walletContext)
+
+	sender, err := testutil.MemChainEOA(chain, 10, big.NewInt(1e18))
+	assert.NoError(t, err)
+	r, err := relayer.NewLocalRelayerWithProvider(sender, chain.Provider())
+	assert.NoError(t, err)
+
+	head, err := chain.Provider().HeaderByNumber(ctx, nil)
+	assert.NoError(t, err)
+	buyer, seller, arbiter := parties(t)
+	terms := escrow.Terms{
+		Buyer:   buyer.Address(),
+		Seller:  seller.Address(),
+		Arbiter: arbiter.Address(),
+		Amount:  big.NewInt(1e18),
+		Timeout: time.Unix(int64(head.Time), 0).Add(time.Hour),
+	}
+
+	// open returns an escrow funded on chain, and its wallet to relay its paths
+	open := func(id string) (*escrow.Escrow, *sequence.Wallet) {
+		e, err := escrow.New(id, terms, walletContext, chain.ChainID())
+		assert.NoError(t, err)
+		wallet, err := e.Wallet()
+		assert.NoError(t, err)
+		assert.NoError(t, wallet.Connect(chain.Provider(), r))
+		testutil.DeployMemChainWallet(chain, wallet)
+		chain.Fund(e.Address, terms.Amount)
+		return e, wallet
+	}
+
+	// sign returns path signed by two parties
+	sign := func(e *escrow.Escrow, path escrow.Path, signer, cosigner *ethwallet.Wallet) *sequence.SignedTransactions {
+		signerWallet, err := e.Wallet(signer)
+		assert.NoError(t, err)
+		cosignerWallet, err := e.Wallet(cosigner)
+		assert.NoError(t, err)
+		signed, err := e.Sign(ctx, signerWallet, path)
+		assert.NoError(t, err)
+		signed, err = e.Cosign(ctx, cosignerWallet, signed)
+		assert.NoError(t, err)
+		signed, err = cosignerWallet.FinalizeTransactions(signed)
+		assert.NoError(t, err)
+		return signed
+	}
+
+	relay := func(wallet *sequence.Wallet, signed *sequence.SignedTransactions) (sequence.MetaTxnStatus, error) {
+		metaTxnID, _, _, err := wallet.SendTransaction(ctx, signed)
+		if err != nil {
+			return 0, err
+		}
+		status, _, err := r.Wait(ctx, metaTxnID, 10*time.Second)
+		return status, err
+	}
+
+	state := func(e *escrow.Escrow, wallet *sequence.Wallet) escrow.State {
+		status, err := e.Status(ctx, wallet)
+		assert.NoError(t, err)
+		return status.State
+	}
+
+	// the refunds are signed at setup
+	disputed, disputedWallet := open("order-42")
+	disputedRefund := sign(disputed, escrow.Refund, buyer, arbiter)
+	released, releasedWallet := open("order-43")
+	releasedRefund := sign(released, escrow.Refund, buyer, arbiter)
+	assert.Equal(t, escrow.Open, state(disputed, disputedWallet))
+
+	// the refund doesn't execute before a release was relayed
+	_, err = relay(disputedWallet, disputedRefund)
+	assert.Error(t, err)
+	assert.Equal(t, terms.Amount, chain.Balance(disputed.Address))
+	assert.Equal(t, escrow.Open, state(disputed, disputedWallet))
+
+	// a release relayed before the timeout pays the seller, so the refund has nothing left to pay
+	status, err := relay(releasedWallet, sign(released, escrow.Release, seller, buyer))
+	assert.NoError(t, err)
+	assert.Equal(t, sequence.MetaTxnExecuted, status)
+	assert.Equal(t, terms.Amount, chain.Balance(seller.Address()))
+	assert.Equal(t, escrow.Released, state(released, releasedWallet))
+
+	_, err = relay(releasedWallet, releasedRefund)
+	assert.Error(t, err)
+	assert.Zero(t, chain.Balance(buyer.Address()).Sign())
+	assert.Equal(t, escrow.Released, state(released, releasedWallet))
+
+	// a release relayed after the timeout lapses, and unlocks the refund
+	chain.AdvanceTime(2 * time.Hour)
+	chain.Mine()
+	assert.Equal(t, escrow.Refundable, state(disputed, disputedWallet))
+
+	status, err = relay(disputedWallet, sign(disputed, escrow.Release, buyer, arbiter))
+	assert.NoError(t, err)
+	assert.Equal(t, sequence.MetaTxnFailed, status)
+	assert.Equal(t, terms.Amount, chain.Balance(seller.Address()))
+	assert.Equal(t, terms.Amount, chain.Balance(disputed.Address))
+	assert.Equal(t, escrow.Refundable, state(disputed, disputedWallet))
+
+	status, err = relay(disputedWallet, disputedRefund)
+	assert.NoError(t, err)
+	assert.Equal(t, sequence.MetaTxnExecuted, status)
+	assert.Equal(t, terms.Amount, chain.Balance(buyer.Address()))
+
+	refundID, err := disputed.MetaTxnID(escrow.Refund)
+	assert.NoError(t, err)
+	refunded, err := disputed.Status(ctx, disputedWallet)
+	assert.NoError(t, err)
+	assert.Equal(t, escrow.Refunded, refunded.State)
+	assert.Equal(t, refundID, refunded.MetaTxnID)
+	assert.NotEqual(t, common.Hash{}, refunded.TxnHash)
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
 	"github.com/0xsequence/go-sequence/contracts"
 	"github.com/0xsequence/go-sequence/contracts/gen/walletgasestimator"
+	"github.com/0xsequence/go-sequence/contracts/gen/walletmain"
 	"github.com/goware/cachestore"
 	"github.com/goware/cachestore/memlru"
 )
@@ -57,7 +58,17 @@ type Estimator struct {
 	DataOneCost  uint64
 	DataZeroCost uint64
 
-	cache cachestore.Store[[]byte]
+	// GasPadding is the percentage the estimates of EstimateGasLimits are padded by, ie. 10
+	// for a margin against state changing between the estimate and the execution.
+	GasPadding uint64
+
+	// CallGasSurcharge is the gas added to the gas limit of each transaction by
+	// EstimateGasLimits, on top of its padding, ie. for calls whose gas depends on the gas
+	// forwarded to them.
+	CallGasSurcharge uint64
+
+	cache   cachestore.Store[[]byte]
+	metrics Metrics
 }
 
 type SimulateResult walletgasestimator.MainModuleGasEstimationSimulateResult
@@ -71,13 +82,20 @@ var defaultEstimator = &Estimator{
 var gasEstimatorCode = hexutil.Encode(contracts.GasEstimator.DeployedBin)
 var walletGasEstimatorCode = hexutil.Encode(contracts.WalletGasEstimator.DeployedBin)
 
-func NewEstimator() *Estimator {
-	defaultCache, _ := memlru.NewWithSize[[]byte](defaultEstimatorCacheSize)
+func NewEstimator(opts ...Option) *Estimator {
+	options := NewOptions(opts...)
+
+	cache := options.Cache
+	if cache == nil {
+		cache, _ = memlru.NewWithSize[[]byte](defaultEstimatorCacheSize)
+	}
+
 	return &Estimator{
 		BaseCost:     defaultEstimator.BaseCost,
 		DataZeroCost: defaultEstimator.DataZeroCost,
 		DataOneCost:  defaultEstimator.DataOneCost,
-		cache:        defaultCache,
+		cache:        cache,
+		metrics:      options.Metrics,
 	}
 }
 
@@ -86,6 +104,19 @@ func (e *Estimator) SetCache(cache cachestore.Store[[]byte]) *Estimator {
 	return e
 }
 
+// SetGasPadding sets the percentage the estimates of EstimateGasLimits are padded by.
+func (e *Estimator) SetGasPadding(percent uint64) *Estimator {
+	e.GasPadding = percent
+	return e
+}
+
+// SetCallGasSurcharge sets the gas added to the gas limit of each transaction by
+// EstimateGasLimits.
+func (e *Estimator) SetCallGasSurcharge(gas uint64) *Estimator {
+	e.CallGasSurcharge = gas
+	return e
+}
+
 func (e *Estimator) CalldataCost(data []byte) uint64 {
 	cost := e.BaseCost
 
@@ -177,7 +208,7 @@ func (e *Estimator) EstimateCall(ctx context.Context, provider *ethrpc.Provider,
 
 	var res string
 	rpcCall := ethrpc.NewCallBuilder[string]("eth_call", nil, estimateCall, blockTag, finalOverrides)
-	err = provider.Do(context.Background(), rpcCall.Into(&res))
+	err = provider.Do(ctx, rpcCall.Into(&res))
 	if err != nil {
 		return nil, err
 	}
@@ -398,6 +429,12 @@ func (e *Estimator) BuildStubSignature(walletConfig WalletConfig, willSign []boo
 }
 
 func (e *Estimator) Estimate(ctx context.Context, provider *ethrpc.Provider, address common.Address, walletConfig WalletConfig, walletContext WalletContext, txs Transactions) (uint64, error) {
+	if e.metrics != nil {
+		defer func(start time.Time) {
+			e.metrics.ObserveDuration("estimator.estimate", time.Since(start))
+		}(time.Now())
+	}
+
 	isEOA, err := e.AreEOAs(ctx, provider, walletConfig)
 	if err != nil {
 		return 0, err
@@ -438,12 +475,12 @@ func (e *Estimator) Estimate(ctx context.Context, provider *ethrpc.Provider, add
 	for i := range estimates {
 		subTxs := txs[0:i]
 
-		encTxs, err := subTxs.EncodedTransactions()
+		calls, err := subTxs.ModuleCalls()
 		if err != nil {
 			return 0, err
 		}
 
-		execData, err := contracts.WalletMainModule.Encode("execute", encTxs, nonce, signature)
+		execData, err := walletmain.WalletMainCalldata.Execute(calls, nonce, signature)
 		if err != nil {
 			return 0, err
 		}
@@ -467,17 +504,62 @@ func (e *Estimator) Estimate(ctx context.Context, provider *ethrpc.Provider, add
 	return estimates[len(estimates)-1].Uint64(), nil
 }
 
+// EstimateGasLimits sets the gas limits of the transactions of txns which have none, ie. a nil
+// or zero gas limit, by simulating their execution by the wallet at address, see Estimate:
+// each is the gas its call used within the execute call of the wallet, padded by GasPadding
+// percent, plus CallGasSurcharge. Transactions with a gas limit keep it, and are simulated
+// with it. Transactions whose simulated gas isn't positive are left without one.
+//
+// It returns the padded gas of the whole execute call, which includes the validation of the
+// signature of the bundle and the overhead of the wallet on top of the calls, ie. for the gas
+// limit of the native transaction carrying it. txns are not modified if the estimation fails.
+func (e *Estimator) EstimateGasLimits(ctx context.Context, provider *ethrpc.Provider, address common.Address, walletConfig WalletConfig, walletContext WalletContext, txns Transactions) (uint64, error) {
+	simulated := txns.Clone()
+	total, err := e.Estimate(ctx, provider, address, walletConfig, walletContext, simulated)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, txn := range txns {
+		if txn.GasLimit != nil && txn.GasLimit.Sign() > 0 {
+			continue
+		}
+		// the gas of a call can't be told apart from the noise of the simulation if it isn't
+		// positive, ie. if it refunds more than it uses, so it's left to the caller
+		if simulated[i].GasLimit.Sign() <= 0 {
+			continue
+		}
+		txn.GasLimit = new(big.Int).SetUint64(e.pad(simulated[i].GasLimit.Uint64()) + e.CallGasSurcharge)
+	}
+
+	return e.pad(total), nil
+}
+
+// pad returns gas padded by GasPadding percent.
+func (e *Estimator) pad(gas uint64) uint64 {
+	return gas + gas*e.GasPadding/100
+}
+
 func Simulate(provider *ethrpc.Provider, wallet common.Address, transactions Transactions, block string, overrides map[common.Address]*CallOverride) ([]SimulateResult, error) {
+	return SimulateContext(context.Background(), provider, wallet, transactions, block, overrides)
+}
+
+// SimulateContext is Simulate, with the eth_call of the simulation bound to ctx.
+func SimulateContext(ctx context.Context, provider *ethrpc.Provider, wallet common.Address, transactions Transactions, block string, overrides map[common.Address]*CallOverride) ([]SimulateResult, error) {
 	if block == "" {
 		block = "latest"
 	}
 
-	encoded, err := transactions.EncodedTransactions()
+	calls, err := transactions.ModuleCalls()
 	if err != nil {
 		return nil, err
 	}
+	simulateCalls := make([]walletgasestimator.IModuleCallsTransaction, 0, len(calls))
+	for _, call := range calls {
+		simulateCalls = append(simulateCalls, walletgasestimator.IModuleCallsTransaction(call))
+	}
 
-	callData, err := contracts.WalletGasEstimator.Encode("simulateExecute", encoded)
+	callData, err := walletgasestimator.WalletGasEstimatorCalldata.SimulateExecute(simulateCalls)
 	if err != nil {
 		return nil, err
 	}
@@ -505,7 +587,7 @@ func Simulate(provider *ethrpc.Provider, wallet common.Address, transactions Tra
 
 	var response string
 	rpcCall := ethrpc.NewCallBuilder[string]("eth_call", nil, params, block, allOverrides)
-	err = provider.Do(context.Background(), rpcCall.Into(&response))
+	err = provider.Do(ctx, rpcCall.Into(&response))
 	if err != nil {
 		return nil, err
 	}
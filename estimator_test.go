@@ -2,6 +2,7 @@ package sequence_test
 
 import (
 	"context"
+	"encoding/json"
 	"math/big"
 	"math/rand"
 	"testing"
@@ -11,9 +12,12 @@ import (
 	"github.com/0xsequence/ethkit/ethtxn"
 	"github.com/0xsequence/ethkit/ethwallet"
 	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
 	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/contracts"
 	"github.com/0xsequence/go-sequence/relayer"
 	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -713,3 +717,70 @@ func TestEstimateIssue5367MultipleSignersTime(t *testing.T) {
 		assert.Equal(t, 1, txs[0].GasLimit.Cmp(big.NewInt(0)))
 	}
 }
+
+func TestEstimatorEstimateGasLimits(t *testing.T) {
+	owner, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(owner)
+	assert.NoError(t, err)
+
+	// a chain which simulates the execute calls of the wallet, where its execution costs 30000
+	// gas, and each transaction 10000 more. The calls run the gas estimator as the overridden
+	// code of a stub address, and memchain doesn't apply overrides, so they are intercepted.
+	var overridden []string
+	chain := memchain.New(memchain.Options{ChainID: big.NewInt(1)})
+	chain.Intercept(func(method string, params []json.RawMessage, next func() (interface{}, error)) (interface{}, error) {
+		if method != "eth_call" {
+			return next()
+		}
+		var call struct {
+			Data hexutil.Bytes `json:"data"`
+		}
+		_ = json.Unmarshal(params[0], &call)
+		var overrides map[common.Address]json.RawMessage
+		_ = json.Unmarshal(params[2], &overrides)
+		for address := range overrides {
+			overridden = append(overridden, address.Hex())
+		}
+
+		args, err := contracts.GasEstimator.ABI.Methods["estimate"].Inputs.Unpack(call.Data[4:])
+		if err != nil {
+			return nil, err
+		}
+		txns, _, _, err := sequence.DecodeExecdata(args[1].([]byte))
+		if err != nil {
+			return nil, err
+		}
+		gas := big.NewInt(30000 + 10000*int64(len(txns)))
+		return ethcoder.AbiCoderHex([]string{"bool", "bytes", "uint256"}, []interface{}{true, []byte{}, gas})
+	})
+	provider := chain.Provider()
+
+	txns := sequence.Transactions{
+		{To: common.HexToAddress("0x7a7"), Value: big.NewInt(1)},
+		{To: common.HexToAddress("0x7a8"), Data: []byte{0x01, 0x02}, GasLimit: big.NewInt(50000)},
+		{To: common.HexToAddress("0x7a9"), Data: []byte{0x01, 0x02, 0x03}},
+	}
+
+	// the gas used by each transaction within the execute call
+	raw := txns.Clone()
+	estimator := sequence.NewEstimator()
+	total, err := estimator.Estimate(context.Background(), provider, wallet.Address(), wallet.GetWalletConfig(), wallet.GetWalletContext(), raw)
+	assert.NoError(t, err)
+	assert.Greater(t, total, uint64(60000))
+
+	// the main modules run the wallet estimator, and the wallet which isn't deployed is a proxy
+	assert.Contains(t, overridden, wallet.GetWalletContext().MainModuleAddress.Hex())
+	assert.Contains(t, overridden, wallet.Address().Hex())
+
+	padded, err := estimator.SetGasPadding(10).SetCallGasSurcharge(1000).EstimateGasLimits(context.Background(), provider, wallet.Address(), wallet.GetWalletConfig(), wallet.GetWalletContext(), txns)
+	assert.NoError(t, err)
+	assert.Equal(t, total+total/10, padded)
+
+	for _, i := range []int{0, 2} {
+		used := raw[i].GasLimit.Uint64()
+		assert.Greater(t, used, uint64(10000))
+		assert.Equal(t, used+used/10+1000, txns[i].GasLimit.Uint64())
+	}
+	assert.Equal(t, big.NewInt(50000), txns[1].GasLimit)
+}
@@ -0,0 +1,187 @@
+package sequence
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common/math"
+	"github.com/0xsequence/go-sequence/contracts"
+)
+
+var (
+	executeMethodID     = contracts.WalletMainModule.ABI.Methods["execute"].ID
+	selfExecuteMethodID = contracts.WalletMainModule.ABI.Methods["selfExecute"].ID
+)
+
+// ExecdataEncoder encodes the calldata of the `execute` and `selfExecute` calls of the main
+// module into a buffer it reuses, writing the ABI encoding of the transactions as it goes
+// instead of building their abi value trees, ie. for bundles of hundreds of payouts.
+//
+// The calldata returned is only valid until the next call of the encoder, and an encoder
+// must not be used concurrently.
+type ExecdataEncoder struct {
+	buf []byte
+}
+
+// NewExecdataEncoder returns an encoder with a buffer of size bytes, which grows as needed.
+func NewExecdataEncoder(size int) *ExecdataEncoder {
+	return &ExecdataEncoder{buf: make([]byte, 0, size)}
+}
+
+// Execute encodes execute(txns, nonce, signature), the same as Transaction.Execdata of a
+// signed bundle.
+func (e *ExecdataEncoder) Execute(txns Transactions, nonce *big.Int, signature []byte) ([]byte, error) {
+	buf, err := appendExecute(e.buf[:0], txns, nonce, signature)
+	if err != nil {
+		return nil, err
+	}
+	e.buf = buf
+	return buf, nil
+}
+
+// SelfExecute encodes selfExecute(txns), the same as Transaction.Execdata of an unsigned
+// bundle.
+func (e *ExecdataEncoder) SelfExecute(txns Transactions) ([]byte, error) {
+	buf, err := appendSelfExecute(e.buf[:0], txns)
+	if err != nil {
+		return nil, err
+	}
+	e.buf = buf
+	return buf, nil
+}
+
+// Reset releases the buffer of the encoder, ie. after encoding an unusually large bundle.
+func (e *ExecdataEncoder) Reset() {
+	e.buf = nil
+}
+
+func appendExecute(buf []byte, txns Transactions, nonce *big.Int, signature []byte) ([]byte, error) {
+	if nonce == nil {
+		return nil, fmt.Errorf("nonce is required for wallet execute")
+	}
+
+	buf = append(buf, executeMethodID...)
+	args := len(buf)
+	buf = appendUint64Word(buf, 0x60)
+	buf = appendUint256Word(buf, nonce)
+	signatureOffset := len(buf)
+	buf = appendZeroWord(buf)
+
+	buf, err := appendTransactions(buf, txns)
+	if err != nil {
+		return nil, err
+	}
+
+	putUint64Word(buf[signatureOffset:], uint64(len(buf)-args))
+	buf = appendUint64Word(buf, uint64(len(signature)))
+	buf = append(buf, signature...)
+	return appendPadding(buf, len(signature)), nil
+}
+
+func appendSelfExecute(buf []byte, txns Transactions) ([]byte, error) {
+	buf = append(buf, selfExecuteMethodID...)
+	buf = appendUint64Word(buf, 0x20)
+	return appendTransactions(buf, txns)
+}
+
+// appendTransactions appends the ABI encoding of txns as a Transaction[], the same as of
+// txns.ModuleCalls(). The offsets and lengths of the transactions are filled in once their
+// data is written, so bundles of bundles are encoded in place.
+func appendTransactions(buf []byte, txns Transactions) ([]byte, error) {
+	buf = appendUint64Word(buf, uint64(len(txns)))
+	heads := len(buf)
+	for range txns {
+		buf = appendZeroWord(buf)
+	}
+
+	for i, txn := range txns {
+		if txn == nil {
+			return nil, fmt.Errorf("cannot sign a nil transaction")
+		}
+		putUint64Word(buf[heads+32*i:], uint64(len(buf)-heads))
+
+		buf = appendBoolWord(buf, txn.DelegateCall)
+		buf = appendBoolWord(buf, txn.RevertOnError)
+		buf = appendUint256Word(buf, txn.GasLimit)
+		buf = append(buf, zeroWord[:12]...)
+		buf = append(buf, txn.To[:]...)
+		buf = appendUint256Word(buf, txn.Value)
+		buf = appendUint64Word(buf, 0xc0)
+
+		length := len(buf)
+		buf = appendZeroWord(buf)
+		data := len(buf)
+
+		if !txn.encoded && txn.IsBundle() {
+			// flatten the bundle into its execdata, as EncodedTransactions does
+			if err := txn.IsValid(); err != nil {
+				return nil, err
+			}
+			var err error
+			if txn.Signature != nil {
+				buf, err = appendExecute(buf, txn.Transactions, txn.Nonce, txn.Signature)
+			} else {
+				buf, err = appendSelfExecute(buf, txn.Transactions)
+			}
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			buf = append(buf, txn.Data...)
+		}
+
+		putUint64Word(buf[length:], uint64(len(buf)-data))
+		buf = appendPadding(buf, len(buf)-data)
+	}
+
+	return buf, nil
+}
+
+var zeroWord [32]byte
+
+func appendZeroWord(buf []byte) []byte {
+	return append(buf, zeroWord[:]...)
+}
+
+func appendPadding(buf []byte, n int) []byte {
+	if rem := n % 32; rem != 0 {
+		buf = append(buf, zeroWord[:32-rem]...)
+	}
+	return buf
+}
+
+func appendBoolWord(buf []byte, b bool) []byte {
+	buf = appendZeroWord(buf)
+	if b {
+		buf[len(buf)-1] = 1
+	}
+	return buf
+}
+
+func appendUint64Word(buf []byte, n uint64) []byte {
+	buf = appendZeroWord(buf)
+	putUint64Word(buf[len(buf)-32:], n)
+	return buf
+}
+
+// appendUint256Word appends x as a uint256 word, in two's complement if it is negative as the
+// ABI encoder does. A nil x is 0.
+func appendUint256Word(buf []byte, x *big.Int) []byte {
+	buf = appendZeroWord(buf)
+	switch {
+	case x == nil:
+	case x.Sign() >= 0 && x.BitLen() <= 256:
+		x.FillBytes(buf[len(buf)-32:])
+	default:
+		copy(buf[len(buf)-32:], math.U256Bytes(new(big.Int).Set(x)))
+	}
+	return buf
+}
+
+// putUint64Word writes n as the uint256 word at the start of buf, which must be zeroed.
+func putUint64Word(buf []byte, n uint64) {
+	for i := 31; n > 0; i-- {
+		buf[i] = byte(n)
+		n >>= 8
+	}
+}
@@ -0,0 +1,105 @@
+package sequence_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/contracts/gen/walletmain"
+	"github.com/stretchr/testify/assert"
+)
+
+// payouts returns a bundle of n transfers, as of a mass disbursement.
+func payouts(n int) sequence.Transactions {
+	txns := make(sequence.Transactions, 0, n)
+	for i := 0; i < n; i++ {
+		txns = append(txns, &sequence.Transaction{
+			RevertOnError: true,
+			To:            common.BigToAddress(big.NewInt(int64(i + 1))),
+			Value:         big.NewInt(int64(i+1) * 1e15),
+			Data:          common.FromHex("0xa9059cbb")[:i%5],
+		})
+	}
+	return txns
+}
+
+func TestExecdataEncoder(t *testing.T) {
+	txns := payouts(300)
+	txns = append(txns, &sequence.Transaction{
+		To:           common.HexToAddress("0x7a7"),
+		Transactions: digestTransactions(),
+		Nonce:        big.NewInt(3),
+		Signature:    []byte{0, 1, 2},
+	}, &sequence.Transaction{To: common.HexToAddress("0x7a8"), Transactions: payouts(3)})
+
+	calls, err := txns.ModuleCalls()
+	assert.NoError(t, err)
+	nonce, signature := big.NewInt(7), common.FromHex("0x0001000102")
+
+	// the calldata is the same as of the abi encoder
+	encoder := sequence.NewExecdataEncoder(0)
+	execdata, err := encoder.Execute(txns, nonce, signature)
+	assert.NoError(t, err)
+	expected, err := walletmain.WalletMainCalldata.Execute(calls, nonce, signature)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, execdata)
+
+	execdata, err = encoder.SelfExecute(txns)
+	assert.NoError(t, err)
+	expected, err = walletmain.WalletMainCalldata.SelfExecute(calls)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, execdata)
+
+	// and decodes to the same transactions
+	execdata, err = encoder.Execute(txns, nonce, signature)
+	assert.NoError(t, err)
+	decoded, decodedNonce, decodedSignature, err := sequence.DecodeExecdata(execdata)
+	assert.NoError(t, err)
+	assert.Equal(t, nonce, decodedNonce)
+	assert.Equal(t, signature, decodedSignature)
+	assert.Len(t, decoded, len(txns))
+
+	// the buffer of the encoder is reused
+	execdata, err = encoder.Execute(txns[:1], nonce, nil)
+	assert.NoError(t, err)
+	expected, err = walletmain.WalletMainCalldata.Execute(calls[:1], nonce, []byte{})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, execdata)
+	assert.Greater(t, cap(execdata), len(execdata))
+
+	_, err = encoder.Execute(txns, nil, signature)
+	assert.Error(t, err)
+	_, err = encoder.SelfExecute(sequence.Transactions{nil})
+	assert.Error(t, err)
+	_, err = encoder.SelfExecute(sequence.Transactions{{Transactions: payouts(1), Nonce: big.NewInt(1)}})
+	assert.Error(t, err)
+}
+
+func BenchmarkExecdataEncoder(b *testing.B) {
+	txns := payouts(500)
+	nonce, signature := big.NewInt(7), make([]byte, 70)
+
+	b.Run("abi", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			calls, err := txns.ModuleCalls()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := walletmain.WalletMainCalldata.Execute(calls, nonce, signature); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("stream", func(b *testing.B) {
+		encoder := sequence.NewExecdataEncoder(0)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := encoder.Execute(txns, nonce, signature); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,170 @@
+package sequence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+// ErrMetaTxnNotFound is returned when the native transaction of a meta-transaction can't be
+// located.
+var ErrMetaTxnNotFound = errors.New("sequence: meta transaction not found")
+
+// ReceiptFallback locates the native transaction which a meta-transaction of wallet was mined
+// in, out of band of the node, ie. when its log queries fail or the meta-transaction is older
+// than the blocks they look back on. It returns ErrMetaTxnNotFound if it isn't found.
+type ReceiptFallback interface {
+	FindMetaTxn(ctx context.Context, wallet common.Address, metaTxnID MetaTxnID) (common.Hash, error)
+}
+
+const (
+	defaultExplorerPageSize = 1000
+	defaultExplorerTimeout  = 30 * time.Second
+)
+
+// ExplorerClient is a client of the logs API of Etherscan, or of a compatible block explorer
+// such as Blockscout, which serves as a ReceiptFallback.
+type ExplorerClient struct {
+	baseURL  string
+	apiKey   string
+	client   *http.Client
+	pageSize int
+}
+
+var _ ReceiptFallback = &ExplorerClient{}
+
+// NewExplorerClient returns a client of the explorer API at baseURL, ie.
+// "https://api.etherscan.io/v2/api?chainid=1" or "https://eth.blockscout.com/api". The query
+// parameters of baseURL are sent with every request. apiKey may be empty.
+func NewExplorerClient(baseURL string, apiKey string) *ExplorerClient {
+	return &ExplorerClient{
+		baseURL:  baseURL,
+		apiKey:   apiKey,
+		client:   &http.Client{Timeout: defaultExplorerTimeout},
+		pageSize: defaultExplorerPageSize,
+	}
+}
+
+func (c *ExplorerClient) SetHTTPClient(client *http.Client) *ExplorerClient {
+	c.client = client
+	return c
+}
+
+// SetPageSize sets the number of logs requested per page, which is capped by the explorer.
+func (c *ExplorerClient) SetPageSize(pageSize int) *ExplorerClient {
+	c.pageSize = pageSize
+	return c
+}
+
+type explorerResponse struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Result  json.RawMessage `json:"result"`
+}
+
+type explorerLog struct {
+	Topics          []common.Hash `json:"topics"`
+	Data            hexutil.Bytes `json:"data"`
+	TransactionHash common.Hash   `json:"transactionHash"`
+}
+
+// FindMetaTxn pages through the logs of wallet for the TxExecuted or TxFailed event of
+// metaTxnID, and returns the hash of the transaction which emitted it.
+func (c *ExplorerClient) FindMetaTxn(ctx context.Context, wallet common.Address, metaTxnID MetaTxnID) (common.Hash, error) {
+	metaTxnHash := common.HexToHash(string(metaTxnID))
+
+	for page := 1; ; page++ {
+		logs, err := c.logs(ctx, wallet, page)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		for _, log := range logs {
+			hash, ok := metaTxnHashOfLog(&types.Log{Topics: log.Topics, Data: log.Data})
+			if ok && hash == metaTxnHash {
+				return log.TransactionHash, nil
+			}
+		}
+		if len(logs) < c.pageSize {
+			return common.Hash{}, fmt.Errorf("%w: %v of %v", ErrMetaTxnNotFound, metaTxnID, wallet.Hex())
+		}
+	}
+}
+
+func (c *ExplorerClient) logs(ctx context.Context, address common.Address, page int) ([]explorerLog, error) {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("sequence: invalid explorer url: %w", err)
+	}
+	query := u.Query()
+	query.Set("module", "logs")
+	query.Set("action", "getLogs")
+	query.Set("address", address.Hex())
+	query.Set("fromBlock", "0")
+	query.Set("toBlock", "latest")
+	query.Set("page", strconv.Itoa(page))
+	query.Set("offset", strconv.Itoa(c.pageSize))
+	if c.apiKey != "" {
+		query.Set("apikey", c.apiKey)
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sequence: explorer request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("sequence: explorer request failed with status %d", resp.StatusCode)
+	}
+
+	var response explorerResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("sequence: invalid explorer response: %w", err)
+	}
+
+	// an empty page has status 0 too, but its result is still a list, while the result of
+	// an error is its message
+	var logs []explorerLog
+	if err := json.Unmarshal(response.Result, &logs); err != nil {
+		var message string
+		_ = json.Unmarshal(response.Result, &message)
+		return nil, fmt.Errorf("sequence: explorer error: %s: %s", response.Message, message)
+	}
+	return logs, nil
+}
+
+// FindMetaTxnReceipt locates the native transaction of metaTxnID of wallet with fallback, and
+// returns the status of the meta-transaction with the receipt fetched from provider.
+func FindMetaTxnReceipt(ctx context.Context, provider *ethrpc.Provider, fallback ReceiptFallback, wallet common.Address, metaTxnID MetaTxnID) (*MetaTxnResult, *types.Receipt, error) {
+	txnHash, err := fallback.FindMetaTxn(ctx, wallet, metaTxnID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	receipt, err := provider.TransactionReceipt(ctx, txnHash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sequence: failed to fetch receipt %v: %w", txnHash.Hex(), err)
+	}
+
+	// the explorer is not trusted with the status, only with the location
+	metaTxnHash := common.HexToHash(string(metaTxnID))
+	result := metaTxnResultOf(metaTxnID, metaTxnHash, receipt.Logs)
+	if result.Status == MetaTxnStatusUnknown {
+		return nil, nil, fmt.Errorf("%w: %v is not in %v", ErrMetaTxnNotFound, metaTxnID, txnHash.Hex())
+	}
+	return result, receipt, nil
+}
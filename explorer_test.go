@@ -0,0 +1,79 @@
+package sequence_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplorerReceiptFallback(t *testing.T) {
+	metaTxnID := sequence.MetaTxnID(ethcoder.Keccak256Hash([]byte("executed")).Hex()[2:])
+
+	node := newReceiptsNode()
+	wallet := node.receipts[0].Logs[0].Address
+	mined := node.receipts[1]
+	mined.Logs = append(mined.Logs, &types.Log{
+		Address:     wallet,
+		Topics:      []common.Hash{},
+		Data:        common.HexToHash(string(metaTxnID)).Bytes(),
+		BlockNumber: mined.BlockNumber.Uint64(),
+		TxHash:      mined.TxHash,
+		BlockHash:   mined.BlockHash,
+	})
+	provider := node.serve()
+
+	// the event is on the second page of the logs of the wallet
+	pages := [][]map[string]interface{}{
+		{
+			{"topics": []common.Hash{sequence.NonceChangeEventSig}, "data": "0x00", "transactionHash": node.receipts[0].TxHash},
+			{"topics": []common.Hash{}, "data": "0x1234", "transactionHash": node.receipts[0].TxHash},
+		},
+		{
+			{"topics": []common.Hash{}, "data": common.HexToHash(string(metaTxnID)), "transactionHash": mined.TxHash},
+		},
+	}
+	rateLimited := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		assert.Equal(t, "getLogs", query.Get("action"))
+		assert.Equal(t, wallet.Hex(), query.Get("address"))
+		assert.Equal(t, "1", query.Get("chainid"))
+		assert.Equal(t, "key", query.Get("apikey"))
+
+		if rateLimited {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"status": "0", "message": "NOTOK", "result": "Max rate limit reached"})
+			return
+		}
+		page, _ := strconv.Atoi(query.Get("page"))
+		result := []map[string]interface{}{}
+		if page <= len(pages) {
+			result = pages[page-1]
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"status": "1", "message": "OK", "result": result})
+	}))
+	defer ts.Close()
+
+	explorer := sequence.NewExplorerClient(ts.URL+"?chainid=1", "key").SetPageSize(2)
+
+	result, receipt, err := sequence.FindMetaTxnReceipt(context.Background(), provider, explorer, wallet, metaTxnID)
+	assert.NoError(t, err)
+	assert.Equal(t, sequence.MetaTxnExecuted, result.Status)
+	assert.Equal(t, mined.TxHash, receipt.TxHash)
+
+	unknown := sequence.MetaTxnID(ethcoder.Keccak256Hash([]byte("unknown")).Hex()[2:])
+	_, _, err = sequence.FindMetaTxnReceipt(context.Background(), provider, explorer, wallet, unknown)
+	assert.ErrorIs(t, err, sequence.ErrMetaTxnNotFound)
+
+	rateLimited = true
+	_, err = explorer.FindMetaTxn(context.Background(), wallet, metaTxnID)
+	assert.ErrorContains(t, err, "Max rate limit reached")
+}
@@ -0,0 +1,287 @@
+package sequence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence/contracts"
+)
+
+var (
+	ErrInsufficientFeeBalance = errors.New("sequence: insufficient balance to pay fee")
+	ErrUnsupportedFeeToken    = errors.New("sequence: unsupported fee token")
+)
+
+// FeeToken is a token fees are paid in on a chain: the gas token of the chain, whose address
+// is the zero address, or an ERC-20 token of a fee market.
+type FeeToken struct {
+	ChainID  uint64         `json:"chainId"`
+	Address  common.Address `json:"address"`
+	Symbol   string         `json:"symbol"`
+	Decimals uint8          `json:"decimals"`
+}
+
+// IsGasToken reports whether the token is the gas token of its chain.
+func (t FeeToken) IsGasToken() bool {
+	return t.Address == common.Address{}
+}
+
+// Format formats amount of the token followed by its symbol, ie. "1.5 POL".
+func (t FeeToken) Format(amount *big.Int) string {
+	return FormatUnits(amount, t.Decimals) + " " + t.Symbol
+}
+
+func (t FeeToken) String() string {
+	if t.IsGasToken() {
+		return fmt.Sprintf("%s (gas token of chain %d)", t.Symbol, t.ChainID)
+	}
+	return fmt.Sprintf("%s (%v on chain %d)", t.Symbol, t.Address.Hex(), t.ChainID)
+}
+
+// gasTokens are the symbols of the gas tokens of known chains which don't pay gas in ETH.
+var gasTokens = map[uint64]string{
+	56:    "BNB",
+	97:    "tBNB",
+	100:   "XDAI",
+	137:   "POL",
+	250:   "FTM",
+	1284:  "GLMR",
+	2020:  "RON",
+	43113: "AVAX",
+	43114: "AVAX",
+	80002: "POL",
+}
+
+var (
+	registeredGasTokens   = map[uint64]FeeToken{}
+	registeredGasTokensMu sync.RWMutex
+)
+
+// RegisterGasToken sets the gas token of a chain, ie. of an appchain with a custom gas token.
+func RegisterGasToken(chainID uint64, symbol string, decimals uint8) {
+	registeredGasTokensMu.Lock()
+	defer registeredGasTokensMu.Unlock()
+	registeredGasTokens[chainID] = FeeToken{ChainID: chainID, Symbol: symbol, Decimals: decimals}
+}
+
+// GasToken returns the gas token of a chain: the registered one, see RegisterGasToken, or the
+// one of a known chain, or ETH.
+func GasToken(chainID uint64) FeeToken {
+	registeredGasTokensMu.RLock()
+	token, ok := registeredGasTokens[chainID]
+	registeredGasTokensMu.RUnlock()
+	if ok {
+		return token
+	}
+
+	symbol, ok := gasTokens[chainID]
+	if !ok {
+		symbol = "ETH"
+	}
+	return FeeToken{ChainID: chainID, Symbol: symbol, Decimals: NativeTokenDecimals}
+}
+
+// FeeMarket prices gas in fee tokens.
+type FeeMarket interface {
+	// FeeTokens returns the tokens the market accepts fees in on a chain.
+	FeeTokens(ctx context.Context, chainID uint64) ([]FeeToken, error)
+
+	// GasPrice returns the price of a unit of gas in token, in its smallest unit, as a
+	// fraction as the price of gas in an ERC-20 token may be less than its smallest unit.
+	GasPrice(ctx context.Context, token FeeToken) (*big.Rat, error)
+}
+
+// NativeFeeMarket is the fee market of the gas token of a chain, priced at the gas price
+// suggested by its node.
+type NativeFeeMarket struct {
+	provider *ethrpc.Provider
+}
+
+var _ FeeMarket = &NativeFeeMarket{}
+
+func NewNativeFeeMarket(provider *ethrpc.Provider) *NativeFeeMarket {
+	return &NativeFeeMarket{provider: provider}
+}
+
+func (m *NativeFeeMarket) FeeTokens(ctx context.Context, chainID uint64) ([]FeeToken, error) {
+	return []FeeToken{GasToken(chainID)}, nil
+}
+
+func (m *NativeFeeMarket) GasPrice(ctx context.Context, token FeeToken) (*big.Rat, error) {
+	if !token.IsGasToken() {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFeeToken, token)
+	}
+	gasPrice, err := m.provider.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("sequence: failed to get gas price: %w", err)
+	}
+	return new(big.Rat).SetInt(gasPrice), nil
+}
+
+// RateFeeMarket is the fee market of ERC-20 tokens exchanged for the gas token at fixed rates,
+// ie. by a relayer which accepts stablecoins.
+type RateFeeMarket struct {
+	native FeeMarket
+	tokens []FeeToken
+
+	// rates are the amounts of the tokens, in their smallest units, per wei of the gas token.
+	rates map[common.Address]*big.Rat
+	mu    sync.RWMutex
+}
+
+var _ FeeMarket = &RateFeeMarket{}
+
+// NewRateFeeMarket prices gas in the gas token with native, and in ERC-20 tokens at their
+// rates, see SetRate.
+func NewRateFeeMarket(native FeeMarket) *RateFeeMarket {
+	return &RateFeeMarket{native: native, rates: map[common.Address]*big.Rat{}}
+}
+
+// SetRate sets the amount of token, in its smallest unit, exchanged for a wei of the gas
+// token.
+func (m *RateFeeMarket) SetRate(token FeeToken, rate *big.Rat) *RateFeeMarket {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.rates[token.Address]; !ok {
+		m.tokens = append(m.tokens, token)
+	}
+	m.rates[token.Address] = new(big.Rat).Set(rate)
+	return m
+}
+
+func (m *RateFeeMarket) FeeTokens(ctx context.Context, chainID uint64) ([]FeeToken, error) {
+	tokens, err := m.native.FeeTokens(ctx, chainID)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, token := range m.tokens {
+		if token.ChainID == chainID {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+func (m *RateFeeMarket) GasPrice(ctx context.Context, token FeeToken) (*big.Rat, error) {
+	if token.IsGasToken() {
+		return m.native.GasPrice(ctx, token)
+	}
+
+	m.mu.RLock()
+	rate, ok := m.rates[token.Address]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFeeToken, token)
+	}
+
+	gasPrice, err := m.native.GasPrice(ctx, GasToken(token.ChainID))
+	if err != nil {
+		return nil, err
+	}
+	return gasPrice.Mul(gasPrice, rate), nil
+}
+
+// FeeQuote is the fee of a gas limit in a fee token.
+type FeeQuote struct {
+	Token    FeeToken
+	GasLimit uint64
+
+	// GasPrice is the price of a unit of gas in the token, and Amount the fee in the smallest
+	// unit of the token, rounded up.
+	GasPrice *big.Rat
+	Amount   *big.Int
+}
+
+func (q *FeeQuote) String() string {
+	return q.Token.Format(q.Amount)
+}
+
+// QuoteFee quotes the fee of gasLimit in token on market.
+func QuoteFee(ctx context.Context, market FeeMarket, token FeeToken, gasLimit uint64) (*FeeQuote, error) {
+	gasPrice, err := market.GasPrice(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	fee := new(big.Rat).Mul(gasPrice, new(big.Rat).SetInt(new(big.Int).SetUint64(gasLimit)))
+	amount, remainder := new(big.Int).QuoRem(fee.Num(), fee.Denom(), new(big.Int))
+	if remainder.Sign() > 0 {
+		amount.Add(amount, big.NewInt(1))
+	}
+
+	return &FeeQuote{Token: token, GasLimit: gasLimit, GasPrice: gasPrice, Amount: amount}, nil
+}
+
+// EstimateFee estimates the gas of txns, see Estimate, and quotes it in token on market.
+func (e *Estimator) EstimateFee(ctx context.Context, provider *ethrpc.Provider, address common.Address, walletConfig WalletConfig, walletContext WalletContext, txns Transactions, market FeeMarket, token FeeToken) (*FeeQuote, error) {
+	gasLimit, err := e.Estimate(ctx, provider, address, walletConfig, walletContext, txns)
+	if err != nil {
+		return nil, err
+	}
+	return QuoteFee(ctx, market, token, gasLimit)
+}
+
+// QuoteFees quotes the fee of gasLimit in every token of market on a chain.
+func QuoteFees(ctx context.Context, market FeeMarket, chainID uint64, gasLimit uint64) ([]*FeeQuote, error) {
+	tokens, err := market.FeeTokens(ctx, chainID)
+	if err != nil {
+		return nil, err
+	}
+	quotes := make([]*FeeQuote, 0, len(tokens))
+	for _, token := range tokens {
+		quote, err := QuoteFee(ctx, market, token, gasLimit)
+		if err != nil {
+			return nil, err
+		}
+		quotes = append(quotes, quote)
+	}
+	return quotes, nil
+}
+
+// FeeBalance returns the balance of payer in token.
+func FeeBalance(ctx context.Context, provider *ethrpc.Provider, token FeeToken, payer common.Address) (*big.Int, error) {
+	if token.IsGasToken() {
+		return provider.BalanceAt(ctx, payer, nil)
+	}
+
+	data, err := contracts.IERC20.Encode("balanceOf", payer)
+	if err != nil {
+		return nil, err
+	}
+	res, err := provider.CallContract(ctx, ethereum.CallMsg{To: &token.Address, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sequence: failed to get balance of %v: %w", token, err)
+	}
+	values, err := contracts.IERC20.ABI.Unpack("balanceOf", res)
+	if err != nil {
+		return nil, fmt.Errorf("sequence: failed to decode balance of %v: %w", token, err)
+	}
+	return values[0].(*big.Int), nil
+}
+
+// CheckBalance checks payer has the balance to pay the fee, plus value in the same token,
+// ie. the value the transactions send when the fee is paid in the gas token. It returns
+// ErrInsufficientFeeBalance if it doesn't.
+func (q *FeeQuote) CheckBalance(ctx context.Context, provider *ethrpc.Provider, payer common.Address, value *big.Int) error {
+	balance, err := FeeBalance(ctx, provider, q.Token, payer)
+	if err != nil {
+		return err
+	}
+
+	required := new(big.Int).Set(q.Amount)
+	if value != nil {
+		required.Add(required, value)
+	}
+	if balance.Cmp(required) < 0 {
+		return fmt.Errorf("%w: %v has %s, requires %s", ErrInsufficientFeeBalance, payer.Hex(), q.Token.Format(balance), q.Token.Format(required))
+	}
+	return nil
+}
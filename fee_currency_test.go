@@ -0,0 +1,62 @@
+package sequence_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeeCurrency(t *testing.T) {
+	ctx := context.Background()
+
+	// 30 gwei gas, and a wallet of 1 POL and 5 USDC
+	usdc := sequence.FeeToken{ChainID: 137, Address: common.HexToAddress("0x3c499c542cef5e3811e1192ce70d8cc03d5c3359"), Symbol: "USDC", Decimals: 6}
+	payer := common.HexToAddress("0x01")
+	chain := memchain.New(memchain.Options{ChainID: big.NewInt(137), BaseFee: big.NewInt(29e9), GasTip: big.NewInt(1e9)})
+	chain.Fund(payer, big.NewInt(1e18))
+	chain.Deploy(usdc.Address, memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		return common.BigToHash(big.NewInt(5e6)).Bytes(), nil
+	}))
+	provider := chain.Provider()
+
+	pol := sequence.GasToken(137)
+	assert.Equal(t, "POL", pol.Symbol)
+	assert.True(t, pol.IsGasToken())
+	assert.Equal(t, "ETH", sequence.GasToken(1).Symbol)
+
+	sequence.RegisterGasToken(990001, "APP", 18)
+	assert.Equal(t, "APP", sequence.GasToken(990001).Symbol)
+	network := sequence.NetworkConfig{}
+	network.ChainID.SetUint64(990001)
+	assert.Equal(t, "APP", network.GetGasToken().Symbol)
+
+	// 1 USDC per POL, ie. 1e6 USDC units per 1e18 wei
+	market := sequence.NewRateFeeMarket(sequence.NewNativeFeeMarket(provider)).SetRate(usdc, big.NewRat(1, 1e12))
+
+	quotes, err := sequence.QuoteFees(ctx, market, 137, 100000)
+	assert.NoError(t, err)
+	assert.Len(t, quotes, 2)
+	assert.Equal(t, "0.003 POL", quotes[0].String())
+	assert.Equal(t, "0.003 USDC", quotes[1].String())
+
+	// fees smaller than the smallest unit of the token are rounded up
+	quote, err := sequence.QuoteFee(ctx, market, usdc, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1), quote.Amount)
+
+	_, err = sequence.QuoteFee(ctx, sequence.NewNativeFeeMarket(provider), usdc, 1)
+	assert.True(t, errors.Is(err, sequence.ErrUnsupportedFeeToken))
+
+	// the balance of the fee token is checked, with the value sent in the gas token
+	assert.NoError(t, quotes[0].CheckBalance(ctx, provider, payer, big.NewInt(1e17)))
+	err = quotes[0].CheckBalance(ctx, provider, payer, big.NewInt(1e18))
+	assert.True(t, errors.Is(err, sequence.ErrInsufficientFeeBalance))
+	assert.ErrorContains(t, err, "has 1 POL, requires 1.003 POL")
+	assert.NoError(t, quotes[1].CheckBalance(ctx, provider, payer, nil))
+}
@@ -0,0 +1,78 @@
+package sequence
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence/contracts"
+)
+
+// FeeOption is a fee a relayer accepts for relaying a bundle, see Relayer.FeeOptions. The fee
+// is paid by a transfer of Value of Token to To, which is appended to the bundle before it is
+// signed, see AppendFeeTransaction.
+type FeeOption struct {
+	Token FeeToken
+
+	// TokenID is the id of the token if Token is an ERC-1155 contract, nil otherwise.
+	TokenID *big.Int
+
+	To    common.Address
+	Value *big.Int
+
+	// GasLimit is the gas limit of the transfer paying the fee, 0 to estimate it.
+	GasLimit uint64
+}
+
+func (o *FeeOption) String() string {
+	return o.Token.Format(o.Value)
+}
+
+// Transaction returns the transfer paying the fee from wallet. It reverts the bundle if it
+// fails, so the bundle isn't relayed without paying the fee.
+func (o *FeeOption) Transaction(wallet common.Address) (*Transaction, error) {
+	if o.Value == nil || o.Value.Sign() < 0 {
+		return nil, fmt.Errorf("sequence: invalid fee value %v", o.Value)
+	}
+	if o.To == (common.Address{}) {
+		return nil, fmt.Errorf("sequence: fee recipient is required")
+	}
+
+	txn := &Transaction{RevertOnError: true, GasLimit: new(big.Int).SetUint64(o.GasLimit)}
+	switch {
+	case o.Token.IsGasToken():
+		txn.To = o.To
+		txn.Value = new(big.Int).Set(o.Value)
+		return txn, nil
+
+	case o.TokenID != nil:
+		data, err := contracts.IERC1155.Encode("safeTransferFrom", wallet, o.To, o.TokenID, o.Value, []byte{})
+		if err != nil {
+			return nil, fmt.Errorf("sequence: unable to encode fee transfer: %w", err)
+		}
+		txn.To, txn.Data = o.Token.Address, data
+		return txn, nil
+
+	default:
+		data, err := contracts.IERC20.Encode("transfer", o.To, o.Value)
+		if err != nil {
+			return nil, fmt.Errorf("sequence: unable to encode fee transfer: %w", err)
+		}
+		txn.To, txn.Data = o.Token.Address, data
+		return txn, nil
+	}
+}
+
+// AppendFeeTransaction returns txns followed by the transfer paying the fee of option from
+// wallet, ie. to relay txns through a relayer which requires a fee. The fee is paid last, so
+// it's only paid if txns succeed. txns isn't modified.
+func AppendFeeTransaction(wallet common.Address, txns Transactions, option *FeeOption) (Transactions, error) {
+	if option == nil {
+		return txns, nil
+	}
+	txn, err := option.Transaction(wallet)
+	if err != nil {
+		return nil, err
+	}
+	return append(txns.Clone(), txn), nil
+}
@@ -0,0 +1,55 @@
+package sequence_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendFeeTransaction(t *testing.T) {
+	wallet := common.HexToAddress("0xf0ba65550f2d1dccf4b131b774844dc3d801d886")
+	feeCollector := common.HexToAddress("0xfee")
+	usdc := sequence.FeeToken{ChainID: 137, Address: common.HexToAddress("0x70c3"), Symbol: "USDC", Decimals: 6}
+	txns := sequence.Transactions{{To: common.HexToAddress("0x7a7"), Value: big.NewInt(1)}}
+
+	// the gas token is sent
+	paid, err := sequence.AppendFeeTransaction(wallet, txns, &sequence.FeeOption{Token: sequence.GasToken(137), To: feeCollector, Value: big.NewInt(1000), GasLimit: 21000})
+	assert.NoError(t, err)
+	assert.Len(t, txns, 1)
+	if assert.Len(t, paid, 2) {
+		assert.Equal(t, feeCollector, paid[1].To)
+		assert.Equal(t, 0, paid[1].Value.Cmp(big.NewInt(1000)))
+		assert.Equal(t, 0, paid[1].GasLimit.Cmp(big.NewInt(21000)))
+		assert.True(t, paid[1].RevertOnError)
+	}
+
+	// tokens are transferred
+	paid, err = sequence.AppendFeeTransaction(wallet, txns, &sequence.FeeOption{Token: usdc, To: feeCollector, Value: big.NewInt(25)})
+	assert.NoError(t, err)
+	if assert.Len(t, paid, 2) {
+		data, err := contracts.IERC20.Encode("transfer", feeCollector, big.NewInt(25))
+		assert.NoError(t, err)
+		assert.Equal(t, usdc.Address, paid[1].To)
+		assert.Equal(t, data, paid[1].Data)
+	}
+
+	paid, err = sequence.AppendFeeTransaction(wallet, txns, &sequence.FeeOption{Token: usdc, TokenID: big.NewInt(7), To: feeCollector, Value: big.NewInt(2)})
+	assert.NoError(t, err)
+	if assert.Len(t, paid, 2) {
+		data, err := contracts.IERC1155.Encode("safeTransferFrom", wallet, feeCollector, big.NewInt(7), big.NewInt(2), []byte{})
+		assert.NoError(t, err)
+		assert.Equal(t, data, paid[1].Data)
+	}
+
+	// without a fee, txns are relayed as they are
+	paid, err = sequence.AppendFeeTransaction(wallet, txns, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, txns, paid)
+
+	_, err = sequence.AppendFeeTransaction(wallet, txns, &sequence.FeeOption{Token: usdc, Value: big.NewInt(25)})
+	assert.Error(t, err)
+}
@@ -7,12 +7,15 @@ go 1.18
 require (
 	github.com/0xsequence/ethkit v1.19.4
 	github.com/0xsequence/go-ethauth v0.13.0
+	github.com/gorilla/websocket v1.5.0
 	github.com/goware/breaker v0.1.2
 	github.com/goware/cachestore v0.5.0
 	github.com/goware/logadapter-zerolog v0.1.0
 	github.com/goware/logger v0.1.0
 	github.com/rs/zerolog v1.28.0
 	github.com/stretchr/testify v1.8.1
+	golang.org/x/crypto v0.6.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -25,7 +28,6 @@ require (
 	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.1.0 // indirect
 	github.com/go-stack/stack v1.8.1 // indirect
 	github.com/google/uuid v1.3.0 // indirect
-	github.com/gorilla/websocket v1.5.0 // indirect
 	github.com/goware/calc v0.2.0 // indirect
 	github.com/goware/channel v0.2.2 // indirect
 	github.com/goware/superr v0.0.2 // indirect
@@ -38,12 +40,10 @@ require (
 	github.com/spf13/cobra v1.6.1 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/tyler-smith/go-bip39 v1.1.0 // indirect
-	golang.org/x/crypto v0.6.0 // indirect
 	golang.org/x/exp v0.0.0-20230124195608-d38c7dcee874 // indirect
 	golang.org/x/net v0.6.0 // indirect
 	golang.org/x/sys v0.5.0 // indirect
 	golang.org/x/term v0.5.0 // indirect
 	golang.org/x/text v0.7.0 // indirect
 	gopkg.in/natefinch/npipe.v2 v2.0.0-20160621034901-c1b8fa8bdcce // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
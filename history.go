@@ -0,0 +1,407 @@
+package sequence
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+const (
+	// DefaultHistoryLimit is the page size of Wallet.History when no limit is set.
+	DefaultHistoryLimit = 50
+
+	// DefaultHistoryScanBlocks is how far back Wallet.History scans the chain when it has
+	// no HistoryIndex and no FromBlock is set.
+	DefaultHistoryScanBlocks = 100_000
+
+	historyScanWindow = 2_000
+)
+
+// HistoryEntry is a meta-transaction executed by a wallet.
+type HistoryEntry struct {
+	MetaTxnID MetaTxnID     `json:"metaTxnID"`
+	Status    MetaTxnStatus `json:"status"`
+	Reason    string        `json:"reason,omitempty"`
+
+	// Transactions are the transactions of the bundle, and Receipts their receipts.
+	Transactions Transactions `json:"transactions"`
+	Receipts     []*Receipt   `json:"-"`
+
+	TxnHash          common.Hash `json:"txnHash"`
+	BlockNumber      uint64      `json:"blockNumber"`
+	TransactionIndex uint        `json:"transactionIndex"`
+	Timestamp        time.Time   `json:"timestamp"`
+}
+
+// HistoryOptions filter and paginate Wallet.History.
+type HistoryOptions struct {
+	// Status only returns meta-transactions with one of the statuses. Empty returns any.
+	Status []MetaTxnStatus
+
+	// Since and Until bound the time of the block a meta-transaction was included in.
+	Since, Until time.Time
+
+	// Contract only returns meta-transactions which call the contract.
+	Contract *common.Address
+
+	// FromBlock and ToBlock bound the blocks which are scanned when the wallet has no
+	// HistoryIndex. ToBlock defaults to the latest block, and FromBlock to
+	// DefaultHistoryScanBlocks before it.
+	FromBlock, ToBlock uint64
+
+	// Limit is the maximum number of entries of a page, DefaultHistoryLimit if zero.
+	Limit int
+
+	// Cursor continues from the Cursor of a previous page.
+	Cursor string
+}
+
+// HistoryPage is a page of history, newest first. Cursor is empty on the last page.
+type HistoryPage struct {
+	Entries []*HistoryEntry `json:"entries"`
+	Cursor  string          `json:"cursor,omitempty"`
+}
+
+// HistoryIndex serves the history of wallets from an index of their receipts, ie. the
+// tables of a receipt indexer, instead of scanning the chain. Implementations can use
+// HistoryOptions.Match to apply the filters.
+type HistoryIndex interface {
+	History(ctx context.Context, wallet common.Address, opts HistoryOptions) (*HistoryPage, error)
+}
+
+// Match reports whether entry passes the status, time and contract filters of o.
+func (o HistoryOptions) Match(entry *HistoryEntry) bool {
+	if len(o.Status) > 0 {
+		found := false
+		for _, status := range o.Status {
+			if status == entry.Status {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !o.Since.IsZero() && entry.Timestamp.Before(o.Since) {
+		return false
+	}
+	if !o.Until.IsZero() && entry.Timestamp.After(o.Until) {
+		return false
+	}
+	if o.Contract != nil && !callsContract(entry.Transactions, *o.Contract) {
+		return false
+	}
+	return true
+}
+
+// History returns the meta-transactions executed by the wallet, newest first. It is served
+// from the HistoryIndex of the wallet if one is set, otherwise the chain is scanned for the
+// NonceChange events of the wallet, so only bundles sent through `execute` are found.
+func (w *Wallet) History(ctx context.Context, opts HistoryOptions) (*HistoryPage, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultHistoryLimit
+	}
+	if w.options.HistoryIndex != nil {
+		return w.options.HistoryIndex.History(ctx, w.address, opts)
+	}
+	if w.provider == nil {
+		return nil, fmt.Errorf("sequence.Wallet#History: %w", ErrProviderNotSet)
+	}
+
+	page, err := scanHistory(ctx, w, opts)
+	if err != nil {
+		return nil, fmt.Errorf("sequence.Wallet#History: %w", err)
+	}
+	return page, nil
+}
+
+// FindMetaTxnReceipt returns the status and receipt of a meta-transaction of the wallet which
+// was already mined. The logs of the wallet in the last DefaultHistoryScanBlocks blocks are
+// searched first, and if the log queries fail, or the meta-transaction is older than that, it
+// is located with the ReceiptFallback of the wallet, if one is set.
+func (w *Wallet) FindMetaTxnReceipt(ctx context.Context, metaTxnID MetaTxnID) (*MetaTxnResult, *types.Receipt, error) {
+	if w.provider == nil {
+		return nil, nil, fmt.Errorf("sequence.Wallet#FindMetaTxnReceipt: %w", ErrProviderNotSet)
+	}
+
+	result, receipt, err := scanMetaTxnReceipt(ctx, w, metaTxnID)
+	if err != nil && w.options.ReceiptFallback != nil {
+		w.options.Logger.Debugf("sequence.Wallet: locating metaTxnID %s with fallback: %v", metaTxnID, err)
+		result, receipt, err = FindMetaTxnReceipt(ctx, w.provider, w.options.ReceiptFallback, w.address, metaTxnID)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("sequence.Wallet#FindMetaTxnReceipt: %w", err)
+	}
+
+	if err := w.options.VerifyReceipt(ctx, w.provider, receipt); err != nil {
+		return nil, nil, fmt.Errorf("sequence.Wallet#FindMetaTxnReceipt: %w", err)
+	}
+	return result, receipt, nil
+}
+
+// scanMetaTxnReceipt searches the logs of the wallet in the last DefaultHistoryScanBlocks
+// blocks for metaTxnID, newest first.
+func scanMetaTxnReceipt(ctx context.Context, w *Wallet, metaTxnID MetaTxnID) (*MetaTxnResult, *types.Receipt, error) {
+	metaTxnHash := common.HexToHash(string(metaTxnID))
+	source := w.blockSource()
+
+	toBlock, err := w.provider.BlockNumber(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	fromBlock := uint64(0)
+	if toBlock > DefaultHistoryScanBlocks {
+		fromBlock = toBlock - DefaultHistoryScanBlocks
+	}
+
+	for end := toBlock; end >= fromBlock; {
+		start := fromBlock
+		if end-fromBlock >= historyScanWindow {
+			start = end - historyScanWindow + 1
+		}
+
+		logs, err := source.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: []common.Address{w.address},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, log := range logs {
+			if hash, ok := metaTxnHashOfLog(&log); !ok || hash != metaTxnHash || log.Removed {
+				continue
+			}
+			receipt, err := w.provider.TransactionReceipt(ctx, log.TxHash)
+			if err != nil {
+				return nil, nil, err
+			}
+			return metaTxnResultOf(metaTxnID, metaTxnHash, receipt.Logs), receipt, nil
+		}
+
+		if start <= fromBlock {
+			break
+		}
+		end = start - 1
+	}
+
+	return nil, nil, fmt.Errorf("%w: %v in the last %d blocks", ErrMetaTxnNotFound, metaTxnID, DefaultHistoryScanBlocks)
+}
+
+// blockSource returns the BlockSource of the wallet, or eth_getLogs of its provider.
+func (w *Wallet) blockSource() BlockSource {
+	if w.options.BlockSource != nil {
+		return w.options.BlockSource
+	}
+	return NewLogsBlockSource(w.provider)
+}
+
+func scanHistory(ctx context.Context, w *Wallet, opts HistoryOptions) (*HistoryPage, error) {
+	toBlock := opts.ToBlock
+	if toBlock == 0 {
+		latest, err := w.provider.BlockNumber(ctx)
+		if err != nil {
+			return nil, err
+		}
+		toBlock = latest
+	}
+	fromBlock := opts.FromBlock
+	if fromBlock == 0 && toBlock > DefaultHistoryScanBlocks {
+		fromBlock = toBlock - DefaultHistoryScanBlocks
+	}
+
+	// the cursor is the position of the last entry of the previous page
+	var cursorBlock uint64
+	var cursorIndex uint
+	if opts.Cursor != "" {
+		var err error
+		cursorBlock, cursorIndex, err = parseHistoryCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursorBlock < toBlock {
+			toBlock = cursorBlock
+		}
+	}
+
+	source := w.blockSource()
+
+	page := &HistoryPage{}
+	timestamps := map[uint64]time.Time{}
+
+	for end := toBlock; end >= fromBlock; {
+		start := fromBlock
+		if end-fromBlock >= historyScanWindow {
+			start = end - historyScanWindow + 1
+		}
+
+		logs, err := source.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: []common.Address{w.address},
+			Topics:    [][]common.Hash{{NonceChangeEventSig}},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// newest first
+		sort.SliceStable(logs, func(i, j int) bool {
+			if logs[i].BlockNumber != logs[j].BlockNumber {
+				return logs[i].BlockNumber > logs[j].BlockNumber
+			}
+			return logs[i].TxIndex > logs[j].TxIndex
+		})
+
+		seen := map[common.Hash]bool{}
+		for _, log := range logs {
+			if seen[log.TxHash] || log.Removed {
+				continue
+			}
+			seen[log.TxHash] = true
+
+			if opts.Cursor != "" && (log.BlockNumber > cursorBlock || (log.BlockNumber == cursorBlock && log.TxIndex >= cursorIndex)) {
+				continue
+			}
+
+			timestamp, ok := timestamps[log.BlockNumber]
+			if !ok {
+				header, err := w.provider.HeaderByNumber(ctx, new(big.Int).SetUint64(log.BlockNumber))
+				if err != nil {
+					return nil, err
+				}
+				timestamp = time.Unix(int64(header.Time), 0)
+				timestamps[log.BlockNumber] = timestamp
+			}
+			if !opts.Since.IsZero() && timestamp.Before(opts.Since) {
+				// blocks are scanned newest first, so there are no more matches
+				return page, nil
+			}
+
+			entries, err := historyEntries(ctx, w, log.TxHash)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range entries {
+				entry.Timestamp = timestamp
+				if !opts.Match(entry) {
+					continue
+				}
+				page.Entries = append(page.Entries, entry)
+			}
+
+			if len(page.Entries) >= opts.Limit {
+				page.Cursor = historyCursor(log.BlockNumber, log.TxIndex)
+				return page, nil
+			}
+		}
+
+		if start == 0 || start <= fromBlock {
+			break
+		}
+		end = start - 1
+	}
+
+	return page, nil
+}
+
+// historyEntries decodes the meta-transactions of the wallet in the transaction with hash.
+func historyEntries(ctx context.Context, w *Wallet, txnHash common.Hash) ([]*HistoryEntry, error) {
+	receipt, err := w.provider.TransactionReceipt(ctx, txnHash)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.options.VerifyReceipt(ctx, w.provider, receipt); err != nil {
+		return nil, err
+	}
+	receipts, _, err := DecodeReceipt(ctx, receipt, w.provider)
+	if err != nil {
+		return nil, err
+	}
+
+	// the wallet is either called directly, or by a transaction of another bundle
+	var bundles [][]*Receipt
+	if isWalletCall(ctx, w, txnHash) {
+		bundles = append(bundles, receipts)
+	} else {
+		bundles = nestedBundles(receipts, w.address, bundles)
+	}
+
+	entries := make([]*HistoryEntry, 0, len(bundles))
+	for _, bundle := range bundles {
+		if len(bundle) == 0 {
+			continue
+		}
+		entry := &HistoryEntry{
+			MetaTxnID:        bundle[0].MetaTxnID,
+			Status:           MetaTxnExecuted,
+			Receipts:         bundle,
+			TxnHash:          receipt.TxHash,
+			BlockNumber:      receipt.BlockNumber.Uint64(),
+			TransactionIndex: receipt.TransactionIndex,
+		}
+		for _, r := range bundle {
+			entry.Transactions = append(entry.Transactions, r.Transaction)
+			if entry.Status == MetaTxnExecuted && r.Status != MetaTxnExecuted {
+				entry.Status, entry.Reason = r.Status, r.Reason
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func isWalletCall(ctx context.Context, w *Wallet, txnHash common.Hash) bool {
+	txn, _, err := w.provider.TransactionByHash(ctx, txnHash)
+	return err == nil && txn.To() != nil && *txn.To() == w.address
+}
+
+func nestedBundles(receipts []*Receipt, wallet common.Address, bundles [][]*Receipt) [][]*Receipt {
+	for _, receipt := range receipts {
+		if receipt.Transaction != nil && receipt.Transaction.To == wallet && receipt.Transaction.Transactions != nil {
+			bundles = append(bundles, receipt.Receipts)
+			continue
+		}
+		bundles = nestedBundles(receipt.Receipts, wallet, bundles)
+	}
+	return bundles
+}
+
+func callsContract(txns Transactions, contract common.Address) bool {
+	for _, txn := range txns {
+		if txn.To == contract || callsContract(txn.Transactions, contract) {
+			return true
+		}
+	}
+	return false
+}
+
+func historyCursor(blockNumber uint64, txIndex uint) string {
+	return fmt.Sprintf("%d:%d", blockNumber, txIndex)
+}
+
+func parseHistoryCursor(cursor string) (uint64, uint, error) {
+	block, index, ok := strings.Cut(cursor, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid history cursor %q", cursor)
+	}
+	blockNumber, err := strconv.ParseUint(block, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid history cursor %q", cursor)
+	}
+	txIndex, err := strconv.ParseUint(index, 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid history cursor %q", cursor)
+	}
+	return blockNumber, uint(txIndex), nil
+}
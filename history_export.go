@@ -0,0 +1,248 @@
+package sequence
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// ERC20TransferEventSig is the topic of ERC20 Transfer(address,address,uint256) events.
+var ERC20TransferEventSig = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// HistoryRecordKind is the kind of value flow of a HistoryRecord.
+type HistoryRecordKind string
+
+const (
+	// HistoryRecordCall is a call of the wallet, with the native value it sent.
+	HistoryRecordCall HistoryRecordKind = "call"
+
+	// HistoryRecordTransfer is an ERC20 transfer from or to the wallet.
+	HistoryRecordTransfer HistoryRecordKind = "transfer"
+
+	// HistoryRecordFee is an ERC20 transfer from the wallet to a fee collector of the relayer.
+	HistoryRecordFee HistoryRecordKind = "fee"
+
+	// HistoryRecordGas is the native gas cost of the transaction which included a bundle,
+	// paid by the relayer's sender.
+	HistoryRecordGas HistoryRecordKind = "gas"
+)
+
+// HistoryRecord is a value flow of a meta-transaction, as exported by a HistoryExporter.
+// Amounts are signed from the point of view of the wallet: outflows are negative.
+type HistoryRecord struct {
+	Timestamp    time.Time         `json:"timestamp"`
+	BlockNumber  uint64            `json:"blockNumber"`
+	TxnHash      common.Hash       `json:"txnHash"`
+	MetaTxnID    MetaTxnID         `json:"metaTxnID"`
+	Status       string            `json:"status"`
+	Kind         HistoryRecordKind `json:"kind"`
+	Counterparty *common.Address   `json:"counterparty,omitempty"`
+	Token        *common.Address   `json:"token,omitempty"` // nil for the native token
+	Amount       *big.Int          `json:"amount"`
+	GasUsed      uint64            `json:"gasUsed,omitempty"`
+	GasPrice     *big.Int          `json:"gasPrice,omitempty"`
+}
+
+var historyCSVHeader = []string{
+	"timestamp", "block_number", "txn_hash", "meta_txn_id", "status", "kind",
+	"counterparty", "token", "amount", "gas_used", "gas_price",
+}
+
+// HistoryExporter turns the history of a wallet into records of its value flows, for
+// accounting of treasury wallets.
+type HistoryExporter struct {
+	// Wallet is the address of the wallet which the history belongs to.
+	Wallet common.Address
+
+	// FeeCollectors are the addresses which the relayer collects fee-token payments at.
+	// Transfers to them are exported as fees instead of transfers.
+	FeeCollectors []common.Address
+}
+
+func NewHistoryExporter(wallet common.Address, feeCollectors ...common.Address) *HistoryExporter {
+	return &HistoryExporter{Wallet: wallet, FeeCollectors: feeCollectors}
+}
+
+// Records returns the value flows of entries, in order.
+func (e *HistoryExporter) Records(entries []*HistoryEntry) []*HistoryRecord {
+	var records []*HistoryRecord
+	for _, entry := range entries {
+		newRecord := func(kind HistoryRecordKind, counterparty, token *common.Address, amount *big.Int) *HistoryRecord {
+			return &HistoryRecord{
+				Timestamp:    entry.Timestamp,
+				BlockNumber:  entry.BlockNumber,
+				TxnHash:      entry.TxnHash,
+				MetaTxnID:    entry.MetaTxnID,
+				Status:       entry.Status.String(),
+				Kind:         kind,
+				Counterparty: counterparty,
+				Token:        token,
+				Amount:       amount,
+			}
+		}
+
+		for _, receipt := range entry.Receipts {
+			txn := receipt.Transaction
+			if txn == nil {
+				continue
+			}
+			to := txn.To
+			amount := new(big.Int)
+			if txn.Value != nil && receipt.Status == MetaTxnExecuted {
+				amount.Neg(txn.Value)
+			}
+			records = append(records, newRecord(HistoryRecordCall, &to, nil, amount))
+
+			for _, log := range receiptLogs(receipt) {
+				from, to, value, ok := decodeERC20Transfer(log)
+				if !ok || (from != e.Wallet && to != e.Wallet) {
+					continue
+				}
+				token := log.Address
+				if from == e.Wallet {
+					kind := HistoryRecordTransfer
+					if e.isFeeCollector(to) {
+						kind = HistoryRecordFee
+					}
+					records = append(records, newRecord(kind, &to, &token, new(big.Int).Neg(value)))
+				} else {
+					records = append(records, newRecord(HistoryRecordTransfer, &from, &token, value))
+				}
+			}
+		}
+
+		if len(entry.Receipts) > 0 && entry.Receipts[0].Receipt != nil {
+			native := entry.Receipts[0].Receipt
+			record := newRecord(HistoryRecordGas, nil, nil, nil)
+			record.GasUsed = native.GasUsed
+			if native.EffectiveGasPrice != nil {
+				record.GasPrice = native.EffectiveGasPrice
+				record.Amount = new(big.Int).Mul(new(big.Int).SetUint64(native.GasUsed), native.EffectiveGasPrice)
+				record.Amount.Neg(record.Amount)
+			}
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// WriteCSV writes the value flows of entries as CSV, with a header row.
+func (e *HistoryExporter) WriteCSV(w io.Writer, entries []*HistoryEntry) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(historyCSVHeader); err != nil {
+		return fmt.Errorf("sequence: history csv: %w", err)
+	}
+	for _, record := range e.Records(entries) {
+		if err := out.Write(record.csvRow()); err != nil {
+			return fmt.Errorf("sequence: history csv: %w", err)
+		}
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return fmt.Errorf("sequence: history csv: %w", err)
+	}
+	return nil
+}
+
+// WriteJSONLines writes the value flows of entries as JSON, one record per line.
+func (e *HistoryExporter) WriteJSONLines(w io.Writer, entries []*HistoryEntry) error {
+	enc := json.NewEncoder(w)
+	for _, record := range e.Records(entries) {
+		if err := enc.Encode(record); err != nil {
+			return fmt.Errorf("sequence: history json: %w", err)
+		}
+	}
+	return nil
+}
+
+func (e *HistoryExporter) isFeeCollector(address common.Address) bool {
+	for _, collector := range e.FeeCollectors {
+		if collector == address {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON encodes amounts as decimal strings, as they may exceed the precision of
+// JSON numbers in other languages.
+func (r *HistoryRecord) MarshalJSON() ([]byte, error) {
+	type record HistoryRecord
+	optString := func(n *big.Int) *string {
+		if n == nil {
+			return nil
+		}
+		s := n.String()
+		return &s
+	}
+	return json.Marshal(&struct {
+		*record
+		Amount   *string `json:"amount"`
+		GasPrice *string `json:"gasPrice,omitempty"`
+	}{
+		record:   (*record)(r),
+		Amount:   optString(r.Amount),
+		GasPrice: optString(r.GasPrice),
+	})
+}
+
+func (r *HistoryRecord) csvRow() []string {
+	optAddress := func(address *common.Address) string {
+		if address == nil {
+			return ""
+		}
+		return address.Hex()
+	}
+	optInt := func(n *big.Int) string {
+		if n == nil {
+			return ""
+		}
+		return n.String()
+	}
+	gasUsed := ""
+	if r.GasUsed > 0 {
+		gasUsed = strconv.FormatUint(r.GasUsed, 10)
+	}
+
+	return []string{
+		r.Timestamp.UTC().Format(time.RFC3339),
+		strconv.FormatUint(r.BlockNumber, 10),
+		r.TxnHash.Hex(),
+		string(r.MetaTxnID),
+		r.Status,
+		string(r.Kind),
+		optAddress(r.Counterparty),
+		optAddress(r.Token),
+		optInt(r.Amount),
+		gasUsed,
+		optInt(r.GasPrice),
+	}
+}
+
+// receiptLogs returns the logs emitted by the transaction of receipt, including those of
+// its nested transactions.
+func receiptLogs(receipt *Receipt) []*types.Log {
+	logs := receipt.Logs
+	for _, child := range receipt.Receipts {
+		logs = append(logs, receiptLogs(child)...)
+	}
+	return logs
+}
+
+func decodeERC20Transfer(log *types.Log) (common.Address, common.Address, *big.Int, bool) {
+	// ERC721 transfers share the signature, but index the token id
+	if len(log.Topics) != 3 || log.Topics[0] != ERC20TransferEventSig || len(log.Data) != 32 {
+		return common.Address{}, common.Address{}, nil, false
+	}
+	from := common.BytesToAddress(log.Topics[1].Bytes())
+	to := common.BytesToAddress(log.Topics[2].Bytes())
+	return from, to, new(big.Int).SetBytes(log.Data), true
+}
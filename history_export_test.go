@@ -0,0 +1,93 @@
+package sequence_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryExporter(t *testing.T) {
+	wallet := common.HexToAddress("0xaa")
+	recipient := common.HexToAddress("0xbb")
+	collector := common.HexToAddress("0xfee")
+	token := common.HexToAddress("0xcc")
+
+	transfer := func(from, to common.Address, amount int64) *types.Log {
+		return &types.Log{
+			Address: token,
+			Topics:  []common.Hash{sequence.ERC20TransferEventSig, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+			Data:    common.BigToHash(big.NewInt(amount)).Bytes(),
+		}
+	}
+
+	native := &types.Receipt{GasUsed: 21000, EffectiveGasPrice: big.NewInt(2)}
+	entry := &sequence.HistoryEntry{
+		MetaTxnID:   "01",
+		Status:      sequence.MetaTxnExecuted,
+		TxnHash:     common.HexToHash("0x1234"),
+		BlockNumber: 10,
+		Timestamp:   time.Unix(1700000000, 0),
+		Receipts: []*sequence.Receipt{
+			{
+				Receipt:     native,
+				Status:      sequence.MetaTxnExecuted,
+				Transaction: &sequence.Transaction{To: recipient, Value: big.NewInt(5)},
+			},
+			{
+				Receipt:     native,
+				Status:      sequence.MetaTxnExecuted,
+				Transaction: &sequence.Transaction{To: token, Value: big.NewInt(0)},
+				Logs: []*types.Log{
+					transfer(wallet, recipient, 100),
+					transfer(wallet, collector, 3),
+					transfer(recipient, wallet, 7),
+					transfer(recipient, collector, 1), // not a flow of the wallet
+				},
+			},
+		},
+	}
+
+	exporter := sequence.NewHistoryExporter(wallet, collector)
+	records := exporter.Records([]*sequence.HistoryEntry{entry})
+	assert.Len(t, records, 6)
+
+	kinds := []sequence.HistoryRecordKind{}
+	amounts := []string{}
+	for _, record := range records {
+		kinds = append(kinds, record.Kind)
+		amounts = append(amounts, record.Amount.String())
+	}
+	assert.Equal(t, []sequence.HistoryRecordKind{
+		sequence.HistoryRecordCall, sequence.HistoryRecordCall, sequence.HistoryRecordTransfer,
+		sequence.HistoryRecordFee, sequence.HistoryRecordTransfer, sequence.HistoryRecordGas,
+	}, kinds)
+	assert.Equal(t, []string{"-5", "0", "-100", "-3", "7", "-42000"}, amounts)
+	assert.Equal(t, recipient, *records[4].Counterparty)
+	assert.Equal(t, token, *records[4].Token)
+
+	var buf bytes.Buffer
+	assert.NoError(t, exporter.WriteCSV(&buf, []*sequence.HistoryEntry{entry}))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 7)
+	assert.Equal(t, "kind", rows[0][5])
+	assert.Equal(t, []string{"2023-11-14T22:13:20Z", "10", entry.TxnHash.Hex(), "01", "executed", "gas", "", "", "-42000", "21000", "2"}, rows[6])
+
+	buf.Reset()
+	assert.NoError(t, exporter.WriteJSONLines(&buf, []*sequence.HistoryEntry{entry}))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 6)
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[3]), &record))
+	assert.Equal(t, "fee", record["kind"])
+	assert.Equal(t, "-3", record["amount"])
+}
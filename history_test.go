@@ -0,0 +1,101 @@
+package sequence_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalletHistory(t *testing.T) {
+	wallet, err := testChain.DummySequenceWallet(1)
+	assert.NoError(t, err)
+
+	callmockContract := testChain.UniDeploy(t, "WALLET_CALL_RECV_MOCK", 0)
+	for i := int64(1); i <= 3; i++ {
+		calldata, err := callmockContract.Encode("testCall", big.NewInt(i), ethcoder.MustHexDecode("0x112255"))
+		assert.NoError(t, err)
+		assert.NoError(t, testutil.SignAndSend(t, wallet, callmockContract.Address, calldata))
+	}
+
+	// paginate the calls to the contract, newest first
+	opts := sequence.HistoryOptions{Contract: &callmockContract.Address, Limit: 2}
+	page, err := wallet.History(context.Background(), opts)
+	assert.NoError(t, err)
+	assert.Len(t, page.Entries, 2)
+	assert.NotEmpty(t, page.Cursor)
+	assert.True(t, page.Entries[0].BlockNumber > page.Entries[1].BlockNumber)
+	for _, entry := range page.Entries {
+		assert.Equal(t, sequence.MetaTxnExecuted, entry.Status)
+		assert.Equal(t, callmockContract.Address, entry.Transactions[0].To)
+	}
+
+	opts.Cursor = page.Cursor
+	next, err := wallet.History(context.Background(), opts)
+	assert.NoError(t, err)
+	assert.Len(t, next.Entries, 1)
+	assert.True(t, next.Entries[0].BlockNumber < page.Entries[1].BlockNumber)
+
+	page, err = wallet.History(context.Background(), sequence.HistoryOptions{Status: []sequence.MetaTxnStatus{sequence.MetaTxnFailed}})
+	assert.NoError(t, err)
+	assert.Empty(t, page.Entries)
+	assert.Empty(t, page.Cursor)
+}
+
+type historyIndex struct {
+	entries []*sequence.HistoryEntry
+}
+
+func (i *historyIndex) History(ctx context.Context, wallet common.Address, opts sequence.HistoryOptions) (*sequence.HistoryPage, error) {
+	page := &sequence.HistoryPage{}
+	for _, entry := range i.entries {
+		if opts.Match(entry) && len(page.Entries) < opts.Limit {
+			page.Entries = append(page.Entries, entry)
+		}
+	}
+	return page, nil
+}
+
+func TestWalletHistoryIndex(t *testing.T) {
+	contract := common.HexToAddress("0x1234")
+	now := time.Now()
+	index := &historyIndex{entries: []*sequence.HistoryEntry{
+		{MetaTxnID: "03", Status: sequence.MetaTxnFailed, Timestamp: now, Transactions: sequence.Transactions{{To: contract}}},
+		{MetaTxnID: "02", Status: sequence.MetaTxnExecuted, Timestamp: now.Add(-time.Hour), Transactions: sequence.Transactions{{To: common.HexToAddress("0x01")}}},
+		{MetaTxnID: "01", Status: sequence.MetaTxnExecuted, Timestamp: now.Add(-2 * time.Hour), Transactions: sequence.Transactions{
+			{To: common.HexToAddress("0x01"), Transactions: sequence.Transactions{{To: contract}}},
+		}},
+	}}
+
+	eoa, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWallet(sequence.WalletOptions{
+		Config:  sequence.WalletConfig{Threshold: 1, Signers: sequence.WalletConfigSigners{{Weight: 1, Address: eoa.Address()}}},
+		Options: []sequence.Option{sequence.WithHistoryIndex(index)},
+	}, eoa)
+	assert.NoError(t, err)
+
+	ids := func(opts sequence.HistoryOptions) []sequence.MetaTxnID {
+		page, err := wallet.History(context.Background(), opts)
+		assert.NoError(t, err)
+		var ids []sequence.MetaTxnID
+		for _, entry := range page.Entries {
+			ids = append(ids, entry.MetaTxnID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []sequence.MetaTxnID{"03", "02", "01"}, ids(sequence.HistoryOptions{}))
+	assert.Equal(t, []sequence.MetaTxnID{"03"}, ids(sequence.HistoryOptions{Limit: 1}))
+	assert.Equal(t, []sequence.MetaTxnID{"02", "01"}, ids(sequence.HistoryOptions{Status: []sequence.MetaTxnStatus{sequence.MetaTxnExecuted}}))
+	assert.Equal(t, []sequence.MetaTxnID{"03", "01"}, ids(sequence.HistoryOptions{Contract: &contract}))
+	assert.Equal(t, []sequence.MetaTxnID{"03", "02"}, ids(sequence.HistoryOptions{Since: now.Add(-90 * time.Minute)}))
+	assert.Equal(t, []sequence.MetaTxnID{"02"}, ids(sequence.HistoryOptions{Since: now.Add(-90 * time.Minute), Until: now.Add(-time.Minute)}))
+}
@@ -0,0 +1,64 @@
+package sequence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// ErrAlreadyExecuted is the error of a bundle of an idempotency key whose nonce was already
+// executed by the wallet.
+var ErrAlreadyExecuted = errors.New("sequence: bundle of idempotency key is already executed")
+
+// IdempotencyNonceSpace returns the nonce space derived from the idempotency key of a job, the
+// first 160 bits of its keccak256 hash.
+//
+// The bundles of a job are signed in the nonce space of its key, at the nonces of their steps,
+// see IdempotencyNonce, so a job re-run, ie. after a restart, signs the same nonces, which the
+// wallet executes only once.
+func IdempotencyNonceSpace(key string) *big.Int {
+	hash := crypto.Keccak256([]byte(key))
+	return new(big.Int).SetBytes(hash[:20])
+}
+
+// IdempotencyNonce returns the nonce of step of the job of key, in the nonce space of key and
+// encoded with it, see EncodeNonce.
+func IdempotencyNonce(key string, step uint64) *big.Int {
+	nonce := new(big.Int).Lsh(IdempotencyNonceSpace(key), 96)
+	return nonce.Add(nonce, new(big.Int).SetUint64(step))
+}
+
+// IdempotentNonce returns the nonce of step of the job of key, see IdempotencyNonce, after
+// reading the nonce of the wallet in the nonce space of key. It fails with ErrAlreadyExecuted
+// if the wallet executed the step already.
+//
+// A step relayed but not yet executed is not reported, its bundle can be relayed again as the
+// wallet executes only one of them.
+func (w *Wallet) IdempotentNonce(key string, step uint64) (*big.Int, error) {
+	space := IdempotencyNonceSpace(key)
+	current, err := w.GetNonceInSpace(space)
+	if err != nil {
+		return nil, fmt.Errorf("sequence: failed to read nonce of idempotency key %q: %w", key, err)
+	}
+
+	// the sequence of the space, whether or not the nonce read is encoded with it
+	_, sequence := DecodeNonce(current)
+	if sequence.Cmp(new(big.Int).SetUint64(step)) > 0 {
+		return nil, fmt.Errorf("%w: %q step %d", ErrAlreadyExecuted, key, step)
+	}
+	return IdempotencyNonce(key, step), nil
+}
+
+// SignIdempotentTransactions signs txns as the bundle of step of the job of key, see
+// IdempotentNonce. Steps are executed in order, a step signed before the previous steps are
+// executed waits for them.
+func (w *Wallet) SignIdempotentTransactions(ctx context.Context, key string, step uint64, txns Transactions) (*SignedTransactions, error) {
+	nonce, err := w.IdempotentNonce(key, step)
+	if err != nil {
+		return nil, err
+	}
+	return w.SignTransactionsWithNonce(ctx, txns, nonce)
+}
@@ -0,0 +1,25 @@
+package sequence_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/go-sequence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyNonce(t *testing.T) {
+	space := sequence.IdempotencyNonceSpace("payout-1")
+	assert.Equal(t, space, sequence.IdempotencyNonceSpace("payout-1"))
+	assert.NotEqual(t, space, sequence.IdempotencyNonceSpace("payout-2"))
+	assert.True(t, space.BitLen() <= 160)
+
+	nonce := sequence.IdempotencyNonce("payout-1", 3)
+	encoded, err := sequence.EncodeNonce(space, big.NewInt(3))
+	assert.NoError(t, err)
+	assert.Equal(t, encoded, nonce)
+
+	decodedSpace, step := sequence.DecodeNonce(nonce)
+	assert.Equal(t, space, decodedSpace)
+	assert.Equal(t, int64(3), step.Int64())
+}
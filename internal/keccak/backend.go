@@ -0,0 +1,96 @@
+package keccak
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// Backend creates the keccak256 states hashers write to, ie. of an assembly or SIMD keccak
+// implementation for digest-heavy workloads.
+type Backend interface {
+	NewState() crypto.KeccakState
+}
+
+// BackendFunc is a Backend function.
+type BackendFunc func() crypto.KeccakState
+
+func (f BackendFunc) NewState() crypto.KeccakState {
+	return f()
+}
+
+// DefaultBackend is the keccak256 of go-ethereum, ie. of golang.org/x/crypto/sha3.
+var DefaultBackend Backend = BackendFunc(crypto.NewKeccakState)
+
+// pool is the *sync.Pool of hashers of the current backend. Hashers are returned to the pool
+// they are from, so hashers of a previous backend are never reused.
+var pool atomic.Value
+
+func init() {
+	pool.Store(newPool(DefaultBackend))
+}
+
+func newPool(backend Backend) *sync.Pool {
+	p := &sync.Pool{}
+	p.New = func() interface{} {
+		return &Hasher{state: backend.NewState(), pool: p}
+	}
+	return p
+}
+
+// SetBackend sets the backend of the hashers, once it hashes the same as DefaultBackend, see
+// CheckBackend. It is meant to be called once, ie. from the init of a package imported only
+// with the build tags of the backend.
+func SetBackend(backend Backend) error {
+	if err := CheckBackend(backend); err != nil {
+		return err
+	}
+	pool.Store(newPool(backend))
+	return nil
+}
+
+// CheckBackend cross-checks backend against DefaultBackend, hashing inputs of up to several
+// blocks, written at once and in pieces, with states which are reset and reused.
+func CheckBackend(backend Backend) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("keccak: backend panicked: %v", r)
+		}
+	}()
+
+	input := make([]byte, 3*136+1)
+	for i := range input {
+		input[i] = byte(i * 7)
+	}
+
+	state := backend.NewState()
+	if state == nil {
+		return fmt.Errorf("keccak: backend returned a nil state")
+	}
+	expected := DefaultBackend.NewState()
+	var sum, expectedSum [32]byte
+
+	for n := 0; n <= len(input); n++ {
+		for _, pieces := range []int{1, 3} {
+			state.Reset()
+			expected.Reset()
+			for i := 0; i < pieces; i++ {
+				piece := input[n*i/pieces : n*(i+1)/pieces]
+				state.Write(piece)
+				expected.Write(piece)
+			}
+			state.Read(sum[:])
+			expected.Read(expectedSum[:])
+			if sum != expectedSum {
+				return fmt.Errorf("keccak: backend hashes %d bytes to %x, expected %x", n, sum, expectedSum)
+			}
+			if digest := state.Sum(nil); !bytes.Equal(digest, expected.Sum(nil)) {
+				return fmt.Errorf("keccak: backend sums %d bytes to %x, expected %x", n, digest, expected.Sum(nil))
+			}
+		}
+	}
+	return nil
+}
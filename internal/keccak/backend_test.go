@@ -0,0 +1,85 @@
+package keccak_test
+
+import (
+	"hash"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence/internal/keccak"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/sha3"
+)
+
+// countingState counts the writes to a keccak256 state, to tell which backend hashed.
+type countingState struct {
+	crypto.KeccakState
+	writes *int
+}
+
+func (s countingState) Write(b []byte) (int, error) {
+	*s.writes++
+	return s.KeccakState.Write(b)
+}
+
+// sha3State is a sha3-256 state, which is not the keccak256 of ethereum as it predates the
+// padding of sha3.
+type sha3State struct {
+	hash.Hash
+}
+
+func (s sha3State) Read(b []byte) (int, error) {
+	return copy(b, s.Sum(nil)), nil
+}
+
+func newSHA3State() crypto.KeccakState {
+	return sha3State{sha3.New256()}
+}
+
+// unresettableState is a broken state, which is not reset.
+type unresettableState struct {
+	crypto.KeccakState
+}
+
+func (unresettableState) Reset() {}
+
+func TestCheckBackend(t *testing.T) {
+	assert.NoError(t, keccak.CheckBackend(keccak.DefaultBackend))
+
+	err := keccak.CheckBackend(keccak.BackendFunc(newSHA3State))
+	assert.ErrorContains(t, err, "backend hashes 0 bytes")
+
+	err = keccak.CheckBackend(keccak.BackendFunc(func() crypto.KeccakState {
+		return unresettableState{crypto.NewKeccakState()}
+	}))
+	assert.ErrorContains(t, err, "panicked")
+
+	err = keccak.CheckBackend(keccak.BackendFunc(func() crypto.KeccakState { return nil }))
+	assert.Error(t, err)
+}
+
+func TestSetBackend(t *testing.T) {
+	defer keccak.SetBackend(keccak.DefaultBackend)
+
+	var writes int
+	err := keccak.SetBackend(keccak.BackendFunc(func() crypto.KeccakState {
+		return countingState{KeccakState: crypto.NewKeccakState(), writes: &writes}
+	}))
+	assert.NoError(t, err)
+
+	writes = 0
+	data := []byte("hello sequence")
+	assert.Equal(t, crypto.Keccak256Hash(data, data), keccak.Sum256(data, data))
+	assert.Equal(t, 2, writes)
+
+	h := keccak.Get()
+	h.WriteUint64(7)
+	assert.Equal(t, crypto.Keccak256Hash(make([]byte, 31), []byte{7}), h.Sum())
+	keccak.Put(h)
+	assert.Equal(t, 3, writes)
+
+	// a broken backend is not set
+	err = keccak.SetBackend(keccak.BackendFunc(newSHA3State))
+	assert.Error(t, err)
+	assert.Equal(t, crypto.Keccak256Hash(data), keccak.Sum256(data))
+	assert.Equal(t, 4, writes)
+}
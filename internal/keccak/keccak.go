@@ -0,0 +1,113 @@
+// Package keccak hashes with pooled keccak256 states, writing ABI words straight into the
+// state, so hot paths computing digests of millions of bundles do not allocate. The states are
+// of a pluggable Backend, see SetBackend.
+package keccak
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/math"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// Hasher is a keccak256 state, with a scratch word the values hashed are encoded in.
+type Hasher struct {
+	state crypto.KeccakState
+	word  [32]byte
+	pool  *sync.Pool
+}
+
+// Get returns a reset hasher from the pool, which must be returned with Put once summed.
+func Get() *Hasher {
+	return pool.Load().(*sync.Pool).Get().(*Hasher)
+}
+
+// Put resets h, and returns it to the pool.
+func Put(h *Hasher) {
+	h.state.Reset()
+	h.pool.Put(h)
+}
+
+// Sum256 returns the keccak256 hash of the concatenation of data.
+func Sum256(data ...[]byte) common.Hash {
+	h := Get()
+	defer Put(h)
+	for _, b := range data {
+		h.state.Write(b)
+	}
+	return h.Sum()
+}
+
+// Write hashes b as is.
+func (h *Hasher) Write(b []byte) {
+	h.state.Write(b)
+}
+
+// WriteWord hashes a 32 bytes word.
+func (h *Hasher) WriteWord(word common.Hash) {
+	h.word = word
+	h.state.Write(h.word[:])
+}
+
+// WriteUint256 hashes x as a uint256 word, in two's complement if it is negative as the
+// ABI encoder does. A nil x is 0.
+func (h *Hasher) WriteUint256(x *big.Int) {
+	switch {
+	case x == nil:
+		h.word = [32]byte{}
+	case x.Sign() >= 0 && x.BitLen() <= 256:
+		x.FillBytes(h.word[:])
+	default:
+		copy(h.word[:], math.U256Bytes(new(big.Int).Set(x)))
+	}
+	h.state.Write(h.word[:])
+}
+
+// WriteUint64 hashes n as a uint256 word.
+func (h *Hasher) WriteUint64(n uint64) {
+	h.word = [32]byte{}
+	for i := 31; n > 0; i-- {
+		h.word[i] = byte(n)
+		n >>= 8
+	}
+	h.state.Write(h.word[:])
+}
+
+// WriteBool hashes b as a word.
+func (h *Hasher) WriteBool(b bool) {
+	h.word = [32]byte{}
+	if b {
+		h.word[31] = 1
+	}
+	h.state.Write(h.word[:])
+}
+
+// WriteAddress hashes address as a left padded word.
+func (h *Hasher) WriteAddress(address common.Address) {
+	h.word = [32]byte{}
+	copy(h.word[12:], address[:])
+	h.state.Write(h.word[:])
+}
+
+// WritePackedAddress hashes the 20 bytes of address, as abi.encodePacked encodes it.
+func (h *Hasher) WritePackedAddress(address common.Address) {
+	copy(h.word[:], address[:])
+	h.state.Write(h.word[:common.AddressLength])
+}
+
+// WritePadded hashes b right padded to a multiple of 32 bytes, as the ABI encodes bytes.
+func (h *Hasher) WritePadded(b []byte) {
+	h.state.Write(b)
+	if rem := len(b) % 32; rem != 0 {
+		h.word = [32]byte{}
+		h.state.Write(h.word[:32-rem])
+	}
+}
+
+// Sum returns the hash of what was written.
+func (h *Hasher) Sum() common.Hash {
+	h.state.Read(h.word[:])
+	return h.word
+}
@@ -0,0 +1,42 @@
+package keccak_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence/internal/keccak"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasher(t *testing.T) {
+	address := common.HexToAddress("0x7a7")
+	data := []byte("hello sequence")
+	packed, err := ethcoder.AbiCoder(
+		[]string{"uint256", "uint256", "uint256", "bool", "address", "bytes32"},
+		[]interface{}{big.NewInt(-1), big.NewInt(1e18), big.NewInt(7), true, address, common.HexToHash("0x1")},
+	)
+	assert.NoError(t, err)
+	packed = append(packed, address.Bytes()...)
+	packed = append(packed, common.RightPadBytes(data, 32)...)
+
+	h := keccak.Get()
+	h.WriteUint256(big.NewInt(-1))
+	h.WriteUint256(big.NewInt(1e18))
+	h.WriteUint64(7)
+	h.WriteBool(true)
+	h.WriteAddress(address)
+	h.WriteWord(common.HexToHash("0x1"))
+	h.WritePackedAddress(address)
+	h.WritePadded(data)
+	assert.Equal(t, crypto.Keccak256Hash(packed), h.Sum())
+	keccak.Put(h)
+
+	// hashers are reset as they are returned to the pool
+	h = keccak.Get()
+	defer keccak.Put(h)
+	h.Write(data)
+	assert.Equal(t, crypto.Keccak256Hash(data), h.Sum())
+}
@@ -0,0 +1,30 @@
+package sequence
+
+import (
+	"github.com/0xsequence/go-sequence/internal/keccak"
+)
+
+// KeccakBackend creates the keccak256 states which the digests of transactions, messages and
+// subdigests are hashed with.
+type KeccakBackend = keccak.Backend
+
+// KeccakBackendFunc is a KeccakBackend function.
+type KeccakBackendFunc = keccak.BackendFunc
+
+// SetKeccakBackend plugs an optimized keccak256 implementation, ie. of assembly or SIMD, for
+// digest-heavy workloads. The backend is cross-checked against the go-ethereum keccak256
+// first, and is not set if it hashes differently.
+//
+// A backend is selected at build time by registering it from the init of a package which is
+// only imported with the build tags of the backend, ie.
+//
+//	//go:build keccak_simd
+//
+//	func init() {
+//		if err := sequence.SetKeccakBackend(simd.Backend); err != nil {
+//			panic(err)
+//		}
+//	}
+func SetKeccakBackend(backend KeccakBackend) error {
+	return keccak.SetBackend(backend)
+}
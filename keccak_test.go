@@ -0,0 +1,37 @@
+package sequence_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetKeccakBackend(t *testing.T) {
+	txns := digestTransactions()
+	address := common.HexToAddress("0x7a7")
+	digests := func() []common.Hash {
+		execDigest, err := sequence.ComputeWalletExecDigest(big.NewInt(7), txns)
+		assert.NoError(t, err)
+		_, subDigest, err := sequence.ComputeMetaTxnIDFromDigest(big.NewInt(1), address, execDigest)
+		assert.NoError(t, err)
+		return []common.Hash{execDigest, subDigest, sequence.MessageDigest([]byte("hello sequence"))}
+	}
+	expected := digests()
+
+	// the digests are the same with another backend, which hashes them
+	var states int
+	err := sequence.SetKeccakBackend(sequence.KeccakBackendFunc(func() crypto.KeccakState {
+		states++
+		return crypto.NewKeccakState()
+	}))
+	assert.NoError(t, err)
+	defer sequence.SetKeccakBackend(sequence.KeccakBackendFunc(crypto.NewKeccakState))
+
+	states = 0
+	assert.Equal(t, expected, digests())
+	assert.Greater(t, states, 0)
+}
@@ -4,22 +4,31 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/big"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/0xsequence/ethkit/ethmonitor"
 	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
 	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/0xsequence/ethkit/go-ethereum/core/types"
 	"github.com/goware/breaker"
 	"github.com/goware/logadapter-zerolog"
-	"github.com/goware/logger"
 	"github.com/rs/zerolog"
 )
 
 const (
 	legacyMaxConcurrentFetchReceipts = 30
 	legacyPastReceiptsBufSize        = 4096
+
+	legacyFetchReceiptTimeout = 2 * time.Minute
+	legacyCheckpointKey       = "legacyReceiptListener::checkpoint"
+
+	// legacyBackfillBlockRange is the number of blocks whose logs are fetched at once when
+	// resuming from a checkpoint
+	legacyBackfillBlockRange = 100
 )
 
 // NOTE: LegacyReceiptListener is older implementation of ReceiptListener,
@@ -33,15 +42,53 @@ type LegacyReceiptListener struct {
 	monitor  *ethmonitor.Monitor
 	br       *breaker.Breaker
 
-	receiptsSem chan struct{}
+	limits ReceiptListenerLimits
+
+	// fetchQueue are the native txns whose receipts are to be fetched, drained by
+	// legacyMaxConcurrentFetchReceipts workers while the listener runs
+	fetchQueue *boundedQueue[*receiptFetch]
+
+	// pastReceipts are the most recent receipts, which are indexed by metaTxnID in
+	// pastReceiptsIndex, and pastReceiptsSizes their estimated sizes in bytes
+	pastReceipts      []BlockOfReceipts
+	pastReceiptsSizes []int64
+	pastReceiptsBytes int64
+	pastReceiptsIndex *MetaTxnIndex[*ReceiptResult]
+	muPastReceipts    sync.Mutex
+
+	// waiters are the subscribers indexed by the metaTxnID they wait for, so receipts are only
+	// sent to the subscribers waiting for them
+	waiters *MetaTxnIndex[*subscriber]
+
+	// inflight tracks the native txn hashes whose receipts are still being fetched
+	inflight   map[common.Hash]struct{}
+	inflightWg sync.WaitGroup
+	muInflight sync.Mutex
+
+	// pendingBlocks counts the fetches in flight by block number, and handled is the last
+	// block handled, so the checkpoint never passes a block whose receipts aren't fetched yet.
+	// Both are guarded by muInflight.
+	pendingBlocks map[uint64]int
+	handled       uint64
 
-	pastReceipts   []BlockOfReceipts
-	muPastReceipts sync.Mutex
+	checkpoint      uint64
+	checkpointStore CheckpointStore
 
-	subscribers   []*subscriber
-	muSubscribers sync.Mutex
+	// wallets are the addresses whose logs are backfilled, if known
+	wallets []common.Address
+
+	options Options
+
+	ctxStop context.CancelFunc
+	muRun   sync.Mutex
+	running int32
 }
 
+var (
+	_ Runnable = &LegacyReceiptListener{}
+	_ Drainer  = &LegacyReceiptListener{}
+)
+
 type ReceiptResult struct {
 	MetaTxnID  MetaTxnID
 	Results    []*LegacyMetaTxnResult
@@ -55,14 +102,40 @@ type LegacyMetaTxnResult struct {
 
 type BlockOfReceipts []ReceiptResult
 
+// ReceiptListenerLimits bound the memory a LegacyReceiptListener buffers, ie. while it
+// backfills a range of blocks faster than their receipts can be fetched, or for subscribers
+// which fall behind. Zero values are unbounded.
+type ReceiptListenerLimits struct {
+	// MaxBufferedBytes bounds the estimated size of the logs and receipts held by the listener,
+	// both queued to be fetched and kept as past receipts. Past receipts are evicted first to
+	// stay within it.
+	MaxBufferedBytes int64
+
+	// MaxQueuedFetches bounds the number of native txns queued for their receipts to be
+	// fetched.
+	MaxQueuedFetches int
+
+	// MaxSubscriberQueue bounds the number of receipts queued for each subscriber.
+	MaxSubscriberQueue int
+
+	// Backpressure is applied when a queue is full. With BackpressureError, Run returns
+	// ErrQueueFull, and the subscribers which fall behind fail with it.
+	Backpressure BackpressurePolicy
+}
+
 type subscriber struct {
-	ch          <-chan ReceiptResult
-	sendCh      chan<- ReceiptResult
-	done        chan struct{}
+	queue       *boundedQueue[ReceiptResult]
 	unsubscribe func()
 }
 
-func NewLegacyReceiptListener(log zerolog.Logger, provider *ethrpc.Provider, monitor *ethmonitor.Monitor) (*LegacyReceiptListener, error) {
+type receiptFetch struct {
+	txHash   common.Hash
+	block    uint64
+	receipts []ReceiptResult
+	size     int64
+}
+
+func NewLegacyReceiptListener(log zerolog.Logger, provider *ethrpc.Provider, monitor *ethmonitor.Monitor, opts ...Option) (*LegacyReceiptListener, error) {
 	if !monitor.Options().WithLogs {
 		return nil, fmt.Errorf("ReceiptListener needs a monitor with WithLogs enabled to function")
 	}
@@ -74,16 +147,93 @@ func NewLegacyReceiptListener(log zerolog.Logger, provider *ethrpc.Provider, mon
 		provider:     provider,
 		monitor:      monitor,
 		br:           breaker.New(logadapter.LogAdapter(log), time.Second, 2, 10),
-		receiptsSem:  make(chan struct{}, legacyMaxConcurrentFetchReceipts),
 		pastReceipts: make([]BlockOfReceipts, 0),
-		subscribers:  make([]*subscriber, 0),
+		inflight:     map[common.Hash]struct{}{},
+		options:      NewOptions(opts...),
+
+		pastReceiptsIndex: NewMetaTxnIndex[*ReceiptResult](),
+		waiters:           NewMetaTxnIndex[*subscriber](),
+		pendingBlocks:     map[uint64]int{},
 	}, nil
 }
 
+// SetCheckpointStore sets the store the listener persists its last processed block number to,
+// and loads the previously persisted checkpoint, if any, which Run resumes from.
+func (l *LegacyReceiptListener) SetCheckpointStore(store CheckpointStore) *LegacyReceiptListener {
+	l.checkpointStore = store
+	if store != nil {
+		if checkpoint, ok, err := store.Get(context.Background(), legacyCheckpointKey); err == nil && ok {
+			atomic.StoreUint64(&l.checkpoint, checkpoint)
+		}
+	}
+	return l
+}
+
+// SetWallets sets the wallets whose meta-transactions are backfilled from the checkpoint, so
+// only their logs are fetched. Otherwise the native txns which executed meta-transactions of
+// any wallet are found by their NonceChange events. It must be called before Run.
+func (l *LegacyReceiptListener) SetWallets(wallets ...common.Address) *LegacyReceiptListener {
+	l.wallets = wallets
+	return l
+}
+
+// SetLimits sets the bounds of the queues and of the memory of the listener, and what it does
+// when they are reached. It must be called before Run.
+func (l *LegacyReceiptListener) SetLimits(limits ReceiptListenerLimits) *LegacyReceiptListener {
+	l.limits = limits
+	return l
+}
+
+// BufferedBytes returns the estimated size of the logs and receipts held by the listener.
+func (l *LegacyReceiptListener) BufferedBytes() int64 {
+	l.muPastReceipts.Lock()
+	defer l.muPastReceipts.Unlock()
+	return l.pastReceiptsBytes + l.queuedBytes()
+}
+
+// Checkpoint returns the last block number which the listener has processed.
+func (l *LegacyReceiptListener) Checkpoint() uint64 {
+	return atomic.LoadUint64(&l.checkpoint)
+}
+
+// Run handles the blocks of the monitor until ctx is done or the listener is stopped. With a
+// checkpoint, ie. when the listener is run again, or loaded from its checkpoint store, the
+// blocks mined since the checkpoint are handled first, so none are missed.
 func (l *LegacyReceiptListener) Run(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&l.running, 0, 1) {
+		return fmt.Errorf("ReceiptListener: already running")
+	}
+	defer atomic.StoreInt32(&l.running, 0)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	l.muRun.Lock()
+	l.ctxStop = cancel
+	l.muRun.Unlock()
+
+	// the fetchers of a previous run may still be measuring the queue they drain
+	fetchQueue := newBoundedQueue(l.limits.MaxQueuedFetches, l.limits.MaxBufferedBytes, l.limits.Backpressure, l.dropFetch)
+	l.muPastReceipts.Lock()
+	l.fetchQueue = fetchQueue
+	l.muPastReceipts.Unlock()
+	for i := 0; i < legacyMaxConcurrentFetchReceipts; i++ {
+		go l.fetchReceipts(fetchQueue)
+	}
+	// the receipts already queued are still fetched, so they may be drained
+	defer fetchQueue.close(nil)
+
 	sub := l.monitor.Subscribe()
 	defer sub.Unsubscribe()
 
+	// the monitor publishes the blocks mined from now on, which may include backfilled ones
+	backfilled, err := l.backfill(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("ReceiptListener: %w", err)
+	}
+
 	for {
 		select {
 
@@ -97,300 +247,447 @@ func (l *LegacyReceiptListener) Run(ctx context.Context) error {
 
 		case blocks := <-sub.Blocks():
 			for _, block := range blocks {
-				l.handleBlock(ctx, block)
+				if hash, ok := backfilled[block.NumberU64()]; ok && hash == block.Hash() {
+					continue
+				}
+				err := l.handleBlock(ctx, block)
+				if err != nil && ctx.Err() == nil {
+					return fmt.Errorf("ReceiptListener: %w", err)
+				}
+			}
+		}
+	}
+}
+
+// backfill handles the blocks from the one after the checkpoint up to the head of the chain,
+// if there is a checkpoint, and returns the hashes of the blocks with logs it handled by
+// number.
+func (l *LegacyReceiptListener) backfill(ctx context.Context) (map[uint64]common.Hash, error) {
+	backfilled := map[uint64]common.Hash{}
+
+	checkpoint := l.Checkpoint()
+	if checkpoint == 0 {
+		return backfilled, nil
+	}
+
+	var head uint64
+	err := l.br.Do(ctx, func() error {
+		var err error
+		head, err = l.provider.BlockNumber(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch head to backfill from block %d: %w", checkpoint+1, err)
+	}
+
+	for from := checkpoint + 1; from <= head; from += legacyBackfillBlockRange {
+		to := from + legacyBackfillBlockRange - 1
+		if to > head {
+			to = head
+		}
+
+		logs, err := l.backfillLogs(ctx, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("unable to backfill blocks %d to %d: %w", from, to, err)
+		}
+
+		// the logs are handled by block, as the monitor publishes them
+		for len(logs) > 0 {
+			n := 1
+			for n < len(logs) && logs[n].BlockHash == logs[0].BlockHash {
+				n++
+			}
+			number, hash := logs[0].BlockNumber, logs[0].BlockHash
+			block := &ethmonitor.Block{
+				Block: types.NewBlockWithHeader(&types.Header{Number: new(big.Int).SetUint64(number)}),
+				Event: ethmonitor.Added,
+				Logs:  logs[:n],
+			}
+			if err := l.handleBlock(ctx, block); err != nil {
+				return nil, err
 			}
+			backfilled[number] = hash
+			logs = logs[n:]
 		}
+
+		l.markHandled(to)
+	}
+
+	l.log.Info().Msgf("receipt listener backfilled blocks %d to %d", checkpoint+1, head)
+	return backfilled, nil
+}
+
+// backfillLogs returns the logs of the native txns from block from to block to which may have
+// executed meta-transactions. With wallets set, they are the logs of the wallets. Otherwise, as
+// the TxExecuted event is anonymous and can't be filtered by topic, they are the logs of the
+// receipts of the native txns with a NonceChange event.
+func (l *LegacyReceiptListener) backfillLogs(ctx context.Context, from, to uint64) ([]types.Log, error) {
+	query := ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+	}
+	if len(l.wallets) > 0 {
+		query.Addresses = l.wallets
+	} else {
+		query.Topics = [][]common.Hash{{NonceChangeEventSig}}
+	}
+
+	var logs []types.Log
+	err := l.br.Do(ctx, func() error {
+		var err error
+		logs, err = l.provider.FilterLogs(ctx, query)
+		return err
+	})
+	if err != nil || len(l.wallets) > 0 {
+		return logs, err
 	}
+
+	var txnLogs []types.Log
+	for len(logs) > 0 {
+		txHash := logs[0].TxHash
+		logs = logs[transactionLogs(logs):]
+
+		var receipt *types.Receipt
+		err := l.br.Do(ctx, func() error {
+			var err error
+			receipt, err = l.provider.TransactionReceipt(ctx, txHash)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch receipt for %v: %w", txHash.Hex(), err)
+		}
+		for _, log := range receipt.Logs {
+			txnLogs = append(txnLogs, *log)
+		}
+	}
+	return txnLogs, nil
+}
+
+func (l *LegacyReceiptListener) Stop() {
+	l.log.Info().Msgf("receipt listener is stopping")
+	l.muRun.Lock()
+	defer l.muRun.Unlock()
+	if l.ctxStop != nil {
+		l.ctxStop()
+	}
+}
+
+func (l *LegacyReceiptListener) IsRunning() bool {
+	return atomic.LoadInt32(&l.running) == 1
+}
+
+// Drain waits for all in-flight receipt fetches to complete, and then persists the
+// checkpoint to the checkpoint store. If the ctx is done before draining completes, the
+// txn hashes of the outstanding fetches are reported as pending.
+func (l *LegacyReceiptListener) Drain(ctx context.Context) (*DrainReport, error) {
+	done := make(chan struct{})
+	go func() {
+		l.inflightWg.Wait()
+		close(done)
+	}()
+
+	report := &DrainReport{Component: "ReceiptListener"}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		l.muInflight.Lock()
+		for txHash := range l.inflight {
+			report.Pending = append(report.Pending, txHash.Hex())
+		}
+		l.muInflight.Unlock()
+	}
+
+	report.Checkpoint = l.Checkpoint()
+
+	if l.checkpointStore != nil && report.Checkpoint > 0 {
+		// use a fresh context, as the drain ctx may be done by now, and we always want to try to persist
+		err := l.checkpointStore.Set(context.Background(), legacyCheckpointKey, report.Checkpoint)
+		if err != nil {
+			return report, fmt.Errorf("ReceiptListener: failed to persist checkpoint: %w", err)
+		}
+	}
+
+	return report, nil
 }
 
 func (l *LegacyReceiptListener) WaitForMetaTxn(ctx context.Context, metaTxnID MetaTxnID, optTimeout ...time.Duration) ([]*LegacyMetaTxnResult, *types.Receipt, error) {
 	// Use optional timeout if passed, otherwise use deadline on the provided ctx, or finally,
-	// set a default timeout of 120 seconds.
+	// set the default wait timeout of the listener options, or 120 seconds.
 	var cancel context.CancelFunc
 	if len(optTimeout) > 0 {
 		ctx, cancel = context.WithTimeout(ctx, optTimeout[0])
 		defer cancel()
 	} else {
 		if _, ok := ctx.Deadline(); !ok {
-			ctx, cancel = context.WithTimeout(ctx, 120*time.Second)
+			ctx, cancel = context.WithTimeout(ctx, l.options.WaitTimeout(120*time.Second))
 			defer cancel()
 		}
 	}
 
 	// Listen for new receipts
-	sub := l.subscribe()
+	sub := l.subscribe(metaTxnID)
 	defer sub.unsubscribe()
 
-	// See if metaTxn has been seen in past blocks
-	receipt := func() *ReceiptResult {
-		l.muPastReceipts.Lock()
-		defer l.muPastReceipts.Unlock()
-
-		totalInspected := 0
-		for _, bol := range l.pastReceipts {
-			for _, receipt := range bol {
-				totalInspected++
-				if receipt.MetaTxnID == metaTxnID {
-					l.log.Debug().
-						Int("inspected", totalInspected).
-						Str("meta-tx", string(metaTxnID)).
-						Msgf("Found receipt among past receipts")
-
-					return &receipt
-				}
-			}
-		}
-
+	// See if metaTxn has been seen in past blocks, the latest receipt is the most relevant
+	var receipt *ReceiptResult
+	if receipts := l.pastReceiptsIndex.Get(metaTxnID); len(receipts) > 0 {
 		l.log.Debug().
-			Int("inspected", totalInspected).
 			Str("meta-tx", string(metaTxnID)).
-			Msgf("Receipt not found among past receipts. Now listening..")
+			Msgf("Found receipt among past receipts")
 
-		return nil
-	}()
-	if receipt != nil {
+		receipt = receipts[len(receipts)-1]
 		return receipt.Results, receipt.TxnReceipt, nil
 	}
 
-	// Wait for receipt or context deadline
-	var err error
-	for done := false; !done; {
-		select {
-		case <-ctx.Done():
-			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-				err = fmt.Errorf("waiting for meta transaction timeout for %v: %w", metaTxnID, ctx.Err())
-			} else if ctx.Err() != nil {
-				err = fmt.Errorf("failed waiting for meta transaction for %v: %w", metaTxnID, ctx.Err())
-			}
-			done = true
+	l.log.Debug().
+		Str("meta-tx", string(metaTxnID)).
+		Msgf("Receipt not found among past receipts. Now listening..")
 
-		case r, ok := <-sub.ch:
-			if ok {
-				if r.MetaTxnID == metaTxnID {
-					receipt = &r
-					done = true
-				}
-			} else {
-				done = true
-			}
-		}
-	}
+	// Wait for receipt or context deadline
+	r, err := sub.queue.pop(ctx)
 	if err != nil {
-		return nil, nil, err
-	}
-	if receipt != nil {
-		return receipt.Results, receipt.TxnReceipt, nil
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, nil, fmt.Errorf("waiting for meta transaction timeout for %v: %w", metaTxnID, err)
+		}
+		return nil, nil, fmt.Errorf("failed waiting for meta transaction for %v: %w", metaTxnID, err)
 	}
-	return nil, nil, nil
+	return r.Results, r.TxnReceipt, nil
 }
 
-func (l *LegacyReceiptListener) handleBlock(ctx context.Context, block *ethmonitor.Block) {
+func (l *LegacyReceiptListener) handleBlock(ctx context.Context, block *ethmonitor.Block) error {
 	if block.Event != ethmonitor.Added {
-		return
+		return nil
 	}
+	l.options.Metrics.IncCounter("receipt_listener.block")
 
-	// txHashes is the set of native transactions with at least one NonceChange event
-	txHashes := map[common.Hash]struct{}{}
-
-	// txLogs is the block's logs indexed by native transaction hash
-	txLogs := map[common.Hash][]*types.Log{}
+	matcher := receiptMatchers.Get().(*receiptMatcher)
+	defer receiptMatchers.Put(matcher)
 
-	for i := range block.Logs {
-		log := &block.Logs[i]
+	// the logs of a block are grouped by native transaction
+	for logs := block.Logs; len(logs) > 0; {
+		n := transactionLogs(logs)
+		txLogs := logs[:n]
+		logs = logs[n:]
 
-		txLogs[log.TxHash] = append(txLogs[log.TxHash], log)
+		// receipts will have their TxnReceipt fields populated in a different goroutine
+		receipts := matcher.match(txLogs, l.log)
+		if len(receipts) == 0 {
+			continue
+		}
 
-		if len(log.Topics) == 1 && log.Topics[0] == NonceChangeEventSig {
-			txHashes[log.TxHash] = struct{}{}
+		err := l.handleReceipts(ctx, block.NumberU64(), txLogs[0].TxHash, receipts, estimateLogsSize(txLogs))
+		if err != nil {
+			return err
 		}
 	}
 
-	// receipts are the meta transaction receipts indexed by native transaction hash
-	// receipts will have their TxnReceipt fields populated in a different goroutine
-	receipts := make(map[common.Hash][]ReceiptResult, len(txHashes))
-
-	for txHash := range txHashes {
-		logs := txLogs[txHash]
+	l.markHandled(block.NumberU64())
+	return nil
+}
 
-		// metaTxnReceipts are meta transaction receipts indexed by meta transaction ID
-		// these get mutated and must be *ReceiptResult
-		metaTxnReceipts := map[common.Hash]*ReceiptResult{}
+// markHandled records the block as handled, and advances the checkpoint up to the last
+// block whose receipts are all fetched.
+func (l *LegacyReceiptListener) markHandled(blockNum uint64) {
+	l.muInflight.Lock()
+	defer l.muInflight.Unlock()
+	if blockNum > l.handled {
+		l.handled = blockNum
+	}
+	l.advanceCheckpoint()
+}
 
-		// receipt gets the current receipt for a given meta transaction ID
-		receipt := func(metaTxnID common.Hash) *ReceiptResult {
-			result := metaTxnReceipts[metaTxnID]
-			if result == nil {
-				result = &ReceiptResult{MetaTxnID: MetaTxnID(metaTxnID.Hex()[2:])}
-				metaTxnReceipts[metaTxnID] = result
-			}
-			return result
+// advanceCheckpoint must be called with muInflight held.
+func (l *LegacyReceiptListener) advanceCheckpoint() {
+	checkpoint := l.handled
+	for blockNum := range l.pendingBlocks {
+		if blockNum == 0 {
+			return
 		}
-
-		// assuming block.Logs is sorted
-		for _, log := range logs {
-			if len(log.Topics) == 0 && len(log.Data) == 32 {
-				// possible TxExecuted event
-
-				r := receipt(common.BytesToHash(log.Data))
-				r.Results = append(r.Results, &LegacyMetaTxnResult{
-					Status: MetaTxnExecuted,
-				})
-			} else if len(log.Topics) == 1 && log.Topics[0] == TxFailedEventSig {
-				// definite TxFailed event
-
-				metaTxnID, reason, err := DecodeTxFailedEvent(log)
-				if err != nil {
-					l.log.Err(err).Msgf("unable to decode TxFailed event: topics=%v data=%v", log.Topics, log.Data)
-					continue
-				}
-
-				r := receipt(metaTxnID)
-				r.Results = append(r.Results, &LegacyMetaTxnResult{
-					Status: MetaTxnFailed,
-					Reason: reason,
-				})
-			}
+		if blockNum-1 < checkpoint {
+			checkpoint = blockNum - 1
 		}
+	}
+	if checkpoint > l.Checkpoint() {
+		atomic.StoreUint64(&l.checkpoint, checkpoint)
+	}
+}
 
-		receipts[txHash] = make([]ReceiptResult, 0, len(metaTxnReceipts))
-		for _, receipt := range metaTxnReceipts {
-			receipts[txHash] = append(receipts[txHash], *receipt)
-		}
+// handleReceipts queues the receipts of txHash in block to be fetched, where size is the
+// estimated size of its logs. With BackpressureBlock, it waits for the queue to have room
+// until ctx is done.
+func (l *LegacyReceiptListener) handleReceipts(ctx context.Context, block uint64, txHash common.Hash, txReceipts []ReceiptResult, size int64) error {
+	if len(txReceipts) == 0 {
+		return nil
 	}
 
-	for txHash, txReceipts := range receipts {
-		l.handleReceipts(ctx, txHash, txReceipts)
+	// past receipts are evicted first to make room for the ones to be fetched
+	l.muPastReceipts.Lock()
+	l.trimPastReceipts(size)
+	l.muPastReceipts.Unlock()
+
+	fetch := &receiptFetch{txHash: txHash, block: block, receipts: txReceipts, size: size}
+
+	l.muInflight.Lock()
+	l.inflight[txHash] = struct{}{}
+	l.pendingBlocks[block]++
+	l.muInflight.Unlock()
+	l.inflightWg.Add(1)
+
+	err := l.fetchQueue.push(ctx, fetch, size)
+	if err != nil {
+		l.doneFetch(fetch)
+		if errors.Is(err, ErrQueueFull) {
+			l.options.Metrics.IncCounter("receipt_listener.fetch.rejected")
+		}
+		return fmt.Errorf("unable to queue receipt fetch for %v: %w", txHash.Hex(), err)
 	}
+	return nil
 }
 
-func (l *LegacyReceiptListener) handleReceipts(ctx context.Context, txHash common.Hash, txReceipts []ReceiptResult) {
-	if len(txReceipts) == 0 {
-		return
+// fetchReceipts fetches the receipts queued on queue until it is closed and empty.
+func (l *LegacyReceiptListener) fetchReceipts(queue *boundedQueue[*receiptFetch]) {
+	for {
+		fetch, err := queue.pop(context.Background())
+		if err != nil {
+			return
+		}
+		l.fetchReceipt(fetch)
+		l.doneFetch(fetch)
 	}
+}
 
-	// handle receipts in an independent goroutine so that node failures don't stall the block handler
-	go func() {
-		err := l.br.Do(ctx, func() error {
-			l.receiptsSem <- struct{}{}
-			defer func() {
-				<-l.receiptsSem
-			}()
-
-			receipt, err := l.provider.TransactionReceipt(ctx, txHash)
-			if err != nil {
-				return fmt.Errorf("unable to fetch receipt for %v: %w", txHash.Hex(), err)
-			} else if receipt == nil {
-				return fmt.Errorf("unable to fetch receipt for %v", txHash.Hex())
-			}
+func (l *LegacyReceiptListener) fetchReceipt(fetch *receiptFetch) {
+	// NOTE: receipt fetches are detached from the run context, so that in-flight work may be
+	// drained after the listener has been stopped. Each fetch is bounded by its own timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), legacyFetchReceiptTimeout)
+	defer cancel()
 
-			// populate TxnReceipt field
-			for _, txReceipt := range txReceipts {
-				txReceipt.TxnReceipt = receipt
-			}
+	txHash, txReceipts := fetch.txHash, fetch.receipts
 
-			return nil
-		})
+	err := l.br.Do(ctx, func() error {
+		receipt, err := l.provider.TransactionReceipt(ctx, txHash)
 		if err != nil {
-			l.log.Warn().Err(err).Msgf("failed to fetch receipt after several tries")
+			return fmt.Errorf("unable to fetch receipt for %v: %w", txHash.Hex(), err)
+		} else if receipt == nil {
+			return fmt.Errorf("unable to fetch receipt for %v", txHash.Hex())
+		}
+
+		// populate TxnReceipt field
+		for i := range txReceipts {
+			txReceipts[i].TxnReceipt = receipt
 		}
 
-		l.pushReceipts(txReceipts)
+		return nil
+	})
+	if err != nil {
+		l.log.Warn().Err(err).Msgf("failed to fetch receipt after several tries")
+	}
 
-		l.muSubscribers.Lock()
-		defer l.muSubscribers.Unlock()
+	l.pushReceipts(txReceipts, fetch.size)
 
-		// broadcast receipts
-		for _, txReceipt := range txReceipts {
-			for _, sub := range l.subscribers {
-				select {
-				case <-sub.done:
-				case sub.sendCh <- txReceipt:
-				}
+	// send receipts to the subscribers waiting for them only
+	for _, txReceipt := range txReceipts {
+		for _, sub := range l.waiters.Get(txReceipt.MetaTxnID) {
+			err := sub.queue.push(ctx, txReceipt, 0)
+			if errors.Is(err, ErrQueueFull) {
+				l.options.Metrics.IncCounter("receipt_listener.subscriber.rejected")
+				sub.queue.close(ErrQueueFull)
 			}
 		}
-	}()
+	}
+}
+
+// dropFetch is called with the fetches dropped from a full queue by BackpressureDropOldest.
+func (l *LegacyReceiptListener) dropFetch(fetch *receiptFetch) {
+	l.log.Warn().Msgf("receipt fetch queue is full, dropping receipts of %v", fetch.txHash.Hex())
+	l.options.Metrics.IncCounter("receipt_listener.fetch.dropped")
+	l.doneFetch(fetch)
 }
 
-func (l *LegacyReceiptListener) pushReceipts(txReceipts []ReceiptResult) {
+func (l *LegacyReceiptListener) doneFetch(fetch *receiptFetch) {
+	l.muInflight.Lock()
+	delete(l.inflight, fetch.txHash)
+	if l.pendingBlocks[fetch.block]--; l.pendingBlocks[fetch.block] <= 0 {
+		delete(l.pendingBlocks, fetch.block)
+	}
+	l.advanceCheckpoint()
+	l.muInflight.Unlock()
+	l.inflightWg.Done()
+}
+
+func (l *LegacyReceiptListener) pushReceipts(txReceipts []ReceiptResult, size int64) {
 	l.muPastReceipts.Lock()
 	defer l.muPastReceipts.Unlock()
 
-	if len(l.pastReceipts) < legacyPastReceiptsBufSize {
-		l.pastReceipts = append(l.pastReceipts, txReceipts)
-	} else {
-		l.pastReceipts = append(l.pastReceipts[1:], txReceipts)
+	if len(l.pastReceipts) >= legacyPastReceiptsBufSize {
+		l.evictPastReceipts()
+	}
+
+	l.pastReceipts = append(l.pastReceipts, txReceipts)
+	l.pastReceiptsSizes = append(l.pastReceiptsSizes, size)
+	l.pastReceiptsBytes += size
+	for i := range txReceipts {
+		l.pastReceiptsIndex.Add(txReceipts[i].MetaTxnID, &txReceipts[i])
 	}
+
+	l.trimPastReceipts(0)
 }
 
-func (l *LegacyReceiptListener) subscribe() *subscriber {
-	l.muSubscribers.Lock()
-	defer l.muSubscribers.Unlock()
+// trimPastReceipts evicts the oldest past receipts until the listener is within its memory
+// budget with extra more bytes buffered. It must be called with muPastReceipts held.
+func (l *LegacyReceiptListener) trimPastReceipts(extra int64) {
+	if l.limits.MaxBufferedBytes <= 0 {
+		return
+	}
+	for len(l.pastReceipts) > 0 && l.pastReceiptsBytes+l.queuedBytes()+extra > l.limits.MaxBufferedBytes {
+		l.evictPastReceipts()
+	}
+}
 
-	ch := make(chan ReceiptResult)
-	subscriber := &subscriber{
-		ch:     ch,
-		sendCh: makeUnboundedBuffered(ch, logadapter.LogAdapter(l.log), 100),
-		done:   make(chan struct{}),
+// evictPastReceipts evicts the oldest past receipts. It must be called with muPastReceipts held.
+func (l *LegacyReceiptListener) evictPastReceipts() {
+	evicted := l.pastReceipts[0]
+	for i := range evicted {
+		l.pastReceiptsIndex.Remove(evicted[i].MetaTxnID, &evicted[i])
 	}
+	l.pastReceiptsBytes -= l.pastReceiptsSizes[0]
+	l.pastReceipts = l.pastReceipts[1:]
+	l.pastReceiptsSizes = l.pastReceiptsSizes[1:]
+}
 
-	subscriber.unsubscribe = func() {
-		close(subscriber.done)
-		l.muSubscribers.Lock()
-		defer l.muSubscribers.Unlock()
-		close(subscriber.sendCh)
+func (l *LegacyReceiptListener) queuedBytes() int64 {
+	if l.fetchQueue == nil {
+		return 0
+	}
+	_, bytes := l.fetchQueue.size()
+	return bytes
+}
 
-		// flush subscriber.ch so that the makeUnboundedBuffered goroutine exits
-		for ok := true; ok; _, ok = <-subscriber.ch {
-		}
+// subscribe returns a subscriber which is sent the receipts of metaTxnID.
+func (l *LegacyReceiptListener) subscribe(metaTxnID MetaTxnID) *subscriber {
+	subscriber := &subscriber{
+		queue: newBoundedQueue[ReceiptResult](l.limits.MaxSubscriberQueue, 0, l.limits.Backpressure, nil),
+	}
 
-		for i, sub := range l.subscribers {
-			if sub == subscriber {
-				l.subscribers = append(l.subscribers[:i], l.subscribers[i+1:]...)
-				return
-			}
-		}
+	subscriber.unsubscribe = func() {
+		l.waiters.Remove(metaTxnID, subscriber)
+		subscriber.queue.close(nil)
 	}
 
-	l.subscribers = append(l.subscribers, subscriber)
+	l.waiters.Add(metaTxnID, subscriber)
 
 	return subscriber
 }
 
-// converts a blocking unbuffered send channel into a non-blocking unbounded buffered one
-// inspired by https://medium.com/capital-one-tech/building-an-unbounded-channel-in-go-789e175cd2cd
-func makeUnboundedBuffered(sendCh chan<- ReceiptResult, log logger.Logger, bufferLimitWarning int) chan<- ReceiptResult {
-	ch := make(chan ReceiptResult)
-
-	go func() {
-		var buffer []ReceiptResult
-
-		for {
-			if len(buffer) == 0 {
-				if message, ok := <-ch; ok {
-					buffer = append(buffer, message)
-					if len(buffer) > bufferLimitWarning {
-						log.Warnf("channel buffer holds %v > %v messages", len(buffer), bufferLimitWarning)
-					}
-				} else {
-					close(sendCh)
-					break
-				}
-			} else {
-				select {
-				case sendCh <- buffer[0]:
-					buffer = buffer[1:]
-
-				case message, ok := <-ch:
-					if ok {
-						buffer = append(buffer, message)
-						if len(buffer) > bufferLimitWarning {
-							log.Warnf("channel buffer holds %v > %v messages", len(buffer), bufferLimitWarning)
-						}
-					}
-				}
-			}
-		}
-	}()
+// estimateLogsSize estimates the memory held by logs, and by the receipt they are fetched
+// with, which is dominated by the logs.
+func estimateLogsSize(logs []types.Log) int64 {
+	const logOverhead = 256
 
-	return ch
+	size := int64(0)
+	for i := range logs {
+		size += logOverhead + int64(len(logs[i].Data)) + int64(len(logs[i].Topics))*common.HashLength
+	}
+	return size
 }
@@ -0,0 +1,32 @@
+package sequence
+
+import (
+	"context"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLegacyReceiptListenerCheckpoint(t *testing.T) {
+	l := &LegacyReceiptListener{
+		inflight:      map[common.Hash]struct{}{},
+		pendingBlocks: map[uint64]int{},
+		fetchQueue:    newBoundedQueue[*receiptFetch](0, 0, BackpressureBlock, nil),
+		options:       NewOptions(),
+	}
+
+	txHash := common.HexToHash("0x01")
+	err := l.handleReceipts(context.Background(), 5, txHash, []ReceiptResult{{MetaTxnID: "01"}}, 0)
+	assert.NoError(t, err)
+	l.markHandled(5)
+	l.markHandled(7)
+
+	// the checkpoint stays before the block whose receipt is still being fetched
+	assert.Equal(t, uint64(4), l.Checkpoint())
+
+	fetch, err := l.fetchQueue.pop(context.Background())
+	assert.NoError(t, err)
+	l.doneFetch(fetch)
+	assert.Equal(t, uint64(7), l.Checkpoint())
+}
@@ -0,0 +1,131 @@
+package sequence_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethmonitor"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/relayer"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/goware/cachestore/memlru"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLegacyReceiptListenerBackfill(t *testing.T) {
+	ctx := context.Background()
+	chain := memchain.New(memchain.Options{})
+
+	target := common.HexToAddress("0x7a7")
+	chain.Deploy(target, memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		if len(input) > 0 {
+			return nil, memchain.Revert("no data expected")
+		}
+		env.Log([]common.Hash{common.HexToHash("0x7a7")}, nil)
+		return nil, nil
+	}))
+
+	sender, err := testutil.MemChainEOA(chain, 1, big.NewInt(1e18))
+	assert.NoError(t, err)
+	r, err := relayer.NewLocalRelayerWithProvider(sender, chain.Provider())
+	assert.NoError(t, err)
+	wallet, err := testutil.MemChainWallet(chain, 2)
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.SetRelayer(r))
+	testutil.DeployMemChainWallet(chain, wallet)
+
+	// the bundles are mined after the checkpoint, while no listener runs
+	chain.Mine()
+	checkpoint := chain.Head()
+
+	send := func(data []byte) sequence.MetaTxnID {
+		signed, err := wallet.SignTransactions(ctx, sequence.Transactions{{To: target, Data: data}})
+		assert.NoError(t, err)
+		metaTxnID, _, _, err := wallet.SendTransaction(ctx, signed)
+		assert.NoError(t, err)
+		_, _, err = r.Wait(ctx, metaTxnID, 10*time.Second)
+		assert.NoError(t, err)
+		return metaTxnID
+	}
+	executed := send(nil)
+	failed := send([]byte{1})
+
+	listen := func(wallets ...common.Address) []map[string]interface{} {
+		// the filters of the eth_getLogs requests are recorded
+		var filters []map[string]interface{}
+		var mu sync.Mutex
+		chain.Intercept(func(method string, params []json.RawMessage, next func() (interface{}, error)) (interface{}, error) {
+			var filter map[string]interface{}
+			if method == "eth_getLogs" && len(params) == 1 && json.Unmarshal(params[0], &filter) == nil {
+				mu.Lock()
+				filters = append(filters, filter)
+				mu.Unlock()
+			}
+			return next()
+		})
+		defer chain.Intercept(nil)
+		provider := chain.Provider()
+
+		monitorOptions := ethmonitor.DefaultOptions
+		monitorOptions.WithLogs = true
+		monitor, err := ethmonitor.NewMonitor(provider, monitorOptions)
+		assert.NoError(t, err)
+		listener, err := sequence.NewLegacyReceiptListener(zerolog.Nop(), provider, monitor)
+		assert.NoError(t, err)
+
+		store, err := memlru.New[uint64]()
+		assert.NoError(t, err)
+		assert.NoError(t, store.Set(ctx, "legacyReceiptListener::checkpoint", checkpoint))
+		listener.SetCheckpointStore(store).SetWallets(wallets...)
+
+		runErr := make(chan error, 1)
+		go func() {
+			runErr <- listener.Run(ctx)
+		}()
+		defer func() {
+			listener.Stop()
+			assert.NoError(t, <-runErr)
+		}()
+
+		results, receipt, err := listener.WaitForMetaTxn(ctx, executed, 5*time.Second)
+		if assert.NoError(t, err) && assert.Len(t, results, 1) {
+			assert.Equal(t, sequence.MetaTxnExecuted, results[0].Status)
+			assert.NotNil(t, receipt)
+		}
+		results, _, err = listener.WaitForMetaTxn(ctx, failed, 5*time.Second)
+		if assert.NoError(t, err) && assert.Len(t, results, 1) {
+			assert.Equal(t, sequence.MetaTxnFailed, results[0].Status)
+			assert.Equal(t, "no data expected", results[0].Reason)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		return filters
+	}
+
+	// without wallets, the txns of meta-transactions are found by their NonceChange events
+	filters := listen()
+	if assert.NotEmpty(t, filters) {
+		for _, filter := range filters {
+			assert.Nil(t, filter["address"])
+			assert.Equal(t, []interface{}{[]interface{}{sequence.NonceChangeEventSig.Hex()}}, filter["topics"])
+		}
+	}
+
+	// with wallets, only their logs are fetched
+	filters = listen(wallet.Address())
+	if assert.NotEmpty(t, filters) {
+		for _, filter := range filters {
+			assert.Equal(t, []interface{}{strings.ToLower(wallet.Address().Hex())}, filter["address"])
+			assert.Nil(t, filter["topics"])
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package sequence
+
+import (
+	"encoding/hex"
+	"sync"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/rs/zerolog"
+)
+
+// receiptMatcher matches the meta-transaction receipts of the logs of native transactions.
+// Scanning the logs of every block is the hot loop of long running listeners, so matchers are
+// pooled, and reuse their buffers from transaction to transaction.
+type receiptMatcher struct {
+	// index is the position of each meta-transaction in hashes and counts, in the order they
+	// are first seen in the logs
+	index  map[common.Hash]int
+	hashes []common.Hash
+	counts []int
+}
+
+var receiptMatchers = sync.Pool{
+	New: func() interface{} {
+		return &receiptMatcher{index: map[common.Hash]int{}}
+	},
+}
+
+// match returns the receipts of the meta-transactions of the logs of a native transaction, or
+// nil if it executed none, ie. had no NonceChange event. The results of the meta-transactions
+// are allocated at once, as the logs are counted before they are decoded.
+func (m *receiptMatcher) match(logs []types.Log, logger zerolog.Logger) []ReceiptResult {
+	if !hasNonceChangeEvent(logs) {
+		return nil
+	}
+	defer m.reset()
+
+	total := 0
+	for i := range logs {
+		metaTxnHash, ok := metaTxnHashOfLog(&logs[i])
+		if !ok {
+			continue
+		}
+		j, ok := m.index[metaTxnHash]
+		if !ok {
+			j = len(m.hashes)
+			m.index[metaTxnHash] = j
+			m.hashes = append(m.hashes, metaTxnHash)
+			m.counts = append(m.counts, 0)
+		}
+		m.counts[j]++
+		total++
+	}
+	if total == 0 {
+		return nil
+	}
+
+	// the IDs of the meta-transactions are substrings of a single string
+	ids := make([]byte, 2*common.HashLength*len(m.hashes))
+	for j := range m.hashes {
+		hex.Encode(ids[2*common.HashLength*j:], m.hashes[j][:])
+	}
+	idsString := string(ids)
+
+	results := make([]LegacyMetaTxnResult, total)
+	pointers := make([]*LegacyMetaTxnResult, total)
+	receipts := make([]ReceiptResult, len(m.hashes))
+	for j, offset := 0, 0; j < len(m.hashes); j++ {
+		receipts[j].MetaTxnID = MetaTxnID(idsString[2*common.HashLength*j : 2*common.HashLength*(j+1)])
+		receipts[j].Results = pointers[offset:offset:(offset + m.counts[j])]
+		offset += m.counts[j]
+	}
+
+	n := 0
+	for i := range logs {
+		log := &logs[i]
+		metaTxnHash, ok := metaTxnHashOfLog(log)
+		if !ok {
+			continue
+		}
+
+		result := &results[n]
+		n++
+		if len(log.Topics) == 0 {
+			// possible TxExecuted event
+			result.Status = MetaTxnExecuted
+		} else {
+			// definite TxFailed event
+			_, reason, err := DecodeTxFailedEvent(log)
+			if err != nil {
+				logger.Err(err).Msgf("unable to decode TxFailed event: topics=%v data=%v", log.Topics, log.Data)
+				continue
+			}
+			result.Status = MetaTxnFailed
+			result.Reason = reason
+		}
+
+		receipt := &receipts[m.index[metaTxnHash]]
+		receipt.Results = append(receipt.Results, result)
+	}
+
+	// meta-transactions whose events were all undecodable have no receipt
+	matched := receipts[:0]
+	for _, receipt := range receipts {
+		if len(receipt.Results) != 0 {
+			matched = append(matched, receipt)
+		}
+	}
+	return matched
+}
+
+func (m *receiptMatcher) reset() {
+	for metaTxnHash := range m.index {
+		delete(m.index, metaTxnHash)
+	}
+	m.hashes = m.hashes[:0]
+	m.counts = m.counts[:0]
+}
+
+func hasNonceChangeEvent(logs []types.Log) bool {
+	for i := range logs {
+		if len(logs[i].Topics) == 1 && logs[i].Topics[0] == NonceChangeEventSig {
+			return true
+		}
+	}
+	return false
+}
+
+// transactionLogs returns the number of the first logs of logs which are of the same native
+// transaction, as the logs of a block are grouped by transaction.
+func transactionLogs(logs []types.Log) int {
+	n := 1
+	for n < len(logs) && logs[n].TxHash == logs[0].TxHash {
+		n++
+	}
+	return n
+}
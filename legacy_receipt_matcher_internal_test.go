@@ -0,0 +1,126 @@
+package sequence
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// metaTxnLogs returns the logs of a native transaction executing metaTxns meta-transactions,
+// of which the last failed, with the transfer events of the meta-transactions in between.
+func metaTxnLogs(t testing.TB, txHash common.Hash, metaTxns int) []types.Log {
+	revert, err := ethcoder.AbiCoder([]string{"string"}, []interface{}{"insufficient balance"})
+	assert.NoError(t, err)
+	revert = append(common.FromHex("0x08c379a0"), revert...)
+
+	nonceChange, err := ethcoder.AbiCoder([]string{"uint256", "uint256"}, []interface{}{big.NewInt(0), big.NewInt(1)})
+	assert.NoError(t, err)
+	logs := []types.Log{{Topics: []common.Hash{NonceChangeEventSig}, Data: nonceChange}}
+
+	for i := 0; i < metaTxns; i++ {
+		metaTxnHash := common.BigToHash(big.NewInt(int64(i + 1)))
+		logs = append(logs, types.Log{
+			Topics: []common.Hash{common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"), {}, {}},
+			Data:   make([]byte, 32),
+		})
+		if i == metaTxns-1 {
+			failed, err := ethcoder.AbiCoder([]string{"bytes32", "bytes"}, []interface{}{metaTxnHash, revert})
+			assert.NoError(t, err)
+			logs = append(logs, types.Log{Topics: []common.Hash{TxFailedEventSig}, Data: failed})
+		} else {
+			logs = append(logs, types.Log{Data: metaTxnHash.Bytes()})
+		}
+	}
+
+	for i := range logs {
+		logs[i].TxHash = txHash
+	}
+	return logs
+}
+
+func TestReceiptMatcher(t *testing.T) {
+	logger := zerolog.Nop()
+	matcher := receiptMatchers.Get().(*receiptMatcher)
+	defer receiptMatchers.Put(matcher)
+
+	logs := metaTxnLogs(t, common.HexToHash("0x01"), 3)
+	receipts := matcher.match(logs, logger)
+	assert.Len(t, receipts, 3)
+	for i, receipt := range receipts {
+		assert.Equal(t, MetaTxnID(common.BigToHash(big.NewInt(int64(i + 1))).Hex()[2:]), receipt.MetaTxnID)
+		assert.Len(t, receipt.Results, 1)
+	}
+	assert.Equal(t, MetaTxnExecuted, receipts[0].Results[0].Status)
+	assert.Equal(t, MetaTxnFailed, receipts[2].Results[0].Status)
+	assert.Equal(t, "insufficient balance", receipts[2].Results[0].Reason)
+
+	// the results of a meta-transaction are in the order of its events, ie. of nested bundles
+	logs = append(logs, types.Log{Data: common.BigToHash(big.NewInt(3)).Bytes()})
+	receipts = matcher.match(logs, logger)
+	assert.Len(t, receipts, 3)
+	assert.Len(t, receipts[2].Results, 2)
+	assert.Equal(t, MetaTxnFailed, receipts[2].Results[0].Status)
+	assert.Equal(t, MetaTxnExecuted, receipts[2].Results[1].Status)
+
+	// undecodable events are skipped, and meta-transactions without results have no receipt
+	logs = metaTxnLogs(t, common.HexToHash("0x02"), 2)
+	logs[len(logs)-1].Data = logs[len(logs)-1].Data[:40]
+	receipts = matcher.match(logs, logger)
+	assert.Len(t, receipts, 1)
+	assert.Equal(t, MetaTxnID(common.BigToHash(big.NewInt(1)).Hex()[2:]), receipts[0].MetaTxnID)
+
+	// transactions which executed no meta-transaction have no receipts
+	assert.Nil(t, matcher.match(logs[1:], logger))
+
+	// the logs of a block are matched transaction by transaction
+	block := append(metaTxnLogs(t, common.HexToHash("0x03"), 2), metaTxnLogs(t, common.HexToHash("0x04"), 5)...)
+	n := transactionLogs(block)
+	assert.Equal(t, 5, n)
+	assert.Len(t, matcher.match(block[:n], logger), 2)
+	assert.Len(t, matcher.match(block[n:], logger), 5)
+	assert.Equal(t, len(block)-n, transactionLogs(block[n:]))
+}
+
+func TestDecodeTxFailedEvent(t *testing.T) {
+	logs := metaTxnLogs(t, common.Hash{}, 1)
+	metaTxnHash, reason, err := DecodeTxFailedEvent(&logs[len(logs)-1])
+	assert.NoError(t, err)
+	assert.Equal(t, common.BigToHash(big.NewInt(1)), metaTxnHash)
+	assert.Equal(t, "insufficient balance", reason)
+
+	// the revert of a meta-transaction is not always an Error(string)
+	failed, err := ethcoder.AbiCoder([]string{"bytes32", "bytes"}, []interface{}{metaTxnHash, common.FromHex("0x4e487b710000000000000000000000000000000000000000000000000000000000000011")})
+	assert.NoError(t, err)
+	_, _, err = DecodeTxFailedEvent(&types.Log{Topics: []common.Hash{TxFailedEventSig}, Data: failed})
+	assert.Error(t, err)
+	failed, err = ethcoder.AbiCoder([]string{"bytes32", "bytes"}, []interface{}{metaTxnHash, []byte{}})
+	assert.NoError(t, err)
+	_, _, err = DecodeTxFailedEvent(&types.Log{Topics: []common.Hash{TxFailedEventSig}, Data: failed})
+	assert.Error(t, err)
+
+	for _, data := range [][]byte{nil, failed[:63], failed[:40], append(failed[:32:32], common.LeftPadBytes([]byte{0xff, 0, 0, 0, 0, 0, 0, 0, 0x40}, 32)...)} {
+		_, _, err = DecodeTxFailedEvent(&types.Log{Topics: []common.Hash{TxFailedEventSig}, Data: data})
+		assert.Error(t, err)
+	}
+	_, _, err = DecodeTxFailedEvent(&logs[0])
+	assert.Error(t, err)
+}
+
+func BenchmarkReceiptMatcher(b *testing.B) {
+	logger := zerolog.Nop()
+	logs := metaTxnLogs(b, common.HexToHash("0x01"), 100)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		matcher := receiptMatchers.Get().(*receiptMatcher)
+		if receipts := matcher.match(logs, logger); len(receipts) != 100 {
+			b.Fatal(len(receipts))
+		}
+		receiptMatchers.Put(matcher)
+	}
+}
@@ -0,0 +1,92 @@
+package sequence
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/goware/cachestore"
+)
+
+// Runnable is the lifecycle of the ReceiptsListener, LegacyReceiptListener, config.Watcher,
+// relayer.NonceGapMonitor and relayer.ScheduledRelayer, and is the same shape as ethkit's
+// ethmonitor.Monitor and ethreceipts.ReceiptsListener, so services can start and stop all of
+// them in a consistent way.
+type Runnable interface {
+	// Run starts the component and blocks until it stops, or the ctx is done.
+	Run(ctx context.Context) error
+
+	// Stop signals the component to stop, it does not wait for in-flight work to complete.
+	Stop()
+
+	// IsRunning returns true while Run has not returned.
+	IsRunning() bool
+}
+
+// Drainer is implemented by the components which hold in-flight work that must be given
+// a chance to complete before a process exits, the LegacyReceiptListener and its receipt
+// fetches, and the relayer.ScheduledRelayer and the bundle it is relaying.
+type Drainer interface {
+	// Drain blocks until all in-flight work has completed, or the ctx is done, in which case
+	// the work still outstanding is listed in the report.
+	Drain(ctx context.Context) (*DrainReport, error)
+}
+
+// DrainReport describes the state a component was left in after shutdown.
+type DrainReport struct {
+	// Component is a human-readable name of the component the report belongs to.
+	Component string
+
+	// Checkpoint is the last block number the component has fully processed, and
+	// which was persisted to its checkpoint store, if it has one.
+	Checkpoint uint64
+
+	// Pending is the list of identifiers (ie. txn hashes or metaTxnIDs) of work that
+	// was still in-flight when the drain deadline was hit.
+	Pending []string
+}
+
+func (r *DrainReport) IsDrained() bool {
+	return len(r.Pending) == 0
+}
+
+// CheckpointStore persists the last processed block number of a LegacyReceiptListener, so
+// that after a restart it may resume from where it left off. Any cachestore backend may be used.
+type CheckpointStore = cachestore.Store[uint64]
+
+// Shutdown stops each of the passed components and drains those which implement Drainer.
+// Components are stopped concurrently, and the ctx deadline bounds the total time spent draining.
+//
+// The returned reports are in the same order as the components. Components which do not
+// implement Drainer return a report without pending work.
+func Shutdown(ctx context.Context, components ...Runnable) ([]*DrainReport, error) {
+	reports := make([]*DrainReport, len(components))
+	errs := make([]error, len(components))
+
+	var wg sync.WaitGroup
+	for i, component := range components {
+		wg.Add(1)
+		go func(i int, component Runnable) {
+			defer wg.Done()
+
+			if component.IsRunning() {
+				component.Stop()
+			}
+
+			drainer, ok := component.(Drainer)
+			if !ok {
+				reports[i] = &DrainReport{Component: fmt.Sprintf("%T", component)}
+				return
+			}
+			reports[i], errs[i] = drainer.Drain(ctx)
+		}(i, component)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return reports, fmt.Errorf("sequence: shutdown: %w", err)
+		}
+	}
+	return reports, nil
+}
@@ -0,0 +1,64 @@
+package sequence_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/go-sequence"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockComponent struct {
+	running  int32
+	inflight chan struct{}
+}
+
+func (c *mockComponent) Run(ctx context.Context) error {
+	atomic.StoreInt32(&c.running, 1)
+	return nil
+}
+
+func (c *mockComponent) Stop() {
+	atomic.StoreInt32(&c.running, 0)
+}
+
+func (c *mockComponent) IsRunning() bool {
+	return atomic.LoadInt32(&c.running) == 1
+}
+
+func (c *mockComponent) Drain(ctx context.Context) (*sequence.DrainReport, error) {
+	report := &sequence.DrainReport{Component: "mock", Checkpoint: 10}
+	select {
+	case <-c.inflight:
+	case <-ctx.Done():
+		report.Pending = []string{"0x01"}
+	}
+	return report, nil
+}
+
+func TestShutdown(t *testing.T) {
+	drained := &mockComponent{inflight: make(chan struct{})}
+	stuck := &mockComponent{inflight: make(chan struct{})}
+
+	drained.Run(context.Background())
+	stuck.Run(context.Background())
+	close(drained.inflight)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	reports, err := sequence.Shutdown(ctx, drained, stuck)
+	assert.NoError(t, err)
+	assert.Len(t, reports, 2)
+
+	assert.False(t, drained.IsRunning())
+	assert.False(t, stuck.IsRunning())
+
+	assert.True(t, reports[0].IsDrained())
+	assert.Equal(t, uint64(10), reports[0].Checkpoint)
+
+	assert.False(t, reports[1].IsDrained())
+	assert.Equal(t, []string{"0x01"}, reports[1].Pending)
+}
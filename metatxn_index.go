@@ -0,0 +1,174 @@
+package sequence
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+const (
+	// metaTxnBloomSize is the number of counters of the bloom filter of a MetaTxnIndex, which
+	// must be a power of 2.
+	metaTxnBloomSize = 1 << 14
+
+	// metaTxnBloomHashes is the number of counters each metaTxnID sets in the bloom filter.
+	metaTxnBloomHashes = 3
+)
+
+// MetaTxnIndex indexes values by metaTxnID, so the TxExecuted and TxFailed events of a block
+// are matched against any number of tracked meta-transactions in O(logs), instead of checking
+// each log against each tracked meta-transaction.
+//
+// The index is keyed by the first 8 bytes of the metaTxnIDs, and a counting bloom filter of
+// the tracked metaTxnIDs rejects most logs before any lookup. As metaTxnIDs are hashes, both
+// are derived from their bytes directly. It is safe for concurrent use.
+type MetaTxnIndex[T comparable] struct {
+	bloom   []uint32
+	entries map[uint64][]*metaTxnIndexEntry[T]
+	n       int
+	mu      sync.RWMutex
+}
+
+type metaTxnIndexEntry[T comparable] struct {
+	hash   common.Hash
+	values []T
+}
+
+func NewMetaTxnIndex[T comparable]() *MetaTxnIndex[T] {
+	return &MetaTxnIndex[T]{
+		bloom:   make([]uint32, metaTxnBloomSize),
+		entries: map[uint64][]*metaTxnIndexEntry[T]{},
+	}
+}
+
+// Add indexes value under metaTxnID, after the values already indexed under it.
+func (x *MetaTxnIndex[T]) Add(metaTxnID MetaTxnID, value T) {
+	hash := common.HexToHash(string(metaTxnID))
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if entry := x.entry(hash); entry != nil {
+		entry.values = append(entry.values, value)
+		return
+	}
+
+	key := metaTxnIndexKey(hash)
+	x.entries[key] = append(x.entries[key], &metaTxnIndexEntry[T]{hash: hash, values: []T{value}})
+	for _, i := range metaTxnBloomPositions(hash) {
+		x.bloom[i]++
+	}
+	x.n++
+}
+
+// Remove removes value from the values indexed under metaTxnID, and returns true if it was
+// indexed. metaTxnID is no longer tracked once its last value is removed.
+func (x *MetaTxnIndex[T]) Remove(metaTxnID MetaTxnID, value T) bool {
+	hash := common.HexToHash(string(metaTxnID))
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	entry := x.entry(hash)
+	if entry == nil {
+		return false
+	}
+	found := false
+	for i, v := range entry.values {
+		if v == value {
+			entry.values = append(entry.values[:i], entry.values[i+1:]...)
+			found = true
+			break
+		}
+	}
+	if len(entry.values) > 0 {
+		return found
+	}
+
+	key := metaTxnIndexKey(hash)
+	entries := x.entries[key]
+	for i, e := range entries {
+		if e == entry {
+			entries = append(entries[:i], entries[i+1:]...)
+			break
+		}
+	}
+	if len(entries) == 0 {
+		delete(x.entries, key)
+	} else {
+		x.entries[key] = entries
+	}
+	for _, i := range metaTxnBloomPositions(hash) {
+		x.bloom[i]--
+	}
+	x.n--
+	return found
+}
+
+// Get returns the values indexed under metaTxnID, oldest first.
+func (x *MetaTxnIndex[T]) Get(metaTxnID MetaTxnID) []T {
+	return x.get(common.HexToHash(string(metaTxnID)))
+}
+
+// Match returns the metaTxnID of log if it is the TxExecuted or TxFailed event of a tracked
+// meta-transaction, and the values indexed under it.
+func (x *MetaTxnIndex[T]) Match(log *types.Log) (MetaTxnID, []T, bool) {
+	hash, ok := metaTxnHashOfLog(log)
+	if !ok {
+		return "", nil, false
+	}
+	values := x.get(hash)
+	if len(values) == 0 {
+		return "", nil, false
+	}
+	return MetaTxnID(hash.Hex()[2:]), values, true
+}
+
+// Len returns the number of metaTxnIDs tracked by the index.
+func (x *MetaTxnIndex[T]) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.n
+}
+
+func (x *MetaTxnIndex[T]) get(hash common.Hash) []T {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	for _, i := range metaTxnBloomPositions(hash) {
+		if x.bloom[i] == 0 {
+			return nil
+		}
+	}
+	entry := x.entry(hash)
+	if entry == nil {
+		return nil
+	}
+	values := make([]T, len(entry.values))
+	copy(values, entry.values)
+	return values
+}
+
+// entry returns the entry of hash, or nil. It must be called with mu held.
+func (x *MetaTxnIndex[T]) entry(hash common.Hash) *metaTxnIndexEntry[T] {
+	for _, entry := range x.entries[metaTxnIndexKey(hash)] {
+		if entry.hash == hash {
+			return entry
+		}
+	}
+	return nil
+}
+
+func metaTxnIndexKey(hash common.Hash) uint64 {
+	return binary.BigEndian.Uint64(hash[:8])
+}
+
+func metaTxnBloomPositions(hash common.Hash) [metaTxnBloomHashes]uint32 {
+	var positions [metaTxnBloomHashes]uint32
+	for i := range positions {
+		positions[i] = binary.BigEndian.Uint32(hash[8+4*i:]) & (metaTxnBloomSize - 1)
+	}
+	return positions
+}
@@ -0,0 +1,67 @@
+package sequence_test
+
+import (
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetaTxnIndex(t *testing.T) {
+	index := sequence.NewMetaTxnIndex[int]()
+
+	executed := sequence.MetaTxnID(ethcoder.Keccak256Hash([]byte("executed")).Hex()[2:])
+	failed := sequence.MetaTxnID(ethcoder.Keccak256Hash([]byte("failed")).Hex()[2:])
+
+	// shares the first 8 bytes of executed, so both are under the same key
+	collidingHash := common.HexToHash(string(executed))
+	collidingHash[31] ^= 0xff
+	colliding := sequence.MetaTxnID(collidingHash.Hex()[2:])
+
+	index.Add(executed, 1)
+	index.Add(executed, 2)
+	index.Add(failed, 3)
+	index.Add(colliding, 4)
+	assert.Equal(t, 3, index.Len())
+
+	assert.Equal(t, []int{1, 2}, index.Get(executed))
+	assert.Equal(t, []int{4}, index.Get(colliding))
+	assert.Equal(t, []int{1, 2}, index.Get(sequence.MetaTxnID("0x"+string(executed))))
+
+	executedLog := &types.Log{Data: common.HexToHash(string(executed)).Bytes()}
+	metaTxnID, values, ok := index.Match(executedLog)
+	assert.True(t, ok)
+	assert.Equal(t, executed, metaTxnID)
+	assert.Equal(t, []int{1, 2}, values)
+
+	failedData, err := ethcoder.AbiCoder([]string{"bytes32", "bytes"}, []interface{}{common.HexToHash(string(failed)), []byte{}})
+	assert.NoError(t, err)
+	metaTxnID, values, ok = index.Match(&types.Log{Topics: []common.Hash{sequence.TxFailedEventSig}, Data: failedData})
+	assert.True(t, ok)
+	assert.Equal(t, failed, metaTxnID)
+	assert.Equal(t, []int{3}, values)
+
+	// logs which are not meta-transaction events, or of untracked ones, do not match
+	_, _, ok = index.Match(&types.Log{Topics: []common.Hash{sequence.NonceChangeEventSig}, Data: executedLog.Data})
+	assert.False(t, ok)
+	_, _, ok = index.Match(&types.Log{Data: ethcoder.Keccak256Hash([]byte("untracked")).Bytes()})
+	assert.False(t, ok)
+
+	assert.True(t, index.Remove(executed, 1))
+	assert.False(t, index.Remove(executed, 1))
+	assert.Equal(t, []int{2}, index.Get(executed))
+	assert.True(t, index.Remove(executed, 2))
+	assert.Empty(t, index.Get(executed))
+	_, _, ok = index.Match(executedLog)
+	assert.False(t, ok)
+	assert.Equal(t, []int{4}, index.Get(colliding))
+	assert.Equal(t, 2, index.Len())
+
+	assert.True(t, index.Remove(colliding, 4))
+	assert.True(t, index.Remove(failed, 3))
+	assert.Equal(t, 0, index.Len())
+	assert.False(t, index.Remove(failed, 3))
+}
@@ -0,0 +1,264 @@
+// Package mpc signs for wallets with keys held by threshold ECDSA (MPC) clusters, ie. tss-lib
+// based, whose key is an EOA signer of the wallet configs. A digest is submitted to the
+// cluster under a correlation ID, and its combined signature returned asynchronously, polled
+// from the cluster or delivered by its callback, see Signer.Deliver.
+//
+//	signer := mpc.NewSigner(cluster, keyID, keyAddress, mpc.Options{Timeout: time.Minute})
+//	signed, err := mpc.SignTransactions(ctx, wallet, txns, signer)
+package mpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+var (
+	ErrTimeout          = errors.New("mpc: signing timed out")
+	ErrSigningFailed    = errors.New("mpc: signing failed")
+	ErrInvalidSignature = errors.New("mpc: invalid signature")
+)
+
+// Status is the status of a signing session of a cluster.
+type Status int
+
+const (
+	StatusPending Status = iota
+	StatusDone
+	StatusFailed
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusPending:
+		return "pending"
+	case StatusDone:
+		return "done"
+	case StatusFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// Request is a digest submitted to a cluster for signing with a key.
+type Request struct {
+	// CorrelationID identifies the signing session. Clusters must accept the resubmission of
+	// a request of the same correlation ID without starting another session.
+	CorrelationID string
+	KeyID         string
+	Digest        common.Hash
+}
+
+// Response is the state of a signing session.
+type Response struct {
+	CorrelationID string
+	Status        Status
+
+	// Signature is the combined signature, r, s and v, once done.
+	Signature []byte
+
+	// Error is the reason the session failed.
+	Error string
+}
+
+// Cluster is the client of an MPC cluster.
+type Cluster interface {
+	// Submit submits a request, returning once the cluster accepted it.
+	Submit(ctx context.Context, req *Request) error
+
+	// Poll returns the state of the session of a correlation ID.
+	Poll(ctx context.Context, correlationID string) (*Response, error)
+}
+
+type Options struct {
+	// Timeout is the longest a signature is waited for, 2 minutes by default.
+	Timeout time.Duration
+
+	// PollInterval is the interval the cluster is polled at, 1 second by default. Sessions
+	// whose responses are delivered, see Signer.Deliver, are also polled, in case a callback
+	// is lost.
+	PollInterval time.Duration
+
+	// SubmitRetries is the number of times a submission which failed is retried, after
+	// RetryBackoff, doubled on each retry, 1 second by default.
+	SubmitRetries int
+	RetryBackoff  time.Duration
+
+	// SessionRetries is the number of times a session which failed is restarted, under a new
+	// correlation ID.
+	SessionRetries int
+}
+
+// Signer signs digests with a key of a cluster.
+type Signer struct {
+	cluster Cluster
+	keyID   string
+	address common.Address
+	options Options
+
+	waiting   map[string]chan *Response
+	waitingMu sync.Mutex
+}
+
+// NewSigner returns the signer of the key of keyID of cluster, whose address is address.
+func NewSigner(cluster Cluster, keyID string, address common.Address, options Options) *Signer {
+	if options.Timeout == 0 {
+		options.Timeout = 2 * time.Minute
+	}
+	if options.PollInterval == 0 {
+		options.PollInterval = time.Second
+	}
+	if options.RetryBackoff == 0 {
+		options.RetryBackoff = time.Second
+	}
+	return &Signer{cluster: cluster, keyID: keyID, address: address, options: options, waiting: map[string]chan *Response{}}
+}
+
+func (s *Signer) Address() common.Address {
+	return s.address
+}
+
+// CorrelationID returns the correlation ID of the signing of digest, which is deterministic so
+// a signature requested again, ie. after a restart, resumes the same session.
+func (s *Signer) CorrelationID(digest common.Hash) string {
+	return crypto.Keccak256Hash([]byte(s.keyID), digest[:]).Hex()
+}
+
+// SignDigest signs digest with the key, returning its signature, r, s and v, with v of 27 or
+// 28. The signature is checked to recover the address of the key.
+func (s *Signer) SignDigest(ctx context.Context, digest common.Hash) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.options.Timeout)
+	defer cancel()
+
+	correlationID := s.CorrelationID(digest)
+	for attempt := 0; ; attempt++ {
+		id := correlationID
+		if attempt > 0 {
+			id = fmt.Sprintf("%s-%d", correlationID, attempt)
+		}
+
+		res, err := s.sign(ctx, &Request{CorrelationID: id, KeyID: s.keyID, Digest: digest})
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w: session %s", ErrTimeout, id)
+		} else if err != nil {
+			return nil, err
+		}
+
+		if res.Status == StatusFailed {
+			if attempt < s.options.SessionRetries {
+				continue
+			}
+			return nil, fmt.Errorf("%w: session %s: %s", ErrSigningFailed, id, res.Error)
+		}
+		return s.verify(digest, res.Signature)
+	}
+}
+
+// sign submits req and waits for its session to be done or fail.
+func (s *Signer) sign(ctx context.Context, req *Request) (*Response, error) {
+	delivered := make(chan *Response, 1)
+	s.waitingMu.Lock()
+	s.waiting[req.CorrelationID] = delivered
+	s.waitingMu.Unlock()
+	defer func() {
+		s.waitingMu.Lock()
+		delete(s.waiting, req.CorrelationID)
+		s.waitingMu.Unlock()
+	}()
+
+	backoff := s.options.RetryBackoff
+	for retry := 0; ; retry++ {
+		err := s.cluster.Submit(ctx, req)
+		if err == nil {
+			break
+		}
+		if retry >= s.options.SubmitRetries || ctx.Err() != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("mpc: failed to submit session %s: %w", req.CorrelationID, err)
+		}
+		if err := sleep(ctx, backoff); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+	}
+
+	ticker := time.NewTicker(s.options.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case res := <-delivered:
+			return res, nil
+		case <-ticker.C:
+			res, err := s.cluster.Poll(ctx, req.CorrelationID)
+			if err != nil {
+				// transient, the session is polled again
+				continue
+			}
+			if res.Status != StatusPending {
+				return res, nil
+			}
+		}
+	}
+}
+
+// Deliver delivers the response of a session to the signature waiting for it, ie. from the
+// callback of the cluster, and reports whether one was. Pending responses are ignored.
+func (s *Signer) Deliver(res *Response) bool {
+	if res == nil || res.Status == StatusPending {
+		return false
+	}
+	s.waitingMu.Lock()
+	delivered, ok := s.waiting[res.CorrelationID]
+	s.waitingMu.Unlock()
+	if !ok {
+		return false
+	}
+	select {
+	case delivered <- res:
+	default:
+	}
+	return true
+}
+
+// verify normalizes the v of sig to 27 or 28, and checks it recovers the address of the key.
+func (s *Signer) verify(digest common.Hash, sig []byte) ([]byte, error) {
+	if len(sig) != 65 {
+		return nil, fmt.Errorf("%w: signature is %d bytes", ErrInvalidSignature, len(sig))
+	}
+	sig = append([]byte{}, sig...)
+	if sig[64] < 27 {
+		sig[64] += 27
+	}
+
+	recoverable := append([]byte{}, sig...)
+	recoverable[64] -= 27
+	pubKey, err := crypto.SigToPub(digest[:], recoverable)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if address := crypto.PubkeyToAddress(*pubKey); address != s.address {
+		return nil, fmt.Errorf("%w: signature recovers %v, expected %v", ErrInvalidSignature, address.Hex(), s.address.Hex())
+	}
+	return sig, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
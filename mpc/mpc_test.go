@@ -0,0 +1,128 @@
+package mpc_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/core/digest"
+	"github.com/0xsequence/go-sequence/mpc"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCluster signs with a local key, failing the first sessions and submissions it is told to.
+type fakeCluster struct {
+	key            *ethwallet.Wallet
+	failSessions   int
+	failSubmits    int
+	neverCompletes bool
+
+	submitted []string
+	sessions  map[string]*mpc.Response
+	mu        sync.Mutex
+}
+
+func (c *fakeCluster) Submit(ctx context.Context, req *mpc.Request) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failSubmits > 0 {
+		c.failSubmits--
+		return errors.New("cluster is busy")
+	}
+	c.submitted = append(c.submitted, req.CorrelationID)
+	if _, ok := c.sessions[req.CorrelationID]; ok || c.neverCompletes {
+		return nil
+	}
+
+	res := &mpc.Response{CorrelationID: req.CorrelationID, Status: mpc.StatusDone}
+	if c.failSessions > 0 {
+		c.failSessions--
+		res.Status, res.Error = mpc.StatusFailed, "party 2 aborted"
+	} else {
+		sig, _ := crypto.Sign(req.Digest[:], c.key.PrivateKey())
+		res.Signature = sig
+	}
+	c.sessions[req.CorrelationID] = res
+	return nil
+}
+
+func (c *fakeCluster) Poll(ctx context.Context, correlationID string) (*mpc.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if res, ok := c.sessions[correlationID]; ok {
+		return res, nil
+	}
+	return &mpc.Response{CorrelationID: correlationID, Status: mpc.StatusPending}, nil
+}
+
+func TestSigner(t *testing.T) {
+	ctx := context.Background()
+	local, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	clusterKey, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(2))
+	assert.NoError(t, err)
+
+	options := mpc.Options{PollInterval: time.Millisecond, RetryBackoff: time.Millisecond, SubmitRetries: 1, SessionRetries: 1}
+	cluster := &fakeCluster{key: clusterKey, failSessions: 1, failSubmits: 1, sessions: map[string]*mpc.Response{}}
+	signer := mpc.NewSigner(cluster, "key-1", clusterKey.Address(), options)
+
+	// a 2 of 2 of a local key and the key of the cluster
+	config := sequence.WalletConfig{Threshold: 2, Signers: sequence.WalletConfigSigners{{Weight: 1, Address: local.Address()}, {Weight: 1, Address: clusterKey.Address()}}}
+	assert.NoError(t, sequence.SortWalletConfig(config))
+	wallet, err := sequence.NewWallet(sequence.WalletOptions{Config: config}, local)
+	assert.NoError(t, err)
+	wallet.SetChainID(big.NewInt(1))
+
+	msgDigest := common.HexToHash("0x1234")
+	sig, _, err := mpc.SignWalletDigest(ctx, wallet, msgDigest, nil, signer)
+	assert.NoError(t, err)
+
+	// the cluster signs the eth_sign msgDigest of the sub-msgDigest, and the failed submission and
+	// session are retried, the session under a new correlation id
+	subDigest, err := sequence.SubDigest(big.NewInt(1), wallet.Address(), msgDigest)
+	assert.NoError(t, err)
+	correlationID := signer.CorrelationID(digest.EthSignDigest(subDigest))
+	assert.Equal(t, []string{correlationID, correlationID + "-1"}, cluster.submitted)
+
+	recovered, err := sequence.RecoverWalletConfigFromDigest(subDigest, sig, sequence.SequenceContext(), big.NewInt(1), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, config, recovered)
+
+	// sessions which fail again are reported
+	cluster.failSessions = 2
+	_, err = mpc.NewSigner(cluster, "key-2", clusterKey.Address(), options).SignDigest(ctx, msgDigest)
+	assert.True(t, errors.Is(err, mpc.ErrSigningFailed))
+
+	// a signature of another key is rejected
+	_, err = mpc.NewSigner(cluster, "key-3", local.Address(), options).SignDigest(ctx, msgDigest)
+	assert.True(t, errors.Is(err, mpc.ErrInvalidSignature))
+
+	// a session is completed by the callback of the cluster, or times out
+	cluster.neverCompletes = true
+	slow := mpc.NewSigner(cluster, "key-4", clusterKey.Address(), mpc.Options{Timeout: 50 * time.Millisecond, PollInterval: time.Hour})
+	_, err = slow.SignDigest(ctx, msgDigest)
+	assert.True(t, errors.Is(err, mpc.ErrTimeout))
+
+	slow = mpc.NewSigner(cluster, "key-5", clusterKey.Address(), mpc.Options{PollInterval: time.Hour})
+	go func() {
+		for !slow.Deliver(&mpc.Response{CorrelationID: slow.CorrelationID(msgDigest), Status: mpc.StatusDone, Signature: mustSign(clusterKey, msgDigest)}) {
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	value, err := slow.SignDigest(ctx, msgDigest)
+	assert.NoError(t, err)
+	assert.Contains(t, []byte{27, 28}, value[64])
+}
+
+func mustSign(key *ethwallet.Wallet, msgDigest common.Hash) []byte {
+	sig, _ := crypto.Sign(msgDigest[:], key.PrivateKey())
+	return sig
+}
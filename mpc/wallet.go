@@ -0,0 +1,127 @@
+package mpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/core/digest"
+)
+
+// SignWalletDigest signs digest with wallet, see sequence.Wallet.SignDigest, adding the
+// signatures of signers to those of its local signers. The signers sign at once, and chainID
+// is optional as in SignDigest.
+func SignWalletDigest(ctx context.Context, wallet *sequence.Wallet, digestToSign common.Hash, chainID *big.Int, signers ...*Signer) ([]byte, *sequence.Signature, error) {
+	var optChainID []*big.Int
+	if chainID != nil {
+		optChainID = append(optChainID, chainID)
+	} else {
+		chainID = wallet.GetChainID()
+	}
+
+	_, sig, err := wallet.SignDigest(digestToSign, optChainID...)
+	if err != nil {
+		return nil, nil, err
+	}
+	subDigest, err := sequence.SubDigest(chainID, wallet.Address(), digestToSign)
+	if err != nil {
+		return nil, nil, err
+	}
+	ethSignDigest := digest.EthSignDigest(subDigest)
+
+	// the signers sign the eth_sign digest of the sub-digest, as local EOA signers do
+	errs := make([]error, len(sig.Signers))
+	var wg sync.WaitGroup
+	for i, part := range sig.Signers {
+		if part.Type != sequence.SignaturePartTypeAddress {
+			continue
+		}
+		signer := signerOf(signers, part.Address)
+		if signer == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, part *sequence.SignaturePart) {
+			defer wg.Done()
+			value, err := signer.SignDigest(ctx, ethSignDigest)
+			if err != nil {
+				errs[i] = fmt.Errorf("mpc: signer %v: %w", part.Address.Hex(), err)
+				return
+			}
+			sig.Signers[i] = &sequence.SignaturePart{
+				Type:    sequence.SignaturePartTypeEOA,
+				Weight:  part.Weight,
+				Address: part.Address,
+				Value:   append(value, sequence.SignatureTypeEthSign),
+			}
+		}(i, part)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	encoded, err := sig.Encode()
+	if err != nil {
+		return nil, nil, err
+	}
+	return encoded, sig, nil
+}
+
+// SignTransactions signs txns as a bundle of wallet, like sequence.Wallet.SignTransactions,
+// with the signatures of signers. The nonce of the bundle is the one of txns, or the next
+// nonce of the wallet, and the gas limits of txns are left as they are.
+func SignTransactions(ctx context.Context, wallet *sequence.Wallet, txns sequence.Transactions, signers ...*Signer) (*sequence.SignedTransactions, error) {
+	if len(txns) == 0 {
+		return nil, fmt.Errorf("mpc: cannot sign an empty set of transactions")
+	}
+	if err := wallet.ValidateTransactions(txns); err != nil {
+		return nil, err
+	}
+
+	nonce, err := txns.Nonce()
+	if err != nil {
+		return nil, fmt.Errorf("mpc: cannot load nonce from transactions: %w", err)
+	}
+	if nonce == nil {
+		nonce, err = wallet.GetNonce()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	bundle := sequence.Transaction{Transactions: txns, Nonce: nonce}
+	bundleDigest, err := bundle.Digest()
+	if err != nil {
+		return nil, err
+	}
+	sig, _, err := SignWalletDigest(ctx, wallet, bundleDigest, nil, signers...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sequence.SignedTransactions{
+		ChainID:       wallet.GetChainID(),
+		WalletConfig:  wallet.GetWalletConfig(),
+		WalletContext: wallet.GetWalletContext(),
+		Transactions:  txns,
+		Nonce:         nonce,
+		Digest:        bundleDigest,
+		Signature:     sig,
+	}, nil
+}
+
+func signerOf(signers []*Signer, address common.Address) *Signer {
+	for _, signer := range signers {
+		if signer.Address() == address {
+			return signer
+		}
+	}
+	return nil
+}
@@ -35,6 +35,17 @@ type NetworkConfig struct {
 	IsAuthChain    bool
 
 	SequenceAPIURL string
+
+	// GasToken is the gas token of the network, optional, see GetGasToken.
+	GasToken *FeeToken
+}
+
+// GetGasToken returns the gas token of the network, or the one known for its chain id.
+func (n *NetworkConfig) GetGasToken() FeeToken {
+	if n.GasToken != nil {
+		return *n.GasToken
+	}
+	return GasToken(n.ChainID.Uint64())
 }
 
 type Networks []NetworkConfig
@@ -0,0 +1,139 @@
+package sequence
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// NonceSpace returns the nonce space of the bundles of a purpose of a wallet, ie. "payouts",
+// derived from label: the first 160 bits of the keccak256 hash of the label, prefixed so the
+// space of a label is never the space of the same idempotency key, see IdempotencyNonceSpace.
+// The empty label is the main nonce space, 0.
+func NonceSpace(label string) *big.Int {
+	if label == "" {
+		return big.NewInt(0)
+	}
+	hash := crypto.Keccak256([]byte("sequence.nonce-space:" + label))
+	return new(big.Int).SetBytes(hash[:20])
+}
+
+// RandomNonceSpace returns a random nonce space, which no bundle of the wallet used yet, so a
+// bundle signed in it is executed regardless of the other bundles of the wallet.
+func RandomNonceSpace() (*big.Int, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("sequence: failed to generate nonce space: %w", err)
+	}
+	space := new(big.Int).SetBytes(b)
+	if space.Sign() == 0 {
+		// the main nonce space, which isn't random
+		space.SetInt64(1)
+	}
+	return space, nil
+}
+
+// NonceManager allocates the nonces of the bundles of a wallet in independent nonce spaces,
+// one per purpose, so the bundles of a purpose are executed in order while those of other
+// purposes don't wait on them, ie. payouts aren't held up by a stuck config update.
+//
+// The nonces allocated in a space are cached, so bundles signed before the previous ones are
+// executed are signed with the next nonces, and not with the nonce of the wallet again. A
+// nonce allocated but not relayed must be released, see Release, or the later bundles of its
+// space are never executed.
+type NonceManager struct {
+	wallet *Wallet
+
+	// next are the next nonces of the spaces, in their spaces, by space.
+	next map[string]*big.Int
+	mu   sync.Mutex
+}
+
+// NewNonceManager returns a manager of the nonces of wallet, which must be connected to a
+// relayer.
+func NewNonceManager(wallet *Wallet) *NonceManager {
+	return &NonceManager{wallet: wallet, next: map[string]*big.Int{}}
+}
+
+// Next allocates the next nonce of the space of label, see NonceSpace, encoded with the space.
+func (m *NonceManager) Next(ctx context.Context, label string) (*big.Int, error) {
+	return m.NextInSpace(ctx, NonceSpace(label))
+}
+
+// NextInSpace allocates the next nonce of space, encoded with the space: the nonce of the
+// wallet in the space, unless nonces past it were allocated and not executed yet.
+func (m *NonceManager) NextInSpace(ctx context.Context, space *big.Int) (*big.Int, error) {
+	current, err := m.wallet.getNonce(ctx, space, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sequence.NonceManager#Next: failed to read nonce of space %v: %w", space, err)
+	}
+	// the nonce in the space, whether or not the nonce read is encoded with it
+	_, current = DecodeNonce(current)
+	nonce, err := EncodeNonce(space, current)
+	if err != nil {
+		return nil, fmt.Errorf("sequence.NonceManager#Next: %w", err)
+	}
+	nonce = m.wallet.nextNonce(nonce)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if next, ok := m.next[space.String()]; ok && next.Cmp(nonce) > 0 {
+		nonce = next
+	}
+	m.next[space.String()] = new(big.Int).Add(nonce, big.NewInt(1))
+	return nonce, nil
+}
+
+// Random allocates the first nonce of a random nonce space, see RandomNonceSpace, for a
+// bundle executed in parallel with all the other bundles of the wallet.
+func (m *NonceManager) Random() (*big.Int, error) {
+	space, err := RandomNonceSpace()
+	if err != nil {
+		return nil, err
+	}
+	return EncodeNonce(space, big.NewInt(0))
+}
+
+// Release returns nonce, allocated but not relayed, ie. as its bundle failed to be signed, so
+// it's allocated again. Only the last nonce allocated in its space can be released, as the
+// later ones are already allocated: it returns false otherwise, and the bundles of the later
+// nonces are only executed once a bundle is relayed with nonce.
+func (m *NonceManager) Release(nonce *big.Int) bool {
+	space, _ := DecodeNonce(nonce)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	next, ok := m.next[space.String()]
+	if !ok || new(big.Int).Sub(next, big.NewInt(1)).Cmp(nonce) != 0 {
+		return false
+	}
+	m.next[space.String()] = new(big.Int).Set(nonce)
+	return true
+}
+
+// Reset forgets the nonces allocated in space, whose next nonce is read from the wallet again,
+// ie. after the bundles of the space were dropped.
+func (m *NonceManager) Reset(space *big.Int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.next, space.String())
+}
+
+// SignTransactions signs txns as the bundle of the next nonce of the space of label. The nonce
+// is released if txns fail to be signed.
+func (m *NonceManager) SignTransactions(ctx context.Context, label string, txns Transactions) (*SignedTransactions, error) {
+	nonce, err := m.Next(ctx, label)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := m.wallet.SignTransactionsWithNonce(ctx, txns, nonce)
+	if err != nil {
+		m.Release(nonce)
+		return nil, err
+	}
+	return signed, nil
+}
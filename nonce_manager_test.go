@@ -0,0 +1,98 @@
+package sequence_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// spacesRelayer is a relayer which only serves the nonces of wallets, by nonce space.
+type spacesRelayer struct {
+	sequence.Relayer
+	nonces map[string]int64
+}
+
+func (r *spacesRelayer) GetNonce(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, space *big.Int, blockNum *big.Int) (*big.Int, error) {
+	if space == nil {
+		space = big.NewInt(0)
+	}
+	return big.NewInt(r.nonces[space.String()]), nil
+}
+
+func TestNonceManager(t *testing.T) {
+	ctx := context.Background()
+
+	owner, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(owner)
+	assert.NoError(t, err)
+	wallet.SetChainID(big.NewInt(137))
+
+	payouts := sequence.NonceSpace("payouts")
+	relayer := &spacesRelayer{nonces: map[string]int64{"0": 4, payouts.String(): 2}}
+	assert.NoError(t, wallet.SetRelayer(relayer))
+
+	assert.Equal(t, big.NewInt(0), sequence.NonceSpace(""))
+	assert.NotEqual(t, sequence.IdempotencyNonceSpace("payouts"), payouts)
+	assert.Less(t, payouts.BitLen(), 161)
+
+	manager := sequence.NewNonceManager(wallet)
+	encode := func(space *big.Int, nonce int64) *big.Int {
+		encoded, err := sequence.EncodeNonce(space, big.NewInt(nonce))
+		assert.NoError(t, err)
+		return encoded
+	}
+
+	// the nonces of a space are allocated in order, past the ones pending
+	nonce, err := manager.Next(ctx, "payouts")
+	assert.NoError(t, err)
+	assert.Equal(t, encode(payouts, 2), nonce)
+	nonce, err = manager.Next(ctx, "payouts")
+	assert.NoError(t, err)
+	assert.Equal(t, encode(payouts, 3), nonce)
+
+	// spaces are independent
+	nonce, err = manager.Next(ctx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(4), nonce)
+
+	// only the last nonce of a space is released
+	assert.False(t, manager.Release(encode(payouts, 2)))
+	assert.True(t, manager.Release(encode(payouts, 3)))
+	nonce, err = manager.Next(ctx, "payouts")
+	assert.NoError(t, err)
+	assert.Equal(t, encode(payouts, 3), nonce)
+
+	// the nonce of the wallet is used once it passed the nonces allocated
+	relayer.nonces[payouts.String()] = 10
+	nonce, err = manager.Next(ctx, "payouts")
+	assert.NoError(t, err)
+	assert.Equal(t, encode(payouts, 10), nonce)
+
+	// a reset space is read from the wallet again
+	relayer.nonces[payouts.String()] = 7
+	manager.Reset(payouts)
+	nonce, err = manager.Next(ctx, "payouts")
+	assert.NoError(t, err)
+	assert.Equal(t, encode(payouts, 7), nonce)
+
+	signed, err := manager.SignTransactions(ctx, "payouts", sequence.Transactions{{To: common.HexToAddress("0xb0b0"), RevertOnError: true}})
+	assert.NoError(t, err)
+	assert.Equal(t, encode(payouts, 8), signed.Nonce)
+
+	// random spaces start at their first nonce
+	random, err := manager.Random()
+	assert.NoError(t, err)
+	space, n := sequence.DecodeNonce(random)
+	assert.Positive(t, space.Sign())
+	assert.Zero(t, n.Sign())
+	other, err := manager.Random()
+	assert.NoError(t, err)
+	assert.NotEqual(t, random, other)
+}
@@ -0,0 +1,210 @@
+package sequence
+
+import (
+	"time"
+
+	"github.com/goware/cachestore"
+	"github.com/goware/logger"
+)
+
+// Option configures the Wallet, relayers, receipt listener and estimator. The same
+// options are accepted by every constructor, and each component uses the ones
+// which are relevant to it and ignores the rest.
+type Option func(*Options)
+
+// Options are the settings shared across the components of go-sequence. They
+// are built from a list of Option with NewOptions.
+type Options struct {
+	// Logger is used to log the activity of a component. Defaults to a no-op logger.
+	Logger logger.Logger
+
+	// Metrics receives counters and timings of a component. Defaults to a no-op implementation.
+	Metrics Metrics
+
+	// WaitOptions are the defaults used when waiting for meta-transaction receipts.
+	WaitOptions WaitOptions
+
+	// Cache is the store used by components which cache chain state, such as the Estimator.
+	// If nil, the component uses its own in-memory cache.
+	Cache cachestore.Store[[]byte]
+
+	// HistoryIndex serves Wallet.History. If nil, the wallet scans the chain.
+	HistoryIndex HistoryIndex
+
+	// TransactionLimits are checked by the Wallet on the transactions it signs, in addition
+	// to their structure. Defaults to no limits, see DefaultTransactionLimits.
+	TransactionLimits TransactionLimits
+
+	// BlockSource is where the logs of ranges of blocks are read from when scanning the
+	// chain. If nil, they are read with eth_getLogs of the provider.
+	BlockSource BlockSource
+
+	// ReceiptFallback locates the native transactions of meta-transactions which can't be
+	// found through the logs of the provider, ie. by Wallet.FindMetaTxnReceipt.
+	ReceiptFallback ReceiptFallback
+
+	// ReceiptTrust is how receipts fetched from the provider are verified. Defaults to
+	// trusting the provider.
+	ReceiptTrust ReceiptTrust
+
+	// Estimator simulates bundles through the wallet to estimate the gas limits of their
+	// transactions, ie. by the LocalRelayer. If nil, each transaction is estimated on its own.
+	Estimator *Estimator
+}
+
+// WaitOptions are the defaults used when waiting for a meta-transaction receipt.
+type WaitOptions struct {
+	// Timeout is how long to wait when neither an explicit timeout is passed, nor
+	// the ctx has a deadline set. A zero value uses the component's own default.
+	Timeout time.Duration
+
+	// Confirmations is the number of blocks mined on top of the block of a receipt before
+	// it is returned, after re-validating that its block is still canonical. If the block
+	// was reorged out, waiting resumes until the meta-transaction is mined again. A zero
+	// value returns the first receipt found, as on chains with instant finality.
+	Confirmations uint64
+
+	// PollInterval is how often the chain head is polled while waiting for confirmations.
+	// A zero value polls every second.
+	PollInterval time.Duration
+
+	// OnStatus, if set, is called each time the status of a meta-transaction changes while
+	// waiting for it, ie. with MetaTxnReorged when its receipt was reorged out.
+	OnStatus func(metaTxnID MetaTxnID, status MetaTxnStatus)
+}
+
+// Metrics is implemented by metrics backends (ie. prometheus) to instrument go-sequence components.
+type Metrics interface {
+	IncCounter(name string)
+	ObserveDuration(name string, d time.Duration)
+}
+
+// LabeledMetrics is implemented by Metrics backends which support labels, ie. to
+// distinguish the metrics of the tenants of a relayer.
+type LabeledMetrics interface {
+	Metrics
+	WithLabels(labels map[string]string) Metrics
+}
+
+// MetricsWithLabels returns metrics which record with labels, if the backend supports
+// them, otherwise metrics is returned as-is.
+func MetricsWithLabels(metrics Metrics, labels map[string]string) Metrics {
+	if labeled, ok := metrics.(LabeledMetrics); ok {
+		return labeled.WithLabels(labels)
+	}
+	return metrics
+}
+
+func NewOptions(opts ...Option) Options {
+	options := Options{
+		Logger:  nopLogger{},
+		Metrics: nopMetrics{},
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&options)
+		}
+	}
+	return options
+}
+
+// WaitTimeout returns the wait timeout, or defaultTimeout if none was configured.
+func (o Options) WaitTimeout(defaultTimeout time.Duration) time.Duration {
+	if o.WaitOptions.Timeout > 0 {
+		return o.WaitOptions.Timeout
+	}
+	return defaultTimeout
+}
+
+func WithLogger(log logger.Logger) Option {
+	return func(o *Options) {
+		if log != nil {
+			o.Logger = log
+		}
+	}
+}
+
+func WithMetrics(metrics Metrics) Option {
+	return func(o *Options) {
+		if metrics != nil {
+			o.Metrics = metrics
+		}
+	}
+}
+
+func WithWaitOptions(waitOptions WaitOptions) Option {
+	return func(o *Options) {
+		o.WaitOptions = waitOptions
+	}
+}
+
+func WithCache(cache cachestore.Store[[]byte]) Option {
+	return func(o *Options) {
+		o.Cache = cache
+	}
+}
+
+// WithHistoryIndex sets the index which Wallet.History is served from.
+func WithHistoryIndex(index HistoryIndex) Option {
+	return func(o *Options) {
+		o.HistoryIndex = index
+	}
+}
+
+// WithTransactionLimits sets the limits the Wallet checks on the transactions it signs,
+// ie. DefaultTransactionLimits.
+func WithTransactionLimits(limits TransactionLimits) Option {
+	return func(o *Options) {
+		o.TransactionLimits = limits
+	}
+}
+
+// WithBlockSource sets where the logs of ranges of blocks are read from when scanning the
+// chain, ie. trace_filter or an indexer on chains where eth_getLogs is unreliable.
+func WithBlockSource(source BlockSource) Option {
+	return func(o *Options) {
+		o.BlockSource = source
+	}
+}
+
+// WithReceiptFallback sets where meta-transactions are located when the log queries of the
+// provider fail or don't look back far enough, ie. an ExplorerClient.
+func WithReceiptFallback(fallback ReceiptFallback) Option {
+	return func(o *Options) {
+		o.ReceiptFallback = fallback
+	}
+}
+
+// WithReceiptTrust sets how receipts fetched from the provider are verified, ie. against
+// the receipts root of block headers from a trusted provider.
+func WithReceiptTrust(trust ReceiptTrust) Option {
+	return func(o *Options) {
+		o.ReceiptTrust = trust
+	}
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) IncCounter(name string)                       {}
+func (nopMetrics) ObserveDuration(name string, d time.Duration) {}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(v ...interface{})                 {}
+func (nopLogger) Debugf(format string, v ...interface{}) {}
+func (nopLogger) Info(v ...interface{})                  {}
+func (nopLogger) Infof(format string, v ...interface{})  {}
+func (nopLogger) Warn(v ...interface{})                  {}
+func (nopLogger) Warnf(format string, v ...interface{})  {}
+func (nopLogger) Error(v ...interface{})                 {}
+func (nopLogger) Errorf(format string, v ...interface{}) {}
+func (nopLogger) Fatal(v ...interface{})                 {}
+func (nopLogger) Fatalf(format string, v ...interface{}) {}
+
+// WithEstimator sets the estimator which bundles are simulated through the wallet with to
+// estimate the gas limits of their transactions, see Estimator.EstimateGasLimits.
+func WithEstimator(estimator *Estimator) Option {
+	return func(o *Options) {
+		o.Estimator = estimator
+	}
+}
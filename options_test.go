@@ -0,0 +1,41 @@
+package sequence_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/go-sequence"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockMetrics struct {
+	mu       sync.Mutex
+	counters map[string]int
+}
+
+func (m *mockMetrics) IncCounter(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[name]++
+}
+
+func (m *mockMetrics) ObserveDuration(name string, d time.Duration) {}
+
+func TestOptions(t *testing.T) {
+	options := sequence.NewOptions()
+	assert.NotNil(t, options.Logger)
+	assert.NotNil(t, options.Metrics)
+	assert.Nil(t, options.Cache)
+	assert.Equal(t, 2*time.Minute, options.WaitTimeout(2*time.Minute))
+
+	metrics := &mockMetrics{counters: map[string]int{}}
+	options = sequence.NewOptions(
+		sequence.WithMetrics(metrics),
+		sequence.WithWaitOptions(sequence.WaitOptions{Timeout: 30 * time.Second}),
+	)
+	assert.Equal(t, 30*time.Second, options.WaitTimeout(2*time.Minute))
+
+	options.Metrics.IncCounter("test")
+	assert.Equal(t, 1, metrics.counters["test"])
+}
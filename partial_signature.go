@@ -0,0 +1,188 @@
+package sequence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	coredigest "github.com/0xsequence/go-sequence/core/digest"
+)
+
+var (
+	// ErrThresholdNotMet is returned when finalizing signed transactions whose signers do not
+	// have the weight of the threshold of their wallet config yet.
+	ErrThresholdNotMet = errors.New("sequence: signature weight does not meet the wallet config threshold")
+
+	// ErrInvalidSignaturePart is returned when a signature collected is not of the signer of
+	// the wallet config at its position.
+	ErrInvalidSignaturePart = errors.New("sequence: invalid signature part")
+)
+
+// signPart signs subDigest with the signer of signerInfo, local or remote, or returns the
+// address part of the signer if it is not available.
+func (w *Wallet) signPart(ctx context.Context, signerInfo WalletConfigSigner, subDigest []byte) (*SignaturePart, error) {
+	if signer, ok := w.GetSigner(signerInfo.Address); ok {
+		// TODO: in future add support for Sequence Signers, right now we only support EOA
+		// signers in go-sequence
+		sigValue, err := signer.SignMessage(subDigest)
+		if err != nil {
+			return nil, fmt.Errorf("signer.SignMessage subDigest: %w", err)
+		}
+		return &SignaturePart{
+			Type: SignaturePartTypeEOA, Weight: signerInfo.Weight, Address: signer.Address(), Value: append(sigValue, SignatureTypeEthSign),
+		}, nil
+	}
+
+	if signer, ok := w.getRemoteSigner(signerInfo.Address); ok {
+		sigValue, err := signer.SignDigest(ctx, coredigest.EthSignDigest(subDigest))
+		if err != nil {
+			return nil, fmt.Errorf("sequence.Wallet: remote signer %v: %w", signerInfo.Address.Hex(), err)
+		}
+		if len(sigValue) != 65 {
+			return nil, fmt.Errorf("%w: remote signer %v returned %d bytes", ErrInvalidSignaturePart, signerInfo.Address.Hex(), len(sigValue))
+		}
+		value := append(append([]byte{}, sigValue...), SignatureTypeEthSign)
+		if value[64] < 27 {
+			value[64] += 27
+		}
+		part := &SignaturePart{
+			Type: SignaturePartTypeEOA, Weight: signerInfo.Weight, Address: signerInfo.Address, Value: value,
+		}
+		if recovered, err := part.Recover(subDigest); err != nil || recovered != signerInfo.Address {
+			return nil, fmt.Errorf("%w: remote signer %v did not sign the digest", ErrInvalidSignaturePart, signerInfo.Address.Hex())
+		}
+		return part, nil
+	}
+
+	// signer isn't available, just include the config value of address
+	// without it's signature
+	return &SignaturePart{
+		Type: SignaturePartTypeAddress, Weight: signerInfo.Weight, Address: signerInfo.Address,
+	}, nil
+}
+
+// SignatureWeight returns the weight of the signers whose signatures were collected in
+// signedTxs, signed by the wallet with SignTransactions or CosignTransactions. It is
+// complete once the weight meets the threshold of the wallet config.
+func (w *Wallet) SignatureWeight(signedTxs *SignedTransactions) (uint16, error) {
+	sig, _, err := w.collectedSignature(signedTxs)
+	if err != nil {
+		return 0, fmt.Errorf("sequence.Wallet#SignatureWeight: %w", err)
+	}
+	return sig.Weight()
+}
+
+// CosignTransactions adds the signatures of the available signers of the wallet to the
+// signatures collected in signedTxs, ie. signed by another party holding other signers of
+// the wallet config with SignTransactions, and returns them as a copy. Signed transactions
+// may be serialized with EncodeSignedTransactions, and passed from party to party until
+// enough weight is collected to finalize them, see FinalizeTransactions.
+func (w *Wallet) CosignTransactions(ctx context.Context, signedTxs *SignedTransactions) (*SignedTransactions, error) {
+	sig, subDigest, err := w.collectedSignature(signedTxs)
+	if err != nil {
+		return nil, fmt.Errorf("sequence.Wallet#CosignTransactions: %w", err)
+	}
+
+	for i, part := range sig.Signers {
+		if part.Type != SignaturePartTypeAddress {
+			continue
+		}
+		sig.Signers[i], err = w.signPart(ctx, w.config.Signers[i], subDigest)
+		if err != nil {
+			return nil, fmt.Errorf("sequence.Wallet#CosignTransactions: %w", err)
+		}
+	}
+
+	encoded, err := sig.Encode()
+	if err != nil {
+		return nil, err
+	}
+	cosigned := signedTxs.Clone()
+	cosigned.Signature = encoded
+	return cosigned, nil
+}
+
+// FinalizeTransactions returns a copy of signedTxs ready to be relayed, once the weight of the
+// signatures collected meets the threshold of the wallet config, or ErrThresholdNotMet. The
+// signatures in excess of the threshold are pruned, so the bundle costs less gas to execute.
+func (w *Wallet) FinalizeTransactions(signedTxs *SignedTransactions) (*SignedTransactions, error) {
+	sig, subDigest, err := w.collectedSignature(signedTxs)
+	if err != nil {
+		return nil, fmt.Errorf("sequence.Wallet#FinalizeTransactions: %w", err)
+	}
+
+	weight, err := sig.Weight()
+	if err != nil {
+		return nil, err
+	}
+	if weight < sig.Threshold {
+		return nil, fmt.Errorf("%w: %d of %d", ErrThresholdNotMet, weight, sig.Threshold)
+	}
+
+	if err := sig.Reduce(subDigest); err != nil {
+		return nil, fmt.Errorf("sequence.Wallet#FinalizeTransactions: %w", err)
+	}
+	encoded, err := sig.Encode()
+	if err != nil {
+		return nil, err
+	}
+	finalized := signedTxs.Clone()
+	finalized.Signature = encoded
+	return finalized, nil
+}
+
+// collectedSignature decodes the signature of signedTxs by the wallet, and checks each of its
+// parts is of the signer of the wallet config at its position. It returns the signature, and
+// the sub-digest it is over.
+func (w *Wallet) collectedSignature(signedTxs *SignedTransactions) (*Signature, []byte, error) {
+	if err := signedTxs.Verify(); err != nil {
+		return nil, nil, err
+	}
+	if !signedTxs.WalletConfig.Equal(w.config) {
+		return nil, nil, fmt.Errorf("transactions are signed with another wallet config")
+	}
+
+	chainID := signedTxs.ChainID
+	if chainID == nil {
+		chainID = w.chainID
+	}
+	if w.chainID != nil && chainID.Cmp(w.chainID) != 0 {
+		return nil, nil, fmt.Errorf("transactions are signed for chain %v, not %v", chainID, w.chainID)
+	}
+	subDigest, err := SubDigest(chainID, w.Address(), signedTxs.Digest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sig, err := DecodeSignature(signedTxs.Signature)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sig.Threshold != w.config.Threshold || len(sig.Signers) != len(w.config.Signers) {
+		return nil, nil, fmt.Errorf("%w: signature is not of the wallet config", ErrInvalidSignaturePart)
+	}
+
+	for i, part := range sig.Signers {
+		signerInfo := w.config.Signers[i]
+		if part.Weight != signerInfo.Weight {
+			return nil, nil, fmt.Errorf("%w: weight of signer %v is %d, not %d", ErrInvalidSignaturePart, signerInfo.Address.Hex(), part.Weight, signerInfo.Weight)
+		}
+
+		switch part.Type {
+		case SignaturePartTypeEOA:
+			recovered, err := part.Recover(subDigest)
+			if err != nil || recovered != signerInfo.Address {
+				return nil, nil, fmt.Errorf("%w: signature %d is not of signer %v", ErrInvalidSignaturePart, i, signerInfo.Address.Hex())
+			}
+			part.Address = recovered
+		case SignaturePartTypeAddress:
+			if part.Address != signerInfo.Address {
+				return nil, nil, fmt.Errorf("%w: part %d is %v, not signer %v", ErrInvalidSignaturePart, i, part.Address.Hex(), signerInfo.Address.Hex())
+			}
+		default:
+			return nil, nil, fmt.Errorf("%w: unsupported part type %d of signer %v", ErrInvalidSignaturePart, part.Type, signerInfo.Address.Hex())
+		}
+	}
+
+	return sig, subDigest, nil
+}
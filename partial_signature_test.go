@@ -0,0 +1,100 @@
+package sequence_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// keySigner is a remote signer signing with a local key, as ie. a KMS would.
+type keySigner struct {
+	address common.Address
+	key     *ethwallet.Wallet
+}
+
+func (s *keySigner) Address() common.Address { return s.address }
+
+func (s *keySigner) SignDigest(ctx context.Context, digest common.Hash) ([]byte, error) {
+	return crypto.Sign(digest[:], s.key.PrivateKey())
+}
+
+func TestPartialSignatures(t *testing.T) {
+	ctx := context.Background()
+
+	var owners []*ethwallet.Wallet
+	config := sequence.WalletConfig{Threshold: 2}
+	for i := uint64(1); i <= 3; i++ {
+		owner, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(i))
+		assert.NoError(t, err)
+		owners = append(owners, owner)
+		config.Signers = append(config.Signers, sequence.WalletConfigSigner{Weight: 1, Address: owner.Address()})
+	}
+
+	// each party holds one signer of the wallet, the third through a remote signer
+	alice, err := sequence.NewWallet(sequence.WalletOptions{Config: config}, owners[0])
+	assert.NoError(t, err)
+	alice.SetChainID(big.NewInt(1))
+	bob, err := alice.UseSigners(owners[1])
+	assert.NoError(t, err)
+	bob.SetChainID(big.NewInt(1))
+	carol, err := alice.UseSigners()
+	assert.NoError(t, err)
+	carol.SetChainID(big.NewInt(1))
+	carol, err = carol.UseRemoteSigners(&keySigner{address: owners[2].Address(), key: owners[2]})
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1), carol.GetSignerWeight())
+
+	txns := sequence.Transactions{{To: common.HexToAddress("0x7a7"), RevertOnError: true}}
+	signed, err := alice.SignTransactionsWithNonce(ctx, txns, big.NewInt(0))
+	assert.NoError(t, err)
+
+	weight, err := alice.SignatureWeight(signed)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(1), weight)
+	_, err = alice.FinalizeTransactions(signed)
+	assert.ErrorIs(t, err, sequence.ErrThresholdNotMet)
+
+	// the partial signature is passed on serialized, and cosigned by the others
+	encoded, err := sequence.EncodeSignedTransactions(signed)
+	assert.NoError(t, err)
+	decoded, err := sequence.DecodeSignedTransactions(encoded)
+	assert.NoError(t, err)
+
+	cosigned, err := bob.CosignTransactions(ctx, decoded)
+	assert.NoError(t, err)
+	cosigned, err = carol.CosignTransactions(ctx, cosigned)
+	assert.NoError(t, err)
+	weight, err = carol.SignatureWeight(cosigned)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(3), weight)
+	assert.Equal(t, signed.Digest, cosigned.Digest)
+
+	// finalizing prunes the signatures in excess of the threshold
+	finalized, err := alice.FinalizeTransactions(cosigned)
+	assert.NoError(t, err)
+	weight, err = alice.SignatureWeight(finalized)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(2), weight)
+	assert.Less(t, len(finalized.Signature), len(cosigned.Signature))
+
+	// signatures which are not of the signers of the wallet config are rejected
+	mallory, err := carol.UseRemoteSigners(&keySigner{address: owners[1].Address(), key: owners[2]})
+	assert.NoError(t, err)
+	_, err = mallory.CosignTransactions(ctx, signed)
+	assert.ErrorIs(t, err, sequence.ErrInvalidSignaturePart)
+
+	forged := signed.Clone()
+	forged.Signature = cosigned.Signature
+	forged.Transactions = sequence.Transactions{{To: common.HexToAddress("0xbad"), RevertOnError: true}}
+	forged.Digest, err = (&sequence.Transaction{Transactions: forged.Transactions, Nonce: forged.Nonce}).Digest()
+	assert.NoError(t, err)
+	_, err = alice.FinalizeTransactions(forged)
+	assert.ErrorIs(t, err, sequence.ErrInvalidSignaturePart)
+}
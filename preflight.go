@@ -0,0 +1,113 @@
+package sequence
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence/contracts"
+)
+
+// PinBlock returns ctx with the reads of the calls made with it pinned to the latest block of
+// provider, see CallOpts, and the block they are pinned to. A ctx whose reads are pinned
+// already is returned as is, so operations pinning their reads can be nested.
+func PinBlock(ctx context.Context, provider *ethrpc.Provider) (context.Context, *big.Int, error) {
+	opts, _ := CallOptsFromContext(ctx)
+	if opts.BlockNumber != nil {
+		return ctx, opts.BlockNumber, nil
+	}
+
+	blockNum, err := provider.BlockNumber(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sequence: unable to pin block: %w", err)
+	}
+	opts.BlockNumber = new(big.Int).SetUint64(blockNum)
+	return WithCallOpts(ctx, opts), opts.BlockNumber, nil
+}
+
+// WalletState is the state of a wallet at a block, see Wallet.Preflight.
+type WalletState struct {
+	BlockNumber *big.Int
+
+	Deployed bool
+	Nonce    *big.Int
+
+	// ImageHash is the image hash in the storage of the wallet, or the zero hash if it never
+	// updated its config, see ImageHashOfWallet.
+	ImageHash common.Hash
+
+	Balance *big.Int
+
+	// TokenBalances are the balances of the ERC-20 tokens of the wallet read by Preflight.
+	TokenBalances map[common.Address]*big.Int
+}
+
+// Preflight reads the state of the wallet which a bundle is prepared with, ie. its nonce,
+// image hash and balances of tokens, all at the same block so decisions are not made on state
+// of different blocks as the chain progresses. The reads are made at the block ctx is pinned
+// to, or else the latest block, see PinBlock.
+func (w *Wallet) Preflight(ctx context.Context, tokens ...common.Address) (*WalletState, error) {
+	provider := w.providerOf(ctx)
+	if provider == nil {
+		return nil, ErrProviderNotSet
+	}
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	ctx, blockNum, err := PinBlock(ctx, provider)
+	if err != nil {
+		return nil, fmt.Errorf("sequence.Wallet#Preflight: %w", err)
+	}
+	state := &WalletState{BlockNumber: blockNum, TokenBalances: make(map[common.Address]*big.Int, len(tokens))}
+
+	code, err := provider.CodeAt(ctx, w.address, blockNum)
+	if err != nil {
+		return nil, fmt.Errorf("sequence.Wallet#Preflight: %w", err)
+	}
+	state.Deployed = len(code) > 2
+
+	state.Nonce = big.NewInt(0)
+	if state.Deployed {
+		state.Nonce, err = GetWalletAddressNonce(provider, w.address, nil, blockNum)
+		if err != nil {
+			return nil, fmt.Errorf("sequence.Wallet#Preflight: %w", err)
+		}
+		state.ImageHash, err = ImageHashOfWallet(ctx, provider, w.address)
+		if err != nil {
+			return nil, fmt.Errorf("sequence.Wallet#Preflight: %w", err)
+		}
+	}
+
+	state.Balance, err = provider.BalanceAt(ctx, w.address, blockNum)
+	if err != nil {
+		return nil, fmt.Errorf("sequence.Wallet#Preflight: %w", err)
+	}
+	for _, token := range tokens {
+		balance, err := tokenBalanceAt(ctx, provider, token, w.address, blockNum)
+		if err != nil {
+			return nil, fmt.Errorf("sequence.Wallet#Preflight: balance of %v: %w", token.Hex(), err)
+		}
+		state.TokenBalances[token] = balance
+	}
+
+	return state, nil
+}
+
+func tokenBalanceAt(ctx context.Context, provider *ethrpc.Provider, token common.Address, account common.Address, blockNum *big.Int) (*big.Int, error) {
+	data, err := contracts.IERC20.Encode("balanceOf", account)
+	if err != nil {
+		return nil, err
+	}
+	res, err := provider.CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	values, err := contracts.IERC20.ABI.Unpack("balanceOf", res)
+	if err != nil {
+		return nil, err
+	}
+	return values[0].(*big.Int), nil
+}
@@ -0,0 +1,100 @@
+package sequence_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalletPreflight(t *testing.T) {
+	ctx := context.Background()
+	owner, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(owner)
+	assert.NoError(t, err)
+	token := common.HexToAddress("0x7a7")
+
+	// a chain at block 100, on which the wallet is deployed and its image hash published,
+	// which records the block of each read
+	chain := memchain.New(memchain.Options{ChainID: big.NewInt(1)})
+	for chain.Head() < 100 {
+		chain.Mine()
+	}
+	chain.Deploy(wallet.Address(), memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		if bytes.HasPrefix(input, contracts.WalletMainModule.ABI.Methods["readNonce"].ID) {
+			return common.BigToHash(big.NewInt(7)).Bytes(), nil
+		}
+		return common.HexToHash("0x1234").Bytes(), nil
+	}))
+	chain.SetCode(wallet.Address(), common.FromHex("0x363d3d"))
+	chain.Fund(wallet.Address(), big.NewInt(16))
+	chain.Deploy(wallet.GetWalletContext().UtilsAddress, memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		return common.HexToHash("0x1234").Bytes(), nil
+	}))
+	chain.Deploy(token, memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		assert.True(t, bytes.HasPrefix(input, contracts.IERC20.ABI.Methods["balanceOf"].ID))
+		return common.BigToHash(big.NewInt(1000)).Bytes(), nil
+	}))
+
+	var blocks []string
+	var blockNumbers int
+	chain.Intercept(func(method string, params []json.RawMessage, next func() (interface{}, error)) (interface{}, error) {
+		if method == "eth_blockNumber" {
+			blockNumbers++
+		}
+		if len(params) > 1 {
+			var block string
+			_ = json.Unmarshal(params[1], &block)
+			blocks = append(blocks, method+"@"+block)
+		}
+		return next()
+	})
+	provider := chain.Provider()
+	assert.NoError(t, wallet.SetProvider(provider))
+
+	// the state of the wallet is read at the latest block
+	state, err := wallet.Preflight(ctx, token)
+	assert.NoError(t, err)
+	assert.Equal(t, &sequence.WalletState{
+		BlockNumber:   big.NewInt(100),
+		Deployed:      true,
+		Nonce:         big.NewInt(7),
+		ImageHash:     common.HexToHash("0x1234"),
+		Balance:       big.NewInt(16),
+		TokenBalances: map[common.Address]*big.Int{token: big.NewInt(1000)},
+	}, state)
+	assert.Equal(t, 1, blockNumbers)
+	assert.Equal(t, []string{"eth_getCode@0x64", "eth_getCode@0x64", "eth_call@0x64", "eth_call@0x64", "eth_getBalance@0x64", "eth_call@0x64"}, blocks)
+
+	// or at the block the reads are pinned to
+	blocks = nil
+	pinned, blockNum, err := sequence.PinBlock(sequence.WithCallOpts(ctx, sequence.CallOpts{BlockNumber: big.NewInt(50)}), provider)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(50), blockNum)
+	state, err = wallet.Preflight(pinned)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(50), state.BlockNumber)
+	assert.Equal(t, 1, blockNumbers)
+	for _, block := range blocks {
+		assert.Contains(t, block, "@0x32")
+	}
+
+	// which the reads of other calls with the pinned context are consistent with
+	blocks = nil
+	pinned, blockNum, err = sequence.PinBlock(ctx, provider)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(100), blockNum)
+	_, err = wallet.PublishedImageHash(pinned)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"eth_call@0x64"}, blocks)
+}
@@ -0,0 +1,196 @@
+package sequence
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// BackpressurePolicy is what a bounded queue does when it is full.
+type BackpressurePolicy int
+
+const (
+	// BackpressureBlock blocks the producer until the queue has room, which in turn slows
+	// down the consumption of new blocks.
+	BackpressureBlock BackpressurePolicy = iota
+
+	// BackpressureDropOldest drops the oldest queued items to make room for new ones. The
+	// dropped items are lost.
+	BackpressureDropOldest
+
+	// BackpressureError fails with ErrQueueFull, stopping the component.
+	BackpressureError
+)
+
+func (p BackpressurePolicy) String() string {
+	switch p {
+	case BackpressureBlock:
+		return "block"
+	case BackpressureDropOldest:
+		return "drop-oldest"
+	case BackpressureError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	ErrQueueFull = errors.New("sequence: queue is full")
+
+	errQueueClosed = errors.New("sequence: queue is closed")
+)
+
+// boundedQueue is a FIFO queue bounded by its number of items and by their estimated size
+// in bytes, which applies its BackpressurePolicy when it is full. A single item larger than
+// maxBytes is accepted into an empty queue, so it never blocks forever.
+type boundedQueue[T any] struct {
+	maxItems int
+	maxBytes int64
+	policy   BackpressurePolicy
+	onDrop   func(item T)
+
+	items   []queuedItem[T]
+	bytes   int64
+	closed  bool
+	err     error
+	changed chan struct{}
+	mu      sync.Mutex
+}
+
+type queuedItem[T any] struct {
+	item T
+	size int64
+}
+
+// newBoundedQueue returns a queue of at most maxItems items and maxBytes bytes, where zero
+// is unbounded. onDrop, if not nil, is called with the items dropped by
+// BackpressureDropOldest.
+func newBoundedQueue[T any](maxItems int, maxBytes int64, policy BackpressurePolicy, onDrop func(item T)) *boundedQueue[T] {
+	return &boundedQueue[T]{
+		maxItems: maxItems,
+		maxBytes: maxBytes,
+		policy:   policy,
+		onDrop:   onDrop,
+		changed:  make(chan struct{}),
+	}
+}
+
+// push appends item of the estimated size to the queue, applying the policy of the queue
+// if it is full.
+func (q *boundedQueue[T]) push(ctx context.Context, item T, size int64) error {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return errQueueClosed
+		}
+
+		var dropped []T
+		if !q.fits(size) {
+			switch q.policy {
+			case BackpressureDropOldest:
+				for !q.fits(size) {
+					dropped = append(dropped, q.items[0].item)
+					q.bytes -= q.items[0].size
+					q.items = q.items[1:]
+				}
+			case BackpressureError:
+				q.mu.Unlock()
+				return ErrQueueFull
+			default:
+				changed := q.changed
+				q.mu.Unlock()
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-changed:
+				}
+				continue
+			}
+		}
+
+		q.items = append(q.items, queuedItem[T]{item: item, size: size})
+		q.bytes += size
+		q.signal()
+		q.mu.Unlock()
+
+		if q.onDrop != nil {
+			for _, item := range dropped {
+				q.onDrop(item)
+			}
+		}
+		return nil
+	}
+}
+
+// pop removes and returns the oldest item of the queue, blocking until there is one. Once
+// the queue is closed and empty, it returns the error the queue was closed with.
+func (q *boundedQueue[T]) pop(ctx context.Context) (T, error) {
+	for {
+		q.mu.Lock()
+		if len(q.items) > 0 {
+			item := q.items[0]
+			q.items = q.items[1:]
+			q.bytes -= item.size
+			q.signal()
+			q.mu.Unlock()
+			return item.item, nil
+		}
+		if q.closed {
+			err := q.err
+			q.mu.Unlock()
+			var zero T
+			if err == nil {
+				err = errQueueClosed
+			}
+			return zero, err
+		}
+		changed := q.changed
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// close stops the queue from accepting items. The items already queued may still be popped,
+// after which pop returns err, or errQueueClosed if nil.
+func (q *boundedQueue[T]) close(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	q.err = err
+	q.signal()
+}
+
+func (q *boundedQueue[T]) size() (int, int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items), q.bytes
+}
+
+// fits returns true if an item of size may be appended. It must be called with mu held.
+func (q *boundedQueue[T]) fits(size int64) bool {
+	if len(q.items) == 0 {
+		return true
+	}
+	if q.maxItems > 0 && len(q.items) >= q.maxItems {
+		return false
+	}
+	return q.maxBytes <= 0 || q.bytes+size <= q.maxBytes
+}
+
+// signal wakes up the producers and consumers waiting on the queue. It must be called with
+// mu held.
+func (q *boundedQueue[T]) signal() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
@@ -2,11 +2,15 @@ package sequence
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/0xsequence/ethkit"
 	"github.com/0xsequence/ethkit/ethreceipts"
+	"github.com/0xsequence/ethkit/ethrpc"
 	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
 	"github.com/0xsequence/ethkit/go-ethereum/core/types"
 )
 
@@ -17,30 +21,138 @@ type MetaTxnResult struct {
 }
 
 func FetchMetaTransactionReceipt(ctx context.Context, receiptListener *ethreceipts.ReceiptsListener, metaTxnID MetaTxnID, optTimeout ...time.Duration) (*MetaTxnResult, *ethreceipts.Receipt, ethreceipts.WaitReceiptFinalityFunc, error) {
-	// Use optional timeout if passed, otherwise use deadline on the provided ctx, or finally,
-	// set a default timeout of 200 seconds.
-	var cancel context.CancelFunc
-	if len(optTimeout) > 0 {
-		ctx, cancel = context.WithTimeout(ctx, optTimeout[0])
-		defer cancel()
-	} else {
-		if _, ok := ctx.Deadline(); !ok {
-			ctx, cancel = context.WithTimeout(ctx, 200*time.Second)
-			defer cancel()
+	ctx, cancel := withWaitTimeout(ctx, optTimeout)
+	defer cancel()
+
+	metaTxnHash := common.HexToHash(string(metaTxnID))
+	return fetchMetaTransactionReceipt(ctx, receiptListener, metaTxnID, FilterMetaTransactionID(metaTxnHash))
+}
+
+// WaitMetaTransactionReceipt fetches the receipt of metaTxnID as FetchMetaTransactionReceipt
+// does, and then waits for waitOptions.Confirmations blocks on top of it, re-validating
+// with provider that its block is still canonical. If the receipt was reorged out, it
+// reports MetaTxnReorged to waitOptions.OnStatus, and resumes watching for the
+// meta-transaction until it is mined again in a block which stays canonical.
+func WaitMetaTransactionReceipt(ctx context.Context, receiptListener *ethreceipts.ReceiptsListener, provider *ethrpc.Provider, metaTxnID MetaTxnID, waitOptions WaitOptions, optTimeout ...time.Duration) (*MetaTxnResult, *ethreceipts.Receipt, error) {
+	ctx, cancel := withWaitTimeout(ctx, optTimeout)
+	defer cancel()
+
+	notify := func(status MetaTxnStatus) {
+		if waitOptions.OnStatus != nil {
+			waitOptions.OnStatus(metaTxnID, status)
 		}
 	}
 
 	metaTxnHash := common.HexToHash(string(metaTxnID))
-	receipt, waitFinality, err := receiptListener.FetchTransactionReceiptWithFilter(ctx, FilterMetaTransactionID(metaTxnHash).LimitOne(true).SearchCache(true))
+	var reorged []common.Hash
+	for {
+		result, receipt, _, err := fetchMetaTransactionReceipt(ctx, receiptListener, metaTxnID, filterMetaTransactionIDExcluding(metaTxnHash, reorged))
+		if err != nil {
+			return nil, nil, err
+		}
+		notify(result.Status)
+		if waitOptions.Confirmations == 0 {
+			return result, receipt, nil
+		}
+
+		err = ConfirmReceipt(ctx, provider, receipt.Receipt(), waitOptions.Confirmations, waitOptions.PollInterval)
+		if err == nil {
+			return result, receipt, nil
+		}
+		if !errors.Is(err, ErrReceiptReorged) {
+			return nil, nil, err
+		}
+		reorged = append(reorged, receipt.BlockHash())
+		notify(MetaTxnReorged)
+	}
+}
+
+// ErrReceiptReorged is returned when the block of a receipt is no longer canonical.
+var ErrReceiptReorged = errors.New("sequence: receipt was reorged out")
+
+const defaultConfirmationsPollInterval = time.Second
+
+// ConfirmReceipt waits until confirmations blocks are mined on top of the block of receipt,
+// polling provider every pollInterval, or every second if zero. It returns
+// ErrReceiptReorged as soon as the block of receipt is no longer canonical.
+func ConfirmReceipt(ctx context.Context, provider *ethrpc.Provider, receipt *types.Receipt, confirmations uint64, pollInterval time.Duration) error {
+	if pollInterval <= 0 {
+		pollInterval = defaultConfirmationsPollInterval
+	}
+	confirmedAt := receipt.BlockNumber.Uint64() + confirmations
+
+	for {
+		// the hash is checked before the head, so a receipt is never confirmed by a head
+		// which is past a reorg of its block
+		if err := checkReceiptCanonical(ctx, provider, receipt); err != nil {
+			return err
+		}
+
+		head, err := provider.BlockNumber(ctx)
+		if err != nil {
+			return fmt.Errorf("sequence: failed to get block number: %w", err)
+		}
+		if head >= confirmedAt {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// checkReceiptCanonical returns ErrReceiptReorged if the block of receipt is no longer
+// canonical.
+func checkReceiptCanonical(ctx context.Context, provider *ethrpc.Provider, receipt *types.Receipt) error {
+	var block *blockHash
+	err := provider.Do(ctx, ethrpc.NewCallBuilder[*blockHash]("eth_getBlockByNumber", nil, hexutil.EncodeBig(receipt.BlockNumber), false).Into(&block))
+	if err != nil {
+		return fmt.Errorf("sequence: failed to get block %v: %w", receipt.BlockNumber, err)
+	}
+	if block == nil || block.Hash != receipt.BlockHash {
+		return fmt.Errorf("%w: block %v of %v is no longer canonical", ErrReceiptReorged, receipt.BlockHash, receipt.TxHash)
+	}
+	return nil
+}
+
+// blockHash is a block fetched for its hash only, which the vendored types.Header can't
+// compute for blocks with fields added after London.
+type blockHash struct {
+	Hash common.Hash `json:"hash"`
+}
+
+// withWaitTimeout uses the optional timeout if passed, otherwise the deadline of ctx, or
+// finally, a default timeout of 200 seconds.
+func withWaitTimeout(ctx context.Context, optTimeout []time.Duration) (context.Context, context.CancelFunc) {
+	if len(optTimeout) > 0 {
+		return context.WithTimeout(ctx, optTimeout[0])
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		return context.WithTimeout(ctx, 200*time.Second)
+	}
+	return ctx, func() {}
+}
+
+func fetchMetaTransactionReceipt(ctx context.Context, receiptListener *ethreceipts.ReceiptsListener, metaTxnID MetaTxnID, filter ethreceipts.FilterQuery) (*MetaTxnResult, *ethreceipts.Receipt, ethreceipts.WaitReceiptFinalityFunc, error) {
+	metaTxnHash := common.HexToHash(string(metaTxnID))
+	receipt, waitFinality, err := receiptListener.FetchTransactionReceiptWithFilter(ctx, filter.LimitOne(true).SearchCache(true))
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
+	return metaTxnResultOf(metaTxnID, metaTxnHash, receipt.Logs()), receipt, waitFinality, nil
+}
+
+// metaTxnResultOf returns the status of metaTxnID from the logs of the receipt it was mined in.
+func metaTxnResultOf(metaTxnID MetaTxnID, metaTxnHash common.Hash, logs []*types.Log) *MetaTxnResult {
 	result := &MetaTxnResult{
 		MetaTxnID: metaTxnID,
 	}
 
-	for _, log := range receipt.Logs() {
+	for _, log := range logs {
 		isTxExecuted := IsTxExecutedEvent(log, metaTxnHash)
 		isTxFailed := IsTxFailedEvent(log, metaTxnHash)
 		if isTxExecuted {
@@ -53,7 +165,7 @@ func FetchMetaTransactionReceipt(ctx context.Context, receiptListener *ethreceip
 		}
 	}
 
-	return result, receipt, waitFinality, nil
+	return result
 }
 
 func FilterMetaTransactionID(metaTxnID ethkit.Hash) ethreceipts.FilterQuery {
@@ -70,6 +182,27 @@ func FilterMetaTransactionID(metaTxnID ethkit.Hash) ethreceipts.FilterQuery {
 	})
 }
 
+// filterMetaTransactionIDExcluding matches metaTxnID, except in the blocks with the hashes
+// of excluded, ie. blocks which were reorged out but may still be cached.
+func filterMetaTransactionIDExcluding(metaTxnID ethkit.Hash, excluded []common.Hash) ethreceipts.FilterQuery {
+	if len(excluded) == 0 {
+		return FilterMetaTransactionID(metaTxnID)
+	}
+	return ethreceipts.FilterLogs(func(logs []*types.Log) bool {
+		for _, log := range logs {
+			for _, blockHash := range excluded {
+				if log.BlockHash == blockHash {
+					return false
+				}
+			}
+			if IsTxExecutedEvent(log, metaTxnID) || IsTxFailedEvent(log, metaTxnID) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
 // Find any Sequence meta txns
 func FilterMetaTransactionAny() ethreceipts.FilterQuery {
 	return ethreceipts.FilterLogs(func(logs []*types.Log) bool {
@@ -0,0 +1,69 @@
+package sequence_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfirmReceipt(t *testing.T) {
+	ctx := context.Background()
+
+	// a chain whose head advances by one block each time it is polled, and on which the block
+	// of the receipt, mined at block 100, is replaced once the head reaches reorgAt
+	serve := func(reorgAt uint64) (*memchain.Chain, *types.Receipt) {
+		chain := memchain.New(memchain.Options{})
+		for chain.Head() < 99 {
+			chain.Mine()
+		}
+		eoa, err := testutil.MemChainEOA(chain, 1, big.NewInt(1e18))
+		assert.NoError(t, err)
+		to := common.HexToAddress("0x7a7")
+		txn, err := eoa.NewTransaction(ctx, &ethtxn.TransactionRequest{To: &to, ETHValue: big.NewInt(1)})
+		assert.NoError(t, err)
+		txn, _, err = eoa.SendTransaction(ctx, txn)
+		assert.NoError(t, err)
+		receipt, err := chain.Provider().TransactionReceipt(ctx, txn.Hash())
+		assert.NoError(t, err)
+
+		chain.Intercept(func(method string, params []json.RawMessage, next func() (interface{}, error)) (interface{}, error) {
+			if method == "eth_blockNumber" {
+				chain.Mine()
+				if head := chain.Head(); head == reorgAt {
+					assert.NoError(t, chain.Reorg(int(head-receipt.BlockNumber.Uint64()+1)))
+				}
+			}
+			return next()
+		})
+		return chain, receipt
+	}
+
+	chain, receipt := serve(0)
+	assert.Equal(t, uint64(100), receipt.BlockNumber.Uint64())
+	assert.NoError(t, sequence.ConfirmReceipt(ctx, chain.Provider(), receipt, 3, time.Millisecond))
+	assert.Equal(t, uint64(103), chain.Head())
+
+	chain, receipt = serve(102)
+	err := sequence.ConfirmReceipt(ctx, chain.Provider(), receipt, 5, time.Millisecond)
+	assert.True(t, errors.Is(err, sequence.ErrReceiptReorged), err)
+	assert.Equal(t, uint64(102), chain.Head())
+
+	chain, receipt = serve(0)
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	err = sequence.ConfirmReceipt(ctx, chain.Provider(), receipt, 1000, 5*time.Millisecond)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+
+	assert.Equal(t, "reorged", sequence.MetaTxnReorged.String())
+}
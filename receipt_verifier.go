@@ -0,0 +1,177 @@
+package sequence
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/rlp"
+	"github.com/0xsequence/go-sequence/core/trie"
+)
+
+// ReceiptTrustMode is whether a component uses the receipts returned by its provider as-is,
+// or verifies them first.
+type ReceiptTrustMode int
+
+const (
+	// TrustProviderReceipts uses receipts as returned by the provider. It is the default.
+	TrustProviderReceipts ReceiptTrustMode = iota
+
+	// VerifyReceiptsRoot checks receipts against the receipts root of their block header,
+	// so a provider can't forge or alter the status and logs of a transaction, ie. to report
+	// a failed settlement as executed.
+	VerifyReceiptsRoot
+)
+
+// ReceiptTrust configures how a component verifies the receipts fetched from its provider.
+// Options are passed per component, so each provider can be trusted differently.
+type ReceiptTrust struct {
+	Mode ReceiptTrustMode
+
+	// Headers is the provider which block headers are fetched from. If nil, headers are
+	// fetched from the same provider as receipts, which catches receipts which are
+	// inconsistent with their block (ie. from a stale cache or a faulty backend behind a
+	// load-balancer), but not a provider which is consistently lying. Set it to a provider
+	// you trust, such as your own node, to verify receipts from third-party providers.
+	Headers *ethrpc.Provider
+}
+
+// ErrReceiptNotVerified is returned when a receipt doesn't match the receipts root of its
+// block header.
+var ErrReceiptNotVerified = errors.New("sequence: receipt does not match the receipts root of its block")
+
+// VerifyReceipt verifies receipt according to o.ReceiptTrust, fetching the receipts of its
+// block from provider. It returns nil without any request if receipts are trusted.
+func (o Options) VerifyReceipt(ctx context.Context, provider *ethrpc.Provider, receipt *types.Receipt) error {
+	if o.ReceiptTrust.Mode != VerifyReceiptsRoot {
+		return nil
+	}
+	headers := o.ReceiptTrust.Headers
+	if headers == nil {
+		headers = provider
+	}
+	return VerifyReceipt(ctx, provider, headers, receipt)
+}
+
+// VerifyReceipt checks that receipt is included in its block, by fetching all the receipts
+// of the block from provider and the block header from headers, and comparing the receipts
+// root of the header with the root of the receipts.
+//
+// Only the consensus fields of receipts are committed to by the receipts root: the status,
+// cumulative gas used, logs bloom and logs. The other fields, such as the transaction hash
+// and gas used, are derived by the provider and are not verified.
+func VerifyReceipt(ctx context.Context, provider, headers *ethrpc.Provider, receipt *types.Receipt) error {
+	if receipt == nil {
+		return fmt.Errorf("sequence: receipt is nil")
+	}
+
+	header, err := headers.HeaderByHash(ctx, receipt.BlockHash)
+	if err != nil {
+		return fmt.Errorf("sequence: failed to get header of block %v: %w", receipt.BlockHash, err)
+	}
+	if receipt.BlockNumber != nil && header.Number.Cmp(receipt.BlockNumber) != 0 {
+		return fmt.Errorf("%w: block %v is number %v, not %v", ErrReceiptNotVerified, receipt.BlockHash, header.Number, receipt.BlockNumber)
+	}
+
+	receipts, err := BlockReceipts(ctx, provider, receipt.BlockHash)
+	if err != nil {
+		return err
+	}
+
+	if root := ReceiptsRoot(receipts); root != header.ReceiptHash {
+		return fmt.Errorf("%w: receipts of block %v have root %v, header has %v", ErrReceiptNotVerified, receipt.BlockHash, root, header.ReceiptHash)
+	}
+
+	if receipt.TransactionIndex >= uint(len(receipts)) {
+		return fmt.Errorf("%w: block %v has no transaction %d", ErrReceiptNotVerified, receipt.BlockHash, receipt.TransactionIndex)
+	}
+	included := receipts[receipt.TransactionIndex]
+	if included.TxHash != receipt.TxHash || !bytes.Equal(encodeConsensusReceipt(included), encodeConsensusReceipt(receipt)) {
+		return fmt.Errorf("%w: receipt of %v differs from the one in block %v", ErrReceiptNotVerified, receipt.TxHash, receipt.BlockHash)
+	}
+	return nil
+}
+
+// BlockReceipts fetches the receipts of all the transactions of the block with blockHash,
+// in their order in the block.
+func BlockReceipts(ctx context.Context, provider *ethrpc.Provider, blockHash common.Hash) ([]*types.Receipt, error) {
+	var block *blockTransactions
+	err := provider.Do(ctx, ethrpc.NewCallBuilder[*blockTransactions]("eth_getBlockByHash", nil, blockHash, false).Into(&block))
+	if err != nil {
+		return nil, fmt.Errorf("sequence: failed to get block %v: %w", blockHash, err)
+	}
+	if block == nil {
+		return nil, fmt.Errorf("sequence: block %v not found", blockHash)
+	}
+	if len(block.Transactions) == 0 {
+		return nil, nil
+	}
+
+	receipts := make([]*types.Receipt, len(block.Transactions))
+	calls := make([]ethrpc.Call, len(block.Transactions))
+	for i, txHash := range block.Transactions {
+		calls[i] = ethrpc.TransactionReceipt(txHash).Into(&receipts[i])
+	}
+	if err := provider.Do(ctx, calls...); err != nil {
+		return nil, fmt.Errorf("sequence: failed to get receipts of block %v: %w", blockHash, err)
+	}
+
+	for i, receipt := range receipts {
+		if receipt == nil {
+			return nil, fmt.Errorf("sequence: receipt of %v not found", block.Transactions[i])
+		}
+		if receipt.BlockHash != blockHash {
+			// the block was reorged out while fetching its receipts
+			return nil, fmt.Errorf("sequence: receipt of %v is in block %v, not %v", receipt.TxHash, receipt.BlockHash, blockHash)
+		}
+	}
+	return receipts, nil
+}
+
+// blockTransactions is a block fetched without its transactions, ie. with only their hashes.
+type blockTransactions struct {
+	Transactions []common.Hash `json:"transactions"`
+}
+
+// ReceiptsRoot computes the receipts root of a block header from the receipts of all its
+// transactions, in their order in the block.
+func ReceiptsRoot(receipts []*types.Receipt) common.Hash {
+	return types.DeriveSha(consensusReceipts(receipts), trie.NewHasher())
+}
+
+// consensusReceipts encodes receipts of any transaction type, unlike types.Receipts which
+// only encodes the types known to the vendored go-ethereum.
+type consensusReceipts []*types.Receipt
+
+func (rs consensusReceipts) Len() int { return len(rs) }
+
+func (rs consensusReceipts) EncodeIndex(i int, w *bytes.Buffer) {
+	w.Write(encodeConsensusReceipt(rs[i]))
+}
+
+type consensusLog struct {
+	Address common.Address
+	Topics  []common.Hash
+	Data    []byte
+}
+
+func encodeConsensusReceipt(r *types.Receipt) []byte {
+	status := r.PostState
+	if len(status) == 0 && r.Status == types.ReceiptStatusSuccessful {
+		status = []byte{0x01}
+	}
+	logs := make([]consensusLog, len(r.Logs))
+	for i, log := range r.Logs {
+		logs[i] = consensusLog{Address: log.Address, Topics: log.Topics, Data: log.Data}
+	}
+
+	data, _ := rlp.EncodeToBytes([]interface{}{status, r.CumulativeGasUsed, r.Bloom, logs})
+	if r.Type == types.LegacyTxType {
+		return data
+	}
+	return append([]byte{r.Type}, data...)
+}
@@ -0,0 +1,177 @@
+package sequence_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+// receiptsNode serves a block of receipts of several types, which memchain doesn't mine, by
+// intercepting the requests to a chain, with tamper applied to the receipts it returns, but
+// not to the receipts root of the block header.
+type receiptsNode struct {
+	header   *types.Header
+	receipts []*types.Receipt
+	tamper   func(*types.Receipt)
+	requests int32
+}
+
+func newReceiptsNode() *receiptsNode {
+	blockHash := common.HexToHash("0xb10c")
+	wallet := common.HexToAddress("0xf0ba65550f2d1dccf4b131b774844dc3d801d886")
+
+	var receipts []*types.Receipt
+	for i, txType := range []uint8{types.LegacyTxType, types.DynamicFeeTxType, 3} {
+		receipt := &types.Receipt{
+			Type:              txType,
+			Status:            uint64(i % 2),
+			CumulativeGasUsed: uint64(21000 * (i + 1)),
+			TxHash:            common.BigToHash(big.NewInt(int64(i + 1))),
+			BlockHash:         blockHash,
+			BlockNumber:       big.NewInt(100),
+			TransactionIndex:  uint(i),
+			GasUsed:           21000,
+			Logs: []*types.Log{{
+				Address:     wallet,
+				Topics:      []common.Hash{sequence.NonceChangeEventSig},
+				Data:        common.BigToHash(big.NewInt(int64(i))).Bytes(),
+				BlockNumber: 100,
+				TxHash:      common.BigToHash(big.NewInt(int64(i + 1))),
+				TxIndex:     uint(i),
+				BlockHash:   blockHash,
+				Index:       uint(i),
+			}},
+		}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		receipts = append(receipts, receipt)
+	}
+
+	return &receiptsNode{
+		header: &types.Header{
+			Difficulty:  big.NewInt(0),
+			Number:      big.NewInt(100),
+			ReceiptHash: sequence.ReceiptsRoot(receipts),
+		},
+		receipts: receipts,
+	}
+}
+
+func (n *receiptsNode) serve() *ethrpc.Provider {
+	chain := memchain.New(memchain.Options{})
+	chain.Intercept(func(method string, params []json.RawMessage, next func() (interface{}, error)) (interface{}, error) {
+		atomic.AddInt32(&n.requests, 1)
+
+		switch method {
+		case "eth_getBlockByHash":
+			header, _ := json.Marshal(n.header)
+			block := map[string]interface{}{}
+			_ = json.Unmarshal(header, &block)
+			txns := make([]common.Hash, len(n.receipts))
+			for j, receipt := range n.receipts {
+				txns[j] = receipt.TxHash
+			}
+			block["hash"] = n.receipts[0].BlockHash
+			block["transactions"] = txns
+			return block, nil
+		case "trace_filter":
+			// a call of each transaction, and one which reverted
+			traces := []map[string]interface{}{{"transactionHash": common.HexToHash("0xdead"), "error": "Reverted"}}
+			for _, receipt := range n.receipts {
+				traces = append(traces, map[string]interface{}{"transactionHash": receipt.TxHash, "blockNumber": 100})
+			}
+			return traces, nil
+		case "eth_getTransactionReceipt":
+			var txHash common.Hash
+			_ = json.Unmarshal(params[0], &txHash)
+			for _, receipt := range n.receipts {
+				if receipt.TxHash == txHash {
+					return n.served(receipt), nil
+				}
+			}
+			return nil, nil
+		}
+		return next()
+	})
+	return chain.Provider()
+}
+
+func (n *receiptsNode) served(receipt *types.Receipt) *types.Receipt {
+	if n.tamper == nil {
+		return receipt
+	}
+	data, _ := json.Marshal(receipt)
+	var tampered *types.Receipt
+	_ = json.Unmarshal(data, &tampered)
+	n.tamper(tampered)
+	return tampered
+}
+
+func TestVerifyReceipt(t *testing.T) {
+	ctx := context.Background()
+	node := newReceiptsNode()
+	provider := node.serve()
+
+	for _, receipt := range node.receipts {
+		assert.NoError(t, sequence.VerifyReceipt(ctx, provider, provider, receipt))
+	}
+
+	receipts, err := sequence.BlockReceipts(ctx, provider, node.receipts[0].BlockHash)
+	assert.NoError(t, err)
+	assert.Equal(t, node.header.ReceiptHash, sequence.ReceiptsRoot(receipts))
+
+	// a receipt which isn't the one in the block
+	forged := node.served(node.receipts[0])
+	forged.Status = types.ReceiptStatusSuccessful
+	err = sequence.VerifyReceipt(ctx, provider, provider, forged)
+	assert.True(t, errors.Is(err, sequence.ErrReceiptNotVerified), err)
+
+	// a provider which alters the logs of a receipt in the block
+	node.tamper = func(receipt *types.Receipt) {
+		if receipt.TransactionIndex == 1 {
+			receipt.Logs[0].Data = common.BigToHash(big.NewInt(42)).Bytes()
+		}
+	}
+	err = sequence.VerifyReceipt(ctx, provider, provider, node.served(node.receipts[1]))
+	assert.True(t, errors.Is(err, sequence.ErrReceiptNotVerified), err)
+}
+
+func TestOptionsVerifyReceipt(t *testing.T) {
+	ctx := context.Background()
+
+	// receipts are from an untrusted provider, and headers from a trusted one
+	untrusted := newReceiptsNode()
+	untrusted.tamper = func(receipt *types.Receipt) {
+		receipt.Status = types.ReceiptStatusSuccessful
+	}
+	trusted := newReceiptsNode()
+	provider, headers := untrusted.serve(), trusted.serve()
+
+	// untrusted also serves a header matching its tampered receipts
+	tampered := make([]*types.Receipt, len(untrusted.receipts))
+	for i, receipt := range untrusted.receipts {
+		tampered[i] = untrusted.served(receipt)
+	}
+	untrusted.header.ReceiptHash = sequence.ReceiptsRoot(tampered)
+	receipt := tampered[0]
+
+	options := sequence.NewOptions()
+	assert.NoError(t, options.VerifyReceipt(ctx, provider, receipt))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&untrusted.requests))
+
+	options = sequence.NewOptions(sequence.WithReceiptTrust(sequence.ReceiptTrust{Mode: sequence.VerifyReceiptsRoot}))
+	assert.NoError(t, options.VerifyReceipt(ctx, provider, receipt))
+
+	options = sequence.NewOptions(sequence.WithReceiptTrust(sequence.ReceiptTrust{Mode: sequence.VerifyReceiptsRoot, Headers: headers}))
+	err := options.VerifyReceipt(ctx, provider, receipt)
+	assert.True(t, errors.Is(err, sequence.ErrReceiptNotVerified), err)
+}
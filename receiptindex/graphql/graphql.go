@@ -0,0 +1,407 @@
+// Package graphql serves a receiptindex.Index over GraphQL, so frontends can query the
+// activity of wallets, look up meta-transactions and aggregate stats in a single request:
+//
+//	http.Handle("/graphql", graphql.NewHandler(index))
+//
+//	query {
+//	  history(wallet: "0x...", status: [FAILED], limit: 10) {
+//	    entries { id txnHash timestamp transactions { to value } }
+//	    cursor
+//	  }
+//	  stats(wallet: "0x...") { metaTxns failed }
+//	}
+//
+// It is a separate module, so depending on go-sequence does not require graphql-go.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/receiptindex"
+	gql "github.com/graph-gophers/graphql-go"
+)
+
+const (
+	// MaxHistoryLimit is the largest page of history served.
+	MaxHistoryLimit = 1000
+
+	// maxDepth limits the nesting of queries, which only nested transactions can grow.
+	maxDepth = 16
+
+	// maxRequestBodySize limits the size of request bodies.
+	maxRequestBodySize = 1 << 20
+)
+
+// Schema is the GraphQL schema served by Handler.
+const Schema = `
+schema {
+	query: Query
+}
+
+type Query {
+	# history returns the meta-transactions of a wallet, newest first, continuing from the
+	# cursor of a previous page.
+	history(wallet: String!, status: [Status!], since: Time, until: Time, contract: String, limit: Int, cursor: String): HistoryPage!
+
+	# metaTxn returns a meta-transaction by id, or null if it is not indexed.
+	metaTxn(id: String!): MetaTxn
+
+	# stats aggregates the meta-transactions of a wallet, or of all wallets.
+	stats(wallet: String, since: Time, until: Time): Stats!
+}
+
+scalar Time
+
+# Uint64 is an unsigned 64 bits integer, ie. a block number, encoded as a JSON number.
+scalar Uint64
+
+enum Status {
+	UNKNOWN
+	EXECUTED
+	FAILED
+	REVERTED
+}
+
+type HistoryPage {
+	entries: [MetaTxn!]!
+	cursor: String
+}
+
+type MetaTxn {
+	id: String!
+	wallet: String!
+	status: Status!
+	reason: String
+	txnHash: String!
+	blockNumber: Uint64!
+	transactionIndex: Int!
+	timestamp: Time!
+	transactions: [Transaction!]!
+}
+
+type Transaction {
+	to: String!
+	value: String!
+	data: String!
+	gasLimit: String!
+	delegateCall: Boolean!
+	revertOnError: Boolean!
+	transactions: [Transaction!]!
+}
+
+type Stats {
+	metaTxns: Int!
+	executed: Int!
+	failed: Int!
+	reverted: Int!
+	wallets: Int!
+	firstBlock: Uint64
+	lastBlock: Uint64
+	first: Time
+	last: Time
+}
+`
+
+// Handler is an http.Handler serving GraphQL queries of an index, POSTed as JSON.
+type Handler struct {
+	schema *gql.Schema
+}
+
+var _ http.Handler = &Handler{}
+
+func NewHandler(index receiptindex.Index) *Handler {
+	return &Handler{
+		schema: gql.MustParseSchema(Schema, &resolver{index: index}, gql.MaxDepth(maxDepth)),
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "graphql: queries must be POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+	var params struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestBodySize)).Decode(&params); err != nil {
+		http.Error(w, fmt.Sprintf("graphql: invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	response := h.schema.Exec(r.Context(), params.Query, params.OperationName, params.Variables)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+type resolver struct {
+	index receiptindex.Index
+}
+
+func (r *resolver) History(ctx context.Context, args struct {
+	Wallet   string
+	Status   *[]string
+	Since    *gql.Time
+	Until    *gql.Time
+	Contract *string
+	Limit    *int32
+	Cursor   *string
+}) (*historyPageResolver, error) {
+	wallet, err := address("wallet", args.Wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := sequence.HistoryOptions{Limit: sequence.DefaultHistoryLimit}
+	if args.Status != nil {
+		for _, status := range *args.Status {
+			opts.Status = append(opts.Status, parseStatus(status))
+		}
+	}
+	if args.Since != nil {
+		opts.Since = args.Since.Time
+	}
+	if args.Until != nil {
+		opts.Until = args.Until.Time
+	}
+	if args.Contract != nil {
+		contract, err := address("contract", *args.Contract)
+		if err != nil {
+			return nil, err
+		}
+		opts.Contract = &contract
+	}
+	if args.Limit != nil {
+		if *args.Limit <= 0 || *args.Limit > MaxHistoryLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", MaxHistoryLimit)
+		}
+		opts.Limit = int(*args.Limit)
+	}
+	if args.Cursor != nil {
+		opts.Cursor = *args.Cursor
+	}
+
+	page, err := r.index.History(ctx, wallet, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &historyPageResolver{wallet: wallet, page: page}, nil
+}
+
+func (r *resolver) MetaTxn(ctx context.Context, args struct{ ID string }) (*metaTxnResolver, error) {
+	entry, err := r.index.MetaTxn(ctx, sequence.MetaTxnID(args.ID))
+	if errors.Is(err, receiptindex.ErrNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &metaTxnResolver{wallet: entry.Wallet, entry: &entry.HistoryEntry}, nil
+}
+
+func (r *resolver) Stats(ctx context.Context, args struct {
+	Wallet *string
+	Since  *gql.Time
+	Until  *gql.Time
+}) (*statsResolver, error) {
+	var opts receiptindex.StatsOptions
+	if args.Wallet != nil {
+		wallet, err := address("wallet", *args.Wallet)
+		if err != nil {
+			return nil, err
+		}
+		opts.Wallet = &wallet
+	}
+	if args.Since != nil {
+		opts.Since = args.Since.Time
+	}
+	if args.Until != nil {
+		opts.Until = args.Until.Time
+	}
+
+	stats, err := r.index.Stats(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &statsResolver{stats: stats}, nil
+}
+
+type historyPageResolver struct {
+	wallet common.Address
+	page   *sequence.HistoryPage
+}
+
+func (r *historyPageResolver) Entries() []*metaTxnResolver {
+	entries := make([]*metaTxnResolver, len(r.page.Entries))
+	for i, entry := range r.page.Entries {
+		entries[i] = &metaTxnResolver{wallet: r.wallet, entry: entry}
+	}
+	return entries
+}
+
+func (r *historyPageResolver) Cursor() *string {
+	if r.page.Cursor == "" {
+		return nil
+	}
+	return &r.page.Cursor
+}
+
+type metaTxnResolver struct {
+	wallet common.Address
+	entry  *sequence.HistoryEntry
+}
+
+func (r *metaTxnResolver) ID() string                   { return string(r.entry.MetaTxnID) }
+func (r *metaTxnResolver) Wallet() string               { return r.wallet.Hex() }
+func (r *metaTxnResolver) Status() string               { return statusName(r.entry.Status) }
+func (r *metaTxnResolver) TxnHash() string              { return r.entry.TxnHash.Hex() }
+func (r *metaTxnResolver) BlockNumber() Uint64          { return Uint64(r.entry.BlockNumber) }
+func (r *metaTxnResolver) TransactionIndex() int32      { return int32(r.entry.TransactionIndex) }
+func (r *metaTxnResolver) Timestamp() gql.Time          { return gql.Time{Time: r.entry.Timestamp} }
+func (r *metaTxnResolver) Transactions() []*txnResolver { return txnResolvers(r.entry.Transactions) }
+
+func (r *metaTxnResolver) Reason() *string {
+	if r.entry.Reason == "" {
+		return nil
+	}
+	return &r.entry.Reason
+}
+
+type txnResolver struct {
+	txn *sequence.Transaction
+}
+
+func txnResolvers(txns sequence.Transactions) []*txnResolver {
+	resolvers := make([]*txnResolver, len(txns))
+	for i, txn := range txns {
+		resolvers[i] = &txnResolver{txn: txn}
+	}
+	return resolvers
+}
+
+func (r *txnResolver) To() string                   { return r.txn.To.Hex() }
+func (r *txnResolver) Value() string                { return bigString(r.txn.Value) }
+func (r *txnResolver) Data() string                 { return hexutil.Encode(r.txn.Data) }
+func (r *txnResolver) GasLimit() string             { return bigString(r.txn.GasLimit) }
+func (r *txnResolver) DelegateCall() bool           { return r.txn.DelegateCall }
+func (r *txnResolver) RevertOnError() bool          { return r.txn.RevertOnError }
+func (r *txnResolver) Transactions() []*txnResolver { return txnResolvers(r.txn.Transactions) }
+
+type statsResolver struct {
+	stats *receiptindex.Stats
+}
+
+func (r *statsResolver) MetaTxns() int32 { return int32(r.stats.MetaTxns) }
+func (r *statsResolver) Executed() int32 { return int32(r.stats.Executed) }
+func (r *statsResolver) Failed() int32   { return int32(r.stats.Failed) }
+func (r *statsResolver) Reverted() int32 { return int32(r.stats.Reverted) }
+func (r *statsResolver) Wallets() int32  { return int32(r.stats.Wallets) }
+
+func (r *statsResolver) FirstBlock() *Uint64 { return r.ifAny(Uint64(r.stats.FirstBlock)) }
+func (r *statsResolver) LastBlock() *Uint64  { return r.ifAny(Uint64(r.stats.LastBlock)) }
+
+func (r *statsResolver) First() *gql.Time {
+	if r.stats.MetaTxns == 0 {
+		return nil
+	}
+	return &gql.Time{Time: r.stats.First}
+}
+
+func (r *statsResolver) Last() *gql.Time {
+	if r.stats.MetaTxns == 0 {
+		return nil
+	}
+	return &gql.Time{Time: r.stats.Last}
+}
+
+func (r *statsResolver) ifAny(n Uint64) *Uint64 {
+	if r.stats.MetaTxns == 0 {
+		return nil
+	}
+	return &n
+}
+
+// Uint64 is the Uint64 scalar of the schema.
+type Uint64 uint64
+
+func (Uint64) ImplementsGraphQLType(name string) bool {
+	return name == "Uint64"
+}
+
+func (n *Uint64) UnmarshalGraphQL(input interface{}) error {
+	switch input := input.(type) {
+	case int32:
+		if input < 0 {
+			return fmt.Errorf("invalid Uint64 %d", input)
+		}
+		*n = Uint64(input)
+	case float64:
+		if input < 0 || input != float64(uint64(input)) {
+			return fmt.Errorf("invalid Uint64 %v", input)
+		}
+		*n = Uint64(input)
+	case string:
+		v, err := strconv.ParseUint(input, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid Uint64 %q", input)
+		}
+		*n = Uint64(v)
+	default:
+		return fmt.Errorf("invalid Uint64 %v", input)
+	}
+	return nil
+}
+
+func (n Uint64) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatUint(uint64(n), 10)), nil
+}
+
+func statusName(status sequence.MetaTxnStatus) string {
+	switch status {
+	case sequence.MetaTxnExecuted:
+		return "EXECUTED"
+	case sequence.MetaTxnFailed:
+		return "FAILED"
+	case sequence.MetaTxnReverted:
+		return "REVERTED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func parseStatus(name string) sequence.MetaTxnStatus {
+	switch name {
+	case "EXECUTED":
+		return sequence.MetaTxnExecuted
+	case "FAILED":
+		return sequence.MetaTxnFailed
+	case "REVERTED":
+		return sequence.MetaTxnReverted
+	default:
+		return sequence.MetaTxnStatusUnknown
+	}
+}
+
+func address(name, value string) (common.Address, error) {
+	if !common.IsHexAddress(value) {
+		return common.Address{}, fmt.Errorf("%s %q is not an address", name, value)
+	}
+	return common.HexToAddress(value), nil
+}
+
+func bigString(n *big.Int) string {
+	if n == nil {
+		return "0"
+	}
+	return n.String()
+}
@@ -0,0 +1,115 @@
+package graphql_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/receiptindex"
+	"github.com/0xsequence/go-sequence/receiptindex/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	index := receiptindex.NewMemoryIndex()
+
+	wallet := common.HexToAddress("0xa")
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, entry := range []sequence.HistoryEntry{
+		{MetaTxnID: "01", Status: sequence.MetaTxnExecuted},
+		{MetaTxnID: "02", Status: sequence.MetaTxnFailed, Reason: "out of gas"},
+		{MetaTxnID: "03", Status: sequence.MetaTxnExecuted},
+	} {
+		assert.NoError(t, index.Record(ctx, wallet, &sequence.HistoryEntry{
+			MetaTxnID:    entry.MetaTxnID,
+			Status:       entry.Status,
+			Reason:       entry.Reason,
+			BlockNumber:  uint64(5_000_000_000 + i),
+			Timestamp:    at.Add(time.Duration(i) * time.Minute),
+			Transactions: sequence.Transactions{{To: common.HexToAddress("0xc0"), Value: big.NewInt(int64(i)), Data: []byte{0x12}}},
+		}))
+	}
+
+	ts := httptest.NewServer(graphql.NewHandler(index))
+	defer ts.Close()
+
+	query := func(query string, variables map[string]interface{}, out interface{}) []json.RawMessage {
+		body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+		resp, err := http.Post(ts.URL, "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		var response struct {
+			Data   json.RawMessage   `json:"data"`
+			Errors []json.RawMessage `json:"errors"`
+		}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		if out != nil && len(response.Data) > 0 {
+			assert.NoError(t, json.Unmarshal(response.Data, out))
+		}
+		return response.Errors
+	}
+
+	var history struct {
+		History struct {
+			Entries []struct {
+				ID           string
+				Status       string
+				Reason       *string
+				BlockNumber  uint64
+				Timestamp    time.Time
+				Transactions []struct{ To, Value, Data string }
+			}
+			Cursor *string
+		}
+		Stats struct {
+			MetaTxns, Failed int
+			FirstBlock       uint64
+		}
+	}
+	errs := query(`query($wallet: String!) {
+		history(wallet: $wallet, limit: 2) {
+			entries { id status reason blockNumber timestamp transactions { to value data } }
+			cursor
+		}
+		stats(wallet: $wallet) { metaTxns failed firstBlock }
+	}`, map[string]interface{}{"wallet": wallet.Hex()}, &history)
+	assert.Empty(t, errs)
+	if assert.Len(t, history.History.Entries, 2) {
+		assert.Equal(t, "03", history.History.Entries[0].ID)
+		assert.Equal(t, uint64(5_000_000_002), history.History.Entries[0].BlockNumber)
+		assert.Equal(t, at.Add(2*time.Minute), history.History.Entries[0].Timestamp)
+		assert.Equal(t, "2", history.History.Entries[0].Transactions[0].Value)
+		assert.Equal(t, "0x12", history.History.Entries[0].Transactions[0].Data)
+		assert.Equal(t, "FAILED", history.History.Entries[1].Status)
+		assert.Equal(t, "out of gas", *history.History.Entries[1].Reason)
+	}
+	assert.NotNil(t, history.History.Cursor)
+	assert.Equal(t, 3, history.Stats.MetaTxns)
+	assert.Equal(t, 1, history.Stats.Failed)
+	assert.Equal(t, uint64(5_000_000_000), history.Stats.FirstBlock)
+
+	var lookup struct {
+		Found   *struct{ Wallet, Status string }
+		Missing *struct{ ID string }
+	}
+	errs = query(`{
+		found: metaTxn(id: "01") { wallet status }
+		missing: metaTxn(id: "04") { id }
+	}`, nil, &lookup)
+	assert.Empty(t, errs)
+	assert.Equal(t, wallet.Hex(), lookup.Found.Wallet)
+	assert.Equal(t, "EXECUTED", lookup.Found.Status)
+	assert.Nil(t, lookup.Missing)
+
+	// invalid arguments are reported as errors
+	errs = query(`{ history(wallet: "0x01", limit: 5000) { cursor } }`, nil, nil)
+	assert.Len(t, errs, 1)
+}
@@ -0,0 +1,237 @@
+// Package receiptindex indexes the executed meta-transactions of wallets, so their history,
+// lookups by metaTxnID and aggregate stats are served without scanning the chain. An Index is
+// a sequence.HistoryIndex, so it can serve Wallet.History:
+//
+//	index := receiptindex.NewSQLIndex(db)
+//	err := index.CreateTables(ctx)
+//	err = index.Record(ctx, wallet, entry)
+//	wallet, err := sequence.NewWallet(sequence.WalletOptions{..., Options: []sequence.Option{sequence.WithHistoryIndex(index)}})
+package receiptindex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+)
+
+var ErrNotFound = errors.New("receiptindex: meta-transaction not found")
+
+// Index is an index of the meta-transactions executed by wallets.
+//
+// MemoryIndex and SQLIndex are provided, and other databases plug in by implementing Index.
+type Index interface {
+	sequence.HistoryIndex
+
+	// Record indexes entry, a meta-transaction of wallet, replacing the entry of the same
+	// MetaTxnID if it was already indexed, ie. when its block was reorged.
+	Record(ctx context.Context, wallet common.Address, entry *sequence.HistoryEntry) error
+
+	// MetaTxn returns the entry of metaTxnID, or ErrNotFound.
+	MetaTxn(ctx context.Context, metaTxnID sequence.MetaTxnID) (*Entry, error)
+
+	// Stats aggregates the entries passing the filters of opts.
+	Stats(ctx context.Context, opts StatsOptions) (*Stats, error)
+}
+
+// Entry is an indexed meta-transaction of Wallet.
+type Entry struct {
+	Wallet common.Address `json:"wallet"`
+	sequence.HistoryEntry
+}
+
+// StatsOptions filter the entries aggregated by Index.Stats.
+type StatsOptions struct {
+	// Wallet only aggregates the entries of a wallet, or of all wallets if nil.
+	Wallet *common.Address
+
+	// Since and Until bound the time of the block a meta-transaction was included in.
+	Since, Until time.Time
+}
+
+// Stats are aggregates of indexed meta-transactions. The blocks and times are zero if no
+// meta-transaction was aggregated.
+type Stats struct {
+	MetaTxns int `json:"metaTxns"`
+	Executed int `json:"executed"`
+	Failed   int `json:"failed"`
+	Reverted int `json:"reverted"`
+	Wallets  int `json:"wallets"`
+
+	FirstBlock uint64    `json:"firstBlock"`
+	LastBlock  uint64    `json:"lastBlock"`
+	First      time.Time `json:"first"`
+	Last       time.Time `json:"last"`
+}
+
+// MemoryIndex is an Index in memory.
+type MemoryIndex struct {
+	entries map[sequence.MetaTxnID]*Entry
+	mu      sync.RWMutex
+}
+
+var _ Index = &MemoryIndex{}
+
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{entries: map[sequence.MetaTxnID]*Entry{}}
+}
+
+func (i *MemoryIndex) Record(ctx context.Context, wallet common.Address, entry *sequence.HistoryEntry) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.entries[entry.MetaTxnID] = &Entry{Wallet: wallet, HistoryEntry: *entry}
+	return nil
+}
+
+func (i *MemoryIndex) MetaTxn(ctx context.Context, metaTxnID sequence.MetaTxnID) (*Entry, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	entry, ok := i.entries[metaTxnID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrNotFound, metaTxnID)
+	}
+	copied := *entry
+	return &copied, nil
+}
+
+func (i *MemoryIndex) History(ctx context.Context, wallet common.Address, opts sequence.HistoryOptions) (*sequence.HistoryPage, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = sequence.DefaultHistoryLimit
+	}
+	var after *cursor
+	if opts.Cursor != "" {
+		c, err := parseCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = &c
+	}
+
+	i.mu.RLock()
+	var entries []*sequence.HistoryEntry
+	for _, entry := range i.entries {
+		if entry.Wallet != wallet || !opts.Match(&entry.HistoryEntry) {
+			continue
+		}
+		if after != nil && !after.before(&entry.HistoryEntry) {
+			continue
+		}
+		copied := entry.HistoryEntry
+		entries = append(entries, &copied)
+	}
+	i.mu.RUnlock()
+
+	sort.Slice(entries, func(a, b int) bool {
+		return cursorOf(entries[a]).before(entries[b])
+	})
+	return newPage(entries, opts.Limit), nil
+}
+
+func (i *MemoryIndex) Stats(ctx context.Context, opts StatsOptions) (*Stats, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	stats := &Stats{}
+	wallets := map[common.Address]bool{}
+	match := sequence.HistoryOptions{Since: opts.Since, Until: opts.Until}
+	for _, entry := range i.entries {
+		if (opts.Wallet != nil && entry.Wallet != *opts.Wallet) || !match.Match(&entry.HistoryEntry) {
+			continue
+		}
+		wallets[entry.Wallet] = true
+		stats.add(&entry.HistoryEntry)
+	}
+	stats.Wallets = len(wallets)
+	return stats, nil
+}
+
+func (s *Stats) add(entry *sequence.HistoryEntry) {
+	s.MetaTxns++
+	switch entry.Status {
+	case sequence.MetaTxnExecuted:
+		s.Executed++
+	case sequence.MetaTxnFailed:
+		s.Failed++
+	case sequence.MetaTxnReverted:
+		s.Reverted++
+	}
+	if s.MetaTxns == 1 || entry.BlockNumber < s.FirstBlock {
+		s.FirstBlock = entry.BlockNumber
+	}
+	if entry.BlockNumber > s.LastBlock {
+		s.LastBlock = entry.BlockNumber
+	}
+	if s.First.IsZero() || entry.Timestamp.Before(s.First) {
+		s.First = entry.Timestamp
+	}
+	if entry.Timestamp.After(s.Last) {
+		s.Last = entry.Timestamp
+	}
+}
+
+// newPage returns the first limit entries, which are sorted newest first, with the cursor of
+// the last one if there are more.
+func newPage(entries []*sequence.HistoryEntry, limit int) *sequence.HistoryPage {
+	page := &sequence.HistoryPage{Entries: entries}
+	if len(entries) > limit {
+		page.Entries = entries[:limit]
+		page.Cursor = cursorOf(entries[limit-1]).String()
+	}
+	if page.Entries == nil {
+		page.Entries = []*sequence.HistoryEntry{}
+	}
+	return page
+}
+
+// cursor is the position of an entry in the history, which is ordered by block, then
+// transaction index, then metaTxnID, as the meta-transactions of nested bundles share their
+// transaction.
+type cursor struct {
+	block     uint64
+	index     uint
+	metaTxnID sequence.MetaTxnID
+}
+
+func cursorOf(entry *sequence.HistoryEntry) cursor {
+	return cursor{block: entry.BlockNumber, index: entry.TransactionIndex, metaTxnID: entry.MetaTxnID}
+}
+
+// before reports whether entry is older than the position of c.
+func (c cursor) before(entry *sequence.HistoryEntry) bool {
+	if entry.BlockNumber != c.block {
+		return entry.BlockNumber < c.block
+	}
+	if entry.TransactionIndex != c.index {
+		return entry.TransactionIndex < c.index
+	}
+	return entry.MetaTxnID < c.metaTxnID
+}
+
+func (c cursor) String() string {
+	return fmt.Sprintf("%d:%d:%s", c.block, c.index, c.metaTxnID)
+}
+
+func parseCursor(s string) (cursor, error) {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) != 3 {
+		return cursor{}, fmt.Errorf("receiptindex: invalid cursor %q", s)
+	}
+	block, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return cursor{}, fmt.Errorf("receiptindex: invalid cursor %q", s)
+	}
+	index, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return cursor{}, fmt.Errorf("receiptindex: invalid cursor %q", s)
+	}
+	return cursor{block: block, index: uint(index), metaTxnID: sequence.MetaTxnID(parts[2])}, nil
+}
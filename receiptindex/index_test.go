@@ -0,0 +1,75 @@
+package receiptindex_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/receiptindex"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryIndex(t *testing.T) {
+	ctx := context.Background()
+	index := receiptindex.NewMemoryIndex()
+
+	wallet, other := common.HexToAddress("0xa"), common.HexToAddress("0xb")
+	contract := common.HexToAddress("0xc0")
+	now := time.Unix(1_700_000_000, 0)
+	record := func(wallet common.Address, id string, block uint64, status sequence.MetaTxnStatus, txns sequence.Transactions) {
+		assert.NoError(t, index.Record(ctx, wallet, &sequence.HistoryEntry{
+			MetaTxnID:    sequence.MetaTxnID(id),
+			Status:       status,
+			BlockNumber:  block,
+			Timestamp:    now.Add(time.Duration(block) * time.Second),
+			Transactions: txns,
+		}))
+	}
+	record(wallet, "01", 10, sequence.MetaTxnExecuted, sequence.Transactions{{To: contract}})
+	record(wallet, "02", 11, sequence.MetaTxnFailed, nil)
+	// the meta-transactions of a nested bundle share their transaction
+	record(wallet, "03", 12, sequence.MetaTxnExecuted, sequence.Transactions{{Transactions: sequence.Transactions{{To: contract}}}})
+	record(wallet, "04", 12, sequence.MetaTxnExecuted, nil)
+	record(other, "05", 13, sequence.MetaTxnExecuted, nil)
+
+	var ids []sequence.MetaTxnID
+	opts := sequence.HistoryOptions{Limit: 3}
+	for {
+		page, err := index.History(ctx, wallet, opts)
+		assert.NoError(t, err)
+		for _, entry := range page.Entries {
+			ids = append(ids, entry.MetaTxnID)
+		}
+		if page.Cursor == "" {
+			break
+		}
+		opts.Cursor = page.Cursor
+	}
+	assert.Equal(t, []sequence.MetaTxnID{"04", "03", "02", "01"}, ids)
+
+	page, err := index.History(ctx, wallet, sequence.HistoryOptions{Contract: &contract})
+	assert.NoError(t, err)
+	assert.Len(t, page.Entries, 2)
+	assert.Empty(t, page.Cursor)
+
+	entry, err := index.MetaTxn(ctx, "05")
+	assert.NoError(t, err)
+	assert.Equal(t, other, entry.Wallet)
+	_, err = index.MetaTxn(ctx, "06")
+	assert.ErrorIs(t, err, receiptindex.ErrNotFound)
+
+	stats, err := index.Stats(ctx, receiptindex.StatsOptions{Since: now.Add(11 * time.Second)})
+	assert.NoError(t, err)
+	assert.Equal(t, &receiptindex.Stats{
+		MetaTxns:   4,
+		Executed:   3,
+		Failed:     1,
+		Wallets:    2,
+		FirstBlock: 11,
+		LastBlock:  13,
+		First:      now.Add(11 * time.Second),
+		Last:       now.Add(13 * time.Second),
+	}, stats)
+}
@@ -0,0 +1,271 @@
+package receiptindex
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+)
+
+// SQLIndex is an Index in the tables sequence_meta_txns and sequence_meta_txn_contracts of a
+// SQL database, which CreateTables creates. It only uses portable SQL, and the driver of the
+// database is left to the caller, ie. sqlite, mysql or postgres.
+type SQLIndex struct {
+	db     *sql.DB
+	dollar bool
+}
+
+var _ Index = &SQLIndex{}
+
+func NewSQLIndex(db *sql.DB) *SQLIndex {
+	return &SQLIndex{db: db}
+}
+
+// UseDollarPlaceholders binds the arguments of queries to $1, $2... as postgres does,
+// instead of to ?.
+func (i *SQLIndex) UseDollarPlaceholders() *SQLIndex {
+	i.dollar = true
+	return i
+}
+
+// CreateTables creates the tables of the index, if they don't exist. Indexes are left to the
+// caller, as their syntax isn't portable: large indexes should index sequence_meta_txns on
+// (wallet, block_number), and sequence_meta_txn_contracts on contract.
+func (i *SQLIndex) CreateTables(ctx context.Context) error {
+	for _, query := range []string{
+		`CREATE TABLE IF NOT EXISTS sequence_meta_txns (
+			meta_txn_id VARCHAR(66) PRIMARY KEY,
+			wallet VARCHAR(42) NOT NULL,
+			status INTEGER NOT NULL,
+			reason TEXT NOT NULL,
+			txn_hash VARCHAR(66) NOT NULL,
+			block_number BIGINT NOT NULL,
+			txn_index INTEGER NOT NULL,
+			block_time BIGINT NOT NULL,
+			transactions TEXT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS sequence_meta_txn_contracts (meta_txn_id VARCHAR(66) NOT NULL, contract VARCHAR(42) NOT NULL)`,
+	} {
+		if _, err := i.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("receiptindex: %w", err)
+		}
+	}
+	return nil
+}
+
+// Record indexes entry with the contracts called by its transactions, nested ones included,
+// which History filters on.
+func (i *SQLIndex) Record(ctx context.Context, wallet common.Address, entry *sequence.HistoryEntry) error {
+	txns, err := json.Marshal(entry.Transactions)
+	if err != nil {
+		return err
+	}
+
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("receiptindex: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		`DELETE FROM sequence_meta_txns WHERE meta_txn_id = ?`,
+		`DELETE FROM sequence_meta_txn_contracts WHERE meta_txn_id = ?`,
+	} {
+		if _, err := tx.ExecContext(ctx, i.bind(query), string(entry.MetaTxnID)); err != nil {
+			return fmt.Errorf("receiptindex: %w", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, i.bind(`INSERT INTO sequence_meta_txns (meta_txn_id, wallet, status, reason, txn_hash, block_number, txn_index, block_time, transactions) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		string(entry.MetaTxnID), wallet.Hex(), int(entry.Status), entry.Reason, entry.TxnHash.Hex(), entry.BlockNumber, entry.TransactionIndex, entry.Timestamp.Unix(), string(txns),
+	)
+	if err != nil {
+		return fmt.Errorf("receiptindex: %w", err)
+	}
+
+	for contract := range contractsOf(entry.Transactions, map[common.Address]bool{}) {
+		if _, err := tx.ExecContext(ctx, i.bind(`INSERT INTO sequence_meta_txn_contracts (meta_txn_id, contract) VALUES (?, ?)`), string(entry.MetaTxnID), contract.Hex()); err != nil {
+			return fmt.Errorf("receiptindex: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("receiptindex: %w", err)
+	}
+	return nil
+}
+
+const entryColumns = `wallet, meta_txn_id, status, reason, txn_hash, block_number, txn_index, block_time, transactions`
+
+func (i *SQLIndex) MetaTxn(ctx context.Context, metaTxnID sequence.MetaTxnID) (*Entry, error) {
+	rows, err := i.db.QueryContext(ctx, i.bind(`SELECT `+entryColumns+` FROM sequence_meta_txns WHERE meta_txn_id = ?`), string(metaTxnID))
+	if err != nil {
+		return nil, fmt.Errorf("receiptindex: %w", err)
+	}
+	entries, err := scanEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: %v", ErrNotFound, metaTxnID)
+	}
+	return entries[0], nil
+}
+
+func (i *SQLIndex) History(ctx context.Context, wallet common.Address, opts sequence.HistoryOptions) (*sequence.HistoryPage, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = sequence.DefaultHistoryLimit
+	}
+
+	where := []string{`wallet = ?`}
+	args := []interface{}{wallet.Hex()}
+	if len(opts.Status) > 0 {
+		placeholders := make([]string, len(opts.Status))
+		for n, status := range opts.Status {
+			placeholders[n] = "?"
+			args = append(args, int(status))
+		}
+		where = append(where, `status IN (`+strings.Join(placeholders, ", ")+`)`)
+	}
+	where, args = timeRange(where, args, opts.Since, opts.Until)
+	if opts.Contract != nil {
+		where = append(where, `meta_txn_id IN (SELECT meta_txn_id FROM sequence_meta_txn_contracts WHERE contract = ?)`)
+		args = append(args, opts.Contract.Hex())
+	}
+	if opts.Cursor != "" {
+		c, err := parseCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		where = append(where, `(block_number < ? OR (block_number = ? AND (txn_index < ? OR (txn_index = ? AND meta_txn_id < ?))))`)
+		args = append(args, c.block, c.block, c.index, c.index, string(c.metaTxnID))
+	}
+	args = append(args, opts.Limit+1)
+
+	rows, err := i.db.QueryContext(ctx, i.bind(`SELECT `+entryColumns+` FROM sequence_meta_txns WHERE `+strings.Join(where, ` AND `)+` ORDER BY block_number DESC, txn_index DESC, meta_txn_id DESC LIMIT ?`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("receiptindex: %w", err)
+	}
+	entries, err := scanEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]*sequence.HistoryEntry, len(entries))
+	for n, entry := range entries {
+		history[n] = &entry.HistoryEntry
+	}
+	return newPage(history, opts.Limit), nil
+}
+
+func (i *SQLIndex) Stats(ctx context.Context, opts StatsOptions) (*Stats, error) {
+	var where []string
+	var args []interface{}
+	if opts.Wallet != nil {
+		where = append(where, `wallet = ?`)
+		args = append(args, opts.Wallet.Hex())
+	}
+	where, args = timeRange(where, args, opts.Since, opts.Until)
+
+	query := `SELECT COUNT(*),
+		SUM(CASE WHEN status = ? THEN 1 ELSE 0 END),
+		SUM(CASE WHEN status = ? THEN 1 ELSE 0 END),
+		SUM(CASE WHEN status = ? THEN 1 ELSE 0 END),
+		COUNT(DISTINCT wallet),
+		MIN(block_number), MAX(block_number), MIN(block_time), MAX(block_time)
+		FROM sequence_meta_txns`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	args = append([]interface{}{int(sequence.MetaTxnExecuted), int(sequence.MetaTxnFailed), int(sequence.MetaTxnReverted)}, args...)
+
+	var stats Stats
+	var executed, failed, reverted, firstBlock, lastBlock, first, last sql.NullInt64
+	err := i.db.QueryRowContext(ctx, i.bind(query), args...).Scan(&stats.MetaTxns, &executed, &failed, &reverted, &stats.Wallets, &firstBlock, &lastBlock, &first, &last)
+	if err != nil {
+		return nil, fmt.Errorf("receiptindex: %w", err)
+	}
+	if stats.MetaTxns > 0 {
+		stats.Executed, stats.Failed, stats.Reverted = int(executed.Int64), int(failed.Int64), int(reverted.Int64)
+		stats.FirstBlock, stats.LastBlock = uint64(firstBlock.Int64), uint64(lastBlock.Int64)
+		stats.First, stats.Last = time.Unix(first.Int64, 0), time.Unix(last.Int64, 0)
+	}
+	return &stats, nil
+}
+
+// timeRange adds the conditions of the time bounds since and until. Timestamps are stored in
+// seconds, so since is rounded up as entries are included at or after it.
+func timeRange(where []string, args []interface{}, since, until time.Time) ([]string, []interface{}) {
+	if !since.IsZero() {
+		from := since.Unix()
+		if since.Nanosecond() > 0 {
+			from++
+		}
+		where = append(where, `block_time >= ?`)
+		args = append(args, from)
+	}
+	if !until.IsZero() {
+		where = append(where, `block_time <= ?`)
+		args = append(args, until.Unix())
+	}
+	return where, args
+}
+
+func scanEntries(rows *sql.Rows) ([]*Entry, error) {
+	defer rows.Close()
+
+	var entries []*Entry
+	for rows.Next() {
+		var entry Entry
+		var wallet, metaTxnID, txnHash, txns string
+		var status int
+		var timestamp int64
+		if err := rows.Scan(&wallet, &metaTxnID, &status, &entry.Reason, &txnHash, &entry.BlockNumber, &entry.TransactionIndex, &timestamp, &txns); err != nil {
+			return nil, fmt.Errorf("receiptindex: %w", err)
+		}
+		if err := json.Unmarshal([]byte(txns), &entry.Transactions); err != nil {
+			return nil, fmt.Errorf("receiptindex: invalid transactions of %v: %w", metaTxnID, err)
+		}
+		entry.Wallet = common.HexToAddress(wallet)
+		entry.MetaTxnID = sequence.MetaTxnID(metaTxnID)
+		entry.Status = sequence.MetaTxnStatus(status)
+		entry.TxnHash = common.HexToHash(txnHash)
+		entry.Timestamp = time.Unix(timestamp, 0)
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("receiptindex: %w", err)
+	}
+	return entries, nil
+}
+
+func contractsOf(txns sequence.Transactions, contracts map[common.Address]bool) map[common.Address]bool {
+	for _, txn := range txns {
+		contracts[txn.To] = true
+		contractsOf(txn.Transactions, contracts)
+	}
+	return contracts
+}
+
+func (i *SQLIndex) bind(query string) string {
+	if !i.dollar {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+		} else {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
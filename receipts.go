@@ -3,12 +3,12 @@ package sequence
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"fmt"
 	"math/big"
 
 	"github.com/0xsequence/ethkit/ethcoder"
 	"github.com/0xsequence/ethkit/ethrpc"
-	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
 	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/0xsequence/ethkit/go-ethereum/core/types"
 )
@@ -89,23 +89,69 @@ func IsTxFailedEvent(log *types.Log, hash common.Hash) bool {
 		bytes.HasPrefix(log.Data, hash[:])
 }
 
+// DecodeTxFailedEvent decodes the meta-transaction hash and revert reason of a TxFailed event.
+// It is decoded without the abi decoder, as listeners decode the events of every block.
 func DecodeTxFailedEvent(log *types.Log) (common.Hash, string, error) {
 	if len(log.Topics) != 1 || log.Topics[0] != TxFailedEventSig {
 		return common.Hash{}, "", fmt.Errorf("not a TxFailed event")
 	}
+	if len(log.Data) < 64 {
+		return common.Hash{}, "", fmt.Errorf("invalid TxFailed event data")
+	}
 
-	var hash common.Hash
-	var revert []byte
-	if err := ethcoder.AbiDecoder([]string{"bytes32", "bytes"}, log.Data, []interface{}{&hash, &revert}); err != nil {
-		return common.Hash{}, "", err
+	revert, err := decodeABIBytes(log.Data, 32)
+	if err != nil {
+		return common.Hash{}, "", fmt.Errorf("invalid TxFailed event data: %w", err)
 	}
 
-	reason, err := abi.UnpackRevert(revert)
+	reason, err := unpackRevert(revert)
 	if err != nil {
 		return common.Hash{}, "", err
 	}
 
-	return hash, reason, nil
+	return common.BytesToHash(log.Data[:32]), reason, nil
+}
+
+var revertSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+
+// unpackRevert decodes the reason of an Error(string) revert, as abi.UnpackRevert does.
+func unpackRevert(data []byte) (string, error) {
+	if !bytes.HasPrefix(data, revertSelector) {
+		return "", fmt.Errorf("invalid data for unpacking")
+	}
+	reason, err := decodeABIBytes(data[4:], 0)
+	if err != nil {
+		return "", err
+	}
+	return string(reason), nil
+}
+
+// decodeABIBytes returns the bytes or string whose offset is the word of data at head, without
+// copying them.
+func decodeABIBytes(data []byte, head int) ([]byte, error) {
+	offset, ok := decodeABILength(data, head)
+	if !ok || offset > uint64(len(data)) {
+		return nil, fmt.Errorf("abi: offset out of bounds")
+	}
+	length, ok := decodeABILength(data, int(offset))
+	if !ok || length > uint64(len(data))-offset-32 {
+		return nil, fmt.Errorf("abi: length out of bounds")
+	}
+	return data[offset+32 : offset+32+length], nil
+}
+
+// decodeABILength decodes the word of data at i as an offset or length, which must fit 64 bits.
+func decodeABILength(data []byte, i int) (uint64, bool) {
+	if i < 0 || len(data)-i < 32 {
+		return 0, false
+	}
+	word := data[i : i+32]
+	for _, b := range word[:24] {
+		if b != 0 {
+			return 0, false
+		}
+	}
+	return binary.BigEndian.Uint64(word[24:]), true
 }
 
 func DecodeNonceChangeEvent(log *types.Log) (*big.Int, *big.Int, error) {
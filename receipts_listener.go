@@ -0,0 +1,334 @@
+package sequence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+const (
+	// DefaultListenerPollInterval is the default interval a ReceiptsListener polls the head of
+	// the chain at, when it doesn't follow a subscription.
+	DefaultListenerPollInterval = 2 * time.Second
+
+	// DefaultListenerPastBlocks is the default number of blocks whose meta-transactions a
+	// ReceiptsListener remembers, so meta-transactions mined before they're waited for are
+	// still found.
+	DefaultListenerPastBlocks = 64
+
+	// listenerBlockRange is the number of blocks whose logs are fetched at once while polling.
+	listenerBlockRange = 100
+)
+
+// ReceiptsListener follows the logs of a chain and serves any number of concurrent waiters for
+// meta-transactions from the same stream, instead of each waiter polling the logs of the node
+// as LegacyWaitForMetaTxn does. The TxExecuted and TxFailed events of each block are indexed
+// by metaTxnID once, and only the receipts of the meta-transactions waited for are fetched.
+//
+// The logs are streamed from the subscriber of the listener, ie. an ethclient.Client dialed
+// over a websocket, see SetSubscriber. Without one, or while its subscription is down, the
+// logs of the blocks mined since the last ones handled are polled from the provider.
+type ReceiptsListener struct {
+	provider     *ethrpc.Provider
+	subscriber   ethereum.LogFilterer
+	pollInterval time.Duration
+	pastBlocks   uint64
+	options      Options
+
+	// waiters are the waiters indexed by the metaTxnID they wait for
+	waiters *MetaTxnIndex[*metaTxnWaiter]
+
+	// seen are the TxExecuted and TxFailed events of the last pastBlocks blocks, by the hash
+	// of their metaTxnID, in seenOrder as they were handled, and cursor the last block whose
+	// logs were all handled
+	seen      map[common.Hash]types.Log
+	seenOrder []seenMetaTxn
+	cursor    uint64
+	mu        sync.Mutex
+
+	ctxStop context.CancelFunc
+	running int32
+}
+
+var _ Runnable = &ReceiptsListener{}
+
+type metaTxnWaiter struct {
+	logs chan types.Log
+}
+
+type seenMetaTxn struct {
+	hash  common.Hash
+	block uint64
+}
+
+func NewReceiptsListener(provider *ethrpc.Provider, opts ...Option) *ReceiptsListener {
+	return &ReceiptsListener{
+		provider:     provider,
+		pollInterval: DefaultListenerPollInterval,
+		pastBlocks:   DefaultListenerPastBlocks,
+		options:      NewOptions(opts...),
+		waiters:      NewMetaTxnIndex[*metaTxnWaiter](),
+		seen:         map[common.Hash]types.Log{},
+	}
+}
+
+// SetSubscriber sets where the logs are streamed from, ie. an ethclient.Client dialed over a
+// websocket. It must be called before Run.
+func (l *ReceiptsListener) SetSubscriber(subscriber ethereum.LogFilterer) *ReceiptsListener {
+	l.subscriber = subscriber
+	return l
+}
+
+// SetPollInterval sets the interval the head of the chain is polled at, and the subscription
+// retried at once it failed. It must be called before Run.
+func (l *ReceiptsListener) SetPollInterval(interval time.Duration) *ReceiptsListener {
+	if interval > 0 {
+		l.pollInterval = interval
+	}
+	return l
+}
+
+// SetPastBlocks sets the number of blocks whose meta-transactions are remembered, and handled
+// when the listener starts. It must be called before Run.
+func (l *ReceiptsListener) SetPastBlocks(blocks uint64) *ReceiptsListener {
+	l.pastBlocks = blocks
+	return l
+}
+
+// Run follows the logs of the chain until ctx is done or the listener is stopped, from the
+// last blocks remembered, see SetPastBlocks.
+func (l *ReceiptsListener) Run(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&l.running, 0, 1) {
+		return fmt.Errorf("sequence.ReceiptsListener: already running")
+	}
+	defer atomic.StoreInt32(&l.running, 0)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	l.mu.Lock()
+	l.ctxStop = cancel
+	l.mu.Unlock()
+
+	head, err := l.provider.BlockNumber(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("sequence.ReceiptsListener: unable to fetch head: %w", err)
+	}
+	l.mu.Lock()
+	if l.cursor == 0 && head > l.pastBlocks {
+		l.cursor = head - l.pastBlocks
+	}
+	l.mu.Unlock()
+
+	for {
+		if l.subscriber != nil {
+			err := l.follow(ctx)
+			if ctx.Err() != nil {
+				return nil
+			}
+			l.options.Logger.Warnf("sequence.ReceiptsListener: subscription failed, polling: %v", err)
+			l.options.Metrics.IncCounter("receipts_listener.subscription.failed")
+		}
+
+		if err := l.poll(ctx); err != nil && ctx.Err() == nil {
+			l.options.Logger.Warnf("sequence.ReceiptsListener: %v", err)
+			l.options.Metrics.IncCounter("receipts_listener.poll.failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(l.pollInterval):
+		}
+	}
+}
+
+func (l *ReceiptsListener) Stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ctxStop != nil {
+		l.ctxStop()
+	}
+}
+
+func (l *ReceiptsListener) IsRunning() bool {
+	return atomic.LoadInt32(&l.running) == 1
+}
+
+// Checkpoint returns the last block whose logs were all handled by the listener.
+func (l *ReceiptsListener) Checkpoint() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.cursor
+}
+
+// WaitForMetaTxn waits for metaTxnID to be mined, and returns its status and the receipt of
+// the native transaction it was mined in. The meta-transaction is found among the blocks the
+// listener remembers, or the ones it handles next, so the listener must be running.
+func (l *ReceiptsListener) WaitForMetaTxn(ctx context.Context, metaTxnID MetaTxnID, optTimeout ...time.Duration) (*MetaTxnResult, *types.Receipt, error) {
+	var cancel context.CancelFunc
+	if len(optTimeout) > 0 {
+		ctx, cancel = context.WithTimeout(ctx, optTimeout[0])
+		defer cancel()
+	} else if _, ok := ctx.Deadline(); !ok {
+		ctx, cancel = context.WithTimeout(ctx, l.options.WaitTimeout(120*time.Second))
+		defer cancel()
+	}
+
+	metaTxnHash := common.HexToHash(string(metaTxnID))
+	waiter := &metaTxnWaiter{logs: make(chan types.Log, 1)}
+	l.waiters.Add(metaTxnID, waiter)
+	defer l.waiters.Remove(metaTxnID, waiter)
+
+	// the waiter is indexed first, so the logs handled from now on are sent to it
+	l.mu.Lock()
+	if log, ok := l.seen[metaTxnHash]; ok {
+		waiter.notify(log)
+	}
+	l.mu.Unlock()
+
+	var log types.Log
+	var retry <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, nil, fmt.Errorf("waiting for meta transaction timeout for %v: %w", metaTxnID, ctx.Err())
+			}
+			return nil, nil, fmt.Errorf("failed waiting for meta transaction for %v: %w", metaTxnID, ctx.Err())
+		case log = <-waiter.logs:
+		case <-retry:
+		}
+
+		// the receipt may not be served by the node yet, or reorged out, in which case it's
+		// fetched again unless another log of the meta-transaction is handled first
+		receipt, err := l.provider.TransactionReceipt(ctx, log.TxHash)
+		if err == nil && receipt != nil {
+			if result := metaTxnResultOf(metaTxnID, metaTxnHash, receipt.Logs); result.Status != MetaTxnStatusUnknown {
+				return result, receipt, nil
+			}
+		}
+		l.options.Metrics.IncCounter("receipts_listener.receipt.retry")
+		retry = time.After(l.pollInterval)
+	}
+}
+
+// follow handles the logs of the subscription of the listener, after the logs of the blocks
+// mined since the last ones handled, until the subscription fails or ctx is done.
+func (l *ReceiptsListener) follow(ctx context.Context) error {
+	logs := make(chan types.Log, 256)
+	sub, err := l.subscriber.SubscribeFilterLogs(ctx, ethereum.FilterQuery{}, logs)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	// the logs mined before the subscription started, some of which may be streamed too
+	if err := l.poll(ctx); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-sub.Err():
+			if err == nil {
+				err = fmt.Errorf("subscription closed")
+			}
+			return err
+		case log := <-logs:
+			// logs are streamed in order, so the blocks before the one of log are complete
+			through := uint64(0)
+			if log.BlockNumber > 0 {
+				through = log.BlockNumber - 1
+			}
+			l.handleLogs([]types.Log{log}, through)
+		}
+	}
+}
+
+// poll handles the logs of the blocks mined since the last ones handled.
+func (l *ReceiptsListener) poll(ctx context.Context) error {
+	head, err := l.provider.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to fetch head: %w", err)
+	}
+
+	for from := l.Checkpoint() + 1; from <= head; {
+		to := from + listenerBlockRange - 1
+		if to > head {
+			to = head
+		}
+		logs, err := l.provider.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(from),
+			ToBlock:   new(big.Int).SetUint64(to),
+		})
+		if err != nil {
+			return fmt.Errorf("unable to fetch logs of blocks %d to %d: %w", from, to, err)
+		}
+		l.handleLogs(logs, to)
+		from = to + 1
+	}
+	return nil
+}
+
+// handleLogs indexes the TxExecuted and TxFailed events of logs, and sends them to their
+// waiters. The logs of the blocks up to through are all handled once it returns.
+func (l *ReceiptsListener) handleLogs(logs []types.Log, through uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, log := range logs {
+		metaTxnHash, ok := metaTxnHashOfLog(&log)
+		if !ok {
+			continue
+		}
+		if log.Removed {
+			if seen, ok := l.seen[metaTxnHash]; ok && seen.TxHash == log.TxHash {
+				delete(l.seen, metaTxnHash)
+			}
+			continue
+		}
+
+		l.seen[metaTxnHash] = log
+		l.seenOrder = append(l.seenOrder, seenMetaTxn{hash: metaTxnHash, block: log.BlockNumber})
+		if _, waiters, ok := l.waiters.Match(&log); ok {
+			for _, waiter := range waiters {
+				waiter.notify(log)
+			}
+		}
+	}
+
+	if through > l.cursor {
+		l.cursor = through
+	}
+	for len(l.seenOrder) > 0 && l.seenOrder[0].block+l.pastBlocks <= l.cursor {
+		evicted := l.seenOrder[0]
+		if seen, ok := l.seen[evicted.hash]; ok && seen.BlockNumber == evicted.block {
+			delete(l.seen, evicted.hash)
+		}
+		l.seenOrder = l.seenOrder[1:]
+	}
+}
+
+// notify sends log to the waiter, replacing the log it wasn't sent yet. It must be called with
+// the mutex of the listener held.
+func (w *metaTxnWaiter) notify(log types.Log) {
+	select {
+	case <-w.logs:
+	default:
+	}
+	w.logs <- log
+}
@@ -0,0 +1,192 @@
+package sequence_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/event"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+// logsNode is a chain whose transactions execute meta-transactions, or fail them, which counts
+// the requests of its logs.
+type logsNode struct {
+	t       *testing.T
+	chain   *memchain.Chain
+	eoa     *ethwallet.Wallet
+	getLogs int
+	mu      sync.Mutex
+}
+
+// outcomes is the contract logging the outcomes of the meta-transactions of its input, a
+// meta-transaction ID followed by a word if it failed.
+var outcomes = common.HexToAddress("0xf0ba65550f2d1dccf4b131b774844dc3d801d886")
+
+// newLogsNode returns a node at block head.
+func newLogsNode(t *testing.T, head uint64) *logsNode {
+	chain := memchain.New(memchain.Options{})
+	for chain.Head() < head {
+		chain.Mine()
+	}
+	chain.Deploy(outcomes, memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		topics := []common.Hash{}
+		if len(input) > 32 {
+			topics = []common.Hash{sequence.TxFailedEventSig}
+		}
+		env.Log(topics, input[:32])
+		return nil, nil
+	}))
+	eoa, err := testutil.MemChainEOA(chain, 1, big.NewInt(1e18))
+	assert.NoError(t, err)
+
+	n := &logsNode{t: t, chain: chain, eoa: eoa}
+	chain.Intercept(func(method string, params []json.RawMessage, next func() (interface{}, error)) (interface{}, error) {
+		if method == "eth_getLogs" {
+			n.mu.Lock()
+			n.getLogs++
+			n.mu.Unlock()
+		}
+		return next()
+	})
+	return n
+}
+
+// mine mines a block with a transaction executing metaTxnID, or failing it, and returns the
+// log of its outcome.
+func (n *logsNode) mine(metaTxnID sequence.MetaTxnID, failed bool) types.Log {
+	ctx := context.Background()
+	data := common.HexToHash(string(metaTxnID)).Bytes()
+	if failed {
+		data = append(data, make([]byte, 32)...)
+	}
+	txn, err := n.eoa.NewTransaction(ctx, &ethtxn.TransactionRequest{To: &outcomes, Data: data})
+	assert.NoError(n.t, err)
+	txn, _, err = n.eoa.SendTransaction(ctx, txn)
+	assert.NoError(n.t, err)
+	receipt, err := n.chain.Provider().TransactionReceipt(ctx, txn.Hash())
+	assert.NoError(n.t, err)
+	return *receipt.Logs[0]
+}
+
+func metaTxnIDOf(i int64) sequence.MetaTxnID {
+	return sequence.MetaTxnID(common.BigToHash(big.NewInt(i)).Hex()[2:])
+}
+
+func TestReceiptsListener(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	node := newLogsNode(t, 100)
+	provider := node.chain.Provider()
+	mined := metaTxnIDOf(1)
+	node.mine(mined, false)
+
+	listener := sequence.NewReceiptsListener(provider).SetPollInterval(10 * time.Millisecond).SetPastBlocks(8)
+	go func() {
+		_ = listener.Run(ctx)
+	}()
+
+	// a meta-transaction mined before it's waited for is found among the past blocks
+	result, receipt, err := listener.WaitForMetaTxn(ctx, mined, 5*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, sequence.MetaTxnExecuted, result.Status)
+	assert.Equal(t, uint64(101), receipt.BlockNumber.Uint64())
+
+	// concurrent waiters are served from the same logs
+	ids := []sequence.MetaTxnID{metaTxnIDOf(2), metaTxnIDOf(3), metaTxnIDOf(4)}
+	statuses := make([]sequence.MetaTxnStatus, len(ids))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id sequence.MetaTxnID) {
+			defer wg.Done()
+			result, _, err := listener.WaitForMetaTxn(ctx, id, 5*time.Second)
+			if assert.NoError(t, err) {
+				statuses[i] = result.Status
+			}
+		}(i, id)
+	}
+	time.Sleep(50 * time.Millisecond)
+	node.mu.Lock()
+	polled := node.getLogs
+	node.mu.Unlock()
+	for i, id := range ids {
+		node.mine(id, i == 2)
+	}
+	wg.Wait()
+	assert.Equal(t, []sequence.MetaTxnStatus{sequence.MetaTxnExecuted, sequence.MetaTxnExecuted, sequence.MetaTxnFailed}, statuses)
+
+	// the logs of the blocks mined are fetched once, whatever the number of waiters
+	node.mu.Lock()
+	assert.LessOrEqual(t, node.getLogs-polled, len(ids))
+	node.mu.Unlock()
+
+	_, _, err = listener.WaitForMetaTxn(ctx, metaTxnIDOf(5), 50*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	listener.Stop()
+	assert.Eventually(t, func() bool { return !listener.IsRunning() }, time.Second, 10*time.Millisecond)
+}
+
+// streamer streams the logs sent on its channel to its subscribers.
+type streamer struct {
+	logs chan types.Log
+}
+
+func (s *streamer) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	return nil, nil
+}
+
+func (s *streamer) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		for {
+			select {
+			case <-quit:
+				return nil
+			case log := <-s.logs:
+				ch <- log
+			}
+		}
+	}), nil
+}
+
+func TestReceiptsListenerSubscription(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	node := newLogsNode(t, 100)
+	provider := node.chain.Provider()
+	stream := &streamer{logs: make(chan types.Log)}
+
+	// the listener follows the subscription, not polling the node
+	listener := sequence.NewReceiptsListener(provider).SetSubscriber(stream).SetPollInterval(time.Hour)
+	go func() {
+		_ = listener.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool { return listener.Checkpoint() == 100 }, time.Second, 10*time.Millisecond)
+
+	metaTxnID := metaTxnIDOf(1)
+	log := node.mine(metaTxnID, false)
+	go func() {
+		stream.logs <- log
+	}()
+
+	result, receipt, err := listener.WaitForMetaTxn(ctx, metaTxnID, 5*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, sequence.MetaTxnExecuted, result.Status)
+	assert.Equal(t, log.TxHash, receipt.TxHash)
+	assert.Equal(t, uint64(100), listener.Checkpoint())
+}
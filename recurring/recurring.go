@@ -0,0 +1,330 @@
+// Package recurring relays the bundles of wallets on a schedule, ie. a weekly payroll. The
+// bundle of each occurrence is built from a template, signed by the signers of the wallet and
+// relayed by its relayer:
+//
+//	scheduler := recurring.NewScheduler(recurring.Options{OnAlert: alert})
+//	err := scheduler.Add(&recurring.Job{
+//		ID:       "payroll",
+//		Wallet:   wallet,
+//		Schedule: recurring.MustParseCron("0 9 * * 1"),
+//		Build:    payroll,
+//	})
+//	go scheduler.Run(ctx)
+//
+// Each occurrence is signed in the nonce space of its idempotency key, see
+// sequence.IdempotencyNonce, so it's executed at most once, even if it's run again after a
+// restart.
+package recurring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/go-sequence"
+)
+
+var (
+	ErrJobNotFound = errors.New("recurring: job not found")
+	ErrJobExists   = errors.New("recurring: job already exists")
+
+	// ErrOverlap is the error of an occurrence skipped because the previous occurrence of its
+	// job is still running.
+	ErrOverlap = errors.New("recurring: previous occurrence is still running")
+)
+
+// BuildFunc builds the bundle of the occurrence of a job due at at.
+type BuildFunc func(ctx context.Context, at time.Time) (sequence.Transactions, error)
+
+// SignFunc signs txns as a bundle of wallet with nonce, ie. with remote signers. It's
+// sequence.Wallet.SignTransactionsWithNonce by default.
+type SignFunc func(ctx context.Context, wallet *sequence.Wallet, txns sequence.Transactions, nonce *big.Int) (*sequence.SignedTransactions, error)
+
+// Job is a bundle of a wallet relayed on a schedule. The wallet must be connected to a
+// provider and a relayer.
+type Job struct {
+	ID       string
+	Wallet   *sequence.Wallet
+	Schedule Schedule
+	Build    BuildFunc
+
+	// Sign signs the bundles, optional.
+	Sign SignFunc
+}
+
+// IdempotencyKey returns the idempotency key of the occurrence of the job due at at.
+func (j *Job) IdempotencyKey(at time.Time) string {
+	return fmt.Sprintf("recurring:%s:%s", j.ID, at.UTC().Format(time.RFC3339Nano))
+}
+
+// Alert reports an occurrence of a job which failed or was skipped.
+type Alert struct {
+	JobID     string
+	At        time.Time
+	MetaTxnID sequence.MetaTxnID
+	Err       error
+}
+
+type Options struct {
+	// Wait waits for the bundle of each occurrence to be executed. An occurrence runs until it
+	// is, so the next occurrence of its job is skipped if it's due before then.
+	Wait bool
+
+	// Timeout bounds an occurrence, from building its bundle to its execution if Wait is set.
+	// Zero doesn't bound.
+	Timeout time.Duration
+
+	// OnAlert is called with the occurrences which failed or were skipped, one at a time.
+	OnAlert func(Alert)
+}
+
+// JobStatus is the state of a job of a Scheduler.
+type JobStatus struct {
+	Paused  bool
+	Running bool
+
+	// Next is the next occurrence of the job, zero if it's paused.
+	Next time.Time
+
+	LastRun       time.Time
+	LastMetaTxnID sequence.MetaTxnID
+	LastErr       error
+}
+
+// Scheduler runs the occurrences of its jobs.
+type Scheduler struct {
+	options Options
+	jobs    map[string]*entry
+	mu      sync.Mutex
+	alertMu sync.Mutex
+	wake    chan struct{}
+	running sync.WaitGroup
+}
+
+type entry struct {
+	job    *Job
+	status JobStatus
+}
+
+func NewScheduler(options Options) *Scheduler {
+	return &Scheduler{
+		options: options,
+		jobs:    map[string]*entry{},
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Add adds job, whose first occurrence is the next of its schedule.
+func (s *Scheduler) Add(job *Job) error {
+	if job.ID == "" || job.Wallet == nil || job.Schedule == nil || job.Build == nil {
+		return fmt.Errorf("recurring: job requires an ID, a wallet, a schedule and a build func")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[job.ID]; ok {
+		return fmt.Errorf("%w: %v", ErrJobExists, job.ID)
+	}
+	s.jobs[job.ID] = &entry{job: job, status: JobStatus{Next: job.Schedule.Next(time.Now())}}
+	s.notify()
+	return nil
+}
+
+// Remove removes the job of id. A running occurrence is not interrupted.
+func (s *Scheduler) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[id]; !ok {
+		return fmt.Errorf("%w: %v", ErrJobNotFound, id)
+	}
+	delete(s.jobs, id)
+	return nil
+}
+
+// Pause stops running the occurrences of the job of id, until Resume. A running occurrence
+// is not interrupted.
+func (s *Scheduler) Pause(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.jobs[id]
+	if !ok {
+		return fmt.Errorf("%w: %v", ErrJobNotFound, id)
+	}
+	e.status.Paused = true
+	e.status.Next = time.Time{}
+	return nil
+}
+
+// Resume resumes the job of id. The occurrences due while it was paused are skipped.
+func (s *Scheduler) Resume(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.jobs[id]
+	if !ok {
+		return fmt.Errorf("%w: %v", ErrJobNotFound, id)
+	}
+	if e.status.Paused {
+		e.status.Paused = false
+		e.status.Next = e.job.Schedule.Next(time.Now())
+		s.notify()
+	}
+	return nil
+}
+
+func (s *Scheduler) Status(id string) (JobStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.jobs[id]
+	if !ok {
+		return JobStatus{}, fmt.Errorf("%w: %v", ErrJobNotFound, id)
+	}
+	return e.status, nil
+}
+
+// Run runs the occurrences of the jobs as they are due, until ctx is done. It then waits for
+// the running occurrences, which are bound to ctx, before returning.
+func (s *Scheduler) Run(ctx context.Context) error {
+	defer s.running.Wait()
+
+	for {
+		now := time.Now()
+		var next time.Time
+
+		s.mu.Lock()
+		for _, e := range s.jobs {
+			if e.status.Paused || e.status.Next.IsZero() {
+				continue
+			}
+			if !e.status.Next.After(now) {
+				s.start(ctx, e, e.status.Next)
+				e.status.Next = e.job.Schedule.Next(now)
+			}
+			if !e.status.Next.IsZero() && (next.IsZero() || e.status.Next.Before(next)) {
+				next = e.status.Next
+			}
+		}
+		s.mu.Unlock()
+
+		var timer *time.Timer
+		var due <-chan time.Time
+		if !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			due = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-s.wake:
+		case <-due:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// start runs the occurrence of e due at at, unless its previous occurrence is still running.
+// s.mu must be held.
+func (s *Scheduler) start(ctx context.Context, e *entry, at time.Time) {
+	if e.status.Running {
+		s.running.Add(1)
+		go func() {
+			defer s.running.Done()
+			s.alert(Alert{JobID: e.job.ID, At: at, Err: ErrOverlap})
+		}()
+		return
+	}
+	e.status.Running = true
+
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+
+		metaTxnID, err := s.run(ctx, e.job, at)
+
+		s.mu.Lock()
+		e.status.Running = false
+		e.status.LastRun = at
+		e.status.LastMetaTxnID = metaTxnID
+		e.status.LastErr = err
+		s.mu.Unlock()
+
+		if err != nil {
+			s.alert(Alert{JobID: e.job.ID, At: at, MetaTxnID: metaTxnID, Err: err})
+		}
+	}()
+}
+
+func (s *Scheduler) run(ctx context.Context, job *Job, at time.Time) (sequence.MetaTxnID, error) {
+	if s.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.options.Timeout)
+		defer cancel()
+	}
+
+	relayer := job.Wallet.GetRelayer()
+	if relayer == nil {
+		return "", fmt.Errorf("recurring: job %v: %w", job.ID, sequence.ErrRelayerNotSet)
+	}
+
+	nonce, err := job.Wallet.IdempotentNonce(job.IdempotencyKey(at), 0)
+	if errors.Is(err, sequence.ErrAlreadyExecuted) {
+		// the occurrence was run before a restart
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("recurring: job %v: %w", job.ID, err)
+	}
+
+	txns, err := job.Build(ctx, at)
+	if err != nil {
+		return "", fmt.Errorf("recurring: job %v: failed to build bundle: %w", job.ID, err)
+	}
+	sign := job.Sign
+	if sign == nil {
+		sign = func(ctx context.Context, wallet *sequence.Wallet, txns sequence.Transactions, nonce *big.Int) (*sequence.SignedTransactions, error) {
+			return wallet.SignTransactionsWithNonce(ctx, txns, nonce)
+		}
+	}
+	signed, err := sign(ctx, job.Wallet, txns, nonce)
+	if err != nil {
+		return "", fmt.Errorf("recurring: job %v: failed to sign bundle: %w", job.ID, err)
+	}
+
+	metaTxnID, _, _, err := relayer.Relay(ctx, signed)
+	if err != nil {
+		return metaTxnID, fmt.Errorf("recurring: job %v: failed to relay bundle: %w", job.ID, err)
+	}
+	if !s.options.Wait {
+		return metaTxnID, nil
+	}
+	status, _, err := relayer.Wait(ctx, metaTxnID)
+	if err != nil {
+		return metaTxnID, fmt.Errorf("recurring: job %v: failed to wait for bundle: %w", job.ID, err)
+	}
+	if status != sequence.MetaTxnExecuted {
+		return metaTxnID, fmt.Errorf("recurring: job %v: bundle %v", job.ID, status)
+	}
+	return metaTxnID, nil
+}
+
+func (s *Scheduler) alert(alert Alert) {
+	if s.options.OnAlert == nil {
+		return
+	}
+	s.alertMu.Lock()
+	defer s.alertMu.Unlock()
+	s.options.OnAlert(alert)
+}
+
+// notify wakes Run up to reschedule.
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,208 @@
+package recurring_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/recurring"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron(t *testing.T) {
+	at := func(s string) time.Time {
+		t, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+
+	tests := []struct {
+		spec string
+		from string
+		next string
+	}{
+		{"0 9 * * 1", "2024-01-03 10:00", "2024-01-08 09:00"},
+		{"*/15 * * * *", "2024-01-03 10:07", "2024-01-03 10:15"},
+		{"30 8-10/2 * * *", "2024-01-03 09:00", "2024-01-03 10:30"},
+		{"0 0 29 2 *", "2023-03-01 00:00", "2024-02-29 00:00"},
+		{"0 0 13 * 5", "2024-01-01 00:00", "2024-01-05 00:00"},
+		{"0 12 * * 7", "2024-01-01 00:00", "2024-01-07 12:00"},
+		{"@monthly", "2024-01-31 12:00", "2024-02-01 00:00"},
+	}
+	for _, test := range tests {
+		schedule, err := recurring.ParseCron(test.spec)
+		assert.NoError(t, err, test.spec)
+		assert.Equal(t, at(test.next), schedule.Next(at(test.from)), test.spec)
+	}
+
+	for _, spec := range []string{"60 * * * *", "* * *", "* * 0 * *", "*/0 * * * *", "a * * * *"} {
+		_, err := recurring.ParseCron(spec)
+		assert.Error(t, err, spec)
+	}
+
+	assert.Equal(t, at("2024-01-03 11:00"), recurring.Every(time.Hour).Next(at("2024-01-03 10:07")))
+}
+
+type fakeRelayer struct {
+	provider *ethrpc.Provider
+	executed map[string]bool
+	relayed  []*sequence.SignedTransactions
+	release  chan struct{}
+	mu       sync.Mutex
+}
+
+func (r *fakeRelayer) GetProvider() *ethrpc.Provider { return r.provider }
+
+func (r *fakeRelayer) EstimateGasLimits(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, txns sequence.Transactions) (sequence.Transactions, error) {
+	return txns, nil
+}
+
+func (r *fakeRelayer) GetNonce(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, space *big.Int, blockNum *big.Int) (*big.Int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if space != nil && r.executed[space.String()] {
+		return big.NewInt(1), nil
+	}
+	return big.NewInt(0), nil
+}
+
+func (r *fakeRelayer) Relay(ctx context.Context, signedTxs *sequence.SignedTransactions) (sequence.MetaTxnID, *types.Transaction, ethtxn.WaitReceipt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.relayed = append(r.relayed, signedTxs)
+	space, _ := sequence.DecodeNonce(signedTxs.Nonce)
+	r.executed[space.String()] = true
+	return sequence.MetaTxnID(signedTxs.Digest.Hex()[2:]), nil, nil, nil
+}
+
+func (r *fakeRelayer) Wait(ctx context.Context, metaTxnID sequence.MetaTxnID, optTimeout ...time.Duration) (sequence.MetaTxnStatus, *types.Receipt, error) {
+	if r.release != nil {
+		select {
+		case <-r.release:
+		case <-ctx.Done():
+			return 0, nil, ctx.Err()
+		}
+	}
+	return sequence.MetaTxnExecuted, nil, nil
+}
+
+func (r *fakeRelayer) FeeOptions(ctx context.Context, signedTxs *sequence.SignedTransactions) ([]*sequence.FeeOption, error) {
+	return nil, nil
+}
+
+func (r *fakeRelayer) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.relayed)
+}
+
+func TestScheduler(t *testing.T) {
+	provider := memchain.New(memchain.Options{ChainID: big.NewInt(1)}).Provider()
+
+	newWallet := func(relayer *fakeRelayer) *sequence.Wallet {
+		key, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+		assert.NoError(t, err)
+		wallet, err := sequence.NewWalletSingleOwner(key)
+		assert.NoError(t, err)
+		assert.NoError(t, wallet.Connect(provider, relayer))
+		return wallet
+	}
+	payroll := func(ctx context.Context, at time.Time) (sequence.Transactions, error) {
+		return sequence.Transactions{{To: common.HexToAddress("0x01"), Value: big.NewInt(at.UnixMilli()), GasLimit: big.NewInt(50000)}}, nil
+	}
+
+	t.Run("occurrences", func(t *testing.T) {
+		relayer := &fakeRelayer{provider: provider, executed: map[string]bool{}}
+		scheduler := recurring.NewScheduler(recurring.Options{})
+		job := &recurring.Job{ID: "payroll", Wallet: newWallet(relayer), Schedule: recurring.Every(20 * time.Millisecond), Build: payroll}
+		assert.NoError(t, scheduler.Add(job))
+		assert.ErrorIs(t, scheduler.Add(job), recurring.ErrJobExists)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = scheduler.Run(ctx)
+		}()
+		assert.Eventually(t, func() bool { return relayer.count() >= 2 }, time.Second, time.Millisecond)
+
+		// occurrences are paused, and resumed
+		assert.NoError(t, scheduler.Pause("payroll"))
+		time.Sleep(10 * time.Millisecond)
+		count := relayer.count()
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, count, relayer.count())
+		status, err := scheduler.Status("payroll")
+		assert.NoError(t, err)
+		assert.True(t, status.Paused)
+		assert.NoError(t, scheduler.Resume("payroll"))
+		assert.Eventually(t, func() bool { return relayer.count() > count }, time.Second, time.Millisecond)
+
+		cancel()
+		<-done
+
+		// each occurrence is signed in the nonce space of its idempotency key
+		relayer.mu.Lock()
+		defer relayer.mu.Unlock()
+		for _, signed := range relayer.relayed {
+			at := time.UnixMilli(signed.Transactions[0].Value.Int64())
+			assert.Equal(t, sequence.IdempotencyNonce(job.IdempotencyKey(at), 0), signed.Nonce)
+		}
+	})
+
+	t.Run("overlap", func(t *testing.T) {
+		relayer := &fakeRelayer{provider: provider, executed: map[string]bool{}, release: make(chan struct{})}
+		alerts := make(chan recurring.Alert, 10)
+		scheduler := recurring.NewScheduler(recurring.Options{
+			Wait:    true,
+			OnAlert: func(alert recurring.Alert) { alerts <- alert },
+		})
+		assert.NoError(t, scheduler.Add(&recurring.Job{ID: "payroll", Wallet: newWallet(relayer), Schedule: recurring.Every(10 * time.Millisecond), Build: payroll}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go scheduler.Run(ctx)
+
+		// the first occurrence waits for its bundle, so the next is skipped
+		alert := <-alerts
+		assert.ErrorIs(t, alert.Err, recurring.ErrOverlap)
+		assert.Equal(t, 1, relayer.count())
+		close(relayer.release)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		relayer := &fakeRelayer{provider: provider, executed: map[string]bool{}}
+		alerts := make(chan recurring.Alert, 10)
+		scheduler := recurring.NewScheduler(recurring.Options{OnAlert: func(alert recurring.Alert) { alerts <- alert }})
+		assert.NoError(t, scheduler.Add(&recurring.Job{
+			ID:       "payroll",
+			Wallet:   newWallet(relayer),
+			Schedule: recurring.Every(10 * time.Millisecond),
+			Build: func(ctx context.Context, at time.Time) (sequence.Transactions, error) {
+				return nil, errors.New("payroll is not approved")
+			},
+		}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go scheduler.Run(ctx)
+
+		alert := <-alerts
+		assert.Equal(t, "payroll", alert.JobID)
+		assert.ErrorContains(t, alert.Err, "payroll is not approved")
+		assert.Equal(t, 0, relayer.count())
+	})
+}
@@ -13,7 +13,7 @@ import (
 	"github.com/0xsequence/ethkit/go-ethereum"
 	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/0xsequence/ethkit/go-ethereum/core/types"
-	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/0xsequence/go-sequence/contracts/gen/walletmain"
 )
 
 type Relayer interface {
@@ -34,8 +34,11 @@ type Relayer interface {
 	// ..
 	Wait(ctx context.Context, metaTxnID MetaTxnID, optTimeout ...time.Duration) (MetaTxnStatus, *types.Receipt, error)
 
-	// TODO, in future when needed..
-	// GasRefundOptions()
+	// FeeOptions returns the fees the relayer accepts for relaying the transactions of signedTxs,
+	// one per fee token, or none if it relays them for free. The fees are quoted before the
+	// transactions are signed, so the signature of signedTxs may be empty. A fee is paid by
+	// appending its transfer to the transactions before signing them, see AppendFeeTransaction.
+	FeeOptions(ctx context.Context, signedTxs *SignedTransactions) ([]*FeeOption, error)
 }
 
 type MetaTxnID string
@@ -47,29 +50,95 @@ const (
 	MetaTxnExecuted
 	MetaTxnFailed
 	MetaTxnReverted
+
+	// MetaTxnReorged is reported while waiting for a meta-transaction, when the block of
+	// its receipt was reorged out. It is never the final status of a meta-transaction.
+	MetaTxnReorged
 )
 
-// returns `to` address (either guest or wallet) and `data` of signed-metatx-calldata, aka execdata
-func EncodeTransactionsForRelaying(relayer Relayer, walletConfig WalletConfig, walletContext WalletContext, txns Transactions, nonce *big.Int, seqSig []byte) (common.Address, []byte, error) {
-	// TODO/NOTE: first version, we assume the wallet is deployed, then we can add bundlecreation after.
-	// .....
+func (s MetaTxnStatus) String() string {
+	switch s {
+	case MetaTxnExecuted:
+		return "executed"
+	case MetaTxnFailed:
+		return "failed"
+	case MetaTxnReverted:
+		return "reverted"
+	case MetaTxnReorged:
+		return "reorged"
+	default:
+		return "unknown"
+	}
+}
 
+// EncodeTransactionsForRelaying returns the `to` address, either the guest module or the wallet, and the
+// signed-metatx-calldata, aka execdata. When the provider of relayer reports the wallet isn't deployed
+// yet, the execdata deploys it in the same native transaction, see EncodeDeployAndExecute.
+func EncodeTransactionsForRelaying(relayer Relayer, walletConfig WalletConfig, walletContext WalletContext, txns Transactions, nonce *big.Int, seqSig []byte) (common.Address, []byte, error) {
 	if len(txns) == 0 {
 		return common.Address{}, nil, fmt.Errorf("cannot encode empty transactions")
 	}
 
-	// Encode transaction to be sent to a deployed wallet
 	walletAddress, err := AddressFromWalletConfig(walletConfig, walletContext)
 	if err != nil {
 		return common.Address{}, nil, err
 	}
 
-	encodedTxns, err := txns.EncodedTransactions()
+	if relayer != nil && relayer.GetProvider() != nil {
+		deployed, err := IsWalletDeployed(relayer.GetProvider(), walletAddress)
+		if err != nil {
+			return common.Address{}, nil, err
+		}
+		if !deployed {
+			return EncodeDeployAndExecute(walletConfig, walletContext, txns, nonce, seqSig)
+		}
+	}
+
+	return encodeWalletExecute(walletAddress, txns, nonce, seqSig)
+}
+
+// EncodeDeployAndExecute returns the guest module of walletContext and its execdata, which deploys the
+// counterfactual wallet of walletConfig through the factory and then executes the signed meta-transaction
+// on it, in a single native transaction. walletConfig must be the initial config of the wallet.
+func EncodeDeployAndExecute(walletConfig WalletConfig, walletContext WalletContext, txns Transactions, nonce *big.Int, seqSig []byte) (common.Address, []byte, error) {
+	if len(txns) == 0 {
+		return common.Address{}, nil, fmt.Errorf("cannot encode empty transactions")
+	}
+	if walletContext.GuestModuleAddress == (common.Address{}) {
+		return common.Address{}, nil, fmt.Errorf("cannot deploy wallet: guest module is not set in wallet context")
+	}
+	if nonce == nil || seqSig == nil {
+		return common.Address{}, nil, fmt.Errorf("cannot deploy wallet: transactions are not signed")
+	}
+
+	walletAddress, factoryAddress, deployData, err := EncodeWalletDeployment(walletConfig, walletContext)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+
+	bundle := Transactions{
+		{To: factoryAddress, Data: deployData, RevertOnError: true},
+		{To: walletAddress, Transactions: txns, Nonce: nonce, Signature: seqSig, RevertOnError: true},
+	}
+
+	// the guest module has the execute method of the main module, and executes any unsigned bundle
+	execdata, err := appendExecute(nil, bundle, big.NewInt(0), []byte{})
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+
+	return walletContext.GuestModuleAddress, execdata, nil
+}
+
+// encodeWalletExecute returns the execdata of the signed meta-transaction sent to the deployed wallet
+// at walletAddress.
+func encodeWalletExecute(walletAddress common.Address, txns Transactions, nonce *big.Int, seqSig []byte) (common.Address, []byte, error) {
+	calls, err := txns.ModuleCalls()
 	if err != nil {
 		return common.Address{}, nil, err
 	}
 
-	execdata, err := contracts.WalletMainModule.Encode("execute", encodedTxns, nonce, seqSig)
+	execdata, err := walletmain.WalletMainCalldata.Execute(calls, nonce, seqSig)
 	if err != nil {
 		return common.Address{}, nil, err
 	}
@@ -78,7 +147,8 @@ func EncodeTransactionsForRelaying(relayer Relayer, walletConfig WalletConfig, w
 }
 
 // DEPRECATED
-// this method is horribly inefficient and we now have the new receipt_fetcher.go impl.
+// this method is horribly inefficient and we now have the new receipt_fetcher.go impl, or
+// ReceiptsListener for waiters without an ethreceipts.ReceiptsListener.
 func LegacyWaitForMetaTxn(ctx context.Context, provider *ethrpc.Provider, metaTxnID MetaTxnID, optTimeout ...time.Duration) (MetaTxnStatus, *types.Receipt, error) {
 	// Use optional timeout if passed, otherwise use deadline on the provided ctx, or finally,
 	// set a default timeout of 120 seconds.
@@ -0,0 +1,228 @@
+package relayer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+var ErrQuotaExceeded = fmt.Errorf("relayer: quota exceeded")
+
+// Usage is the activity of a wallet or project on a relayer.
+type Usage struct {
+	// Transactions is the number of meta-transaction bundles relayed.
+	Transactions int64
+
+	// GasUsed is the gas used by the native transactions, once mined.
+	GasUsed uint64
+
+	// GasSpent is the gas used multiplied by the gas price, in wei.
+	GasSpent *big.Int
+}
+
+func (u Usage) add(delta Usage) Usage {
+	gasSpent := new(big.Int)
+	if u.GasSpent != nil {
+		gasSpent.Set(u.GasSpent)
+	}
+	if delta.GasSpent != nil {
+		gasSpent.Add(gasSpent, delta.GasSpent)
+	}
+	return Usage{
+		Transactions: u.Transactions + delta.Transactions,
+		GasUsed:      u.GasUsed + delta.GasUsed,
+		GasSpent:     gasSpent,
+	}
+}
+
+// UsageLimits are hard caps on the Usage of a wallet or project. Zero values mean no limit.
+type UsageLimits struct {
+	MaxTransactions int64
+	MaxGasSpent     *big.Int
+}
+
+func (l *UsageLimits) check(usage Usage) error {
+	if l == nil {
+		return nil
+	}
+	if l.MaxTransactions > 0 && usage.Transactions > l.MaxTransactions {
+		return fmt.Errorf("%w: %d transactions exceeds limit of %d", ErrQuotaExceeded, usage.Transactions, l.MaxTransactions)
+	}
+	if l.MaxGasSpent != nil && l.MaxGasSpent.Sign() > 0 && usage.GasSpent != nil && usage.GasSpent.Cmp(l.MaxGasSpent) >= 0 {
+		return fmt.Errorf("%w: gas spent %v reached limit of %v", ErrQuotaExceeded, usage.GasSpent, l.MaxGasSpent)
+	}
+	return nil
+}
+
+// UsageStore persists usage. Implementations must apply AddUsage atomically, as it is
+// used to reserve quota by concurrent relays.
+type UsageStore interface {
+	// AddUsage adds delta to the usage of key, and returns the updated usage.
+	AddUsage(ctx context.Context, key string, delta Usage) (Usage, error)
+
+	// GetUsage returns the usage of key, or a zero usage if none was recorded.
+	GetUsage(ctx context.Context, key string) (Usage, error)
+
+	// ResetUsage clears the usage of key, ie. at the start of a new billing period.
+	ResetUsage(ctx context.Context, key string) error
+}
+
+// Accountant tracks the usage of wallets and projects (tenants) of a relayer in a
+// UsageStore, and enforces their optional limits.
+type Accountant struct {
+	store UsageStore
+
+	walletLimits  *UsageLimits
+	projectLimits map[string]*UsageLimits
+	muLimits      sync.RWMutex
+}
+
+func NewAccountant(store UsageStore) *Accountant {
+	if store == nil {
+		store = NewMemoryUsageStore()
+	}
+	return &Accountant{
+		store:         store,
+		projectLimits: map[string]*UsageLimits{},
+	}
+}
+
+// SetWalletLimits sets the limits applied to each wallet.
+func (a *Accountant) SetWalletLimits(limits *UsageLimits) *Accountant {
+	a.muLimits.Lock()
+	defer a.muLimits.Unlock()
+	a.walletLimits = limits
+	return a
+}
+
+// SetProjectLimits sets the limits of a project, nil removes them.
+func (a *Accountant) SetProjectLimits(project string, limits *UsageLimits) *Accountant {
+	a.muLimits.Lock()
+	defer a.muLimits.Unlock()
+	if limits == nil {
+		delete(a.projectLimits, project)
+	} else {
+		a.projectLimits[project] = limits
+	}
+	return a
+}
+
+func (a *Accountant) WalletUsage(ctx context.Context, wallet common.Address) (Usage, error) {
+	return a.store.GetUsage(ctx, walletUsageKey(wallet))
+}
+
+func (a *Accountant) ProjectUsage(ctx context.Context, project string) (Usage, error) {
+	return a.store.GetUsage(ctx, projectUsageKey(project))
+}
+
+func (a *Accountant) ResetWalletUsage(ctx context.Context, wallet common.Address) error {
+	return a.store.ResetUsage(ctx, walletUsageKey(wallet))
+}
+
+func (a *Accountant) ResetProjectUsage(ctx context.Context, project string) error {
+	return a.store.ResetUsage(ctx, projectUsageKey(project))
+}
+
+// Reserve accounts a bundle about to be relayed for wallet on behalf of project, and returns
+// ErrQuotaExceeded without accounting it if either is over its limits. An empty project
+// is not accounted.
+func (a *Accountant) Reserve(ctx context.Context, project string, wallet common.Address) error {
+	a.muLimits.RLock()
+	walletLimits, projectLimits := a.walletLimits, a.projectLimits[project]
+	a.muLimits.RUnlock()
+
+	keys := []string{walletUsageKey(wallet)}
+	limits := []*UsageLimits{walletLimits}
+	if project != "" {
+		keys = append(keys, projectUsageKey(project))
+		limits = append(limits, projectLimits)
+	}
+
+	for i, key := range keys {
+		usage, err := a.store.AddUsage(ctx, key, Usage{Transactions: 1})
+		if err != nil {
+			a.release(ctx, keys[:i])
+			return err
+		}
+		if err := limits[i].check(usage); err != nil {
+			a.release(ctx, keys[:i+1])
+			return err
+		}
+	}
+	return nil
+}
+
+// Release returns a reservation, ie. when the bundle failed to be sent.
+func (a *Accountant) Release(ctx context.Context, project string, wallet common.Address) {
+	keys := []string{walletUsageKey(wallet)}
+	if project != "" {
+		keys = append(keys, projectUsageKey(project))
+	}
+	a.release(ctx, keys)
+}
+
+func (a *Accountant) release(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		_, _ = a.store.AddUsage(ctx, key, Usage{Transactions: -1})
+	}
+}
+
+// RecordGas accounts the gas spent by a mined native transaction.
+func (a *Accountant) RecordGas(ctx context.Context, project string, wallet common.Address, gasUsed uint64, gasPrice *big.Int) error {
+	delta := Usage{GasUsed: gasUsed, GasSpent: new(big.Int).Mul(new(big.Int).SetUint64(gasUsed), gasPrice)}
+
+	if _, err := a.store.AddUsage(ctx, walletUsageKey(wallet), delta); err != nil {
+		return err
+	}
+	if project != "" {
+		if _, err := a.store.AddUsage(ctx, projectUsageKey(project), delta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walletUsageKey(wallet common.Address) string {
+	return "wallet:" + strings.ToLower(wallet.Hex())
+}
+
+func projectUsageKey(project string) string {
+	return "project:" + project
+}
+
+// MemoryUsageStore is an in-memory UsageStore, its usage is lost on restart.
+type MemoryUsageStore struct {
+	usage map[string]Usage
+	mu    sync.Mutex
+}
+
+var _ UsageStore = &MemoryUsageStore{}
+
+func NewMemoryUsageStore() *MemoryUsageStore {
+	return &MemoryUsageStore{usage: map[string]Usage{}}
+}
+
+func (s *MemoryUsageStore) AddUsage(ctx context.Context, key string, delta Usage) (Usage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	usage := s.usage[key].add(delta)
+	s.usage[key] = usage
+	return usage, nil
+}
+
+func (s *MemoryUsageStore) GetUsage(ctx context.Context, key string) (Usage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usage[key].add(Usage{}), nil
+}
+
+func (s *MemoryUsageStore) ResetUsage(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.usage, key)
+	return nil
+}
@@ -0,0 +1,49 @@
+package relayer_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence/relayer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountant(t *testing.T) {
+	ctx := context.Background()
+	wallet1 := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	wallet2 := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	accountant := relayer.NewAccountant(nil)
+	accountant.SetWalletLimits(&relayer.UsageLimits{MaxTransactions: 2})
+	accountant.SetProjectLimits("app", &relayer.UsageLimits{MaxGasSpent: big.NewInt(1000)})
+
+	assert.NoError(t, accountant.Reserve(ctx, "app", wallet1))
+	assert.NoError(t, accountant.Reserve(ctx, "app", wallet1))
+	assert.ErrorIs(t, accountant.Reserve(ctx, "app", wallet1), relayer.ErrQuotaExceeded)
+
+	// the rejected reservation is not accounted
+	usage, err := accountant.WalletUsage(ctx, wallet1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), usage.Transactions)
+
+	usage, err = accountant.ProjectUsage(ctx, "app")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), usage.Transactions)
+
+	assert.NoError(t, accountant.RecordGas(ctx, "app", wallet1, 100, big.NewInt(10)))
+	usage, err = accountant.ProjectUsage(ctx, "app")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100), usage.GasUsed)
+	assert.Equal(t, big.NewInt(1000), usage.GasSpent)
+
+	// the project spent its gas, so other wallets are capped too
+	assert.ErrorIs(t, accountant.Reserve(ctx, "app", wallet2), relayer.ErrQuotaExceeded)
+	usage, err = accountant.WalletUsage(ctx, wallet2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), usage.Transactions)
+
+	assert.NoError(t, accountant.ResetProjectUsage(ctx, "app"))
+	assert.NoError(t, accountant.Reserve(ctx, "app", wallet2))
+}
@@ -1,9 +1,12 @@
 package relayer
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"math/big"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/0xsequence/ethkit/ethreceipts"
@@ -14,6 +17,7 @@ import (
 	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/0xsequence/ethkit/go-ethereum/core/types"
 	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/contracts"
 )
 
 // LocalRelayer is a simple implementation of a relayer which will dispatch
@@ -22,27 +26,116 @@ import (
 type LocalRelayer struct {
 	Sender          *ethwallet.Wallet
 	receiptListener *ethreceipts.ReceiptsListener
+	metaTxnListener *sequence.ReceiptsListener
+	options         sequence.Options
+	policy          atomic.Value // *Policy
+	nonceSpace      *big.Int
+	walletContext   *sequence.WalletContext
+
+	accountant *Accountant
+	project    string
+	senderPool *SenderPool
+	private    *PrivateSubmission
+	txBuilder  TxBuilder
+	estimator  *sequence.Estimator
+
+	paused    int32
+	pending   map[sequence.MetaTxnID]*pendingTransaction
+	muPending sync.Mutex
+	muSender  sync.RWMutex
+
+	subscribers   map[chan StatusUpdate]struct{}
+	muSubscribers sync.Mutex
 }
 
-var _ sequence.Relayer = &LocalRelayer{}
+var (
+	_ sequence.Relayer             = &LocalRelayer{}
+	_ sequence.PendingNonceTracker = &LocalRelayer{}
+)
 
-func NewLocalRelayer(sender *ethwallet.Wallet, receiptListener *ethreceipts.ReceiptsListener) (*LocalRelayer, error) {
+func NewLocalRelayer(sender *ethwallet.Wallet, receiptListener *ethreceipts.ReceiptsListener, opts ...sequence.Option) (*LocalRelayer, error) {
 	if sender.GetProvider() == nil {
 		return nil, sequence.ErrProviderNotSet
 	}
+	options := sequence.NewOptions(opts...)
 	return &LocalRelayer{
 		Sender:          sender,
 		receiptListener: receiptListener,
+		options:         options,
+		pending:         map[sequence.MetaTxnID]*pendingTransaction{},
+		estimator:       options.Estimator,
 	}, nil
 }
 
+// NewLocalRelayerWithProvider returns a LocalRelayer sending from sender, a funded EOA, through
+// provider, to self-relay bundles straight to a node without a hosted relayer. It has no
+// receipts listener, so Wait polls the logs of the node instead, see
+// sequence.LegacyWaitForMetaTxn, unless a sequence.ReceiptsListener is set with
+// SetMetaTxnListener, and WaitForMany is not supported.
+func NewLocalRelayerWithProvider(sender *ethwallet.Wallet, provider *ethrpc.Provider, opts ...sequence.Option) (*LocalRelayer, error) {
+	if provider == nil {
+		return nil, sequence.ErrProviderNotSet
+	}
+	sender.SetProvider(provider)
+	return NewLocalRelayer(sender, nil, opts...)
+}
+
+// SetPolicy atomically replaces the policy the relayer checks transactions against. A nil
+// policy relays any transactions.
+func (r *LocalRelayer) SetPolicy(policy *Policy) *LocalRelayer {
+	r.policy.Store(policy)
+	return r
+}
+
+func (r *LocalRelayer) Policy() *Policy {
+	policy, _ := r.policy.Load().(*Policy)
+	return policy
+}
+
+// GetSender returns the EOA which sends the relayer's transactions.
+func (r *LocalRelayer) GetSender() *ethwallet.Wallet {
+	r.muSender.RLock()
+	defer r.muSender.RUnlock()
+	return r.Sender
+}
+
+// SetSender rotates the EOA which sends the relayer's transactions. Pending transactions of
+// the previous sender are still tracked, and bumped by the previous sender.
+func (r *LocalRelayer) SetSender(sender *ethwallet.Wallet) error {
+	if sender.GetProvider() == nil {
+		sender.SetProvider(r.GetProvider())
+	}
+	if sender.GetProvider() == nil {
+		return sequence.ErrProviderNotSet
+	}
+
+	r.muSender.Lock()
+	defer r.muSender.Unlock()
+	r.Sender = sender
+	return nil
+}
+
 func (r *LocalRelayer) GetProvider() *ethrpc.Provider {
-	if r.Sender == nil || r.Sender.GetProvider() == nil {
+	sender := r.GetSender()
+	if sender == nil || sender.GetProvider() == nil {
 		return nil
 	}
-	return r.Sender.GetProvider()
+	return sender.GetProvider()
+}
+
+// SetEstimator sets the estimator which EstimateGasLimits simulates bundles through the wallet
+// with, ie. with a gas padding, see sequence.WithEstimator. A nil estimator, the default,
+// estimates each transaction on its own with eth_estimateGas, as do nodes which don't support
+// the state overrides of the estimator.
+func (r *LocalRelayer) SetEstimator(estimator *sequence.Estimator) *LocalRelayer {
+	r.estimator = estimator
+	return r
 }
 
+// EstimateGasLimits sets the gas limits of the transactions of txns which have none, ie. a nil
+// or zero gas limit. They are simulated through the execute call of the wallet if an estimator
+// is set, see sequence.Estimator.EstimateGasLimits, and those left without a gas limit are
+// estimated on their own with eth_estimateGas from the wallet.
 func (r *LocalRelayer) EstimateGasLimits(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, txns sequence.Transactions) (sequence.Transactions, error) {
 	walletAddress, err := sequence.AddressFromWalletConfig(walletConfig, walletContext)
 	if err != nil {
@@ -51,6 +144,14 @@ func (r *LocalRelayer) EstimateGasLimits(ctx context.Context, walletConfig seque
 
 	provider := r.GetProvider()
 
+	if r.estimator != nil {
+		_, err := r.estimator.EstimateGasLimits(ctx, provider, walletAddress, walletConfig, walletContext, txns)
+		if err != nil {
+			r.options.Metrics.IncCounter("relayer.estimate.simulation_failed")
+			r.options.Logger.Warnf("relayer: unable to simulate bundle of %s, estimating its transactions on their own: %v", walletAddress.Hex(), err)
+		}
+	}
+
 	isWalletDeployed, err := sequence.IsWalletDeployed(provider, walletAddress)
 	if err != nil {
 		return nil, err
@@ -112,17 +213,91 @@ func (r *LocalRelayer) EstimateGasLimits(ctx context.Context, walletConfig seque
 }
 
 func (r *LocalRelayer) GetNonce(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, space *big.Int, blockNum *big.Int) (*big.Int, error) {
+	if space == nil && r.nonceSpace != nil {
+		space = r.nonceSpace
+	}
 	return sequence.GetWalletNonce(r.GetProvider(), walletConfig, walletContext, space, blockNum)
 }
 
+// FeeOptions returns no fee options, as the relayer pays the gas of the bundles it relays with
+// its sender.
+func (r *LocalRelayer) FeeOptions(ctx context.Context, signedTxs *sequence.SignedTransactions) ([]*sequence.FeeOption, error) {
+	return nil, nil
+}
+
+// SetAccountant sets the accountant the relayer tracks usage and enforces limits with, the
+// usage of relayed bundles is accounted to their wallet and to project, if not empty. It must
+// be set before the relayer starts relaying.
+func (r *LocalRelayer) SetAccountant(accountant *Accountant, project string) *LocalRelayer {
+	r.accountant = accountant
+	r.project = project
+	return r
+}
+
+// GetWalletNonce returns the nonce of the wallet at walletAddress, in the relayer's nonce
+// space if space is nil.
+func (r *LocalRelayer) GetWalletNonce(ctx context.Context, walletAddress common.Address, space *big.Int) (*big.Int, error) {
+	if space == nil && r.nonceSpace != nil {
+		space = r.nonceSpace
+	}
+	return sequence.GetWalletAddressNonce(r.GetProvider(), walletAddress, space, nil)
+}
+
+// SetSenderPool sets a pool of senders the relayer rotates through instead of its Sender,
+// which is then only used to query the chain. It must be set before the relayer starts
+// relaying.
+func (r *LocalRelayer) SetSenderPool(pool *SenderPool) *LocalRelayer {
+	r.senderPool = pool
+	return r
+}
+
+func (r *LocalRelayer) SenderPool() *SenderPool {
+	return r.senderPool
+}
+
+// SetMetaTxnListener sets the listener Wait waits for meta-transactions with when the relayer
+// has no receipts listener, instead of polling the logs of the node for each of them. The
+// listener must be running.
+func (r *LocalRelayer) SetMetaTxnListener(listener *sequence.ReceiptsListener) *LocalRelayer {
+	r.metaTxnListener = listener
+	return r
+}
+
+// SetNonceSpace sets the meta-transaction nonce space used by GetNonce when no space is
+// passed, so that bundles relayed through this relayer do not contend on the nonce of
+// bundles of the same wallet relayed elsewhere.
+func (r *LocalRelayer) SetNonceSpace(space *big.Int) *LocalRelayer {
+	r.nonceSpace = space
+	return r
+}
+
+// SetWalletContext sets the context of the wallets whose execdata RelayExecdata relays, so
+// deployments of counterfactual wallets through its guest module are accepted. Defaults to
+// sequence.SequenceContext().
+func (r *LocalRelayer) SetWalletContext(walletContext sequence.WalletContext) *LocalRelayer {
+	r.walletContext = &walletContext
+	return r
+}
+
+// WalletContext returns the context of the wallets whose execdata RelayExecdata relays.
+func (r *LocalRelayer) WalletContext() sequence.WalletContext {
+	if r.walletContext != nil {
+		return *r.walletContext
+	}
+	return sequence.SequenceContext()
+}
+
 func (r *LocalRelayer) Relay(ctx context.Context, signedTxs *sequence.SignedTransactions) (sequence.MetaTxnID, *types.Transaction, ethtxn.WaitReceipt, error) {
-	// NOTE: this implementation assumes the wallet is deployed and does not do automatic bundle creation (aka prepending / bundling
-	// a wallet creation call)
+	if err := signedTxs.Verify(); err != nil {
+		return "", nil, nil, err
+	}
 
-	// TODO: lets update LocalRelayer so it'll do auto-bundle creation.. to prepend, and send to guestModule, etc..
-	// its more consistent, and easier for tests..
+	// NOTE: a wallet which isn't deployed yet is deployed by the same native transaction, sent to the
+	// guest module, see sequence.EncodeTransactionsForRelaying
 
-	sender := r.Sender
+	if err := r.Policy().Check(signedTxs.Transactions); err != nil {
+		return "", nil, nil, err
+	}
 
 	to, execdata, err := sequence.EncodeTransactionsForRelaying(
 		r,
@@ -146,37 +321,249 @@ func (r *LocalRelayer) Relay(ctx context.Context, signedTxs *sequence.SignedTran
 		return "", nil, nil, err
 	}
 
-	ntx, err := sender.NewTransaction(ctx, &ethtxn.TransactionRequest{
-		To: &to, Data: execdata,
-	})
+	ntx, waitReceipt, err := r.send(ctx, metaTxnID, signedTxs.ChainID, walletAddress, signedTxs.Nonce, signedTxs.Transactions, to, execdata)
 	if err != nil {
 		return metaTxnID, nil, nil, err
 	}
+	r.options.Logger.Debugf("relayer: sent metaTxnID %s in txn %s", metaTxnID, ntx.Hash().Hex())
+
+	// transactions of types unknown to go-ethereum are not returned
+	tx, _ := ntx.(*types.Transaction)
+	return metaTxnID, tx, waitReceipt, nil
+}
 
-	signedTx, err := sender.SignTx(ntx, signedTxs.ChainID)
+// RelayExecdata relays execdata which was already encoded by a client, ie. with
+// sequence.EncodeTransactionsForRelaying, to the wallet at walletAddress. This is used
+// by relayer servers receiving meta-transactions over the network. The execdata of a wallet
+// which isn't deployed yet is sent to the guest module of WalletContext, and deploys the
+// wallet before executing its bundle, see sequence.EncodeDeployAndExecute.
+func (r *LocalRelayer) RelayExecdata(ctx context.Context, walletAddress common.Address, to common.Address, execdata []byte) (sequence.MetaTxnID, *types.Transaction, ethtxn.WaitReceipt, error) {
+	var txns sequence.Transactions
+	var nonce *big.Int
+	var err error
+
+	switch walletContext := r.WalletContext(); to {
+	case walletAddress:
+		txns, nonce, _, err = sequence.DecodeExecdata(execdata)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("relayer: invalid execdata: %w", err)
+		}
+		if nonce == nil {
+			return "", nil, nil, fmt.Errorf("relayer: invalid execdata: selfExecute calls cannot be relayed")
+		}
+	case walletContext.GuestModuleAddress:
+		txns, nonce, err = decodeDeployAndExecute(walletContext, walletAddress, execdata)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("relayer: invalid execdata: %w", err)
+		}
+	default:
+		return "", nil, nil, fmt.Errorf("relayer: execdata must be sent to the wallet %v or the guest module %v, not %v", walletAddress, walletContext.GuestModuleAddress, to)
+	}
+
+	if err := r.Policy().Check(txns); err != nil {
+		return "", nil, nil, err
+	}
+
+	chainID, err := r.GetProvider().ChainID(ctx)
 	if err != nil {
-		return metaTxnID, nil, nil, err
+		return "", nil, nil, err
+	}
+
+	metaTxnID, _, err := sequence.ComputeMetaTxnID(chainID, walletAddress, txns, nonce, sequence.MetaTxnWalletExec)
+	if err != nil {
+		return "", nil, nil, err
 	}
 
-	ntx, waitReceipt, err := sender.SendTransaction(ctx, signedTx)
+	ntx, waitReceipt, err := r.send(ctx, metaTxnID, chainID, walletAddress, nonce, txns, to, execdata)
 	if err != nil {
 		return metaTxnID, nil, nil, err
 	}
+	r.options.Logger.Debugf("relayer: sent metaTxnID %s in txn %s", metaTxnID, ntx.Hash().Hex())
 
-	return metaTxnID, ntx, waitReceipt, nil
+	// transactions of types unknown to go-ethereum are not returned
+	tx, _ := ntx.(*types.Transaction)
+	return metaTxnID, tx, waitReceipt, nil
+}
+
+// decodeDeployAndExecute decodes the execdata of the guest module of walletContext which deploys
+// the wallet at walletAddress and executes its bundle, and returns the bundle of the wallet.
+func decodeDeployAndExecute(walletContext sequence.WalletContext, walletAddress common.Address, execdata []byte) (sequence.Transactions, *big.Int, error) {
+	bundle, _, _, err := sequence.DecodeExecdata(execdata)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(bundle) != 2 {
+		return nil, nil, fmt.Errorf("guest module execdata must deploy the wallet and execute its bundle")
+	}
+	deploy, execute := bundle[0], bundle[1]
+
+	// the wallet deployed by the factory is the one of walletAddress
+	deployMethod := contracts.WalletFactory.ABI.Methods["deploy"]
+	if deploy.To != walletContext.FactoryAddress || len(deploy.Data) < 4 || !bytes.Equal(deploy.Data[:4], deployMethod.ID) {
+		return nil, nil, fmt.Errorf("guest module execdata must deploy the wallet through the factory %v", walletContext.FactoryAddress)
+	}
+	args, err := deployMethod.Inputs.Unpack(deploy.Data[4:])
+	if err != nil || len(args) != 2 {
+		return nil, nil, fmt.Errorf("invalid wallet deployment: %w", err)
+	}
+	mainModule, _ := args[0].(common.Address)
+	salt, _ := args[1].([32]byte)
+	deployed, err := sequence.AddressFromImageHash(common.Hash(salt).Hex(), walletContext)
+	if err != nil {
+		return nil, nil, err
+	}
+	if mainModule != walletContext.MainModuleAddress || deployed != walletAddress {
+		return nil, nil, fmt.Errorf("guest module execdata deploys %v, not the wallet %v", deployed, walletAddress)
+	}
+
+	if execute.To != walletAddress || !execute.IsBundle() || execute.Nonce == nil || execute.Signature == nil {
+		return nil, nil, fmt.Errorf("guest module execdata must execute a signed bundle of the wallet %v", walletAddress)
+	}
+	return execute.Transactions, execute.Nonce, nil
+}
+
+func (r *LocalRelayer) send(ctx context.Context, metaTxnID sequence.MetaTxnID, chainID *big.Int, walletAddress common.Address, walletNonce *big.Int, txns sequence.Transactions, to common.Address, execdata []byte) (NativeTx, ethtxn.WaitReceipt, error) {
+	if r.IsPaused() {
+		return nil, nil, ErrRelayerPaused
+	}
+
+	if r.accountant != nil {
+		if err := r.accountant.Reserve(ctx, r.project, walletAddress); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	private := r.private.applies(txns)
+
+	var ntx NativeTx
+	var waitReceipt ethtxn.WaitReceipt
+	sender, nonce, release, err := r.acquireSender(ctx)
+	if err == nil {
+		ntx, waitReceipt, err = r.sendTransaction(ctx, sender, nonce, chainID, to, execdata, private)
+		release(err == nil)
+	}
+	if err != nil {
+		if r.accountant != nil {
+			r.accountant.Release(ctx, r.project, walletAddress)
+		}
+		r.options.Metrics.IncCounter("relayer.relay.error")
+		return nil, nil, err
+	}
+	r.options.Metrics.IncCounter("relayer.relay")
+
+	r.track(metaTxnID, walletAddress, walletNonce, sender, chainID, ntx, waitReceipt, private)
+
+	return ntx, waitReceipt, nil
+}
+
+// acquireSender returns the sender of the next transaction, and its nonce if it is managed
+// by the sender pool. release must be called once the transaction was sent, or failed.
+func (r *LocalRelayer) acquireSender(ctx context.Context) (*ethwallet.Wallet, *big.Int, func(sent bool), error) {
+	pool := r.senderPool
+	if pool == nil {
+		return r.GetSender(), nil, func(bool) {}, nil
+	}
+
+	s, err := pool.acquire(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	nonce, err := pool.nextNonce(ctx, s)
+	if err != nil {
+		pool.release(s, false)
+		return nil, nil, nil, err
+	}
+	return s.wallet, nonce, func(sent bool) { pool.release(s, sent) }, nil
+}
+
+func (r *LocalRelayer) sendTransaction(ctx context.Context, sender *ethwallet.Wallet, nonce *big.Int, chainID *big.Int, to common.Address, execdata []byte, private bool) (NativeTx, ethtxn.WaitReceipt, error) {
+	var fees *FeeStrategy
+	if policy := r.Policy(); policy != nil {
+		fees = policy.Fees
+	}
+	gasPrice, err := fees.GasPrice(ctx, r.GetProvider())
+	if err != nil {
+		return nil, nil, err
+	}
+	gasPrice, err = capGasPrice(ctx, r.GetProvider(), gasPrice)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return r.buildAndSend(ctx, sender, chainID, &ethtxn.TransactionRequest{
+		To: &to, Nonce: nonce, Data: execdata, GasPrice: gasPrice,
+	}, private)
+}
+
+func (r *LocalRelayer) buildAndSend(ctx context.Context, sender *ethwallet.Wallet, chainID *big.Int, req *ethtxn.TransactionRequest, private bool) (NativeTx, ethtxn.WaitReceipt, error) {
+	signedTx, err := r.getTxBuilder().BuildTx(ctx, sender, chainID, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if private {
+		return r.sendPrivate(ctx, sender.Address(), signedTx)
+	}
+	waitReceipt, err := sendNativeTx(ctx, sender.GetProvider(), signedTx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return signedTx, waitReceipt, nil
+}
+
+// WaitForMany waits for all of metaTxnIDs at once, sending the result of each on the returned
+// channel as it is mined, see sequence.WaitForMany.
+func (r *LocalRelayer) WaitForMany(ctx context.Context, metaTxnIDs []sequence.MetaTxnID, optTimeout ...time.Duration) <-chan sequence.WaitResult {
+	return waitForMany(ctx, r.receiptListener, r.GetSender().GetProvider(), r.options, metaTxnIDs, optTimeout)
 }
 
 func (r *LocalRelayer) Wait(ctx context.Context, metaTxnID sequence.MetaTxnID, optTimeout ...time.Duration) (sequence.MetaTxnStatus, *types.Receipt, error) {
+	defer func(start time.Time) {
+		r.options.Metrics.ObserveDuration("relayer.wait", time.Since(start))
+	}(time.Now())
+
 	if r.receiptListener == nil {
-		return 0, nil, fmt.Errorf("relayer: failed to wait for metaTxnID as receiptListener is not set")
+		return r.pollMetaTxn(ctx, metaTxnID, optTimeout)
 	}
-	result, receipt, _, err := sequence.FetchMetaTransactionReceipt(ctx, r.receiptListener, metaTxnID, optTimeout...)
+
+	result, receipt, err := sequence.WaitMetaTransactionReceipt(ctx, r.receiptListener, r.GetSender().GetProvider(), metaTxnID, r.options.WaitOptions, waitTimeout(ctx, r.options, optTimeout)...)
 	if err != nil {
 		return 0, nil, err
 	}
+	if err := r.options.VerifyReceipt(ctx, r.GetSender().GetProvider(), receipt.Receipt()); err != nil {
+		return 0, nil, err
+	}
 	var status sequence.MetaTxnStatus
 	if result != nil {
 		status = result.Status
 	}
 	return status, receipt.Receipt(), nil
 }
+
+// pollMetaTxn waits for metaTxnID with the meta-transactions listener of the relayer, or else
+// by polling the logs of the node, for relayers without a receipts listener.
+func (r *LocalRelayer) pollMetaTxn(ctx context.Context, metaTxnID sequence.MetaTxnID, optTimeout []time.Duration) (sequence.MetaTxnStatus, *types.Receipt, error) {
+	provider := r.GetProvider()
+	if r.metaTxnListener != nil {
+		result, receipt, err := r.metaTxnListener.WaitForMetaTxn(ctx, metaTxnID, waitTimeout(ctx, r.options, optTimeout)...)
+		if err != nil {
+			return 0, nil, err
+		}
+		if err := r.options.VerifyReceipt(ctx, provider, receipt); err != nil {
+			return 0, nil, err
+		}
+		return result.Status, receipt, nil
+	}
+
+	status, receipt, err := sequence.LegacyWaitForMetaTxn(ctx, provider, metaTxnID, waitTimeout(ctx, r.options, optTimeout)...)
+	if err != nil {
+		return 0, nil, err
+	}
+	if receipt == nil {
+		return 0, nil, fmt.Errorf("relayer: failed to wait for metaTxnID %v: %w", metaTxnID, ctx.Err())
+	}
+	if err := r.options.VerifyReceipt(ctx, provider, receipt); err != nil {
+		return 0, nil, err
+	}
+	return status, receipt, nil
+}
@@ -0,0 +1,210 @@
+package relayer_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/0xsequence/go-sequence/relayer"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalRelayerWithProvider(t *testing.T) {
+	ctx := context.Background()
+	chain := memchain.New(memchain.Options{})
+
+	target := common.HexToAddress("0x7a7")
+	chain.Deploy(target, memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		if len(input) > 0 {
+			return nil, memchain.Revert("no data expected")
+		}
+		return nil, nil
+	}))
+
+	sender, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	chain.Fund(sender.Address(), big.NewInt(1e18))
+
+	_, err = relayer.NewLocalRelayerWithProvider(sender, nil)
+	assert.ErrorIs(t, err, sequence.ErrProviderNotSet)
+
+	r, err := relayer.NewLocalRelayerWithProvider(sender, chain.Provider())
+	assert.NoError(t, err)
+	assert.Equal(t, chain.Provider(), r.GetProvider())
+
+	wallet, err := testutil.MemChainWallet(chain, 2)
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.SetRelayer(r))
+	testutil.DeployMemChainWallet(chain, wallet)
+
+	// bundles are relayed by the EOA, and waited for by polling the node
+	send := func(data []byte) (sequence.MetaTxnStatus, error) {
+		signed, err := wallet.SignTransactions(ctx, sequence.Transactions{{To: target, Data: data}})
+		if err != nil {
+			return 0, err
+		}
+		assert.NotNil(t, signed.Transactions[0].GasLimit)
+		metaTxnID, _, _, err := wallet.SendTransaction(ctx, signed)
+		if err != nil {
+			return 0, err
+		}
+		status, receipt, err := r.Wait(ctx, metaTxnID, 10*time.Second)
+		if err == nil {
+			assert.Equal(t, wallet.Address(), receipt.Logs[0].Address)
+		}
+		return status, err
+	}
+
+	status, err := send(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, sequence.MetaTxnExecuted, status)
+
+	status, err = send([]byte{1})
+	assert.NoError(t, err)
+	assert.Equal(t, sequence.MetaTxnFailed, status)
+
+	nonce, err := wallet.GetNonce()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), nonce.Int64())
+
+	// there is no receipts listener to wait for many at once
+	for res := range r.WaitForMany(ctx, []sequence.MetaTxnID{"00"}) {
+		assert.Error(t, res.Err)
+	}
+}
+
+func TestLocalRelayerMaxGasPrice(t *testing.T) {
+	ctx := context.Background()
+	node := newMempoolNode(0)
+	sender, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+	sender.SetProvider(node.chain.Provider())
+	r, err := relayer.NewLocalRelayer(sender, nil)
+	assert.NoError(t, err)
+
+	wallet := mempoolWallet
+	txns, err := sequence.Transactions{{To: common.HexToAddress("0xb0b0"), Value: big.NewInt(1)}}.EncodedTransactions()
+	assert.NoError(t, err)
+	execdata, err := contracts.WalletMainModule.Encode("execute", txns, big.NewInt(0), []byte{0x01})
+	assert.NoError(t, err)
+
+	// the gas price of the node is 1 gwei, capped for this relay only
+	_, _, _, err = r.RelayExecdata(sequence.WithCallOpts(ctx, sequence.CallOpts{MaxGasPrice: big.NewInt(500_000_000)}), wallet, wallet, execdata)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(500_000_000), node.lastSent().GasPrice())
+
+	_, _, _, err = r.RelayExecdata(sequence.WithCallOpts(ctx, sequence.CallOpts{MaxGasPrice: big.NewInt(2_000_000_000)}), wallet, wallet, execdata)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1_000_000_000), node.lastSent().GasPrice())
+
+	_, _, _, err = r.RelayExecdata(ctx, wallet, wallet, execdata)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1_000_000_000), node.lastSent().GasPrice())
+}
+
+func TestLocalRelayerEstimateGasLimitsFallback(t *testing.T) {
+	ctx := context.Background()
+	node := newMempoolNode(0)
+	sender, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+	sender.SetProvider(node.chain.Provider())
+	r, err := relayer.NewLocalRelayer(sender, nil)
+	assert.NoError(t, err)
+
+	owner, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(owner)
+	assert.NoError(t, err)
+
+	// the node doesn't simulate the bundle through the wallet, so each transaction is
+	// estimated on its own, and those with a gas limit keep it
+	for _, estimator := range []*sequence.Estimator{sequence.NewEstimator(), nil} {
+		txns := sequence.Transactions{
+			{To: common.HexToAddress("0xb0b0"), Value: big.NewInt(1)},
+			{To: common.HexToAddress("0xb0b1"), GasLimit: big.NewInt(50000)},
+		}
+		estimated, err := r.SetEstimator(estimator).EstimateGasLimits(ctx, wallet.GetWalletConfig(), wallet.GetWalletContext(), txns)
+		assert.NoError(t, err)
+		assert.Equal(t, big.NewInt(200000), estimated[0].GasLimit)
+		assert.Equal(t, big.NewInt(50000), estimated[1].GasLimit)
+	}
+}
+
+func TestLocalRelayerEstimateGasLimitsSimulated(t *testing.T) {
+	ctx := context.Background()
+	node := newMempoolNode(0)
+
+	// the node simulates the execute calls of the wallet, where its execution costs 30000 gas,
+	// each transaction 10000 more, and those to 0xb0b2 refund 20000, as memchain doesn't apply
+	// the state overrides of the estimator
+	refunder := common.HexToAddress("0xb0b2")
+	node.chain.Intercept(func(method string, params []json.RawMessage, next func() (interface{}, error)) (interface{}, error) {
+		if method != "eth_call" || len(params) <= 2 {
+			return node.serve(method, params, next)
+		}
+		var call struct {
+			Data hexutil.Bytes `json:"data"`
+		}
+		if err := json.Unmarshal(params[0], &call); err != nil {
+			return nil, err
+		}
+		args, err := contracts.GasEstimator.ABI.Methods["estimate"].Inputs.Unpack(call.Data[4:])
+		if err != nil {
+			return nil, err
+		}
+		txns, _, _, err := sequence.DecodeExecdata(args[1].([]byte))
+		if err != nil {
+			return nil, err
+		}
+		gas := int64(30000)
+		for _, txn := range txns {
+			if txn.To == refunder {
+				gas -= 20000
+			} else {
+				gas += 10000
+			}
+		}
+		return ethcoder.AbiCoderHex([]string{"bool", "bytes", "uint256"}, []interface{}{true, []byte{}, big.NewInt(gas)})
+	})
+
+	sender, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+	sender.SetProvider(node.chain.Provider())
+	r, err := relayer.NewLocalRelayer(sender, nil, sequence.WithEstimator(sequence.NewEstimator()))
+	assert.NoError(t, err)
+
+	owner, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(owner)
+	assert.NoError(t, err)
+
+	txns := sequence.Transactions{
+		{To: common.HexToAddress("0xb0b0"), Value: big.NewInt(1)},
+		{To: common.HexToAddress("0xb0b1"), GasLimit: big.NewInt(50000)},
+		{To: refunder},
+		{To: refunder, GasLimit: big.NewInt(60000)},
+	}
+	estimated, err := r.EstimateGasLimits(ctx, wallet.GetWalletConfig(), wallet.GetWalletContext(), txns)
+	assert.NoError(t, err)
+
+	// the transaction without a gas limit is given the gas it used in the simulation, with its calldata
+	assert.Greater(t, estimated[0].GasLimit.Uint64(), uint64(10000))
+	assert.Less(t, estimated[0].GasLimit.Uint64(), uint64(20000))
+
+	// those with a gas limit keep it
+	assert.Equal(t, big.NewInt(50000), estimated[1].GasLimit)
+	assert.Equal(t, big.NewInt(60000), estimated[3].GasLimit)
+
+	// and the refunding one isn't given a zero or negative gas limit, but is estimated on its own
+	assert.Equal(t, big.NewInt(200000), estimated[2].GasLimit)
+}
@@ -0,0 +1,331 @@
+package relayer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/go-sequence"
+)
+
+const (
+	// DefaultNonceGapStuckAfter is how long a relayed bundle may stay unmined before it is
+	// diagnosed by a NonceGapMonitor.
+	DefaultNonceGapStuckAfter = 2 * time.Minute
+
+	// DefaultNonceGapPollInterval is how often a NonceGapMonitor checks pending bundles.
+	DefaultNonceGapPollInterval = 30 * time.Second
+
+	// DefaultNonceGapMaxBumps is how many times a stuck transaction is bumped by a
+	// NonceGapMonitor, before it is left for an operator.
+	DefaultNonceGapMaxBumps = 5
+)
+
+// NonceGapKind is the diagnosis of a relayed bundle which was not executed in time.
+type NonceGapKind int
+
+const (
+	// NonceGapStuck is a native transaction which is known by the node but not mined, ie.
+	// because it is underpriced. It is healed by bumping its gas price.
+	NonceGapStuck NonceGapKind = iota + 1
+
+	// NonceGapDropped is a native transaction which is unknown to the node, and whose sender
+	// nonce is unused. It is healed by sending it again.
+	NonceGapDropped
+
+	// NonceGapReplaced is a native transaction which is unknown to the node, and whose sender
+	// nonce was used by another transaction. It is healed by sending the bundle again on a
+	// new sender nonce.
+	NonceGapReplaced
+
+	// NonceGapSuperseded is a bundle whose wallet nonce was used by another bundle, so it
+	// cannot be executed anymore. It is healed by cancelling its native transaction.
+	NonceGapSuperseded
+)
+
+func (k NonceGapKind) String() string {
+	switch k {
+	case NonceGapStuck:
+		return "stuck"
+	case NonceGapDropped:
+		return "dropped"
+	case NonceGapReplaced:
+		return "replaced"
+	case NonceGapSuperseded:
+		return "superseded"
+	default:
+		return "unknown"
+	}
+}
+
+// NonceGap is a relayed bundle which the wallet's on-chain nonce has not advanced past, or
+// which was superseded, and the diagnosis of its native transaction.
+type NonceGap struct {
+	PendingTransaction
+	Kind NonceGapKind
+
+	// WalletNonce is the on-chain nonce of the wallet, in the nonce space of the bundle.
+	WalletNonce *big.Int
+
+	// SenderNonce is the on-chain nonce of the sender of the native transaction.
+	SenderNonce uint64
+}
+
+// NonceGapMonitor watches the pending transactions of a LocalRelayer, and diagnoses and heals
+// the bundles which are not executed after some time: stuck transactions are bumped, dropped
+// ones are sent again, those whose sender nonce was taken are reassigned a new one, and those
+// whose wallet nonce was taken are cancelled.
+type NonceGapMonitor struct {
+	relayer *LocalRelayer
+	options sequence.Options
+
+	stuckAfter   time.Duration
+	pollInterval time.Duration
+	maxBumps     int
+	onGap        func(ctx context.Context, gap NonceGap)
+
+	ctx     context.Context
+	ctxStop context.CancelFunc
+	running int32
+	mu      sync.Mutex
+}
+
+var _ sequence.Runnable = &NonceGapMonitor{}
+
+func NewNonceGapMonitor(relayer *LocalRelayer, opts ...sequence.Option) *NonceGapMonitor {
+	return &NonceGapMonitor{
+		relayer:      relayer,
+		options:      sequence.NewOptions(opts...),
+		stuckAfter:   DefaultNonceGapStuckAfter,
+		pollInterval: DefaultNonceGapPollInterval,
+		maxBumps:     DefaultNonceGapMaxBumps,
+	}
+}
+
+// SetStuckAfter sets how long a bundle may stay unmined before it is diagnosed.
+func (m *NonceGapMonitor) SetStuckAfter(d time.Duration) *NonceGapMonitor {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stuckAfter = d
+	return m
+}
+
+// SetPollInterval sets how often Run checks the pending bundles.
+func (m *NonceGapMonitor) SetPollInterval(d time.Duration) *NonceGapMonitor {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pollInterval = d
+	return m
+}
+
+// SetMaxBumps sets how many times a stuck transaction is bumped, before Heal gives up on it.
+func (m *NonceGapMonitor) SetMaxBumps(n int) *NonceGapMonitor {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxBumps = n
+	return m
+}
+
+// OnGap sets fn to be called with each gap found by Run, before it is healed.
+func (m *NonceGapMonitor) OnGap(fn func(ctx context.Context, gap NonceGap)) *NonceGapMonitor {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onGap = fn
+	return m
+}
+
+// Check diagnoses the pending transactions of the relayer which were sent more than the
+// stuck duration ago. Transactions which are mined, or which cannot be diagnosed, are not
+// returned.
+func (m *NonceGapMonitor) Check(ctx context.Context) ([]NonceGap, error) {
+	m.mu.Lock()
+	stuckAfter := m.stuckAfter
+	m.mu.Unlock()
+
+	type walletSpace struct {
+		wallet common.Address
+		space  string
+	}
+	walletNonces := map[walletSpace]*big.Int{}
+	senderNonces := map[common.Address]uint64{}
+
+	var gaps []NonceGap
+	for _, p := range m.relayer.Pending() {
+		if time.Since(p.SentAt) < stuckAfter || p.Nonce == nil {
+			continue
+		}
+		provider := m.relayer.GetProvider()
+
+		space, nonce := sequence.DecodeNonce(p.Nonce)
+		key := walletSpace{p.Wallet, space.String()}
+		walletNonce, ok := walletNonces[key]
+		if !ok {
+			var err error
+			walletNonce, err = sequence.GetWalletAddressNonce(provider, p.Wallet, space, nil)
+			if err != nil {
+				return gaps, fmt.Errorf("relayer: failed to get nonce of wallet %v: %w", p.Wallet, err)
+			}
+			walletNonces[key] = walletNonce
+		}
+
+		known, mined, err := transactionStatus(ctx, provider, p.Transaction.Hash())
+		if err != nil {
+			return gaps, err
+		}
+		if mined {
+			// the receipt is accounted by the relayer once it is fetched
+			continue
+		}
+
+		gap := NonceGap{PendingTransaction: p, WalletNonce: walletNonce}
+		switch {
+		case walletNonce.Cmp(nonce) > 0:
+			gap.Kind = NonceGapSuperseded
+		case known:
+			gap.Kind = NonceGapStuck
+		default:
+			senderNonce, ok := senderNonces[p.Sender]
+			if !ok {
+				senderNonce, err = provider.NonceAt(ctx, p.Sender, nil)
+				if err != nil {
+					return gaps, fmt.Errorf("relayer: failed to get nonce of sender %v: %w", p.Sender, err)
+				}
+				senderNonces[p.Sender] = senderNonce
+			}
+			gap.SenderNonce = senderNonce
+
+			if senderNonce > p.Transaction.Nonce() {
+				gap.Kind = NonceGapReplaced
+			} else {
+				gap.Kind = NonceGapDropped
+			}
+		}
+		gaps = append(gaps, gap)
+	}
+	return gaps, nil
+}
+
+// Heal resolves a gap found by Check according to its kind.
+func (m *NonceGapMonitor) Heal(ctx context.Context, gap NonceGap) error {
+	m.mu.Lock()
+	maxBumps := m.maxBumps
+	m.mu.Unlock()
+
+	var err error
+	switch gap.Kind {
+	case NonceGapStuck:
+		if gap.Bumps >= maxBumps {
+			return fmt.Errorf("relayer: metaTxnID %s is still stuck after %d bumps", gap.MetaTxnID, gap.Bumps)
+		}
+		_, err = m.relayer.Bump(ctx, gap.MetaTxnID, nil)
+	case NonceGapDropped:
+		// a transaction evicted for its price is rejected again, so it is bumped instead
+		if err = m.relayer.rebroadcast(ctx, gap.MetaTxnID); err != nil && !errors.Is(err, ErrNotPending) {
+			_, err = m.relayer.Bump(ctx, gap.MetaTxnID, nil)
+		}
+	case NonceGapReplaced:
+		_, err = m.relayer.reassign(ctx, gap.MetaTxnID)
+	case NonceGapSuperseded:
+		known, _, statusErr := transactionStatus(ctx, m.relayer.GetProvider(), gap.Transaction.Hash())
+		if statusErr == nil && known {
+			_, err = m.relayer.Cancel(ctx, gap.MetaTxnID)
+		} else {
+			err = m.relayer.Evict(gap.MetaTxnID)
+		}
+	default:
+		return fmt.Errorf("relayer: unknown nonce gap kind %d", gap.Kind)
+	}
+	if err != nil {
+		return fmt.Errorf("relayer: failed to heal %s metaTxnID %s: %w", gap.Kind, gap.MetaTxnID, err)
+	}
+	return nil
+}
+
+func (m *NonceGapMonitor) Run(ctx context.Context) error {
+	if m.IsRunning() {
+		return fmt.Errorf("relayer: nonce gap monitor already running")
+	}
+
+	m.mu.Lock()
+	m.ctx, m.ctxStop = context.WithCancel(ctx)
+	runCtx, pollInterval := m.ctx, m.pollInterval
+	m.mu.Unlock()
+
+	atomic.StoreInt32(&m.running, 1)
+	defer atomic.StoreInt32(&m.running, 0)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-runCtx.Done():
+			return nil
+		case <-ticker.C:
+			m.checkAndHeal(runCtx)
+		}
+	}
+}
+
+func (m *NonceGapMonitor) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ctxStop != nil {
+		m.ctxStop()
+	}
+}
+
+func (m *NonceGapMonitor) IsRunning() bool {
+	return atomic.LoadInt32(&m.running) == 1
+}
+
+func (m *NonceGapMonitor) checkAndHeal(ctx context.Context) {
+	gaps, err := m.Check(ctx)
+	if err != nil {
+		m.options.Metrics.IncCounter("relayer.nonce_gap.check.error")
+		m.options.Logger.Warnf("relayer: failed to check nonce gaps: %v", err)
+	}
+
+	m.mu.Lock()
+	onGap := m.onGap
+	m.mu.Unlock()
+
+	for _, gap := range gaps {
+		m.options.Metrics.IncCounter("relayer.nonce_gap." + gap.Kind.String())
+		m.options.Logger.Warnf("relayer: metaTxnID %s is %s, txn %s sent %v ago", gap.MetaTxnID, gap.Kind, gap.Transaction.Hash().Hex(), time.Since(gap.SentAt).Round(time.Second))
+		if onGap != nil {
+			onGap(ctx, gap)
+		}
+
+		if err := m.Heal(ctx, gap); err != nil {
+			m.options.Metrics.IncCounter("relayer.nonce_gap.heal.error")
+			m.options.Logger.Errorf("%v", err)
+		}
+	}
+}
+
+// pendingTxn is the part of a transaction returned by eth_getTransactionByHash which tells
+// whether it is mined, fetched as raw json so transactions of any type are supported.
+type pendingTxn struct {
+	BlockNumber *hexutil.Big `json:"blockNumber"`
+}
+
+// transactionStatus returns whether the transaction of txnHash is known by the node, and
+// whether it is mined.
+func transactionStatus(ctx context.Context, provider *ethrpc.Provider, txnHash common.Hash) (bool, bool, error) {
+	var txn *pendingTxn
+	if err := provider.Do(ctx, ethrpc.NewCallBuilder[*pendingTxn]("eth_getTransactionByHash", nil, txnHash).Into(&txn)); err != nil {
+		return false, false, fmt.Errorf("relayer: failed to get txn %s: %w", txnHash.Hex(), err)
+	}
+	if txn == nil {
+		return false, false, nil
+	}
+	return true, txn.BlockNumber != nil, nil
+}
@@ -0,0 +1,181 @@
+package relayer_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/0xsequence/go-sequence/relayer"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+// mempoolNode is a chain whose mempool, wallet nonce and sender nonce are set by the test. Its
+// transactions are kept in the mempool, and never mined.
+type mempoolNode struct {
+	chain       *memchain.Chain
+	mu          sync.Mutex
+	known       map[common.Hash]bool
+	sent        []*types.Transaction
+	walletNonce int64
+	senderNonce uint64
+}
+
+// mempoolWallet is the wallet deployed on the chains of mempool nodes.
+var mempoolWallet = common.HexToAddress("0x5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a")
+
+// newMempoolNode returns a node whose gas price is 1 gwei, and whose wallet is at walletNonce.
+func newMempoolNode(walletNonce int64) *mempoolNode {
+	n := &mempoolNode{
+		chain:       memchain.New(memchain.Options{BaseFee: big.NewInt(0), GasTip: big.NewInt(1_000_000_000)}),
+		known:       map[common.Hash]bool{},
+		walletNonce: walletNonce,
+	}
+	n.chain.Deploy(mempoolWallet, memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		return common.BigToHash(big.NewInt(n.walletNonce)).Bytes(), nil
+	}))
+	n.chain.SetCode(mempoolWallet, common.FromHex("0x363d3d"))
+	n.chain.Intercept(n.serve)
+	return n
+}
+
+// serve keeps the transactions sent in the mempool, and estimates any transaction at 200000 gas.
+func (n *mempoolNode) serve(method string, params []json.RawMessage, next func() (interface{}, error)) (interface{}, error) {
+	switch method {
+	case "eth_estimateGas":
+		return "0x30d40", nil
+	case "eth_getTransactionCount":
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		return hexutil.EncodeUint64(n.senderNonce), nil
+	case "eth_sendRawTransaction":
+		var raw hexutil.Bytes
+		if err := json.Unmarshal(params[0], &raw); err != nil {
+			return nil, err
+		}
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(raw); err != nil {
+			return nil, err
+		}
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		n.sent = append(n.sent, tx)
+		n.known[tx.Hash()] = true
+		return tx.Hash(), nil
+	case "eth_getTransactionByHash":
+		var hash common.Hash
+		if err := json.Unmarshal(params[0], &hash); err != nil {
+			return nil, err
+		}
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		if n.known[hash] {
+			return map[string]interface{}{"hash": hash, "blockNumber": nil}, nil
+		}
+		return nil, nil
+	default:
+		return next()
+	}
+}
+
+func (n *mempoolNode) set(fn func(n *mempoolNode)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	fn(n)
+}
+
+func (n *mempoolNode) lastSent() *types.Transaction {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.sent[len(n.sent)-1]
+}
+
+func TestNonceGapMonitor(t *testing.T) {
+	ctx := context.Background()
+
+	node := newMempoolNode(5)
+	sender, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+	sender.SetProvider(node.chain.Provider())
+
+	r, err := relayer.NewLocalRelayer(sender, nil)
+	assert.NoError(t, err)
+
+	wallet := mempoolWallet
+	txns, err := sequence.Transactions{{To: common.HexToAddress("0xb0b0"), Value: big.NewInt(1)}}.EncodedTransactions()
+	assert.NoError(t, err)
+	execdata, err := contracts.WalletMainModule.Encode("execute", txns, big.NewInt(5), []byte{0x01})
+	assert.NoError(t, err)
+
+	metaTxnID, _, _, err := r.RelayExecdata(ctx, wallet, wallet, execdata)
+	assert.NoError(t, err)
+	relayed := node.lastSent()
+
+	monitor := relayer.NewNonceGapMonitor(r).SetStuckAfter(0).SetMaxBumps(1)
+
+	check := func(kind relayer.NonceGapKind) relayer.NonceGap {
+		gaps, err := monitor.Check(ctx)
+		assert.NoError(t, err)
+		if !assert.Len(t, gaps, 1) {
+			t.FailNow()
+		}
+		assert.Equal(t, kind, gaps[0].Kind, gaps[0].Kind.String())
+		assert.Equal(t, metaTxnID, gaps[0].MetaTxnID)
+		assert.Equal(t, big.NewInt(5), gaps[0].Nonce)
+		return gaps[0]
+	}
+
+	// the transaction is in the mempool: it is bumped, once
+	gap := check(relayer.NonceGapStuck)
+	assert.NoError(t, monitor.Heal(ctx, gap))
+	bumped := node.lastSent()
+	assert.Equal(t, relayed.Nonce(), bumped.Nonce())
+	assert.Equal(t, big.NewInt(1_200_000_000), bumped.GasPrice())
+
+	gap = check(relayer.NonceGapStuck)
+	assert.ErrorContains(t, monitor.Heal(ctx, gap), "still stuck after 1 bumps")
+
+	// the node dropped the transaction and its nonce is unused: it is sent again as is
+	node.set(func(n *mempoolNode) { n.known = map[common.Hash]bool{} })
+	gap = check(relayer.NonceGapDropped)
+	assert.NoError(t, monitor.Heal(ctx, gap))
+	assert.Equal(t, bumped.Hash(), node.lastSent().Hash())
+
+	// another transaction of the sender took its nonce: it is sent on the next nonce
+	node.set(func(n *mempoolNode) {
+		n.known = map[common.Hash]bool{}
+		n.senderNonce = relayed.Nonce() + 1
+	})
+	gap = check(relayer.NonceGapReplaced)
+	assert.Equal(t, relayed.Nonce()+1, gap.SenderNonce)
+	assert.NoError(t, monitor.Heal(ctx, gap))
+	reassigned := node.lastSent()
+	assert.Equal(t, relayed.Nonce()+1, reassigned.Nonce())
+	assert.Equal(t, execdata, reassigned.Data())
+	assert.Equal(t, reassigned.Hash(), r.Pending()[0].Transaction.Hash())
+
+	// another bundle took the wallet nonce: the transaction is cancelled and forgotten
+	node.set(func(n *mempoolNode) { n.walletNonce = 6 })
+	gap = check(relayer.NonceGapSuperseded)
+	assert.Equal(t, big.NewInt(6), gap.WalletNonce)
+	assert.NoError(t, monitor.Heal(ctx, gap))
+	cancel := node.lastSent()
+	assert.Equal(t, reassigned.Nonce(), cancel.Nonce())
+	assert.Equal(t, sender.Address(), *cancel.To())
+	assert.Equal(t, uint64(21_000), cancel.Gas())
+	assert.Empty(t, cancel.Data())
+	assert.Empty(t, r.Pending())
+
+	_, err = r.Cancel(ctx, metaTxnID)
+	assert.ErrorIs(t, err, relayer.ErrNotPending)
+}
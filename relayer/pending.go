@@ -0,0 +1,359 @@
+package relayer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+)
+
+var (
+	ErrRelayerPaused = fmt.Errorf("relayer: relaying is paused")
+	ErrNotPending    = fmt.Errorf("relayer: metaTxnID is not pending")
+)
+
+// DefaultBumpPercent is how much the gas price of a pending transaction is raised by Bump,
+// when no gas price is given. Nodes require at least 10% to accept a replacement.
+const DefaultBumpPercent = 20
+
+// pendingTransactionTimeout bounds how long a relayed transaction is tracked, before it is
+// dropped from the pending transactions and left unaccounted.
+const pendingTransactionTimeout = 10 * time.Minute
+
+// cancelGasLimit is the gas of the empty transfer replacing a cancelled transaction.
+const cancelGasLimit = 21_000
+
+// PendingTransaction is a native transaction sent by a LocalRelayer which is not mined yet.
+type PendingTransaction struct {
+	MetaTxnID sequence.MetaTxnID
+	Wallet    common.Address
+	Sender    common.Address
+
+	// Nonce is the meta-transaction nonce of the bundle, including its nonce space.
+	Nonce *big.Int
+
+	// Transaction is the latest native transaction carrying the bundle, which replaces the
+	// previous ones if it was bumped.
+	Transaction NativeTx
+
+	// Private is true if the transaction was submitted to private relays.
+	Private bool
+
+	SentAt time.Time
+	Bumps  int
+}
+
+type pendingTransaction struct {
+	PendingTransaction
+	sender  *ethwallet.Wallet
+	chainID *big.Int
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
+}
+
+// Pause stops the relayer from relaying new bundles, which fail with ErrRelayerPaused until
+// Resume is called. Pending transactions are still tracked and may be bumped.
+func (r *LocalRelayer) Pause() {
+	atomic.StoreInt32(&r.paused, 1)
+}
+
+func (r *LocalRelayer) Resume() {
+	atomic.StoreInt32(&r.paused, 0)
+}
+
+func (r *LocalRelayer) IsPaused() bool {
+	return atomic.LoadInt32(&r.paused) == 1
+}
+
+// Pending returns the transactions sent by the relayer which are not mined yet, oldest first.
+func (r *LocalRelayer) Pending() []PendingTransaction {
+	r.muPending.Lock()
+	defer r.muPending.Unlock()
+
+	pending := make([]PendingTransaction, 0, len(r.pending))
+	for _, p := range r.pending {
+		pending = append(pending, p.PendingTransaction)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].SentAt.Before(pending[j].SentAt)
+	})
+	return pending
+}
+
+// PendingNonces returns the nonces of the bundles of wallet which are pending, so they are
+// exported with it, see sequence.Wallet.Export.
+func (r *LocalRelayer) PendingNonces(wallet common.Address) []*big.Int {
+	r.muPending.Lock()
+	defer r.muPending.Unlock()
+
+	var nonces []*big.Int
+	for _, p := range r.pending {
+		if p.Wallet == wallet && p.Nonce != nil {
+			nonces = append(nonces, new(big.Int).Set(p.Nonce))
+		}
+	}
+	return nonces
+}
+
+// Evict stops tracking a pending transaction, ie. one which was dropped by the network. The
+// transaction itself cannot be recalled, and its gas is no longer accounted if it is mined.
+func (r *LocalRelayer) Evict(metaTxnID sequence.MetaTxnID) error {
+	r.muPending.Lock()
+	p, ok := r.pending[metaTxnID]
+	delete(r.pending, metaTxnID)
+	var tx NativeTx
+	if ok {
+		tx = p.Transaction
+	}
+	r.muPending.Unlock()
+
+	if !ok {
+		return ErrNotPending
+	}
+	p.cancel()
+	r.publish(StatusUpdate{MetaTxnID: metaTxnID, Wallet: p.Wallet, State: PendingDropped, Transaction: tx.Hash()})
+	return nil
+}
+
+// Bump replaces a stuck pending transaction with one of the same nonce paying gasPrice, or
+// DefaultBumpPercent more than the current transaction if gasPrice is nil. The replacement is
+// sent by the same sender as the original, even if the relayer's sender was rotated since.
+func (r *LocalRelayer) Bump(ctx context.Context, metaTxnID sequence.MetaTxnID, gasPrice *big.Int) (NativeTx, error) {
+	r.muPending.Lock()
+	p, ok := r.pending[metaTxnID]
+	var prev NativeTx
+	var sender *ethwallet.Wallet
+	if ok {
+		prev, sender = p.Transaction, p.sender
+	}
+	r.muPending.Unlock()
+
+	if !ok {
+		return nil, ErrNotPending
+	}
+
+	if gasPrice == nil {
+		gasPrice = bumpGasPrice(prev.GasPrice())
+	}
+	if gasPrice.Cmp(prev.GasPrice()) <= 0 {
+		return nil, fmt.Errorf("relayer: bumped gas price %v must exceed %v", gasPrice, prev.GasPrice())
+	}
+
+	ntx, waitReceipt, err := r.buildAndSend(ctx, sender, p.chainID, requestFor(prev, gasPrice), p.Private)
+	if err != nil {
+		return nil, err
+	}
+	r.options.Metrics.IncCounter("relayer.bump")
+	r.options.Logger.Infof("relayer: bumped metaTxnID %s from txn %s to %s", metaTxnID, prev.Hash().Hex(), ntx.Hash().Hex())
+
+	r.muPending.Lock()
+	p.Transaction = ntx
+	p.Bumps++
+	p.waiters++
+	r.muPending.Unlock()
+	r.publish(StatusUpdate{MetaTxnID: metaTxnID, Wallet: p.Wallet, State: PendingReplaced, Transaction: ntx.Hash()})
+
+	go r.waitPending(p, ntx, waitReceipt)
+
+	return ntx, nil
+}
+
+// Cancel replaces a pending transaction with an empty transfer of its sender to itself, of
+// the same nonce and paying DefaultBumpPercent more, and stops tracking it. It is used when
+// the bundle cannot be executed anymore, ie. its wallet nonce was used by another bundle, so
+// its transaction would only revert and spend gas.
+func (r *LocalRelayer) Cancel(ctx context.Context, metaTxnID sequence.MetaTxnID) (NativeTx, error) {
+	r.muPending.Lock()
+	p, ok := r.pending[metaTxnID]
+	var prev NativeTx
+	var sender *ethwallet.Wallet
+	if ok {
+		prev, sender = p.Transaction, p.sender
+	}
+	r.muPending.Unlock()
+
+	if !ok {
+		return nil, ErrNotPending
+	}
+
+	to := sender.Address()
+	ntx, _, err := r.buildAndSend(ctx, sender, p.chainID, &ethtxn.TransactionRequest{
+		To:       &to,
+		Nonce:    new(big.Int).SetUint64(prev.Nonce()),
+		GasLimit: cancelGasLimit,
+		GasPrice: bumpGasPrice(prev.GasPrice()),
+		ETHValue: big.NewInt(0),
+	}, p.Private)
+	if err != nil {
+		return nil, err
+	}
+	r.options.Metrics.IncCounter("relayer.cancel")
+	r.options.Logger.Infof("relayer: cancelled metaTxnID %s, replaced txn %s with %s", metaTxnID, prev.Hash().Hex(), ntx.Hash().Hex())
+
+	_ = r.Evict(metaTxnID)
+	return ntx, nil
+}
+
+// rebroadcast sends a pending transaction which was dropped by the node again, as is.
+func (r *LocalRelayer) rebroadcast(ctx context.Context, metaTxnID sequence.MetaTxnID) error {
+	r.muPending.Lock()
+	p, ok := r.pending[metaTxnID]
+	var tx NativeTx
+	var sender *ethwallet.Wallet
+	if ok {
+		tx, sender = p.Transaction, p.sender
+	}
+	r.muPending.Unlock()
+
+	if !ok {
+		return ErrNotPending
+	}
+
+	// the transaction is still waited for by its hash, the new wait is not needed
+	var err error
+	if p.Private && r.private != nil {
+		_, _, err = r.sendPrivate(ctx, sender.Address(), tx)
+	} else {
+		_, err = sendNativeTx(ctx, sender.GetProvider(), tx)
+	}
+	if err != nil {
+		return err
+	}
+	r.options.Metrics.IncCounter("relayer.rebroadcast")
+	r.options.Logger.Infof("relayer: rebroadcast txn %s of metaTxnID %s", tx.Hash().Hex(), metaTxnID)
+	return nil
+}
+
+// reassign sends a pending transaction whose sender nonce was used by another transaction
+// again, on a new nonce of the next sender of the relayer.
+func (r *LocalRelayer) reassign(ctx context.Context, metaTxnID sequence.MetaTxnID) (NativeTx, error) {
+	r.muPending.Lock()
+	p, ok := r.pending[metaTxnID]
+	var prev NativeTx
+	if ok {
+		prev = p.Transaction
+	}
+	r.muPending.Unlock()
+
+	if !ok {
+		return nil, ErrNotPending
+	}
+	if prev.To() == nil {
+		return nil, fmt.Errorf("relayer: txn %s of metaTxnID %s has no recipient", prev.Hash().Hex(), metaTxnID)
+	}
+
+	sender, nonce, release, err := r.acquireSender(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ntx, waitReceipt, err := r.sendTransaction(ctx, sender, nonce, p.chainID, *prev.To(), prev.Data(), p.Private)
+	release(err == nil)
+	if err != nil {
+		return nil, err
+	}
+	r.options.Metrics.IncCounter("relayer.reassign")
+	r.options.Logger.Infof("relayer: reassigned metaTxnID %s from txn %s to %s of sender %v", metaTxnID, prev.Hash().Hex(), ntx.Hash().Hex(), sender.Address())
+
+	r.muPending.Lock()
+	p.Transaction = ntx
+	p.Sender = sender.Address()
+	p.sender = sender
+	p.waiters++
+	r.muPending.Unlock()
+	r.publish(StatusUpdate{MetaTxnID: metaTxnID, Wallet: p.Wallet, State: PendingReplaced, Transaction: ntx.Hash()})
+
+	go r.waitPending(p, ntx, waitReceipt)
+
+	return ntx, nil
+}
+
+// bumpGasPrice returns gasPrice raised by DefaultBumpPercent.
+func bumpGasPrice(gasPrice *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(gasPrice, big.NewInt(100+DefaultBumpPercent))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+// track records a sent transaction as pending until it, or one of its replacements, is
+// mined, and then accounts its gas.
+func (r *LocalRelayer) track(metaTxnID sequence.MetaTxnID, walletAddress common.Address, walletNonce *big.Int, sender *ethwallet.Wallet, chainID *big.Int, ntx NativeTx, waitReceipt ethtxn.WaitReceipt, private bool) {
+	// the wait is detached from the relay request's ctx, which ends when the request returns
+	ctx, cancel := context.WithTimeout(context.Background(), pendingTransactionTimeout)
+
+	p := &pendingTransaction{
+		PendingTransaction: PendingTransaction{
+			MetaTxnID:   metaTxnID,
+			Wallet:      walletAddress,
+			Sender:      sender.Address(),
+			Nonce:       walletNonce,
+			Transaction: ntx,
+			Private:     private,
+			SentAt:      time.Now(),
+		},
+		sender:  sender,
+		chainID: chainID,
+		ctx:     ctx,
+		cancel:  cancel,
+		waiters: 1,
+	}
+
+	r.muPending.Lock()
+	if prev, ok := r.pending[metaTxnID]; ok {
+		prev.cancel()
+	}
+	r.pending[metaTxnID] = p
+	r.muPending.Unlock()
+	r.publish(StatusUpdate{MetaTxnID: metaTxnID, Wallet: walletAddress, State: PendingSent, Transaction: ntx.Hash()})
+
+	go r.waitPending(p, ntx, waitReceipt)
+}
+
+func (r *LocalRelayer) waitPending(p *pendingTransaction, ntx NativeTx, waitReceipt ethtxn.WaitReceipt) {
+	receipt, err := waitReceipt(p.ctx)
+
+	r.muPending.Lock()
+	p.waiters--
+	current := r.pending[p.MetaTxnID] == p
+	done := current && (err == nil || p.waiters == 0)
+	if done {
+		// the bundle is mined, or none of its transactions can be waited for anymore
+		delete(r.pending, p.MetaTxnID)
+	}
+	latest := p.Transaction
+	r.muPending.Unlock()
+
+	if done && (err != nil || receipt == nil) {
+		r.publish(StatusUpdate{MetaTxnID: p.MetaTxnID, Wallet: p.Wallet, State: PendingDropped, Transaction: latest.Hash()})
+	}
+	if err != nil || receipt == nil || !current {
+		return
+	}
+	r.publish(StatusUpdate{
+		MetaTxnID:   p.MetaTxnID,
+		Wallet:      p.Wallet,
+		State:       PendingMined,
+		Transaction: receipt.TxHash,
+		Status:      minedStatus(p.MetaTxnID, receipt),
+		Receipt:     receipt,
+	})
+	// only one transaction of the same nonce is mined, stop waiting for the others
+	p.cancel()
+
+	if r.accountant != nil {
+		gasPrice := receipt.EffectiveGasPrice
+		if gasPrice == nil {
+			gasPrice = ntx.GasPrice()
+		}
+		if gasPrice == nil {
+			gasPrice = big.NewInt(0)
+		}
+		_ = r.accountant.RecordGas(context.Background(), r.project, p.Wallet, receipt.GasUsed, gasPrice)
+	}
+}
@@ -0,0 +1,121 @@
+package relayer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+)
+
+var ErrPolicyViolation = fmt.Errorf("relayer: transactions violate relaying policy")
+
+// Policy is the sponsorship policy and fee strategy of a relayer. A relayer's policy may be
+// swapped at runtime, ie. when its config is reloaded, and is never mutated once set.
+type Policy struct {
+	// MaxGasLimit is the maximum sum of the gas limits of a bundle, 0 means no limit.
+	MaxGasLimit uint64
+
+	// MaxTransactions is the maximum number of transactions of a bundle, 0 means no limit.
+	MaxTransactions int
+
+	// AllowedTargets restricts the contracts which may be called, empty means any.
+	AllowedTargets []common.Address
+
+	// Fees is the strategy used to price the native transactions, optional.
+	Fees *FeeStrategy
+}
+
+// FeeStrategy adjusts the gas price suggested by the node.
+type FeeStrategy struct {
+	// GasPriceMultiplier is applied to the suggested gas price, ie. 1.2 to outbid by 20%.
+	GasPriceMultiplier float64
+
+	// MaxGasPrice caps the gas price, nil means no cap.
+	MaxGasPrice *big.Int
+}
+
+// Check returns ErrPolicyViolation if txns are not sponsored by the policy.
+func (p *Policy) Check(txns sequence.Transactions) error {
+	if p == nil {
+		return nil
+	}
+
+	if p.MaxTransactions > 0 && len(txns) > p.MaxTransactions {
+		return fmt.Errorf("%w: %d transactions exceeds limit of %d", ErrPolicyViolation, len(txns), p.MaxTransactions)
+	}
+
+	if p.MaxGasLimit > 0 {
+		gasLimit := big.NewInt(0)
+		for _, txn := range txns {
+			if txn.GasLimit != nil {
+				gasLimit.Add(gasLimit, txn.GasLimit)
+			}
+		}
+		if gasLimit.Cmp(new(big.Int).SetUint64(p.MaxGasLimit)) > 0 {
+			return fmt.Errorf("%w: gas limit %v exceeds limit of %d", ErrPolicyViolation, gasLimit, p.MaxGasLimit)
+		}
+	}
+
+	if len(p.AllowedTargets) > 0 {
+		for _, txn := range txns {
+			if !p.isAllowedTarget(txn.To) {
+				return fmt.Errorf("%w: target %v is not allowed", ErrPolicyViolation, txn.To)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *Policy) isAllowedTarget(target common.Address) bool {
+	for _, allowed := range p.AllowedTargets {
+		if allowed == target {
+			return true
+		}
+	}
+	return false
+}
+
+// capGasPrice caps gasPrice to the MaxGasPrice of the call options of ctx, if any, see
+// sequence.CallOpts. A nil gasPrice is sampled from the node first.
+func capGasPrice(ctx context.Context, provider *ethrpc.Provider, gasPrice *big.Int) (*big.Int, error) {
+	opts, ok := sequence.CallOptsFromContext(ctx)
+	if !ok || opts.MaxGasPrice == nil {
+		return gasPrice, nil
+	}
+	if gasPrice == nil {
+		var err error
+		gasPrice, err = provider.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if gasPrice.Cmp(opts.MaxGasPrice) > 0 {
+		gasPrice = new(big.Int).Set(opts.MaxGasPrice)
+	}
+	return gasPrice, nil
+}
+
+// GasPrice returns the gas price to send a native transaction with, or nil to let the sender
+// sample it from the node.
+func (f *FeeStrategy) GasPrice(ctx context.Context, provider *ethrpc.Provider) (*big.Int, error) {
+	if f == nil || (f.GasPriceMultiplier == 0 && f.MaxGasPrice == nil) {
+		return nil, nil
+	}
+
+	gasPrice, err := provider.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.GasPriceMultiplier > 0 {
+		gasPrice, _ = new(big.Float).Mul(new(big.Float).SetInt(gasPrice), big.NewFloat(f.GasPriceMultiplier)).Int(nil)
+	}
+	if f.MaxGasPrice != nil && gasPrice.Cmp(f.MaxGasPrice) > 0 {
+		gasPrice = new(big.Int).Set(f.MaxGasPrice)
+	}
+	return gasPrice, nil
+}
@@ -0,0 +1,192 @@
+package relayer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+)
+
+var (
+	ErrSimulationFailed = fmt.Errorf("relayer: transaction simulation failed")
+	ErrNotIncluded      = fmt.Errorf("relayer: transaction was not included by the private relays")
+)
+
+const (
+	// DefaultPrivateInclusionBlocks is how many blocks a privately submitted transaction is
+	// waited for, before it is considered not included.
+	DefaultPrivateInclusionBlocks = 25
+
+	privateInclusionPollInterval = 2 * time.Second
+)
+
+// PrivateSubmission submits native transactions to private transaction relays, ie. Flashbots
+// Protect or MEV Blocker, instead of the public mempool, so value-sensitive bundles cannot
+// be front-run or sandwiched. Transactions are simulated before submission, and their
+// inclusion is monitored on the public chain.
+type PrivateSubmission struct {
+	// Relays are the rpc endpoints of the private relays, a transaction is submitted to
+	// each of them.
+	Relays []*ethrpc.Provider
+
+	// Sensitive selects the bundles which are submitted privately. If nil, all bundles are.
+	Sensitive func(txns sequence.Transactions) bool
+
+	// InclusionBlocks is how many blocks inclusion is waited for, DefaultPrivateInclusionBlocks
+	// if 0.
+	InclusionBlocks uint64
+
+	// PublicFallback sends transactions which were not included in time to the public
+	// mempool, instead of failing with ErrNotIncluded.
+	PublicFallback bool
+}
+
+// NewPrivateSubmission returns a PrivateSubmission to the private relays at urls, for all
+// bundles.
+func NewPrivateSubmission(urls ...string) (*PrivateSubmission, error) {
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("relayer: private submission requires at least one relay")
+	}
+	relays := make([]*ethrpc.Provider, 0, len(urls))
+	for _, url := range urls {
+		relay, err := ethrpc.NewProvider(url)
+		if err != nil {
+			return nil, fmt.Errorf("relayer: invalid private relay %q: %w", url, err)
+		}
+		relays = append(relays, relay)
+	}
+	return &PrivateSubmission{Relays: relays}, nil
+}
+
+// MinValueSensitive selects the bundles which transfer at least minValue of the native
+// currency, including in nested bundles.
+func MinValueSensitive(minValue *big.Int) func(txns sequence.Transactions) bool {
+	return func(txns sequence.Transactions) bool {
+		return bundleValue(txns).Cmp(minValue) >= 0
+	}
+}
+
+func bundleValue(txns sequence.Transactions) *big.Int {
+	value := new(big.Int)
+	for _, txn := range txns {
+		if txn.Value != nil {
+			value.Add(value, txn.Value)
+		}
+		value.Add(value, bundleValue(txn.Transactions))
+	}
+	return value
+}
+
+func (s *PrivateSubmission) applies(txns sequence.Transactions) bool {
+	return s != nil && (s.Sensitive == nil || s.Sensitive(txns))
+}
+
+// SetPrivateSubmission sets the private relays value-sensitive bundles are submitted
+// through. It must be set before the relayer starts relaying.
+func (r *LocalRelayer) SetPrivateSubmission(private *PrivateSubmission) *LocalRelayer {
+	r.private = private
+	return r
+}
+
+// sendPrivate simulates signedTx on the public chain, and submits it to the private relays.
+func (r *LocalRelayer) sendPrivate(ctx context.Context, from common.Address, signedTx NativeTx) (NativeTx, ethtxn.WaitReceipt, error) {
+	provider := r.GetProvider()
+
+	_, err := provider.CallContract(ctx, ethereum.CallMsg{
+		From:     from,
+		To:       signedTx.To(),
+		Gas:      signedTx.Gas(),
+		GasPrice: signedTx.GasPrice(),
+		Value:    signedTx.Value(),
+		Data:     signedTx.Data(),
+	}, nil)
+	if err != nil {
+		r.options.Metrics.IncCounter("relayer.private.simulation_failed")
+		return nil, nil, fmt.Errorf("%w: %v", ErrSimulationFailed, err)
+	}
+
+	startBlock, err := provider.BlockNumber(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	raw, err := signedTx.MarshalBinary()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var accepted int
+	for _, relay := range r.private.Relays {
+		if _, err = relay.SendRawTransaction(ctx, hexutil.Encode(raw)); err != nil {
+			r.options.Logger.Warnf("relayer: private relay rejected txn %s: %v", signedTx.Hash().Hex(), err)
+			continue
+		}
+		accepted++
+	}
+	if accepted == 0 {
+		return nil, nil, fmt.Errorf("relayer: no private relay accepted the transaction: %w", err)
+	}
+	r.options.Metrics.IncCounter("relayer.private.submit")
+
+	waitReceipt := func(ctx context.Context) (*types.Receipt, error) {
+		return r.waitPrivateInclusion(ctx, signedTx, startBlock)
+	}
+	return signedTx, waitReceipt, nil
+}
+
+// waitPrivateInclusion waits for signedTx to be mined within the inclusion window, then
+// falls back to the public mempool or fails with ErrNotIncluded.
+func (r *LocalRelayer) waitPrivateInclusion(ctx context.Context, signedTx NativeTx, startBlock uint64) (*types.Receipt, error) {
+	provider := r.GetProvider()
+
+	inclusionBlocks := r.private.InclusionBlocks
+	if inclusionBlocks == 0 {
+		inclusionBlocks = DefaultPrivateInclusionBlocks
+	}
+
+	for {
+		receipt, err := provider.TransactionReceipt(ctx, signedTx.Hash())
+		if err == nil && receipt != nil {
+			return receipt, nil
+		}
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			return nil, err
+		}
+
+		head, err := provider.BlockNumber(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if head > startBlock+inclusionBlocks {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(privateInclusionPollInterval):
+		}
+	}
+
+	if !r.private.PublicFallback {
+		r.options.Metrics.IncCounter("relayer.private.not_included")
+		return nil, ErrNotIncluded
+	}
+
+	r.options.Metrics.IncCounter("relayer.private.fallback")
+	r.options.Logger.Warnf("relayer: txn %s was not included privately, sending it publicly", signedTx.Hash().Hex())
+	waitReceipt, err := sendNativeTx(ctx, provider, signedTx)
+	if err != nil {
+		return nil, err
+	}
+	return waitReceipt(ctx)
+}
@@ -0,0 +1,25 @@
+package relayer_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/relayer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinValueSensitive(t *testing.T) {
+	_, err := relayer.NewPrivateSubmission()
+	assert.Error(t, err)
+
+	sensitive := relayer.MinValueSensitive(big.NewInt(100))
+
+	assert.False(t, sensitive(sequence.Transactions{{Value: big.NewInt(60)}, {}}))
+	assert.True(t, sensitive(sequence.Transactions{{Value: big.NewInt(60)}, {Value: big.NewInt(40)}}))
+
+	// value moved by nested bundles counts too
+	assert.True(t, sensitive(sequence.Transactions{
+		{Value: big.NewInt(60), Transactions: sequence.Transactions{{Value: big.NewInt(50)}}},
+	}))
+}
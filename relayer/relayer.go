@@ -1 +1,57 @@
 package relayer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethreceipts"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/go-sequence"
+)
+
+// AccessKeyHeader is the header requests to a relayer server are authenticated with.
+const AccessKeyHeader = "X-Access-Key"
+
+// waitTimeout returns the timeout to wait on a metaTxnID with. An explicitly passed timeout
+// takes precedence, then the deadline of the ctx, and finally the relayer's WaitOptions.
+func waitTimeout(ctx context.Context, options sequence.Options, optTimeout []time.Duration) []time.Duration {
+	if len(optTimeout) > 0 || options.WaitOptions.Timeout <= 0 {
+		return optTimeout
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return optTimeout
+	}
+	return []time.Duration{options.WaitOptions.Timeout}
+}
+
+// waitForMany waits for metaTxnIDs as sequence.WaitForMany does, with the WaitOptions of the
+// relayer, and verifies each receipt as Wait does.
+func waitForMany(ctx context.Context, receiptListener *ethreceipts.ReceiptsListener, provider *ethrpc.Provider, options sequence.Options, metaTxnIDs []sequence.MetaTxnID, optTimeout []time.Duration) <-chan sequence.WaitResult {
+	results := make(chan sequence.WaitResult, len(metaTxnIDs))
+	if receiptListener == nil {
+		for _, metaTxnID := range metaTxnIDs {
+			results <- sequence.WaitResult{MetaTxnID: metaTxnID, Err: fmt.Errorf("relayer: failed to wait for metaTxnID as receiptListener is not set")}
+		}
+		close(results)
+		return results
+	}
+
+	go func(start time.Time) {
+		defer close(results)
+		defer func() {
+			options.Metrics.ObserveDuration("relayer.wait_many", time.Since(start))
+		}()
+
+		for result := range sequence.WaitForMany(ctx, receiptListener, provider, metaTxnIDs, options.WaitOptions, waitTimeout(ctx, options, optTimeout)...) {
+			if result.Err == nil {
+				if err := options.VerifyReceipt(ctx, provider, result.Receipt.Receipt()); err != nil {
+					result = sequence.WaitResult{MetaTxnID: result.MetaTxnID, Err: err}
+				}
+			}
+			results <- result
+		}
+	}(time.Now())
+
+	return results
+}
@@ -0,0 +1,139 @@
+package relayer
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence/relayer/proto"
+)
+
+// Headers of a signed relay request.
+const (
+	SignatureSchemeHeader = "X-Sequence-Signature-Scheme"
+	SignatureKeyIDHeader  = "X-Sequence-Key-Id"
+	SignatureHeader       = "X-Sequence-Signature"
+	TimestampHeader       = "X-Sequence-Timestamp"
+)
+
+// Signature schemes of relay requests.
+const (
+	// SchemeHMAC signs requests with an HMAC-SHA256 keyed with the tenant's api key, the key
+	// id is the tenant id. The api key itself is never sent.
+	SchemeHMAC = "hmac-sha256"
+
+	// SchemeEIP191 signs requests with an EOA as an EIP-191 message, the key id is the address
+	// of the signer.
+	SchemeEIP191 = "eip191"
+)
+
+// RequestSigner authenticates the requests a RpcRelayer sends to a relayer server.
+type RequestSigner interface {
+	Scheme() string
+	KeyID() string
+	Sign(digest []byte) ([]byte, error)
+}
+
+// RequestDigest is the digest signed by a relay request. It binds the request path and body
+// (the encoded bundle) to the time of the request, so it cannot be replayed later or against
+// another method.
+func RequestDigest(path string, body []byte, timestamp int64) []byte {
+	return crypto.Keccak256(
+		[]byte("sequence relay request:"),
+		[]byte(path),
+		crypto.Keccak256(body),
+		[]byte(strconv.FormatInt(timestamp, 10)),
+	)
+}
+
+// HMACRequestDigest returns the mac of digest keyed with apiKey, as signed by SchemeHMAC.
+func HMACRequestDigest(apiKey string, digest []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(apiKey))
+	mac.Write(digest)
+	return mac.Sum(nil)
+}
+
+type hmacRequestSigner struct {
+	tenantID string
+	apiKey   string
+}
+
+// NewHMACRequestSigner signs requests with the api key of the tenant.
+func NewHMACRequestSigner(tenantID, apiKey string) RequestSigner {
+	return &hmacRequestSigner{tenantID: tenantID, apiKey: apiKey}
+}
+
+func (s *hmacRequestSigner) Scheme() string { return SchemeHMAC }
+
+func (s *hmacRequestSigner) KeyID() string { return s.tenantID }
+
+func (s *hmacRequestSigner) Sign(digest []byte) ([]byte, error) {
+	return HMACRequestDigest(s.apiKey, digest), nil
+}
+
+type walletRequestSigner struct {
+	wallet *ethwallet.Wallet
+}
+
+// NewWalletRequestSigner signs requests with an EOA, which the relayer server must know as a
+// signer of the tenant.
+func NewWalletRequestSigner(wallet *ethwallet.Wallet) RequestSigner {
+	return &walletRequestSigner{wallet: wallet}
+}
+
+func (s *walletRequestSigner) Scheme() string { return SchemeEIP191 }
+
+func (s *walletRequestSigner) KeyID() string { return s.wallet.Address().Hex() }
+
+func (s *walletRequestSigner) Sign(digest []byte) ([]byte, error) {
+	return s.wallet.SignMessage(digest)
+}
+
+// SetRequestSigner sets the signer authenticating each request of the relayer, in place
+// of an access key.
+func (r *RpcRelayer) SetRequestSigner(signer RequestSigner) *RpcRelayer {
+	r.Service = proto.NewRelayerClient(r.rpcRelayerURL, &signingClient{client: r.httpClient, signer: signer})
+	return r
+}
+
+type signingClient struct {
+	client proto.HTTPClient
+	signer RequestSigner
+}
+
+func (c *signingClient) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	timestamp := time.Now().Unix()
+	sig, err := c.signer.Sign(RequestDigest(req.URL.Path, body, timestamp))
+	if err != nil {
+		return nil, fmt.Errorf("relayer: failed to sign request: %w", err)
+	}
+
+	req.Header.Set(SignatureSchemeHeader, c.signer.Scheme())
+	req.Header.Set(SignatureKeyIDHeader, c.signer.KeyID())
+	req.Header.Set(SignatureHeader, hexutil.Encode(sig))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+
+	return c.client.Do(req)
+}
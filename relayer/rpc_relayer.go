@@ -2,8 +2,10 @@ package relayer
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math/big"
+	"net/http"
 	"net/url"
 	"time"
 
@@ -21,11 +23,21 @@ type RpcRelayer struct {
 	provider        *ethrpc.Provider
 	receiptListener *ethreceipts.ReceiptsListener
 	Service         proto.Relayer
+	options         sequence.Options
+
+	rpcRelayerURL string
+	httpClient    proto.HTTPClient
+
+	pollInterval time.Duration
+	simulate     bool
 }
 
+// defaultPollInterval is how often the status of a meta-transaction is polled by default.
+const defaultPollInterval = time.Second
+
 var _ sequence.Relayer = &RpcRelayer{}
 
-func NewRpcRelayer(provider *ethrpc.Provider, receiptListener *ethreceipts.ReceiptsListener, rpcRelayerURL string, httpClient proto.HTTPClient) (*RpcRelayer, error) {
+func NewRpcRelayer(provider *ethrpc.Provider, receiptListener *ethreceipts.ReceiptsListener, rpcRelayerURL string, httpClient proto.HTTPClient, opts ...sequence.Option) (*RpcRelayer, error) {
 	_, err := url.Parse(rpcRelayerURL)
 	if err != nil {
 		return nil, fmt.Errorf("rpcRelayerURL is invalid: %w", err)
@@ -37,9 +49,48 @@ func NewRpcRelayer(provider *ethrpc.Provider, receiptListener *ethreceipts.Recei
 		provider:        provider,
 		receiptListener: receiptListener,
 		Service:         service,
+		options:         sequence.NewOptions(opts...),
+		rpcRelayerURL:   rpcRelayerURL,
+		httpClient:      httpClient,
+		pollInterval:    defaultPollInterval,
 	}, nil
 }
 
+// SetPollInterval sets how often the relayer is polled for the status of meta-transactions
+// waited for without a receiptListener, every second by default.
+func (r *RpcRelayer) SetPollInterval(pollInterval time.Duration) *RpcRelayer {
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	r.pollInterval = pollInterval
+	return r
+}
+
+// SetSimulate sets whether bundles are simulated before they are sent to the relayer, so
+// bundles with failing transactions are rejected with ErrSimulationFailed instead of being
+// relayed, ie. when the relayer charges for failed meta-transactions.
+func (r *RpcRelayer) SetSimulate(simulate bool) *RpcRelayer {
+	r.simulate = simulate
+	return r
+}
+
+// SetAccessKey sets the api key the relayer authenticates its requests with, as expected
+// by relayer servers hosting many tenants.
+func (r *RpcRelayer) SetAccessKey(accessKey string) *RpcRelayer {
+	r.Service = proto.NewRelayerClient(r.rpcRelayerURL, &accessKeyClient{client: r.httpClient, accessKey: accessKey})
+	return r
+}
+
+type accessKeyClient struct {
+	client    proto.HTTPClient
+	accessKey string
+}
+
+func (c *accessKeyClient) Do(req *http.Request) (*http.Response, error) {
+	req.Header.Set(AccessKeyHeader, c.accessKey)
+	return c.client.Do(req)
+}
+
 func (r *RpcRelayer) GetProvider() *ethrpc.Provider {
 	return r.provider
 }
@@ -107,11 +158,22 @@ func (r *RpcRelayer) GetNonce(ctx context.Context, walletConfig sequence.WalletC
 // responds with the native transaction hash (*types.Transaction), which means the relayer has submitted the transaction
 // request to the network. Clients can use WaitReceipt to wait until the metaTxnID has been mined.
 func (r *RpcRelayer) Relay(ctx context.Context, signedTxs *sequence.SignedTransactions) (sequence.MetaTxnID, *types.Transaction, ethtxn.WaitReceipt, error) {
+	if err := signedTxs.Verify(); err != nil {
+		return "", nil, nil, err
+	}
+
 	walletAddress, err := sequence.AddressFromWalletConfig(signedTxs.WalletConfig, signedTxs.WalletContext)
 	if err != nil {
 		return "", nil, nil, err
 	}
 
+	if r.simulate {
+		if err := r.checkSimulation(ctx, walletAddress, signedTxs.Transactions); err != nil {
+			r.options.Metrics.IncCounter("relayer.relay.simulation_failed")
+			return "", nil, nil, err
+		}
+	}
+
 	to, execdata, err := sequence.EncodeTransactionsForRelaying(
 		r,
 		signedTxs.WalletConfig,
@@ -134,6 +196,7 @@ func (r *RpcRelayer) Relay(ctx context.Context, signedTxs *sequence.SignedTransa
 
 	ok, metaTxnID, err := r.Service.SendMetaTxn(ctx, call)
 	if err != nil {
+		r.options.Metrics.IncCounter("relayer.relay.error")
 		return sequence.MetaTxnID(metaTxnID), nil, nil, err
 	}
 	if !ok {
@@ -142,6 +205,7 @@ func (r *RpcRelayer) Relay(ctx context.Context, signedTxs *sequence.SignedTransa
 	if metaTxnID == "" {
 		return "", nil, nil, proto.Failf("failed to relay meta transaction: server returned empty metaTxnID")
 	}
+	r.options.Metrics.IncCounter("relayer.relay")
 
 	waitReceipt := func(ctx context.Context) (*types.Receipt, error) {
 		// NOTE: to timeout the request, pass a ctx from context.WithTimeout
@@ -153,19 +217,25 @@ func (r *RpcRelayer) Relay(ctx context.Context, signedTxs *sequence.SignedTransa
 	return sequence.MetaTxnID(metaTxnID), nil, waitReceipt, nil
 }
 
-// ....
+// Wait waits for metaTxnID to be mined, and returns its status and the receipt of the native
+// transaction it was mined in. Without a receiptListener, the relayer is polled for its status
+// instead of the chain, see SetPollInterval.
 func (r *RpcRelayer) Wait(ctx context.Context, metaTxnID sequence.MetaTxnID, optTimeout ...time.Duration) (sequence.MetaTxnStatus, *types.Receipt, error) {
+	defer func(start time.Time) {
+		r.options.Metrics.ObserveDuration("relayer.wait", time.Since(start))
+	}(time.Now())
+
 	if r.receiptListener == nil {
-		return 0, nil, fmt.Errorf("relayer: failed to wait for metaTxnID as receiptListener is not set")
+		return r.pollReceipt(ctx, metaTxnID, waitTimeout(ctx, r.options, optTimeout))
 	}
 
-	// TODO: call rpcRelayer host RPC method GetMetaTxnReceipt()
-	// which in the future will be renamed to WaitTransactionReceipt()
-
-	result, receipt, _, err := sequence.FetchMetaTransactionReceipt(ctx, r.receiptListener, metaTxnID, optTimeout...)
+	result, receipt, err := sequence.WaitMetaTransactionReceipt(ctx, r.receiptListener, r.provider, metaTxnID, r.options.WaitOptions, waitTimeout(ctx, r.options, optTimeout)...)
 	if err != nil {
 		return 0, nil, err
 	}
+	if err := r.options.VerifyReceipt(ctx, r.provider, receipt.Receipt()); err != nil {
+		return 0, nil, err
+	}
 	var status sequence.MetaTxnStatus
 	if result != nil {
 		status = result.Status
@@ -173,6 +243,190 @@ func (r *RpcRelayer) Wait(ctx context.Context, metaTxnID sequence.MetaTxnID, opt
 	return status, receipt.Receipt(), nil
 }
 
+// WaitForMany waits for all of metaTxnIDs at once, sending the result of each on the returned
+// channel as it is mined, see sequence.WaitForMany. It requires a receiptListener.
+func (r *RpcRelayer) WaitForMany(ctx context.Context, metaTxnIDs []sequence.MetaTxnID, optTimeout ...time.Duration) <-chan sequence.WaitResult {
+	return waitForMany(ctx, r.receiptListener, r.provider, r.options, metaTxnIDs, optTimeout)
+}
+
+// pollReceipt polls the relayer for the status of metaTxnID until it is mined, or dropped.
+func (r *RpcRelayer) pollReceipt(ctx context.Context, metaTxnID sequence.MetaTxnID, optTimeout []time.Duration) (sequence.MetaTxnStatus, *types.Receipt, error) {
+	if len(optTimeout) > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, optTimeout[0])
+		defer cancel()
+	}
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		metaTxnReceipt, err := r.Service.GetMetaTxnReceipt(ctx, string(metaTxnID))
+		if err == nil {
+			status, receipt, err := r.decodeReceipt(ctx, metaTxnID, metaTxnReceipt)
+			if err != nil || receipt != nil {
+				return status, receipt, err
+			}
+		} else if ctx.Err() == nil && !proto.IsErrorCode(err, proto.ErrNotFound) && !proto.IsErrorCode(err, proto.ErrUnavailable) {
+			return 0, nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, nil, fmt.Errorf("relayer: waiting for meta transaction %v: %w", metaTxnID, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// decodeReceipt returns the status and native receipt of a meta-transaction receipt of the
+// relayer, or no receipt if it is still pending.
+func (r *RpcRelayer) decodeReceipt(ctx context.Context, metaTxnID sequence.MetaTxnID, metaTxnReceipt *proto.MetaTxnReceipt) (sequence.MetaTxnStatus, *types.Receipt, error) {
+	var status sequence.MetaTxnStatus
+	switch metaTxnReceipt.Status {
+	case proto.ETHTxnStatus_SUCCEEDED.String():
+		status = sequence.MetaTxnExecuted
+	case proto.ETHTxnStatus_FAILED.String(), proto.ETHTxnStatus_PARTIALLY_FAILED.String():
+		status = sequence.MetaTxnFailed
+	case "REVERTED":
+		status = sequence.MetaTxnReverted
+	case proto.ETHTxnStatus_DROPPED.String():
+		return 0, nil, fmt.Errorf("relayer: meta transaction %v was dropped", metaTxnID)
+	default:
+		return 0, nil, nil
+	}
+
+	var receipt types.Receipt
+	if err := json.Unmarshal([]byte(metaTxnReceipt.TxnReceipt), &receipt); err != nil {
+		return 0, nil, fmt.Errorf("relayer: invalid receipt of meta transaction %v: %w", metaTxnID, err)
+	}
+	if err := r.options.VerifyReceipt(ctx, r.provider, &receipt); err != nil {
+		return 0, nil, err
+	}
+	if r.options.WaitOptions.OnStatus != nil {
+		r.options.WaitOptions.OnStatus(metaTxnID, status)
+	}
+	return status, &receipt, nil
+}
+
+// FeeTokens returns the tokens the relayer accepts fees in, or none if it relays for free.
+func (r *RpcRelayer) FeeTokens(ctx context.Context) ([]*proto.FeeToken, error) {
+	enabled, tokens, err := r.Service.FeeTokens(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, nil
+	}
+	return tokens, nil
+}
+
+// FeeOptions returns the quotes of the relayer for relaying the transactions of signedTxs, one
+// per fee token. A fee is paid by appending its transfer to the transactions before they are
+// signed, see sequence.AppendFeeTransaction.
+func (r *RpcRelayer) FeeOptions(ctx context.Context, signedTxs *sequence.SignedTransactions) ([]*sequence.FeeOption, error) {
+	walletAddress, err := sequence.AddressFromWalletConfig(signedTxs.WalletConfig, signedTxs.WalletContext)
+	if err != nil {
+		return nil, err
+	}
+
+	requestData, err := signedTxs.Transactions.EncodeRaw()
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := r.protoConfig(ctx, &signedTxs.WalletConfig, walletAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := r.Service.GetMetaTxnNetworkFeeOptions(ctx, config, hexutil.Encode(requestData))
+	if err != nil {
+		return nil, err
+	}
+
+	var chainID uint64
+	if signedTxs.ChainID != nil {
+		chainID = signedTxs.ChainID.Uint64()
+	}
+	feeOptions := make([]*sequence.FeeOption, 0, len(options))
+	for _, option := range options {
+		feeOption, err := feeOptionOf(option, chainID)
+		if err != nil {
+			return nil, err
+		}
+		feeOptions = append(feeOptions, feeOption)
+	}
+	return feeOptions, nil
+}
+
+// feeOptionOf returns the fee option of a quote of the relayer, whose token is the gas token of
+// chainID unless it has a contract.
+func feeOptionOf(option *proto.FeeOption, chainID uint64) (*sequence.FeeOption, error) {
+	value, ok := new(big.Int).SetString(option.Value, 10)
+	if !ok {
+		return nil, fmt.Errorf("relayer: invalid fee value %q", option.Value)
+	}
+	if !common.IsHexAddress(option.To) {
+		return nil, fmt.Errorf("relayer: invalid fee recipient %q", option.To)
+	}
+	feeOption := &sequence.FeeOption{To: common.HexToAddress(option.To), Value: value, GasLimit: uint64(option.GasLimit)}
+
+	token := option.Token
+	if token == nil || token.ContractAddress == nil || *token.ContractAddress == "" {
+		feeOption.Token = sequence.GasToken(chainID)
+		if token != nil && token.Symbol != "" {
+			feeOption.Token.Symbol = token.Symbol
+		}
+		return feeOption, nil
+	}
+
+	if !common.IsHexAddress(*token.ContractAddress) {
+		return nil, fmt.Errorf("relayer: invalid fee token %q", *token.ContractAddress)
+	}
+	feeOption.Token = sequence.FeeToken{ChainID: chainID, Address: common.HexToAddress(*token.ContractAddress), Symbol: token.Symbol}
+	if token.ChainId != 0 {
+		feeOption.Token.ChainID = token.ChainId
+	}
+	if token.Decimals != nil {
+		feeOption.Token.Decimals = uint8(*token.Decimals)
+	}
+	if token.Type != nil && *token.Type == proto.FeeTokenType_ERC1155_TOKEN {
+		if token.TokenID == nil {
+			return nil, fmt.Errorf("relayer: fee token %v has no token id", *token.ContractAddress)
+		}
+		feeOption.TokenID, ok = new(big.Int).SetString(*token.TokenID, 10)
+		if !ok {
+			return nil, fmt.Errorf("relayer: invalid fee token id %q", *token.TokenID)
+		}
+	}
+	return feeOption, nil
+}
+
+// Simulate simulates the execution of txns by the wallet of walletConfig at the latest block,
+// and returns the result of each. The wallet does not need to be deployed.
+func (r *RpcRelayer) Simulate(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, txns sequence.Transactions) ([]sequence.SimulateResult, error) {
+	walletAddress, err := sequence.AddressFromWalletConfig(walletConfig, walletContext)
+	if err != nil {
+		return nil, err
+	}
+	return sequence.SimulateContext(ctx, r.provider, walletAddress, txns, "", nil)
+}
+
+// checkSimulation simulates txns, and fails with ErrSimulationFailed if any of them fails.
+func (r *RpcRelayer) checkSimulation(ctx context.Context, walletAddress common.Address, txns sequence.Transactions) error {
+	results, err := sequence.SimulateContext(ctx, r.provider, walletAddress, txns, "", nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSimulationFailed, err)
+	}
+	for i, result := range results {
+		if result.Executed && !result.Succeeded {
+			return fmt.Errorf("%w: transaction %d failed: %v", ErrSimulationFailed, i, hexutil.Encode(result.Result))
+		}
+	}
+	return nil
+}
+
 func (r *RpcRelayer) protoConfig(ctx context.Context, config *sequence.WalletConfig, walletAddress common.Address) (*proto.WalletConfig, error) {
 	var signers []*proto.WalletSigner
 	for _, signer := range config.Signers {
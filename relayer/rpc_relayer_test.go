@@ -0,0 +1,164 @@
+package relayer_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/relayer"
+	"github.com/0xsequence/go-sequence/relayer/proto"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+// hostedRelayer stubs the hosted relayer service, with the statuses each meta-transaction
+// goes through as it is polled.
+type hostedRelayer struct {
+	statuses map[string][]string
+	payloads []string
+	mu       sync.Mutex
+}
+
+func (h *hostedRelayer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var in struct {
+		MetaTxID string `json:"metaTxID"`
+		Payload  string `json:"payload"`
+	}
+	body, _ := io.ReadAll(r.Body)
+	json.Unmarshal(body, &in)
+
+	var out interface{}
+	switch strings.TrimPrefix(r.URL.Path, proto.RelayerPathPrefix) {
+	case "GetMetaTxnReceipt":
+		statuses := h.statuses[in.MetaTxID]
+		if len(statuses) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(proto.Errorf(proto.ErrNotFound, "unknown meta-transaction").Payload())
+			return
+		}
+		h.statuses[in.MetaTxID] = statuses[1:]
+		txnReceipt, _ := json.Marshal(&types.Receipt{Status: types.ReceiptStatusSuccessful, TxHash: common.HexToHash("0x1"), Logs: []*types.Log{}})
+		out = map[string]interface{}{"receipt": &proto.MetaTxnReceipt{ID: in.MetaTxID, Status: statuses[0], TxnReceipt: string(txnReceipt)}}
+	case "FeeTokens":
+		out = map[string]interface{}{"isFeeRequired": true, "tokens": []*proto.FeeToken{{Name: "Ether", Symbol: "ETH"}}}
+	case "GetMetaTxnNetworkFeeOptions":
+		h.payloads = append(h.payloads, in.Payload)
+		usdc, decimals := common.HexToAddress("0x70c3").Hex(), uint32(6)
+		out = map[string]interface{}{"options": []*proto.FeeOption{
+			{Token: &proto.FeeToken{Symbol: "ETH"}, To: common.HexToAddress("0xfee").Hex(), Value: "1000", GasLimit: 21000},
+			{Token: &proto.FeeToken{Symbol: "USDC", ContractAddress: &usdc, Decimals: &decimals}, To: common.HexToAddress("0xfee").Hex(), Value: "25", GasLimit: 60000},
+		}}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+func TestRpcRelayer(t *testing.T) {
+	ctx := context.Background()
+	chain := memchain.New(memchain.Options{})
+
+	hosted := &hostedRelayer{statuses: map[string][]string{
+		"0x01": {"QUEUED", "SENT", "SUCCEEDED"},
+		"0x02": {"SENT", "FAILED"},
+		"0x03": {"QUEUED", "DROPPED"},
+	}}
+	ts := httptest.NewServer(hosted)
+	defer ts.Close()
+
+	r, err := relayer.NewRpcRelayer(chain.Provider(), nil, ts.URL, http.DefaultClient)
+	assert.NoError(t, err)
+	r.SetPollInterval(10 * time.Millisecond)
+
+	// without a receipts listener, the relayer is polled until the meta-transaction is mined
+	status, receipt, err := r.Wait(ctx, "0x01", 5*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, sequence.MetaTxnExecuted, status)
+	assert.Equal(t, common.HexToHash("0x1"), receipt.TxHash)
+
+	status, _, err = r.Wait(ctx, "0x02", 5*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, sequence.MetaTxnFailed, status)
+
+	_, _, err = r.Wait(ctx, "0x03", 5*time.Second)
+	assert.ErrorContains(t, err, "dropped")
+
+	// unknown meta-transactions are polled for until the timeout
+	_, _, err = r.Wait(ctx, "0x04", 50*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// quotes
+	tokens, err := r.FeeTokens(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, tokens, 1)
+	assert.Equal(t, "ETH", tokens[0].Symbol)
+
+	wallet, err := testutil.MemChainWallet(chain, 1)
+	assert.NoError(t, err)
+	txns := sequence.Transactions{{To: common.HexToAddress("0x7a7")}}
+	options, err := r.FeeOptions(ctx, &sequence.SignedTransactions{
+		ChainID:       big.NewInt(1),
+		WalletConfig:  wallet.GetWalletConfig(),
+		WalletContext: wallet.GetWalletContext(),
+		Transactions:  txns,
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, options, 2) {
+		assert.True(t, options[0].Token.IsGasToken())
+		assert.Equal(t, "1000", options[0].Value.String())
+		assert.Equal(t, common.HexToAddress("0xfee"), options[0].To)
+		assert.Equal(t, common.HexToAddress("0x70c3"), options[1].Token.Address)
+		assert.Equal(t, uint8(6), options[1].Token.Decimals)
+		assert.Equal(t, "0.000025 USDC", options[1].String())
+		assert.Equal(t, uint64(60000), options[1].GasLimit)
+	}
+
+	encoded, err := txns.EncodeRaw()
+	assert.NoError(t, err)
+	assert.Len(t, hosted.payloads, 1)
+	assert.Equal(t, common.Bytes2Hex(encoded), strings.TrimPrefix(hosted.payloads[0], "0x"))
+}
+
+func TestRpcRelayerSimulateContext(t *testing.T) {
+	// a chain which doesn't answer simulations until the test is over
+	done := make(chan struct{})
+	defer close(done)
+	chain := memchain.New(memchain.Options{})
+	chain.Intercept(func(method string, params []json.RawMessage, next func() (interface{}, error)) (interface{}, error) {
+		if method == "eth_call" {
+			<-done
+		}
+		return next()
+	})
+	provider := chain.Provider()
+
+	r, err := relayer.NewRpcRelayer(provider, nil, "http://127.0.0.1:1", http.DefaultClient)
+	assert.NoError(t, err)
+
+	wallet, err := testutil.MemChainWallet(chain, 1)
+	assert.NoError(t, err)
+
+	// the simulation is given up on with its context
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = r.Simulate(ctx, wallet.GetWalletConfig(), wallet.GetWalletContext(), sequence.Transactions{{To: common.HexToAddress("0x7a7")}})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -0,0 +1,547 @@
+package relayer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+)
+
+var ErrNotScheduled = fmt.Errorf("relayer: metaTxnID is not scheduled")
+
+// DefaultSchedulePollInterval is how often a ScheduledRelayer checks for bundles due.
+const DefaultSchedulePollInterval = 5 * time.Second
+
+// NotBefore is the earliest a scheduled bundle is relayed: at or after Time, and at or after
+// block Block. Zero values don't constrain.
+type NotBefore struct {
+	Time  time.Time `json:"time,omitempty"`
+	Block uint64    `json:"block,omitempty"`
+}
+
+func (n NotBefore) IsZero() bool {
+	return n.Time.IsZero() && n.Block == 0
+}
+
+func (n NotBefore) reached(now time.Time, block uint64) bool {
+	return !now.Before(n.Time) && block >= n.Block
+}
+
+type notBeforeCtxKey struct{}
+
+// WithNotBefore returns a context relaying bundles with a ScheduledRelayer no earlier than
+// notBefore, ie.
+//
+//	metaTxnID, _, _, err := wallet.SendTransactions(relayer.WithNotBefore(ctx, relayer.NotBefore{Block: 20_000_000}), txns)
+func WithNotBefore(ctx context.Context, notBefore NotBefore) context.Context {
+	return context.WithValue(ctx, notBeforeCtxKey{}, notBefore)
+}
+
+// NotBeforeFromContext returns the NotBefore of ctx, see WithNotBefore.
+func NotBeforeFromContext(ctx context.Context) (NotBefore, bool) {
+	notBefore, ok := ctx.Value(notBeforeCtxKey{}).(NotBefore)
+	return notBefore, ok && !notBefore.IsZero()
+}
+
+// ScheduledBundle is a signed bundle held by a ScheduledRelayer until it's due.
+type ScheduledBundle struct {
+	MetaTxnID   sequence.MetaTxnID
+	Bundle      *sequence.SignedTransactions
+	NotBefore   NotBefore
+	ScheduledAt time.Time
+}
+
+type scheduledBundleJSON struct {
+	MetaTxnID   sequence.MetaTxnID `json:"metaTxnID"`
+	Bundle      []byte             `json:"bundle"`
+	NotBefore   NotBefore          `json:"notBefore"`
+	ScheduledAt time.Time          `json:"scheduledAt"`
+}
+
+// MarshalJSON encodes the bundle with sequence.EncodeSignedTransactions, so stored bundles
+// remain decodable across versions.
+func (b *ScheduledBundle) MarshalJSON() ([]byte, error) {
+	bundle, err := sequence.EncodeSignedTransactions(b.Bundle)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(scheduledBundleJSON{MetaTxnID: b.MetaTxnID, Bundle: bundle, NotBefore: b.NotBefore, ScheduledAt: b.ScheduledAt})
+}
+
+func (b *ScheduledBundle) UnmarshalJSON(data []byte) error {
+	var v scheduledBundleJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	bundle, err := sequence.DecodeSignedTransactions(v.Bundle)
+	if err != nil {
+		return err
+	}
+	*b = ScheduledBundle{MetaTxnID: v.MetaTxnID, Bundle: bundle, NotBefore: v.NotBefore, ScheduledAt: v.ScheduledAt}
+	return nil
+}
+
+// ScheduleStore persists the bundles held by a ScheduledRelayer, so they survive restarts.
+type ScheduleStore interface {
+	SaveScheduled(ctx context.Context, bundle *ScheduledBundle) error
+	DeleteScheduled(ctx context.Context, metaTxnID sequence.MetaTxnID) error
+
+	// ListScheduled returns the stored bundles, in any order.
+	ListScheduled(ctx context.Context) ([]*ScheduledBundle, error)
+}
+
+// MemoryScheduleStore is a ScheduleStore in memory, whose bundles don't survive restarts.
+type MemoryScheduleStore struct {
+	bundles map[sequence.MetaTxnID]*ScheduledBundle
+	mu      sync.Mutex
+}
+
+var _ ScheduleStore = &MemoryScheduleStore{}
+
+func NewMemoryScheduleStore() *MemoryScheduleStore {
+	return &MemoryScheduleStore{bundles: map[sequence.MetaTxnID]*ScheduledBundle{}}
+}
+
+func (s *MemoryScheduleStore) SaveScheduled(ctx context.Context, bundle *ScheduledBundle) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bundles[bundle.MetaTxnID] = bundle
+	return nil
+}
+
+func (s *MemoryScheduleStore) DeleteScheduled(ctx context.Context, metaTxnID sequence.MetaTxnID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.bundles, metaTxnID)
+	return nil
+}
+
+func (s *MemoryScheduleStore) ListScheduled(ctx context.Context) ([]*ScheduledBundle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bundles := make([]*ScheduledBundle, 0, len(s.bundles))
+	for _, bundle := range s.bundles {
+		bundles = append(bundles, bundle)
+	}
+	return bundles, nil
+}
+
+// DirScheduleStore is a ScheduleStore of one JSON file per bundle in a directory.
+type DirScheduleStore struct {
+	dir string
+}
+
+var _ ScheduleStore = &DirScheduleStore{}
+
+// NewDirScheduleStore returns a DirScheduleStore in dir, which is created if needed.
+func NewDirScheduleStore(dir string) (*DirScheduleStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("relayer: failed to create schedule directory: %w", err)
+	}
+	return &DirScheduleStore{dir: dir}, nil
+}
+
+func (s *DirScheduleStore) SaveScheduled(ctx context.Context, bundle *ScheduledBundle) error {
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		return err
+	}
+
+	// written to a temporary file first, so a crash never leaves a partial bundle
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("relayer: failed to save scheduled bundle: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("relayer: failed to save scheduled bundle: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("relayer: failed to save scheduled bundle: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("relayer: failed to save scheduled bundle: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(bundle.MetaTxnID)); err != nil {
+		return fmt.Errorf("relayer: failed to save scheduled bundle: %w", err)
+	}
+	return nil
+}
+
+func (s *DirScheduleStore) DeleteScheduled(ctx context.Context, metaTxnID sequence.MetaTxnID) error {
+	if err := os.Remove(s.path(metaTxnID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("relayer: failed to delete scheduled bundle: %w", err)
+	}
+	return nil
+}
+
+func (s *DirScheduleStore) ListScheduled(ctx context.Context) ([]*ScheduledBundle, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("relayer: failed to list scheduled bundles: %w", err)
+	}
+	var bundles []*ScheduledBundle
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("relayer: failed to read scheduled bundle: %w", err)
+		}
+		bundle := &ScheduledBundle{}
+		if err := json.Unmarshal(data, bundle); err != nil {
+			return nil, fmt.Errorf("relayer: invalid scheduled bundle %v: %w", entry.Name(), err)
+		}
+		bundles = append(bundles, bundle)
+	}
+	return bundles, nil
+}
+
+func (s *DirScheduleStore) path(metaTxnID sequence.MetaTxnID) string {
+	return filepath.Join(s.dir, string(metaTxnID)+".json")
+}
+
+// ScheduledRelayer relays bundles through another relayer at or after a target time or block,
+// see WithNotBefore. Bundles relayed without a NotBefore are relayed at once.
+//
+// Scheduled bundles are held in its ScheduleStore, and relayed by Run once due, in the order
+// they are due. Relay returns the metaTxnID of a scheduled bundle without a native
+// transaction, and Wait waits for it to be relayed and executed.
+//
+// Stop lets the bundle being relayed complete, and Drain waits for it, the bundles not due yet
+// remain in the ScheduleStore.
+type ScheduledRelayer struct {
+	sequence.Relayer
+	store        ScheduleStore
+	options      sequence.Options
+	pollInterval time.Duration
+
+	scheduled map[sequence.MetaTxnID]*scheduledBundle
+
+	// relaying is the metaTxnID of the bundle being relayed by Run, if any
+	relaying   sequence.MetaTxnID
+	relayingWg sync.WaitGroup
+
+	stop    chan struct{}
+	running int32
+	mu      sync.Mutex
+}
+
+type scheduledBundle struct {
+	*ScheduledBundle
+	relayed chan struct{}
+	err     error
+}
+
+var (
+	_ sequence.Relayer  = &ScheduledRelayer{}
+	_ sequence.Runnable = &ScheduledRelayer{}
+	_ sequence.Drainer  = &ScheduledRelayer{}
+)
+
+// NewScheduledRelayer returns a ScheduledRelayer through relayer, holding the scheduled
+// bundles in store, or in memory if nil.
+func NewScheduledRelayer(relayer sequence.Relayer, store ScheduleStore, opts ...sequence.Option) *ScheduledRelayer {
+	if store == nil {
+		store = NewMemoryScheduleStore()
+	}
+	return &ScheduledRelayer{
+		Relayer:      relayer,
+		store:        store,
+		options:      sequence.NewOptions(opts...),
+		pollInterval: DefaultSchedulePollInterval,
+		scheduled:    map[sequence.MetaTxnID]*scheduledBundle{},
+	}
+}
+
+func (r *ScheduledRelayer) SetPollInterval(pollInterval time.Duration) *ScheduledRelayer {
+	r.pollInterval = pollInterval
+	return r
+}
+
+// Relay relays signedTxs, or schedules it if ctx has a NotBefore, see WithNotBefore.
+func (r *ScheduledRelayer) Relay(ctx context.Context, signedTxs *sequence.SignedTransactions) (sequence.MetaTxnID, *types.Transaction, ethtxn.WaitReceipt, error) {
+	notBefore, ok := NotBeforeFromContext(ctx)
+	if !ok {
+		return r.Relayer.Relay(ctx, signedTxs)
+	}
+
+	if err := signedTxs.Verify(); err != nil {
+		return "", nil, nil, err
+	}
+	walletAddress, err := sequence.AddressFromWalletConfig(signedTxs.WalletConfig, signedTxs.WalletContext)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	metaTxnID, _, err := sequence.ComputeMetaTxnID(signedTxs.ChainID, walletAddress, signedTxs.Transactions, signedTxs.Nonce, sequence.MetaTxnWalletExec)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	bundle := &ScheduledBundle{MetaTxnID: metaTxnID, Bundle: signedTxs.Clone(), NotBefore: notBefore, ScheduledAt: time.Now()}
+	if err := r.store.SaveScheduled(ctx, bundle); err != nil {
+		return "", nil, nil, err
+	}
+	r.hold(bundle)
+	return metaTxnID, nil, nil, nil
+}
+
+// Wait waits for the bundle of metaTxnID to be relayed if it's scheduled, and then for it to
+// be executed. optTimeout only bounds the latter.
+func (r *ScheduledRelayer) Wait(ctx context.Context, metaTxnID sequence.MetaTxnID, optTimeout ...time.Duration) (sequence.MetaTxnStatus, *types.Receipt, error) {
+	r.mu.Lock()
+	bundle, ok := r.scheduled[metaTxnID]
+	r.mu.Unlock()
+	if ok {
+		select {
+		case <-ctx.Done():
+			return 0, nil, ctx.Err()
+		case <-bundle.relayed:
+		}
+		if bundle.err != nil {
+			return sequence.MetaTxnFailed, nil, bundle.err
+		}
+	}
+	return r.Relayer.Wait(ctx, metaTxnID, optTimeout...)
+}
+
+// Scheduled returns the bundles waiting to be relayed, in the order they are due.
+func (r *ScheduledRelayer) Scheduled() []*ScheduledBundle {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	bundles := make([]*ScheduledBundle, 0, len(r.scheduled))
+	for _, bundle := range r.scheduled {
+		if !isClosed(bundle.relayed) {
+			bundles = append(bundles, bundle.ScheduledBundle)
+		}
+	}
+	sortScheduled(bundles)
+	return bundles
+}
+
+// Cancel drops the scheduled bundle of metaTxnID, which fails with ErrNotScheduled if it's not
+// scheduled anymore. Its signature remains valid, so the bundle can still be executed if it
+// was shared.
+func (r *ScheduledRelayer) Cancel(ctx context.Context, metaTxnID sequence.MetaTxnID) error {
+	r.mu.Lock()
+	bundle, ok := r.scheduled[metaTxnID]
+	if !ok || isClosed(bundle.relayed) {
+		r.mu.Unlock()
+		return fmt.Errorf("%w: %v", ErrNotScheduled, metaTxnID)
+	}
+	delete(r.scheduled, metaTxnID)
+	r.mu.Unlock()
+
+	bundle.err = fmt.Errorf("relayer: scheduled metaTxnID %v was cancelled", metaTxnID)
+	close(bundle.relayed)
+	return r.store.DeleteScheduled(ctx, metaTxnID)
+}
+
+// Load loads the bundles of the store, ie. scheduled before a restart. It's called by Run, and
+// before it to Wait for the bundles before Run starts.
+func (r *ScheduledRelayer) Load(ctx context.Context) error {
+	bundles, err := r.store.ListScheduled(ctx)
+	if err != nil {
+		return err
+	}
+	for _, bundle := range bundles {
+		r.hold(bundle)
+	}
+	return nil
+}
+
+// Run loads the bundles of the store and relays the bundles due until ctx is done or the
+// relayer is stopped. A bundle which fails to relay is dropped, and its error returned by Wait.
+func (r *ScheduledRelayer) Run(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&r.running, 0, 1) {
+		return fmt.Errorf("relayer: scheduled relayer already running")
+	}
+	defer atomic.StoreInt32(&r.running, 0)
+
+	stop := make(chan struct{})
+	r.mu.Lock()
+	r.stop = stop
+	r.mu.Unlock()
+
+	if err := r.Load(ctx); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+	for {
+		if err := r.relayDue(ctx, stop); err != nil && ctx.Err() == nil {
+			r.options.Logger.Warnf("relayer: failed to relay scheduled bundles: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop signals Run to return once the bundle being relayed, if any, is relayed.
+func (r *ScheduledRelayer) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stop != nil && !isClosed(r.stop) {
+		close(r.stop)
+	}
+}
+
+func (r *ScheduledRelayer) IsRunning() bool {
+	return atomic.LoadInt32(&r.running) == 1
+}
+
+// Drain waits for the bundle being relayed, if any, after the relayer is stopped. If the ctx is
+// done first, its metaTxnID is reported as pending.
+func (r *ScheduledRelayer) Drain(ctx context.Context) (*sequence.DrainReport, error) {
+	done := make(chan struct{})
+	go func() {
+		r.relayingWg.Wait()
+		close(done)
+	}()
+
+	report := &sequence.DrainReport{Component: "ScheduledRelayer"}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		r.mu.Lock()
+		if r.relaying != "" {
+			report.Pending = append(report.Pending, string(r.relaying))
+		}
+		r.mu.Unlock()
+	}
+
+	return report, nil
+}
+
+// relayDue relays the bundles due, until stop is closed.
+func (r *ScheduledRelayer) relayDue(ctx context.Context, stop chan struct{}) error {
+	bundles := r.Scheduled()
+	if len(bundles) == 0 {
+		return nil
+	}
+
+	var block uint64
+	for _, bundle := range bundles {
+		if bundle.NotBefore.Block == 0 {
+			continue
+		}
+		var err error
+		block, err = r.GetProvider().BlockNumber(ctx)
+		if err != nil {
+			return fmt.Errorf("relayer: failed to read block number: %w", err)
+		}
+		break
+	}
+
+	now := time.Now()
+	for _, bundle := range bundles {
+		if !bundle.NotBefore.reached(now, block) {
+			continue
+		}
+
+		// Drain only waits for the bundles started before the relayer is stopped
+		r.mu.Lock()
+		if isClosed(stop) {
+			r.mu.Unlock()
+			return nil
+		}
+		r.relaying = bundle.MetaTxnID
+		r.relayingWg.Add(1)
+		r.mu.Unlock()
+
+		err := r.relayScheduled(ctx, bundle)
+
+		r.mu.Lock()
+		r.relaying = ""
+		r.mu.Unlock()
+		r.relayingWg.Done()
+
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ScheduledRelayer) relayScheduled(ctx context.Context, bundle *ScheduledBundle) error {
+	_, _, _, err := r.Relayer.Relay(ctx, bundle.Bundle)
+	if err != nil && ctx.Err() != nil {
+		return err
+	} else if err != nil {
+		r.options.Logger.Errorf("relayer: failed to relay scheduled metaTxnID %s: %v", bundle.MetaTxnID, err)
+	} else {
+		r.options.Logger.Debugf("relayer: relayed scheduled metaTxnID %s", bundle.MetaTxnID)
+	}
+	if err := r.store.DeleteScheduled(ctx, bundle.MetaTxnID); err != nil {
+		return err
+	}
+	r.release(bundle.MetaTxnID, err)
+	return nil
+}
+
+func (r *ScheduledRelayer) hold(bundle *ScheduledBundle) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.scheduled[bundle.MetaTxnID]; ok {
+		return
+	}
+	r.scheduled[bundle.MetaTxnID] = &scheduledBundle{ScheduledBundle: bundle, relayed: make(chan struct{})}
+}
+
+// release marks the bundle of metaTxnID as relayed, with err if it failed. Relayed bundles are
+// kept so Wait can report them, until a newer release.
+func (r *ScheduledRelayer) release(metaTxnID sequence.MetaTxnID, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, bundle := range r.scheduled {
+		if isClosed(bundle.relayed) {
+			delete(r.scheduled, id)
+		}
+	}
+	if bundle, ok := r.scheduled[metaTxnID]; ok {
+		bundle.err = err
+		close(bundle.relayed)
+	}
+}
+
+func sortScheduled(bundles []*ScheduledBundle) {
+	sort.SliceStable(bundles, func(i, j int) bool {
+		a, b := bundles[i].NotBefore, bundles[j].NotBefore
+		if !a.Time.Equal(b.Time) {
+			return a.Time.Before(b.Time)
+		}
+		if a.Block != b.Block {
+			return a.Block < b.Block
+		}
+		return bundles[i].ScheduledAt.Before(bundles[j].ScheduledAt)
+	})
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,190 @@
+package relayer_test
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/relayer"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingRelayer struct {
+	provider *ethrpc.Provider
+	relayed  []*sequence.SignedTransactions
+	mu       sync.Mutex
+}
+
+func (r *recordingRelayer) GetProvider() *ethrpc.Provider { return r.provider }
+
+func (r *recordingRelayer) EstimateGasLimits(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, txns sequence.Transactions) (sequence.Transactions, error) {
+	return txns, nil
+}
+
+func (r *recordingRelayer) GetNonce(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, space *big.Int, blockNum *big.Int) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (r *recordingRelayer) Relay(ctx context.Context, signedTxs *sequence.SignedTransactions) (sequence.MetaTxnID, *types.Transaction, ethtxn.WaitReceipt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.relayed = append(r.relayed, signedTxs)
+	return "", nil, nil, nil
+}
+
+func (r *recordingRelayer) Wait(ctx context.Context, metaTxnID sequence.MetaTxnID, optTimeout ...time.Duration) (sequence.MetaTxnStatus, *types.Receipt, error) {
+	return sequence.MetaTxnExecuted, nil, nil
+}
+
+func (r *recordingRelayer) FeeOptions(ctx context.Context, signedTxs *sequence.SignedTransactions) ([]*sequence.FeeOption, error) {
+	return nil, nil
+}
+
+func (r *recordingRelayer) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.relayed)
+}
+
+// blockingRelayer holds each bundle it relays until it's released.
+type blockingRelayer struct {
+	recordingRelayer
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r *blockingRelayer) Relay(ctx context.Context, signedTxs *sequence.SignedTransactions) (sequence.MetaTxnID, *types.Transaction, ethtxn.WaitReceipt, error) {
+	r.started <- struct{}{}
+	<-r.release
+	return r.recordingRelayer.Relay(ctx, signedTxs)
+}
+
+func TestScheduledRelayer(t *testing.T) {
+	ctx := context.Background()
+
+	// a chain at block 5
+	chain := memchain.New(memchain.Options{ChainID: big.NewInt(1)})
+	for chain.Head() < 5 {
+		chain.Mine()
+	}
+	provider := chain.Provider()
+
+	inner := &recordingRelayer{provider: provider}
+	key, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(key)
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.Connect(provider, inner))
+
+	sign := func(nonce int64) *sequence.SignedTransactions {
+		signed, err := wallet.SignTransactionsWithNonce(ctx, sequence.Transactions{{To: common.HexToAddress("0x01"), Value: big.NewInt(1), GasLimit: big.NewInt(50000)}}, big.NewInt(nonce))
+		assert.NoError(t, err)
+		return signed
+	}
+
+	dir := t.TempDir()
+	store, err := relayer.NewDirScheduleStore(dir)
+	assert.NoError(t, err)
+
+	// bundles without a NotBefore are relayed at once
+	scheduled := relayer.NewScheduledRelayer(inner, store)
+	_, _, _, err = scheduled.Relay(ctx, sign(0))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, inner.count())
+
+	atBlock, _, _, err := scheduled.Relay(relayer.WithNotBefore(ctx, relayer.NotBefore{Block: 10}), sign(1))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, atBlock)
+	atTime, _, _, err := scheduled.Relay(relayer.WithNotBefore(ctx, relayer.NotBefore{Time: time.Now().Add(-time.Second)}), sign(2))
+	assert.NoError(t, err)
+	assert.Len(t, scheduled.Scheduled(), 2)
+	assert.Equal(t, 1, inner.count())
+
+	// the scheduled bundles survive a restart
+	store, err = relayer.NewDirScheduleStore(dir)
+	assert.NoError(t, err)
+	scheduled = relayer.NewScheduledRelayer(inner, store).SetPollInterval(time.Millisecond)
+	assert.NoError(t, scheduled.Load(ctx))
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go scheduled.Run(runCtx)
+
+	status, _, err := scheduled.Wait(ctx, atTime)
+	assert.NoError(t, err)
+	assert.Equal(t, sequence.MetaTxnExecuted, status)
+	assert.Equal(t, 2, inner.count())
+	assert.Equal(t, big.NewInt(2), inner.relayed[1].Nonce)
+
+	// the bundle due at block 10 is held until then
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 2, inner.count())
+	assert.Len(t, scheduled.Scheduled(), 1)
+
+	for chain.Head() < 10 {
+		chain.Mine()
+	}
+	status, _, err = scheduled.Wait(ctx, atBlock)
+	assert.NoError(t, err)
+	assert.Equal(t, sequence.MetaTxnExecuted, status)
+	assert.Equal(t, 3, inner.count())
+
+	bundles, err := store.ListScheduled(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, bundles)
+
+	// cancelled bundles are dropped
+	cancelled, _, _, err := scheduled.Relay(relayer.WithNotBefore(ctx, relayer.NotBefore{Block: 100}), sign(3))
+	assert.NoError(t, err)
+	assert.NoError(t, scheduled.Cancel(ctx, cancelled))
+	assert.Empty(t, scheduled.Scheduled())
+	assert.ErrorIs(t, scheduled.Cancel(ctx, cancelled), relayer.ErrNotScheduled)
+}
+
+func TestScheduledRelayerDrain(t *testing.T) {
+	ctx := context.Background()
+	chain := memchain.New(memchain.Options{})
+
+	inner := &blockingRelayer{recordingRelayer: recordingRelayer{provider: chain.Provider()}, started: make(chan struct{}), release: make(chan struct{})}
+	wallet, err := testutil.MemChainWallet(chain, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.SetRelayer(inner))
+	signed, err := wallet.SignTransactionsWithNonce(ctx, sequence.Transactions{{To: common.HexToAddress("0x01"), Value: big.NewInt(1), GasLimit: big.NewInt(50000)}}, big.NewInt(0))
+	assert.NoError(t, err)
+
+	scheduled := relayer.NewScheduledRelayer(inner, nil).SetPollInterval(time.Millisecond)
+	metaTxnID, _, _, err := scheduled.Relay(relayer.WithNotBefore(ctx, relayer.NotBefore{Time: time.Now()}), signed)
+	assert.NoError(t, err)
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- scheduled.Run(ctx)
+	}()
+	<-inner.started
+
+	// the bundle being relayed when the relayer is stopped is pending until it's relayed
+	drainCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	reports, err := sequence.Shutdown(drainCtx, scheduled)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{string(metaTxnID)}, reports[0].Pending)
+
+	close(inner.release)
+	assert.NoError(t, <-runErr)
+	assert.False(t, scheduled.IsRunning())
+
+	report, err := scheduled.Drain(ctx)
+	assert.NoError(t, err)
+	assert.True(t, report.IsDrained())
+	assert.Equal(t, 1, inner.count())
+	assert.Empty(t, scheduled.Scheduled())
+}
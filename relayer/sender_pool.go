@@ -0,0 +1,211 @@
+package relayer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+)
+
+// TopUpFunc funds a sender whose balance dropped below the minimum balance of a SenderPool.
+type TopUpFunc func(ctx context.Context, sender common.Address, balance *big.Int) error
+
+// SenderPool is a pool of sender EOAs which a LocalRelayer rotates through, so bundles are
+// sent concurrently instead of queueing on the nonce of a single sender. Each sender sends
+// one transaction at a time, and its nonce is managed locally between sends.
+type SenderPool struct {
+	senders []*poolSender
+	idle    chan *poolSender
+	options sequence.Options
+
+	minBalance   *big.Int
+	onLowBalance func(ctx context.Context, sender common.Address, balance *big.Int)
+	topUp        TopUpFunc
+	mu           sync.RWMutex
+}
+
+type poolSender struct {
+	wallet *ethwallet.Wallet
+
+	// nonce is the next nonce of the sender, or nil if it must be fetched from the node.
+	// It is only accessed while the sender is acquired.
+	nonce *uint64
+
+	checkingBalance int32
+}
+
+func NewSenderPool(senders []*ethwallet.Wallet, opts ...sequence.Option) (*SenderPool, error) {
+	if len(senders) == 0 {
+		return nil, fmt.Errorf("relayer: sender pool requires at least one sender")
+	}
+
+	pool := &SenderPool{
+		senders: make([]*poolSender, 0, len(senders)),
+		idle:    make(chan *poolSender, len(senders)),
+		options: sequence.NewOptions(opts...),
+	}
+	seen := map[common.Address]struct{}{}
+	for _, sender := range senders {
+		if sender.GetProvider() == nil {
+			return nil, sequence.ErrProviderNotSet
+		}
+		if _, ok := seen[sender.Address()]; ok {
+			return nil, fmt.Errorf("relayer: sender %v is in the pool twice", sender.Address())
+		}
+		seen[sender.Address()] = struct{}{}
+
+		s := &poolSender{wallet: sender}
+		pool.senders = append(pool.senders, s)
+		pool.idle <- s
+	}
+	return pool, nil
+}
+
+// SetLowBalance sets the balance below which a sender is low on funds. After each send, a
+// sender below minBalance is reported to fn, if not nil, and topped up by the TopUpFunc.
+func (p *SenderPool) SetLowBalance(minBalance *big.Int, fn func(ctx context.Context, sender common.Address, balance *big.Int)) *SenderPool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.minBalance = minBalance
+	p.onLowBalance = fn
+	return p
+}
+
+// SetTopUp sets the hook funding senders low on funds, ie. TreasuryTopUp.
+func (p *SenderPool) SetTopUp(topUp TopUpFunc) *SenderPool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.topUp = topUp
+	return p
+}
+
+// Senders returns the addresses of the senders of the pool.
+func (p *SenderPool) Senders() []common.Address {
+	addresses := make([]common.Address, len(p.senders))
+	for i, s := range p.senders {
+		addresses[i] = s.wallet.Address()
+	}
+	return addresses
+}
+
+// acquire returns the sender which has been idle for the longest time, blocking until
+// one is idle or ctx is done.
+func (p *SenderPool) acquire(ctx context.Context) (*poolSender, error) {
+	select {
+	case s := <-p.idle:
+		return s, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("relayer: no idle sender: %w", ctx.Err())
+	}
+}
+
+// release returns an acquired sender to the pool. If its transaction was sent, its nonce
+// is advanced and its balance is checked, otherwise its nonce is fetched again on its
+// next send, in case the failure left it out of sync.
+func (p *SenderPool) release(s *poolSender, sent bool) {
+	if sent && s.nonce != nil {
+		*s.nonce++
+	} else {
+		s.nonce = nil
+	}
+	p.idle <- s
+
+	if sent {
+		go p.checkBalance(s)
+	}
+}
+
+func (p *SenderPool) nextNonce(ctx context.Context, s *poolSender) (*big.Int, error) {
+	if s.nonce == nil {
+		nonce, err := s.wallet.GetProvider().PendingNonceAt(ctx, s.wallet.Address())
+		if err != nil {
+			return nil, err
+		}
+		s.nonce = &nonce
+	}
+	return new(big.Int).SetUint64(*s.nonce), nil
+}
+
+func (p *SenderPool) checkBalance(s *poolSender) {
+	p.mu.RLock()
+	minBalance, onLowBalance, topUp := p.minBalance, p.onLowBalance, p.topUp
+	p.mu.RUnlock()
+
+	if minBalance == nil {
+		return
+	}
+	// one check at a time per sender, so a low sender is not topped up repeatedly
+	if !atomic.CompareAndSwapInt32(&s.checkingBalance, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&s.checkingBalance, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), pendingTransactionTimeout)
+	defer cancel()
+
+	balance, err := s.wallet.GetBalance(ctx)
+	if err != nil {
+		p.options.Logger.Warnf("relayer: failed to get balance of sender %v: %v", s.wallet.Address(), err)
+		return
+	}
+	if balance.Cmp(minBalance) >= 0 {
+		return
+	}
+
+	p.options.Metrics.IncCounter("relayer.sender.low_balance")
+	p.options.Logger.Warnf("relayer: sender %v balance %v is below %v", s.wallet.Address(), balance, minBalance)
+	if onLowBalance != nil {
+		onLowBalance(ctx, s.wallet.Address(), balance)
+	}
+
+	if topUp != nil {
+		if err := topUp(ctx, s.wallet.Address(), balance); err != nil {
+			p.options.Metrics.IncCounter("relayer.sender.top_up.error")
+			p.options.Logger.Warnf("relayer: failed to top up sender %v: %v", s.wallet.Address(), err)
+			return
+		}
+		p.options.Metrics.IncCounter("relayer.sender.top_up")
+	}
+}
+
+// TreasuryTopUp returns a TopUpFunc sending amount from the treasury wallet to the sender,
+// and waiting for the transfer to be mined.
+func TreasuryTopUp(treasury *ethwallet.Wallet, amount *big.Int) TopUpFunc {
+	var mu sync.Mutex
+
+	return func(ctx context.Context, sender common.Address, balance *big.Int) error {
+		// the treasury sends one transfer at a time, to not reuse its nonce
+		mu.Lock()
+		defer mu.Unlock()
+
+		chainID, err := treasury.GetProvider().ChainID(ctx)
+		if err != nil {
+			return err
+		}
+
+		ntx, err := treasury.NewTransaction(ctx, &ethtxn.TransactionRequest{
+			To: &sender, ETHValue: amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		signedTx, err := treasury.SignTx(ntx, chainID)
+		if err != nil {
+			return err
+		}
+
+		_, waitReceipt, err := treasury.SendTransaction(ctx, signedTx)
+		if err != nil {
+			return err
+		}
+		_, err = waitReceipt(ctx)
+		return err
+	}
+}
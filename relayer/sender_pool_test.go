@@ -0,0 +1,60 @@
+package relayer_test
+
+import (
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/relayer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSenderPool(t *testing.T) {
+	provider, err := ethrpc.NewProvider("http://127.0.0.1:1")
+	assert.NoError(t, err)
+
+	var senders []*ethwallet.Wallet
+	var addresses []common.Address
+	for i := 0; i < 3; i++ {
+		sender, err := ethwallet.NewWalletFromRandomEntropy()
+		assert.NoError(t, err)
+		senders = append(senders, sender)
+		addresses = append(addresses, sender.Address())
+	}
+
+	_, err = relayer.NewSenderPool(nil)
+	assert.Error(t, err)
+
+	_, err = relayer.NewSenderPool(senders)
+	assert.ErrorIs(t, err, sequence.ErrProviderNotSet)
+
+	for _, sender := range senders {
+		sender.SetProvider(provider)
+	}
+	_, err = relayer.NewSenderPool(append(senders, senders[0]))
+	assert.ErrorContains(t, err, "in the pool twice")
+
+	pool, err := relayer.NewSenderPool(senders)
+	assert.NoError(t, err)
+	assert.Equal(t, addresses, pool.Senders())
+
+	// tenants without their own sender share the pool
+	own, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+
+	relayers, err := relayer.NewMultiTenantRelayer(senders[0], nil)
+	assert.NoError(t, err)
+	relayers.SetSenderPool(pool)
+	assert.NoError(t, relayers.AddTenant(relayer.Tenant{ID: "shared", APIKey: "key-1"}))
+	assert.NoError(t, relayers.AddTenant(relayer.Tenant{ID: "own", APIKey: "key-2", Sender: own}))
+
+	shared, err := relayers.Relayer("shared")
+	assert.NoError(t, err)
+	assert.Equal(t, pool, shared.SenderPool())
+
+	ownRelayer, err := relayers.Relayer("own")
+	assert.NoError(t, err)
+	assert.Nil(t, ownRelayer.SenderPool())
+}
@@ -0,0 +1,247 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/relayer"
+	"github.com/0xsequence/go-sequence/relayer/proto"
+)
+
+// AdminPathPrefix is the path prefix of the admin methods, which are called with a JSON
+// body like the methods of the Relayer service.
+const AdminPathPrefix = "/rpc/RelayerAdmin/"
+
+// AdminServer is an http.Handler serving the operator methods of a MultiTenantRelayer:
+// pausing relaying, inspecting, evicting and bumping pending transactions, and rotating
+// sender EOAs. It is authenticated with a bearer token which is separate from the api keys
+// of the tenants, and is meant to be served on a private address only.
+//
+// Methods taking a `tenant` apply to all tenants when it is empty.
+type AdminServer struct {
+	relayers *relayer.MultiTenantRelayer
+	token    []byte
+	options  sequence.Options
+}
+
+var _ http.Handler = &AdminServer{}
+
+func NewAdminServer(relayers *relayer.MultiTenantRelayer, token string, opts ...sequence.Option) *AdminServer {
+	return &AdminServer{
+		relayers: relayers,
+		token:    []byte(token),
+		options:  sequence.NewOptions(opts...),
+	}
+}
+
+type adminRequest struct {
+	Tenant     string `json:"tenant"`
+	MetaTxnID  string `json:"metaTxnID"`
+	GasPrice   string `json:"gasPrice"`
+	PrivateKey string `json:"privateKey"`
+}
+
+type pendingTransaction struct {
+	Tenant    string    `json:"tenant"`
+	MetaTxnID string    `json:"metaTxnID"`
+	Wallet    string    `json:"wallet"`
+	Sender    string    `json:"sender"`
+	TxnHash   string    `json:"txnHash"`
+	Nonce     uint64    `json:"nonce"`
+	GasPrice  string    `json:"gasPrice"`
+	Private   bool      `json:"private"`
+	SentAt    time.Time `json:"sentAt"`
+	Bumps     int       `json:"bumps"`
+}
+
+func (s *AdminServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.URL.Path, AdminPathPrefix) {
+		writeError(w, proto.Errorf(proto.ErrBadRoute, "no handler for path %q", r.URL.Path))
+		return
+	}
+	method := strings.TrimPrefix(r.URL.Path, AdminPathPrefix)
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if len(s.token) == 0 || subtle.ConstantTimeCompare([]byte(token), s.token) != 1 {
+		s.options.Metrics.IncCounter("server.admin.unauthenticated")
+		writeError(w, proto.Errorf(proto.ErrUnauthenticated, "invalid or missing admin token"))
+		return
+	}
+
+	var in adminRequest
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBodySize))
+	if err == nil && len(body) > 0 {
+		err = json.Unmarshal(body, &in)
+	}
+	if err != nil {
+		writeError(w, proto.WrapError(proto.ErrInvalidArgument, err, "invalid request body"))
+		return
+	}
+
+	relayers, err := s.tenantRelayers(in.Tenant)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var out interface{}
+	switch method {
+	case "Pause":
+		for _, rel := range relayers {
+			rel.Pause()
+		}
+		out, err = s.status(relayers)
+	case "Resume":
+		for _, rel := range relayers {
+			rel.Resume()
+		}
+		out, err = s.status(relayers)
+	case "Status":
+		out, err = s.status(relayers)
+	case "ListPending":
+		out, err = s.listPending(relayers)
+	case "Evict":
+		out, err = s.evict(relayers, in)
+	case "Bump":
+		out, err = s.bump(r, relayers, in)
+	case "RotateSender":
+		out, err = s.rotateSender(in)
+	default:
+		err = proto.Errorf(proto.ErrUnimplemented, "method %s is not supported", method)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.options.Logger.Infof("relayer: admin %s tenant=%q", method, in.Tenant)
+	writeJSON(w, out)
+}
+
+// tenantRelayers returns the relayer of tenantID, or of all tenants if tenantID is empty.
+func (s *AdminServer) tenantRelayers(tenantID string) (map[string]*relayer.LocalRelayer, error) {
+	tenants := []string{tenantID}
+	if tenantID == "" {
+		tenants = s.relayers.Tenants()
+	}
+
+	relayers := make(map[string]*relayer.LocalRelayer, len(tenants))
+	for _, id := range tenants {
+		rel, err := s.relayers.Relayer(id)
+		if err != nil {
+			return nil, proto.Errorf(proto.ErrNotFound, "tenant %q is not registered", id)
+		}
+		relayers[id] = rel
+	}
+	return relayers, nil
+}
+
+func (s *AdminServer) status(relayers map[string]*relayer.LocalRelayer) (interface{}, error) {
+	type tenantStatus struct {
+		Paused  bool   `json:"paused"`
+		Sender  string `json:"sender"`
+		Pending int    `json:"pending"`
+	}
+	status := map[string]tenantStatus{}
+	for id, rel := range relayers {
+		status[id] = tenantStatus{
+			Paused:  rel.IsPaused(),
+			Sender:  rel.GetSender().Address().Hex(),
+			Pending: len(rel.Pending()),
+		}
+	}
+	return map[string]interface{}{"tenants": status}, nil
+}
+
+func (s *AdminServer) listPending(relayers map[string]*relayer.LocalRelayer) (interface{}, error) {
+	pending := []pendingTransaction{}
+	for id, rel := range relayers {
+		for _, p := range rel.Pending() {
+			pending = append(pending, pendingTransaction{
+				Tenant:    id,
+				MetaTxnID: string(p.MetaTxnID),
+				Wallet:    p.Wallet.Hex(),
+				Sender:    p.Sender.Hex(),
+				TxnHash:   p.Transaction.Hash().Hex(),
+				Nonce:     p.Transaction.Nonce(),
+				GasPrice:  p.Transaction.GasPrice().String(),
+				Private:   p.Private,
+				SentAt:    p.SentAt,
+				Bumps:     p.Bumps,
+			})
+		}
+	}
+	return map[string]interface{}{"pending": pending}, nil
+}
+
+func (s *AdminServer) evict(relayers map[string]*relayer.LocalRelayer, in adminRequest) (interface{}, error) {
+	if in.MetaTxnID == "" {
+		return nil, proto.ErrorRequiredArgument("metaTxnID")
+	}
+	for _, rel := range relayers {
+		if err := rel.Evict(sequence.MetaTxnID(in.MetaTxnID)); err == nil {
+			return map[string]interface{}{"status": true}, nil
+		}
+	}
+	return nil, proto.Errorf(proto.ErrNotFound, "%s is not pending", in.MetaTxnID)
+}
+
+func (s *AdminServer) bump(r *http.Request, relayers map[string]*relayer.LocalRelayer, in adminRequest) (interface{}, error) {
+	if in.MetaTxnID == "" {
+		return nil, proto.ErrorRequiredArgument("metaTxnID")
+	}
+
+	var gasPrice *big.Int
+	if in.GasPrice != "" {
+		var ok bool
+		gasPrice, ok = new(big.Int).SetString(in.GasPrice, 0)
+		if !ok {
+			return nil, proto.ErrorInvalidArgument("gasPrice", "is not a number")
+		}
+	}
+
+	for _, rel := range relayers {
+		ntx, err := rel.Bump(r.Context(), sequence.MetaTxnID(in.MetaTxnID), gasPrice)
+		if errors.Is(err, relayer.ErrNotPending) {
+			continue
+		}
+		if err != nil {
+			return nil, proto.WrapFailf(err, "failed to bump %s", in.MetaTxnID)
+		}
+		return map[string]interface{}{"txnHash": ntx.Hash().Hex()}, nil
+	}
+	return nil, proto.Errorf(proto.ErrNotFound, "%s is not pending", in.MetaTxnID)
+}
+
+// rotateSender rotates the sender of a tenant, or the default sender shared by the tenants
+// without their own sender if no tenant is given.
+func (s *AdminServer) rotateSender(in adminRequest) (interface{}, error) {
+	if in.PrivateKey == "" {
+		return nil, proto.ErrorRequiredArgument("privateKey")
+	}
+	sender, err := ethwallet.NewWalletFromPrivateKey(strings.TrimPrefix(in.PrivateKey, "0x"))
+	if err != nil {
+		return nil, proto.ErrorInvalidArgument("privateKey", "is not a private key")
+	}
+
+	if in.Tenant == "" {
+		err = s.relayers.SetSender(sender)
+	} else {
+		var rel *relayer.LocalRelayer
+		rel, err = s.relayers.Relayer(in.Tenant)
+		if err == nil {
+			err = rel.SetSender(sender)
+		}
+	}
+	if err != nil {
+		return nil, proto.WrapFailf(err, "failed to rotate sender")
+	}
+	return map[string]interface{}{"sender": sender.Address().Hex()}, nil
+}
@@ -0,0 +1,317 @@
+// Package server serves a relayer over HTTP, speaking the same webrpc protocol as the
+// hosted Sequence relayer, so it can be used by clients through relayer.RpcRelayer.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/relayer"
+	"github.com/0xsequence/go-sequence/relayer/proto"
+)
+
+// maxRequestBodySize limits the size of request bodies.
+const maxRequestBodySize = 1 << 20
+
+// Server is an http.Handler serving the relay and status methods of the webrpc Relayer
+// service for the tenants of a MultiTenantRelayer. Ping and Version are public, all other
+// methods require the api key of a tenant.
+//
+// In addition to the webrpc methods, WatchMetaTxn and WatchWallet stream the status
+// transitions of meta-transactions as they happen, see StatusUpdate, so clients don't poll
+// GetMetaTxnReceipt.
+type Server struct {
+	relayers      *relayer.MultiTenantRelayer
+	walletContext sequence.WalletContext
+	options       sequence.Options
+	startTime     time.Time
+}
+
+var _ http.Handler = &Server{}
+
+type tenantCtxKey struct{}
+
+// NewServer serves the tenants of relayers, which relay the meta-transactions of the wallets of
+// walletContext, including the deployments of counterfactual wallets by its guest module.
+func NewServer(relayers *relayer.MultiTenantRelayer, walletContext sequence.WalletContext, opts ...sequence.Option) *Server {
+	relayers.SetWalletContext(walletContext)
+	return &Server{
+		relayers:      relayers,
+		walletContext: walletContext,
+		options:       sequence.NewOptions(opts...),
+		startTime:     time.Now(),
+	}
+}
+
+// TenantID returns the id of the tenant which authenticated the request of ctx.
+func TenantID(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantCtxKey{}).(string)
+	return tenantID, ok
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.URL.Path, proto.RelayerPathPrefix) {
+		writeError(w, proto.Errorf(proto.ErrBadRoute, "no handler for path %q", r.URL.Path))
+		return
+	}
+	method := strings.TrimPrefix(r.URL.Path, proto.RelayerPathPrefix)
+
+	ctx := r.Context()
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBodySize))
+	if err != nil {
+		writeError(w, proto.WrapError(proto.ErrInvalidArgument, err, "failed to read request body"))
+		return
+	}
+
+	switch method {
+	case "Ping":
+		writeJSON(w, map[string]interface{}{"status": true})
+		return
+	case "Version":
+		writeJSON(w, map[string]interface{}{"version": &proto.Version{
+			WebrpcVersion: proto.WebRPCVersion(),
+			SchemaVersion: proto.WebRPCSchemaVersion(),
+			SchemaHash:    proto.WebRPCSchemaHash(),
+		}})
+		return
+	}
+
+	// requests may already be authenticated by the RequestVerifier middleware
+	tenantID, ok := TenantID(ctx)
+	if !ok {
+		tenantID, err = s.relayers.Authenticate(r.Header.Get(relayer.AccessKeyHeader))
+		if err != nil {
+			s.options.Metrics.IncCounter("server.unauthenticated")
+			writeError(w, proto.Errorf(proto.ErrUnauthenticated, "invalid or missing %s header", relayer.AccessKeyHeader))
+			return
+		}
+		ctx = context.WithValue(ctx, tenantCtxKey{}, tenantID)
+	}
+
+	rel, err := s.relayers.Relayer(tenantID)
+	if err != nil {
+		writeError(w, proto.Errorf(proto.ErrUnauthenticated, "tenant %s is not registered", tenantID))
+		return
+	}
+
+	// the watch methods stream their responses
+	switch method {
+	case "WatchMetaTxn":
+		s.watchMetaTxn(ctx, w, rel, body)
+		return
+	case "WatchWallet":
+		s.watchWallet(ctx, w, rel, body)
+		return
+	}
+
+	var out interface{}
+	switch method {
+	case "RuntimeStatus":
+		out, err = s.runtimeStatus(ctx, rel)
+	case "GetSequenceContext":
+		out, err = s.getSequenceContext(ctx)
+	case "GetChainID":
+		out, err = s.getChainID(ctx, rel)
+	case "SendMetaTxn":
+		out, err = s.sendMetaTxn(ctx, rel, body)
+	case "GetMetaTxnNonce":
+		out, err = s.getMetaTxnNonce(ctx, rel, body)
+	case "GetMetaTxnReceipt":
+		out, err = s.getMetaTxnReceipt(ctx, rel, body)
+	default:
+		err = proto.Errorf(proto.ErrUnimplemented, "method %s is not supported", method)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, out)
+}
+
+func (s *Server) runtimeStatus(ctx context.Context, rel *relayer.LocalRelayer) (interface{}, error) {
+	_, err := rel.GetProvider().ChainID(ctx)
+
+	senders := []*proto.SenderStatus{
+		{Index: 0, Address: rel.GetSender().Address().Hex(), Active: true},
+	}
+	if pool := rel.SenderPool(); pool != nil {
+		senders = senders[:0]
+		for i, address := range pool.Senders() {
+			senders = append(senders, &proto.SenderStatus{Index: uint32(i), Address: address.Hex(), Active: true})
+		}
+	}
+
+	return map[string]interface{}{"status": &proto.RuntimeStatus{
+		HealthOK:  err == nil,
+		StartTime: s.startTime,
+		Uptime:    uint64(time.Since(s.startTime).Seconds()),
+		Senders:   senders,
+		Checks:    &proto.RuntimeChecks{},
+	}}, nil
+}
+
+func (s *Server) getSequenceContext(ctx context.Context) (interface{}, error) {
+	return map[string]interface{}{"data": &proto.SequenceContext{
+		Factory:              s.walletContext.FactoryAddress.Hex(),
+		MainModule:           s.walletContext.MainModuleAddress.Hex(),
+		MainModuleUpgradable: s.walletContext.MainModuleUpgradableAddress.Hex(),
+		GuestModule:          s.walletContext.GuestModuleAddress.Hex(),
+		Utils:                s.walletContext.UtilsAddress.Hex(),
+	}}, nil
+}
+
+func (s *Server) getChainID(ctx context.Context, rel *relayer.LocalRelayer) (interface{}, error) {
+	chainID, err := rel.GetProvider().ChainID(ctx)
+	if err != nil {
+		return nil, proto.WrapError(proto.ErrUnavailable, err, "failed to get chain id")
+	}
+	return map[string]interface{}{"chainID": chainID.Uint64()}, nil
+}
+
+func (s *Server) sendMetaTxn(ctx context.Context, rel *relayer.LocalRelayer, body []byte) (interface{}, error) {
+	var in struct {
+		Call *proto.MetaTxn `json:"call"`
+	}
+	if err := json.Unmarshal(body, &in); err != nil || in.Call == nil {
+		return nil, proto.ErrorRequiredArgument("call")
+	}
+	if !common.IsHexAddress(in.Call.WalletAddress) {
+		return nil, proto.ErrorInvalidArgument("walletAddress", "is not an address")
+	}
+	if !common.IsHexAddress(in.Call.Contract) {
+		return nil, proto.ErrorInvalidArgument("contract", "is not an address")
+	}
+	execdata, err := hexutil.Decode(in.Call.Input)
+	if err != nil {
+		return nil, proto.ErrorInvalidArgument("input", "is not hex encoded")
+	}
+
+	metaTxnID, _, _, err := rel.RelayExecdata(ctx, common.HexToAddress(in.Call.WalletAddress), common.HexToAddress(in.Call.Contract), execdata)
+	if err != nil {
+		if errors.Is(err, relayer.ErrPolicyViolation) {
+			return nil, proto.WrapError(proto.ErrPermissionDenied, err, "meta-transaction rejected by policy")
+		}
+		if errors.Is(err, relayer.ErrRelayerPaused) {
+			return nil, proto.WrapError(proto.ErrUnavailable, err, "relaying is paused")
+		}
+		if errors.Is(err, relayer.ErrQuotaExceeded) {
+			return nil, proto.WrapError(proto.ErrResourceExhausted, err, "meta-transaction rejected by quota")
+		}
+		return nil, proto.WrapFailf(err, "failed to relay meta-transaction")
+	}
+	return map[string]interface{}{"status": true, "txnHash": string(metaTxnID)}, nil
+}
+
+func (s *Server) getMetaTxnNonce(ctx context.Context, rel *relayer.LocalRelayer, body []byte) (interface{}, error) {
+	var in struct {
+		WalletContractAddress string  `json:"walletContractAddress"`
+		Space                 *string `json:"space"`
+	}
+	if err := json.Unmarshal(body, &in); err != nil || !common.IsHexAddress(in.WalletContractAddress) {
+		return nil, proto.ErrorInvalidArgument("walletContractAddress", "is not an address")
+	}
+
+	var space *big.Int
+	if in.Space != nil {
+		var ok bool
+		space, ok = new(big.Int).SetString(*in.Space, 0)
+		if !ok {
+			return nil, proto.ErrorInvalidArgument("space", "is not a number")
+		}
+	}
+
+	nonce, err := rel.GetWalletNonce(ctx, common.HexToAddress(in.WalletContractAddress), space)
+	if err != nil {
+		return nil, proto.WrapFailf(err, "failed to get nonce")
+	}
+	return map[string]interface{}{"nonce": nonce.String()}, nil
+}
+
+func (s *Server) getMetaTxnReceipt(ctx context.Context, rel *relayer.LocalRelayer, body []byte) (interface{}, error) {
+	var in struct {
+		MetaTxID string `json:"metaTxID"`
+	}
+	if err := json.Unmarshal(body, &in); err != nil || in.MetaTxID == "" {
+		return nil, proto.ErrorRequiredArgument("metaTxID")
+	}
+
+	status, receipt, err := rel.Wait(ctx, sequence.MetaTxnID(in.MetaTxID))
+	if err != nil {
+		return nil, proto.WrapError(proto.ErrNotFound, err, "receipt of %s not found", in.MetaTxID)
+	}
+
+	out, err := metaTxnReceipt(in.MetaTxID, status, receipt)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"receipt": out}, nil
+}
+
+func metaTxnReceipt(metaTxID string, status sequence.MetaTxnStatus, receipt *types.Receipt) (*proto.MetaTxnReceipt, error) {
+	txnReceipt, err := json.Marshal(receipt)
+	if err != nil {
+		return nil, proto.WrapError(proto.ErrInternal, err, "failed to encode receipt")
+	}
+
+	logs := make([]*proto.MetaTxnReceiptLog, 0, len(receipt.Logs))
+	for _, log := range receipt.Logs {
+		topics := make([]string, len(log.Topics))
+		for i, topic := range log.Topics {
+			topics[i] = topic.Hex()
+		}
+		logs = append(logs, &proto.MetaTxnReceiptLog{
+			Address: log.Address.Hex(),
+			Topics:  topics,
+			Data:    hexutil.Encode(log.Data),
+		})
+	}
+
+	return &proto.MetaTxnReceipt{
+		ID:         metaTxID,
+		Status:     metaTxnStatus(status),
+		Logs:       logs,
+		TxnReceipt: string(txnReceipt),
+	}, nil
+}
+
+func metaTxnStatus(status sequence.MetaTxnStatus) string {
+	switch status {
+	case sequence.MetaTxnExecuted:
+		return "SUCCEEDED"
+	case sequence.MetaTxnFailed:
+		return "FAILED"
+	case sequence.MetaTxnReverted:
+		return "REVERTED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func writeJSON(w http.ResponseWriter, out interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	rpcErr, ok := err.(proto.Error)
+	if !ok {
+		rpcErr = proto.WrapError(proto.ErrInternal, err, fmt.Sprintf("%v", err))
+	}
+	payload := rpcErr.Payload()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(payload.Status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
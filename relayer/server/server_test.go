@@ -0,0 +1,581 @@
+package server_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/0xsequence/go-sequence/relayer"
+	"github.com/0xsequence/go-sequence/relayer/proto"
+	"github.com/0xsequence/go-sequence/relayer/server"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerAuth(t *testing.T) {
+	provider, err := ethrpc.NewProvider("http://127.0.0.1:1")
+	assert.NoError(t, err)
+
+	sender, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+	sender.SetProvider(provider)
+
+	relayers, err := relayer.NewMultiTenantRelayer(sender, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, relayers.AddTenant(relayer.Tenant{ID: "app-1", APIKey: "key-1"}))
+	assert.ErrorIs(t, relayers.AddTenant(relayer.Tenant{ID: "app-2", APIKey: "key-1"}), relayer.ErrTenantConflict)
+	assert.NoError(t, relayers.AddTenant(relayer.Tenant{ID: "app-2", APIKey: "key-2"}))
+	assert.Equal(t, []string{"app-1", "app-2"}, relayers.Tenants())
+
+	ts := httptest.NewServer(server.NewServer(relayers, sequence.SequenceContext()))
+	defer ts.Close()
+
+	// public methods do not require an api key
+	client := proto.NewRelayerClient(ts.URL, http.DefaultClient)
+	ok, err := client.Ping(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = client.GetSequenceContext(context.Background())
+	assert.True(t, proto.IsErrorCode(err, proto.ErrUnauthenticated))
+
+	rpcRelayer, err := relayer.NewRpcRelayer(provider, nil, ts.URL, http.DefaultClient)
+	assert.NoError(t, err)
+
+	rpcRelayer.SetAccessKey("wrong")
+	_, err = rpcRelayer.Service.GetSequenceContext(context.Background())
+	assert.True(t, proto.IsErrorCode(err, proto.ErrUnauthenticated))
+
+	rpcRelayer.SetAccessKey("key-2")
+	seqContext, err := rpcRelayer.Service.GetSequenceContext(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, sequence.SequenceContext().FactoryAddress.Hex(), seqContext.Factory)
+
+	// malformed meta-transactions are rejected before reaching the chain
+	_, _, err = rpcRelayer.Service.SendMetaTxn(context.Background(), &proto.MetaTxn{
+		WalletAddress: common.Address{}.Hex(),
+		Contract:      common.Address{}.Hex(),
+		Input:         "not-hex",
+	})
+	assert.True(t, proto.IsErrorCode(err, proto.ErrInvalidArgument))
+
+	relayers.RemoveTenant("app-2")
+	_, err = rpcRelayer.Service.GetSequenceContext(context.Background())
+	assert.True(t, proto.IsErrorCode(err, proto.ErrUnauthenticated))
+}
+
+func TestRequestVerifier(t *testing.T) {
+	provider, err := ethrpc.NewProvider("http://127.0.0.1:1")
+	assert.NoError(t, err)
+
+	sender, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+	sender.SetProvider(provider)
+
+	signer, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+
+	relayers, err := relayer.NewMultiTenantRelayer(sender, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, relayers.AddTenant(relayer.Tenant{ID: "app-1", APIKey: "key-1", Signers: []common.Address{signer.Address()}}))
+
+	verifier := server.NewRequestVerifier(relayers, true)
+	ts := httptest.NewServer(verifier.Middleware(server.NewServer(relayers, sequence.SequenceContext())))
+	defer ts.Close()
+
+	rpcRelayer, err := relayer.NewRpcRelayer(provider, nil, ts.URL, http.DefaultClient)
+	assert.NoError(t, err)
+
+	// unsigned requests are rejected, even with a valid access key
+	rpcRelayer.SetAccessKey("key-1")
+	_, err = rpcRelayer.Service.GetSequenceContext(context.Background())
+	assert.True(t, proto.IsErrorCode(err, proto.ErrUnauthenticated))
+
+	rpcRelayer.SetRequestSigner(relayer.NewHMACRequestSigner("app-1", "key-1"))
+	_, err = rpcRelayer.Service.GetSequenceContext(context.Background())
+	assert.NoError(t, err)
+
+	rpcRelayer.SetRequestSigner(relayer.NewHMACRequestSigner("app-1", "wrong"))
+	_, err = rpcRelayer.Service.GetSequenceContext(context.Background())
+	assert.True(t, proto.IsErrorCode(err, proto.ErrUnauthenticated))
+
+	rpcRelayer.SetRequestSigner(relayer.NewWalletRequestSigner(signer))
+	_, err = rpcRelayer.Service.GetSequenceContext(context.Background())
+	assert.NoError(t, err)
+
+	rpcRelayer.SetRequestSigner(relayer.NewWalletRequestSigner(sender))
+	_, err = rpcRelayer.Service.GetSequenceContext(context.Background())
+	assert.True(t, proto.IsErrorCode(err, proto.ErrUnauthenticated))
+
+	// a replayed request is rejected
+	body := []byte(`{}`)
+	timestamp := time.Now().Unix()
+	path := proto.RelayerPathPrefix + "GetSequenceContext"
+	sig := relayer.HMACRequestDigest("key-1", relayer.RequestDigest(path, body, timestamp))
+
+	for i, expectedStatus := range []int{http.StatusOK, http.StatusUnauthorized} {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+path, bytes.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set(relayer.SignatureSchemeHeader, relayer.SchemeHMAC)
+		req.Header.Set(relayer.SignatureKeyIDHeader, "app-1")
+		req.Header.Set(relayer.SignatureHeader, hexutil.Encode(sig))
+		req.Header.Set(relayer.TimestampHeader, strconv.FormatInt(timestamp, 10))
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, expectedStatus, resp.StatusCode, "request %d", i)
+	}
+}
+
+func TestRequestVerifierConcurrentReplay(t *testing.T) {
+	provider, err := ethrpc.NewProvider("http://127.0.0.1:1")
+	assert.NoError(t, err)
+
+	sender, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+	sender.SetProvider(provider)
+
+	relayers, err := relayer.NewMultiTenantRelayer(sender, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, relayers.AddTenant(relayer.Tenant{ID: "app-1", APIKey: "key-1"}))
+
+	verifier := server.NewRequestVerifier(relayers, true)
+	ts := httptest.NewServer(verifier.Middleware(server.NewServer(relayers, sequence.SequenceContext())))
+	defer ts.Close()
+
+	body := []byte(`{}`)
+	timestamp := time.Now().Unix()
+	path := proto.RelayerPathPrefix + "GetSequenceContext"
+	sig := relayer.HMACRequestDigest("key-1", relayer.RequestDigest(path, body, timestamp))
+
+	send := func() int {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+path, bytes.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set(relayer.SignatureSchemeHeader, relayer.SchemeHMAC)
+		req.Header.Set(relayer.SignatureKeyIDHeader, "app-1")
+		req.Header.Set(relayer.SignatureHeader, hexutil.Encode(sig))
+		req.Header.Set(relayer.TimestampHeader, strconv.FormatInt(timestamp, 10))
+
+		resp, err := http.DefaultClient.Do(req)
+		if !assert.NoError(t, err) {
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// of concurrent requests with the same signature, only one is accepted
+	var (
+		accepted int
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if send() == http.StatusOK {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, accepted)
+
+	// requests are rejected when the store can't tell if they were already used
+	verifier.SetReplayStore(failingStore{})
+	timestamp++
+	sig = relayer.HMACRequestDigest("key-1", relayer.RequestDigest(path, body, timestamp))
+	assert.Equal(t, http.StatusServiceUnavailable, send())
+}
+
+func TestRequestVerifierMalleatedReplay(t *testing.T) {
+	provider, err := ethrpc.NewProvider("http://127.0.0.1:1")
+	assert.NoError(t, err)
+
+	sender, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+	sender.SetProvider(provider)
+
+	signer, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+
+	relayers, err := relayer.NewMultiTenantRelayer(sender, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, relayers.AddTenant(relayer.Tenant{ID: "app-1", APIKey: "key-1", Signers: []common.Address{signer.Address()}}))
+
+	verifier := server.NewRequestVerifier(relayers, true)
+	ts := httptest.NewServer(verifier.Middleware(server.NewServer(relayers, sequence.SequenceContext())))
+	defer ts.Close()
+
+	body := []byte(`{}`)
+	timestamp := time.Now().Unix()
+	path := proto.RelayerPathPrefix + "GetSequenceContext"
+	digest := relayer.RequestDigest(path, body, timestamp)
+	sig, err := signer.SignMessage(digest)
+	assert.NoError(t, err)
+
+	send := func(sig []byte) int {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+path, bytes.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set(relayer.SignatureSchemeHeader, relayer.SchemeEIP191)
+		req.Header.Set(relayer.SignatureKeyIDHeader, signer.Address().Hex())
+		req.Header.Set(relayer.SignatureHeader, hexutil.Encode(sig))
+		req.Header.Set(relayer.TimestampHeader, strconv.FormatInt(timestamp, 10))
+
+		resp, err := http.DefaultClient.Do(req)
+		if !assert.NoError(t, err) {
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// the signature with v of 0 or 1 instead of 27 or 28, or the other way around
+	flipped := append([]byte{}, sig...)
+	if flipped[64] >= 27 {
+		flipped[64] -= 27
+	} else {
+		flipped[64] += 27
+	}
+
+	// the signature with s replaced by n - s, which recovers the same signer with the other v
+	malleated := append([]byte{}, sig...)
+	s := new(big.Int).Sub(crypto.S256().Params().N, new(big.Int).SetBytes(sig[32:64]))
+	copy(malleated[32:64], common.LeftPadBytes(s.Bytes(), 32))
+	if malleated[64] >= 27 {
+		malleated[64] = 27 + ((malleated[64] - 27) ^ 1)
+	} else {
+		malleated[64] ^= 1
+	}
+
+	for _, replayed := range [][]byte{flipped, malleated} {
+		recovered, err := ethwallet.RecoverAddress(digest, replayed)
+		assert.NoError(t, err)
+		assert.Equal(t, signer.Address(), recovered)
+	}
+
+	// the request is accepted once, however its signature is encoded
+	assert.Equal(t, http.StatusOK, send(sig))
+	assert.Equal(t, http.StatusUnauthorized, send(flipped))
+	assert.Equal(t, http.StatusUnauthorized, send(malleated))
+	assert.Equal(t, http.StatusUnauthorized, send(sig))
+}
+
+type failingStore struct{}
+
+func (failingStore) Consume(ctx context.Context, key common.Hash, expiresAt time.Time) (bool, error) {
+	return false, fmt.Errorf("store is down")
+}
+
+func (failingStore) Consumed(ctx context.Context, key common.Hash) (bool, error) {
+	return false, fmt.Errorf("store is down")
+}
+
+func (failingStore) Prune(ctx context.Context, now time.Time) error {
+	return fmt.Errorf("store is down")
+}
+
+func TestAdminServer(t *testing.T) {
+	provider, err := ethrpc.NewProvider("http://127.0.0.1:1")
+	assert.NoError(t, err)
+
+	sender, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+	sender.SetProvider(provider)
+
+	relayers, err := relayer.NewMultiTenantRelayer(sender, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, relayers.AddTenant(relayer.Tenant{ID: "app-1", APIKey: "key-1"}))
+	assert.NoError(t, relayers.AddTenant(relayer.Tenant{ID: "app-2", APIKey: "key-2"}))
+
+	ts := httptest.NewServer(server.NewAdminServer(relayers, "admin-token"))
+	defer ts.Close()
+
+	call := func(token, method, body string) int {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+server.AdminPathPrefix+method, bytes.NewReader([]byte(body)))
+		assert.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// tenant api keys are not admin credentials
+	assert.Equal(t, http.StatusUnauthorized, call("key-1", "Pause", `{}`))
+
+	assert.Equal(t, http.StatusOK, call("admin-token", "Pause", `{"tenant":"app-1"}`))
+	app1, err := relayers.Relayer("app-1")
+	assert.NoError(t, err)
+	app2, err := relayers.Relayer("app-2")
+	assert.NoError(t, err)
+	assert.True(t, app1.IsPaused())
+	assert.False(t, app2.IsPaused())
+
+	assert.Equal(t, http.StatusOK, call("admin-token", "Resume", `{}`))
+	assert.False(t, app1.IsPaused())
+
+	assert.Equal(t, http.StatusOK, call("admin-token", "ListPending", `{}`))
+	assert.Equal(t, http.StatusNotFound, call("admin-token", "Evict", `{"metaTxnID":"0x01"}`))
+	assert.Equal(t, http.StatusNotFound, call("admin-token", "Pause", `{"tenant":"unknown"}`))
+
+	// rotating the default sender applies to the tenants sharing it
+	rotated, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, call("admin-token", "RotateSender", `{"privateKey":"`+rotated.PrivateKeyHex()+`"}`))
+	assert.Equal(t, rotated.Address(), app1.GetSender().Address())
+	assert.Equal(t, rotated.Address(), app2.GetSender().Address())
+	assert.NotNil(t, app1.GetProvider())
+}
+
+// minerNode is a chain accepting transactions, which it keeps in its mempool, and whose receipts
+// are returned once mined is set.
+type minerNode struct {
+	chain *memchain.Chain
+	mu    sync.Mutex
+	mined bool
+	sent  []*types.Transaction
+	logs  []*types.Log
+}
+
+// newMinerNode returns a node whose gas price is 1 gwei.
+func newMinerNode() *minerNode {
+	n := &minerNode{chain: memchain.New(memchain.Options{ChainID: big.NewInt(1), BaseFee: big.NewInt(0), GasTip: big.NewInt(1_000_000_000)})}
+	n.chain.Intercept(n.serve)
+	return n
+}
+
+// serve keeps the transactions sent in the mempool, and estimates any transaction at 200000 gas.
+func (n *minerNode) serve(method string, params []json.RawMessage, next func() (interface{}, error)) (interface{}, error) {
+	switch method {
+	case "eth_estimateGas":
+		return "0x30d40", nil
+	case "eth_sendRawTransaction":
+		var raw hexutil.Bytes
+		if err := json.Unmarshal(params[0], &raw); err != nil {
+			return nil, err
+		}
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(raw); err != nil {
+			return nil, err
+		}
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		n.sent = append(n.sent, tx)
+		return tx.Hash(), nil
+	case "eth_getTransactionReceipt":
+		var hash common.Hash
+		if err := json.Unmarshal(params[0], &hash); err != nil {
+			return nil, err
+		}
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		if n.mined && hash == n.sent[len(n.sent)-1].Hash() {
+			return &types.Receipt{
+				Status:      types.ReceiptStatusSuccessful,
+				TxHash:      hash,
+				BlockNumber: big.NewInt(1),
+				Logs:        n.logs,
+			}, nil
+		}
+		return nil, nil
+	default:
+		return next()
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ctx := context.Background()
+
+	node := newMinerNode()
+	provider := node.chain.Provider()
+
+	sender, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+	sender.SetProvider(provider)
+
+	relayers, err := relayer.NewMultiTenantRelayer(sender, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, relayers.AddTenant(relayer.Tenant{ID: "app-1", APIKey: "key-1"}))
+	rel, err := relayers.Relayer("app-1")
+	assert.NoError(t, err)
+
+	srv := httptest.NewServer(server.NewServer(relayers, sequence.SequenceContext()))
+	defer srv.Close()
+
+	wallet := common.HexToAddress("0x5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a")
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	watch := func(method, body string) <-chan map[string]json.RawMessage {
+		req, err := http.NewRequestWithContext(watchCtx, http.MethodPost, srv.URL+proto.RelayerPathPrefix+method, strings.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set(relayer.AccessKeyHeader, "key-1")
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		lines := make(chan map[string]json.RawMessage, 10)
+		go func() {
+			defer resp.Body.Close()
+			defer close(lines)
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				var line map[string]json.RawMessage
+				_ = json.Unmarshal(scanner.Bytes(), &line)
+				lines <- line
+			}
+		}()
+		return lines
+	}
+	next := func(lines <-chan map[string]json.RawMessage) server.StatusUpdate {
+		select {
+		case line := <-lines:
+			var update server.StatusUpdate
+			assert.NoError(t, json.Unmarshal(line["update"], &update))
+			return update
+		case <-time.After(5 * time.Second):
+			t.Fatal("no update streamed")
+			return server.StatusUpdate{}
+		}
+	}
+
+	walletUpdates := watch("WatchWallet", `{"walletAddress":"`+wallet.Hex()+`"}`)
+
+	txns, err := sequence.Transactions{{To: common.HexToAddress("0xb0b0"), Value: big.NewInt(1)}}.EncodedTransactions()
+	assert.NoError(t, err)
+	execdata, err := contracts.WalletMainModule.Encode("execute", txns, big.NewInt(0), []byte{0x01})
+	assert.NoError(t, err)
+	metaTxnID, _, _, err := rel.RelayExecdata(ctx, wallet, wallet, execdata)
+	assert.NoError(t, err)
+
+	update := next(walletUpdates)
+	assert.Equal(t, string(metaTxnID), update.ID)
+	assert.Equal(t, "SENT", update.Status)
+	assert.Equal(t, wallet.Hex(), update.WalletAddress)
+
+	bumped, err := rel.Bump(ctx, metaTxnID, nil)
+	assert.NoError(t, err)
+	update = next(walletUpdates)
+	assert.Equal(t, "REPLACED", update.Status)
+	assert.Equal(t, bumped.Hash().Hex(), update.TxnHash)
+
+	// a stream opened later starts from the current state
+	metaTxnUpdates := watch("WatchMetaTxn", `{"metaTxID":"`+string(metaTxnID)+`"}`)
+	update = next(metaTxnUpdates)
+	assert.Equal(t, "REPLACED", update.Status)
+	assert.Equal(t, bumped.Hash().Hex(), update.TxnHash)
+
+	metaTxnHash := common.HexToHash(string(metaTxnID))
+	node.mu.Lock()
+	node.mined, node.logs = true, []*types.Log{{Address: wallet, Topics: []common.Hash{}, Data: metaTxnHash.Bytes(), TxHash: bumped.Hash()}}
+	node.mu.Unlock()
+
+	for _, updates := range []<-chan map[string]json.RawMessage{metaTxnUpdates, walletUpdates} {
+		update = next(updates)
+		assert.Equal(t, "SUCCEEDED", update.Status)
+		assert.Equal(t, bumped.Hash().Hex(), update.TxnHash)
+		if assert.NotNil(t, update.Receipt) {
+			assert.Len(t, update.Receipt.Logs, 1)
+		}
+	}
+
+	// the stream of the meta-transaction ends once it is mined
+	select {
+	case _, ok := <-metaTxnUpdates:
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not end")
+	}
+	assert.Empty(t, rel.Pending())
+}
+
+func TestSendMetaTxnOfUndeployedWallet(t *testing.T) {
+	ctx := context.Background()
+
+	node := newMinerNode()
+	provider := node.chain.Provider()
+
+	sender, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+	sender.SetProvider(provider)
+
+	relayers, err := relayer.NewMultiTenantRelayer(sender, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, relayers.AddTenant(relayer.Tenant{ID: "app-1", APIKey: "key-1"}))
+
+	srv := httptest.NewServer(server.NewServer(relayers, sequence.SequenceContext()))
+	defer srv.Close()
+
+	rpcRelayer, err := relayer.NewRpcRelayer(provider, nil, srv.URL, http.DefaultClient)
+	assert.NoError(t, err)
+	rpcRelayer.SetAccessKey("key-1")
+
+	owner, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+	walletConfig := sequence.WalletConfig{Threshold: 1, Signers: sequence.WalletConfigSigners{{Weight: 1, Address: owner.Address()}}}
+	walletAddress, err := sequence.AddressFromWalletConfig(walletConfig, sequence.SequenceContext())
+	assert.NoError(t, err)
+
+	txns := sequence.Transactions{{To: common.HexToAddress("0xb0b0"), Value: big.NewInt(1), RevertOnError: true}}
+	nonce := big.NewInt(0)
+	digest, err := (&sequence.Transaction{Transactions: txns, Nonce: nonce}).Digest()
+	assert.NoError(t, err)
+	signedTxs := &sequence.SignedTransactions{
+		ChainID:       big.NewInt(1),
+		WalletConfig:  walletConfig,
+		WalletContext: sequence.SequenceContext(),
+		Transactions:  txns,
+		Nonce:         nonce,
+		Digest:        digest,
+		Signature:     []byte{0x01},
+	}
+
+	// the first bundle of a counterfactual wallet is relayed through the guest module
+	metaTxnID, _, _, err := rpcRelayer.Relay(ctx, signedTxs)
+	assert.NoError(t, err)
+	expected, _, err := sequence.ComputeMetaTxnID(signedTxs.ChainID, walletAddress, txns, nonce, sequence.MetaTxnWalletExec)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, metaTxnID)
+
+	to, execdata, err := sequence.EncodeDeployAndExecute(walletConfig, sequence.SequenceContext(), txns, nonce, signedTxs.Signature)
+	assert.NoError(t, err)
+	node.mu.Lock()
+	sent := node.sent
+	node.mu.Unlock()
+	if assert.Len(t, sent, 1) {
+		assert.Equal(t, to, *sent[0].To())
+		assert.Equal(t, execdata, sent[0].Data())
+	}
+
+	// the guest module only relays the deployment of the wallet of the meta-transaction, and
+	// execdata is not relayed to any other contract
+	rel, err := relayers.Relayer("app-1")
+	assert.NoError(t, err)
+	_, _, _, err = rel.RelayExecdata(ctx, common.HexToAddress("0x5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a"), to, execdata)
+	assert.Error(t, err)
+	_, _, _, err = rel.RelayExecdata(ctx, walletAddress, common.HexToAddress("0xdead"), execdata)
+	assert.Error(t, err)
+}
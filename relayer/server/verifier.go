@@ -0,0 +1,173 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence/relayer"
+	"github.com/0xsequence/go-sequence/relayer/proto"
+	"github.com/0xsequence/go-sequence/replayguard"
+)
+
+// DefaultMaxRequestSkew is how far the timestamp of a signed request may be from the
+// server's clock.
+const DefaultMaxRequestSkew = 5 * time.Minute
+
+// RequestVerifier is a middleware authenticating relay requests signed by a
+// relayer.RequestSigner. Signed requests are bound to their body and a timestamp, and are
+// accepted only once, so intercepted requests cannot be replayed, even with their signature
+// re-encoded.
+type RequestVerifier struct {
+	// MaxSkew is how far the timestamp of a request may be from the server's clock.
+	MaxSkew time.Duration
+
+	// Required rejects requests which are not signed. Otherwise unsigned requests are passed
+	// on to be authenticated by their access key.
+	Required bool
+
+	relayers *relayer.MultiTenantRelayer
+	seen     replayguard.Store
+
+	pruned  time.Time
+	muPrune sync.Mutex
+}
+
+func NewRequestVerifier(relayers *relayer.MultiTenantRelayer, required bool) *RequestVerifier {
+	return &RequestVerifier{
+		MaxSkew:  DefaultMaxRequestSkew,
+		Required: required,
+		relayers: relayers,
+		seen:     replayguard.NewMemoryStore(),
+	}
+}
+
+// SetReplayStore sets the store the accepted requests are recorded in, ie. a
+// replayguard.SQLStore shared by the instances of the relayer behind a load balancer.
+func (v *RequestVerifier) SetReplayStore(store replayguard.Store) *RequestVerifier {
+	v.seen = store
+	return v
+}
+
+func (v *RequestVerifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(relayer.SignatureHeader) == "" {
+			if v.Required && !isPublicMethod(r.URL.Path) {
+				writeError(w, proto.Errorf(proto.ErrUnauthenticated, "request must be signed"))
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBodySize))
+		if err != nil {
+			writeError(w, proto.WrapError(proto.ErrInvalidArgument, err, "failed to read request body"))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		tenantID, err := v.verify(r, body)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tenantCtxKey{}, tenantID)))
+	})
+}
+
+func (v *RequestVerifier) verify(r *http.Request, body []byte) (string, error) {
+	timestamp, err := strconv.ParseInt(r.Header.Get(relayer.TimestampHeader), 10, 64)
+	if err != nil {
+		return "", proto.ErrorInvalidArgument(relayer.TimestampHeader, "is not a unix timestamp")
+	}
+	skew := time.Since(time.Unix(timestamp, 0))
+	if skew < -v.MaxSkew || skew > v.MaxSkew {
+		return "", proto.Errorf(proto.ErrUnauthenticated, "request timestamp is outside of the allowed window")
+	}
+
+	sigHex := r.Header.Get(relayer.SignatureHeader)
+	sig, err := hexutil.Decode(sigHex)
+	if err != nil {
+		return "", proto.ErrorInvalidArgument(relayer.SignatureHeader, "is not hex encoded")
+	}
+
+	digest := relayer.RequestDigest(r.URL.Path, body, timestamp)
+	keyID := r.Header.Get(relayer.SignatureKeyIDHeader)
+
+	var tenantID, principal string
+	scheme := r.Header.Get(relayer.SignatureSchemeHeader)
+	switch scheme {
+	case relayer.SchemeHMAC:
+		if err := v.relayers.AuthenticateHMAC(keyID, digest, sig); err != nil {
+			return "", proto.Errorf(proto.ErrUnauthenticated, "invalid request signature")
+		}
+		tenantID, principal = keyID, keyID
+
+	case relayer.SchemeEIP191:
+		if !common.IsHexAddress(keyID) {
+			return "", proto.ErrorInvalidArgument(relayer.SignatureKeyIDHeader, "is not an address")
+		}
+		signer := common.HexToAddress(keyID)
+		recovered, err := ethwallet.RecoverAddress(digest, sig)
+		if err != nil || recovered != signer {
+			return "", proto.Errorf(proto.ErrUnauthenticated, "invalid request signature")
+		}
+		tenantID, err = v.relayers.AuthenticateSigner(signer)
+		if err != nil {
+			return "", proto.Errorf(proto.ErrUnauthenticated, "signer %v is not authorized", signer)
+		}
+		principal = signer.Hex()
+
+	default:
+		return "", proto.ErrorInvalidArgument(relayer.SignatureSchemeHeader, "is not supported")
+	}
+
+	// reject replays of a request within the allowed window, the request is consumed
+	// atomically so only one of concurrent requests with it is accepted. It's keyed by what
+	// was signed rather than by the signature, as an ECDSA signature has other encodings
+	// recovering the same signer, ie. with v of 0 or 1 or a high s.
+	v.prune(r.Context())
+	fresh, err := v.seen.Consume(r.Context(), requestKey(scheme, principal, digest), time.Unix(timestamp, 0).Add(v.MaxSkew))
+	if err != nil {
+		return "", proto.WrapError(proto.ErrUnavailable, err, "unable to check request for replays")
+	}
+	if !fresh {
+		return "", proto.Errorf(proto.ErrUnauthenticated, "request was already used")
+	}
+
+	return tenantID, nil
+}
+
+// requestKey identifies a signed request by its signature scheme, signer and digest, which
+// binds its path, body and timestamp.
+func requestKey(scheme, principal string, digest []byte) common.Hash {
+	return crypto.Keccak256Hash([]byte(scheme), []byte{0}, []byte(principal), []byte{0}, digest)
+}
+
+// prune removes the accepted requests whose requests are past the allowed window, at most once
+// per MaxSkew.
+func (v *RequestVerifier) prune(ctx context.Context) {
+	v.muPrune.Lock()
+	if time.Since(v.pruned) < v.MaxSkew {
+		v.muPrune.Unlock()
+		return
+	}
+	v.pruned = time.Now()
+	v.muPrune.Unlock()
+
+	_ = v.seen.Prune(ctx, time.Now())
+}
+
+func isPublicMethod(path string) bool {
+	return path == proto.RelayerPathPrefix+"Ping" || path == proto.RelayerPathPrefix+"Version"
+}
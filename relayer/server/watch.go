@@ -0,0 +1,223 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/relayer"
+	"github.com/0xsequence/go-sequence/relayer/proto"
+)
+
+// watchBuffer is the number of updates a watch stream may fall behind, before it is closed.
+const watchBuffer = 64
+
+// StatusUpdate is a status transition of a meta-transaction, streamed by the WatchMetaTxn and
+// WatchWallet methods as newline delimited JSON. Status is SENT, REPLACED when the transaction
+// carrying the meta-transaction is bumped, then SUCCEEDED, FAILED or REVERTED once it is
+// mined, or DROPPED if it stopped being tracked before.
+type StatusUpdate struct {
+	ID            string                `json:"id"`
+	WalletAddress string                `json:"walletAddress"`
+	Status        string                `json:"status"`
+	TxnHash       string                `json:"txnHash"`
+	Receipt       *proto.MetaTxnReceipt `json:"receipt,omitempty"`
+}
+
+// watchMetaTxn streams the transitions of a meta-transaction until it is mined or dropped. A
+// meta-transaction which is not pending is waited for, so the stream may be opened before it
+// is sent.
+func (s *Server) watchMetaTxn(ctx context.Context, w http.ResponseWriter, rel *relayer.LocalRelayer, body []byte) {
+	var in struct {
+		MetaTxID string `json:"metaTxID"`
+	}
+	if err := json.Unmarshal(body, &in); err != nil || in.MetaTxID == "" {
+		writeError(w, proto.ErrorRequiredArgument("metaTxID"))
+		return
+	}
+	metaTxnID := sequence.MetaTxnID(in.MetaTxID)
+
+	updates, unsubscribe := rel.Subscribe(watchBuffer)
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream := newStream(w)
+	for _, p := range rel.Pending() {
+		if p.MetaTxnID == metaTxnID {
+			stream.send(pendingUpdate(p))
+		}
+	}
+
+	// the meta-transaction may have been mined before the stream was opened
+	mined := make(chan *StatusUpdate, 1)
+	go func() {
+		status, receipt, err := rel.Wait(ctx, metaTxnID)
+		if err != nil {
+			return
+		}
+		update, err := receiptUpdate(relayer.StatusUpdate{MetaTxnID: metaTxnID, Transaction: receipt.TxHash, Status: status, Receipt: receipt})
+		if err == nil {
+			mined <- update
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update := <-mined:
+			stream.send(update)
+			return
+		case update, ok := <-updates:
+			if !ok {
+				stream.fail(proto.Errorf(proto.ErrResourceExhausted, "stream fell behind"))
+				return
+			}
+			if update.MetaTxnID != metaTxnID {
+				continue
+			}
+			out, err := statusUpdate(update)
+			if err != nil {
+				stream.fail(err)
+				return
+			}
+			stream.send(out)
+			if update.Terminal() {
+				return
+			}
+		}
+	}
+}
+
+// watchWallet streams the transitions of the pending meta-transactions of a wallet, and of
+// those relayed after, until the client disconnects.
+func (s *Server) watchWallet(ctx context.Context, w http.ResponseWriter, rel *relayer.LocalRelayer, body []byte) {
+	var in struct {
+		WalletAddress string `json:"walletAddress"`
+	}
+	if err := json.Unmarshal(body, &in); err != nil || !common.IsHexAddress(in.WalletAddress) {
+		writeError(w, proto.ErrorInvalidArgument("walletAddress", "is not an address"))
+		return
+	}
+	wallet := common.HexToAddress(in.WalletAddress)
+
+	updates, unsubscribe := rel.Subscribe(watchBuffer)
+	defer unsubscribe()
+
+	stream := newStream(w)
+	for _, p := range rel.Pending() {
+		if p.Wallet == wallet {
+			stream.send(pendingUpdate(p))
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update, ok := <-updates:
+			if !ok {
+				stream.fail(proto.Errorf(proto.ErrResourceExhausted, "stream fell behind"))
+				return
+			}
+			if update.Wallet != wallet {
+				continue
+			}
+			out, err := statusUpdate(update)
+			if err != nil {
+				stream.fail(err)
+				return
+			}
+			stream.send(out)
+		}
+	}
+}
+
+func pendingUpdate(p relayer.PendingTransaction) *StatusUpdate {
+	status := "SENT"
+	if p.Bumps > 0 {
+		status = "REPLACED"
+	}
+	return &StatusUpdate{
+		ID:            string(p.MetaTxnID),
+		WalletAddress: p.Wallet.Hex(),
+		Status:        status,
+		TxnHash:       p.Transaction.Hash().Hex(),
+	}
+}
+
+func statusUpdate(update relayer.StatusUpdate) (*StatusUpdate, error) {
+	out := &StatusUpdate{
+		ID:            string(update.MetaTxnID),
+		WalletAddress: update.Wallet.Hex(),
+		TxnHash:       update.Transaction.Hex(),
+	}
+	switch update.State {
+	case relayer.PendingSent:
+		out.Status = "SENT"
+	case relayer.PendingReplaced:
+		out.Status = "REPLACED"
+	case relayer.PendingDropped:
+		out.Status = "DROPPED"
+	case relayer.PendingMined:
+		return receiptUpdate(update)
+	}
+	return out, nil
+}
+
+func receiptUpdate(update relayer.StatusUpdate) (*StatusUpdate, error) {
+	receipt, err := metaTxnReceipt(string(update.MetaTxnID), update.Status, update.Receipt)
+	if err != nil {
+		return nil, err
+	}
+	out := &StatusUpdate{
+		ID:      string(update.MetaTxnID),
+		Status:  receipt.Status,
+		TxnHash: update.Transaction.Hex(),
+		Receipt: receipt,
+	}
+	if update.Wallet != (common.Address{}) {
+		out.WalletAddress = update.Wallet.Hex()
+	}
+	return out, nil
+}
+
+// stream writes newline delimited JSON to a response, flushing each line.
+type stream struct {
+	w       http.ResponseWriter
+	encoder *json.Encoder
+}
+
+func newStream(w http.ResponseWriter) *stream {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	s := &stream{w: w, encoder: json.NewEncoder(w)}
+	s.flush()
+	return s
+}
+
+func (s *stream) send(update *StatusUpdate) {
+	_ = s.encoder.Encode(map[string]interface{}{"update": update})
+	s.flush()
+}
+
+// fail ends the stream with an error, as the headers were already written.
+func (s *stream) fail(err error) {
+	rpcErr, ok := err.(proto.Error)
+	if !ok {
+		rpcErr = proto.WrapError(proto.ErrInternal, err, err.Error())
+	}
+	_ = s.encoder.Encode(map[string]interface{}{"error": rpcErr.Payload()})
+	s.flush()
+}
+
+func (s *stream) flush() {
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,203 @@
+package relayer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/ethkit/go-ethereum/rlp"
+)
+
+// SetCodeTxType is the EIP-2718 type of EIP-7702 set-code transactions.
+const SetCodeTxType = 0x04
+
+// setCodeAuthorizationGas is the intrinsic gas charged per authorization, which is not
+// accounted by eth_estimateGas on nodes unaware of EIP-7702.
+const setCodeAuthorizationGas = 25_000
+
+// SetCodeAuthorization is a signed EIP-7702 authorization, delegating the code of the
+// signing EOA to Address. A ChainID of 0 is valid on any chain.
+type SetCodeAuthorization struct {
+	ChainID *big.Int
+	Address common.Address
+	Nonce   uint64
+	V       uint8
+	R       *big.Int
+	S       *big.Int
+}
+
+// SetCodeTx is an EIP-7702 set-code transaction.
+//
+// EXPERIMENTAL: go-ethereum does not support type 0x04 transactions in this version, so
+// they are encoded here, and may only be sent to chains where EIP-7702 is live.
+type SetCodeTx struct {
+	ChainID        *big.Int
+	TxNonce        uint64
+	GasTipCap      *big.Int
+	GasFeeCap      *big.Int
+	GasLimit       uint64
+	Destination    common.Address
+	Amount         *big.Int
+	Input          []byte
+	AccessList     types.AccessList
+	Authorizations []SetCodeAuthorization
+
+	V uint8
+	R *big.Int
+	S *big.Int
+}
+
+var _ NativeTx = &SetCodeTx{}
+
+func (tx *SetCodeTx) Type() uint8         { return SetCodeTxType }
+func (tx *SetCodeTx) Nonce() uint64       { return tx.TxNonce }
+func (tx *SetCodeTx) Gas() uint64         { return tx.GasLimit }
+func (tx *SetCodeTx) GasPrice() *big.Int  { return tx.GasFeeCap }
+func (tx *SetCodeTx) To() *common.Address { return &tx.Destination }
+func (tx *SetCodeTx) Value() *big.Int     { return tx.Amount }
+func (tx *SetCodeTx) Data() []byte        { return tx.Input }
+
+// SigningHash is the hash signed by the sender of the transaction.
+func (tx *SetCodeTx) SigningHash() common.Hash {
+	return prefixedRlpHash(SetCodeTxType, tx.fields(false))
+}
+
+func (tx *SetCodeTx) Hash() common.Hash {
+	return prefixedRlpHash(SetCodeTxType, tx.fields(true))
+}
+
+func (tx *SetCodeTx) MarshalBinary() ([]byte, error) {
+	payload, err := rlp.EncodeToBytes(tx.fields(true))
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{SetCodeTxType}, payload...), nil
+}
+
+// Sign signs the transaction with the private key of sender.
+func (tx *SetCodeTx) Sign(sender *ethwallet.Wallet) error {
+	hash := tx.SigningHash()
+	sig, err := crypto.Sign(hash[:], sender.PrivateKey())
+	if err != nil {
+		return err
+	}
+	tx.R, tx.S, tx.V = new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64]), sig[64]
+	return nil
+}
+
+func (tx *SetCodeTx) fields(signed bool) []interface{} {
+	authorizations := make([][]interface{}, len(tx.Authorizations))
+	for i, auth := range tx.Authorizations {
+		authorizations[i] = []interface{}{bigOrZero(auth.ChainID), auth.Address, auth.Nonce, auth.V, bigOrZero(auth.R), bigOrZero(auth.S)}
+	}
+	accessList := tx.AccessList
+	if accessList == nil {
+		accessList = types.AccessList{}
+	}
+
+	fields := []interface{}{
+		bigOrZero(tx.ChainID),
+		tx.TxNonce,
+		bigOrZero(tx.GasTipCap),
+		bigOrZero(tx.GasFeeCap),
+		tx.GasLimit,
+		tx.Destination,
+		bigOrZero(tx.Amount),
+		tx.Input,
+		accessList,
+		authorizations,
+	}
+	if signed {
+		fields = append(fields, tx.V, bigOrZero(tx.R), bigOrZero(tx.S))
+	}
+	return fields
+}
+
+// SetCodeTxBuilder is an experimental TxBuilder sending each transaction as an EIP-7702
+// set-code transaction, carrying Authorizations. It is used to delegate EOAs as part of the
+// transaction relaying their first bundle.
+type SetCodeTxBuilder struct {
+	Authorizations []SetCodeAuthorization
+}
+
+var _ TxBuilder = &SetCodeTxBuilder{}
+
+func (b *SetCodeTxBuilder) BuildTx(ctx context.Context, sender *ethwallet.Wallet, chainID *big.Int, req *ethtxn.TransactionRequest) (NativeTx, error) {
+	if req.To == nil {
+		return nil, fmt.Errorf("relayer: set-code transactions cannot create contracts")
+	}
+	provider := sender.GetProvider()
+
+	tx := &SetCodeTx{
+		ChainID:        chainID,
+		GasTipCap:      req.GasTip,
+		GasFeeCap:      req.GasPrice,
+		GasLimit:       req.GasLimit,
+		Destination:    *req.To,
+		Amount:         req.ETHValue,
+		Input:          req.Data,
+		AccessList:     req.AccessList,
+		Authorizations: b.Authorizations,
+	}
+
+	if req.Nonce != nil {
+		tx.TxNonce = req.Nonce.Uint64()
+	} else {
+		nonce, err := provider.PendingNonceAt(ctx, sender.Address())
+		if err != nil {
+			return nil, err
+		}
+		tx.TxNonce = nonce
+	}
+
+	if tx.GasTipCap == nil {
+		tip, err := provider.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tx.GasTipCap = tip
+	}
+	if tx.GasFeeCap == nil {
+		gasPrice, err := provider.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tx.GasFeeCap = gasPrice
+	}
+	if tx.GasFeeCap.Cmp(tx.GasTipCap) < 0 {
+		tx.GasTipCap = tx.GasFeeCap
+	}
+
+	if tx.GasLimit == 0 {
+		gasLimit, err := provider.EstimateGas(ctx, ethereum.CallMsg{
+			From: sender.Address(), To: req.To, Value: req.ETHValue, Data: req.Data,
+		})
+		if err != nil {
+			return nil, err
+		}
+		tx.GasLimit = gasLimit + uint64(len(tx.Authorizations))*setCodeAuthorizationGas
+	}
+
+	if err := tx.Sign(sender); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func prefixedRlpHash(prefix byte, x interface{}) common.Hash {
+	payload, _ := rlp.EncodeToBytes(x)
+	return crypto.Keccak256Hash([]byte{prefix}, payload)
+}
+
+func bigOrZero(v *big.Int) *big.Int {
+	if v == nil {
+		return new(big.Int)
+	}
+	return v
+}
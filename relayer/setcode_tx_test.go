@@ -0,0 +1,52 @@
+package relayer_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/ethkit/go-ethereum/rlp"
+	"github.com/0xsequence/go-sequence/relayer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetCodeTx(t *testing.T) {
+	sender, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+
+	tx := &relayer.SetCodeTx{
+		ChainID:     big.NewInt(1),
+		TxNonce:     3,
+		GasTipCap:   big.NewInt(1e9),
+		GasFeeCap:   big.NewInt(30e9),
+		GasLimit:    100_000,
+		Destination: common.HexToAddress("0x1111111111111111111111111111111111111111"),
+		Input:       []byte{0x01, 0x02},
+		Authorizations: []relayer.SetCodeAuthorization{{
+			ChainID: big.NewInt(1),
+			Address: common.HexToAddress("0x2222222222222222222222222222222222222222"),
+			Nonce:   4,
+			R:       big.NewInt(1),
+			S:       big.NewInt(2),
+		}},
+	}
+	assert.NoError(t, tx.Sign(sender))
+
+	raw, err := tx.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, byte(relayer.SetCodeTxType), raw[0])
+	assert.Equal(t, crypto.Keccak256Hash(raw), tx.Hash())
+
+	// the envelope has the 13 fields of an EIP-7702 transaction
+	var fields []rlp.RawValue
+	assert.NoError(t, rlp.DecodeBytes(raw[1:], &fields))
+	assert.Len(t, fields, 13)
+
+	hash := tx.SigningHash()
+	sig := append(append(common.LeftPadBytes(tx.R.Bytes(), 32), common.LeftPadBytes(tx.S.Bytes(), 32)...), tx.V)
+	pub, err := crypto.SigToPub(hash[:], sig)
+	assert.NoError(t, err)
+	assert.Equal(t, sender.Address(), crypto.PubkeyToAddress(*pub))
+}
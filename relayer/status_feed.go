@@ -0,0 +1,114 @@
+package relayer
+
+import (
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+)
+
+// PendingState is the state of a bundle relayed by a LocalRelayer.
+type PendingState int
+
+const (
+	// PendingSent is reported when the transaction of a bundle is sent.
+	PendingSent PendingState = iota
+
+	// PendingReplaced is reported when the transaction of a bundle is replaced by another of
+	// the same bundle, ie. when it is bumped or reassigned to another sender.
+	PendingReplaced
+
+	// PendingMined is reported when a transaction of a bundle is mined.
+	PendingMined
+
+	// PendingDropped is reported when a bundle is no longer tracked before it was mined, ie.
+	// when it is evicted, cancelled, or none of its transactions were mined in time.
+	PendingDropped
+)
+
+func (s PendingState) String() string {
+	switch s {
+	case PendingSent:
+		return "sent"
+	case PendingReplaced:
+		return "replaced"
+	case PendingMined:
+		return "mined"
+	case PendingDropped:
+		return "dropped"
+	default:
+		return "unknown"
+	}
+}
+
+// StatusUpdate is a transition of a bundle relayed by a LocalRelayer.
+type StatusUpdate struct {
+	MetaTxnID sequence.MetaTxnID
+	Wallet    common.Address
+	State     PendingState
+
+	// Transaction is the hash of the native transaction carrying the bundle.
+	Transaction common.Hash
+
+	// Status and Receipt are set once the bundle is mined.
+	Status  sequence.MetaTxnStatus
+	Receipt *types.Receipt
+}
+
+// Terminal reports whether the bundle is no longer tracked after the update.
+func (u StatusUpdate) Terminal() bool {
+	return u.State == PendingMined || u.State == PendingDropped
+}
+
+// Subscribe returns a channel receiving the transitions of the bundles relayed from now on,
+// and a func to unsubscribe. A subscriber which falls more than buffer updates behind is
+// unsubscribed, and its channel closed.
+func (r *LocalRelayer) Subscribe(buffer int) (<-chan StatusUpdate, func()) {
+	ch := make(chan StatusUpdate, buffer)
+
+	r.muSubscribers.Lock()
+	if r.subscribers == nil {
+		r.subscribers = map[chan StatusUpdate]struct{}{}
+	}
+	r.subscribers[ch] = struct{}{}
+	r.muSubscribers.Unlock()
+
+	return ch, func() {
+		r.muSubscribers.Lock()
+		defer r.muSubscribers.Unlock()
+		if _, ok := r.subscribers[ch]; ok {
+			delete(r.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (r *LocalRelayer) publish(update StatusUpdate) {
+	r.muSubscribers.Lock()
+	defer r.muSubscribers.Unlock()
+	for ch := range r.subscribers {
+		select {
+		case ch <- update:
+		default:
+			delete(r.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// minedStatus returns the status of metaTxnID from the receipt of the transaction it was
+// mined in.
+func minedStatus(metaTxnID sequence.MetaTxnID, receipt *types.Receipt) sequence.MetaTxnStatus {
+	if receipt.Status == types.ReceiptStatusFailed {
+		return sequence.MetaTxnReverted
+	}
+	hash := common.HexToHash(string(metaTxnID))
+	for _, log := range receipt.Logs {
+		if sequence.IsTxExecutedEvent(log, hash) {
+			return sequence.MetaTxnExecuted
+		}
+		if sequence.IsTxFailedEvent(log, hash) {
+			return sequence.MetaTxnFailed
+		}
+	}
+	return sequence.MetaTxnStatusUnknown
+}
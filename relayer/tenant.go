@@ -0,0 +1,328 @@
+package relayer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/0xsequence/ethkit/ethreceipts"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+)
+
+var (
+	ErrUnknownTenant  = fmt.Errorf("relayer: unknown tenant")
+	ErrInvalidAPIKey  = fmt.Errorf("relayer: invalid api key")
+	ErrTenantConflict = fmt.Errorf("relayer: tenant id or api key already registered")
+)
+
+// Tenant is an application served by a MultiTenantRelayer.
+type Tenant struct {
+	// ID uniquely identifies the tenant, and is used as the `tenant` metrics label.
+	ID string
+
+	// APIKey authenticates requests of the tenant to the relayer handlers.
+	APIKey string
+
+	// Sender is the EOA dispatching the tenant's meta-transactions. If nil, the tenant
+	// shares the default sender of the relayer.
+	Sender *ethwallet.Wallet
+
+	// NonceSpace is the meta-transaction nonce space used for the tenant's wallets,
+	// when a nonce is requested without one. Optional.
+	NonceSpace *big.Int
+
+	// Policy is the sponsorship policy and quotas of the tenant. If nil, any
+	// transactions are relayed.
+	Policy *Policy
+
+	// Limits caps the usage of the tenant, enforced if the relayer has an Accountant.
+	Limits *UsageLimits
+
+	// Signers are the EOAs which may sign relay requests on behalf of the tenant.
+	Signers []common.Address
+}
+
+// MultiTenantRelayer serves many applications from one relayer deployment. Each tenant is
+// isolated in its own LocalRelayer, with its own sender EOA or nonce space, policy and
+// metrics labels.
+type MultiTenantRelayer struct {
+	sender          *ethwallet.Wallet
+	receiptListener *ethreceipts.ReceiptsListener
+	opts            []sequence.Option
+	options         sequence.Options
+	accountant      *Accountant
+	senderPool      *SenderPool
+	private         *PrivateSubmission
+	walletContext   *sequence.WalletContext
+
+	tenants  map[string]*tenantRelayer
+	apiKeys  map[[32]byte]string
+	signers  map[common.Address]string
+	muTenant sync.RWMutex
+}
+
+type tenantRelayer struct {
+	tenant  Tenant
+	relayer *LocalRelayer
+}
+
+func NewMultiTenantRelayer(sender *ethwallet.Wallet, receiptListener *ethreceipts.ReceiptsListener, opts ...sequence.Option) (*MultiTenantRelayer, error) {
+	if sender.GetProvider() == nil {
+		return nil, sequence.ErrProviderNotSet
+	}
+	return &MultiTenantRelayer{
+		sender:          sender,
+		receiptListener: receiptListener,
+		opts:            opts,
+		options:         sequence.NewOptions(opts...),
+		tenants:         map[string]*tenantRelayer{},
+		apiKeys:         map[[32]byte]string{},
+		signers:         map[common.Address]string{},
+	}, nil
+}
+
+// AddTenant registers a tenant, its id and api key must be unique.
+func (m *MultiTenantRelayer) AddTenant(tenant Tenant) error {
+	if tenant.ID == "" || tenant.APIKey == "" {
+		return fmt.Errorf("relayer: tenant id and api key are required")
+	}
+
+	m.muTenant.RLock()
+	defaultSender := m.sender
+	m.muTenant.RUnlock()
+
+	sender := tenant.Sender
+	if sender == nil {
+		sender = defaultSender
+	} else if sender.GetProvider() == nil {
+		sender.SetProvider(defaultSender.GetProvider())
+	}
+
+	metrics := sequence.MetricsWithLabels(m.options.Metrics, map[string]string{"tenant": tenant.ID})
+	opts := append(append([]sequence.Option{}, m.opts...), sequence.WithMetrics(metrics))
+
+	relayer, err := NewLocalRelayer(sender, m.receiptListener, opts...)
+	if err != nil {
+		return err
+	}
+	relayer.SetPolicy(tenant.Policy).SetNonceSpace(tenant.NonceSpace)
+
+	m.muTenant.Lock()
+	defer m.muTenant.Unlock()
+
+	apiKey := hashAPIKey(tenant.APIKey)
+	if _, ok := m.tenants[tenant.ID]; ok {
+		return ErrTenantConflict
+	}
+	if _, ok := m.apiKeys[apiKey]; ok {
+		return ErrTenantConflict
+	}
+	for _, signer := range tenant.Signers {
+		if _, ok := m.signers[signer]; ok {
+			return ErrTenantConflict
+		}
+	}
+
+	if m.accountant != nil {
+		relayer.SetAccountant(m.accountant, tenant.ID)
+		m.accountant.SetProjectLimits(tenant.ID, tenant.Limits)
+	}
+	if tenant.Sender == nil {
+		relayer.SetSenderPool(m.senderPool)
+	}
+	relayer.SetPrivateSubmission(m.private)
+	if m.walletContext != nil {
+		relayer.SetWalletContext(*m.walletContext)
+	}
+
+	m.tenants[tenant.ID] = &tenantRelayer{tenant: tenant, relayer: relayer}
+	m.apiKeys[apiKey] = tenant.ID
+	for _, signer := range tenant.Signers {
+		m.signers[signer] = tenant.ID
+	}
+	return nil
+}
+
+func (m *MultiTenantRelayer) RemoveTenant(tenantID string) {
+	m.muTenant.Lock()
+	defer m.muTenant.Unlock()
+
+	t, ok := m.tenants[tenantID]
+	if !ok {
+		return
+	}
+	delete(m.apiKeys, hashAPIKey(t.tenant.APIKey))
+	for _, signer := range t.tenant.Signers {
+		delete(m.signers, signer)
+	}
+	delete(m.tenants, tenantID)
+	if m.accountant != nil {
+		m.accountant.SetProjectLimits(tenantID, nil)
+	}
+}
+
+// SetAccountant sets the accountant tracking the usage of tenants, each tenant is accounted
+// as a project with the tenant id. It must be set before the relayer starts relaying.
+func (m *MultiTenantRelayer) SetAccountant(accountant *Accountant) *MultiTenantRelayer {
+	m.muTenant.Lock()
+	defer m.muTenant.Unlock()
+
+	m.accountant = accountant
+	for id, t := range m.tenants {
+		t.relayer.SetAccountant(accountant, id)
+		if accountant != nil {
+			accountant.SetProjectLimits(id, t.tenant.Limits)
+		}
+	}
+	return m
+}
+
+// SetSenderPool sets the pool of senders shared by the tenants which do not have their own
+// sender. It must be set before the relayer starts relaying.
+func (m *MultiTenantRelayer) SetSenderPool(pool *SenderPool) *MultiTenantRelayer {
+	m.muTenant.Lock()
+	defer m.muTenant.Unlock()
+
+	m.senderPool = pool
+	for _, t := range m.tenants {
+		if t.tenant.Sender == nil {
+			t.relayer.SetSenderPool(pool)
+		}
+	}
+	return m
+}
+
+// SetPrivateSubmission sets the private relays value-sensitive bundles of all tenants are
+// submitted through. It must be set before the relayer starts relaying.
+func (m *MultiTenantRelayer) SetPrivateSubmission(private *PrivateSubmission) *MultiTenantRelayer {
+	m.muTenant.Lock()
+	defer m.muTenant.Unlock()
+
+	m.private = private
+	for _, t := range m.tenants {
+		t.relayer.SetPrivateSubmission(private)
+	}
+	return m
+}
+
+// SetWalletContext sets the context of the wallets the tenants relay execdata of, see
+// LocalRelayer.SetWalletContext.
+func (m *MultiTenantRelayer) SetWalletContext(walletContext sequence.WalletContext) *MultiTenantRelayer {
+	m.muTenant.Lock()
+	defer m.muTenant.Unlock()
+
+	m.walletContext = &walletContext
+	for _, t := range m.tenants {
+		t.relayer.SetWalletContext(walletContext)
+	}
+	return m
+}
+
+// SetSender rotates the default sender of the relayer, used by the tenants which do not have
+// their own sender.
+func (m *MultiTenantRelayer) SetSender(sender *ethwallet.Wallet) error {
+	if sender.GetProvider() == nil {
+		sender.SetProvider(m.sender.GetProvider())
+	}
+
+	m.muTenant.Lock()
+	defer m.muTenant.Unlock()
+
+	for _, t := range m.tenants {
+		if t.tenant.Sender != nil {
+			continue
+		}
+		if err := t.relayer.SetSender(sender); err != nil {
+			return err
+		}
+	}
+	m.sender = sender
+	return nil
+}
+
+// Accountant returns the accountant of the relayer, or nil if usage is not tracked.
+func (m *MultiTenantRelayer) Accountant() *Accountant {
+	m.muTenant.RLock()
+	defer m.muTenant.RUnlock()
+	return m.accountant
+}
+
+// Tenants returns the ids of the registered tenants, in sorted order.
+func (m *MultiTenantRelayer) Tenants() []string {
+	m.muTenant.RLock()
+	defer m.muTenant.RUnlock()
+
+	ids := make([]string, 0, len(m.tenants))
+	for id := range m.tenants {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Relayer returns the relayer of the tenant.
+func (m *MultiTenantRelayer) Relayer(tenantID string) (*LocalRelayer, error) {
+	m.muTenant.RLock()
+	defer m.muTenant.RUnlock()
+
+	t, ok := m.tenants[tenantID]
+	if !ok {
+		return nil, ErrUnknownTenant
+	}
+	return t.relayer, nil
+}
+
+// Authenticate returns the id of the tenant which owns apiKey.
+func (m *MultiTenantRelayer) Authenticate(apiKey string) (string, error) {
+	if apiKey == "" {
+		return "", ErrInvalidAPIKey
+	}
+
+	m.muTenant.RLock()
+	defer m.muTenant.RUnlock()
+
+	// api keys are looked up by their hash, so the lookup time does not depend on
+	// how much of a guessed key matches a registered one
+	tenantID, ok := m.apiKeys[hashAPIKey(apiKey)]
+	if !ok {
+		return "", ErrInvalidAPIKey
+	}
+	return tenantID, nil
+}
+
+// AuthenticateHMAC verifies the mac of digest was keyed with the api key of the tenant,
+// as signed by a SchemeHMAC RequestSigner.
+func (m *MultiTenantRelayer) AuthenticateHMAC(tenantID string, digest, mac []byte) error {
+	m.muTenant.RLock()
+	t, ok := m.tenants[tenantID]
+	m.muTenant.RUnlock()
+	if !ok {
+		return ErrUnknownTenant
+	}
+
+	if !hmac.Equal(HMACRequestDigest(t.tenant.APIKey, digest), mac) {
+		return ErrInvalidAPIKey
+	}
+	return nil
+}
+
+// AuthenticateSigner returns the id of the tenant which signer may sign relay requests for.
+func (m *MultiTenantRelayer) AuthenticateSigner(signer common.Address) (string, error) {
+	m.muTenant.RLock()
+	defer m.muTenant.RUnlock()
+
+	tenantID, ok := m.signers[signer]
+	if !ok {
+		return "", ErrUnknownTenant
+	}
+	return tenantID, nil
+}
+
+func hashAPIKey(apiKey string) [32]byte {
+	return sha256.Sum256([]byte(apiKey))
+}
@@ -0,0 +1,100 @@
+package relayer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+// NativeTx is a signed native transaction of any EIP-2718 type. *types.Transaction
+// implements it, and so can transaction types go-ethereum does not support yet.
+type NativeTx interface {
+	Type() uint8
+	Hash() common.Hash
+	Nonce() uint64
+	Gas() uint64
+	GasPrice() *big.Int
+	To() *common.Address
+	Value() *big.Int
+	Data() []byte
+
+	// MarshalBinary returns the EIP-2718 encoding of the transaction, as sent to nodes.
+	MarshalBinary() ([]byte, error)
+}
+
+var _ NativeTx = &types.Transaction{}
+
+// TxBuilder builds and signs the native transactions a LocalRelayer sends. Implementing it
+// adds support for a new transaction type, without changes to the relayer.
+type TxBuilder interface {
+	// BuildTx returns the transaction described by req signed by sender. The nonce, gas
+	// limit and fees of req are filled in by the builder when they are not set.
+	BuildTx(ctx context.Context, sender *ethwallet.Wallet, chainID *big.Int, req *ethtxn.TransactionRequest) (NativeTx, error)
+}
+
+// DefaultTxBuilder builds legacy transactions, or EIP-1559 dynamic fee transactions if the
+// request has a gas tip, as ethtxn does.
+type DefaultTxBuilder struct{}
+
+var _ TxBuilder = DefaultTxBuilder{}
+
+func (DefaultTxBuilder) BuildTx(ctx context.Context, sender *ethwallet.Wallet, chainID *big.Int, req *ethtxn.TransactionRequest) (NativeTx, error) {
+	ntx, err := sender.NewTransaction(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	signedTx, err := sender.SignTx(ntx, chainID)
+	if err != nil {
+		return nil, err
+	}
+	return signedTx, nil
+}
+
+// SetTxBuilder sets the builder of the native transactions of the relayer, DefaultTxBuilder
+// if nil. Relay only returns the native transaction if it is a *types.Transaction. It must be
+// set before the relayer starts relaying.
+func (r *LocalRelayer) SetTxBuilder(builder TxBuilder) *LocalRelayer {
+	r.txBuilder = builder
+	return r
+}
+
+func (r *LocalRelayer) getTxBuilder() TxBuilder {
+	if r.txBuilder == nil {
+		return DefaultTxBuilder{}
+	}
+	return r.txBuilder
+}
+
+// sendNativeTx broadcasts a signed transaction of any type through provider.
+func sendNativeTx(ctx context.Context, provider *ethrpc.Provider, tx NativeTx) (ethtxn.WaitReceipt, error) {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := provider.SendRawTransaction(ctx, hexutil.Encode(raw)); err != nil {
+		return nil, fmt.Errorf("relayer: failed to send txn %s: %w", tx.Hash().Hex(), err)
+	}
+	waitReceipt := func(ctx context.Context) (*types.Receipt, error) {
+		return ethrpc.WaitForTxnReceipt(ctx, provider, tx.Hash())
+	}
+	return waitReceipt, nil
+}
+
+// requestFor returns the request building a replacement of tx, with the same nonce.
+func requestFor(tx NativeTx, gasPrice *big.Int) *ethtxn.TransactionRequest {
+	return &ethtxn.TransactionRequest{
+		To:       tx.To(),
+		Nonce:    new(big.Int).SetUint64(tx.Nonce()),
+		GasLimit: tx.Gas(),
+		GasPrice: gasPrice,
+		ETHValue: tx.Value(),
+		Data:     tx.Data(),
+	}
+}
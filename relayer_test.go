@@ -4,11 +4,17 @@ import (
 	"context"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/0xsequence/ethkit/go-ethereum/core/types"
 	"github.com/0xsequence/go-sequence"
 	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/assertions"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -99,6 +105,25 @@ func TestGetReceiptOfErrorTransaction(t *testing.T) {
 	assert.Equal(t, sequence.MetaTxnFailed, result.Status)
 }
 
+func TestScenarioOfErrorTransaction(t *testing.T) {
+	testChain.Scenario().
+		DeployWallet(1).
+		// Turn on revert flag on callmock
+		Deploy("callmock", "WALLET_CALL_RECV_MOCK").
+		Call("callmock", "setRevertFlag", true).
+		ExpectExecuted().
+		// Call callmock, this should revert and fail the transaction
+		Call("callmock", "testCall", big.NewInt(55), ethcoder.MustHexDecode("0x112255")).
+		ExpectFail("CallReceiverMock#testCall: REVERT_FLAG").
+		Step("InnerCallReverted", func(t *testing.T, state *testutil.ScenarioState) {
+			if assert.NotNil(t, state.Receipt) {
+				assert.Equal(t, types.ReceiptStatusSuccessful, state.Receipt.Status) // native txn was successful
+				assertions.AssertInnerCallReverted(t, testChain.Provider, state.Receipt, 0, "REVERT_FLAG")
+			}
+		}).
+		Run(t)
+}
+
 func TestGetReceiptOfFailedTransactionBetweenTransactions(t *testing.T) {
 	// Ensure dummy sequence wallet from seed 1 is deployed
 	wallet, err := testChain.DummySequenceWallet(1)
@@ -242,3 +267,150 @@ func TestGetReceiptOfTransactionBetweenTransactions(t *testing.T) {
 	assert.NotNil(t, receipt)
 	assert.Equal(t, sequence.MetaTxnExecuted, result.Status)
 }
+
+func TestAssertMetaTxnOutcomes(t *testing.T) {
+	wallet, err := testChain.DummySequenceWallet(1)
+	assert.NoError(t, err)
+
+	callmockContract, _ := testChain.Deploy(t, "WALLET_CALL_RECV_MOCK")
+	calldata, err := callmockContract.Encode("testCall", big.NewInt(55), ethcoder.MustHexDecode("0x112255"))
+	assert.NoError(t, err)
+
+	send := func() sequence.MetaTxnID {
+		signedTx, err := wallet.SignTransaction(context.Background(), &sequence.Transaction{To: callmockContract.Address, Data: calldata, GasLimit: big.NewInt(190000)})
+		assert.NoError(t, err)
+		metaTxnID, _, _, err := wallet.SendTransaction(context.Background(), signedTx)
+		assert.NoError(t, err)
+		return metaTxnID
+	}
+
+	receipt := assertions.AssertMetaTxnExecuted(t, testChain.ReceiptsListener, send())
+	assert.NotNil(t, receipt)
+
+	// once the revert flag is on, the call reverts and fails the meta-transaction
+	revertFlagData, err := callmockContract.Encode("setRevertFlag", true)
+	assert.NoError(t, err)
+	assert.NoError(t, testutil.SignAndSend(t, wallet, callmockContract.Address, revertFlagData))
+
+	receipt = assertions.AssertMetaTxnFailed(t, testChain.ReceiptsListener, send(), "REVERT_FLAG")
+	if assert.NotNil(t, receipt) {
+		assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status) // native txn was successful
+		assertions.AssertInnerCallReverted(t, testChain.Provider, receipt, 0, "REVERT_FLAG")
+	}
+}
+
+func TestWaitForMany(t *testing.T) {
+	ctx := context.Background()
+
+	wallets, err := testChain.DummySequenceWallets(2, 1)
+	assert.NoError(t, err)
+
+	callmockContract, _ := testChain.Deploy(t, "WALLET_CALL_RECV_MOCK")
+	calldata, err := callmockContract.Encode("testCall", big.NewInt(55), ethcoder.MustHexDecode("0x112255"))
+	assert.NoError(t, err)
+
+	// the second bundle calls a callmock which reverts, and fails
+	revertingContract, _ := testChain.Deploy(t, "WALLET_CALL_RECV_MOCK")
+	revertFlagData, err := revertingContract.Encode("setRevertFlag", true)
+	assert.NoError(t, err)
+	assert.NoError(t, testutil.SignAndSend(t, wallets[1], revertingContract.Address, revertFlagData))
+
+	var metaTxnIDs []sequence.MetaTxnID
+	for i, to := range []common.Address{callmockContract.Address, revertingContract.Address} {
+		signedTx, err := wallets[i].SignTransaction(ctx, &sequence.Transaction{To: to, Data: calldata, GasLimit: big.NewInt(190000)})
+		assert.NoError(t, err)
+		metaTxnID, _, _, err := wallets[i].SendTransaction(ctx, signedTx)
+		assert.NoError(t, err)
+		metaTxnIDs = append(metaTxnIDs, metaTxnID)
+	}
+
+	// a meta-transaction which is never mined is reported once the wait times out
+	unknown := sequence.MetaTxnID(common.HexToHash("0xabcd").Hex()[2:])
+
+	results := map[sequence.MetaTxnID]sequence.WaitResult{}
+	for result := range sequence.WaitForMany(ctx, testChain.ReceiptsListener, testChain.Provider, append(metaTxnIDs, unknown), sequence.WaitOptions{}, 10*time.Second) {
+		results[result.MetaTxnID] = result
+	}
+	assert.Len(t, results, 3)
+
+	assert.NoError(t, results[metaTxnIDs[0]].Err)
+	assert.Equal(t, sequence.MetaTxnExecuted, results[metaTxnIDs[0]].Result.Status)
+	assert.NotNil(t, results[metaTxnIDs[0]].Receipt)
+
+	assert.NoError(t, results[metaTxnIDs[1]].Err)
+	assert.Equal(t, sequence.MetaTxnFailed, results[metaTxnIDs[1]].Result.Status)
+
+	assert.ErrorIs(t, results[unknown].Err, context.DeadlineExceeded)
+}
+
+// providerRelayer is a relayer of a provider, which reports whether wallets are deployed.
+type providerRelayer struct {
+	nonceRelayer
+	provider *ethrpc.Provider
+}
+
+func (r *providerRelayer) GetProvider() *ethrpc.Provider {
+	return r.provider
+}
+
+func TestBuildDeployAndExecute(t *testing.T) {
+	ctx := context.Background()
+	owner, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(owner)
+	assert.NoError(t, err)
+	wallet.SetChainID(big.NewInt(1))
+
+	chain := memchain.New(memchain.Options{ChainID: big.NewInt(1)})
+	provider := chain.Provider()
+	relayer := &providerRelayer{nonceRelayer: nonceRelayer{nonce: big.NewInt(0)}, provider: provider}
+	assert.NoError(t, wallet.Connect(provider, relayer))
+
+	txns := sequence.Transactions{{To: common.HexToAddress("0x7a7"), Value: big.NewInt(1), RevertOnError: true}}
+	signed, err := wallet.SignTransactions(ctx, txns)
+	assert.NoError(t, err)
+
+	// a counterfactual wallet is deployed by the guest module, then executes the transactions
+	to, execdata, err := wallet.BuildDeployAndExecute(ctx, signed)
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.GetWalletContext().GuestModuleAddress, to)
+
+	bundle, nonce, signature, err := sequence.DecodeExecdata(execdata)
+	assert.NoError(t, err)
+	assert.Zero(t, nonce.Sign())
+	assert.Empty(t, signature)
+	assert.Len(t, bundle, 2)
+
+	walletAddress, factoryAddress, deployData, err := sequence.EncodeWalletDeployment(wallet.GetWalletConfig(), wallet.GetWalletContext())
+	assert.NoError(t, err)
+	assert.Equal(t, factoryAddress, bundle[0].To)
+	assert.Equal(t, deployData, bundle[0].Data)
+	assert.True(t, bundle[0].RevertOnError)
+	assert.Equal(t, walletAddress, bundle[1].To)
+	assert.True(t, bundle[1].RevertOnError)
+	assert.True(t, signed.Transactions.Equal(bundle[1].Transactions))
+	assert.Zero(t, signed.Nonce.Cmp(bundle[1].Nonce))
+	assert.Equal(t, signed.Signature, bundle[1].Signature)
+
+	// which is how the transactions are relayed
+	relayTo, relayExecdata, err := sequence.EncodeTransactionsForRelaying(relayer, signed.WalletConfig, signed.WalletContext, signed.Transactions, signed.Nonce, signed.Signature)
+	assert.NoError(t, err)
+	assert.Equal(t, to, relayTo)
+	assert.Equal(t, execdata, relayExecdata)
+
+	// once deployed, the transactions are sent to the wallet
+	chain.SetCode(wallet.Address(), common.FromHex(sequence.WalletContractBytecode))
+	to, execdata, err = wallet.BuildDeployAndExecute(ctx, signed)
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.Address(), to)
+	executed, nonce, signature, err := sequence.DecodeExecdata(execdata)
+	assert.NoError(t, err)
+	assert.True(t, signed.Transactions.Equal(executed))
+	assert.Zero(t, signed.Nonce.Cmp(nonce))
+	assert.Equal(t, signed.Signature, signature)
+
+	relayTo, relayExecdata, err = sequence.EncodeTransactionsForRelaying(relayer, signed.WalletConfig, signed.WalletContext, signed.Transactions, signed.Nonce, signed.Signature)
+	assert.NoError(t, err)
+	assert.Equal(t, to, relayTo)
+	assert.Equal(t, execdata, relayExecdata)
+}
@@ -0,0 +1,390 @@
+// Package replay re-executes historical meta-transactions against a fork of their chain, for
+// post-mortems. The native transaction which carried a bundle is sent again on a fork at the
+// block before it was mined, and the outcome, logs and state changes of the replay are
+// compared with what happened on-chain:
+//
+//	report, _ := replay.MetaTxn(ctx, metaTxnID, replay.Options{
+//		Wallet:      wallet,
+//		UpstreamURL: "https://archive.node",
+//		Fork:        anvil,
+//	})
+//	for _, diff := range report.Logs { ... }
+//
+// The fork is at the state of the end of the parent block, so the transactions mined before
+// the bundle in its own block are not applied, which is a cause of divergence on its own.
+package replay
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/testutil"
+)
+
+// Options of a replay.
+type Options struct {
+	// Wallet is the wallet which executed the meta-transaction, connected to the upstream
+	// chain, which the meta-transaction is located on with Wallet.FindMetaTxnReceipt.
+	Wallet *sequence.Wallet
+
+	// UpstreamURL is the url of the node the fork is forked from, which must serve the
+	// state of past blocks, ie. an archive node.
+	UpstreamURL string
+
+	// Fork is the test chain the meta-transaction is replayed on, which must be able to fork,
+	// see testutil.TestChain.Fork.
+	Fork *testutil.TestChain
+
+	// ReceiptTimeout bounds the wait for the replayed transaction to be mined. Defaults to
+	// 30 seconds.
+	ReceiptTimeout time.Duration
+}
+
+// Report compares the replay of a meta-transaction with what happened on-chain.
+type Report struct {
+	MetaTxnID sequence.MetaTxnID
+
+	// ForkBlock is the block the chain was forked at, the parent of the block the
+	// meta-transaction was mined in.
+	ForkBlock uint64
+
+	Original Outcome
+	Replayed Outcome
+
+	// Logs are the logs which differ, and State the state changes which differ.
+	Logs  []LogDiff
+	State []StateDiff
+
+	// StateErr is why the state changes could not be compared, ie. as a node does not serve
+	// debug_traceTransaction, in which case State is empty.
+	StateErr error
+}
+
+// Diverged returns true if the replay differs from what happened on-chain.
+func (r *Report) Diverged() bool {
+	return r.Original.Status != r.Replayed.Status ||
+		r.Original.Reason != r.Replayed.Reason ||
+		r.Original.Receipt.Status != r.Replayed.Receipt.Status ||
+		len(r.Logs) > 0 ||
+		len(r.State) > 0
+}
+
+// Outcome is the result of a run of a meta-transaction.
+type Outcome struct {
+	Status  sequence.MetaTxnStatus
+	Reason  string
+	Receipt *types.Receipt
+}
+
+// LogDiff is a log at Index of the receipts which differs. Original or Replayed is nil if the
+// log was only emitted by the other run.
+type LogDiff struct {
+	Index    int
+	Original *types.Log
+	Replayed *types.Log
+}
+
+// StateDiff is a field of an account which was left in a different state by the runs, where
+// Field is one of "balance", "nonce", "code" or "storage", and Slot is the storage slot.
+type StateDiff struct {
+	Address  common.Address
+	Field    string
+	Slot     *common.Hash
+	Original string
+	Replayed string
+}
+
+// MetaTxn replays metaTxnID of opts.Wallet on opts.Fork, forked at the block before the one it
+// was mined in, and reports the differences with the original run.
+func MetaTxn(ctx context.Context, metaTxnID sequence.MetaTxnID, opts Options) (*Report, error) {
+	upstream := opts.Wallet.GetProvider()
+	if upstream == nil {
+		return nil, fmt.Errorf("replay: %w", sequence.ErrProviderNotSet)
+	}
+
+	_, receipt, err := opts.Wallet.FindMetaTxnReceipt(ctx, metaTxnID)
+	if err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+
+	var txn *transaction
+	if err := upstream.Do(ctx, ethrpc.NewCallBuilder[*transaction]("eth_getTransactionByHash", nil, receipt.TxHash).Into(&txn)); err != nil {
+		return nil, fmt.Errorf("replay: failed to get txn %v: %w", receipt.TxHash.Hex(), err)
+	}
+	if txn == nil {
+		return nil, fmt.Errorf("replay: txn %v not found", receipt.TxHash.Hex())
+	}
+
+	report := &Report{
+		MetaTxnID: metaTxnID,
+		ForkBlock: receipt.BlockNumber.Uint64() - 1,
+		Original:  outcomeOf(metaTxnID, receipt),
+	}
+
+	if err := opts.Fork.Fork(ctx, opts.UpstreamURL, report.ForkBlock); err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+	if err := opts.Fork.Impersonate(ctx, txn.From); err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+
+	var replayHash common.Hash
+	send := &sendTransaction{From: txn.From, To: txn.To, Input: txn.Input, Value: txn.Value, Gas: txn.Gas}
+	if err := opts.Fork.Provider.Do(ctx, ethrpc.NewCallBuilder[common.Hash]("eth_sendTransaction", nil, send).Into(&replayHash)); err != nil {
+		return nil, fmt.Errorf("replay: failed to send txn: %w", err)
+	}
+	replayed, err := waitReceipt(ctx, opts.Fork.Provider, replayHash, opts.ReceiptTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+	report.Replayed = outcomeOf(metaTxnID, replayed)
+
+	report.Logs = DiffLogs(receipt.Logs, replayed.Logs)
+
+	original, err := traceStateChanges(ctx, upstream, receipt.TxHash)
+	if err == nil {
+		var replayedChanges *stateChanges
+		replayedChanges, err = traceStateChanges(ctx, opts.Fork.Provider, replayHash)
+		if err == nil {
+			report.State = diffState(original, replayedChanges)
+		}
+	}
+	report.StateErr = err
+
+	return report, nil
+}
+
+// DiffLogs returns the logs which differ in their address, topics or data between the
+// original and the replayed run.
+func DiffLogs(original, replayed []*types.Log) []LogDiff {
+	n := len(original)
+	if len(replayed) > n {
+		n = len(replayed)
+	}
+
+	var diffs []LogDiff
+	for i := 0; i < n; i++ {
+		diff := LogDiff{Index: i}
+		if i < len(original) {
+			diff.Original = original[i]
+		}
+		if i < len(replayed) {
+			diff.Replayed = replayed[i]
+		}
+		if diff.Original != nil && diff.Replayed != nil && sameLog(diff.Original, diff.Replayed) {
+			continue
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs
+}
+
+func sameLog(a, b *types.Log) bool {
+	if a.Address != b.Address || len(a.Topics) != len(b.Topics) || !bytes.Equal(a.Data, b.Data) {
+		return false
+	}
+	for i := range a.Topics {
+		if a.Topics[i] != b.Topics[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func outcomeOf(metaTxnID sequence.MetaTxnID, receipt *types.Receipt) Outcome {
+	outcome := Outcome{Receipt: receipt}
+	metaTxnHash := common.HexToHash(string(metaTxnID))
+	for _, log := range receipt.Logs {
+		if sequence.IsTxExecutedEvent(log, metaTxnHash) {
+			outcome.Status = sequence.MetaTxnExecuted
+			break
+		}
+		if sequence.IsTxFailedEvent(log, metaTxnHash) {
+			outcome.Status = sequence.MetaTxnFailed
+			_, outcome.Reason, _ = sequence.DecodeTxFailedEvent(log)
+		}
+	}
+	return outcome
+}
+
+// transaction is a native transaction fetched for the fields it is replayed with, decoded
+// from json as any transaction type may be replayed.
+type transaction struct {
+	From  common.Address  `json:"from"`
+	To    *common.Address `json:"to"`
+	Input hexutil.Bytes   `json:"input"`
+	Value *hexutil.Big    `json:"value"`
+	Gas   hexutil.Uint64  `json:"gas"`
+}
+
+type sendTransaction struct {
+	From  common.Address  `json:"from"`
+	To    *common.Address `json:"to,omitempty"`
+	Input hexutil.Bytes   `json:"data"`
+	Value *hexutil.Big    `json:"value,omitempty"`
+	Gas   hexutil.Uint64  `json:"gas"`
+}
+
+const (
+	defaultReceiptTimeout = 30 * time.Second
+	receiptPollInterval   = 250 * time.Millisecond
+)
+
+func waitReceipt(ctx context.Context, provider *ethrpc.Provider, txnHash common.Hash, timeout time.Duration) (*types.Receipt, error) {
+	if timeout <= 0 {
+		timeout = defaultReceiptTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		receipt, err := provider.TransactionReceipt(ctx, txnHash)
+		if err == nil && receipt != nil {
+			return receipt, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("replayed txn %v was not mined: %w", txnHash.Hex(), ctx.Err())
+		case <-time.After(receiptPollInterval):
+		}
+	}
+}
+
+// stateChanges are the accounts of the state before and after a transaction, as traced by
+// the prestateTracer in diff mode. Post only has the fields which changed.
+type stateChanges struct {
+	Pre  map[common.Address]*account `json:"pre"`
+	Post map[common.Address]*account `json:"post"`
+}
+
+type account struct {
+	Balance *hexutil.Big                `json:"balance,omitempty"`
+	Nonce   *uint64                     `json:"nonce,omitempty"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+type tracerConfig struct {
+	Tracer       string          `json:"tracer"`
+	TracerConfig map[string]bool `json:"tracerConfig"`
+}
+
+func traceStateChanges(ctx context.Context, provider *ethrpc.Provider, txnHash common.Hash) (*stateChanges, error) {
+	var changes *stateChanges
+	config := tracerConfig{Tracer: "prestateTracer", TracerConfig: map[string]bool{"diffMode": true}}
+	if err := provider.Do(ctx, ethrpc.NewCallBuilder[*stateChanges]("debug_traceTransaction", nil, txnHash, config).Into(&changes)); err != nil {
+		return nil, fmt.Errorf("replay: failed to trace state changes of %v: %w", txnHash.Hex(), err)
+	}
+	if changes == nil {
+		return nil, fmt.Errorf("replay: no state changes traced for %v", txnHash.Hex())
+	}
+	return changes, nil
+}
+
+// diffState returns the fields of the accounts touched by either run which were left in a
+// different state.
+func diffState(original, replayed *stateChanges) []StateDiff {
+	addresses := map[common.Address]struct{}{}
+	for _, changes := range []*stateChanges{original, replayed} {
+		for address := range changes.Pre {
+			addresses[address] = struct{}{}
+		}
+		for address := range changes.Post {
+			addresses[address] = struct{}{}
+		}
+	}
+
+	var diffs []StateDiff
+	for address := range addresses {
+		a, b := original.after(address), replayed.after(address)
+
+		if s, t := bigString(a.Balance), bigString(b.Balance); s != t {
+			diffs = append(diffs, StateDiff{Address: address, Field: "balance", Original: s, Replayed: t})
+		}
+		if s, t := nonceString(a.Nonce), nonceString(b.Nonce); s != t {
+			diffs = append(diffs, StateDiff{Address: address, Field: "nonce", Original: s, Replayed: t})
+		}
+		if !bytes.Equal(a.Code, b.Code) {
+			diffs = append(diffs, StateDiff{Address: address, Field: "code", Original: a.Code.String(), Replayed: b.Code.String()})
+		}
+
+		slots := map[common.Hash]struct{}{}
+		for slot := range a.Storage {
+			slots[slot] = struct{}{}
+		}
+		for slot := range b.Storage {
+			slots[slot] = struct{}{}
+		}
+		for slot := range slots {
+			if a.Storage[slot] != b.Storage[slot] {
+				slot := slot
+				diffs = append(diffs, StateDiff{Address: address, Field: "storage", Slot: &slot, Original: a.Storage[slot].Hex(), Replayed: b.Storage[slot].Hex()})
+			}
+		}
+	}
+
+	sortStateDiffs(diffs)
+	return diffs
+}
+
+func sortStateDiffs(diffs []StateDiff) {
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].Address != diffs[j].Address {
+			return bytes.Compare(diffs[i].Address[:], diffs[j].Address[:]) < 0
+		}
+		if diffs[i].Field != diffs[j].Field {
+			return diffs[i].Field < diffs[j].Field
+		}
+		if diffs[i].Slot == nil || diffs[j].Slot == nil {
+			return diffs[j].Slot != nil
+		}
+		return bytes.Compare(diffs[i].Slot[:], diffs[j].Slot[:]) < 0
+	})
+}
+
+// after returns the state of address after the transaction, where the fields which did not
+// change are the ones before it.
+func (c *stateChanges) after(address common.Address) *account {
+	state := &account{Storage: map[common.Hash]common.Hash{}}
+	for _, a := range []*account{c.Pre[address], c.Post[address]} {
+		if a == nil {
+			continue
+		}
+		if a.Balance != nil {
+			state.Balance = a.Balance
+		}
+		if a.Nonce != nil {
+			state.Nonce = a.Nonce
+		}
+		if a.Code != nil {
+			state.Code = a.Code
+		}
+		for slot, value := range a.Storage {
+			state.Storage[slot] = value
+		}
+	}
+	return state
+}
+
+func bigString(b *hexutil.Big) string {
+	if b == nil {
+		return "0"
+	}
+	return (*big.Int)(b).String()
+}
+
+func nonceString(nonce *uint64) string {
+	if nonce == nil {
+		return "0"
+	}
+	return fmt.Sprint(*nonce)
+}
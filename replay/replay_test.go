@@ -0,0 +1,29 @@
+package replay_test
+
+import (
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/replay"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffLogs(t *testing.T) {
+	wallet := common.HexToAddress("0xf0ba65550f2d1dccf4b131b774844dc3d801d886")
+	nonceChange := &types.Log{Address: wallet, Topics: []common.Hash{sequence.NonceChangeEventSig}, Data: common.Hash{}.Bytes()}
+	executed := &types.Log{Address: wallet, Data: common.HexToHash("0x01").Bytes()}
+	failed := &types.Log{Address: wallet, Topics: []common.Hash{sequence.TxFailedEventSig}, Data: common.HexToHash("0x01").Bytes()}
+
+	// logs are compared by content, not by block or transaction
+	replayedNonceChange := *nonceChange
+	replayedNonceChange.BlockNumber = 100
+	assert.Empty(t, replay.DiffLogs([]*types.Log{nonceChange, executed}, []*types.Log{&replayedNonceChange, executed}))
+
+	diffs := replay.DiffLogs([]*types.Log{nonceChange, executed}, []*types.Log{nonceChange, failed, executed})
+	assert.Equal(t, []replay.LogDiff{
+		{Index: 1, Original: executed, Replayed: failed},
+		{Index: 2, Replayed: executed},
+	}, diffs)
+}
@@ -0,0 +1,138 @@
+// Package replayguard protects backends validating off-chain messages signed by wallets,
+// ie. one-time vouchers, from replays of their signatures. A Registry verifies the signature
+// of a message and records it as consumed in one step, so a message is accepted once, even by
+// concurrent validators sharing the registry's store:
+//
+//	registry := replayguard.NewRegistry(replayguard.NewSQLStore(db), provider, chainID, sequence.SequenceContext())
+//	err := registry.Verify(ctx, replayguard.Message{Wallet: wallet, Digest: digest, Signature: sig, ExpiresAt: expiry})
+//	if errors.Is(err, replayguard.ErrReplayed) {
+//		// the voucher was already redeemed
+//	}
+package replayguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+)
+
+var (
+	ErrReplayed         = errors.New("replayguard: message was already consumed")
+	ErrExpired          = errors.New("replayguard: message expired")
+	ErrInvalidSignature = errors.New("replayguard: invalid signature")
+)
+
+// Message is an off-chain message signed by Wallet, whose Digest is what was signed, ie. a
+// sequence.MessageDigest or an EIP-712 typed data digest.
+type Message struct {
+	Wallet    common.Address
+	Digest    common.Hash
+	Signature []byte
+
+	// ExpiresAt is when the message expires, after which it is rejected, and its record can
+	// be pruned. Zero never expires, and its record is kept forever.
+	ExpiresAt time.Time
+}
+
+// Validator reports whether signature is a valid signature of digest by wallet.
+type Validator func(ctx context.Context, wallet common.Address, digest common.Hash, signature []byte) (bool, error)
+
+// Registry verifies messages signed by wallets and consumes them, once.
+type Registry struct {
+	store     Store
+	chainID   *big.Int
+	validator Validator
+	now       func() time.Time
+}
+
+// NewRegistry returns a registry of the messages of the wallets of walletContext on the chain
+// of chainID, whose signatures are validated with provider, see sequence.IsValidSignatureWith.
+func NewRegistry(store Store, provider *ethrpc.Provider, chainID *big.Int, walletContext sequence.WalletContext) *Registry {
+	return &Registry{
+		store:   store,
+		chainID: chainID,
+		validator: func(ctx context.Context, wallet common.Address, digest common.Hash, signature []byte) (bool, error) {
+			return sequence.IsValidSignatureWith(ctx, provider, walletContext, wallet, digest, signature)
+		},
+		now: time.Now,
+	}
+}
+
+// SetValidator replaces how signatures are validated, ie. with tracker.IsValidSignature for
+// wallets whose config was updated.
+func (r *Registry) SetValidator(validator Validator) *Registry {
+	r.validator = validator
+	return r
+}
+
+// SetClock replaces the clock messages expire by, ie. in tests.
+func (r *Registry) SetClock(now func() time.Time) *Registry {
+	r.now = now
+	return r
+}
+
+// Verify validates the signature of msg and consumes it. It returns ErrInvalidSignature if
+// the signature is invalid, ErrExpired if the message expired, and ErrReplayed if it was
+// already consumed. Messages which fail validation are not consumed.
+func (r *Registry) Verify(ctx context.Context, msg Message) error {
+	if !msg.ExpiresAt.IsZero() && !r.now().Before(msg.ExpiresAt) {
+		return fmt.Errorf("%w: at %v", ErrExpired, msg.ExpiresAt)
+	}
+
+	valid, err := r.validator(ctx, msg.Wallet, msg.Digest, msg.Signature)
+	if !valid {
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+		return ErrInvalidSignature
+	}
+
+	return r.Consume(ctx, msg.Wallet, msg.Digest, msg.ExpiresAt)
+}
+
+// Consume consumes a message whose signature was validated by the caller, returning
+// ErrReplayed if it was already consumed.
+func (r *Registry) Consume(ctx context.Context, wallet common.Address, digest common.Hash, expiresAt time.Time) error {
+	key, err := r.key(wallet, digest)
+	if err != nil {
+		return err
+	}
+	ok, err := r.store.Consume(ctx, key, expiresAt)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: %v of wallet %v", ErrReplayed, digest.Hex(), wallet.Hex())
+	}
+	return nil
+}
+
+// Consumed reports whether the message of wallet with digest was consumed.
+func (r *Registry) Consumed(ctx context.Context, wallet common.Address, digest common.Hash) (bool, error) {
+	key, err := r.key(wallet, digest)
+	if err != nil {
+		return false, err
+	}
+	return r.store.Consumed(ctx, key)
+}
+
+// Prune removes the records of the expired messages from the store.
+func (r *Registry) Prune(ctx context.Context) error {
+	return r.store.Prune(ctx, r.now())
+}
+
+// key is the sub-digest of the message, which is the digest the wallet actually signs, so a
+// message is consumed per wallet and chain.
+func (r *Registry) key(wallet common.Address, digest common.Hash) (common.Hash, error) {
+	subDigest, err := sequence.SubDigest(r.chainID, wallet, digest)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("replayguard: %w", err)
+	}
+	return common.BytesToHash(subDigest), nil
+}
@@ -0,0 +1,92 @@
+package replayguard_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/replayguard"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+
+	// a chain on which no wallet is deployed
+	provider := memchain.New(memchain.Options{ChainID: big.NewInt(1)}).Provider()
+
+	owner, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(owner)
+	assert.NoError(t, err)
+
+	chainID := big.NewInt(1)
+	now := time.Unix(1700000000, 0)
+	registry := replayguard.NewRegistry(replayguard.NewMemoryStore(), provider, chainID, wallet.GetWalletContext()).SetClock(func() time.Time { return now })
+
+	sign := func(voucher string, expiresAt time.Time) replayguard.Message {
+		digest := sequence.MessageDigest([]byte(voucher))
+		sig, _, err := wallet.SignDigest(digest, chainID)
+		assert.NoError(t, err)
+		return replayguard.Message{Wallet: wallet.Address(), Digest: digest, Signature: sig, ExpiresAt: expiresAt}
+	}
+
+	// a message is accepted once
+	msg := sign("voucher 1", now.Add(time.Hour))
+	assert.NoError(t, registry.Verify(ctx, msg))
+	err = registry.Verify(ctx, msg)
+	assert.True(t, errors.Is(err, replayguard.ErrReplayed), err)
+
+	consumed, err := registry.Consumed(ctx, msg.Wallet, msg.Digest)
+	assert.NoError(t, err)
+	assert.True(t, consumed)
+
+	// an invalid signature is rejected, and doesn't consume the message
+	forged := sign("voucher 2", time.Time{})
+	forged.Signature = sign("voucher 3", time.Time{}).Signature
+	err = registry.Verify(ctx, forged)
+	assert.True(t, errors.Is(err, replayguard.ErrInvalidSignature), err)
+	consumed, err = registry.Consumed(ctx, forged.Wallet, forged.Digest)
+	assert.NoError(t, err)
+	assert.False(t, consumed)
+
+	// an expired message is rejected
+	err = registry.Verify(ctx, sign("voucher 4", now))
+	assert.True(t, errors.Is(err, replayguard.ErrExpired), err)
+
+	// of concurrent verifications of a message, one is accepted
+	msg = sign("voucher 5", time.Time{})
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	accepted := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if registry.Verify(ctx, msg) == nil {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, accepted)
+
+	// the expired records are pruned, and those which never expire are kept
+	now = now.Add(2 * time.Hour)
+	assert.NoError(t, registry.Prune(ctx))
+	consumed, err = registry.Consumed(ctx, wallet.Address(), sequence.MessageDigest([]byte("voucher 1")))
+	assert.NoError(t, err)
+	assert.False(t, consumed)
+	consumed, err = registry.Consumed(ctx, msg.Wallet, msg.Digest)
+	assert.NoError(t, err)
+	assert.True(t, consumed)
+}
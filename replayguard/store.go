@@ -0,0 +1,161 @@
+package replayguard
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// Store records the keys of consumed messages.
+//
+// MemoryStore and SQLStore are provided, and other databases plug in by implementing Store,
+// ie. redis with SET NX. A cachestore.Store cannot implement it, as it has no atomic
+// check-and-set.
+type Store interface {
+	// Consume marks key as consumed until expiresAt, or forever if it is zero, and reports
+	// whether it was not consumed before. It must be atomic, so that of concurrent calls
+	// with the same key, only one reports true.
+	Consume(ctx context.Context, key common.Hash, expiresAt time.Time) (bool, error)
+
+	// Consumed reports whether key is consumed.
+	Consumed(ctx context.Context, key common.Hash) (bool, error)
+
+	// Prune removes the keys which expired before now, as their messages are rejected as
+	// expired anyway.
+	Prune(ctx context.Context, now time.Time) error
+}
+
+// MemoryStore is a Store in memory, which only protects the validators of a single process.
+type MemoryStore struct {
+	consumed map[common.Hash]time.Time
+	mu       sync.Mutex
+}
+
+var _ Store = &MemoryStore{}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{consumed: map[common.Hash]time.Time{}}
+}
+
+func (s *MemoryStore) Consume(ctx context.Context, key common.Hash, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.consumed[key]; ok {
+		return false, nil
+	}
+	s.consumed[key] = expiresAt
+	return true, nil
+}
+
+func (s *MemoryStore) Consumed(ctx context.Context, key common.Hash) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.consumed[key]
+	return ok, nil
+}
+
+func (s *MemoryStore) Prune(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, expiresAt := range s.consumed {
+		if !expiresAt.IsZero() && expiresAt.Before(now) {
+			delete(s.consumed, key)
+		}
+	}
+	return nil
+}
+
+// SQLStore is a Store in the table sequence_consumed_messages of a SQL database, which
+// CreateTables creates. Consume relies on the primary key of the table to be atomic. It only
+// uses portable SQL, and the driver of the database is left to the caller, ie. sqlite, mysql
+// or postgres.
+type SQLStore struct {
+	db     *sql.DB
+	dollar bool
+}
+
+var _ Store = &SQLStore{}
+
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: db}
+}
+
+// UseDollarPlaceholders binds the arguments of queries to $1, $2... as postgres does,
+// instead of to ?.
+func (s *SQLStore) UseDollarPlaceholders() *SQLStore {
+	s.dollar = true
+	return s
+}
+
+// CreateTables creates the table of the store, if it doesn't exist.
+func (s *SQLStore) CreateTables(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS sequence_consumed_messages (message_key VARCHAR(66) PRIMARY KEY, expires_at BIGINT NOT NULL)`)
+	if err != nil {
+		return fmt.Errorf("replayguard: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) Consume(ctx context.Context, key common.Hash, expiresAt time.Time) (bool, error) {
+	var expiry int64
+	if !expiresAt.IsZero() {
+		expiry = expiresAt.Unix()
+	}
+
+	// the syntax of inserts ignoring conflicts isn't portable, so a failed insert is told
+	// apart from a consumed key by looking the key up
+	_, insertErr := s.db.ExecContext(ctx, s.bind(`INSERT INTO sequence_consumed_messages (message_key, expires_at) VALUES (?, ?)`), key.Hex(), expiry)
+	if insertErr == nil {
+		return true, nil
+	}
+	if consumed, err := s.Consumed(ctx, key); err != nil || !consumed {
+		return false, fmt.Errorf("replayguard: %w", insertErr)
+	}
+	return false, nil
+}
+
+func (s *SQLStore) Consumed(ctx context.Context, key common.Hash) (bool, error) {
+	var found int
+	err := s.db.QueryRowContext(ctx, s.bind(`SELECT 1 FROM sequence_consumed_messages WHERE message_key = ?`), key.Hex()).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("replayguard: %w", err)
+	}
+	return true, nil
+}
+
+func (s *SQLStore) Prune(ctx context.Context, now time.Time) error {
+	_, err := s.db.ExecContext(ctx, s.bind(`DELETE FROM sequence_consumed_messages WHERE expires_at > 0 AND expires_at < ?`), now.Unix())
+	if err != nil {
+		return fmt.Errorf("replayguard: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) bind(query string) string {
+	if !s.dollar {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+		} else {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
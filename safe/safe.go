@@ -0,0 +1,421 @@
+// Package safe lets a Sequence wallet act as an owner of a Gnosis Safe (v1.3.0 and later),
+// and builds, decodes and executes Safe transactions from within Sequence bundles.
+//
+// A Sequence wallet approves a Safe transaction either with an ERC-1271 contract signature,
+// validated by the Safe calling isValidSignature(bytes,bytes) on the wallet, or on-chain
+// by calling approveHash from a bundle. Contract signatures are only valid once the wallet
+// is deployed, as the Safe calls into the wallet to validate them.
+package safe
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/0xsequence/ethkit/ethcontract"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi/bind"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/core/digest"
+)
+
+// Operation is the kind of call a Safe transaction makes.
+type Operation uint8
+
+const (
+	OperationCall         Operation = 0
+	OperationDelegateCall Operation = 1
+)
+
+var (
+	// DomainSeparatorTypeHash is the EIP-712 domain type of Safes since v1.3.0.
+	DomainSeparatorTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(uint256 chainId,address verifyingContract)"))
+
+	// SafeTxTypeHash is the EIP-712 type of Safe transactions.
+	SafeTxTypeHash = crypto.Keccak256Hash([]byte("SafeTx(address to,uint256 value,bytes data,uint8 operation,uint256 safeTxGas,uint256 baseGas,uint256 gasPrice,address gasToken,address refundReceiver,uint256 nonce)"))
+)
+
+// safeABI is the subset of the Safe ABI used by the adapter.
+var safeABI = mustParseABI(`[
+	{"type":"function","name":"execTransaction","stateMutability":"payable","inputs":[
+		{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"},
+		{"name":"operation","type":"uint8"},{"name":"safeTxGas","type":"uint256"},{"name":"baseGas","type":"uint256"},
+		{"name":"gasPrice","type":"uint256"},{"name":"gasToken","type":"address"},{"name":"refundReceiver","type":"address"},
+		{"name":"signatures","type":"bytes"}],"outputs":[{"name":"success","type":"bool"}]},
+	{"type":"function","name":"approveHash","stateMutability":"nonpayable","inputs":[{"name":"hashToApprove","type":"bytes32"}],"outputs":[]},
+	{"type":"function","name":"approvedHashes","stateMutability":"view","inputs":[{"name":"owner","type":"address"},{"name":"hash","type":"bytes32"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"nonce","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"getThreshold","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"getOwners","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address[]"}]}
+]`)
+
+func mustParseABI(s string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(s))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// Transaction is a Safe transaction, the SafeTx signed by the owners of a Safe.
+type Transaction struct {
+	To             common.Address
+	Value          *big.Int
+	Data           []byte
+	Operation      Operation
+	SafeTxGas      *big.Int
+	BaseGas        *big.Int
+	GasPrice       *big.Int
+	GasToken       common.Address
+	RefundReceiver common.Address
+	Nonce          *big.Int
+}
+
+// DomainSeparator returns the EIP-712 domain separator of the Safe at address.
+func DomainSeparator(chainID *big.Int, safe common.Address) common.Hash {
+	return crypto.Keccak256Hash(
+		DomainSeparatorTypeHash[:],
+		common.LeftPadBytes(chainID.Bytes(), 32),
+		common.LeftPadBytes(safe[:], 32),
+	)
+}
+
+// StructHash returns the EIP-712 struct hash of the transaction.
+func (t *Transaction) StructHash() common.Hash {
+	return crypto.Keccak256Hash(
+		SafeTxTypeHash[:],
+		common.LeftPadBytes(t.To[:], 32),
+		uint256(t.Value),
+		crypto.Keccak256(t.Data),
+		uint256(big.NewInt(int64(t.Operation))),
+		uint256(t.SafeTxGas),
+		uint256(t.BaseGas),
+		uint256(t.GasPrice),
+		common.LeftPadBytes(t.GasToken[:], 32),
+		common.LeftPadBytes(t.RefundReceiver[:], 32),
+		uint256(t.Nonce),
+	)
+}
+
+// Digest returns the hash of the transaction signed by the owners of the Safe at address,
+// which the Safe calls the transaction hash.
+func (t *Transaction) Digest(chainID *big.Int, safe common.Address) common.Hash {
+	return digest.TypedDataDigest(DomainSeparator(chainID, safe), t.StructHash())
+}
+
+// SignatureKind is the kind of an owner signature, as encoded in its v byte.
+type SignatureKind uint8
+
+const (
+	// SignatureContract is an ERC-1271 signature of a contract owner, ie. a Sequence wallet.
+	SignatureContract SignatureKind = iota
+
+	// SignatureApprovedHash is an approval made on-chain by the owner with approveHash, or
+	// the sender of execTransaction being the owner.
+	SignatureApprovedHash
+
+	// SignatureEOA is an ECDSA signature of the transaction hash.
+	SignatureEOA
+
+	// SignatureEthSign is an ECDSA signature of the eth_sign digest of the transaction hash.
+	SignatureEthSign
+)
+
+// Signature is the approval of a transaction by an owner of a Safe.
+type Signature struct {
+	Owner common.Address
+	Kind  SignatureKind
+
+	// Data is the 65 byte ECDSA signature of an EOA, or the signature passed to
+	// isValidSignature of a contract owner.
+	Data []byte
+}
+
+// EncodeSignatures encodes signatures in the format of execTransaction, ordered by owner.
+func EncodeSignatures(signatures []Signature) ([]byte, error) {
+	signatures = append([]Signature{}, signatures...)
+	sort.Slice(signatures, func(i, j int) bool {
+		return bytes.Compare(signatures[i].Owner[:], signatures[j].Owner[:]) < 0
+	})
+
+	static := make([]byte, 0, 65*len(signatures))
+	var dynamic []byte
+	for i, sig := range signatures {
+		if i > 0 && sig.Owner == signatures[i-1].Owner {
+			return nil, fmt.Errorf("safe: duplicate signature of owner %v", sig.Owner)
+		}
+
+		switch sig.Kind {
+		case SignatureContract:
+			// the signature is appended after the static parts, at the offset in s
+			offset := big.NewInt(int64(65*len(signatures) + len(dynamic)))
+			static = append(static, common.LeftPadBytes(sig.Owner[:], 32)...)
+			static = append(static, uint256(offset)...)
+			static = append(static, 0)
+			dynamic = append(dynamic, uint256(big.NewInt(int64(len(sig.Data))))...)
+			dynamic = append(dynamic, sig.Data...)
+
+		case SignatureApprovedHash:
+			static = append(static, common.LeftPadBytes(sig.Owner[:], 32)...)
+			static = append(static, make([]byte, 32)...)
+			static = append(static, 1)
+
+		case SignatureEOA, SignatureEthSign:
+			if len(sig.Data) != 65 {
+				return nil, fmt.Errorf("safe: signature of owner %v is not 65 bytes", sig.Owner)
+			}
+			v := sig.Data[64]
+			if v < 27 {
+				v += 27
+			}
+			if sig.Kind == SignatureEthSign {
+				v += 4
+			}
+			static = append(static, sig.Data[:64]...)
+			static = append(static, v)
+
+		default:
+			return nil, fmt.Errorf("safe: unknown signature kind %d", sig.Kind)
+		}
+	}
+	return append(static, dynamic...), nil
+}
+
+// DecodeSignatures decodes the first count signatures of execTransaction signatures.
+// Owners of ECDSA signatures are recovered from txHash.
+func DecodeSignatures(data []byte, count int, txHash common.Hash) ([]Signature, error) {
+	if len(data) < 65*count {
+		return nil, fmt.Errorf("safe: signatures are too short for %d signatures", count)
+	}
+
+	signatures := make([]Signature, 0, count)
+	for i := 0; i < count; i++ {
+		part := data[65*i : 65*(i+1)]
+		r, s, v := part[:32], part[32:64], part[64]
+
+		switch {
+		case v == 0:
+			offset := new(big.Int).SetBytes(s)
+			if !offset.IsUint64() || offset.Uint64()+32 > uint64(len(data)) {
+				return nil, fmt.Errorf("safe: contract signature %d is out of bounds", i)
+			}
+			start := offset.Uint64() + 32
+			length := new(big.Int).SetBytes(data[offset.Uint64():start])
+			if !length.IsUint64() || start+length.Uint64() > uint64(len(data)) {
+				return nil, fmt.Errorf("safe: contract signature %d is out of bounds", i)
+			}
+			signatures = append(signatures, Signature{
+				Owner: common.BytesToAddress(r),
+				Kind:  SignatureContract,
+				Data:  common.CopyBytes(data[start : start+length.Uint64()]),
+			})
+
+		case v == 1:
+			signatures = append(signatures, Signature{Owner: common.BytesToAddress(r), Kind: SignatureApprovedHash})
+
+		case v == 27 || v == 28 || v == 31 || v == 32:
+			kind, signedHash := SignatureEOA, txHash
+			sig := common.CopyBytes(part)
+			if v > 30 {
+				kind, signedHash = SignatureEthSign, digest.EthSignDigest(txHash[:])
+				sig[64] -= 4
+			}
+			owner, err := ethwallet.RecoverAddressFromDigest(signedHash[:], sig)
+			if err != nil {
+				return nil, fmt.Errorf("safe: invalid signature %d: %w", i, err)
+			}
+			signatures = append(signatures, Signature{Owner: owner, Kind: kind, Data: sig})
+
+		default:
+			return nil, fmt.Errorf("safe: signature %d has unknown v %d", i, v)
+		}
+	}
+	return signatures, nil
+}
+
+// SignEOA signs the transaction hash with an EOA owner of a Safe.
+func SignEOA(eoa *ethwallet.Wallet, txHash common.Hash) (Signature, error) {
+	sig, err := crypto.Sign(txHash[:], eoa.PrivateKey())
+	if err != nil {
+		return Signature{}, fmt.Errorf("safe: failed to sign: %w", err)
+	}
+	sig[64] += 27
+	return Signature{Owner: eoa.Address(), Kind: SignatureEOA, Data: sig}, nil
+}
+
+// Owner is a Sequence wallet owning a Safe.
+type Owner struct {
+	Wallet *sequence.Wallet
+	Safe   common.Address
+}
+
+func NewOwner(wallet *sequence.Wallet, safe common.Address) *Owner {
+	return &Owner{Wallet: wallet, Safe: safe}
+}
+
+// Sign returns the ERC-1271 signature of the wallet approving txn. The wallet must have
+// a chain id set.
+func (o *Owner) Sign(txn *Transaction) (Signature, error) {
+	chainID := o.Wallet.GetChainID()
+	if chainID == nil {
+		return Signature{}, fmt.Errorf("safe: %w", digest.ErrNoChainID)
+	}
+
+	// the Safe calls isValidSignature(bytes,bytes) on the wallet with the pre-image of the
+	// transaction hash, which the wallet hashes before validating the signature
+	sig, _, err := o.Wallet.SignDigest(txn.Digest(chainID, o.Safe))
+	if err != nil {
+		return Signature{}, fmt.Errorf("safe: failed to sign transaction: %w", err)
+	}
+	return Signature{Owner: o.Wallet.Address(), Kind: SignatureContract, Data: sig}, nil
+}
+
+// Approve returns the Sequence transaction approving txn on-chain from the wallet, and
+// the signature referencing the approval.
+func (o *Owner) Approve(txn *Transaction) (*sequence.Transaction, Signature, error) {
+	chainID := o.Wallet.GetChainID()
+	if chainID == nil {
+		return nil, Signature{}, fmt.Errorf("safe: %w", digest.ErrNoChainID)
+	}
+
+	data, err := safeABI.Pack("approveHash", txn.Digest(chainID, o.Safe))
+	if err != nil {
+		return nil, Signature{}, fmt.Errorf("safe: failed to encode approveHash: %w", err)
+	}
+	approval := &sequence.Transaction{To: o.Safe, Data: data, RevertOnError: true}
+	return approval, Signature{Owner: o.Wallet.Address(), Kind: SignatureApprovedHash}, nil
+}
+
+// Execute returns the Sequence transaction executing txn on safe with signatures, to be
+// sent in a bundle of any wallet.
+func Execute(safe common.Address, txn *Transaction, signatures []Signature) (*sequence.Transaction, error) {
+	encodedSignatures, err := EncodeSignatures(signatures)
+	if err != nil {
+		return nil, err
+	}
+	data, err := safeABI.Pack("execTransaction",
+		txn.To, bigOrZero(txn.Value), txn.Data, uint8(txn.Operation),
+		bigOrZero(txn.SafeTxGas), bigOrZero(txn.BaseGas), bigOrZero(txn.GasPrice),
+		txn.GasToken, txn.RefundReceiver, encodedSignatures,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("safe: failed to encode execTransaction: %w", err)
+	}
+	return &sequence.Transaction{To: safe, Data: data, RevertOnError: true}, nil
+}
+
+// DecodeExecute decodes the calldata of execTransaction. The nonce of the transaction is
+// not part of the calldata, and is left nil.
+func DecodeExecute(data []byte) (*Transaction, []byte, error) {
+	method := safeABI.Methods["execTransaction"]
+	if len(data) < 4 || !bytes.Equal(data[:4], method.ID) {
+		return nil, nil, fmt.Errorf("safe: not an execTransaction call")
+	}
+
+	values, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("safe: failed to decode execTransaction: %w", err)
+	}
+	var (
+		txn        Transaction
+		operation  uint8
+		signatures []byte
+	)
+	err = method.Inputs.Copy(&[]interface{}{
+		&txn.To, &txn.Value, &txn.Data, &operation, &txn.SafeTxGas, &txn.BaseGas,
+		&txn.GasPrice, &txn.GasToken, &txn.RefundReceiver, &signatures,
+	}, values)
+	if err != nil {
+		return nil, nil, fmt.Errorf("safe: failed to decode execTransaction: %w", err)
+	}
+	txn.Operation = Operation(operation)
+	return &txn, signatures, nil
+}
+
+// Execution is a Safe transaction executed within a Sequence bundle.
+type Execution struct {
+	Safe        common.Address
+	Transaction *Transaction
+	Signatures  []byte
+
+	// Path is the path of the Sequence transaction in the bundle, ie. [0] or [2, 1] for
+	// the second transaction of a nested bundle in the third transaction.
+	Path []int
+}
+
+// DecodeTransactions returns the Safe transactions executed by txns, including those of
+// nested bundles.
+func DecodeTransactions(txns sequence.Transactions) []*Execution {
+	return decodeTransactions(txns, nil)
+}
+
+func decodeTransactions(txns sequence.Transactions, path []int) []*Execution {
+	var executions []*Execution
+	for i, txn := range txns {
+		txnPath := append(append([]int{}, path...), i)
+		if len(txn.Transactions) > 0 {
+			executions = append(executions, decodeTransactions(txn.Transactions, txnPath)...)
+			continue
+		}
+		safeTxn, signatures, err := DecodeExecute(txn.Data)
+		if err != nil {
+			continue
+		}
+		executions = append(executions, &Execution{Safe: txn.To, Transaction: safeTxn, Signatures: signatures, Path: txnPath})
+	}
+	return executions
+}
+
+// Nonce returns the nonce of the next transaction of safe.
+func Nonce(ctx context.Context, provider *ethrpc.Provider, safe common.Address) (*big.Int, error) {
+	var nonce *big.Int
+	if err := call(ctx, provider, safe, "nonce", &nonce); err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+// Threshold returns the number of owners required to approve transactions of safe.
+func Threshold(ctx context.Context, provider *ethrpc.Provider, safe common.Address) (int, error) {
+	var threshold *big.Int
+	if err := call(ctx, provider, safe, "getThreshold", &threshold); err != nil {
+		return 0, err
+	}
+	return int(threshold.Int64()), nil
+}
+
+// Owners returns the owners of safe.
+func Owners(ctx context.Context, provider *ethrpc.Provider, safe common.Address) ([]common.Address, error) {
+	var owners []common.Address
+	if err := call(ctx, provider, safe, "getOwners", &owners); err != nil {
+		return nil, err
+	}
+	return owners, nil
+}
+
+func call(ctx context.Context, provider *ethrpc.Provider, safe common.Address, method string, result interface{}, args ...interface{}) error {
+	contract := ethcontract.NewContractCaller(safe, safeABI, provider)
+	results := []interface{}{result}
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &results, method, args...); err != nil {
+		return fmt.Errorf("safe: %s failed: %w", method, err)
+	}
+	return nil
+}
+
+func uint256(n *big.Int) []byte {
+	return common.LeftPadBytes(bigOrZero(n).Bytes(), 32)
+}
+
+func bigOrZero(n *big.Int) *big.Int {
+	if n == nil {
+		return new(big.Int)
+	}
+	return n
+}
@@ -0,0 +1,141 @@
+package safe_test
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/core/digest"
+	"github.com/0xsequence/go-sequence/safe"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionDigest(t *testing.T) {
+	safeAddress := common.HexToAddress("0x5afe00000000000000000000000000000000a1b2")
+	txn := &safe.Transaction{
+		To:        common.HexToAddress("0x1111111111111111111111111111111111111111"),
+		Value:     big.NewInt(1e18),
+		Data:      []byte{0xde, 0xad, 0xbe, 0xef},
+		Operation: safe.OperationCall,
+		SafeTxGas: big.NewInt(50000),
+		Nonce:     big.NewInt(3),
+	}
+
+	// the digest is the EIP-712 digest of the SafeTx
+	typedData, err := digest.ParseTypedData([]byte(`{
+		"types": {
+			"EIP712Domain": [{"name": "chainId", "type": "uint256"}, {"name": "verifyingContract", "type": "address"}],
+			"SafeTx": [
+				{"name": "to", "type": "address"}, {"name": "value", "type": "uint256"}, {"name": "data", "type": "bytes"},
+				{"name": "operation", "type": "uint8"}, {"name": "safeTxGas", "type": "uint256"}, {"name": "baseGas", "type": "uint256"},
+				{"name": "gasPrice", "type": "uint256"}, {"name": "gasToken", "type": "address"}, {"name": "refundReceiver", "type": "address"},
+				{"name": "nonce", "type": "uint256"}
+			]
+		},
+		"primaryType": "SafeTx",
+		"domain": {"chainId": 5, "verifyingContract": "0x5afe00000000000000000000000000000000a1b2"},
+		"message": {
+			"to": "0x1111111111111111111111111111111111111111", "value": "1000000000000000000", "data": "0xdeadbeef",
+			"operation": 0, "safeTxGas": 50000, "baseGas": 0, "gasPrice": 0,
+			"gasToken": "0x0000000000000000000000000000000000000000", "refundReceiver": "0x0000000000000000000000000000000000000000",
+			"nonce": 3
+		}
+	}`))
+	assert.NoError(t, err)
+	expected, err := typedData.Digest()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, txn.Digest(big.NewInt(5), safeAddress))
+}
+
+func TestSignatures(t *testing.T) {
+	eoa, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+	walletOwner, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(walletOwner)
+	assert.NoError(t, err)
+	wallet.SetChainID(big.NewInt(5))
+
+	safeAddress := common.HexToAddress("0x5afe00000000000000000000000000000000a1b2")
+	txn := &safe.Transaction{To: common.HexToAddress("0x01"), Value: big.NewInt(1), Nonce: big.NewInt(0)}
+	txHash := txn.Digest(big.NewInt(5), safeAddress)
+
+	eoaSig, err := safe.SignEOA(eoa, txHash)
+	assert.NoError(t, err)
+
+	owner := safe.NewOwner(wallet, safeAddress)
+	walletSig, err := owner.Sign(txn)
+	assert.NoError(t, err)
+
+	// the wallet signature is a Sequence signature of the transaction hash
+	subDigest, err := sequence.SubDigest(big.NewInt(5), wallet.Address(), txHash)
+	assert.NoError(t, err)
+	config, err := sequence.RecoverWalletConfigFromDigest(subDigest, walletSig.Data, sequence.SequenceContext(), big.NewInt(5), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, walletOwner.Address(), config.Signers[0].Address)
+
+	approval, approvedSig, err := owner.Approve(txn)
+	assert.NoError(t, err)
+	assert.Equal(t, safeAddress, approval.To)
+
+	for _, signatures := range [][]safe.Signature{{eoaSig, walletSig}, {walletSig, eoaSig}, {approvedSig, eoaSig}} {
+		encoded, err := safe.EncodeSignatures(signatures)
+		assert.NoError(t, err)
+
+		decoded, err := safe.DecodeSignatures(encoded, len(signatures), txHash)
+		assert.NoError(t, err)
+		assert.Len(t, decoded, len(signatures))
+
+		// signatures are ordered by owner
+		assert.Equal(t, -1, bytes.Compare(decoded[0].Owner[:], decoded[1].Owner[:]))
+		byOwner := map[common.Address]safe.Signature{}
+		for _, sig := range decoded {
+			byOwner[sig.Owner] = sig
+		}
+		for _, sig := range signatures {
+			assert.Equal(t, sig.Kind, byOwner[sig.Owner].Kind)
+			assert.Equal(t, sig.Data, byOwner[sig.Owner].Data)
+		}
+	}
+
+	_, err = safe.EncodeSignatures([]safe.Signature{eoaSig, eoaSig})
+	assert.Error(t, err)
+}
+
+func TestExecuteInBundle(t *testing.T) {
+	safeAddress := common.HexToAddress("0x5afe00000000000000000000000000000000a1b2")
+	txn := &safe.Transaction{
+		To:        common.HexToAddress("0x01"),
+		Value:     big.NewInt(7),
+		Data:      []byte{1, 2, 3},
+		Operation: safe.OperationDelegateCall,
+		SafeTxGas: big.NewInt(21000),
+	}
+	signatures := []safe.Signature{{Owner: common.HexToAddress("0x02"), Kind: safe.SignatureApprovedHash}}
+
+	exec, err := safe.Execute(safeAddress, txn, signatures)
+	assert.NoError(t, err)
+
+	bundle := sequence.Transactions{
+		{To: common.HexToAddress("0x03"), Data: []byte{0xff}},
+		{Transactions: sequence.Transactions{exec}, Nonce: big.NewInt(1), Signature: []byte{1}},
+	}
+	executions := safe.DecodeTransactions(bundle)
+	assert.Len(t, executions, 1)
+	assert.Equal(t, safeAddress, executions[0].Safe)
+	assert.Equal(t, []int{1, 0}, executions[0].Path)
+
+	decoded := executions[0].Transaction
+	assert.Equal(t, txn.To, decoded.To)
+	assert.Equal(t, txn.Data, decoded.Data)
+	assert.Equal(t, txn.Operation, decoded.Operation)
+	assert.Equal(t, 0, txn.Value.Cmp(decoded.Value))
+	assert.Equal(t, 0, txn.SafeTxGas.Cmp(decoded.SafeTxGas))
+
+	decodedSignatures, err := safe.DecodeSignatures(executions[0].Signatures, 1, common.Hash{})
+	assert.NoError(t, err)
+	assert.Equal(t, signatures[0].Owner, decodedSignatures[0].Owner)
+}
@@ -0,0 +1,262 @@
+package sequence
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+)
+
+// Decoders of the JSON produced by sequence.js, so payloads of web frontends can be used
+// as-is. Numbers may be JSON numbers, decimal or hex strings, or ethers BigNumbers, which
+// JSON.stringify encodes as {"type":"BigNumber","hex":"0x..."}.
+
+// DecodeJSTransactions decodes a sequence.js transaction request: a transaction or an
+// array of transactions, with to, value, data, gasLimit, nonce, delegateCall and
+// revertOnError fields.
+func DecodeJSTransactions(data []byte) (Transactions, error) {
+	var jsTxns []*jsTransaction
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var jsTxn jsTransaction
+		if err := json.Unmarshal(data, &jsTxn); err != nil {
+			return nil, fmt.Errorf("sequence: decode sequence.js transaction: %w", err)
+		}
+		jsTxns = []*jsTransaction{&jsTxn}
+	} else if err := json.Unmarshal(data, &jsTxns); err != nil {
+		return nil, fmt.Errorf("sequence: decode sequence.js transactions: %w", err)
+	}
+	return jsTransactions(jsTxns)
+}
+
+// DecodeJSSignedTransactions decodes the signed transactions of sequence.js, with digest,
+// chainId, config, context, transactions, nonce and signature fields. The transactions are
+// verified to hash to the digest.
+func DecodeJSSignedTransactions(data []byte) (*SignedTransactions, error) {
+	var in struct {
+		Digest       common.Hash      `json:"digest"`
+		ChainID      *jsBigNumber     `json:"chainId"`
+		Config       *jsWalletConfig  `json:"config"`
+		Context      *jsWalletContext `json:"context"`
+		Transactions []*jsTransaction `json:"transactions"`
+		Nonce        *jsBigNumber     `json:"nonce"`
+		Signature    hexutil.Bytes    `json:"signature"`
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, fmt.Errorf("sequence: decode sequence.js signed transactions: %w", err)
+	}
+	if in.Config == nil {
+		return nil, fmt.Errorf("sequence: decode sequence.js signed transactions: config is required")
+	}
+
+	txns, err := jsTransactions(in.Transactions)
+	if err != nil {
+		return nil, err
+	}
+	config, err := in.Config.walletConfig()
+	if err != nil {
+		return nil, fmt.Errorf("sequence: decode sequence.js signed transactions: %w", err)
+	}
+
+	signedTxs := &SignedTransactions{
+		ChainID:      in.ChainID.bigInt(),
+		WalletConfig: config,
+		Transactions: txns,
+		Nonce:        in.Nonce.bigInt(),
+		Digest:       in.Digest,
+		Signature:    in.Signature,
+	}
+	if in.Context != nil {
+		signedTxs.WalletContext = in.Context.walletContext()
+	} else {
+		signedTxs.WalletContext = SequenceContext()
+	}
+
+	if err := signedTxs.Verify(); err != nil {
+		return nil, fmt.Errorf("sequence: decode sequence.js signed transactions: %w", err)
+	}
+	return signedTxs, nil
+}
+
+// DecodeJSWalletConfig decodes a sequence.js wallet config, with threshold and signers
+// fields. Its address and chainId fields are ignored.
+func DecodeJSWalletConfig(data []byte) (WalletConfig, error) {
+	var config jsWalletConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return WalletConfig{}, fmt.Errorf("sequence: decode sequence.js wallet config: %w", err)
+	}
+	walletConfig, err := config.walletConfig()
+	if err != nil {
+		return WalletConfig{}, fmt.Errorf("sequence: decode sequence.js wallet config: %w", err)
+	}
+	return walletConfig, nil
+}
+
+type jsTransaction struct {
+	To            common.Address `json:"to"`
+	Value         *jsBigNumber   `json:"value"`
+	Data          jsBytes        `json:"data"`
+	GasLimit      *jsBigNumber   `json:"gasLimit"`
+	Nonce         *jsBigNumber   `json:"nonce"`
+	DelegateCall  bool           `json:"delegateCall"`
+	RevertOnError bool           `json:"revertOnError"`
+}
+
+func jsTransactions(jsTxns []*jsTransaction) (Transactions, error) {
+	txns := make(Transactions, 0, len(jsTxns))
+	for i, jsTxn := range jsTxns {
+		if jsTxn == nil {
+			return nil, fmt.Errorf("sequence: decode sequence.js transactions: transaction %d is null", i)
+		}
+		txns = append(txns, &Transaction{
+			To:            jsTxn.To,
+			Value:         jsTxn.Value.bigInt(),
+			Data:          jsTxn.Data,
+			GasLimit:      jsTxn.GasLimit.bigInt(),
+			Nonce:         jsTxn.Nonce.bigInt(),
+			DelegateCall:  jsTxn.DelegateCall,
+			RevertOnError: jsTxn.RevertOnError,
+		})
+	}
+	return txns, nil
+}
+
+type jsWalletConfig struct {
+	Threshold jsBigNumber `json:"threshold"`
+	Signers   []struct {
+		Weight  jsBigNumber    `json:"weight"`
+		Address common.Address `json:"address"`
+	} `json:"signers"`
+}
+
+func (c *jsWalletConfig) walletConfig() (WalletConfig, error) {
+	threshold := c.Threshold.bigInt()
+	if threshold.Sign() < 0 || threshold.BitLen() > 16 {
+		return WalletConfig{}, fmt.Errorf("threshold %v is not a uint16", threshold)
+	}
+	config := WalletConfig{
+		Threshold: uint16(threshold.Uint64()),
+		Signers:   make(WalletConfigSigners, 0, len(c.Signers)),
+	}
+	for _, signer := range c.Signers {
+		weight := signer.Weight.bigInt()
+		if weight.Sign() < 0 || weight.BitLen() > 8 {
+			return WalletConfig{}, fmt.Errorf("weight %v of signer %v is not a uint8", weight, signer.Address)
+		}
+		config.Signers = append(config.Signers, WalletConfigSigner{
+			Weight:  uint8(weight.Uint64()),
+			Address: signer.Address,
+		})
+	}
+	return config, nil
+}
+
+type jsWalletContext struct {
+	Factory              common.Address `json:"factory"`
+	MainModule           common.Address `json:"mainModule"`
+	MainModuleUpgradable common.Address `json:"mainModuleUpgradable"`
+	GuestModule          common.Address `json:"guestModule"`
+	SequenceUtils        common.Address `json:"sequenceUtils"`
+	Utils                common.Address `json:"utils"`
+}
+
+func (c *jsWalletContext) walletContext() WalletContext {
+	utils := c.SequenceUtils
+	if utils == (common.Address{}) {
+		utils = c.Utils
+	}
+	return WalletContext{
+		FactoryAddress:              c.Factory,
+		MainModuleAddress:           c.MainModule,
+		MainModuleUpgradableAddress: c.MainModuleUpgradable,
+		GuestModuleAddress:          c.GuestModule,
+		UtilsAddress:                utils,
+	}
+}
+
+// jsBigNumber decodes an ethers BigNumberish.
+type jsBigNumber big.Int
+
+func (n *jsBigNumber) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var s string
+	switch data[0] {
+	case '{':
+		var bn struct {
+			Type string `json:"type"`
+			Hex  string `json:"hex"`
+		}
+		if err := json.Unmarshal(data, &bn); err != nil {
+			return err
+		}
+		if bn.Type != "BigNumber" {
+			return fmt.Errorf("invalid BigNumber of type %q", bn.Type)
+		}
+		s = bn.Hex
+	case '"':
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	default:
+		s = string(data)
+	}
+
+	// hex strings are prefixed, all others are decimal
+	s = strings.TrimSpace(s)
+	base := 10
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "-0x") {
+		s, base = strings.Replace(s, "0x", "", 1), 16
+	}
+	v, ok := new(big.Int).SetString(s, base)
+	if !ok {
+		return fmt.Errorf("invalid number %s", data)
+	}
+	*n = jsBigNumber(*v)
+	return nil
+}
+
+func (n *jsBigNumber) bigInt() *big.Int {
+	if n == nil {
+		return nil
+	}
+	return new(big.Int).Set((*big.Int)(n))
+}
+
+// jsBytes decodes ethers BytesLike: a hex string, or an array of bytes.
+type jsBytes []byte
+
+func (b *jsBytes) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		return nil
+	case data[0] == '[':
+		var values []uint8
+		if err := json.Unmarshal(data, &values); err != nil {
+			return err
+		}
+		*b = values
+		return nil
+	default:
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if s == "" || s == "0x" {
+			return nil
+		}
+		decoded, err := hexutil.Decode(s)
+		if err != nil {
+			return err
+		}
+		*b = decoded
+		return nil
+	}
+}
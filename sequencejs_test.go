@@ -0,0 +1,88 @@
+package sequence_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeJSTransactions(t *testing.T) {
+	txns, err := sequence.DecodeJSTransactions([]byte(`[
+		{"to": "0x1111111111111111111111111111111111111111", "value": {"type": "BigNumber", "hex": "0x03e8"}, "data": "0xdead"},
+		{"to": "0x2222222222222222222222222222222222222222", "gasLimit": "50000", "revertOnError": true, "data": "0x"},
+		{"to": "0x2222222222222222222222222222222222222222", "value": 10, "data": [1, 2], "nonce": "0x07", "delegateCall": true}
+	]`))
+	assert.NoError(t, err)
+	assert.Len(t, txns, 3)
+	assert.Equal(t, int64(1000), txns[0].Value.Int64())
+	assert.Equal(t, []byte{0xde, 0xad}, txns[0].Data)
+	assert.Nil(t, txns[0].GasLimit)
+	assert.Equal(t, int64(50000), txns[1].GasLimit.Int64())
+	assert.True(t, txns[1].RevertOnError)
+	assert.Nil(t, txns[1].Data)
+	assert.Equal(t, []byte{1, 2}, txns[2].Data)
+	assert.Equal(t, int64(7), txns[2].Nonce.Int64())
+	assert.True(t, txns[2].DelegateCall)
+
+	// a single transaction is accepted
+	txns, err = sequence.DecodeJSTransactions([]byte(`{"to": "0x1111111111111111111111111111111111111111", "value": "010"}`))
+	assert.NoError(t, err)
+	assert.Len(t, txns, 1)
+	assert.Equal(t, int64(10), txns[0].Value.Int64())
+
+	_, err = sequence.DecodeJSTransactions([]byte(`[{"value": {"type": "Number", "hex": "0x1"}}]`))
+	assert.Error(t, err)
+	_, err = sequence.DecodeJSTransactions([]byte(`[{"value": "1.5"}]`))
+	assert.Error(t, err)
+}
+
+func TestDecodeJSSignedTransactions(t *testing.T) {
+	signedTxs, err := sequence.DecodeJSSignedTransactions([]byte(`{
+		"digest": "0xebcc7d011d0d37d10546312fc789d1516bf9e5e179357854188390ab03037539",
+		"chainId": 137,
+		"config": {"threshold": 1, "signers": [{"weight": 1, "address": "0x3333333333333333333333333333333333333333"}], "address": "0x4444444444444444444444444444444444444444"},
+		"context": {
+			"factory": "0xf9D09D634Fb818b05149329C1dcCFAeA53639d96",
+			"mainModule": "0xd01F11855bCcb95f88D7A48492F66410d4637313",
+			"mainModuleUpgradable": "0x7EFE6cE415956c5f80C6530cC6cc81b4808F6118",
+			"guestModule": "0x02390F3E6E5FD1C6786CB78FD3027C117a9955A7",
+			"sequenceUtils": "0xd130B43062D875a4B7aF3f8fc036Bc6e9D3E1B3E"
+		},
+		"transactions": [
+			{"to": "0x1111111111111111111111111111111111111111", "value": {"type": "BigNumber", "hex": "0x03e8"}, "data": "0xdead", "delegateCall": false, "revertOnError": false},
+			{"to": "0x2222222222222222222222222222222222222222", "gasLimit": {"type": "BigNumber", "hex": "0xc350"}, "data": "0x", "delegateCall": false, "revertOnError": true}
+		],
+		"nonce": {"type": "BigNumber", "hex": "0x07"},
+		"signature": "0x010203"
+	}`))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(137), signedTxs.ChainID.Int64())
+	assert.Equal(t, sequence.SequenceContext(), signedTxs.WalletContext)
+	assert.Equal(t, common.HexToAddress("0x3333333333333333333333333333333333333333"), signedTxs.WalletConfig.Signers[0].Address)
+	assert.Equal(t, big.NewInt(7), signedTxs.Nonce)
+	assert.Equal(t, []byte{1, 2, 3}, signedTxs.Signature)
+
+	// transactions which do not match the digest are rejected
+	_, err = sequence.DecodeJSSignedTransactions([]byte(`{
+		"digest": "0xebcc7d011d0d37d10546312fc789d1516bf9e5e179357854188390ab03037539",
+		"chainId": 137,
+		"config": {"threshold": 1, "signers": []},
+		"transactions": [{"to": "0x1111111111111111111111111111111111111111"}],
+		"nonce": 7,
+		"signature": "0x010203"
+	}`))
+	assert.ErrorIs(t, err, sequence.ErrSignedTransactionsMutated)
+}
+
+func TestDecodeJSWalletConfig(t *testing.T) {
+	config, err := sequence.DecodeJSWalletConfig([]byte(`{"threshold": 2, "signers": [{"weight": "1", "address": "0x3333333333333333333333333333333333333333"}, {"weight": 1, "address": "0x4444444444444444444444444444444444444444"}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(2), config.Threshold)
+	assert.Len(t, config.Signers, 2)
+
+	_, err = sequence.DecodeJSWalletConfig([]byte(`{"threshold": 2, "signers": [{"weight": 256, "address": "0x3333333333333333333333333333333333333333"}]}`))
+	assert.Error(t, err)
+}
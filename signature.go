@@ -2,7 +2,6 @@ package sequence
 
 import (
 	"bytes"
-	"context"
 	"encoding/binary"
 	"fmt"
 	"math"
@@ -10,12 +9,12 @@ import (
 
 	"github.com/0xsequence/ethkit/ethcoder"
 	"github.com/0xsequence/ethkit/ethrpc"
-	"github.com/0xsequence/ethkit/ethwallet"
 	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi/bind"
 	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
 	"github.com/0xsequence/ethkit/go-ethereum/crypto"
 	"github.com/0xsequence/go-sequence/contracts/gen/ierc1271"
+	coredigest "github.com/0xsequence/go-sequence/core/digest"
 )
 
 func Sign(wallet *Wallet, input common.Hash) ([]byte, *Signature, error) {
@@ -155,8 +154,7 @@ func (p *SignaturePart) Recover(msg []byte) (common.Address, error) {
 		return common.Address{}, fmt.Errorf("signature type not implemented %d", sigType)
 	}
 
-	m := fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(msg), msg)
-	h := crypto.Keccak256([]byte(m))
+	h := coredigest.EthSignDigest(msg).Bytes()
 
 	sigx := make([]byte, 65)
 	copy(sigx, p.Value)
@@ -452,7 +450,7 @@ func (p *SignaturePart) IsValid(digest [32]byte, provider *ethrpc.Provider) (boo
 			return false, err
 		}
 
-		return ierc1271.IsValidSignatureBytes32_MagicReturnValue != hexutil.Encode(res[:]), nil
+		return ierc1271.IsValidSignatureBytes32_MagicReturnValue == hexutil.Encode(res[:]), nil
 
 	default:
 		return false, fmt.Errorf("signature type not implemented %d", sigType)
@@ -614,59 +612,8 @@ func RecoverWalletConfigFromDigest(digest, seqSig []byte, context WalletContext,
 	return wc, nil
 }
 
-func IsValidSignature(walletAddress common.Address, digest common.Hash, seqSig []byte, walletContext WalletContext, chainID *big.Int, provider *ethrpc.Provider) (bool, error) {
-	// Try to do it first with ethereum sign signature format
-	ok, err := ethwallet.IsValid191Signature(walletAddress, digest[:], seqSig)
-	if err == nil {
-		return ok, nil
-	}
-
-	code, err := provider.CodeAt(context.Background(), walletAddress, nil)
-	if err != nil {
-		return false, err
-	}
-
-	if len(code) == 0 {
-		// It may be a signature from a non-deployed sequence wallet, check and attempt to validate
-		subDigest, err := SubDigest(chainID, walletAddress, digest)
-		if err != nil {
-			return false, err
-		}
-		recoveredWalletConfig, err := RecoverWalletConfigFromDigest(subDigest, seqSig, walletContext, chainID, nil)
-		if err != nil {
-			return false, err
-		}
-		recoveredAddress, err := AddressFromWalletConfig(recoveredWalletConfig, walletContext)
-		if err != nil {
-			return false, err
-		}
-		if recoveredAddress != walletAddress {
-			return false, fmt.Errorf("failed to validate")
-		}
-
-	} else {
-		// Smart wallet is deployed, query erc1271 method to check signature
-		erc1271, err := ierc1271.NewIERC1271(walletAddress, provider)
-		if err != nil {
-			return false, err
-		}
-
-		// NOTE: we expect digest to be ready for ERC1271 call, so digest must be fully encoded as expected
-		res, err := erc1271.IsValidSignature(&bind.CallOpts{From: common.Address{0x1}}, digest, seqSig)
-		if err != nil {
-			return false, err
-		}
-
-		if ierc1271.IsValidSignatureBytes32_MagicReturnValue != hexutil.Encode(res[:]) {
-			return false, fmt.Errorf("failed to validate")
-		}
-	}
-
-	return true, nil
-}
-
 func MessageDigest(message []byte) common.Hash {
-	return common.BytesToHash(ethcoder.Keccak256(message))
+	return coredigest.MessageDigest(message)
 }
 
 func MustEncodeSig(str string) common.Hash {
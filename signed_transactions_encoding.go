@@ -0,0 +1,239 @@
+package sequence
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+)
+
+// Serialized SignedTransactions are wrapped in an envelope of the magic bytes, the version
+// of the wire format and the codec of the payload:
+//
+//	"SEQT" ++ version (1 byte) ++ codec (1 byte) ++ payload
+//
+// so bundles stored in queues remain decodable after SignedTransactions changes. Payloads
+// without the magic bytes are decoded as version 0, the encoding/json encoding of
+// SignedTransactions used before the envelope was introduced.
+var signedTransactionsMagic = []byte("SEQT")
+
+// SignedTransactionsVersion is the version of the wire format written by
+// EncodeSignedTransactions.
+const SignedTransactionsVersion = 1
+
+// SignedTransactionsCodec is the codec of the payload of an envelope.
+type SignedTransactionsCodec uint8
+
+const (
+	// SignedTransactionsCodecJSON encodes payloads as JSON, with byte strings and integers
+	// hex encoded.
+	SignedTransactionsCodecJSON SignedTransactionsCodec = 1
+)
+
+// EncodeSignedTransactions serializes signedTxs with the current version of the wire format.
+func EncodeSignedTransactions(signedTxs *SignedTransactions) ([]byte, error) {
+	payload, err := json.Marshal(newSignedTransactionsV1(signedTxs))
+	if err != nil {
+		return nil, fmt.Errorf("sequence: encode signed transactions: %w", err)
+	}
+
+	data := make([]byte, 0, len(signedTransactionsMagic)+2+len(payload))
+	data = append(data, signedTransactionsMagic...)
+	data = append(data, SignedTransactionsVersion, byte(SignedTransactionsCodecJSON))
+	return append(data, payload...), nil
+}
+
+// DecodeSignedTransactions deserializes signed transactions of any version of the wire
+// format, and verifies they still hash to the digest they were signed over.
+func DecodeSignedTransactions(data []byte) (*SignedTransactions, error) {
+	signedTxs, err := decodeSignedTransactions(data)
+	if err != nil {
+		return nil, fmt.Errorf("sequence: decode signed transactions: %w", err)
+	}
+	if err := signedTxs.Verify(); err != nil {
+		return nil, fmt.Errorf("sequence: decode signed transactions: %w", err)
+	}
+	return signedTxs, nil
+}
+
+func decodeSignedTransactions(data []byte) (*SignedTransactions, error) {
+	if !bytes.HasPrefix(data, signedTransactionsMagic) {
+		// version 0 predates the envelope
+		var signedTxs SignedTransactions
+		if err := json.Unmarshal(data, &signedTxs); err != nil {
+			return nil, fmt.Errorf("unknown format: %w", err)
+		}
+		return &signedTxs, nil
+	}
+
+	header := data[len(signedTransactionsMagic):]
+	if len(header) < 2 {
+		return nil, fmt.Errorf("truncated envelope")
+	}
+	version, codec, payload := header[0], SignedTransactionsCodec(header[1]), header[2:]
+
+	switch version {
+	case 1:
+		if codec != SignedTransactionsCodecJSON {
+			return nil, fmt.Errorf("unsupported codec %d for version %d", codec, version)
+		}
+		var v1 signedTransactionsV1
+		if err := json.Unmarshal(payload, &v1); err != nil {
+			return nil, err
+		}
+		return v1.signedTransactions(), nil
+	default:
+		return nil, fmt.Errorf("unsupported version %d", version)
+	}
+}
+
+// signedTransactionsV1 is version 1 of the wire format. It must not be changed: changes to
+// the wire format are a new version, with its own type and decoder.
+type signedTransactionsV1 struct {
+	ChainID       *hexutil.Big     `json:"chainID"`
+	WalletConfig  walletConfigV1   `json:"walletConfig"`
+	WalletContext walletContextV1  `json:"walletContext"`
+	Transactions  []*transactionV1 `json:"transactions"`
+	Nonce         *hexutil.Big     `json:"nonce"`
+	Digest        common.Hash      `json:"digest"`
+	Signature     hexutil.Bytes    `json:"signature"`
+}
+
+type walletConfigV1 struct {
+	Threshold uint16                 `json:"threshold"`
+	Signers   []walletConfigSignerV1 `json:"signers"`
+}
+
+type walletConfigSignerV1 struct {
+	Weight  uint8          `json:"weight"`
+	Address common.Address `json:"address"`
+}
+
+type walletContextV1 struct {
+	Factory              common.Address `json:"factory"`
+	MainModule           common.Address `json:"mainModule"`
+	MainModuleUpgradable common.Address `json:"mainModuleUpgradable"`
+	GuestModule          common.Address `json:"guestModule"`
+	Utils                common.Address `json:"utils"`
+}
+
+type transactionV1 struct {
+	DelegateCall  bool             `json:"delegateCall"`
+	RevertOnError bool             `json:"revertOnError"`
+	GasLimit      *hexutil.Big     `json:"gasLimit,omitempty"`
+	To            common.Address   `json:"to"`
+	Value         *hexutil.Big     `json:"value,omitempty"`
+	Data          hexutil.Bytes    `json:"data,omitempty"`
+	Transactions  []*transactionV1 `json:"transactions,omitempty"`
+	Nonce         *hexutil.Big     `json:"nonce,omitempty"`
+	Signature     hexutil.Bytes    `json:"signature,omitempty"`
+
+	// Encoded is set for transactions whose Data is already the execdata of their child
+	// transactions, see Transactions.EncodedTransactions, which are hashed by their Data.
+	Encoded bool `json:"encoded,omitempty"`
+}
+
+func newSignedTransactionsV1(t *SignedTransactions) *signedTransactionsV1 {
+	v1 := &signedTransactionsV1{
+		ChainID:      toHexBig(t.ChainID),
+		Transactions: newTransactionsV1(t.Transactions),
+		Nonce:        toHexBig(t.Nonce),
+		Digest:       t.Digest,
+		Signature:    t.Signature,
+		WalletContext: walletContextV1{
+			Factory:              t.WalletContext.FactoryAddress,
+			MainModule:           t.WalletContext.MainModuleAddress,
+			MainModuleUpgradable: t.WalletContext.MainModuleUpgradableAddress,
+			GuestModule:          t.WalletContext.GuestModuleAddress,
+			Utils:                t.WalletContext.UtilsAddress,
+		},
+	}
+	v1.WalletConfig.Threshold = t.WalletConfig.Threshold
+	for _, signer := range t.WalletConfig.Signers {
+		v1.WalletConfig.Signers = append(v1.WalletConfig.Signers, walletConfigSignerV1{Weight: signer.Weight, Address: signer.Address})
+	}
+	return v1
+}
+
+func (v1 *signedTransactionsV1) signedTransactions() *SignedTransactions {
+	t := &SignedTransactions{
+		ChainID:      fromHexBig(v1.ChainID),
+		Transactions: transactionsFromV1(v1.Transactions),
+		Nonce:        fromHexBig(v1.Nonce),
+		Digest:       v1.Digest,
+		Signature:    v1.Signature,
+		WalletConfig: WalletConfig{Threshold: v1.WalletConfig.Threshold, Signers: WalletConfigSigners{}},
+		WalletContext: WalletContext{
+			FactoryAddress:              v1.WalletContext.Factory,
+			MainModuleAddress:           v1.WalletContext.MainModule,
+			MainModuleUpgradableAddress: v1.WalletContext.MainModuleUpgradable,
+			GuestModuleAddress:          v1.WalletContext.GuestModule,
+			UtilsAddress:                v1.WalletContext.Utils,
+		},
+	}
+	for _, signer := range v1.WalletConfig.Signers {
+		t.WalletConfig.Signers = append(t.WalletConfig.Signers, WalletConfigSigner{Weight: signer.Weight, Address: signer.Address})
+	}
+	return t
+}
+
+func newTransactionsV1(txns Transactions) []*transactionV1 {
+	if txns == nil {
+		return nil
+	}
+	v1 := make([]*transactionV1, 0, len(txns))
+	for _, txn := range txns {
+		v1 = append(v1, &transactionV1{
+			DelegateCall:  txn.DelegateCall,
+			RevertOnError: txn.RevertOnError,
+			GasLimit:      toHexBig(txn.GasLimit),
+			To:            txn.To,
+			Value:         toHexBig(txn.Value),
+			Data:          txn.Data,
+			Transactions:  newTransactionsV1(txn.Transactions),
+			Nonce:         toHexBig(txn.Nonce),
+			Signature:     txn.Signature,
+			Encoded:       txn.encoded,
+		})
+	}
+	return v1
+}
+
+func transactionsFromV1(v1 []*transactionV1) Transactions {
+	if v1 == nil {
+		return nil
+	}
+	txns := make(Transactions, 0, len(v1))
+	for _, txn := range v1 {
+		txns = append(txns, &Transaction{
+			DelegateCall:  txn.DelegateCall,
+			RevertOnError: txn.RevertOnError,
+			GasLimit:      fromHexBig(txn.GasLimit),
+			To:            txn.To,
+			Value:         fromHexBig(txn.Value),
+			Data:          txn.Data,
+			Transactions:  transactionsFromV1(txn.Transactions),
+			Nonce:         fromHexBig(txn.Nonce),
+			Signature:     txn.Signature,
+			encoded:       txn.Encoded,
+		})
+	}
+	return txns
+}
+
+func toHexBig(n *big.Int) *hexutil.Big {
+	if n == nil {
+		return nil
+	}
+	return (*hexutil.Big)(new(big.Int).Set(n))
+}
+
+func fromHexBig(n *hexutil.Big) *big.Int {
+	if n == nil {
+		return nil
+	}
+	return new(big.Int).Set(n.ToInt())
+}
@@ -0,0 +1,107 @@
+package sequence_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/stretchr/testify/assert"
+)
+
+// signedTransactionsV0 is a bundle serialized with encoding/json, before the versioned envelope.
+const signedTransactionsV0 = `{"ChainID":137,"WalletConfig":{"threshold":1,"signers":[{"weight":1,"address":"0x3333333333333333333333333333333333333333"}]},"WalletContext":{"factory":"0xf9d09d634fb818b05149329c1dccfaea53639d96","mainModule":"0xd01f11855bccb95f88d7a48492f66410d4637313","mainModuleUpgradable":"0x7efe6ce415956c5f80c6530cc6cc81b4808f6118","guestModule":"0x02390f3e6e5fd1c6786cb78fd3027c117a9955a7","utils":"0xd130b43062d875a4b7af3f8fc036bc6e9d3e1b3e"},"Transactions":[{"DelegateCall":false,"RevertOnError":false,"GasLimit":null,"To":"0x1111111111111111111111111111111111111111","Value":1000,"Data":"3q0=","Transactions":null,"Nonce":null,"Signature":null},{"DelegateCall":false,"RevertOnError":true,"GasLimit":50000,"To":"0x2222222222222222222222222222222222222222","Value":null,"Data":null,"Transactions":null,"Nonce":null,"Signature":null}],"Nonce":7,"Digest":"0xebcc7d011d0d37d10546312fc789d1516bf9e5e179357854188390ab03037539","Signature":"AQID"}`
+
+// signedTransactionsV1 is the same bundle in version 1 of the wire format.
+const signedTransactionsV1 = "SEQT\x01\x01" + `{"chainID":"0x89","walletConfig":{"threshold":1,"signers":[{"weight":1,"address":"0x3333333333333333333333333333333333333333"}]},"walletContext":{"factory":"0xf9d09d634fb818b05149329c1dccfaea53639d96","mainModule":"0xd01f11855bccb95f88d7a48492f66410d4637313","mainModuleUpgradable":"0x7efe6ce415956c5f80c6530cc6cc81b4808f6118","guestModule":"0x02390f3e6e5fd1c6786cb78fd3027c117a9955a7","utils":"0xd130b43062d875a4b7af3f8fc036bc6e9d3e1b3e"},"transactions":[{"delegateCall":false,"revertOnError":false,"to":"0x1111111111111111111111111111111111111111","value":"0x3e8","data":"0xdead"},{"delegateCall":false,"revertOnError":true,"gasLimit":"0xc350","to":"0x2222222222222222222222222222222222222222"}],"nonce":"0x7","digest":"0xebcc7d011d0d37d10546312fc789d1516bf9e5e179357854188390ab03037539","signature":"0x010203"}`
+
+func TestSignedTransactionsEncoding(t *testing.T) {
+	expected := &sequence.SignedTransactions{
+		ChainID:       big.NewInt(137),
+		WalletConfig:  sequence.WalletConfig{Threshold: 1, Signers: sequence.WalletConfigSigners{{Weight: 1, Address: common.HexToAddress("0x3333333333333333333333333333333333333333")}}},
+		WalletContext: sequence.SequenceContext(),
+		Transactions: sequence.Transactions{
+			{To: common.HexToAddress("0x1111111111111111111111111111111111111111"), Value: big.NewInt(1000), Data: []byte{0xde, 0xad}},
+			{RevertOnError: true, GasLimit: big.NewInt(50000), To: common.HexToAddress("0x2222222222222222222222222222222222222222")},
+		},
+		Nonce:     big.NewInt(7),
+		Digest:    common.HexToHash("0xebcc7d011d0d37d10546312fc789d1516bf9e5e179357854188390ab03037539"),
+		Signature: []byte{1, 2, 3},
+	}
+
+	// every historical version decodes to the same bundle
+	for _, data := range []string{signedTransactionsV0, signedTransactionsV1} {
+		decoded, err := sequence.DecodeSignedTransactions([]byte(data))
+		assert.NoError(t, err)
+		assert.True(t, expected.Equal(decoded))
+	}
+
+	encoded, err := sequence.EncodeSignedTransactions(expected)
+	assert.NoError(t, err)
+	assert.Equal(t, signedTransactionsV1, string(encoded))
+
+	// gob encodes the fields of SignedTransactions, as it did before the envelope
+	var buf bytes.Buffer
+	assert.NoError(t, gob.NewEncoder(&buf).Encode(expected))
+	var gobDecoded sequence.SignedTransactions
+	assert.NoError(t, gob.NewDecoder(&buf).Decode(&gobDecoded))
+	assert.True(t, expected.Equal(&gobDecoded))
+
+	// unknown versions and codecs, and mutated bundles, are rejected
+	_, err = sequence.DecodeSignedTransactions([]byte("SEQT\x02\x01{}"))
+	assert.Error(t, err)
+	_, err = sequence.DecodeSignedTransactions([]byte("SEQT\x01\x09{}"))
+	assert.Error(t, err)
+	_, err = sequence.DecodeSignedTransactions([]byte("SEQT\x01"))
+	assert.Error(t, err)
+
+	expected.Transactions[0].Value = big.NewInt(1)
+	encoded, err = sequence.EncodeSignedTransactions(expected)
+	assert.NoError(t, err)
+	_, err = sequence.DecodeSignedTransactions(encoded)
+	assert.ErrorIs(t, err, sequence.ErrSignedTransactionsMutated)
+}
+
+func TestSignedTransactionsEncodingEncodedBundle(t *testing.T) {
+	wallet := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	nested := sequence.Transactions{{
+		To:           wallet,
+		Transactions: sequence.Transactions{{To: common.HexToAddress("0x1111111111111111111111111111111111111111"), Value: big.NewInt(1000)}},
+		Nonce:        big.NewInt(1),
+		Signature:    []byte{4, 5, 6},
+	}}
+	encodedTxns, err := nested.EncodedTransactions()
+	assert.NoError(t, err)
+
+	bundle := sequence.Transaction{Transactions: sequence.Transactions{&encodedTxns[0]}, Nonce: big.NewInt(7)}
+	digest, err := bundle.Digest()
+	assert.NoError(t, err)
+
+	signedTxs := &sequence.SignedTransactions{
+		ChainID:       big.NewInt(137),
+		WalletConfig:  sequence.WalletConfig{Threshold: 1, Signers: sequence.WalletConfigSigners{{Weight: 1, Address: wallet}}},
+		WalletContext: sequence.SequenceContext(),
+		Transactions:  bundle.Transactions,
+		Nonce:         bundle.Nonce,
+		Digest:        digest,
+		Signature:     []byte{1, 2, 3},
+	}
+
+	data, err := sequence.EncodeSignedTransactions(signedTxs)
+	assert.NoError(t, err)
+	decoded, err := sequence.DecodeSignedTransactions(data)
+	assert.NoError(t, err)
+	assert.True(t, decoded.Transactions[0].IsEncoded())
+
+	decodedDigest, err := (&sequence.Transaction{Transactions: decoded.Transactions, Nonce: decoded.Nonce}).Digest()
+	assert.NoError(t, err)
+	assert.Equal(t, digest, decodedDigest)
+
+	metaTxnID, _, err := sequence.ComputeMetaTxnID(signedTxs.ChainID, wallet, signedTxs.Transactions, signedTxs.Nonce, sequence.MetaTxnWalletExec)
+	assert.NoError(t, err)
+	decodedMetaTxnID, _, err := sequence.ComputeMetaTxnID(decoded.ChainID, wallet, decoded.Transactions, decoded.Nonce, sequence.MetaTxnWalletExec)
+	assert.NoError(t, err)
+	assert.Equal(t, metaTxnID, decodedMetaTxnID)
+}
@@ -0,0 +1,38 @@
+package sequence
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// Signer is an EOA signer of wallet configs whose key is not held in-process, ie. by a
+// hardware wallet, a HSM, a KMS or a MPC cluster, see the signer package for adapters. It
+// signs the eth_sign digest of sub-digests, as the ethwallet signers of a wallet do, and
+// returns the 65 bytes [R || S || V] signature.
+type Signer interface {
+	Address() common.Address
+	SignDigest(ctx context.Context, digest common.Hash) ([]byte, error)
+}
+
+// UseRemoteSigners returns a copy of the wallet which signs with signers in addition to its
+// other signers.
+func (w *Wallet) UseRemoteSigners(signers ...Signer) (*Wallet, error) {
+	ww, err := w.UseSigners(w.signers...)
+	if err != nil {
+		return nil, fmt.Errorf("sequence.Wallet#UseRemoteSigners: %w", err)
+	}
+	ww.chainID = w.chainID
+	ww.remoteSigners = append(append([]Signer{}, w.remoteSigners...), signers...)
+	return ww, nil
+}
+
+func (w *Wallet) getRemoteSigner(address common.Address) (Signer, bool) {
+	for _, s := range w.remoteSigners {
+		if s.Address() == address {
+			return s, true
+		}
+	}
+	return nil, false
+}
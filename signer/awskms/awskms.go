@@ -0,0 +1,58 @@
+// Package awskms signs for wallets with secp256k1 keys held by AWS KMS, ie. keys of the
+// ECC_SECG_P256K1 key spec with the SIGN_VERIFY usage:
+//
+//	client := kms.NewFromConfig(awsConfig)
+//	kmsSigner, err := awskms.NewSigner(ctx, client, keyID)
+//
+// It is a separate module, so depending on go-sequence does not require the AWS SDK.
+package awskms
+
+import (
+	"context"
+
+	"github.com/0xsequence/go-sequence/signer"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// API is the subset of the methods of kms.Client used to sign.
+type API interface {
+	GetPublicKey(ctx context.Context, params *kms.GetPublicKeyInput, optFns ...func(*kms.Options)) (*kms.GetPublicKeyOutput, error)
+	Sign(ctx context.Context, params *kms.SignInput, optFns ...func(*kms.Options)) (*kms.SignOutput, error)
+}
+
+// NewClient returns the signer.KMSClient of api.
+func NewClient(api API) signer.KMSClient {
+	return &client{api: api}
+}
+
+// NewSigner returns a signer of the key of keyID, its id, ARN or alias, held by api.
+func NewSigner(ctx context.Context, api API, keyID string) (*signer.KMSSigner, error) {
+	return signer.NewKMSSigner(ctx, NewClient(api), keyID)
+}
+
+type client struct {
+	api API
+}
+
+func (c *client) PublicKey(ctx context.Context, keyID string) ([]byte, error) {
+	out, err := c.api.GetPublicKey(ctx, &kms.GetPublicKeyInput{KeyId: aws.String(keyID)})
+	if err != nil {
+		return nil, err
+	}
+	return out.PublicKey, nil
+}
+
+func (c *client) Sign(ctx context.Context, keyID string, digest []byte) ([]byte, error) {
+	out, err := c.api.Sign(ctx, &kms.SignInput{
+		KeyId:            aws.String(keyID),
+		Message:          digest,
+		MessageType:      types.MessageTypeDigest,
+		SigningAlgorithm: types.SigningAlgorithmSpecEcdsaSha256,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Signature, nil
+}
@@ -0,0 +1,50 @@
+package awskms_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence/signer/awskms"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// publicKey is the DER encoded public key of a ECC_SECG_P256K1 key, as returned by KMS.
+const publicKey = "3056301006072a8648ce3d020106052b8104000a034200" +
+	"04" + "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798" +
+	"483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"
+
+type fakeAPI struct {
+	signed *kms.SignInput
+}
+
+func (a *fakeAPI) GetPublicKey(ctx context.Context, params *kms.GetPublicKeyInput, optFns ...func(*kms.Options)) (*kms.GetPublicKeyOutput, error) {
+	return &kms.GetPublicKeyOutput{KeyId: params.KeyId, PublicKey: common.FromHex(publicKey)}, nil
+}
+
+func (a *fakeAPI) Sign(ctx context.Context, params *kms.SignInput, optFns ...func(*kms.Options)) (*kms.SignOutput, error) {
+	a.signed = params
+	return nil, errors.New("kms: access denied")
+}
+
+func TestSigner(t *testing.T) {
+	ctx := context.Background()
+	api := &fakeAPI{}
+
+	s, err := awskms.NewSigner(ctx, api, "alias/session-key")
+	assert.NoError(t, err)
+	// the public key of the private key 1
+	assert.Equal(t, common.HexToAddress("0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf"), s.Address())
+
+	_, err = s.SignDigest(ctx, common.HexToHash("0x01"))
+	assert.ErrorContains(t, err, "access denied")
+	assert.Equal(t, aws.String("alias/session-key"), api.signed.KeyId)
+	assert.Equal(t, types.MessageTypeDigest, api.signed.MessageType)
+	assert.Equal(t, types.SigningAlgorithmSpecEcdsaSha256, api.signed.SigningAlgorithm)
+	assert.Len(t, api.signed.Message, 32)
+
+}
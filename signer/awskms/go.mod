@@ -0,0 +1,50 @@
+module github.com/0xsequence/go-sequence/signer/awskms
+
+go 1.20
+
+// the integration is developed against the go-sequence of this repository
+replace github.com/0xsequence/go-sequence => ../..
+
+require (
+	github.com/0xsequence/ethkit v1.19.4
+	github.com/0xsequence/go-sequence v0.0.0-00010101000000-000000000000
+	github.com/aws/aws-sdk-go-v2 v1.26.0
+	github.com/aws/aws-sdk-go-v2/service/kms v1.30.0
+	github.com/stretchr/testify v1.8.1
+)
+
+require (
+	github.com/0xsequence/go-ethauth v0.13.0 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/configsources v1.3.4 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/endpoints/v2 v2.6.4 // indirect
+	github.com/aws/smithy-go v1.20.1 // indirect
+	github.com/btcsuite/btcd v0.23.4 // indirect
+	github.com/btcsuite/btcd/btcec/v2 v2.3.2 // indirect
+	github.com/btcsuite/btcd/btcutil v1.1.3 // indirect
+	github.com/btcsuite/btcd/chaincfg/chainhash v1.0.2 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/deckarep/golang-set v1.8.0 // indirect
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.1.0 // indirect
+	github.com/go-stack/stack v1.8.1 // indirect
+	github.com/google/uuid v1.3.0 // indirect
+	github.com/gorilla/websocket v1.5.0 // indirect
+	github.com/goware/breaker v0.1.2 // indirect
+	github.com/goware/cachestore v0.5.0 // indirect
+	github.com/goware/calc v0.2.0 // indirect
+	github.com/goware/channel v0.2.2 // indirect
+	github.com/goware/logadapter-zerolog v0.1.0 // indirect
+	github.com/goware/logger v0.1.0 // indirect
+	github.com/goware/superr v0.0.2 // indirect
+	github.com/hashicorp/golang-lru/v2 v2.0.1 // indirect
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.17 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/rs/zerolog v1.28.0 // indirect
+	github.com/tyler-smith/go-bip39 v1.1.0 // indirect
+	golang.org/x/crypto v0.6.0 // indirect
+	golang.org/x/exp v0.0.0-20230124195608-d38c7dcee874 // indirect
+	golang.org/x/net v0.6.0 // indirect
+	golang.org/x/sys v0.5.0 // indirect
+	golang.org/x/text v0.7.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,99 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/go-sequence"
+)
+
+// HTTPClient is the client of a HTTPSigner, ie. http.DefaultClient.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// HTTPSigner signs with a key held by a generic HTTP signing endpoint, ie. a signing service
+// in front of a HSM. Digests are POSTed to the endpoint as JSON:
+//
+//	{"address": "0x...", "digest": "0x..."}
+//
+// which responds with the hex encoded 65 bytes [R || S || V] signature of the digest:
+//
+//	{"signature": "0x..."}
+type HTTPSigner struct {
+	url     string
+	address common.Address
+	client  HTTPClient
+	header  http.Header
+}
+
+var _ sequence.Signer = &HTTPSigner{}
+
+// NewHTTPSigner returns a signer of address by the endpoint at url, requested with client, or
+// http.DefaultClient if nil.
+func NewHTTPSigner(url string, address common.Address, client HTTPClient) *HTTPSigner {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPSigner{url: url, address: address, client: client, header: http.Header{}}
+}
+
+// SetHeader sets a header of the requests to the endpoint, ie. the credentials they are
+// authenticated with.
+func (s *HTTPSigner) SetHeader(key, value string) *HTTPSigner {
+	s.header.Set(key, value)
+	return s
+}
+
+func (s *HTTPSigner) Address() common.Address {
+	return s.address
+}
+
+// SignDigest requests the signature of digest from the endpoint, and checks it is of the
+// address of the signer.
+func (s *HTTPSigner) SignDigest(ctx context.Context, digest common.Hash) ([]byte, error) {
+	body, err := json.Marshal(map[string]string{"address": s.address.Hex(), "digest": digest.Hex()})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("signer: %w", err)
+	}
+	for key := range s.header {
+		req.Header.Set(key, s.header.Get(key))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("signer: signing request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(res.Body, 1<<16))
+	if err != nil {
+		return nil, fmt.Errorf("signer: signing request failed: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("signer: signing request failed with status %d: %s", res.StatusCode, bytes.TrimSpace(respBody))
+	}
+
+	var out struct {
+		Signature string `json:"signature"`
+	}
+	if err := json.Unmarshal(respBody, &out); err != nil {
+		return nil, fmt.Errorf("signer: invalid signing response: %w", err)
+	}
+	sig, err := hexutil.Decode(out.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("signer: invalid signing response: %w", err)
+	}
+	return verify(s.address, digest, sig)
+}
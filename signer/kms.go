@@ -0,0 +1,107 @@
+package signer
+
+import (
+	"context"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence"
+)
+
+// KMSClient is the client of a KMS holding secp256k1 keys, ie. the ECC_SECG_P256K1 keys of
+// AWS KMS, see the awskms module.
+type KMSClient interface {
+	// PublicKey returns the DER encoded SubjectPublicKeyInfo of the key of keyID.
+	PublicKey(ctx context.Context, keyID string) ([]byte, error)
+
+	// Sign returns the DER encoded ECDSA signature of digest by the key of keyID, without
+	// hashing it.
+	Sign(ctx context.Context, keyID string, digest []byte) ([]byte, error)
+}
+
+// KMSSigner signs with a secp256k1 key held by a KMS.
+type KMSSigner struct {
+	client  KMSClient
+	keyID   string
+	address common.Address
+}
+
+var _ sequence.Signer = &KMSSigner{}
+
+// secp256k1N is the order of the secp256k1 curve, and secp256k1HalfN half of it.
+var (
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// NewKMSSigner returns a signer of the key of keyID held by client, whose address is derived
+// from its public key.
+func NewKMSSigner(ctx context.Context, client KMSClient, keyID string) (*KMSSigner, error) {
+	der, err := client.PublicKey(ctx, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("signer: failed to get public key of %s: %w", keyID, err)
+	}
+
+	var spki struct {
+		Algorithm struct {
+			Algorithm  asn1.ObjectIdentifier
+			Parameters asn1.RawValue `asn1:"optional"`
+		}
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(der, &spki); err != nil {
+		return nil, fmt.Errorf("signer: invalid public key of %s: %w", keyID, err)
+	}
+	pubkey, err := crypto.UnmarshalPubkey(spki.PublicKey.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("signer: public key of %s is not a secp256k1 key: %w", keyID, err)
+	}
+
+	return &KMSSigner{client: client, keyID: keyID, address: crypto.PubkeyToAddress(*pubkey)}, nil
+}
+
+func (s *KMSSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *KMSSigner) KeyID() string {
+	return s.keyID
+}
+
+// SignDigest signs digest with the key, and returns its signature in the [R || S || V]
+// format of Ethereum, with its S value in the lower half of the curve order as required
+// by the EVM.
+func (s *KMSSigner) SignDigest(ctx context.Context, digest common.Hash) ([]byte, error) {
+	der, err := s.client.Sign(ctx, s.keyID, digest[:])
+	if err != nil {
+		return nil, fmt.Errorf("signer: failed to sign with %s: %w", s.keyID, err)
+	}
+
+	var rs struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(der, &rs); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if rs.R == nil || rs.S == nil || rs.R.Sign() <= 0 || rs.S.Sign() <= 0 || rs.R.Cmp(secp256k1N) >= 0 || rs.S.Cmp(secp256k1N) >= 0 {
+		return nil, fmt.Errorf("%w: signature values out of range", ErrInvalidSignature)
+	}
+	if rs.S.Cmp(secp256k1HalfN) > 0 {
+		rs.S = new(big.Int).Sub(secp256k1N, rs.S)
+	}
+
+	// the KMS does not return the recovery id, so V is the one recovering the address
+	sig := make([]byte, 65)
+	rs.R.FillBytes(sig[:32])
+	rs.S.FillBytes(sig[32:64])
+	for v := byte(0); v < 2; v++ {
+		sig[64] = v
+		if verified, err := verify(s.address, digest, sig); err == nil {
+			return verified, nil
+		}
+	}
+	return nil, ErrInvalidSignature
+}
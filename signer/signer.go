@@ -0,0 +1,37 @@
+// Package signer adapts keys which are not held in-process to sequence.Signer, so services
+// can keep the session keys of their wallets in a HSM or KMS, and still sign for them:
+//
+//	kmsSigner, err := signer.NewKMSSigner(ctx, kmsClient, keyID)
+//	wallet, err := sequence.NewWallet(sequence.WalletOptions{Config: config, Signers: []sequence.Signer{kmsSigner}})
+//
+// The KMS adapter of AWS is the github.com/0xsequence/go-sequence/signer/awskms module, so
+// the AWS SDK is only required by its users.
+package signer
+
+import (
+	"errors"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// ErrInvalidSignature is returned when a signature is not of the key of a signer.
+var ErrInvalidSignature = errors.New("signer: invalid signature")
+
+// verify checks sig is the signature of digest by address, and returns it with V as 27 or
+// 28, as ethwallet signs.
+func verify(address common.Address, digest common.Hash, sig []byte) ([]byte, error) {
+	if len(sig) != 65 {
+		return nil, ErrInvalidSignature
+	}
+	sig = append([]byte{}, sig...)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	pubkey, err := crypto.SigToPub(digest[:], sig)
+	if err != nil || crypto.PubkeyToAddress(*pubkey) != address {
+		return nil, ErrInvalidSignature
+	}
+	sig[64] += 27
+	return sig, nil
+}
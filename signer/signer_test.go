@@ -0,0 +1,116 @@
+package signer_test
+
+import (
+	"context"
+	"encoding/asn1"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/signer"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeKMS signs with a local key, returning DER encoded signatures as a KMS does.
+type fakeKMS struct {
+	key   *ethwallet.Wallet
+	highS bool
+}
+
+func (k *fakeKMS) PublicKey(ctx context.Context, keyID string) ([]byte, error) {
+	type algorithm struct {
+		Algorithm  asn1.ObjectIdentifier
+		Parameters asn1.ObjectIdentifier
+	}
+	pubkey := crypto.FromECDSAPub(&k.key.PrivateKey().PublicKey)
+	return asn1.Marshal(struct {
+		Algorithm algorithm
+		PublicKey asn1.BitString
+	}{
+		Algorithm: algorithm{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}, Parameters: asn1.ObjectIdentifier{1, 3, 132, 0, 10}},
+		PublicKey: asn1.BitString{Bytes: pubkey, BitLength: 8 * len(pubkey)},
+	})
+}
+
+func (k *fakeKMS) Sign(ctx context.Context, keyID string, digest []byte) ([]byte, error) {
+	sig, err := crypto.Sign(digest, k.key.PrivateKey())
+	if err != nil {
+		return nil, err
+	}
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64])
+	if k.highS {
+		s.Sub(crypto.S256().Params().N, s)
+	}
+	return asn1.Marshal(struct{ R, S *big.Int }{r, s})
+}
+
+func TestSigners(t *testing.T) {
+	ctx := context.Background()
+
+	kmsKey, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	httpKey, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(2))
+	assert.NoError(t, err)
+
+	kmsSigner, err := signer.NewKMSSigner(ctx, &fakeKMS{key: kmsKey, highS: true}, "key-1")
+	assert.NoError(t, err)
+	assert.Equal(t, kmsKey.Address(), kmsSigner.Address())
+
+	digest := crypto.Keccak256Hash([]byte("digest"))
+	sig, err := kmsSigner.SignDigest(ctx, digest)
+	assert.NoError(t, err)
+	assert.True(t, sig[64] == 27 || sig[64] == 28)
+	assert.True(t, crypto.ValidateSignatureValues(sig[64]-27, new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64]), true))
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var in struct {
+			Address common.Address `json:"address"`
+			Digest  common.Hash    `json:"digest"`
+		}
+		json.NewDecoder(r.Body).Decode(&in)
+		sig, _ := crypto.Sign(in.Digest[:], httpKey.PrivateKey())
+		json.NewEncoder(w).Encode(map[string]string{"signature": hexutil.Encode(sig)})
+	}))
+	defer ts.Close()
+
+	httpSigner := signer.NewHTTPSigner(ts.URL, httpKey.Address(), nil)
+	_, err = httpSigner.SignDigest(ctx, digest)
+	assert.ErrorContains(t, err, "401")
+
+	httpSigner.SetHeader("Authorization", "Bearer token")
+	_, err = httpSigner.SignDigest(ctx, digest)
+	assert.NoError(t, err)
+
+	// the endpoint signing with another key is rejected
+	_, err = signer.NewHTTPSigner(ts.URL, kmsKey.Address(), nil).SetHeader("Authorization", "Bearer token").SignDigest(ctx, digest)
+	assert.ErrorIs(t, err, signer.ErrInvalidSignature)
+
+	// a wallet signs with both, without holding any key in-process
+	config := sequence.WalletConfig{Threshold: 2, Signers: sequence.WalletConfigSigners{
+		{Weight: 1, Address: kmsKey.Address()},
+		{Weight: 1, Address: httpKey.Address()},
+	}}
+	wallet, err := sequence.NewWallet(sequence.WalletOptions{Config: config, Signers: []sequence.Signer{kmsSigner, httpSigner}})
+	assert.NoError(t, err)
+	wallet.SetChainID(big.NewInt(1))
+
+	signed, err := wallet.SignTransactionsWithNonce(ctx, sequence.Transactions{{To: common.HexToAddress("0x7a7"), RevertOnError: true}}, big.NewInt(0))
+	assert.NoError(t, err)
+	weight, err := wallet.SignatureWeight(signed)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(2), weight)
+	_, err = wallet.FinalizeTransactions(signed)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,124 @@
+package sequence
+
+import (
+	"sort"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// SignerReport is the power the signers of a fleet of wallets have over them, so the blast
+// radius of a key is known before it is rotated, or once it is compromised.
+type SignerReport struct {
+	// Signers are the exposures of the signers of the wallets, the most powerful first: by
+	// the number of wallets they can sign for alone, then the number of wallets they are
+	// critical to, then the number of wallets they are a signer of.
+	Signers []*SignerExposure
+
+	bySigner map[common.Address]*SignerExposure
+}
+
+// SignerExposure is the power a signer has over the wallets it is a signer of.
+type SignerExposure struct {
+	Signer common.Address
+
+	// Wallets are the powers of the signer over each of its wallets, sorted by address.
+	Wallets []SignerPower
+
+	// SoleSigner is the number of wallets the signer can sign for alone.
+	SoleSigner int
+
+	// Critical is the number of wallets which can't sign without the signer.
+	Critical int
+}
+
+// SignerPower is the power of a signer over a wallet.
+type SignerPower struct {
+	Wallet common.Address
+
+	// Weight is the weight of the signer, summed if it is a signer of the config more than
+	// once.
+	Weight uint64
+
+	// Threshold is the threshold of the config, and TotalWeight the weight of all its signers.
+	Threshold   uint16
+	TotalWeight uint64
+
+	// Power is the share of the threshold the signer has, from 0 to 1.
+	Power float64
+
+	// SoleSigner is set if the signer can sign for the wallet alone.
+	SoleSigner bool
+
+	// Critical is set if the other signers don't have the weight to sign for the wallet, so
+	// it can't sign once the signer is lost, ie. if its key is revoked without rotation.
+	Critical bool
+}
+
+// AnalyzeSigners reports the power of the signers of configs, the configs of wallets by
+// address.
+func AnalyzeSigners(configs map[common.Address]WalletConfig) *SignerReport {
+	report := &SignerReport{bySigner: map[common.Address]*SignerExposure{}}
+
+	for wallet, config := range configs {
+		weights := map[common.Address]uint64{}
+		var total uint64
+		for _, signer := range config.Signers {
+			weights[signer.Address] += uint64(signer.Weight)
+			total += uint64(signer.Weight)
+		}
+
+		for signer, weight := range weights {
+			power := SignerPower{
+				Wallet:      wallet,
+				Weight:      weight,
+				Threshold:   config.Threshold,
+				TotalWeight: total,
+				Power:       1,
+				SoleSigner:  weight >= uint64(config.Threshold),
+				Critical:    total-weight < uint64(config.Threshold),
+			}
+			if config.Threshold > 0 && weight < uint64(config.Threshold) {
+				power.Power = float64(weight) / float64(config.Threshold)
+			}
+
+			exposure := report.bySigner[signer]
+			if exposure == nil {
+				exposure = &SignerExposure{Signer: signer}
+				report.bySigner[signer] = exposure
+				report.Signers = append(report.Signers, exposure)
+			}
+			exposure.Wallets = append(exposure.Wallets, power)
+			if power.SoleSigner {
+				exposure.SoleSigner++
+			}
+			if power.Critical {
+				exposure.Critical++
+			}
+		}
+	}
+
+	for _, exposure := range report.Signers {
+		sort.Slice(exposure.Wallets, func(i, j int) bool {
+			return exposure.Wallets[i].Wallet.Hash().Big().Cmp(exposure.Wallets[j].Wallet.Hash().Big()) < 0
+		})
+	}
+	sort.Slice(report.Signers, func(i, j int) bool {
+		a, b := report.Signers[i], report.Signers[j]
+		if a.SoleSigner != b.SoleSigner {
+			return a.SoleSigner > b.SoleSigner
+		}
+		if a.Critical != b.Critical {
+			return a.Critical > b.Critical
+		}
+		if len(a.Wallets) != len(b.Wallets) {
+			return len(a.Wallets) > len(b.Wallets)
+		}
+		return a.Signer.Hash().Big().Cmp(b.Signer.Hash().Big()) < 0
+	})
+	return report
+}
+
+// Signer returns the exposure of signer, or nil if it isn't a signer of any wallet.
+func (r *SignerReport) Signer(signer common.Address) *SignerExposure {
+	return r.bySigner[signer]
+}
@@ -0,0 +1,43 @@
+package sequence_test
+
+import (
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeSigners(t *testing.T) {
+	shared := common.HexToAddress("0x01")
+	a := common.HexToAddress("0x02")
+	b := common.HexToAddress("0x03")
+
+	report := sequence.AnalyzeSigners(map[common.Address]sequence.WalletConfig{
+		// shared signs alone
+		common.HexToAddress("0x11"): {Threshold: 1, Signers: sequence.WalletConfigSigners{{Weight: 1, Address: shared}}},
+		// shared is one of two signers of a 2 of 2
+		common.HexToAddress("0x12"): {Threshold: 2, Signers: sequence.WalletConfigSigners{{Weight: 1, Address: shared}, {Weight: 1, Address: a}}},
+		// shared is one of three signers of a 2 of 3
+		common.HexToAddress("0x13"): {Threshold: 2, Signers: sequence.WalletConfigSigners{{Weight: 1, Address: shared}, {Weight: 1, Address: a}, {Weight: 1, Address: b}}},
+	})
+
+	assert.Len(t, report.Signers, 3)
+	assert.Equal(t, shared, report.Signers[0].Signer)
+
+	exposure := report.Signer(shared)
+	assert.Equal(t, 1, exposure.SoleSigner)
+	assert.Equal(t, 2, exposure.Critical)
+	assert.Equal(t, []sequence.SignerPower{
+		{Wallet: common.HexToAddress("0x11"), Weight: 1, Threshold: 1, TotalWeight: 1, Power: 1, SoleSigner: true, Critical: true},
+		{Wallet: common.HexToAddress("0x12"), Weight: 1, Threshold: 2, TotalWeight: 2, Power: 0.5, Critical: true},
+		{Wallet: common.HexToAddress("0x13"), Weight: 1, Threshold: 2, TotalWeight: 3, Power: 0.5},
+	}, exposure.Wallets)
+
+	exposure = report.Signer(a)
+	assert.Equal(t, 0, exposure.SoleSigner)
+	assert.Equal(t, 1, exposure.Critical)
+	assert.Len(t, exposure.Wallets, 2)
+
+	assert.Nil(t, report.Signer(common.HexToAddress("0x04")))
+}
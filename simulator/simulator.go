@@ -0,0 +1,192 @@
+// Package simulator simulates the native transactions carrying bundles, ie. to preview what a
+// signed bundle does before it is relayed. A Backend simulates a call, with state overrides:
+// EthCall through the eth_call of a node, which reports whether the call succeeds, or
+// Tenderly through its simulation API, which also reports the call trace, logs and the
+// changes of assets of the accounts:
+//
+//	backend := simulator.NewTenderly("account", "project", accessKey)
+//	result, err := simulator.PreviewBundle(ctx, backend, wallet, signed)
+//	for _, change := range result.AssetChanges { ... }
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethrpc/jsonrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+)
+
+// Request is a call to simulate.
+type Request struct {
+	ChainID *big.Int
+
+	// From is the sender of the call, ie. the sender of a relayer, or the zero address.
+	From  common.Address
+	To    common.Address
+	Data  []byte
+	Value *big.Int
+
+	// Gas is the gas limit of the call, 0 for the default of the backend.
+	Gas uint64
+
+	// BlockNumber is the block the call is simulated at, nil for the latest block.
+	BlockNumber *big.Int
+
+	// Overrides replace the state of accounts for the simulation, ie. to fund a wallet.
+	Overrides map[common.Address]*sequence.CallOverride
+}
+
+// Result is the outcome of a simulation. The fields other than Success, ReturnData and Error
+// are only reported by backends which trace the call.
+type Result struct {
+	Success    bool
+	ReturnData []byte
+	Error      string
+
+	GasUsed uint64
+	Logs    []*types.Log
+
+	// Trace is the tree of the calls made, nil if not traced.
+	Trace *CallTrace
+
+	// AssetChanges are the transfers, mints and burns of the native token and tokens.
+	AssetChanges []*AssetChange
+
+	// URL is where the simulation can be inspected, if it was saved by the backend.
+	URL string
+}
+
+// CallTrace is a call of a simulation, and the calls it made.
+type CallTrace struct {
+	Type    string
+	From    common.Address
+	To      common.Address
+	Value   *big.Int
+	Input   []byte
+	Output  []byte
+	GasUsed uint64
+	Error   string
+	Calls   []*CallTrace
+}
+
+// AssetChange is a change of the balances of an asset by a simulation.
+type AssetChange struct {
+	// Type is the kind of change, ie. "Transfer", "Mint" or "Burn".
+	Type string
+
+	// Standard is the standard of the asset, ie. "NativeCurrency", "ERC20", "ERC721" or
+	// "ERC1155". Token is the zero address for the native token.
+	Standard string
+	Token    common.Address
+	Symbol   string
+	Decimals int
+	TokenID  *big.Int
+
+	From   common.Address
+	To     common.Address
+	Amount *big.Int
+}
+
+// Backend simulates calls.
+type Backend interface {
+	Simulate(ctx context.Context, req *Request) (*Result, error)
+}
+
+// BackendFunc is a Backend of a func.
+type BackendFunc func(ctx context.Context, req *Request) (*Result, error)
+
+func (f BackendFunc) Simulate(ctx context.Context, req *Request) (*Result, error) {
+	return f(ctx, req)
+}
+
+// PreviewBundle simulates the relay of signed, the bundle of wallet, with backend: the native
+// transaction sending it to the wallet, or deploying the wallet with it while it isn't deployed,
+// see sequence.Wallet.BuildDeployAndExecute.
+func PreviewBundle(ctx context.Context, backend Backend, wallet *sequence.Wallet, signed *sequence.SignedTransactions) (*Result, error) {
+	to, execdata, err := wallet.BuildDeployAndExecute(ctx, signed)
+	if err != nil {
+		return nil, err
+	}
+
+	chainID := signed.ChainID
+	if chainID == nil {
+		chainID = wallet.GetChainID()
+	}
+	result, err := backend.Simulate(ctx, &Request{ChainID: chainID, To: to, Data: execdata})
+	if err != nil {
+		return nil, fmt.Errorf("simulator: unable to preview bundle of %v: %w", wallet.Address().Hex(), err)
+	}
+	return result, nil
+}
+
+// EthCall is a Backend simulating calls with the eth_call of a node, which must support state
+// overrides to simulate requests with overrides. It doesn't trace calls.
+type EthCall struct {
+	provider *ethrpc.Provider
+}
+
+var _ Backend = &EthCall{}
+
+// NewEthCall returns a backend simulating calls with the eth_call of provider.
+func NewEthCall(provider *ethrpc.Provider) *EthCall {
+	return &EthCall{provider: provider}
+}
+
+type ethCallParams struct {
+	From  common.Address `json:"from"`
+	To    common.Address `json:"to"`
+	Data  string         `json:"data"`
+	Value string         `json:"value,omitempty"`
+	Gas   string         `json:"gas,omitempty"`
+}
+
+// Simulate calls req at its block. A call which reverts is a Result which didn't succeed,
+// with its revert data, while errors of the node are returned.
+func (b *EthCall) Simulate(ctx context.Context, req *Request) (*Result, error) {
+	params := ethCallParams{From: req.From, To: req.To, Data: hexutil.Encode(req.Data)}
+	if req.Value != nil {
+		params.Value = hexutil.EncodeBig(req.Value)
+	}
+	if req.Gas != 0 {
+		params.Gas = hexutil.EncodeUint64(req.Gas)
+	}
+	block := "latest"
+	if req.BlockNumber != nil {
+		block = hexutil.EncodeBig(req.BlockNumber)
+	}
+
+	args := []interface{}{params, block}
+	if len(req.Overrides) != 0 {
+		args = append(args, req.Overrides)
+	}
+
+	var output string
+	err := b.provider.Do(ctx, ethrpc.NewCallBuilder[string]("eth_call", nil, args...).Into(&output))
+	var rpcErr *jsonrpc.Error
+	if errors.As(err, &rpcErr) && strings.Contains(strings.ToLower(rpcErr.Message), "revert") {
+		result := &Result{Error: rpcErr.Message}
+		var data string
+		if json.Unmarshal(rpcErr.Data, &data) == nil {
+			result.ReturnData, _ = hexutil.Decode(data)
+		}
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("simulator: eth_call failed: %w", err)
+	}
+
+	returnData, err := hexutil.Decode(output)
+	if err != nil {
+		return nil, fmt.Errorf("simulator: invalid eth_call output: %w", err)
+	}
+	return &Result{Success: true, ReturnData: returnData}, nil
+}
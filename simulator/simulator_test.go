@@ -0,0 +1,180 @@
+package simulator_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/simulator"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+const tenderlyResponse = `{
+	"transaction": {
+		"status": true,
+		"gas_used": 84000,
+		"error_message": "",
+		"transaction_info": {
+			"call_trace": {
+				"call_type": "CALL",
+				"from": "0x0000000000000000000000000000000000000000",
+				"to": "0xf0ba65550f2d1dccf4b131b774844dc3d801d886",
+				"value": "0x0",
+				"input": "0x7a9a1628",
+				"output": "0x",
+				"gas_used": 84000,
+				"calls": [{
+					"call_type": "CALL",
+					"from": "0xf0ba65550f2d1dccf4b131b774844dc3d801d886",
+					"to": "0x00000000000000000000000000000000000070c3",
+					"value": "0",
+					"input": "0xa9059cbb",
+					"output": "0x0000000000000000000000000000000000000000000000000000000000000001",
+					"gas_used": 30000
+				}]
+			},
+			"logs": [{
+				"name": "Transfer",
+				"raw": {
+					"address": "0x00000000000000000000000000000000000070c3",
+					"topics": ["0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"],
+					"data": "0x64"
+				}
+			}],
+			"asset_changes": [{
+				"token_info": {"standard": "ERC20", "type": "Fungible", "contract_address": "0x00000000000000000000000000000000000070c3", "symbol": "USDC", "decimals": 6},
+				"type": "Transfer",
+				"from": "0xf0ba65550f2d1dccf4b131b774844dc3d801d886",
+				"to": "0x00000000000000000000000000000000000007a7",
+				"amount": "0.0001",
+				"raw_amount": "100"
+			}]
+		}
+	},
+	"simulation": {"id": "sim-1"}
+}`
+
+func TestTenderly(t *testing.T) {
+	var request map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/account/acme/project/wallets/simulate", r.URL.Path)
+		if r.Header.Get("X-Access-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error": {"message": "invalid access key"}}`))
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		_, _ = w.Write([]byte(tenderlyResponse))
+	}))
+	defer ts.Close()
+
+	wallet := common.HexToAddress("0xf0ba65550f2d1dccf4b131b774844dc3d801d886")
+	req := &simulator.Request{
+		ChainID:     big.NewInt(137),
+		To:          wallet,
+		Data:        []byte{0x7a, 0x9a, 0x16, 0x28},
+		BlockNumber: big.NewInt(100),
+		Overrides: map[common.Address]*sequence.CallOverride{
+			wallet: {Balance: big.NewInt(1e18), StateDiff: []*sequence.StateOverride{{Key: "0x01", Value: "0x02"}}},
+		},
+	}
+
+	backend := simulator.NewTenderly("acme", "wallets", "secret").SetBaseURL(ts.URL + "/").SetSave(true)
+	result, err := backend.Simulate(context.Background(), req)
+	assert.NoError(t, err)
+
+	// the request is simulated at its block, with its state overrides
+	assert.Equal(t, "137", request["network_id"])
+	assert.Equal(t, "0x7a9a1628", request["input"])
+	assert.Equal(t, float64(100), request["block_number"])
+	assert.Equal(t, map[string]interface{}{
+		"0xf0ba65550f2d1dccf4b131b774844dc3d801d886": map[string]interface{}{"balance": "1000000000000000000", "storage": map[string]interface{}{"0x01": "0x02"}},
+	}, request["state_objects"])
+
+	assert.True(t, result.Success)
+	assert.Equal(t, uint64(84000), result.GasUsed)
+	assert.Equal(t, "https://dashboard.tenderly.co/acme/wallets/simulator/sim-1", result.URL)
+	assert.Len(t, result.Logs, 1)
+	assert.Equal(t, sequence.ERC20TransferEventSig, result.Logs[0].Topics[0])
+
+	assert.Equal(t, wallet, result.Trace.To)
+	assert.Len(t, result.Trace.Calls, 1)
+	assert.Equal(t, uint64(30000), result.Trace.Calls[0].GasUsed)
+	assert.Equal(t, common.HexToHash("0x01").Bytes(), result.Trace.Calls[0].Output)
+
+	assert.Len(t, result.AssetChanges, 1)
+	change := result.AssetChanges[0]
+	assert.Equal(t, "ERC20", change.Standard)
+	assert.Equal(t, common.HexToAddress("0x70c3"), change.Token)
+	assert.Equal(t, wallet, change.From)
+	assert.Equal(t, big.NewInt(100), change.Amount)
+	assert.Equal(t, 6, change.Decimals)
+
+	_, err = simulator.NewTenderly("acme", "wallets", "wrong").SetBaseURL(ts.URL).Simulate(context.Background(), req)
+	assert.ErrorContains(t, err, "invalid access key")
+}
+
+func TestEthCall(t *testing.T) {
+	chain := memchain.New(memchain.Options{})
+	revert := false
+	chain.Deploy(common.HexToAddress("0x7a7"), memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		if revert {
+			return nil, &memchain.RevertError{Data: hexutil.MustDecode("0x08c379a0")}
+		}
+		return []byte{0x01}, nil
+	}))
+	provider := chain.Provider()
+
+	backend := simulator.NewEthCall(provider)
+	result, err := backend.Simulate(context.Background(), &simulator.Request{To: common.HexToAddress("0x7a7")})
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, []byte{0x01}, result.ReturnData)
+	assert.Nil(t, result.Trace)
+
+	revert = true
+	result, err = backend.Simulate(context.Background(), &simulator.Request{To: common.HexToAddress("0x7a7")})
+	assert.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Equal(t, "execution reverted", result.Error)
+	assert.Equal(t, hexutil.MustDecode("0x08c379a0"), result.ReturnData)
+}
+
+func TestPreviewBundle(t *testing.T) {
+	ctx := context.Background()
+	provider := memchain.New(memchain.Options{ChainID: big.NewInt(137)}).Provider()
+
+	owner, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(owner)
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.SetProvider(provider))
+
+	signed, err := wallet.SignTransactionsWithNonce(ctx, sequence.Transactions{{To: common.HexToAddress("0x7a7"), Value: big.NewInt(1), RevertOnError: true}}, big.NewInt(0))
+	assert.NoError(t, err)
+
+	// the wallet isn't deployed, so the relay is previewed with its deployment
+	var simulated *simulator.Request
+	backend := simulator.BackendFunc(func(ctx context.Context, req *simulator.Request) (*simulator.Result, error) {
+		simulated = req
+		return &simulator.Result{Success: true}, nil
+	})
+	result, err := simulator.PreviewBundle(ctx, backend, wallet, signed)
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, big.NewInt(137), simulated.ChainID)
+	assert.Equal(t, wallet.GetWalletContext().GuestModuleAddress, simulated.To)
+
+	_, execdata, err := wallet.BuildDeployAndExecute(ctx, signed)
+	assert.NoError(t, err)
+	assert.Equal(t, execdata, simulated.Data)
+}
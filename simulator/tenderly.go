@@ -0,0 +1,303 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+)
+
+const (
+	// DefaultTenderlyURL is the url of the Tenderly API.
+	DefaultTenderlyURL = "https://api.tenderly.co/api/v1"
+
+	// DefaultTenderlyGas is the gas limit of the simulations of requests without one.
+	DefaultTenderlyGas = 30_000_000
+
+	defaultTenderlyTimeout = 30 * time.Second
+)
+
+// Tenderly is a Backend simulating calls with the simulation API of Tenderly, which traces
+// them and summarizes their changes of assets.
+type Tenderly struct {
+	baseURL   string
+	account   string
+	project   string
+	accessKey string
+	client    *http.Client
+	save      bool
+}
+
+var _ Backend = &Tenderly{}
+
+// NewTenderly returns a backend simulating with the project of account, authenticated by the
+// access key of the account.
+func NewTenderly(account, project, accessKey string) *Tenderly {
+	return &Tenderly{
+		baseURL:   DefaultTenderlyURL,
+		account:   account,
+		project:   project,
+		accessKey: accessKey,
+		client:    &http.Client{Timeout: defaultTenderlyTimeout},
+	}
+}
+
+// SetBaseURL sets the url of the Tenderly API, ie. of a proxy to it.
+func (t *Tenderly) SetBaseURL(baseURL string) *Tenderly {
+	t.baseURL = strings.TrimSuffix(baseURL, "/")
+	return t
+}
+
+func (t *Tenderly) SetHTTPClient(client *http.Client) *Tenderly {
+	t.client = client
+	return t
+}
+
+// SetSave sets whether simulations are saved to the project, so they can be inspected in the
+// dashboard of Tenderly at the URL of their result.
+func (t *Tenderly) SetSave(save bool) *Tenderly {
+	t.save = save
+	return t
+}
+
+type tenderlyRequest struct {
+	NetworkID      string                          `json:"network_id"`
+	From           string                          `json:"from"`
+	To             string                          `json:"to"`
+	Input          string                          `json:"input"`
+	Value          string                          `json:"value"`
+	Gas            uint64                          `json:"gas"`
+	GasPrice       string                          `json:"gas_price"`
+	BlockNumber    *uint64                         `json:"block_number,omitempty"`
+	Save           bool                            `json:"save"`
+	SimulationType string                          `json:"simulation_type"`
+	StateObjects   map[string]*tenderlyStateObject `json:"state_objects,omitempty"`
+}
+
+type tenderlyStateObject struct {
+	Balance string            `json:"balance,omitempty"`
+	Code    string            `json:"code,omitempty"`
+	Nonce   *uint64           `json:"nonce,omitempty"`
+	Storage map[string]string `json:"storage,omitempty"`
+}
+
+type tenderlyResponse struct {
+	Transaction struct {
+		Status          bool   `json:"status"`
+		GasUsed         uint64 `json:"gas_used"`
+		ErrorMessage    string `json:"error_message"`
+		TransactionInfo struct {
+			CallTrace    *tenderlyCallTrace     `json:"call_trace"`
+			Logs         []*tenderlyLog         `json:"logs"`
+			AssetChanges []*tenderlyAssetChange `json:"asset_changes"`
+		} `json:"transaction_info"`
+	} `json:"transaction"`
+	Simulation struct {
+		ID string `json:"id"`
+	} `json:"simulation"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type tenderlyCallTrace struct {
+	CallType string               `json:"call_type"`
+	From     common.Address       `json:"from"`
+	To       common.Address       `json:"to"`
+	Value    string               `json:"value"`
+	Input    string               `json:"input"`
+	Output   string               `json:"output"`
+	GasUsed  uint64               `json:"gas_used"`
+	Error    string               `json:"error"`
+	Calls    []*tenderlyCallTrace `json:"calls"`
+}
+
+type tenderlyLog struct {
+	Raw struct {
+		Address common.Address `json:"address"`
+		Topics  []common.Hash  `json:"topics"`
+		Data    string         `json:"data"`
+	} `json:"raw"`
+}
+
+type tenderlyAssetChange struct {
+	TokenInfo struct {
+		Standard        string         `json:"standard"`
+		ContractAddress common.Address `json:"contract_address"`
+		Symbol          string         `json:"symbol"`
+		Decimals        int            `json:"decimals"`
+	} `json:"token_info"`
+	Type      string         `json:"type"`
+	From      common.Address `json:"from"`
+	To        common.Address `json:"to"`
+	RawAmount string         `json:"raw_amount"`
+	TokenID   string         `json:"token_id"`
+}
+
+// Simulate simulates req with the API. A call which reverts is a Result which didn't succeed,
+// with the error of Tenderly, while errors of the API are returned.
+func (t *Tenderly) Simulate(ctx context.Context, req *Request) (*Result, error) {
+	if req.ChainID == nil {
+		return nil, fmt.Errorf("simulator: tenderly: chain ID is required")
+	}
+
+	body := tenderlyRequest{
+		NetworkID:      req.ChainID.String(),
+		From:           strings.ToLower(req.From.Hex()),
+		To:             strings.ToLower(req.To.Hex()),
+		Input:          hexutil.Encode(req.Data),
+		Value:          "0",
+		Gas:            req.Gas,
+		GasPrice:       "0",
+		Save:           t.save,
+		SimulationType: "full",
+	}
+	if req.Value != nil {
+		body.Value = req.Value.String()
+	}
+	if body.Gas == 0 {
+		body.Gas = DefaultTenderlyGas
+	}
+	if req.BlockNumber != nil {
+		blockNumber := req.BlockNumber.Uint64()
+		body.BlockNumber = &blockNumber
+	}
+	if len(req.Overrides) != 0 {
+		body.StateObjects = make(map[string]*tenderlyStateObject, len(req.Overrides))
+		for address, override := range req.Overrides {
+			body.StateObjects[strings.ToLower(address.Hex())] = tenderlyStateObjectOf(override)
+		}
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("simulator: tenderly: %w", err)
+	}
+	url := fmt.Sprintf("%s/account/%s/project/%s/simulate", t.baseURL, t.account, t.project)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("simulator: tenderly: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-Access-Key", t.accessKey)
+
+	resp, err := t.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("simulator: tenderly request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("simulator: tenderly request failed: %w", err)
+	}
+
+	var response tenderlyResponse
+	if err := json.Unmarshal(respBody, &response); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("simulator: invalid tenderly response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if response.Error != nil && response.Error.Message != "" {
+			return nil, fmt.Errorf("simulator: tenderly request failed with status %d: %s", resp.StatusCode, response.Error.Message)
+		}
+		return nil, fmt.Errorf("simulator: tenderly request failed with status %d", resp.StatusCode)
+	}
+
+	return t.result(&response), nil
+}
+
+func (t *Tenderly) result(response *tenderlyResponse) *Result {
+	txn := &response.Transaction
+	info := &txn.TransactionInfo
+
+	result := &Result{
+		Success: txn.Status,
+		Error:   txn.ErrorMessage,
+		GasUsed: txn.GasUsed,
+	}
+	if info.CallTrace != nil {
+		result.Trace = info.CallTrace.trace()
+		result.ReturnData = result.Trace.Output
+	}
+	for _, log := range info.Logs {
+		data, _ := hexutil.Decode(log.Raw.Data)
+		result.Logs = append(result.Logs, &types.Log{Address: log.Raw.Address, Topics: log.Raw.Topics, Data: data})
+	}
+	for _, change := range info.AssetChanges {
+		result.AssetChanges = append(result.AssetChanges, &AssetChange{
+			Type:     change.Type,
+			Standard: change.TokenInfo.Standard,
+			Token:    change.TokenInfo.ContractAddress,
+			Symbol:   change.TokenInfo.Symbol,
+			Decimals: change.TokenInfo.Decimals,
+			TokenID:  parseBig(change.TokenID),
+			From:     change.From,
+			To:       change.To,
+			Amount:   parseBig(change.RawAmount),
+		})
+	}
+	if t.save && response.Simulation.ID != "" {
+		result.URL = fmt.Sprintf("https://dashboard.tenderly.co/%s/%s/simulator/%s", t.account, t.project, response.Simulation.ID)
+	}
+	return result
+}
+
+func (c *tenderlyCallTrace) trace() *CallTrace {
+	trace := &CallTrace{
+		Type:    c.CallType,
+		From:    c.From,
+		To:      c.To,
+		Value:   parseBig(c.Value),
+		GasUsed: c.GasUsed,
+		Error:   c.Error,
+	}
+	trace.Input, _ = hexutil.Decode(c.Input)
+	trace.Output, _ = hexutil.Decode(c.Output)
+	for _, call := range c.Calls {
+		trace.Calls = append(trace.Calls, call.trace())
+	}
+	return trace
+}
+
+func tenderlyStateObjectOf(override *sequence.CallOverride) *tenderlyStateObject {
+	object := &tenderlyStateObject{Code: override.Code}
+	if override.Balance != nil {
+		object.Balance = override.Balance.String()
+	}
+	if override.Nonce != nil {
+		nonce := override.Nonce.Uint64()
+		object.Nonce = &nonce
+	}
+	// Tenderly only overrides slots, so the State of an override, which replaces all the
+	// storage of the account, is applied as a diff
+	for _, slots := range [][]*sequence.StateOverride{override.State, override.StateDiff} {
+		for _, slot := range slots {
+			if object.Storage == nil {
+				object.Storage = map[string]string{}
+			}
+			object.Storage[slot.Key] = slot.Value
+		}
+	}
+	return object
+}
+
+// parseBig parses an integer in decimal, or in hex with a 0x prefix, nil if empty or invalid.
+func parseBig(s string) *big.Int {
+	if s == "" {
+		return nil
+	}
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return nil
+	}
+	return n
+}
@@ -0,0 +1,13 @@
+// Package soak holds the soak test of the receipts listener: meta-transactions are relayed
+// continuously to an in-memory chain, while the sequence.LegacyReceiptListener waiting for
+// them is restarted at random, the RPC requests of the listener and its monitor fail at random,
+// and the chain reorgs. Every meta-transaction must then reach exactly one terminal status,
+// the one it was executed with, without any missed or duplicated.
+//
+// The test runs for 10 seconds, or 2 seconds with -short, and for as long as SOAK_DURATION
+// when set, ie. for a long-running soak with the race detector:
+//
+//	SOAK_DURATION=1h go test -race -timeout 2h ./soak/
+//
+// The faults are drawn from a seed, which is logged with the number of faults injected.
+package soak
@@ -0,0 +1,334 @@
+package soak_test
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethmonitor"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/relayer"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/chaos"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/goware/cachestore/memlru"
+	"github.com/goware/logger"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	target       = common.HexToAddress("0x7a7")
+	waitTimeout  = 2 * time.Minute
+	senderFunds  = new(big.Int).Exp(big.NewInt(10), big.NewInt(21), nil)
+	firstSender  = uint64(100)
+	firstWallet  = uint64(1000)
+	numWorkers   = 4
+	pollInterval = 5 * time.Millisecond
+
+	// faults are the faults of the RPC requests while injected
+	faults = chaos.Config{DropRate: 0.1, RateLimitRate: 0.1, Jitter: 5 * time.Millisecond}
+)
+
+// targetContract reverts the calls with data, so bundles are executed or fail by their data.
+var targetContract = memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+	if len(input) > 0 {
+		return nil, memchain.Revert("failed on purpose")
+	}
+	return nil, nil
+})
+
+// duration returns how long the soak runs: SOAK_DURATION if set, and otherwise 10 seconds,
+// or 2 seconds with -short.
+func duration(t *testing.T) time.Duration {
+	t.Helper()
+
+	if value := os.Getenv("SOAK_DURATION"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			t.Fatalf("invalid SOAK_DURATION: %v", err)
+		}
+		return d
+	}
+	if testing.Short() {
+		return 2 * time.Second
+	}
+	return 10 * time.Second
+}
+
+// terminal is the terminal status a meta-transaction reached, as waited for with the listener.
+type terminal struct {
+	expected    sequence.MetaTxnStatus
+	transaction common.Hash
+	results     []*sequence.LegacyMetaTxnResult
+	receipt     *types.Receipt
+	err         error
+}
+
+type harness struct {
+	chain    *memchain.Chain
+	faults   *chaos.Injector
+	relayer  *relayer.LocalRelayer
+	listener *sequence.LegacyReceiptListener
+
+	// runErr receives what each run of the listener returned
+	runErr chan error
+
+	terminals map[sequence.MetaTxnID]*terminal
+	mu        sync.Mutex
+	waiters   sync.WaitGroup
+
+	faulty                         bool
+	restarts, reorgs, faultPeriods int
+}
+
+// newHarness returns an in-memory chain with the target contract deployed, a local relayer
+// sending from a pool of senders, and a receipts listener running on a faulty provider of the
+// chain until t is done.
+func newHarness(t *testing.T, seed int64) *harness {
+	t.Helper()
+
+	chain := memchain.New(memchain.Options{})
+	chain.Deploy(target, targetContract)
+
+	var senders []*ethwallet.Wallet
+	for i := 0; i < numWorkers; i++ {
+		sender, err := testutil.MemChainEOA(chain, firstSender+uint64(i), senderFunds)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		senders = append(senders, sender)
+	}
+	pool, err := relayer.NewSenderPool(senders)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	r, err := relayer.NewLocalRelayerWithProvider(senders[0], chain.Provider())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	r.SetSenderPool(pool)
+
+	// only the listener and its monitor read from the faulty provider
+	injector := chaos.New(seed)
+	provider, err := ethrpc.NewProvider("http://memchain", ethrpc.WithHTTPClient(injector.HTTPClient(chain.Client())))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// the monitor warns of the failures injected, and of the blocks queued while the listener
+	// is down
+	monitorOptions := ethmonitor.DefaultOptions
+	monitorOptions.Logger = logger.NewLogger(logger.LogLevel_ERROR)
+	monitorOptions.PollingInterval = pollInterval
+	monitorOptions.WithLogs = true
+	monitor, err := ethmonitor.NewMonitor(provider, monitorOptions)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	listener, err := sequence.NewLegacyReceiptListener(zerolog.Nop(), provider, monitor)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// the listener resumes from the head of the chain, so it misses no bundle from the start,
+	// which is past genesis as there is no checkpoint at block 0
+	chain.Mine()
+	store, err := memlru.New[uint64]()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, store.Set(context.Background(), "legacyReceiptListener::checkpoint", chain.Head()))
+	listener.SetCheckpointStore(store)
+
+	h := &harness{
+		chain:     chain,
+		faults:    injector,
+		relayer:   r,
+		listener:  listener,
+		runErr:    make(chan error, 1),
+		terminals: map[sequence.MetaTxnID]*terminal{},
+	}
+
+	monitorDone := make(chan struct{})
+	go func() {
+		defer close(monitorDone)
+		monitor.Run(context.Background())
+	}()
+	// the monitor starts at the head of the chain, so the listener runs once it is there
+	for deadline := time.Now().Add(waitTimeout); monitor.LatestBlock() == nil; {
+		if time.Now().After(deadline) {
+			t.Fatal("monitor did not start")
+		}
+		time.Sleep(pollInterval)
+	}
+	h.run(t)
+	t.Cleanup(func() {
+		listener.Stop()
+		<-h.runErr
+		monitor.Stop()
+		<-monitorDone
+	})
+
+	return h
+}
+
+// run runs the listener, and waits for it to be running.
+func (h *harness) run(t *testing.T) {
+	t.Helper()
+
+	go func() {
+		h.runErr <- h.listener.Run(context.Background())
+	}()
+	for deadline := time.Now().Add(waitTimeout); !h.listener.IsRunning(); {
+		if time.Now().After(deadline) {
+			t.Fatal("listener did not start")
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// restart stops the listener, and runs it again after down.
+func (h *harness) restart(t *testing.T, down time.Duration) {
+	t.Helper()
+
+	h.listener.Stop()
+	assert.NoError(t, <-h.runErr)
+	time.Sleep(down)
+	h.run(t)
+	h.restarts++
+}
+
+// chaos restarts the listener, reorgs the chain, and toggles the faults of the provider at
+// random until deadline, and then leaves the listener running without faults.
+func (h *harness) chaos(t *testing.T, rng *rand.Rand, deadline time.Time) {
+	t.Helper()
+
+	for time.Now().Before(deadline) {
+		time.Sleep(time.Duration(rng.Intn(100)) * time.Millisecond)
+
+		switch rng.Intn(3) {
+		case 0:
+			h.restart(t, time.Duration(rng.Intn(50))*time.Millisecond)
+		case 1:
+			if err := h.chain.Reorg(1 + rng.Intn(3)); err == nil {
+				h.reorgs++
+			}
+		case 2:
+			if h.faulty = !h.faulty; h.faulty {
+				h.faults.SetConfig(faults)
+				h.faultPeriods++
+			} else {
+				h.faults.SetConfig(chaos.Config{})
+			}
+		}
+	}
+	h.faults.SetConfig(chaos.Config{})
+}
+
+// wallet returns the deployed wallet of seed, connected to the relayer.
+func (h *harness) wallet(t *testing.T, seed uint64) *sequence.Wallet {
+	t.Helper()
+
+	wallet, err := testutil.MemChainWallet(h.chain, firstWallet+seed)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, wallet.SetRelayer(h.relayer))
+	testutil.DeployMemChainWallet(h.chain, wallet)
+	return wallet
+}
+
+// send relays bundles of wallet until deadline, a third of which fail, and waits for each of
+// them with the listener.
+func (h *harness) send(t *testing.T, wallet *sequence.Wallet, deadline time.Time) {
+	ctx := context.Background()
+
+	for n := int64(0); time.Now().Before(deadline); n++ {
+		expected, data := sequence.MetaTxnExecuted, []byte(nil)
+		if n%3 == 2 {
+			expected, data = sequence.MetaTxnFailed, []byte{1}
+		}
+
+		nonce, err := sequence.EncodeNonce(big.NewInt(0), big.NewInt(n))
+		if !assert.NoError(t, err) {
+			return
+		}
+		signed, err := wallet.SignTransactionsWithNonce(ctx, sequence.Transactions{{To: target, Data: data}}, nonce)
+		if !assert.NoError(t, err) {
+			return
+		}
+		metaTxnID, ntx, _, err := wallet.SendTransaction(ctx, signed)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		terminal := &terminal{expected: expected, transaction: ntx.Hash()}
+		h.mu.Lock()
+		h.terminals[metaTxnID] = terminal
+		h.mu.Unlock()
+
+		h.waiters.Add(1)
+		go func() {
+			defer h.waiters.Done()
+			terminal.results, terminal.receipt, terminal.err = h.listener.WaitForMetaTxn(ctx, metaTxnID, waitTimeout)
+		}()
+
+		time.Sleep(pollInterval)
+	}
+}
+
+func TestReceiptListenerSoak(t *testing.T) {
+	seed := time.Now().UnixNano()
+	rng := rand.New(rand.NewSource(seed))
+	h := newHarness(t, seed)
+	deadline := time.Now().Add(duration(t))
+
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wallet := h.wallet(t, uint64(i))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.send(t, wallet, deadline)
+		}()
+	}
+	h.chaos(t, rng, deadline)
+	wg.Wait()
+	h.waiters.Wait()
+
+	stats := h.faults.Stats()
+	t.Logf("seed %d: %d meta-transactions, %d restarts, %d reorgs, %d fault periods, %d dropped and %d rate-limited of %d requests",
+		seed, len(h.terminals), h.restarts, h.reorgs, h.faultPeriods, stats.Dropped, stats.RateLimited, stats.Requests)
+	assert.NotEmpty(t, h.terminals)
+
+	var missed, duplicated, mismatched int
+	for metaTxnID, terminal := range h.terminals {
+		switch {
+		case terminal.err != nil:
+			missed++
+			t.Errorf("%v: missed: %v", metaTxnID, terminal.err)
+		case len(terminal.results) != 1:
+			duplicated++
+			t.Errorf("%v: %d terminal statuses", metaTxnID, len(terminal.results))
+		case terminal.results[0].Status != terminal.expected:
+			mismatched++
+			t.Errorf("%v: status %v, expected %v", metaTxnID, terminal.results[0].Status, terminal.expected)
+		case terminal.receipt == nil || terminal.receipt.TxHash != terminal.transaction:
+			mismatched++
+			t.Errorf("%v: receipt is not of transaction %v", metaTxnID, terminal.transaction.Hex())
+		}
+	}
+	assert.Zero(t, missed, "missed terminal statuses")
+	assert.Zero(t, duplicated, "duplicated terminal statuses")
+	assert.Zero(t, mismatched, "mismatched terminal statuses")
+}
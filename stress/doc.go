@@ -0,0 +1,9 @@
+// Package stress holds the stress tests backing the concurrency-safety of wallets and relayers:
+// a wallet used by many goroutines, bundles relayed in parallel on many nonce spaces, and many
+// subscribers waiting for the same meta-transactions. They run on an in-memory chain, and are
+// meant to be run with the race detector:
+//
+//	go test -race ./stress/
+//
+// The tests are shortened with -short.
+package stress
@@ -0,0 +1,454 @@
+package stress_test
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethmonitor"
+	"github.com/0xsequence/ethkit/ethreceipts"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/relayer"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/goware/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	recorder     = common.HexToAddress("0x5eed")
+	recordData   = crypto.Keccak256([]byte("record()"))[:4]
+	recordsData  = crypto.Keccak256([]byte("records(address)"))[:4]
+	recordedSig  = crypto.Keccak256Hash([]byte("Recorded(address,uint256)"))
+	waitTimeout  = 30 * time.Second
+	senderFunds  = new(big.Int).Exp(big.NewInt(10), big.NewInt(21), nil)
+	firstSender  = uint64(100)
+	firstWallet  = uint64(1000)
+	numSenders   = 4
+	pollInterval = 5 * time.Millisecond
+)
+
+// recorderContract counts the calls of each caller, so the bundles executed by each wallet
+// can be counted.
+var recorderContract = memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+	switch {
+	case len(input) >= 4 && string(input[:4]) == string(recordData):
+		key := common.BytesToHash(env.Caller.Bytes())
+		count := new(big.Int).Add(env.Load(key).Big(), big.NewInt(1))
+		env.Store(key, common.BigToHash(count))
+		env.Log([]common.Hash{recordedSig, key}, common.BigToHash(count).Bytes())
+		return nil, nil
+	case len(input) >= 36 && string(input[:4]) == string(recordsData):
+		return env.Load(common.BytesToHash(input[4:36])).Bytes(), nil
+	default:
+		return nil, memchain.Revert("unknown method")
+	}
+})
+
+// scale returns n, or a quarter of n with -short.
+func scale(n int) int {
+	if testing.Short() {
+		return (n + 3) / 4
+	}
+	return n
+}
+
+type stressEnv struct {
+	chain   *memchain.Chain
+	relayer *relayer.LocalRelayer
+
+	// published is the number of the last block published by the monitor
+	published uint64
+}
+
+// newStressEnv returns an in-memory chain with the recorder contract deployed, and a local
+// relayer sending from a pool of senders, with its receipts listener running until t is done.
+func newStressEnv(t *testing.T) *stressEnv {
+	t.Helper()
+
+	chain := memchain.New(memchain.Options{})
+	chain.Deploy(recorder, recorderContract)
+	provider := chain.Provider()
+
+	var senders []*ethwallet.Wallet
+	for i := 0; i < numSenders; i++ {
+		sender, err := testutil.MemChainEOA(chain, firstSender+uint64(i), senderFunds)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		senders = append(senders, sender)
+	}
+
+	monitorOptions := ethmonitor.DefaultOptions
+	monitorOptions.PollingInterval = pollInterval
+	monitorOptions.BlockRetentionLimit = 1000
+	monitorOptions.WithLogs = true
+	monitor, err := ethmonitor.NewMonitor(provider, monitorOptions)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	receiptsOptions := ethreceipts.DefaultOptions
+	receiptsOptions.NumBlocksToFinality = 1
+	receipts, err := ethreceipts.NewReceiptsListener(logger.NewLogger(logger.LogLevel_ERROR), provider, monitor, receiptsOptions)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	env := &stressEnv{chain: chain}
+
+	// only the monitor is stopped once t is done, leaving the receipts listener idle: stopping
+	// it while it fetches receipts makes it send on closed channels, as of ethkit v1.19.4
+	monitorDone := make(chan struct{})
+	go func() {
+		defer close(monitorDone)
+		monitor.Run(context.Background())
+	}()
+	go receipts.Run(context.Background())
+	sub := monitor.Subscribe()
+	go func() {
+		for {
+			select {
+			case blocks := <-sub.Blocks():
+				for _, block := range blocks {
+					atomic.StoreUint64(&env.published, block.NumberU64())
+				}
+			case <-sub.Done():
+				return
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		sub.Unsubscribe()
+		monitor.Stop()
+		<-monitorDone
+	})
+
+	// the monitor starts at the head of the chain, so bundles are relayed once it is there
+	for deadline := time.Now().Add(waitTimeout); monitor.LatestBlock() == nil || !receipts.IsRunning(); {
+		if time.Now().After(deadline) {
+			t.Fatal("monitor did not start")
+		}
+		time.Sleep(pollInterval)
+	}
+
+	pool, err := relayer.NewSenderPool(senders)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	r, err := relayer.NewLocalRelayer(senders[0], receipts, sequence.WithWaitOptions(sequence.WaitOptions{Timeout: waitTimeout, PollInterval: pollInterval}))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	r.SetSenderPool(pool)
+	env.relayer = r
+
+	return env
+}
+
+// sync waits until the monitor published the head of the chain.
+//
+// The receipts listener searches the blocks of the monitor for the filters of new waiters
+// while the monitor fills in the logs of the block it is adding, which is a data race as of
+// ethkit v1.19.4, so waiters only start waiting once it is done.
+func (e *stressEnv) sync(t *testing.T) {
+	t.Helper()
+
+	head := e.chain.Head()
+	for deadline := time.Now().Add(waitTimeout); atomic.LoadUint64(&e.published) < head; {
+		if time.Now().After(deadline) {
+			t.Fatalf("monitor did not publish block %d", head)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// wallet returns the deployed wallet of seed, connected to the relayer.
+func (e *stressEnv) wallet(t *testing.T, seed uint64) *sequence.Wallet {
+	t.Helper()
+
+	wallet, err := testutil.MemChainWallet(e.chain, firstWallet+seed)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, wallet.SetRelayer(e.relayer))
+	testutil.DeployMemChainWallet(e.chain, wallet)
+	return wallet
+}
+
+// records returns the number of calls recorded for caller.
+func (e *stressEnv) records(t *testing.T, caller common.Address) int64 {
+	t.Helper()
+
+	data := append(append([]byte{}, recordsData...), common.BytesToHash(caller.Bytes()).Bytes()...)
+	res, err := e.chain.Provider().CallContract(context.Background(), ethereum.CallMsg{To: &recorder, Data: data}, nil)
+	assert.NoError(t, err)
+	return new(big.Int).SetBytes(res).Int64()
+}
+
+// isExecuted reports whether metaTxnID was executed in the transaction of receipt.
+func isExecuted(receipt *types.Receipt, metaTxnID sequence.MetaTxnID) bool {
+	for _, log := range receipt.Logs {
+		if sequence.IsTxExecutedEvent(log, common.HexToHash(string(metaTxnID))) {
+			return true
+		}
+	}
+	return false
+}
+
+// signRecord signs a bundle of wallet calling the recorder, with nonce n in space.
+func signRecord(ctx context.Context, wallet *sequence.Wallet, space int64, n int64) (*sequence.SignedTransactions, error) {
+	nonce, err := sequence.EncodeNonce(big.NewInt(space), big.NewInt(n))
+	if err != nil {
+		return nil, err
+	}
+	return wallet.SignTransactionsWithNonce(ctx, sequence.Transactions{{
+		To:            recorder,
+		Data:          recordData,
+		RevertOnError: true,
+	}}, nonce)
+}
+
+func TestConcurrentWalletUsage(t *testing.T) {
+	ctx := context.Background()
+	env := newStressEnv(t)
+	wallet := env.wallet(t, 0)
+	numWorkers := scale(32)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- func() error {
+				// signatures are made and validated concurrently
+				digest := sequence.MessageDigest([]byte(fmt.Sprintf("message %d", i)))
+				sig, _, err := wallet.SignDigest(digest)
+				if err != nil {
+					return err
+				}
+				ok, err := wallet.IsValidSignature(digest, sig)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return fmt.Errorf("invalid signature of message %d", i)
+				}
+
+				// bundles are signed concurrently, in nonce spaces of their own
+				signed, err := signRecord(ctx, wallet, int64(i+1), 0)
+				if err != nil {
+					return err
+				}
+				if err := signed.Verify(); err != nil {
+					return err
+				}
+				if signed.Transactions[0].To != recorder {
+					return fmt.Errorf("bundle %d was signed with the transactions of another", i)
+				}
+
+				// and the state of the wallet is read concurrently
+				if _, err := wallet.ImageHash(); err != nil {
+					return err
+				}
+				nonce, err := wallet.GetNonceInSpace(big.NewInt(int64(i + 1)))
+				if err != nil {
+					return err
+				}
+				if nonce.Sign() != 0 {
+					return fmt.Errorf("nonce space %d was used", i+1)
+				}
+				return nil
+			}()
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+}
+
+func TestParallelRelays(t *testing.T) {
+	ctx := context.Background()
+	env := newStressEnv(t)
+	numWallets, numSpaces, bundlesPerSpace := scale(8), scale(4), 3
+
+	wallets := make([]*sequence.Wallet, numWallets)
+	for i := range wallets {
+		wallets[i] = env.wallet(t, uint64(i))
+	}
+
+	// each worker relays its bundles in order in a nonce space of a wallet, in parallel with
+	// the other nonce spaces of the wallet, and the other wallets
+	var wg sync.WaitGroup
+	errs := make(chan error, numWallets*numSpaces)
+	for _, wallet := range wallets {
+		for space := 1; space <= numSpaces; space++ {
+			wg.Add(1)
+			go func(wallet *sequence.Wallet, space int64) {
+				defer wg.Done()
+				errs <- func() error {
+					for n := int64(0); n < int64(bundlesPerSpace); n++ {
+						signed, err := signRecord(ctx, wallet, space, n)
+						if err != nil {
+							return err
+						}
+						metaTxnID, _, waitReceipt, err := wallet.SendTransaction(ctx, signed)
+						if err != nil {
+							return fmt.Errorf("relay of bundle %d in space %d: %w", n, space, err)
+						}
+						receipt, err := waitReceipt(ctx)
+						if err != nil {
+							return fmt.Errorf("wait for bundle %d in space %d: %w", n, space, err)
+						}
+						if !isExecuted(receipt, metaTxnID) {
+							return fmt.Errorf("bundle %d in space %d was not executed", n, space)
+						}
+					}
+					return nil
+				}()
+			}(wallet, int64(space))
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	// every bundle was executed once, and every nonce space was used independently
+	for _, wallet := range wallets {
+		assert.Equal(t, int64(numSpaces*bundlesPerSpace), env.records(t, wallet.Address()))
+		for space := 1; space <= numSpaces; space++ {
+			nonce, err := wallet.GetNonceInSpace(big.NewInt(int64(space)))
+			assert.NoError(t, err)
+			assert.Equal(t, int64(bundlesPerSpace), nonce.Int64())
+		}
+	}
+	assert.Eventually(t, func() bool { return len(env.relayer.Pending()) == 0 }, waitTimeout, pollInterval)
+}
+
+func TestConcurrentWaiters(t *testing.T) {
+	ctx := context.Background()
+	env := newStressEnv(t)
+	wallet := env.wallet(t, 0)
+	numBundles, numWaiters := scale(8), scale(8)
+
+	signed := make([]*sequence.SignedTransactions, numBundles)
+	metaTxnIDs := make([]sequence.MetaTxnID, numBundles)
+	for i := range signed {
+		var err error
+		signed[i], err = signRecord(ctx, wallet, int64(i+1), 0)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		metaTxnIDs[i], _, err = sequence.ComputeMetaTxnID(signed[i].ChainID, wallet.Address(), signed[i].Transactions, signed[i].Nonce, sequence.MetaTxnWalletExec)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+
+	// the status feed reports each bundle relayed in parallel mined once
+	updates, unsubscribe := env.relayer.Subscribe(4 * numBundles)
+	var relays sync.WaitGroup
+	for i := range signed {
+		relays.Add(1)
+		go func(signed *sequence.SignedTransactions) {
+			defer relays.Done()
+			_, _, _, err := wallet.SendTransaction(ctx, signed)
+			assert.NoError(t, err)
+		}(signed[i])
+	}
+	relays.Wait()
+
+	mined := map[sequence.MetaTxnID]common.Hash{}
+	deadline := time.After(waitTimeout)
+	for len(mined) < numBundles {
+		select {
+		case update, ok := <-updates:
+			if !assert.True(t, ok, "status feed closed") {
+				t.FailNow()
+			}
+			if update.State == relayer.PendingMined {
+				_, seen := mined[update.MetaTxnID]
+				assert.False(t, seen, "%v mined twice", update.MetaTxnID)
+				assert.Equal(t, sequence.MetaTxnExecuted, update.Status)
+				mined[update.MetaTxnID] = update.Transaction
+			}
+		case <-deadline:
+			t.Fatalf("%d of %d bundles mined on the status feed", len(mined), numBundles)
+		}
+	}
+	unsubscribe()
+	env.sync(t)
+
+	// waiters wait for every bundle at once, with Wait and WaitForMany
+	type result struct {
+		metaTxnID sequence.MetaTxnID
+		txnHash   common.Hash
+		err       error
+	}
+	results := make(chan result, 2*numWaiters*numBundles)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < numWaiters; i++ {
+		for _, metaTxnID := range metaTxnIDs {
+			wg.Add(1)
+			go func(metaTxnID sequence.MetaTxnID) {
+				defer wg.Done()
+				<-start
+				status, receipt, err := env.relayer.Wait(ctx, metaTxnID)
+				if err == nil && status != sequence.MetaTxnExecuted {
+					err = fmt.Errorf("%v was %v", metaTxnID, status)
+				}
+				res := result{metaTxnID: metaTxnID, err: err}
+				if receipt != nil {
+					res.txnHash = receipt.TxHash
+				}
+				results <- res
+			}(metaTxnID)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			for res := range env.relayer.WaitForMany(ctx, metaTxnIDs) {
+				r := result{metaTxnID: res.MetaTxnID, err: res.Err}
+				if res.Receipt != nil {
+					r.txnHash = res.Receipt.TransactionHash()
+				}
+				results <- r
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(results)
+
+	// every waiter saw its bundle mined in the same transaction
+	counts := map[sequence.MetaTxnID]int{}
+	for res := range results {
+		if !assert.NoError(t, res.err) {
+			continue
+		}
+		assert.Equal(t, mined[res.metaTxnID], res.txnHash, res.metaTxnID)
+		counts[res.metaTxnID]++
+	}
+	for _, metaTxnID := range metaTxnIDs {
+		assert.Equal(t, 2*numWaiters, counts[metaTxnID], metaTxnID)
+	}
+	assert.Equal(t, int64(numBundles), env.records(t, wallet.Address()))
+}
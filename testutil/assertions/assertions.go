@@ -0,0 +1,162 @@
+// Package assertions are testify-style assertions on meta-transactions and their receipts,
+// for tests against a test chain:
+//
+//	receipt := assertions.AssertMetaTxnExecuted(t, testChain.ReceiptsListener, metaTxnID)
+//	assertions.AssertEventEmitted(t, receipt, contracts.IERC20.ABI, "Transfer", wallet.Address(), to, amount)
+package assertions
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethreceipts"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/stretchr/testify/assert"
+)
+
+// DefaultTimeout is how long the assertions wait for a meta-transaction to be mined.
+var DefaultTimeout = 60 * time.Second
+
+// AssertMetaTxnExecuted asserts that metaTxnID is mined and executed, and returns the receipt
+// of the native transaction it was mined in, or nil if it was not found.
+func AssertMetaTxnExecuted(t testing.TB, listener *ethreceipts.ReceiptsListener, metaTxnID sequence.MetaTxnID, msgAndArgs ...interface{}) *types.Receipt {
+	t.Helper()
+	return assertMetaTxnStatus(t, listener, metaTxnID, sequence.MetaTxnExecuted, "", msgAndArgs...)
+}
+
+// AssertMetaTxnFailed asserts that metaTxnID is mined and failed, with reason if it is not
+// empty, and returns the receipt of the native transaction it was mined in, or nil if it was
+// not found.
+func AssertMetaTxnFailed(t testing.TB, listener *ethreceipts.ReceiptsListener, metaTxnID sequence.MetaTxnID, reason string, msgAndArgs ...interface{}) *types.Receipt {
+	t.Helper()
+	return assertMetaTxnStatus(t, listener, metaTxnID, sequence.MetaTxnFailed, reason, msgAndArgs...)
+}
+
+func assertMetaTxnStatus(t testing.TB, listener *ethreceipts.ReceiptsListener, metaTxnID sequence.MetaTxnID, status sequence.MetaTxnStatus, reason string, msgAndArgs ...interface{}) *types.Receipt {
+	t.Helper()
+
+	result, receipt, _, err := sequence.FetchMetaTransactionReceipt(context.Background(), listener, metaTxnID, DefaultTimeout)
+	if !assert.NoError(t, err, msgAndArgs...) {
+		return nil
+	}
+	assert.Equal(t, status.String(), result.Status.String(), append([]interface{}{"status of metaTxnID %v", metaTxnID}, msgAndArgs...)...)
+	if reason != "" {
+		assert.Contains(t, result.Reason, reason, msgAndArgs...)
+	}
+	return receipt.Receipt()
+}
+
+// AssertInnerCallReverted asserts that the transaction at index of the bundle mined in receipt
+// reverted with reason, or with any reason if it is empty. The bundle is decoded from the
+// native transaction fetched from provider.
+func AssertInnerCallReverted(t testing.TB, provider *ethrpc.Provider, receipt *types.Receipt, index int, reason string, msgAndArgs ...interface{}) bool {
+	t.Helper()
+
+	if receipt == nil {
+		return assert.Fail(t, "no receipt", msgAndArgs...)
+	}
+	receipts, _, err := sequence.DecodeReceipt(context.Background(), receipt, provider)
+	if !assert.NoError(t, err, msgAndArgs...) {
+		return false
+	}
+	if index >= len(receipts) {
+		return assert.Fail(t, fmt.Sprintf("bundle has %d transactions, not %d", len(receipts), index+1), msgAndArgs...)
+	}
+
+	inner := receipts[index]
+	if inner.Status != sequence.MetaTxnFailed {
+		return assert.Fail(t, fmt.Sprintf("transaction %d of the bundle did not revert, its status is %v", index, inner.Status), msgAndArgs...)
+	}
+	if reason != "" && !strings.Contains(inner.Reason, reason) {
+		return assert.Fail(t, fmt.Sprintf("transaction %d of the bundle reverted with %q, not %q", index, inner.Reason, reason), msgAndArgs...)
+	}
+	return true
+}
+
+// AssertEventEmitted asserts that a log of receipt is the event of contractABI, with args
+// as the values of its inputs in order. Fewer args than inputs only match the first inputs,
+// so no args match any values.
+func AssertEventEmitted(t testing.TB, receipt *types.Receipt, contractABI abi.ABI, event string, args ...interface{}) bool {
+	t.Helper()
+
+	ev, ok := contractABI.Events[event]
+	if !ok {
+		return assert.Fail(t, fmt.Sprintf("event %s is not in the abi", event))
+	}
+	if len(args) > len(ev.Inputs) {
+		return assert.Fail(t, fmt.Sprintf("event %s has %d inputs, not %d", event, len(ev.Inputs), len(args)))
+	}
+
+	var emitted []string
+	for _, log := range receipt.Logs {
+		if len(log.Topics) == 0 || log.Topics[0] != ev.ID {
+			continue
+		}
+		values, err := eventValues(ev, log)
+		if err != nil {
+			emitted = append(emitted, fmt.Sprintf("undecodable: %v", err))
+			continue
+		}
+		if matchValues(args, values) {
+			return true
+		}
+		emitted = append(emitted, fmt.Sprintf("%v", values))
+	}
+
+	if len(emitted) == 0 {
+		return assert.Fail(t, fmt.Sprintf("event %s was not emitted", event))
+	}
+	return assert.Fail(t, fmt.Sprintf("event %s was not emitted with %v, but with:\n%s", event, args, strings.Join(emitted, "\n")))
+}
+
+// eventValues decodes the values of the inputs of ev from log, in order.
+func eventValues(ev abi.Event, log *types.Log) ([]interface{}, error) {
+	values := map[string]interface{}{}
+
+	var indexed abi.Arguments
+	for _, input := range ev.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if err := abi.ParseTopicsIntoMap(values, indexed, log.Topics[1:]); err != nil {
+		return nil, err
+	}
+	if err := ev.Inputs.UnpackIntoMap(values, log.Data); err != nil {
+		return nil, err
+	}
+
+	ordered := make([]interface{}, len(ev.Inputs))
+	for i, input := range ev.Inputs {
+		ordered[i] = values[input.Name]
+	}
+	return ordered, nil
+}
+
+func matchValues(expected, actual []interface{}) bool {
+	for i, value := range expected {
+		if !equalValue(value, actual[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalValue(expected, actual interface{}) bool {
+	if e, ok := expected.(*big.Int); ok {
+		a, ok := actual.(*big.Int)
+		return ok && e.Cmp(a) == 0
+	}
+	if e, ok := expected.(int); ok {
+		a, ok := actual.(*big.Int)
+		return ok && a.IsInt64() && a.Int64() == int64(e)
+	}
+	return assert.ObjectsAreEqual(expected, actual)
+}
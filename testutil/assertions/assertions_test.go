@@ -0,0 +1,38 @@
+package assertions_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/0xsequence/go-sequence/testutil/assertions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssertEventEmitted(t *testing.T) {
+	from := common.HexToAddress("0xf0ba65550f2d1dccf4b131b774844dc3d801d886")
+	to := common.HexToAddress("0xb0b0")
+
+	erc20 := contracts.IERC20.ABI
+	transfer := erc20.Events["Transfer"]
+	data, err := transfer.Inputs.NonIndexed().Pack(big.NewInt(55))
+	assert.NoError(t, err)
+
+	receipt := &types.Receipt{Logs: []*types.Log{{
+		Topics: []common.Hash{transfer.ID, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:   data,
+	}}}
+
+	assert.True(t, assertions.AssertEventEmitted(t, receipt, erc20, "Transfer"))
+	assert.True(t, assertions.AssertEventEmitted(t, receipt, erc20, "Transfer", from, to, big.NewInt(55)))
+	assert.True(t, assertions.AssertEventEmitted(t, receipt, erc20, "Transfer", from, to, 55))
+
+	// failures are reported to the test
+	mockT := new(testing.T)
+	assert.False(t, assertions.AssertEventEmitted(mockT, receipt, erc20, "Transfer", to))
+	assert.False(t, assertions.AssertEventEmitted(mockT, receipt, erc20, "Approval"))
+	assert.False(t, assertions.AssertEventEmitted(mockT, receipt, erc20, "Unknown"))
+	assert.True(t, mockT.Failed())
+}
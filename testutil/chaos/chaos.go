@@ -0,0 +1,360 @@
+// Package chaos injects faults into the requests of providers and the calls of relayers, ie.
+// latency, dropped responses, rate-limiting and corrupted receipts, so tests can validate the
+// retries, breakers and fallbacks of the components using them:
+//
+//	faults := chaos.New(seed).SetConfig(chaos.Config{DropRate: 0.1, Latency: 50 * time.Millisecond})
+//	provider, _ := ethrpc.NewProvider(nodeURL, ethrpc.WithHTTPClient(faults.HTTPClient(nil)))
+//	wallet.SetRelayer(faults.Relayer(relayer))
+//
+// The faults are drawn at random from seed, and may be changed while the injector is in use.
+package chaos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+var (
+	// ErrDropped is returned instead of the response of a request which was served.
+	ErrDropped = errors.New("chaos: response dropped")
+
+	// ErrRateLimited is returned by the relayer calls rejected as rate-limited. Providers are
+	// answered with a RateLimitCode JSON-RPC error instead.
+	ErrRateLimited = errors.New("chaos: rate limited")
+)
+
+// RateLimitCode is the JSON-RPC error code of the requests rejected as rate-limited, as
+// returned by node providers, with the HTTP status 429 Too Many Requests.
+const RateLimitCode = -32005
+
+// Config is the faults injected, each at a rate between 0, never, and 1, always.
+type Config struct {
+	// Latency is added to each request, with up to Jitter more at random.
+	Latency time.Duration
+	Jitter  time.Duration
+
+	// DropRate is the rate of the requests whose response is dropped after they were served,
+	// ie. as if the connection was lost, so they take effect without their callers knowing.
+	DropRate float64
+
+	// RateLimitRate is the rate of the requests rejected as rate-limited, without being served.
+	RateLimitRate float64
+
+	// CorruptRate is the rate of the receipts returned which are corrupted: either their status
+	// is flipped, their logs are dropped, or their block hash is changed.
+	CorruptRate float64
+
+	// Methods are the methods which faults are injected into, ie. eth_getTransactionReceipt for
+	// providers, or Relay for relayers. Faults are injected into all methods if empty.
+	Methods []string
+}
+
+// Stats are the counts of the requests, and of the faults injected into them.
+type Stats struct {
+	Requests    int
+	Delayed     int
+	Dropped     int
+	RateLimited int
+	Corrupted   int
+}
+
+// Injector injects faults into providers and relayers.
+type Injector struct {
+	config Config
+	rand   *rand.Rand
+	stats  Stats
+	mu     sync.Mutex
+}
+
+// New returns an injector drawing faults from seed, which injects none until configured.
+func New(seed int64) *Injector {
+	return &Injector{rand: rand.New(rand.NewSource(seed))}
+}
+
+// SetConfig sets the faults injected from now on.
+func (i *Injector) SetConfig(config Config) *Injector {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.config = config
+	return i
+}
+
+func (i *Injector) Config() Config {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.config
+}
+
+func (i *Injector) Stats() Stats {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.stats
+}
+
+// faults are the faults drawn for a request.
+type faults struct {
+	delay     time.Duration
+	drop      bool
+	rateLimit bool
+
+	// corrupt is the rate its receipts are corrupted at
+	corrupt float64
+}
+
+// draw draws the faults of a request of methods.
+func (i *Injector) draw(methods ...string) faults {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.stats.Requests++
+	if !i.targets(methods) {
+		return faults{}
+	}
+
+	f := faults{delay: i.config.Latency, corrupt: i.config.CorruptRate}
+	if i.config.Jitter > 0 {
+		f.delay += time.Duration(i.rand.Int63n(int64(i.config.Jitter)))
+	}
+	if f.delay > 0 {
+		i.stats.Delayed++
+	}
+	if i.rand.Float64() < i.config.RateLimitRate {
+		f.rateLimit = true
+		i.stats.RateLimited++
+	} else if i.rand.Float64() < i.config.DropRate {
+		f.drop = true
+		i.stats.Dropped++
+	}
+	return f
+}
+
+// targets reports whether faults are injected into a request of methods.
+func (i *Injector) targets(methods []string) bool {
+	if len(i.config.Methods) == 0 {
+		return true
+	}
+	for _, method := range methods {
+		for _, target := range i.config.Methods {
+			if method == target {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// corrupt returns a corrupted copy of receipt at rate, or receipt itself.
+func (i *Injector) corrupt(receipt *types.Receipt, rate float64) *types.Receipt {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if receipt == nil || i.rand.Float64() >= rate {
+		return receipt
+	}
+	i.stats.Corrupted++
+
+	// the logs are only dropped from receipts with logs
+	kinds := 2
+	if len(receipt.Logs) > 0 {
+		kinds = 3
+	}
+	corrupted := *receipt
+	switch i.rand.Intn(kinds) {
+	case 0:
+		corrupted.Status ^= 1
+	case 1:
+		i.rand.Read(corrupted.BlockHash[:])
+	default:
+		corrupted.Logs = []*types.Log{}
+	}
+	return &corrupted
+}
+
+// sleep waits for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// HTTPClient is the client of a provider, as set with ethrpc.WithHTTPClient.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// HTTPClient returns a client sending the JSON-RPC requests of a provider with next, or
+// http.DefaultClient if nil, with faults injected. The methods of Config.Methods are the
+// JSON-RPC methods, and the receipts of eth_getTransactionReceipt are corrupted.
+func (i *Injector) HTTPClient(next HTTPClient) HTTPClient {
+	if next == nil {
+		next = http.DefaultClient
+	}
+	return &httpClient{injector: i, next: next}
+}
+
+type httpClient struct {
+	injector *Injector
+	next     HTTPClient
+}
+
+// rpcMessage is a JSON-RPC request or response.
+type rpcMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      json.RawMessage  `json:"id"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *json.RawMessage `json:"error,omitempty"`
+}
+
+func (c *httpClient) Do(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	requests, batch := decodeMessages(body)
+	methods := make([]string, len(requests))
+	for n, request := range requests {
+		methods[n] = request.Method
+	}
+
+	f := c.injector.draw(methods...)
+	if err := sleep(req.Context(), f.delay); err != nil {
+		return nil, err
+	}
+	if f.rateLimit {
+		return rateLimited(req, requests, batch)
+	}
+
+	res, err := c.next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if f.drop {
+		res.Body.Close()
+		return nil, ErrDropped
+	}
+	if f.corrupt > 0 {
+		return c.corruptReceipts(res, requests, f.corrupt)
+	}
+	return res, nil
+}
+
+// corruptReceipts corrupts the receipts of the eth_getTransactionReceipt requests in res at
+// rate.
+func (c *httpClient) corruptReceipts(res *http.Response, requests []rpcMessage, rate float64) (*http.Response, error) {
+	receiptIDs := map[string]bool{}
+	for _, request := range requests {
+		if request.Method == "eth_getTransactionReceipt" {
+			receiptIDs[string(request.ID)] = true
+		}
+	}
+	if len(receiptIDs) == 0 {
+		return res, nil
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	responses, batch := decodeMessages(body)
+	if len(responses) == 0 {
+		return withBody(res, body), nil
+	}
+	for n, response := range responses {
+		if !receiptIDs[string(response.ID)] {
+			continue
+		}
+		var receipt *types.Receipt
+		if err := json.Unmarshal(response.Result, &receipt); err != nil || receipt == nil {
+			continue
+		}
+		if corrupted := c.injector.corrupt(receipt, rate); corrupted != receipt {
+			if responses[n].Result, err = json.Marshal(corrupted); err != nil {
+				return nil, err
+			}
+		}
+	}
+	body, err = encodeMessages(responses, batch)
+	if err != nil {
+		return nil, err
+	}
+	return withBody(res, body), nil
+}
+
+// rateLimited returns the response of a node rejecting requests as rate-limited.
+func rateLimited(req *http.Request, requests []rpcMessage, batch bool) (*http.Response, error) {
+	rejected := json.RawMessage(`{"code":` + strconv.Itoa(RateLimitCode) + `,"message":"` + ErrRateLimited.Error() + `"}`)
+	responses := make([]rpcMessage, len(requests))
+	for n, request := range requests {
+		responses[n] = rpcMessage{JSONRPC: "2.0", ID: request.ID, Error: &rejected}
+	}
+
+	res := &http.Response{
+		Status:     http.StatusText(http.StatusTooManyRequests),
+		StatusCode: http.StatusTooManyRequests,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Request:    req,
+	}
+	body, err := encodeMessages(responses, batch)
+	if err != nil {
+		return nil, err
+	}
+	return withBody(res, body), nil
+}
+
+// withBody sets the body of res.
+func withBody(res *http.Response, body []byte) *http.Response {
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	res.ContentLength = int64(len(body))
+	res.Header.Del("Content-Length")
+	return res
+}
+
+// decodeMessages decodes a JSON-RPC message, or batch of messages. Undecodable bodies have no
+// messages.
+func decodeMessages(body []byte) ([]rpcMessage, bool) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var messages []rpcMessage
+		json.Unmarshal(body, &messages)
+		return messages, true
+	}
+	var message rpcMessage
+	if err := json.Unmarshal(body, &message); err != nil {
+		return nil, false
+	}
+	return []rpcMessage{message}, false
+}
+
+func encodeMessages(messages []rpcMessage, batch bool) ([]byte, error) {
+	if batch || len(messages) != 1 {
+		return json.Marshal(messages)
+	}
+	return json.Marshal(messages[0])
+}
@@ -0,0 +1,151 @@
+package chaos_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/relayer"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/chaos"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+var target = common.HexToAddress("0x7a7")
+
+func TestHTTPClient(t *testing.T) {
+	ctx := context.Background()
+	chain := memchain.New(memchain.Options{})
+
+	faults := chaos.New(1)
+	provider, err := ethrpc.NewProvider("http://memchain", ethrpc.WithHTTPClient(faults.HTTPClient(chain.Client())))
+	assert.NoError(t, err)
+	eoa, err := testutil.MemChainEOA(chain, 1, big.NewInt(1e18))
+	assert.NoError(t, err)
+	eoa.SetProvider(provider)
+
+	// no faults are injected until configured
+	_, err = provider.BlockNumber(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, chaos.Stats{Requests: 1}, faults.Stats())
+
+	faults.SetConfig(chaos.Config{Latency: 20 * time.Millisecond, Jitter: 10 * time.Millisecond})
+	start := time.Now()
+	_, err = provider.BlockNumber(ctx)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.Equal(t, 1, faults.Stats().Delayed)
+
+	// rate-limited requests are not served
+	send := func() error {
+		txn, err := eoa.NewTransaction(ctx, &ethtxn.TransactionRequest{To: &target, ETHValue: big.NewInt(1), GasLimit: memchain.TxGas})
+		if err != nil {
+			return err
+		}
+		_, _, err = eoa.SendTransaction(ctx, txn)
+		return err
+	}
+	faults.SetConfig(chaos.Config{RateLimitRate: 1, Methods: []string{"eth_sendRawTransaction"}})
+	assert.ErrorContains(t, send(), chaos.ErrRateLimited.Error())
+	assert.Equal(t, uint64(0), chain.Head())
+	assert.Equal(t, 1, faults.Stats().RateLimited)
+
+	// dropped requests are served, without a response
+	faults.SetConfig(chaos.Config{DropRate: 1, Methods: []string{"eth_sendRawTransaction"}})
+	assert.ErrorIs(t, send(), chaos.ErrDropped)
+	assert.Equal(t, uint64(1), chain.Head())
+	assert.Equal(t, 1, faults.Stats().Dropped)
+
+	// only the receipts are corrupted
+	block, err := chain.Provider().BlockByNumber(ctx, big.NewInt(1))
+	assert.NoError(t, err)
+	txnHash := block.Transactions()[0].Hash()
+	receipt, err := chain.Provider().TransactionReceipt(ctx, txnHash)
+	assert.NoError(t, err)
+
+	faults.SetConfig(chaos.Config{CorruptRate: 1})
+	corrupted, err := provider.TransactionReceipt(ctx, txnHash)
+	assert.NoError(t, err)
+	assert.Equal(t, receipt.TxHash, corrupted.TxHash)
+	assert.False(t, receipt.Status == corrupted.Status && len(receipt.Logs) == len(corrupted.Logs) && receipt.BlockHash == corrupted.BlockHash)
+	assert.Equal(t, 1, faults.Stats().Corrupted)
+
+	header, err := provider.HeaderByNumber(ctx, big.NewInt(1))
+	assert.NoError(t, err)
+	assert.Equal(t, block.Hash(), header.Hash())
+	assert.Equal(t, 1, faults.Stats().Corrupted)
+}
+
+func TestRelayer(t *testing.T) {
+	ctx := context.Background()
+	chain := memchain.New(memchain.Options{})
+	chain.Deploy(target, memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		return nil, nil
+	}))
+
+	sender, err := testutil.MemChainEOA(chain, 1, big.NewInt(1e18))
+	assert.NoError(t, err)
+	r, err := relayer.NewLocalRelayerWithProvider(sender, chain.Provider())
+	assert.NoError(t, err)
+
+	faults := chaos.New(1)
+	wallet, err := testutil.MemChainWallet(chain, 2)
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.SetRelayer(faults.Relayer(r)))
+	testutil.DeployMemChainWallet(chain, wallet)
+
+	send := func(n int64) (sequence.MetaTxnID, error) {
+		nonce, err := sequence.EncodeNonce(big.NewInt(0), big.NewInt(n))
+		if err != nil {
+			return "", err
+		}
+		signed, err := wallet.SignTransactionsWithNonce(ctx, sequence.Transactions{{To: target}}, nonce)
+		if err != nil {
+			return "", err
+		}
+		metaTxnID, _, _, err := wallet.SendTransaction(ctx, signed)
+		return metaTxnID, err
+	}
+	nonce := func() int64 {
+		nonce, err := r.GetNonce(ctx, wallet.GetWalletConfig(), wallet.GetWalletContext(), nil, nil)
+		assert.NoError(t, err)
+		return nonce.Int64()
+	}
+
+	// rate-limited bundles are not relayed
+	faults.SetConfig(chaos.Config{RateLimitRate: 1, Methods: []string{"Relay"}})
+	_, err = send(0)
+	assert.ErrorIs(t, err, chaos.ErrRateLimited)
+	assert.Equal(t, int64(0), nonce())
+
+	// dropped bundles are relayed, without their caller knowing
+	faults.SetConfig(chaos.Config{DropRate: 1, Methods: []string{"Relay"}})
+	_, err = send(0)
+	assert.ErrorIs(t, err, chaos.ErrDropped)
+	assert.Equal(t, int64(1), nonce())
+
+	faults.SetConfig(chaos.Config{})
+	metaTxnID, err := send(1)
+	assert.NoError(t, err)
+	status, receipt, err := r.Wait(ctx, metaTxnID, 10*time.Second)
+	assert.NoError(t, err)
+
+	// the receipts waited for are corrupted, but not the status of their bundle
+	faults.SetConfig(chaos.Config{CorruptRate: 1, Latency: 10 * time.Millisecond, Methods: []string{"Wait"}})
+	corruptedStatus, corrupted, err := wallet.GetRelayer().Wait(ctx, metaTxnID, 10*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, status, corruptedStatus)
+	assert.False(t, receipt.Status == corrupted.Status && len(receipt.Logs) == len(corrupted.Logs) && receipt.BlockHash == corrupted.BlockHash)
+
+	stats := faults.Stats()
+	assert.Equal(t, 1, stats.RateLimited)
+	assert.Equal(t, 1, stats.Dropped)
+	assert.Equal(t, 1, stats.Corrupted)
+	assert.Equal(t, 1, stats.Delayed)
+}
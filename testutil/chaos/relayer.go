@@ -0,0 +1,108 @@
+package chaos
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+)
+
+// Relayer returns next with faults injected into its calls. The methods of Config.Methods are
+// the methods of sequence.Relayer, ie. Relay or Wait, and the receipts of Wait are corrupted.
+// GetProvider is passed through, so the provider of the relayer has no faults injected unless
+// its client is wrapped with HTTPClient.
+func (i *Injector) Relayer(next sequence.Relayer) sequence.Relayer {
+	return &relayer{injector: i, next: next}
+}
+
+type relayer struct {
+	injector *Injector
+	next     sequence.Relayer
+}
+
+var _ sequence.Relayer = &relayer{}
+
+// before injects the latency and rate-limiting of a call of method, and returns the faults
+// drawn for it.
+func (r *relayer) before(ctx context.Context, method string) (faults, error) {
+	f := r.injector.draw(method)
+	if err := sleep(ctx, f.delay); err != nil {
+		return f, err
+	}
+	if f.rateLimit {
+		return f, ErrRateLimited
+	}
+	return f, nil
+}
+
+func (r *relayer) GetProvider() *ethrpc.Provider {
+	return r.next.GetProvider()
+}
+
+func (r *relayer) EstimateGasLimits(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, txns sequence.Transactions) (sequence.Transactions, error) {
+	f, err := r.before(ctx, "EstimateGasLimits")
+	if err != nil {
+		return nil, err
+	}
+	txns, err = r.next.EstimateGasLimits(ctx, walletConfig, walletContext, txns)
+	if err == nil && f.drop {
+		return nil, ErrDropped
+	}
+	return txns, err
+}
+
+func (r *relayer) GetNonce(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, space *big.Int, blockNum *big.Int) (*big.Int, error) {
+	f, err := r.before(ctx, "GetNonce")
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := r.next.GetNonce(ctx, walletConfig, walletContext, space, blockNum)
+	if err == nil && f.drop {
+		return nil, ErrDropped
+	}
+	return nonce, err
+}
+
+func (r *relayer) Relay(ctx context.Context, signedTxs *sequence.SignedTransactions) (sequence.MetaTxnID, *types.Transaction, ethtxn.WaitReceipt, error) {
+	f, err := r.before(ctx, "Relay")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	metaTxnID, tx, waitReceipt, err := r.next.Relay(ctx, signedTxs)
+	if err == nil && f.drop {
+		// the bundle is relayed, without its caller knowing
+		return "", nil, nil, ErrDropped
+	}
+	return metaTxnID, tx, waitReceipt, err
+}
+
+func (r *relayer) Wait(ctx context.Context, metaTxnID sequence.MetaTxnID, optTimeout ...time.Duration) (sequence.MetaTxnStatus, *types.Receipt, error) {
+	f, err := r.before(ctx, "Wait")
+	if err != nil {
+		return 0, nil, err
+	}
+	status, receipt, err := r.next.Wait(ctx, metaTxnID, optTimeout...)
+	if err != nil {
+		return status, receipt, err
+	}
+	if f.drop {
+		return 0, nil, ErrDropped
+	}
+	return status, r.injector.corrupt(receipt, f.corrupt), nil
+}
+
+func (r *relayer) FeeOptions(ctx context.Context, signedTxs *sequence.SignedTransactions) ([]*sequence.FeeOption, error) {
+	f, err := r.before(ctx, "FeeOptions")
+	if err != nil {
+		return nil, err
+	}
+	options, err := r.next.FeeOptions(ctx, signedTxs)
+	if err == nil && f.drop {
+		return nil, ErrDropped
+	}
+	return options, err
+}
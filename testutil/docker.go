@@ -0,0 +1,151 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+)
+
+// DockerNode is a test chain node run in a Docker container, for machines without a test
+// chain already running. The node must serve http json-rpc on Port, with accounts[0] funded
+// and unlocked, as it funds the test wallets.
+type DockerNode struct {
+	Image string
+	Args  []string
+	Port  int
+
+	// HealthTimeout is how long the node has to serve json-rpc once started.
+	HealthTimeout time.Duration
+}
+
+var (
+	// GethDockerNode is a geth dev node, like the one of `make start-testchain`.
+	GethDockerNode = DockerNode{
+		Image: "ethereum/client-go:v1.10.16",
+		Args: []string{
+			"--dev", "--dev.period", "2", "--networkid", "1337",
+			"--miner.gaslimit", "15000000", "--miner.gasprice", "1",
+			"--http", "--http.addr", "0.0.0.0", "--rpc.allow-unprotected-txs", "--verbosity", "1",
+		},
+		Port:          8545,
+		HealthTimeout: 60 * time.Second,
+	}
+
+	// AnvilDockerNode is an anvil node, which also serves the hardhat_* and evm_* methods
+	// needed by TestChain.Fork, TestChain.Impersonate and TestChain.Snapshot.
+	AnvilDockerNode = DockerNode{
+		Image:         "ghcr.io/foundry-rs/foundry:latest",
+		Args:          []string{"anvil --host 0.0.0.0 --chain-id 1337 --block-time 1 --gas-limit 15000000"},
+		Port:          8545,
+		HealthTimeout: 60 * time.Second,
+	}
+
+	// DockerNodes are the docker nodes by name, ie. for the TESTCHAIN_DOCKER env var.
+	DockerNodes = map[string]DockerNode{
+		"geth":  GethDockerNode,
+		"anvil": AnvilDockerNode,
+	}
+)
+
+// dockerContainer is a running docker node.
+type dockerContainer struct {
+	id      string
+	nodeURL string
+}
+
+// startDockerNode runs node in a new container, and waits for it to serve json-rpc. The
+// container is removed if the node doesn't get healthy.
+func startDockerNode(ctx context.Context, node DockerNode) (*dockerContainer, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return nil, fmt.Errorf("testutil: docker node needs the docker cli: %w", err)
+	}
+
+	args := []string{"run", "-d", "--rm", "-p", fmt.Sprintf("127.0.0.1::%d", node.Port), node.Image}
+	out, err := docker(ctx, append(args, node.Args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("testutil: unable to start docker node %s: %w", node.Image, err)
+	}
+	container := &dockerContainer{id: out}
+
+	// test binaries usually exit without a Disconnect, so the container is also removed by
+	// a reaper process once this one exits
+	if err := reapAfterExit(container.id); err != nil {
+		container.stop()
+		return nil, fmt.Errorf("testutil: unable to start reaper of docker node %s: %w", node.Image, err)
+	}
+
+	out, err = docker(ctx, "port", container.id, fmt.Sprintf("%d/tcp", node.Port))
+	if err != nil {
+		container.stop()
+		return nil, fmt.Errorf("testutil: unable to get port of docker node %s: %w", node.Image, err)
+	}
+	// there is a line per address family, the first one is the one bound above
+	hostPort := strings.SplitN(out, "\n", 2)[0]
+	if _, _, err := net.SplitHostPort(hostPort); err != nil {
+		container.stop()
+		return nil, fmt.Errorf("testutil: invalid port of docker node %s: %q", node.Image, hostPort)
+	}
+	container.nodeURL = "http://" + hostPort
+
+	if err := waitForNode(ctx, container.nodeURL, node.HealthTimeout); err != nil {
+		container.stop()
+		return nil, fmt.Errorf("testutil: docker node %s is not healthy: %w", node.Image, err)
+	}
+	return container, nil
+}
+
+// reapAfterExit starts a shell which removes the container id once this process exits.
+func reapAfterExit(id string) error {
+	script := fmt.Sprintf("while kill -0 %d 2>/dev/null; do sleep 1; done; docker rm -f %s >/dev/null 2>&1", os.Getpid(), id)
+	cmd := exec.Command("sh", "-c", script)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+func (c *dockerContainer) stop() error {
+	_, err := docker(context.Background(), "rm", "-f", c.id)
+	return err
+}
+
+// waitForNode polls the node at nodeURL until it serves its chainID and block number.
+func waitForNode(ctx context.Context, nodeURL string, timeout time.Duration) error {
+	provider, err := ethrpc.NewProvider(nodeURL)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		_, err = provider.ChainID(ctx)
+		if err == nil {
+			_, err = provider.BlockNumber(ctx)
+		}
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %v", ctx.Err(), err)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+func docker(ctx context.Context, args ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, "docker", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,45 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+type forking struct {
+	JSONRPCURL  string `json:"jsonRpcUrl"`
+	BlockNumber uint64 `json:"blockNumber"`
+}
+
+type resetParams struct {
+	Forking forking `json:"forking"`
+}
+
+// Fork resets the test chain to a fork of the chain at upstreamURL, at the state of the end of
+// blockNumber. It needs a node serving hardhat_reset, such as anvil or hardhat, and the
+// test chain then has the chainID of the upstream chain.
+func (c *TestChain) Fork(ctx context.Context, upstreamURL string, blockNumber uint64) error {
+	params := resetParams{Forking: forking{JSONRPCURL: upstreamURL, BlockNumber: blockNumber}}
+	if err := c.Provider.Do(ctx, ethrpc.NewCall("hardhat_reset", params)); err != nil {
+		return fmt.Errorf("testutil: unable to fork at block %d: %w", blockNumber, err)
+	}
+
+	chainID, err := c.Provider.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("testutil: unable to get chainID of fork: %w", err)
+	}
+	c.chainID = chainID
+	return nil
+}
+
+// Impersonate lets the test chain send transactions from address with eth_sendTransaction,
+// without its key. It needs a node serving hardhat_impersonateAccount, such as anvil or
+// hardhat.
+func (c *TestChain) Impersonate(ctx context.Context, address common.Address) error {
+	if err := c.Provider.Do(ctx, ethrpc.NewCall("hardhat_impersonateAccount", address)); err != nil {
+		return fmt.Errorf("testutil: unable to impersonate %v: %w", address.Hex(), err)
+	}
+	return nil
+}
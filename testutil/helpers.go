@@ -16,6 +16,7 @@ import (
 	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/0xsequence/ethkit/go-ethereum/core/types"
 	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -67,6 +68,37 @@ func DummyPrivateKey(seed uint64) string {
 	return fmt.Sprintf("%064x", seed)
 }
 
+// MemChainEOA returns the EOA of seed, funded with balance on chain, an in-memory chain, and
+// connected to its provider.
+func MemChainEOA(chain *memchain.Chain, seed uint64, balance *big.Int) (*ethwallet.Wallet, error) {
+	eoa, err := ethwallet.NewWalletFromPrivateKey(DummyPrivateKey(seed))
+	if err != nil {
+		return nil, err
+	}
+	eoa.SetProvider(chain.Provider())
+	chain.Fund(eoa.Address(), balance)
+	return eoa, nil
+}
+
+// MemChainWallet returns the single-owner Sequence wallet of seed, as DummySequenceWallet,
+// connected to the provider of chain, an in-memory chain, without a relayer. The wallet is
+// counterfactual on chain: it signs, and its signatures are validated, and its bundles are
+// executed there once it is deployed with DeployMemChainWallet.
+func MemChainWallet(chain *memchain.Chain, seed uint64) (*sequence.Wallet, error) {
+	owner, err := ethwallet.NewWalletFromPrivateKey(DummyPrivateKey(seed))
+	if err != nil {
+		return nil, err
+	}
+	wallet, err := sequence.NewWalletSingleOwner(owner)
+	if err != nil {
+		return nil, err
+	}
+	if err := wallet.SetProvider(chain.Provider()); err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
 func SignAndSend(t *testing.T, wallet *sequence.Wallet, to common.Address, data []byte) error {
 	stx := &sequence.Transaction{
 		// DelegateCall:  false,
@@ -0,0 +1,115 @@
+package memchain
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// MaxCallDepth is the maximum depth of nested calls, as in the EVM.
+const MaxCallDepth = 1024
+
+// Contract is the Go implementation of a contract deployed on the chain with Chain.Deploy.
+type Contract interface {
+	// Call executes a call to the contract, with the calldata input, and returns its return
+	// data. The changes made through env are reverted if it returns an error, which is a
+	// RevertError to return revert data.
+	Call(env *Env, input []byte) ([]byte, error)
+}
+
+// ContractFunc adapts a function to a Contract.
+type ContractFunc func(env *Env, input []byte) ([]byte, error)
+
+func (f ContractFunc) Call(env *Env, input []byte) ([]byte, error) {
+	return f(env, input)
+}
+
+// RevertError is the error of a reverted call, with its revert data.
+type RevertError struct {
+	Reason string
+	Data   []byte
+}
+
+func (e *RevertError) Error() string {
+	if e.Reason == "" {
+		return "execution reverted"
+	}
+	return "execution reverted: " + e.Reason
+}
+
+var (
+	errorSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	stringType, _ = abi.NewType("string", "", nil)
+)
+
+// Revert returns the error reverting a call with reason, encoded as Solidity does.
+func Revert(reason string) error {
+	data, _ := abi.Arguments{{Type: stringType}}.Pack(reason)
+	return &RevertError{Reason: reason, Data: append(append([]byte{}, errorSelector...), data...)}
+}
+
+// Env is the environment of a call to a contract, through which it reads and changes the
+// state of the chain.
+type Env struct {
+	// Caller is the address calling the contract at Address, with Value.
+	Caller  common.Address
+	Address common.Address
+	Value   *big.Int
+
+	// BlockNumber and Time are the number and time of the block the call is executed in.
+	BlockNumber uint64
+	Time        uint64
+
+	chain *Chain
+	state *state
+	gas   *uint64
+	depth int
+}
+
+// Load returns the value of key in the storage of the contract.
+func (e *Env) Load(key common.Hash) common.Hash {
+	return e.state.get(e.Address).storage[key]
+}
+
+// Store sets key to value in the storage of the contract.
+func (e *Env) Store(key, value common.Hash) {
+	e.state.mutable(e.Address).storage[key] = value
+}
+
+// Log emits a log of the contract.
+func (e *Env) Log(topics []common.Hash, data []byte) {
+	e.state.logs = append(e.state.logs, &types.Log{
+		Address: e.Address,
+		Topics:  append([]common.Hash{}, topics...),
+		Data:    append([]byte{}, data...),
+	})
+}
+
+// Balance returns the balance of addr.
+func (e *Env) Balance(addr common.Address) *big.Int {
+	return new(big.Int).Set(e.state.get(addr).balance)
+}
+
+// Code returns the code of addr.
+func (e *Env) Code(addr common.Address) []byte {
+	return e.state.get(addr).code
+}
+
+// Call calls to with value and the calldata input, as the contract. The changes of the call
+// are reverted if it fails, and the contract may go on, as with a low-level call.
+func (e *Env) Call(to common.Address, value *big.Int, input []byte) ([]byte, error) {
+	if e.depth >= MaxCallDepth {
+		return nil, fmt.Errorf("memchain: max call depth exceeded")
+	}
+	return e.chain.call(e.state, e.Address, to, value, input, e.gas, e.BlockNumber, e.Time, e.depth+1)
+}
+
+// UseGas counts gas against the gas limit of the transaction, as the contract spends it. Calls
+// only use the intrinsic gas of their transaction otherwise.
+func (e *Env) UseGas(gas uint64) {
+	*e.gas += gas
+}
@@ -0,0 +1,457 @@
+// Package memchain is an in-memory chain for unit tests, served to an ethrpc.Provider within
+// the process, so tests which verify digests, signatures and encodings, or send basic
+// transactions, run in milliseconds without a node.
+//
+// The chain is deterministic: a block is mined for each transaction sent, at times advancing
+// by Options.BlockTime from Options.GenesisTime, so the same transactions always produce the
+// same blocks. It has no EVM: value transfers are executed natively, and contracts are Go
+// implementations of Contract deployed with Chain.Deploy. Sequence wallets are counterfactual
+// on the chain, which is enough to sign and validate their signatures, and execute their
+// bundles once deployed with testutil.DeployMemChainWallet. State is only served for the latest
+// block, whatever block reads ask for, and kept for the past blocks to roll back to with
+// Chain.Reorg.
+//
+//	chain := memchain.New(memchain.Options{})
+//	chain.Fund(eoa.Address(), big.NewInt(1e18))
+//	eoa.SetProvider(chain.Provider())
+package memchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+// TxGas is the intrinsic gas of transactions, to which the gas of their calldata is added.
+const TxGas uint64 = 21000
+
+// code is the code of the contracts deployed with Chain.Deploy, the INVALID opcode, so they
+// are contracts to callers checking their code.
+var code = []byte{0xfe}
+
+type Options struct {
+	// ChainID is the id of the chain, 1337 by default.
+	ChainID *big.Int
+
+	// GenesisTime is the time of the genesis block, 2024-01-01 by default, and BlockTime the
+	// time between blocks, 1 second by default.
+	GenesisTime time.Time
+	BlockTime   time.Duration
+
+	// BaseFee is the base fee of the blocks, and GasTip the tip suggested to senders, 1 gwei
+	// each by default.
+	BaseFee *big.Int
+	GasTip  *big.Int
+
+	// BlockGasLimit is the gas limit of the blocks, 30M by default.
+	BlockGasLimit uint64
+}
+
+// Chain is an in-memory chain. It is an http.Handler serving the JSON-RPC API of a node.
+type Chain struct {
+	options Options
+
+	state     *state
+	contracts map[common.Address]Contract
+	blocks    []*block
+	txns      map[common.Hash]txnLocation
+	offset    time.Duration
+	reorgs    uint64
+
+	interceptor Interceptor
+	mu          sync.Mutex
+}
+
+// Interceptor intercepts the JSON-RPC requests to the chain, which it serves with next, or
+// answers itself, ie. to record, delay or fail requests. The errors it returns are returned to
+// the callers, as reverts if they are RevertErrors.
+type Interceptor func(method string, params []json.RawMessage, next func() (interface{}, error)) (interface{}, error)
+
+type block struct {
+	header   *types.Header
+	hash     common.Hash
+	txns     []*types.Transaction
+	senders  []common.Address
+	receipts []*types.Receipt
+
+	// parent is the state the block was mined on, which a reorg rolls the chain back to
+	parent *state
+}
+
+type txnLocation struct {
+	block *block
+	index int
+}
+
+func New(options Options) *Chain {
+	if options.ChainID == nil {
+		options.ChainID = big.NewInt(1337)
+	}
+	if options.GenesisTime.IsZero() {
+		options.GenesisTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if options.BlockTime == 0 {
+		options.BlockTime = time.Second
+	}
+	if options.BaseFee == nil {
+		options.BaseFee = big.NewInt(1_000_000_000)
+	}
+	if options.GasTip == nil {
+		options.GasTip = big.NewInt(1_000_000_000)
+	}
+	if options.BlockGasLimit == 0 {
+		options.BlockGasLimit = 30_000_000
+	}
+
+	c := &Chain{
+		options:   options,
+		state:     newState(),
+		contracts: map[common.Address]Contract{},
+		txns:      map[common.Hash]txnLocation{},
+	}
+	c.mine(c.state.snapshot(), nil, nil, nil)
+	return c
+}
+
+// Provider returns a provider of the chain, whose requests are served within the process.
+func (c *Chain) Provider() *ethrpc.Provider {
+	provider, _ := ethrpc.NewProvider("http://memchain", ethrpc.WithHTTPClient(c.Client()))
+	return provider
+}
+
+// Client returns an HTTP client whose requests are served by the chain within the process,
+// whatever their URL, ie. to be wrapped by the client of a provider.
+func (c *Chain) Client() *http.Client {
+	return &http.Client{Transport: transport{c}}
+}
+
+// transport serves the requests of a client with the chain.
+type transport struct {
+	chain *Chain
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	recorder := httptest.NewRecorder()
+	served := make(chan struct{})
+	go func() {
+		t.chain.ServeHTTP(recorder, req)
+		close(served)
+	}()
+	select {
+	case <-served:
+		return recorder.Result(), nil
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+}
+
+// SetBaseFee sets the base fee of the blocks mined from now on.
+func (c *Chain) SetBaseFee(baseFee *big.Int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.options.BaseFee = new(big.Int).Set(baseFee)
+}
+
+// Intercept sets the interceptor of the requests to the chain, none if nil.
+func (c *Chain) Intercept(interceptor Interceptor) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interceptor = interceptor
+}
+
+func (c *Chain) ChainID() *big.Int {
+	return new(big.Int).Set(c.options.ChainID)
+}
+
+// Head returns the number of the latest block.
+func (c *Chain) Head() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.head().header.Number.Uint64()
+}
+
+// Fund adds amount to the balance of addr.
+func (c *Chain) Fund(addr common.Address, amount *big.Int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	a := c.state.mutable(addr)
+	a.balance.Add(a.balance, amount)
+}
+
+// SetBalance sets the balance of addr.
+func (c *Chain) SetBalance(addr common.Address, balance *big.Int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.state.mutable(addr).balance = new(big.Int).Set(balance)
+}
+
+// Balance returns the balance of addr.
+func (c *Chain) Balance(addr common.Address) *big.Int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return new(big.Int).Set(c.state.get(addr).balance)
+}
+
+// Deploy deploys contract at addr, replacing the contract deployed there, if any.
+func (c *Chain) Deploy(addr common.Address, contract Contract) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.contracts[addr] = contract
+	c.state.mutable(addr).code = code
+}
+
+// SetCode sets the code of addr, ie. so a wallet is deployed to callers checking its code.
+// Calls to code without a Contract deployed fail.
+func (c *Chain) SetCode(addr common.Address, code []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.state.mutable(addr).code = common.CopyBytes(code)
+}
+
+// Mine mines an empty block.
+func (c *Chain) Mine() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mine(c.state.snapshot(), nil, nil, nil)
+}
+
+// Reorg replaces the latest depth blocks with as many new blocks, of other hashes, which
+// include the same transactions again, ie. so the receipts and logs of the replaced blocks are
+// removed, and then added back in the new ones. The state is rolled back to the one the first
+// replaced block was mined on, so the changes of Fund, Deploy and SetCode made since are lost.
+func (c *Chain) Reorg(depth int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if depth <= 0 || depth >= len(c.blocks) {
+		return fmt.Errorf("memchain: can't reorg %d blocks of a chain of %d blocks", depth, len(c.blocks))
+	}
+	replaced := c.blocks[len(c.blocks)-depth:]
+	c.blocks = c.blocks[:len(c.blocks)-depth]
+	c.state = replaced[0].parent.snapshot()
+	c.reorgs++
+
+	for _, b := range replaced {
+		for _, tx := range b.txns {
+			delete(c.txns, tx.Hash())
+		}
+	}
+	for _, b := range replaced {
+		if len(b.txns) == 0 {
+			c.mine(c.state.snapshot(), nil, nil, nil)
+			continue
+		}
+		for _, tx := range b.txns {
+			if err := c.sendTransaction(tx); err != nil {
+				return fmt.Errorf("memchain: failed to include %v again: %w", tx.Hash().Hex(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// AdvanceTime moves the time of the next blocks forward by d.
+func (c *Chain) AdvanceTime(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offset += d
+}
+
+func (c *Chain) head() *block {
+	return c.blocks[len(c.blocks)-1]
+}
+
+// mine appends a block of txns mined on parent, and sets the positions of their receipts and
+// logs in it.
+func (c *Chain) mine(parent *state, txns []*types.Transaction, senders []common.Address, receipts []*types.Receipt) {
+	number := uint64(len(c.blocks))
+	header := &types.Header{
+		UncleHash:   types.EmptyUncleHash,
+		Root:        types.EmptyRootHash,
+		TxHash:      types.EmptyRootHash,
+		ReceiptHash: types.EmptyRootHash,
+		Difficulty:  new(big.Int),
+		Number:      new(big.Int).SetUint64(number),
+		GasLimit:    c.options.BlockGasLimit,
+		Time:        c.nextTime(),
+		BaseFee:     new(big.Int).Set(c.options.BaseFee),
+		Bloom:       types.CreateBloom(receipts),
+	}
+	if number > 0 {
+		header.ParentHash = c.head().hash
+	}
+	if c.reorgs > 0 {
+		// the blocks replacing others have other hashes
+		header.Extra = new(big.Int).SetUint64(c.reorgs).Bytes()
+	}
+	for _, receipt := range receipts {
+		header.GasUsed += receipt.GasUsed
+	}
+
+	b := &block{header: header, hash: header.Hash(), txns: txns, senders: senders, receipts: receipts, parent: parent}
+	var logIndex uint
+	for i, receipt := range receipts {
+		receipt.BlockHash = b.hash
+		receipt.BlockNumber = new(big.Int).SetUint64(number)
+		receipt.TransactionIndex = uint(i)
+		for _, log := range receipt.Logs {
+			log.BlockHash, log.BlockNumber = b.hash, number
+			log.TxHash, log.TxIndex = receipt.TxHash, uint(i)
+			log.Index = logIndex
+			logIndex++
+		}
+		c.txns[receipt.TxHash] = txnLocation{block: b, index: i}
+	}
+	c.blocks = append(c.blocks, b)
+}
+
+// nextTime is the time of the next block.
+func (c *Chain) nextTime() uint64 {
+	return uint64(c.options.GenesisTime.Add(time.Duration(len(c.blocks))*c.options.BlockTime + c.offset).Unix())
+}
+
+// sendTransaction executes tx in a block of its own.
+func (c *Chain) sendTransaction(tx *types.Transaction) error {
+	if _, ok := c.txns[tx.Hash()]; ok {
+		return fmt.Errorf("already known")
+	}
+	if tx.Protected() && tx.ChainId().Cmp(c.options.ChainID) != 0 {
+		return fmt.Errorf("invalid chain id %v", tx.ChainId())
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(c.options.ChainID), tx)
+	if err != nil {
+		return fmt.Errorf("invalid sender: %w", err)
+	}
+	if tx.To() == nil {
+		return fmt.Errorf("memchain: contract creation is not supported, deploy Go contracts with Chain.Deploy")
+	}
+
+	from := c.state.get(sender)
+	if tx.Nonce() < from.nonce {
+		return fmt.Errorf("nonce too low: next nonce %d, tx nonce %d", from.nonce, tx.Nonce())
+	} else if tx.Nonce() > from.nonce {
+		return fmt.Errorf("nonce too high: next nonce %d, tx nonce %d", from.nonce, tx.Nonce())
+	}
+	if tx.GasFeeCap().Cmp(c.options.BaseFee) < 0 {
+		return fmt.Errorf("max fee per gas less than block base fee: maxFeePerGas %v, baseFee %v", tx.GasFeeCap(), c.options.BaseFee)
+	}
+	intrinsic := intrinsicGas(tx.Data())
+	if tx.Gas() < intrinsic {
+		return fmt.Errorf("intrinsic gas too low: have %d, want %d", tx.Gas(), intrinsic)
+	}
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()), tx.GasFeeCap())
+	cost.Add(cost, tx.Value())
+	if from.balance.Cmp(cost) < 0 {
+		return fmt.Errorf("insufficient funds for gas * price + value: address %v have %v want %v", sender.Hex(), from.balance, cost)
+	}
+
+	parent := c.state.snapshot()
+	c.state.mutable(sender).nonce++
+
+	gasUsed := intrinsic
+	st := c.state.child()
+	_, err = c.call(st, sender, *tx.To(), tx.Value(), tx.Data(), &gasUsed, uint64(len(c.blocks)), c.nextTime(), 0)
+	receipt := &types.Receipt{
+		Type:   tx.Type(),
+		TxHash: tx.Hash(),
+		Logs:   []*types.Log{},
+	}
+	if err == nil && gasUsed <= tx.Gas() {
+		st.commit()
+		receipt.Status = types.ReceiptStatusSuccessful
+		receipt.Logs = append(receipt.Logs, c.state.logs...)
+	} else {
+		receipt.Status = types.ReceiptStatusFailed
+	}
+	c.state.logs = nil
+	if gasUsed > tx.Gas() {
+		gasUsed = tx.Gas()
+	}
+
+	price := effectiveGasPrice(tx, c.options.BaseFee)
+	payer := c.state.mutable(sender)
+	payer.balance.Sub(payer.balance, new(big.Int).Mul(new(big.Int).SetUint64(gasUsed), price))
+
+	receipt.GasUsed, receipt.CumulativeGasUsed = gasUsed, gasUsed
+	receipt.EffectiveGasPrice = price
+	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+	c.mine(parent, []*types.Transaction{tx}, []common.Address{sender}, []*types.Receipt{receipt})
+	return nil
+}
+
+// call executes a call of from to to in st, which it changes if the call succeeds.
+func (c *Chain) call(st *state, from, to common.Address, value *big.Int, input []byte, gas *uint64, number, time uint64, depth int) ([]byte, error) {
+	child := st.child()
+	if err := child.transfer(from, to, value); err != nil {
+		return nil, err
+	}
+
+	contract := c.contracts[to]
+	if contract == nil {
+		if len(child.get(to).code) > 0 {
+			return nil, fmt.Errorf("memchain: no contract implements the code of %v", to.Hex())
+		}
+		child.commit()
+		return nil, nil
+	}
+
+	if value == nil {
+		value = new(big.Int)
+	}
+	out, err := contract.Call(&Env{
+		Caller:      from,
+		Address:     to,
+		Value:       new(big.Int).Set(value),
+		BlockNumber: number,
+		Time:        time,
+		chain:       c,
+		state:       child,
+		gas:         gas,
+		depth:       depth,
+	}, input)
+	if err != nil {
+		return nil, err
+	}
+	child.commit()
+	return out, nil
+}
+
+func intrinsicGas(data []byte) uint64 {
+	gas := TxGas
+	zeros := uint64(bytes.Count(data, []byte{0}))
+	gas += zeros*4 + (uint64(len(data))-zeros)*16
+	return gas
+}
+
+func effectiveGasPrice(tx *types.Transaction, baseFee *big.Int) *big.Int {
+	if tx.Type() == types.LegacyTxType || tx.Type() == types.AccessListTxType {
+		return tx.GasPrice()
+	}
+	price := new(big.Int).Add(baseFee, tx.GasTipCap())
+	if price.Cmp(tx.GasFeeCap()) > 0 {
+		price = tx.GasFeeCap()
+	}
+	return price
+}
+
+// revertData returns the revert data of err, if it is a RevertError.
+func revertData(err error) ([]byte, bool) {
+	var revert *RevertError
+	if errors.As(err, &revert) {
+		return revert.Data, true
+	}
+	return nil, false
+}
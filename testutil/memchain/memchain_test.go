@@ -0,0 +1,284 @@
+package memchain_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	counter          = common.HexToAddress("0xc0c0")
+	incrementedTopic = crypto.Keccak256Hash([]byte("Incremented(uint256)"))
+	incrementData    = crypto.Keccak256([]byte("increment()"))[:4]
+	countData        = crypto.Keccak256([]byte("count()"))[:4]
+)
+
+// counterContract counts its increments, which are limited to 2.
+var counterContract = memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+	count := env.Load(common.Hash{}).Big()
+	switch {
+	case len(input) >= 4 && string(input[:4]) == string(incrementData):
+		if count.Int64() >= 2 {
+			return nil, memchain.Revert("too many increments")
+		}
+		count.Add(count, big.NewInt(1))
+		env.Store(common.Hash{}, common.BigToHash(count))
+		env.Log([]common.Hash{incrementedTopic}, common.BigToHash(count).Bytes())
+		env.UseGas(20000)
+		return nil, nil
+	case len(input) >= 4 && string(input[:4]) == string(countData):
+		return common.BigToHash(count).Bytes(), nil
+	default:
+		return nil, memchain.Revert("unknown method")
+	}
+})
+
+func send(t *testing.T, eoa *ethwallet.Wallet, to common.Address, value *big.Int, data []byte) *types.Receipt {
+	ctx := context.Background()
+	txn, err := eoa.NewTransaction(ctx, &ethtxn.TransactionRequest{To: &to, ETHValue: value, Data: data})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	txn, _, err = eoa.SendTransaction(ctx, txn)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	receipt, err := eoa.GetProvider().TransactionReceipt(ctx, txn.Hash())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return receipt
+}
+
+func TestChain(t *testing.T) {
+	ctx := context.Background()
+
+	chain := memchain.New(memchain.Options{})
+	provider := chain.Provider()
+	chain.Deploy(counter, counterContract)
+
+	chainID, err := provider.ChainID(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1337), chainID)
+
+	eoa, err := testutil.MemChainEOA(chain, 1, big.NewInt(1e18))
+	assert.NoError(t, err)
+
+	// value is transferred, and the gas is paid by the sender
+	bob := common.HexToAddress("0xb0b")
+	receipt := send(t, eoa, bob, big.NewInt(1000), nil)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	assert.Equal(t, uint64(1), receipt.BlockNumber.Uint64())
+	assert.Equal(t, memchain.TxGas, receipt.GasUsed)
+	assert.Equal(t, big.NewInt(1000), chain.Balance(bob))
+	gasPrice, err := provider.SuggestGasPrice(ctx)
+	assert.NoError(t, err)
+	fee := new(big.Int).Mul(big.NewInt(int64(memchain.TxGas)), gasPrice)
+	assert.Equal(t, new(big.Int).Sub(big.NewInt(1e18), new(big.Int).Add(fee, big.NewInt(1000))), chain.Balance(eoa.Address()))
+	chain.SetBalance(bob, big.NewInt(10))
+	balance, err := provider.BalanceAt(ctx, bob, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(10), balance)
+
+	// contracts store values, emit logs and revert
+	receipt = send(t, eoa, counter, nil, incrementData)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	assert.Len(t, receipt.Logs, 1)
+	assert.Greater(t, receipt.GasUsed, memchain.TxGas+20000)
+	send(t, eoa, counter, nil, incrementData)
+
+	res, err := provider.CallContract(ctx, ethereum.CallMsg{To: &counter, Data: countData}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, common.BigToHash(big.NewInt(2)).Bytes(), res)
+
+	_, err = provider.CallContract(ctx, ethereum.CallMsg{To: &counter, Data: incrementData}, nil)
+	assert.ErrorContains(t, err, "execution reverted: too many increments")
+
+	// a transaction which reverts is mined, without its changes
+	nonce, err := provider.NonceAt(ctx, eoa.Address(), nil)
+	assert.NoError(t, err)
+	txn, err := eoa.NewTransaction(ctx, &ethtxn.TransactionRequest{To: &counter, Data: incrementData, GasLimit: 100000})
+	assert.NoError(t, err)
+	txn, _, err = eoa.SendTransaction(ctx, txn)
+	assert.NoError(t, err)
+	receipt, err = provider.TransactionReceipt(ctx, txn.Hash())
+	assert.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusFailed, receipt.Status)
+	assert.Empty(t, receipt.Logs)
+	nextNonce, err := provider.NonceAt(ctx, eoa.Address(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, nonce+1, nextNonce)
+
+	// blocks and logs are served
+	assert.Equal(t, uint64(4), chain.Head())
+	block, err := provider.BlockByNumber(ctx, big.NewInt(4))
+	assert.NoError(t, err)
+	assert.Equal(t, receipt.BlockHash, block.Hash())
+	assert.Len(t, block.Transactions(), 1)
+	assert.Equal(t, uint64(time.Date(2024, 1, 1, 0, 0, 4, 0, time.UTC).Unix()), block.Time())
+
+	logs, err := provider.FilterLogs(ctx, ethereum.FilterQuery{FromBlock: big.NewInt(0), Addresses: []common.Address{counter}, Topics: [][]common.Hash{{incrementedTopic}}})
+	assert.NoError(t, err)
+	if assert.Len(t, logs, 2) {
+		assert.Equal(t, uint64(2), logs[0].BlockNumber)
+		assert.Equal(t, common.BigToHash(big.NewInt(2)).Bytes(), logs[1].Data)
+	}
+
+	// the chain is deterministic
+	replayed := memchain.New(memchain.Options{})
+	replayed.Deploy(counter, counterContract)
+	replayedEOA, err := testutil.MemChainEOA(replayed, 1, big.NewInt(1e18))
+	assert.NoError(t, err)
+	send(t, replayedEOA, bob, big.NewInt(1000), nil)
+	header, err := replayed.Provider().HeaderByNumber(ctx, big.NewInt(1))
+	assert.NoError(t, err)
+	original, err := provider.HeaderByNumber(ctx, big.NewInt(1))
+	assert.NoError(t, err)
+	assert.Equal(t, original.Hash(), header.Hash())
+}
+
+func TestSequenceWallet(t *testing.T) {
+	ctx := context.Background()
+	chain := memchain.New(memchain.Options{})
+	wallet, err := testutil.MemChainWallet(chain, 1)
+	assert.NoError(t, err)
+
+	// counterfactual wallets sign, and their signatures are validated
+	digest := sequence.MessageDigest([]byte("hello"))
+	sig, _, err := wallet.SignDigest(digest)
+	assert.NoError(t, err)
+	ok, err := sequence.IsValidSignatureWith(ctx, wallet.GetProvider(), wallet.GetWalletContext(), wallet.Address(), digest, sig)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// deployed wallets are validated with ERC-1271, by the contract deployed at their address
+	chain.Deploy(wallet.Address(), memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		return nil, memchain.Revert("invalid signature")
+	}))
+	ok, err = sequence.IsValidSignatureWith(ctx, wallet.GetProvider(), wallet.GetWalletContext(), wallet.Address(), digest, sig)
+	assert.Error(t, err)
+	assert.False(t, ok)
+}
+
+func TestReorg(t *testing.T) {
+	ctx := context.Background()
+
+	chain := memchain.New(memchain.Options{})
+	provider := chain.Provider()
+	chain.Deploy(counter, counterContract)
+
+	eoa, err := testutil.MemChainEOA(chain, 1, big.NewInt(1e18))
+	assert.NoError(t, err)
+	first := send(t, eoa, counter, nil, incrementData)
+	second := send(t, eoa, counter, nil, incrementData)
+
+	assert.Error(t, chain.Reorg(0))
+	assert.Error(t, chain.Reorg(3))
+
+	// the transactions are included again in blocks of other hashes
+	bob := common.HexToAddress("0xb0b")
+	chain.Fund(bob, big.NewInt(1000))
+	assert.NoError(t, chain.Reorg(2))
+	assert.Equal(t, uint64(2), chain.Head())
+
+	for _, replaced := range []*types.Receipt{first, second} {
+		receipt, err := provider.TransactionReceipt(ctx, replaced.TxHash)
+		assert.NoError(t, err)
+		assert.Equal(t, replaced.BlockNumber, receipt.BlockNumber)
+		assert.NotEqual(t, replaced.BlockHash, receipt.BlockHash)
+		if assert.Len(t, receipt.Logs, 1) {
+			assert.Equal(t, replaced.Logs[0].Data, receipt.Logs[0].Data)
+			assert.Equal(t, receipt.BlockHash, receipt.Logs[0].BlockHash)
+		}
+	}
+	block, err := provider.BlockByNumber(ctx, big.NewInt(2))
+	assert.NoError(t, err)
+	parent, err := provider.BlockByNumber(ctx, big.NewInt(1))
+	assert.NoError(t, err)
+	assert.Equal(t, parent.Hash(), block.ParentHash())
+
+	// the state is the one of the new blocks, without the changes made since the fork
+	res, err := provider.CallContract(ctx, ethereum.CallMsg{To: &counter, Data: countData}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, common.BigToHash(big.NewInt(2)).Bytes(), res)
+	assert.Zero(t, chain.Balance(bob).Sign())
+
+	nonce, err := provider.NonceAt(ctx, eoa.Address(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), nonce)
+	receipt := send(t, eoa, bob, big.NewInt(1000), nil)
+	assert.Equal(t, uint64(3), receipt.BlockNumber.Uint64())
+	header, err := provider.HeaderByNumber(ctx, receipt.BlockNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, block.Hash(), header.ParentHash)
+}
+
+func TestIntercept(t *testing.T) {
+	ctx := context.Background()
+
+	chain := memchain.New(memchain.Options{})
+	provider := chain.Provider()
+	chain.Deploy(counter, counterContract)
+
+	// the requests are recorded, and the reads of the count fail
+	var methods []string
+	chain.Intercept(func(method string, params []json.RawMessage, next func() (interface{}, error)) (interface{}, error) {
+		methods = append(methods, method)
+		if method == "eth_call" {
+			return nil, errors.New("unavailable")
+		}
+		return next()
+	})
+	_, err := provider.CallContract(ctx, ethereum.CallMsg{To: &counter, Data: countData}, nil)
+	assert.ErrorContains(t, err, "unavailable")
+	head, err := provider.BlockNumber(ctx)
+	assert.NoError(t, err)
+	assert.Zero(t, head)
+	assert.Equal(t, []string{"eth_call", "eth_blockNumber"}, methods)
+
+	// slow requests are bounded by the context of their callers
+	chain.Intercept(func(method string, params []json.RawMessage, next func() (interface{}, error)) (interface{}, error) {
+		time.Sleep(100 * time.Millisecond)
+		return next()
+	})
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = provider.BlockNumber(timeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	chain.Intercept(nil)
+	res, err := provider.CallContract(ctx, ethereum.CallMsg{To: &counter, Data: countData}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, common.Hash{}.Bytes(), res)
+}
+
+func TestSetBaseFee(t *testing.T) {
+	ctx := context.Background()
+
+	chain := memchain.New(memchain.Options{BaseFee: big.NewInt(200)})
+	provider := chain.Provider()
+
+	// the base fee is the one of the blocks mined since it was set
+	chain.SetBaseFee(big.NewInt(50))
+	header, err := provider.HeaderByNumber(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(200), header.BaseFee)
+	chain.Mine()
+	header, err = provider.HeaderByNumber(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(50), header.BaseFee)
+}
@@ -0,0 +1,459 @@
+package memchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+type rpcRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data,omitempty"`
+}
+
+// ServeHTTP serves JSON-RPC requests, and batches of requests, to the chain.
+func (c *Chain) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if body = bytes.TrimSpace(body); len(body) > 0 && body[0] == '[' {
+		var reqs []rpcRequest
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		responses := make([]map[string]interface{}, len(reqs))
+		for i, req := range reqs {
+			responses[i] = c.handle(req)
+		}
+		_ = json.NewEncoder(w).Encode(responses)
+		return
+	}
+
+	var req rpcRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(c.handle(req))
+}
+
+func (c *Chain) handle(req rpcRequest) map[string]interface{} {
+	response := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	next := func() (interface{}, error) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.dispatch(req.Method, req.Params)
+	}
+
+	c.mu.Lock()
+	interceptor := c.interceptor
+	c.mu.Unlock()
+
+	var result interface{}
+	var err error
+	if interceptor != nil {
+		result, err = interceptor(req.Method, req.Params, next)
+	} else {
+		result, err = next()
+	}
+	if err != nil {
+		rpcErr := &rpcError{Code: -32000, Message: err.Error()}
+		if data, ok := revertData(err); ok {
+			rpcErr.Code, rpcErr.Data = 3, hexutil.Encode(data)
+		}
+		if _, ok := err.(methodNotFound); ok {
+			rpcErr.Code = -32601
+		}
+		response["error"] = rpcErr
+	} else {
+		response["result"] = result
+	}
+	return response
+}
+
+type methodNotFound string
+
+func (m methodNotFound) Error() string {
+	return fmt.Sprintf("the method %s does not exist/is not available", string(m))
+}
+
+func (c *Chain) dispatch(method string, params []json.RawMessage) (interface{}, error) {
+	param := func(i int, v interface{}) error {
+		if i >= len(params) {
+			return fmt.Errorf("missing value for required argument %d", i)
+		}
+		if err := json.Unmarshal(params[i], v); err != nil {
+			return fmt.Errorf("invalid argument %d: %w", i, err)
+		}
+		return nil
+	}
+
+	switch method {
+	case "eth_chainId":
+		return hexutil.EncodeBig(c.options.ChainID), nil
+	case "net_version":
+		return c.options.ChainID.String(), nil
+	case "web3_clientVersion":
+		return "memchain", nil
+	case "eth_syncing":
+		return false, nil
+	case "eth_accounts":
+		return []common.Address{}, nil
+	case "eth_blockNumber":
+		return hexutil.EncodeUint64(c.head().header.Number.Uint64()), nil
+	case "eth_gasPrice":
+		return hexutil.EncodeBig(new(big.Int).Add(c.options.BaseFee, c.options.GasTip)), nil
+	case "eth_maxPriorityFeePerGas":
+		return hexutil.EncodeBig(c.options.GasTip), nil
+
+	case "eth_getBalance", "eth_getTransactionCount", "eth_getCode":
+		var addr common.Address
+		if err := param(0, &addr); err != nil {
+			return nil, err
+		}
+		a := c.state.get(addr)
+		switch method {
+		case "eth_getBalance":
+			return hexutil.EncodeBig(a.balance), nil
+		case "eth_getTransactionCount":
+			return hexutil.EncodeUint64(a.nonce), nil
+		default:
+			return hexutil.Bytes(a.code), nil
+		}
+
+	case "eth_getStorageAt":
+		var addr common.Address
+		var key common.Hash
+		if err := param(0, &addr); err != nil {
+			return nil, err
+		}
+		if err := param(1, &key); err != nil {
+			return nil, err
+		}
+		return c.state.get(addr).storage[key], nil
+
+	case "eth_call", "eth_estimateGas":
+		var args callArgs
+		if err := param(0, &args); err != nil {
+			return nil, err
+		}
+		gasUsed := intrinsicGas(args.data())
+		out, err := c.call(c.state.child(), args.from(), args.to(), args.value(), args.data(), &gasUsed, uint64(len(c.blocks)), c.nextTime(), 0)
+		if err != nil {
+			return nil, err
+		}
+		if method == "eth_call" {
+			return hexutil.Bytes(out), nil
+		}
+		return hexutil.EncodeUint64(gasUsed), nil
+
+	case "eth_sendRawTransaction":
+		var raw hexutil.Bytes
+		if err := param(0, &raw); err != nil {
+			return nil, err
+		}
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(raw); err != nil {
+			return nil, fmt.Errorf("invalid transaction: %w", err)
+		}
+		if err := c.sendTransaction(tx); err != nil {
+			return nil, err
+		}
+		return tx.Hash(), nil
+
+	case "eth_getTransactionByHash", "eth_getTransactionReceipt":
+		var hash common.Hash
+		if err := param(0, &hash); err != nil {
+			return nil, err
+		}
+		location, ok := c.txns[hash]
+		if !ok {
+			return nil, nil
+		}
+		if method == "eth_getTransactionReceipt" {
+			return receiptJSON(location.block, location.index)
+		}
+		return txnJSON(location.block, location.index)
+
+	case "eth_getBlockByNumber", "eth_getBlockByHash":
+		var full bool
+		if len(params) > 1 {
+			if err := param(1, &full); err != nil {
+				return nil, err
+			}
+		}
+		var b *block
+		if method == "eth_getBlockByHash" {
+			var hash common.Hash
+			if err := param(0, &hash); err != nil {
+				return nil, err
+			}
+			for _, candidate := range c.blocks {
+				if candidate.hash == hash {
+					b = candidate
+				}
+			}
+		} else {
+			var tag string
+			if err := param(0, &tag); err != nil {
+				return nil, err
+			}
+			number, err := c.blockNumber(tag)
+			if err != nil {
+				return nil, err
+			}
+			if number < uint64(len(c.blocks)) {
+				b = c.blocks[number]
+			}
+		}
+		if b == nil {
+			return nil, nil
+		}
+		return blockJSON(b, full)
+
+	case "eth_getLogs":
+		var filter logFilter
+		if err := param(0, &filter); err != nil {
+			return nil, err
+		}
+		return c.logs(&filter)
+
+	default:
+		return nil, methodNotFound(method)
+	}
+}
+
+// blockNumber returns the number of the block of tag, a number or a tag of the latest block.
+func (c *Chain) blockNumber(tag string) (uint64, error) {
+	switch tag {
+	case "", "latest", "pending", "safe", "finalized":
+		return c.head().header.Number.Uint64(), nil
+	case "earliest":
+		return 0, nil
+	default:
+		number, err := hexutil.DecodeUint64(tag)
+		if err != nil {
+			return 0, fmt.Errorf("invalid block number %q", tag)
+		}
+		return number, nil
+	}
+}
+
+type callArgs struct {
+	From  *common.Address `json:"from"`
+	To    *common.Address `json:"to"`
+	Value *hexutil.Big    `json:"value"`
+	Data  *hexutil.Bytes  `json:"data"`
+	Input *hexutil.Bytes  `json:"input"`
+}
+
+func (a *callArgs) from() common.Address {
+	if a.From == nil {
+		return common.Address{}
+	}
+	return *a.From
+}
+
+func (a *callArgs) to() common.Address {
+	if a.To == nil {
+		return common.Address{}
+	}
+	return *a.To
+}
+
+func (a *callArgs) value() *big.Int {
+	if a.Value == nil {
+		return new(big.Int)
+	}
+	return a.Value.ToInt()
+}
+
+func (a *callArgs) data() []byte {
+	if a.Input != nil {
+		return *a.Input
+	}
+	if a.Data != nil {
+		return *a.Data
+	}
+	return nil
+}
+
+type logFilter struct {
+	FromBlock string            `json:"fromBlock"`
+	ToBlock   string            `json:"toBlock"`
+	BlockHash *common.Hash      `json:"blockHash"`
+	Address   json.RawMessage   `json:"address"`
+	Topics    []json.RawMessage `json:"topics"`
+}
+
+func (c *Chain) logs(filter *logFilter) ([]*types.Log, error) {
+	var addresses []common.Address
+	if len(filter.Address) > 0 && string(filter.Address) != "null" {
+		if filter.Address[0] == '[' {
+			if err := json.Unmarshal(filter.Address, &addresses); err != nil {
+				return nil, fmt.Errorf("invalid address: %w", err)
+			}
+		} else {
+			var addr common.Address
+			if err := json.Unmarshal(filter.Address, &addr); err != nil {
+				return nil, fmt.Errorf("invalid address: %w", err)
+			}
+			addresses = []common.Address{addr}
+		}
+	}
+
+	topics := make([][]common.Hash, len(filter.Topics))
+	for i, raw := range filter.Topics {
+		switch {
+		case len(raw) == 0 || string(raw) == "null":
+		case raw[0] == '[':
+			if err := json.Unmarshal(raw, &topics[i]); err != nil {
+				return nil, fmt.Errorf("invalid topic %d: %w", i, err)
+			}
+		default:
+			var topic common.Hash
+			if err := json.Unmarshal(raw, &topic); err != nil {
+				return nil, fmt.Errorf("invalid topic %d: %w", i, err)
+			}
+			topics[i] = []common.Hash{topic}
+		}
+	}
+
+	from, err := c.blockNumber(filter.FromBlock)
+	if err != nil {
+		return nil, err
+	}
+	to, err := c.blockNumber(filter.ToBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := []*types.Log{}
+	for _, b := range c.blocks {
+		number := b.header.Number.Uint64()
+		if filter.BlockHash != nil {
+			if b.hash != *filter.BlockHash {
+				continue
+			}
+		} else if number < from || number > to {
+			continue
+		}
+		for _, receipt := range b.receipts {
+			for _, log := range receipt.Logs {
+				if matchLog(log, addresses, topics) {
+					logs = append(logs, log)
+				}
+			}
+		}
+	}
+	return logs, nil
+}
+
+func matchLog(log *types.Log, addresses []common.Address, topics [][]common.Hash) bool {
+	if len(addresses) > 0 {
+		found := false
+		for _, addr := range addresses {
+			found = found || log.Address == addr
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(topics) > len(log.Topics) {
+		return false
+	}
+	for i, options := range topics {
+		if len(options) == 0 {
+			continue
+		}
+		found := false
+		for _, topic := range options {
+			found = found || log.Topics[i] == topic
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func txnJSON(b *block, index int) (map[string]interface{}, error) {
+	tx := b.txns[index]
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return nil, err
+	}
+	var txn map[string]interface{}
+	if err := json.Unmarshal(data, &txn); err != nil {
+		return nil, err
+	}
+	txn["blockHash"] = b.hash
+	txn["blockNumber"] = hexutil.EncodeBig(b.header.Number)
+	txn["transactionIndex"] = hexutil.EncodeUint64(uint64(index))
+	txn["from"] = b.senders[index]
+	txn["gasPrice"] = hexutil.EncodeBig(b.receipts[index].EffectiveGasPrice)
+	return txn, nil
+}
+
+func receiptJSON(b *block, index int) (map[string]interface{}, error) {
+	receipt := b.receipts[index]
+	data, err := json.Marshal(receipt)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	result["from"] = b.senders[index]
+	result["to"] = b.txns[index].To()
+	result["effectiveGasPrice"] = hexutil.EncodeBig(receipt.EffectiveGasPrice)
+	return result, nil
+}
+
+func blockJSON(b *block, full bool) (map[string]interface{}, error) {
+	data, err := json.Marshal(b.header)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+
+	txns := make([]interface{}, len(b.txns))
+	for i, tx := range b.txns {
+		if !full {
+			txns[i] = tx.Hash()
+			continue
+		}
+		if txns[i], err = txnJSON(b, i); err != nil {
+			return nil, err
+		}
+	}
+	result["hash"] = b.hash
+	result["transactions"] = txns
+	result["uncles"] = []common.Hash{}
+	result["size"] = "0x0"
+	result["totalDifficulty"] = "0x0"
+	return result, nil
+}
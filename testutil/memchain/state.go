@@ -0,0 +1,102 @@
+package memchain
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+type account struct {
+	balance *big.Int
+	nonce   uint64
+	code    []byte
+	storage map[common.Hash]common.Hash
+}
+
+func (a *account) copy() *account {
+	copied := &account{
+		balance: new(big.Int).Set(a.balance),
+		nonce:   a.nonce,
+		code:    a.code,
+		storage: make(map[common.Hash]common.Hash, len(a.storage)),
+	}
+	for key, value := range a.storage {
+		copied.storage[key] = value
+	}
+	return copied
+}
+
+// state is the state of the accounts, and the logs emitted, as of a call. A call changes a
+// child of the state of its caller, which is committed to it if the call succeeds, and
+// discarded otherwise.
+type state struct {
+	parent   *state
+	accounts map[common.Address]*account
+	logs     []*types.Log
+
+	// owned are the accounts which s may change in place, ie. not shared with a snapshot
+	owned map[common.Address]bool
+}
+
+func newState() *state {
+	return &state{accounts: map[common.Address]*account{}, owned: map[common.Address]bool{}}
+}
+
+func (s *state) child() *state {
+	return &state{parent: s, accounts: map[common.Address]*account{}, owned: map[common.Address]bool{}}
+}
+
+// snapshot returns a copy of s, which shares the accounts of s until either changes them.
+func (s *state) snapshot() *state {
+	copied := newState()
+	for addr, a := range s.accounts {
+		copied.accounts[addr] = a
+	}
+	s.owned = map[common.Address]bool{}
+	return copied
+}
+
+// get returns the account of addr, which must not be changed.
+func (s *state) get(addr common.Address) *account {
+	for st := s; st != nil; st = st.parent {
+		if a, ok := st.accounts[addr]; ok {
+			return a
+		}
+	}
+	return &account{balance: new(big.Int)}
+}
+
+// mutable returns the account of addr, to be changed in s.
+func (s *state) mutable(addr common.Address) *account {
+	if a, ok := s.accounts[addr]; ok && s.owned[addr] {
+		return a
+	}
+	a := s.get(addr).copy()
+	s.accounts[addr] = a
+	s.owned[addr] = true
+	return a
+}
+
+func (s *state) transfer(from, to common.Address, amount *big.Int) error {
+	if amount == nil || amount.Sign() == 0 {
+		return nil
+	}
+	sender := s.mutable(from)
+	if sender.balance.Cmp(amount) < 0 {
+		return fmt.Errorf("memchain: insufficient balance of %v to transfer %v", from.Hex(), amount)
+	}
+	sender.balance.Sub(sender.balance, amount)
+	recipient := s.mutable(to)
+	recipient.balance.Add(recipient.balance, amount)
+	return nil
+}
+
+func (s *state) commit() {
+	for addr, a := range s.accounts {
+		s.parent.accounts[addr] = a
+		s.parent.owned[addr] = true
+	}
+	s.parent.logs = append(s.parent.logs, s.logs...)
+}
@@ -0,0 +1,266 @@
+package testutil
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+)
+
+const (
+	// memChainWalletCallGas is the gas used by the wallet for each of the calls of a bundle.
+	memChainWalletCallGas = 5000
+
+	memChainWalletExecError = "MainModule#execute: "
+)
+
+var (
+	executeMethod          = contracts.WalletMainModule.ABI.Methods["execute"]
+	selfExecuteMethod      = contracts.WalletMainModule.ABI.Methods["selfExecute"]
+	readNonceMethod        = contracts.WalletMainModule.ABI.Methods["readNonce"]
+	requireNonExpired      = contracts.WalletUtils.ABI.Methods["requireNonExpired"]
+	requireMinNonce        = contracts.WalletUtils.ABI.Methods["requireMinNonce"]
+	isValidSignatureMethod = crypto.Keccak256([]byte("isValidSignature(bytes32,bytes)"))[:4]
+	isValidSignatureMagic  = common.FromHex("0x1626ba7e")
+	nonceStorageSlotPrefix = crypto.Keccak256Hash([]byte("org.arcadeum.module.calls.nonce"))
+
+	// walletProxyCode is the runtime code of wallets, returned by their creation code.
+	walletProxyCode = common.FromHex(sequence.WalletContractBytecode)[14:]
+)
+
+// DeployMemChainWallet deploys wallet on chain, an in-memory chain, as MemChainWalletContract,
+// so the bundles it signs are executed there, ie. when relayed by a relayer.LocalRelayer
+// connected to the provider of chain.
+func DeployMemChainWallet(chain *memchain.Chain, wallet *sequence.Wallet) {
+	chain.Deploy(wallet.Address(), MemChainWalletContract(chain.ChainID(), wallet.GetWalletContext()))
+	chain.SetCode(wallet.Address(), walletProxyCode)
+}
+
+// MemChainWalletContract is a Go implementation of the main module of Sequence wallets with
+// walletContext on the chain of chainID, for memchain. It implements execute, selfExecute,
+// readNonce and isValidSignature(bytes32,bytes), and accepts the signatures of the config the
+// wallet was created with, emitting the NonceChange, TxExecuted and TxFailed events of the
+// main module.
+func MemChainWalletContract(chainID *big.Int, walletContext sequence.WalletContext) memchain.Contract {
+	w := &memChainWallet{chainID: new(big.Int).Set(chainID), context: walletContext}
+	return memchain.ContractFunc(w.call)
+}
+
+type memChainWallet struct {
+	chainID *big.Int
+	context sequence.WalletContext
+}
+
+func (w *memChainWallet) call(env *memchain.Env, input []byte) ([]byte, error) {
+	if len(input) < 4 {
+		// receive
+		return nil, nil
+	}
+	switch selector := input[:4]; {
+	case string(selector) == string(executeMethod.ID):
+		return nil, w.execute(env, input)
+	case string(selector) == string(selfExecuteMethod.ID):
+		return nil, w.selfExecute(env, input)
+	case string(selector) == string(readNonceMethod.ID):
+		values, err := readNonceMethod.Inputs.Unpack(input[4:])
+		if err != nil {
+			return nil, memchain.Revert(err.Error())
+		}
+		space, _ := values[0].(*big.Int)
+		return env.Load(nonceSlot(space)).Bytes(), nil
+	case string(selector) == string(isValidSignatureMethod):
+		var digest [32]byte
+		var signature []byte
+		if err := ethcoder.AbiDecoder([]string{"bytes32", "bytes"}, input[4:], []interface{}{&digest, &signature}); err != nil {
+			return nil, memchain.Revert(err.Error())
+		}
+		if err := w.validateSignature(env.Address, digest, signature); err != nil {
+			return make([]byte, 32), nil
+		}
+		return common.RightPadBytes(isValidSignatureMagic, 32), nil
+	default:
+		return nil, memchain.Revert("unknown method")
+	}
+}
+
+func (w *memChainWallet) execute(env *memchain.Env, input []byte) error {
+	txns, nonce, signature, err := sequence.DecodeExecdata(input)
+	if err != nil {
+		return memchain.Revert(memChainWalletExecError + err.Error())
+	}
+
+	digest, err := sequence.ComputeWalletExecDigest(nonce, txns)
+	if err != nil {
+		return memchain.Revert(memChainWalletExecError + err.Error())
+	}
+	if err := w.validateSignature(env.Address, digest, signature); err != nil {
+		return memchain.Revert(memChainWalletExecError + err.Error())
+	}
+
+	space, n := sequence.DecodeNonce(nonce)
+	slot := nonceSlot(space)
+	if current := env.Load(slot).Big(); current.Cmp(n) != 0 {
+		return memchain.Revert(memChainWalletExecError + "INVALID_NONCE")
+	}
+	next := new(big.Int).Add(n, big.NewInt(1))
+	env.Store(slot, common.BigToHash(next))
+	nonceChange, _ := ethcoder.AbiCoder([]string{"uint256", "uint256"}, []interface{}{space, next})
+	env.Log([]common.Hash{sequence.NonceChangeEventSig}, nonceChange)
+
+	_, metaTxnHash, err := sequence.ComputeMetaTxnIDFromDigest(w.chainID, env.Address, digest)
+	if err != nil {
+		return memchain.Revert(memChainWalletExecError + err.Error())
+	}
+	return w.run(env, metaTxnHash, txns)
+}
+
+// selfExecute executes the bundle of a call of the wallet to itself, without a nonce nor a
+// signature.
+func (w *memChainWallet) selfExecute(env *memchain.Env, input []byte) error {
+	if env.Caller != env.Address {
+		return memchain.Revert("ModuleSelfAuth#onlySelf: NOT_AUTHORIZED")
+	}
+	txns, _, _, err := sequence.DecodeExecdata(input)
+	if err != nil {
+		return memchain.Revert(err.Error())
+	}
+	_, metaTxnHash, err := sequence.ComputeMetaTxnID(w.chainID, env.Address, txns, nil, sequence.MetaTxnSelfExec)
+	if err != nil {
+		return memchain.Revert(err.Error())
+	}
+	return w.run(env, metaTxnHash, txns)
+}
+
+// run executes the calls of the bundle of metaTxnHash, emitting their TxExecuted and TxFailed
+// events.
+func (w *memChainWallet) run(env *memchain.Env, metaTxnHash common.Hash, txns sequence.Transactions) error {
+	for _, txn := range txns {
+		if txn.DelegateCall {
+			return memchain.Revert(memChainWalletExecError + "delegate calls are not supported")
+		}
+		// nested bundles are decoded with the bundle, and are called with their execdata
+		data := txn.Data
+		if txn.IsBundle() {
+			var err error
+			if data, err = txn.Execdata(); err != nil {
+				return memchain.Revert(err.Error())
+			}
+		}
+		env.UseGas(memChainWalletCallGas)
+		_, err := env.Call(txn.To, txn.Value, data)
+		if err == nil {
+			env.Log(nil, metaTxnHash.Bytes())
+			continue
+		}
+		if txn.RevertOnError {
+			return err
+		}
+		var revert *memchain.RevertError
+		var reason []byte
+		if errors.As(err, &revert) {
+			reason = revert.Data
+		}
+		failed, _ := ethcoder.AbiCoder([]string{"bytes32", "bytes"}, []interface{}{metaTxnHash, reason})
+		env.Log([]common.Hash{sequence.TxFailedEventSig}, failed)
+	}
+	return nil
+}
+
+// validateSignature validates signature of digest against the image hash the wallet at
+// address was created with, ie. without config updates.
+func (w *memChainWallet) validateSignature(address common.Address, digest common.Hash, signature []byte) error {
+	subDigest, err := sequence.SubDigest(w.chainID, address, digest)
+	if err != nil {
+		return err
+	}
+	decoded, err := sequence.DecodeSignature(signature)
+	if err != nil {
+		return err
+	}
+	if err := decoded.Recover(subDigest, nil); err != nil {
+		return err
+	}
+	weight, err := decoded.Weight()
+	if err != nil {
+		return err
+	}
+	if weight < decoded.Threshold {
+		return fmt.Errorf("INVALID_SIGNATURE: weight %d below threshold %d", weight, decoded.Threshold)
+	}
+	imageHash, err := decoded.ImageHash()
+	if err != nil {
+		return err
+	}
+	signer, err := sequence.AddressFromImageHash(common.Bytes2Hex(imageHash[:]), w.context)
+	if err != nil {
+		return err
+	}
+	if signer != address {
+		return fmt.Errorf("INVALID_SIGNATURE")
+	}
+	return nil
+}
+
+// DeployMemChainWalletUtils deploys MemChainWalletUtilsContract on chain, an in-memory chain,
+// at the utils address of walletContext, so the assertions of the bundles of wallets are
+// executed there.
+func DeployMemChainWalletUtils(chain *memchain.Chain, walletContext sequence.WalletContext) {
+	chain.Deploy(walletContext.UtilsAddress, MemChainWalletUtilsContract())
+}
+
+// MemChainWalletUtilsContract is a Go implementation of the requireNonExpired and
+// requireMinNonce assertions of the wallet utils, for memchain.
+func MemChainWalletUtilsContract() memchain.Contract {
+	return memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		if len(input) < 4 {
+			return nil, memchain.Revert("unknown method")
+		}
+		switch selector := input[:4]; {
+		case string(selector) == string(requireNonExpired.ID):
+			values, err := requireNonExpired.Inputs.Unpack(input[4:])
+			if err != nil {
+				return nil, memchain.Revert(err.Error())
+			}
+			expiration, _ := values[0].(*big.Int)
+			if new(big.Int).SetUint64(env.Time).Cmp(expiration) >= 0 {
+				return nil, memchain.Revert("RequireUtils#requireNonExpired: EXPIRED")
+			}
+			return nil, nil
+		case string(selector) == string(requireMinNonce.ID):
+			values, err := requireMinNonce.Inputs.Unpack(input[4:])
+			if err != nil {
+				return nil, memchain.Revert(err.Error())
+			}
+			wallet, _ := values[0].(common.Address)
+			minNonce, _ := values[1].(*big.Int)
+			space, nonce := sequence.DecodeNonce(minNonce)
+			data, err := readNonceMethod.Inputs.Pack(space)
+			if err != nil {
+				return nil, memchain.Revert(err.Error())
+			}
+			current, err := env.Call(wallet, nil, append(append([]byte{}, readNonceMethod.ID...), data...))
+			if err != nil {
+				return nil, err
+			}
+			if new(big.Int).SetBytes(current).Cmp(nonce) < 0 {
+				return nil, memchain.Revert("RequireUtils#requireMinNonce: NONCE_BELOW_REQUIRED")
+			}
+			return nil, nil
+		default:
+			return nil, memchain.Revert("unknown method")
+		}
+	})
+}
+
+func nonceSlot(space *big.Int) common.Hash {
+	if space == nil {
+		space = new(big.Int)
+	}
+	return crypto.Keccak256Hash(nonceStorageSlotPrefix.Bytes(), common.BigToHash(space).Bytes())
+}
@@ -0,0 +1,103 @@
+package testutil
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+)
+
+const (
+	defaultDeployAccount  = 5
+	defaultRelayerAccount = 6
+
+	// firstParallelAccount is the first account index of the test wallets given to parallel
+	// tests, past those used by the test chain and the tests of this repo.
+	firstParallelAccount = 1000
+
+	// firstParallelSeed is the first seed given to parallel tests, past the seeds of the
+	// dummy wallets of the tests of this repo.
+	firstParallelSeed = 1 << 32
+)
+
+// parallelism is the state shared by a test chain and its parallel views.
+type parallelism struct {
+	mu          sync.Mutex
+	nextSeed    uint64
+	nextAccount uint32
+
+	// tests holds a read lock for each parallel test running, and a write lock for each
+	// snapshot taken, so snapshots are never reverted under a parallel test.
+	tests sync.RWMutex
+}
+
+// Parallel marks t as a parallel test with t.Parallel, and returns a view of the test chain
+// which t can use concurrently with the other parallel tests. The view shares the node, monitor
+// and receipts listener of the test chain, but deploys contracts and relays transactions with
+// test wallets of its own, so the nonces of their accounts are only managed by t.
+//
+// Parallel tests must not share dummy wallets either, see Seed.
+func (c *TestChain) Parallel(t *testing.T) *TestChain {
+	t.Helper()
+	t.Parallel()
+
+	c.parallel.tests.RLock()
+	t.Cleanup(c.parallel.tests.RUnlock)
+
+	c.parallel.mu.Lock()
+	account := c.parallel.nextAccount
+	c.parallel.nextAccount += 2
+	c.parallel.mu.Unlock()
+
+	view := *c
+	view.deployAccount = account
+	view.relayerAccount = account + 1
+	view.RpcRelayer = nil
+	view.isParallel = true
+	return &view
+}
+
+// Seed returns a seed for DummySequenceWallet which no other caller of Seed on the test chain,
+// or on its parallel views, is given.
+func (c *TestChain) Seed() uint64 {
+	c.parallel.mu.Lock()
+	defer c.parallel.mu.Unlock()
+	seed := c.parallel.nextSeed
+	c.parallel.nextSeed++
+	return seed
+}
+
+// Snapshot takes a snapshot of the test chain, which is reverted to once t and its subtests
+// complete. It needs a node serving evm_snapshot, such as anvil or hardhat.
+//
+// Snapshot waits for the parallel tests running to complete, and they wait for the snapshot
+// to be reverted to, so it can't be taken by a parallel test.
+func (c *TestChain) Snapshot(t *testing.T) {
+	t.Helper()
+
+	if c.isParallel {
+		t.Fatal("testutil: snapshot can't be taken by a parallel test")
+	}
+
+	c.parallel.tests.Lock()
+
+	var id string
+	err := c.Provider.Do(context.Background(), ethrpc.NewCallBuilder[string]("evm_snapshot", nil).Into(&id))
+	if err != nil {
+		c.parallel.tests.Unlock()
+		t.Fatalf("testutil: unable to take snapshot: %v", err)
+	}
+
+	t.Cleanup(func() {
+		defer c.parallel.tests.Unlock()
+
+		var reverted bool
+		err := c.Provider.Do(context.Background(), ethrpc.NewCallBuilder[bool]("evm_revert", nil, id).Into(&reverted))
+		if err != nil {
+			t.Errorf("testutil: unable to revert to snapshot %s: %v", id, err)
+		} else if !reverted {
+			t.Errorf("testutil: snapshot %s was not reverted to", id)
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcontract"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/testutil/assertions"
+)
+
+// scenarioGasLimit is the gas limit of the calls of a scenario, so the calls which are
+// expected to revert are sent instead of failing gas estimation.
+const scenarioGasLimit = 500_000
+
+// Scenario is an end-to-end test of a wallet on the test chain, declared as a list of steps
+// which are run in order as subtests by Run, until one fails:
+//
+//	testChain.Scenario().
+//		DeployWallet(1).
+//		Deploy("callmock", "WALLET_CALL_RECV_MOCK").
+//		Call("callmock", "setRevertFlag", true).ExpectExecuted().
+//		Call("callmock", "testCall", big.NewInt(1), []byte{}).ExpectFail("").
+//		Run(t)
+type Scenario struct {
+	chain *TestChain
+	steps []scenarioStep
+}
+
+type scenarioStep struct {
+	name string
+	run  func(t *testing.T, state *ScenarioState)
+}
+
+// ScenarioState is the state of a scenario which is passed from step to step.
+type ScenarioState struct {
+	// Wallet is the wallet which sends the bundles of the scenario.
+	Wallet *sequence.Wallet
+
+	// Contracts are the contracts deployed by the scenario, by name.
+	Contracts map[string]*ethcontract.Contract
+
+	// MetaTxnID is the last bundle sent, and Receipt the receipt of the native transaction
+	// it was mined in.
+	MetaTxnID sequence.MetaTxnID
+	Receipt   *types.Receipt
+}
+
+// Scenario returns an empty scenario on the test chain.
+func (c *TestChain) Scenario() *Scenario {
+	return &Scenario{chain: c}
+}
+
+// Step adds a custom step to the scenario.
+func (s *Scenario) Step(name string, run func(t *testing.T, state *ScenarioState)) *Scenario {
+	s.steps = append(s.steps, scenarioStep{name: name, run: run})
+	return s
+}
+
+// DeployWallet deploys the dummy wallet of seed, which sends the bundles of the next steps.
+func (s *Scenario) DeployWallet(seed uint64) *Scenario {
+	return s.Step(fmt.Sprintf("DeployWallet(%d)", seed), func(t *testing.T, state *ScenarioState) {
+		wallet, err := s.chain.DummySequenceWallet(seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		state.Wallet = wallet
+	})
+}
+
+// UseWallet sends the bundles of the next steps with wallet.
+func (s *Scenario) UseWallet(wallet *sequence.Wallet) *Scenario {
+	return s.Step(fmt.Sprintf("UseWallet(%v)", wallet.Address().Hex()), func(t *testing.T, state *ScenarioState) {
+		state.Wallet = wallet
+	})
+}
+
+// Deploy deploys a new instance of a contract of the Contracts registry, which the next steps
+// refer to by name.
+func (s *Scenario) Deploy(name string, contractName string, constructorArgs ...interface{}) *Scenario {
+	return s.Step(fmt.Sprintf("Deploy(%s)", name), func(t *testing.T, state *ScenarioState) {
+		contract, _ := s.chain.Deploy(t, contractName, constructorArgs...)
+		state.Contracts[name] = contract
+	})
+}
+
+// Send signs txns as a bundle of the wallet, relays it and waits for it to be mined.
+func (s *Scenario) Send(txns ...*sequence.Transaction) *Scenario {
+	return s.Step(fmt.Sprintf("Send(%d txns)", len(txns)), func(t *testing.T, state *ScenarioState) {
+		s.send(t, state, txns)
+	})
+}
+
+// Call sends a bundle calling method of the contract deployed as name.
+func (s *Scenario) Call(name string, method string, args ...interface{}) *Scenario {
+	return s.Step(fmt.Sprintf("Call(%s.%s)", name, method), func(t *testing.T, state *ScenarioState) {
+		contract := state.contract(t, name)
+		data, err := contract.Encode(method, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.send(t, state, []*sequence.Transaction{{
+			To:       contract.Address,
+			Data:     data,
+			GasLimit: big.NewInt(scenarioGasLimit),
+		}})
+	})
+}
+
+// ExpectExecuted expects the last bundle sent to be executed.
+func (s *Scenario) ExpectExecuted() *Scenario {
+	return s.Step("ExpectExecuted", func(t *testing.T, state *ScenarioState) {
+		assertions.AssertMetaTxnExecuted(t, s.chain.ReceiptsListener, state.metaTxnID(t))
+	})
+}
+
+// ExpectFail expects the last bundle sent to fail, with a reason containing reason if it is
+// not empty.
+func (s *Scenario) ExpectFail(reason string) *Scenario {
+	return s.Step(fmt.Sprintf("ExpectFail(%q)", reason), func(t *testing.T, state *ScenarioState) {
+		assertions.AssertMetaTxnFailed(t, s.chain.ReceiptsListener, state.metaTxnID(t), reason)
+	})
+}
+
+// ExpectEvent expects the last bundle sent to emit event of the contract deployed as name,
+// with args as the values of its first inputs.
+func (s *Scenario) ExpectEvent(name string, event string, args ...interface{}) *Scenario {
+	return s.Step(fmt.Sprintf("ExpectEvent(%s.%s)", name, event), func(t *testing.T, state *ScenarioState) {
+		state.metaTxnID(t)
+		assertions.AssertEventEmitted(t, state.Receipt, state.contract(t, name).ABI, event, args...)
+	})
+}
+
+// Run runs the steps of the scenario in order, each as a subtest of t, and stops at the first
+// one which fails.
+func (s *Scenario) Run(t *testing.T) {
+	t.Helper()
+
+	state := &ScenarioState{Contracts: map[string]*ethcontract.Contract{}}
+	for i, step := range s.steps {
+		ok := t.Run(fmt.Sprintf("%d:%s", i, step.name), func(t *testing.T) {
+			step.run(t, state)
+		})
+		if !ok {
+			return
+		}
+	}
+}
+
+func (s *Scenario) send(t *testing.T, state *ScenarioState, txns sequence.Transactions) {
+	if state.Wallet == nil {
+		t.Fatal("scenario has no wallet, see DeployWallet")
+	}
+
+	signed, err := state.Wallet.SignTransactions(context.Background(), txns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metaTxnID, _, waitReceipt, err := state.Wallet.SendTransactions(context.Background(), signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := waitReceipt(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state.MetaTxnID = metaTxnID
+	state.Receipt = receipt
+}
+
+func (state *ScenarioState) metaTxnID(t *testing.T) sequence.MetaTxnID {
+	if state.MetaTxnID == "" {
+		t.Fatal("scenario has not sent a bundle, see Send")
+	}
+	return state.MetaTxnID
+}
+
+func (state *ScenarioState) contract(t *testing.T, name string) *ethcontract.Contract {
+	contract, ok := state.Contracts[name]
+	if !ok {
+		t.Fatalf("scenario has no contract %s, see Deploy", name)
+	}
+	return contract
+}
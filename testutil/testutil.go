@@ -6,6 +6,7 @@ import (
 	"log"
 	"math/big"
 	"net/http"
+	"os"
 	"testing"
 	"time"
 
@@ -37,12 +38,23 @@ type TestChain struct {
 
 	RpcRelayer *relayer.RpcRelayer // helper to track RpcRelayer client
 
+	container *dockerContainer // docker node started for the test chain, if any
+
+	deployAccount  uint32       // account index of the test wallet deploying contracts
+	relayerAccount uint32       // account index of the test wallet relaying transactions
+	parallel       *parallelism // shared by the parallel views of the test chain
+	isParallel     bool         // whether the test chain is the view of a parallel test
+
 	runCtx     context.Context
 	runCtxStop context.CancelFunc
 }
 
 type TestChainOptions struct {
 	NodeURL string
+
+	// Docker, if set, is the node started in Docker for the test chain, in place of the node
+	// at NodeURL. It is removed by Disconnect.
+	Docker *DockerNode
 }
 
 var DefaultTestChainOptions = TestChainOptions{
@@ -51,13 +63,41 @@ var DefaultTestChainOptions = TestChainOptions{
 
 func NewTestChain(opts ...TestChainOptions) (*TestChain, error) {
 	var err error
-	tc := &TestChain{}
+	tc := &TestChain{
+		deployAccount:  defaultDeployAccount,
+		relayerAccount: defaultRelayerAccount,
+		parallel:       &parallelism{nextSeed: firstParallelSeed, nextAccount: firstParallelAccount},
+	}
 
 	// set options
 	if len(opts) > 0 {
 		tc.options = opts[0]
 	} else {
 		tc.options = DefaultTestChainOptions
+
+		// TESTCHAIN_DOCKER=geth or TESTCHAIN_DOCKER=anvil starts the node in Docker
+		if name := os.Getenv("TESTCHAIN_DOCKER"); name != "" {
+			node, ok := DockerNodes[name]
+			if !ok {
+				return nil, fmt.Errorf("testutil: unknown docker node %q", name)
+			}
+			tc.options.Docker = &node
+		}
+	}
+
+	// docker node
+	if tc.options.Docker != nil {
+		tc.container, err = startDockerNode(context.Background(), *tc.options.Docker)
+		if err != nil {
+			return nil, err
+		}
+		tc.options.NodeURL = tc.container.nodeURL
+
+		defer func() {
+			if err != nil {
+				tc.container.stop()
+			}
+		}()
 	}
 
 	// provider
@@ -132,11 +172,17 @@ func (c *TestChain) Connect() error {
 }
 
 func (c *TestChain) Disconnect() {
-	if c.runCtxStop == nil {
-		return
+	if c.runCtxStop != nil {
+		c.runCtxStop()
+		c.runCtx = nil
+	}
+
+	if c.container != nil {
+		if err := c.container.stop(); err != nil {
+			log.Printf("testutil: unable to remove docker node: %v", err)
+		}
+		c.container = nil
 	}
-	c.runCtxStop()
-	c.runCtx = nil
 }
 
 func (c *TestChain) ChainID() *big.Int {
@@ -262,7 +308,7 @@ func (c *TestChain) MustFundAddress(addr common.Address) error {
 }
 
 func (c *TestChain) GetDeployWallet() *ethwallet.Wallet {
-	return c.MustWallet(5)
+	return c.MustWallet(c.deployAccount)
 }
 
 // GetDeployTransactor returns a account transactor typically used for deploying contracts
@@ -272,7 +318,7 @@ func (c *TestChain) GetDeployTransactor() (*bind.TransactOpts, error) {
 
 // GetRelayerWallet is the wallet dedicated EOA wallet to relaying transactions
 func (c *TestChain) GetRelayerWallet() *ethwallet.Wallet {
-	return c.MustWallet(6)
+	return c.MustWallet(c.relayerAccount)
 }
 
 func (c *TestChain) DeploySequenceContext() (sequence.WalletContext, error) {
@@ -339,11 +385,11 @@ func (c *TestChain) UniDeploy(t *testing.T, contractName string, contractInstanc
 	if err != nil {
 		t.Fatal(err)
 	}
-	contractAddress, err := ud.Deploy(context.Background(), artifact.ABI, artifact.Bin, contractInstanceNum, nil, 5000000, contractConstructorArgs...)
+	deployment, err := ud.SetGasLimit(5000000).DeployArtifact(context.Background(), artifact, deployer.InstanceSalt(contractInstanceNum), contractConstructorArgs...)
 	if err != nil {
 		t.Fatal(err)
 	}
-	return ethcontract.NewContractCaller(contractAddress, artifact.ABI, c.Provider)
+	return ethcontract.NewContractCaller(deployment.Address, artifact.ABI, c.Provider)
 }
 
 // Deploy will deploy a contract registered in `Contracts` registry using the standard deployment method. Each Deploy call
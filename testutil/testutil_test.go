@@ -68,3 +68,19 @@ func TestContractHelpers(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, uint64(143), result.Uint64())
 }
+
+func TestParallel(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		t.Run("", func(t *testing.T) {
+			chain := testChain.Parallel(t)
+
+			callmockContract, _ := chain.Deploy(t, "WALLET_CALL_RECV_MOCK")
+			calldata, err := callmockContract.Encode("testCall", big.NewInt(143), ethcoder.MustHexDecode("0x112233"))
+			assert.NoError(t, err)
+
+			wallet, err := chain.DummySequenceWallet(chain.Seed())
+			assert.NoError(t, err)
+			assert.NoError(t, testutil.SignAndSend(t, wallet, callmockContract.Address, calldata))
+		})
+	}
+}
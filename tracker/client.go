@@ -0,0 +1,116 @@
+package tracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+)
+
+const defaultClientTimeout = 30 * time.Second
+
+// Client is a client of a config tracker service, which serves the configs of wallets with
+// the webrpc methods ConfigTracker/ConfigOfWallet and ConfigTracker/ConfigOfImageHash.
+//
+// The configs of image hashes served by the service are verified by their image hash, but
+// the service is trusted with the latest config of a wallet, which can't be verified
+// without a chain. Use OnChain to only resolve configs proven on-chain.
+type Client struct {
+	url    string
+	client *http.Client
+}
+
+var _ ConfigTracker = &Client{}
+
+// NewClient returns a client of the config tracker service at url, ie.
+// "https://sessions.sequence.app".
+func NewClient(url string) *Client {
+	return &Client{
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{Timeout: defaultClientTimeout},
+	}
+}
+
+func (c *Client) SetHTTPClient(client *http.Client) *Client {
+	c.client = client
+	return c
+}
+
+type configResponse struct {
+	Config *sequence.WalletConfig `json:"config"`
+}
+
+func (c *Client) ConfigOfWallet(ctx context.Context, wallet common.Address) (*sequence.WalletConfig, error) {
+	var response configResponse
+	if err := c.call(ctx, "ConfigOfWallet", map[string]interface{}{"wallet": wallet}, &response); err != nil {
+		return nil, err
+	}
+	if response.Config == nil {
+		return nil, fmt.Errorf("%w: %v", ErrConfigNotFound, wallet.Hex())
+	}
+	return response.Config, nil
+}
+
+func (c *Client) ConfigOfImageHash(ctx context.Context, imageHash common.Hash) (*sequence.WalletConfig, error) {
+	var response configResponse
+	if err := c.call(ctx, "ConfigOfImageHash", map[string]interface{}{"imageHash": imageHash}, &response); err != nil {
+		return nil, err
+	}
+	if response.Config == nil {
+		return nil, fmt.Errorf("%w: %v", ErrConfigNotFound, imageHash.Hex())
+	}
+
+	configImageHash, err := sequence.ImageHashOfWalletConfigBytes32(*response.Config)
+	if err != nil {
+		return nil, fmt.Errorf("tracker: invalid config of %v: %w", imageHash.Hex(), err)
+	}
+	if common.Hash(configImageHash) != imageHash {
+		return nil, fmt.Errorf("tracker: config of %v has image hash %v", imageHash.Hex(), common.Hash(configImageHash).Hex())
+	}
+	return response.Config, nil
+}
+
+// call calls a method of the service, and returns ErrConfigNotFound if it responds with 404.
+func (c *Client) call(ctx context.Context, method string, request interface{}, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/rpc/ConfigTracker/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("tracker: %s request failed: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrConfigNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		var rpcErr struct {
+			Msg string `json:"msg"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+		if json.Unmarshal(data, &rpcErr) == nil && rpcErr.Msg != "" {
+			return fmt.Errorf("tracker: %s request failed with status %d: %s", method, resp.StatusCode, rpcErr.Msg)
+		}
+		return fmt.Errorf("tracker: %s request failed with status %d", method, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("tracker: invalid %s response: %w", method, err)
+	}
+	return nil
+}
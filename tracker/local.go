@@ -0,0 +1,131 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+)
+
+// Local is a ConfigTracker of the configs it observes, recorded in a Store: the configs of
+// wallets which sign, of RequiredConfig events of the wallet utils contract, of signatures it
+// recovers, and configs imported by hand. A Local tracker can be shared by the components
+// which resolve configs, so a config observed by one is known to all.
+type Local struct {
+	store Store
+}
+
+var _ ConfigTracker = &Local{}
+
+// NewLocal returns a tracker recording configs in store, or in a MemoryStore if nil.
+func NewLocal(store Store) *Local {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Local{store: store}
+}
+
+func (l *Local) ConfigOfWallet(ctx context.Context, wallet common.Address) (*sequence.WalletConfig, error) {
+	record, err := l.store.Wallet(ctx, wallet)
+	if err != nil {
+		return nil, err
+	}
+	return l.store.Config(ctx, record.ImageHash)
+}
+
+func (l *Local) ConfigOfImageHash(ctx context.Context, imageHash common.Hash) (*sequence.WalletConfig, error) {
+	return l.store.Config(ctx, imageHash)
+}
+
+// ImportConfig records config by its image hash, which it returns.
+func (l *Local) ImportConfig(ctx context.Context, config sequence.WalletConfig) (common.Hash, error) {
+	imageHash, err := sequence.ImageHashOfWalletConfigBytes32(config)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("tracker: %w", err)
+	}
+	if err := l.store.SaveConfig(ctx, imageHash, config); err != nil {
+		return common.Hash{}, err
+	}
+	return imageHash, nil
+}
+
+// ImportWalletConfig records config as the latest config of wallet.
+func (l *Local) ImportWalletConfig(ctx context.Context, wallet common.Address, config sequence.WalletConfig) error {
+	imageHash, err := l.ImportConfig(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	// keep the block of a config observed on-chain, so older events don't replace this one
+	record := WalletRecord{ImageHash: imageHash}
+	if previous, err := l.store.Wallet(ctx, wallet); err == nil {
+		record.BlockNumber = previous.BlockNumber
+	} else if !errors.Is(err, ErrConfigNotFound) {
+		return err
+	}
+	return l.store.SaveWallet(ctx, wallet, record)
+}
+
+// ImportWallet records the current config of w as its latest config, ie. when w signs.
+func (l *Local) ImportWallet(ctx context.Context, w *sequence.Wallet) error {
+	return l.ImportWalletConfig(ctx, w.Address(), w.GetWalletConfig())
+}
+
+// ImportPublishedConfig records a config published on-chain, as the latest config of its
+// wallet unless a config published in a later block was recorded already.
+func (l *Local) ImportPublishedConfig(ctx context.Context, published *sequence.PublishedConfig) error {
+	if err := l.store.SaveConfig(ctx, published.ImageHash, published.Config); err != nil {
+		return err
+	}
+
+	previous, err := l.store.Wallet(ctx, published.Wallet)
+	if err != nil && !errors.Is(err, ErrConfigNotFound) {
+		return err
+	}
+	if previous != nil && previous.BlockNumber > published.BlockNumber {
+		return nil
+	}
+	return l.store.SaveWallet(ctx, published.Wallet, WalletRecord{ImageHash: published.ImageHash, BlockNumber: published.BlockNumber})
+}
+
+// ImportLogs records the configs of the RequiredConfig events in logs, and ignores other
+// logs. The logs must be of the wallet utils contract of the wallet context of the tracker,
+// as the events of other contracts are not authenticated.
+func (l *Local) ImportLogs(ctx context.Context, logs []types.Log) error {
+	for i := range logs {
+		if len(logs[i].Topics) == 0 || logs[i].Topics[0] != sequence.RequiredConfigEventSig {
+			continue
+		}
+		published, err := sequence.DecodeRequiredConfigEvent(&logs[i])
+		if err != nil {
+			return fmt.Errorf("tracker: %w", err)
+		}
+		if err := l.ImportPublishedConfig(ctx, published); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecoverWalletConfig recovers the config of wallet from its signature seqSig of digest, see
+// sequence.RecoverWalletConfigFromDigest, and records it by its image hash. It doesn't record
+// it as the latest config of wallet, as a signature may be of an earlier config.
+func (l *Local) RecoverWalletConfig(ctx context.Context, walletContext sequence.WalletContext, chainID *big.Int, wallet common.Address, digest common.Hash, seqSig []byte, provider *ethrpc.Provider) (sequence.WalletConfig, error) {
+	subDigest, err := sequence.SubDigest(chainID, wallet, digest)
+	if err != nil {
+		return sequence.WalletConfig{}, err
+	}
+	config, err := sequence.RecoverWalletConfigFromDigest(subDigest, seqSig, walletContext, chainID, provider)
+	if err != nil {
+		return sequence.WalletConfig{}, err
+	}
+	if _, err := l.ImportConfig(ctx, config); err != nil {
+		return sequence.WalletConfig{}, err
+	}
+	return config, nil
+}
@@ -0,0 +1,77 @@
+package tracker_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/tracker"
+	"github.com/goware/cachestore/memlru"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocal(t *testing.T) {
+	cache, err := memlru.NewWithSize[[]byte](100)
+	assert.NoError(t, err)
+
+	stores := map[string]tracker.Store{
+		"memory": tracker.NewMemoryStore(),
+		"cache":  tracker.NewCacheStore(cache),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			local := tracker.NewLocal(store)
+
+			owner, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+			assert.NoError(t, err)
+			wallet, err := sequence.NewWalletSingleOwner(owner)
+			assert.NoError(t, err)
+			imageHash, err := wallet.ImageHash()
+			assert.NoError(t, err)
+
+			_, err = local.ConfigOfWallet(ctx, wallet.Address())
+			assert.True(t, errors.Is(err, tracker.ErrConfigNotFound))
+
+			// observed when signing
+			assert.NoError(t, local.ImportWallet(ctx, wallet))
+			config, err := local.ConfigOfWallet(ctx, wallet.Address())
+			assert.NoError(t, err)
+			assert.Equal(t, wallet.GetWalletConfig(), *config)
+			config, err = local.ConfigOfImageHash(ctx, imageHash)
+			assert.NoError(t, err)
+			assert.Equal(t, wallet.GetWalletConfig(), *config)
+
+			// published on-chain, in order or not
+			updated := sequence.WalletConfig{Threshold: 2, Signers: sequence.WalletConfigSigners{{Weight: 2, Address: common.HexToAddress("0x02")}}}
+			updatedImageHash, err := sequence.ImageHashOfWalletConfigBytes32(updated)
+			assert.NoError(t, err)
+			assert.NoError(t, local.ImportPublishedConfig(ctx, &sequence.PublishedConfig{Wallet: wallet.Address(), ImageHash: updatedImageHash, Config: updated, BlockNumber: 20}))
+			assert.NoError(t, local.ImportPublishedConfig(ctx, &sequence.PublishedConfig{Wallet: wallet.Address(), ImageHash: imageHash, Config: wallet.GetWalletConfig(), BlockNumber: 10}))
+			config, err = local.ConfigOfWallet(ctx, wallet.Address())
+			assert.NoError(t, err)
+			assert.Equal(t, updated, *config)
+
+			// recovered from a signature
+			other, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(2))
+			assert.NoError(t, err)
+			otherWallet, err := sequence.NewWalletSingleOwner(other)
+			assert.NoError(t, err)
+			digest := sequence.MessageDigest([]byte("hello"))
+			sig, _, err := otherWallet.SignDigest(digest, big.NewInt(1))
+			assert.NoError(t, err)
+			recovered, err := local.RecoverWalletConfig(ctx, otherWallet.GetWalletContext(), big.NewInt(1), otherWallet.Address(), digest, sig, nil)
+			assert.NoError(t, err)
+			otherImageHash, err := otherWallet.ImageHash()
+			assert.NoError(t, err)
+			config, err = local.ConfigOfImageHash(ctx, otherImageHash)
+			assert.NoError(t, err)
+			assert.Equal(t, recovered, *config)
+		})
+	}
+}
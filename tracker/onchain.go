@@ -0,0 +1,94 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/0xsequence/go-sequence/contracts/gen/walletutils"
+)
+
+// OnChain is a ConfigTracker which resolves the configs published on-chain with the wallet
+// utils contract of a wallet context, see sequence.Wallet.PublishConfig. The config of a
+// wallet is checked against the image hash the wallet currently has on-chain, so a config
+// which was replaced since it was published is not returned.
+type OnChain struct {
+	provider      *ethrpc.Provider
+	walletContext sequence.WalletContext
+}
+
+var _ ConfigTracker = &OnChain{}
+
+// NewOnChain returns a tracker of the configs published on the chain of provider.
+func NewOnChain(provider *ethrpc.Provider, walletContext sequence.WalletContext) *OnChain {
+	return &OnChain{provider: provider, walletContext: walletContext}
+}
+
+func (t *OnChain) ConfigOfWallet(ctx context.Context, wallet common.Address) (*sequence.WalletConfig, error) {
+	// a wallet which updated its config has its image hash in storage
+	imageHash, err := sequence.ImageHashOfWallet(ctx, t.provider, wallet)
+	if err != nil {
+		return nil, err
+	}
+	if imageHash != (common.Hash{}) {
+		return t.ConfigOfImageHash(ctx, imageHash)
+	}
+
+	// otherwise it still has its counterfactual config
+	published, err := sequence.LatestPublishedConfig(ctx, t.provider, t.walletContext, wallet)
+	if err != nil {
+		return nil, fmt.Errorf("tracker: %w", err)
+	}
+	if published == nil {
+		return nil, fmt.Errorf("%w: %v", ErrConfigNotFound, wallet.Hex())
+	}
+	address, err := sequence.AddressFromImageHash(published.ImageHash.Hex(), t.walletContext)
+	if err != nil {
+		return nil, err
+	}
+	if address != wallet {
+		return nil, fmt.Errorf("%w: published config of %v is not its current config", ErrConfigNotFound, wallet.Hex())
+	}
+	return &published.Config, nil
+}
+
+func (t *OnChain) ConfigOfImageHash(ctx context.Context, imageHash common.Hash) (*sequence.WalletConfig, error) {
+	data, err := walletutils.WalletUtilsCalldata.LastImageHashUpdate(imageHash)
+	if err != nil {
+		return nil, err
+	}
+	output, err := t.provider.CallContract(ctx, ethereum.CallMsg{To: &t.walletContext.UtilsAddress, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("tracker: %w", err)
+	}
+	var block *big.Int
+	if err := contracts.WalletUtils.ABI.UnpackIntoInterface(&block, "lastImageHashUpdate", output); err != nil {
+		return nil, fmt.Errorf("tracker: %w", err)
+	}
+	if block.Sign() == 0 {
+		return nil, fmt.Errorf("%w: %v", ErrConfigNotFound, imageHash.Hex())
+	}
+
+	logs, err := t.provider.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: block,
+		ToBlock:   block,
+		Addresses: []common.Address{t.walletContext.UtilsAddress},
+		Topics:    [][]common.Hash{{sequence.RequiredConfigEventSig}, nil, {imageHash}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("tracker: %w", err)
+	}
+	if len(logs) == 0 {
+		return nil, fmt.Errorf("tracker: no config of %v in block %v", imageHash.Hex(), block)
+	}
+	published, err := sequence.DecodeRequiredConfigEvent(&logs[len(logs)-1])
+	if err != nil {
+		return nil, fmt.Errorf("tracker: %w", err)
+	}
+	return &published.Config, nil
+}
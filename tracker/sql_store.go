@@ -0,0 +1,144 @@
+package tracker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+)
+
+// SQLStore is a Store in the tables sequence_configs and sequence_wallets of a SQL database,
+// which CreateTables creates. It only uses portable SQL, and the driver of the database is
+// left to the caller, ie. sqlite, mysql or postgres.
+type SQLStore struct {
+	db     *sql.DB
+	dollar bool
+}
+
+var _ Store = &SQLStore{}
+
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: db}
+}
+
+// UseDollarPlaceholders binds the arguments of queries to $1, $2... as postgres does,
+// instead of to ?.
+func (s *SQLStore) UseDollarPlaceholders() *SQLStore {
+	s.dollar = true
+	return s
+}
+
+// CreateTables creates the tables of the store, if they don't exist.
+func (s *SQLStore) CreateTables(ctx context.Context) error {
+	for _, query := range []string{
+		`CREATE TABLE IF NOT EXISTS sequence_configs (image_hash VARCHAR(66) PRIMARY KEY, config TEXT NOT NULL)`,
+		`CREATE TABLE IF NOT EXISTS sequence_wallets (address VARCHAR(42) PRIMARY KEY, image_hash VARCHAR(66) NOT NULL, block_number BIGINT NOT NULL)`,
+	} {
+		if _, err := s.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("tracker: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLStore) Config(ctx context.Context, imageHash common.Hash) (*sequence.WalletConfig, error) {
+	var data string
+	err := s.db.QueryRowContext(ctx, s.bind(`SELECT config FROM sequence_configs WHERE image_hash = ?`), imageHash.Hex()).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %v", ErrConfigNotFound, imageHash.Hex())
+	} else if err != nil {
+		return nil, fmt.Errorf("tracker: %w", err)
+	}
+
+	var config sequence.WalletConfig
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		return nil, fmt.Errorf("tracker: invalid config of %v: %w", imageHash.Hex(), err)
+	}
+	return &config, nil
+}
+
+func (s *SQLStore) SaveConfig(ctx context.Context, imageHash common.Hash, config sequence.WalletConfig) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return s.upsert(ctx,
+		`SELECT 1 FROM sequence_configs WHERE image_hash = ?`, imageHash.Hex(),
+		`UPDATE sequence_configs SET config = ? WHERE image_hash = ?`,
+		`INSERT INTO sequence_configs (config, image_hash) VALUES (?, ?)`,
+		string(data), imageHash.Hex(),
+	)
+}
+
+func (s *SQLStore) Wallet(ctx context.Context, wallet common.Address) (*WalletRecord, error) {
+	var imageHash string
+	var record WalletRecord
+	err := s.db.QueryRowContext(ctx, s.bind(`SELECT image_hash, block_number FROM sequence_wallets WHERE address = ?`), wallet.Hex()).Scan(&imageHash, &record.BlockNumber)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %v", ErrConfigNotFound, wallet.Hex())
+	} else if err != nil {
+		return nil, fmt.Errorf("tracker: %w", err)
+	}
+	record.ImageHash = common.HexToHash(imageHash)
+	return &record, nil
+}
+
+func (s *SQLStore) SaveWallet(ctx context.Context, wallet common.Address, record WalletRecord) error {
+	return s.upsert(ctx,
+		`SELECT 1 FROM sequence_wallets WHERE address = ?`, wallet.Hex(),
+		`UPDATE sequence_wallets SET image_hash = ?, block_number = ? WHERE address = ?`,
+		`INSERT INTO sequence_wallets (image_hash, block_number, address) VALUES (?, ?, ?)`,
+		record.ImageHash.Hex(), record.BlockNumber, wallet.Hex(),
+	)
+}
+
+// upsert updates a row, or inserts it if the select query of its key finds none, as the
+// syntax of upserts isn't portable across databases. The update and insert queries take the
+// same arguments.
+func (s *SQLStore) upsert(ctx context.Context, exists string, key interface{}, update, insert string, args ...interface{}) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("tracker: %w", err)
+	}
+	defer tx.Rollback()
+
+	var found int
+	query := update
+	err = tx.QueryRowContext(ctx, s.bind(exists), key).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		query = insert
+	} else if err != nil {
+		return fmt.Errorf("tracker: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, s.bind(query), args...); err != nil {
+		return fmt.Errorf("tracker: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("tracker: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) bind(query string) string {
+	if !s.dollar {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+		} else {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
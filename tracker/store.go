@@ -0,0 +1,156 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/goware/cachestore"
+)
+
+// Store persists the configs recorded by a Local tracker. Its methods return
+// ErrConfigNotFound for records which aren't stored.
+//
+// MemoryStore, CacheStore and SQLStore are provided, and other databases, ie. a bolt file,
+// plug in by implementing Store.
+type Store interface {
+	// Config returns the config of imageHash.
+	Config(ctx context.Context, imageHash common.Hash) (*sequence.WalletConfig, error)
+
+	// SaveConfig stores the config of imageHash.
+	SaveConfig(ctx context.Context, imageHash common.Hash, config sequence.WalletConfig) error
+
+	// Wallet returns the record of the latest config of wallet.
+	Wallet(ctx context.Context, wallet common.Address) (*WalletRecord, error)
+
+	// SaveWallet stores the record of the latest config of wallet.
+	SaveWallet(ctx context.Context, wallet common.Address, record WalletRecord) error
+}
+
+// WalletRecord is the latest config of a wallet recorded by a Local tracker.
+type WalletRecord struct {
+	ImageHash common.Hash `json:"imageHash"`
+
+	// BlockNumber is the block the config was published in, or zero if it wasn't observed
+	// on-chain.
+	BlockNumber uint64 `json:"blockNumber"`
+}
+
+// MemoryStore is a Store in memory.
+type MemoryStore struct {
+	configs map[common.Hash]sequence.WalletConfig
+	wallets map[common.Address]WalletRecord
+	mu      sync.RWMutex
+}
+
+var _ Store = &MemoryStore{}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		configs: map[common.Hash]sequence.WalletConfig{},
+		wallets: map[common.Address]WalletRecord{},
+	}
+}
+
+func (s *MemoryStore) Config(ctx context.Context, imageHash common.Hash) (*sequence.WalletConfig, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	config, ok := s.configs[imageHash]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrConfigNotFound, imageHash.Hex())
+	}
+	config = config.Clone()
+	return &config, nil
+}
+
+func (s *MemoryStore) SaveConfig(ctx context.Context, imageHash common.Hash, config sequence.WalletConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.configs[imageHash] = config.Clone()
+	return nil
+}
+
+func (s *MemoryStore) Wallet(ctx context.Context, wallet common.Address) (*WalletRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	record, ok := s.wallets[wallet]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrConfigNotFound, wallet.Hex())
+	}
+	return &record, nil
+}
+
+func (s *MemoryStore) SaveWallet(ctx context.Context, wallet common.Address, record WalletRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.wallets[wallet] = record
+	return nil
+}
+
+// CacheStore is a Store in a cachestore backend, ie. redis. The backend must not evict
+// records, or the configs they hold are forgotten.
+type CacheStore struct {
+	cache cachestore.Store[[]byte]
+}
+
+var _ Store = &CacheStore{}
+
+func NewCacheStore(cache cachestore.Store[[]byte]) *CacheStore {
+	return &CacheStore{cache: cache}
+}
+
+func (s *CacheStore) Config(ctx context.Context, imageHash common.Hash) (*sequence.WalletConfig, error) {
+	var config sequence.WalletConfig
+	if err := s.get(ctx, "config:"+imageHash.Hex(), &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (s *CacheStore) SaveConfig(ctx context.Context, imageHash common.Hash, config sequence.WalletConfig) error {
+	return s.set(ctx, "config:"+imageHash.Hex(), config)
+}
+
+func (s *CacheStore) Wallet(ctx context.Context, wallet common.Address) (*WalletRecord, error) {
+	var record WalletRecord
+	if err := s.get(ctx, "wallet:"+wallet.Hex(), &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (s *CacheStore) SaveWallet(ctx context.Context, wallet common.Address, record WalletRecord) error {
+	return s.set(ctx, "wallet:"+wallet.Hex(), record)
+}
+
+func (s *CacheStore) get(ctx context.Context, key string, value interface{}) error {
+	data, ok, err := s.cache.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("tracker: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("%w: %v", ErrConfigNotFound, key)
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("tracker: invalid record %v: %w", key, err)
+	}
+	return nil
+}
+
+func (s *CacheStore) set(ctx context.Context, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := s.cache.Set(ctx, key, data); err != nil {
+		return fmt.Errorf("tracker: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Package tracker resolves the configs of Sequence wallets, from their address or from an
+// image hash, so signatures can be validated with only the address of the wallet which
+// signed. Configs are resolved by a config tracker service (Client), from the configs
+// published on-chain with the wallet utils contract (OnChain), from the configs observed in
+// process (Local), or from any of several trackers (Fallback).
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+)
+
+// ErrConfigNotFound is returned by a ConfigTracker which doesn't know a config.
+var ErrConfigNotFound = errors.New("tracker: config not found")
+
+// ConfigTracker resolves the configs of wallets.
+type ConfigTracker interface {
+	// ConfigOfWallet returns the latest known config of wallet, or ErrConfigNotFound.
+	ConfigOfWallet(ctx context.Context, wallet common.Address) (*sequence.WalletConfig, error)
+
+	// ConfigOfImageHash returns the config of imageHash, or ErrConfigNotFound.
+	ConfigOfImageHash(ctx context.Context, imageHash common.Hash) (*sequence.WalletConfig, error)
+}
+
+// Fallback is a ConfigTracker which resolves configs with the first of its trackers which
+// knows them, in order.
+type Fallback []ConfigTracker
+
+var _ ConfigTracker = Fallback{}
+
+func (f Fallback) ConfigOfWallet(ctx context.Context, wallet common.Address) (*sequence.WalletConfig, error) {
+	return f.resolve(func(tracker ConfigTracker) (*sequence.WalletConfig, error) {
+		return tracker.ConfigOfWallet(ctx, wallet)
+	})
+}
+
+func (f Fallback) ConfigOfImageHash(ctx context.Context, imageHash common.Hash) (*sequence.WalletConfig, error) {
+	return f.resolve(func(tracker ConfigTracker) (*sequence.WalletConfig, error) {
+		return tracker.ConfigOfImageHash(ctx, imageHash)
+	})
+}
+
+// resolve returns the first config found by the trackers. An error of a tracker other than
+// ErrConfigNotFound, ie. a service which is down, doesn't stop the next trackers, but is
+// returned if none of them knows the config.
+func (f Fallback) resolve(resolve func(ConfigTracker) (*sequence.WalletConfig, error)) (*sequence.WalletConfig, error) {
+	var errs []error
+	for _, tracker := range f {
+		config, err := resolve(tracker)
+		if err == nil {
+			return config, nil
+		}
+		if !errors.Is(err, ErrConfigNotFound) {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("tracker: %v", errs)
+	}
+	return nil, ErrConfigNotFound
+}
+
+// IsValidSignature reports whether seqSig is a valid signature of digest by wallet, as of the
+// latest config of wallet known to tracker. Unlike sequence.IsValidSignature, it doesn't need
+// the wallet to be deployed on the chain of chainID, nor to still have its counterfactual
+// config. A wallet whose config is unknown to tracker is validated against its counterfactual
+// config. The provider validates the signatures of contract signers, and may only be nil if
+// all signers are EOAs.
+func IsValidSignature(ctx context.Context, tracker ConfigTracker, walletContext sequence.WalletContext, chainID *big.Int, wallet common.Address, digest common.Hash, seqSig []byte, provider *ethrpc.Provider) (bool, error) {
+	subDigest, err := sequence.SubDigest(chainID, wallet, digest)
+	if err != nil {
+		return false, err
+	}
+
+	signature, err := sequence.DecodeSignature(seqSig)
+	if err != nil {
+		return false, fmt.Errorf("tracker: invalid signature: %w", err)
+	}
+	for _, part := range signature.Signers {
+		if provider == nil && len(part.Value) != 0 && part.Address != (common.Address{}) {
+			return false, fmt.Errorf("tracker: a provider is required to validate the signature of %v", part.Address.Hex())
+		}
+	}
+	if err := signature.Recover(subDigest, provider); err != nil {
+		return false, err
+	}
+	weight, err := signature.Weight()
+	if err != nil {
+		return false, err
+	}
+	if weight < signature.Threshold {
+		return false, nil
+	}
+
+	imageHash, err := signature.ImageHash()
+	if err != nil {
+		return false, err
+	}
+
+	config, err := tracker.ConfigOfWallet(ctx, wallet)
+	if errors.Is(err, ErrConfigNotFound) {
+		address, err := sequence.AddressFromImageHash(common.Hash(imageHash).Hex(), walletContext)
+		if err != nil {
+			return false, err
+		}
+		return address == wallet, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	configImageHash, err := sequence.ImageHashOfWalletConfigBytes32(*config)
+	if err != nil {
+		return false, err
+	}
+	return configImageHash == imageHash, nil
+}
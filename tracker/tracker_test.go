@@ -0,0 +1,122 @@
+package tracker_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/tracker"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticTracker map[common.Address]*sequence.WalletConfig
+
+func (s staticTracker) ConfigOfWallet(ctx context.Context, wallet common.Address) (*sequence.WalletConfig, error) {
+	if config, ok := s[wallet]; ok {
+		return config, nil
+	}
+	return nil, tracker.ErrConfigNotFound
+}
+
+func (s staticTracker) ConfigOfImageHash(ctx context.Context, imageHash common.Hash) (*sequence.WalletConfig, error) {
+	return nil, tracker.ErrConfigNotFound
+}
+
+func TestClient(t *testing.T) {
+	owner, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(owner)
+	assert.NoError(t, err)
+	config := wallet.GetWalletConfig()
+	imageHash, err := wallet.ImageHash()
+	assert.NoError(t, err)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Wallet    *common.Address `json:"wallet"`
+			ImageHash *common.Hash    `json:"imageHash"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		switch {
+		case r.URL.Path == "/rpc/ConfigTracker/ConfigOfWallet" && *req.Wallet == wallet.Address():
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"config": config})
+		case r.URL.Path == "/rpc/ConfigTracker/ConfigOfImageHash":
+			// a config which matches any image hash
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"config": config})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	client := tracker.NewClient(ts.URL)
+
+	resolved, err := client.ConfigOfWallet(context.Background(), wallet.Address())
+	assert.NoError(t, err)
+	assert.Equal(t, config, *resolved)
+
+	_, err = client.ConfigOfWallet(context.Background(), common.HexToAddress("0x01"))
+	assert.True(t, errors.Is(err, tracker.ErrConfigNotFound))
+
+	resolved, err = client.ConfigOfImageHash(context.Background(), imageHash)
+	assert.NoError(t, err)
+	assert.Equal(t, config, *resolved)
+
+	// the config served isn't the one of the image hash
+	_, err = client.ConfigOfImageHash(context.Background(), common.HexToHash("0x01"))
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, tracker.ErrConfigNotFound))
+
+	// the client is down, but a fallback knows the config
+	ts.Close()
+	resolved, err = tracker.Fallback{client, staticTracker{wallet.Address(): &config}}.ConfigOfWallet(context.Background(), wallet.Address())
+	assert.NoError(t, err)
+	assert.Equal(t, config, *resolved)
+
+	_, err = tracker.Fallback{staticTracker{}}.ConfigOfWallet(context.Background(), wallet.Address())
+	assert.True(t, errors.Is(err, tracker.ErrConfigNotFound))
+}
+
+func TestIsValidSignature(t *testing.T) {
+	owner, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(owner)
+	assert.NoError(t, err)
+
+	chainID := big.NewInt(1)
+	digest := sequence.MessageDigest([]byte("hello"))
+	sig, _, err := wallet.SignDigest(digest, chainID)
+	assert.NoError(t, err)
+
+	// counterfactual config
+	ok, err := tracker.IsValidSignature(context.Background(), staticTracker{}, wallet.GetWalletContext(), chainID, wallet.Address(), digest, sig, nil)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// the wallet updated its config to another owner
+	other, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(2))
+	assert.NoError(t, err)
+	updated := sequence.WalletConfig{Threshold: 1, Signers: sequence.WalletConfigSigners{{Weight: 1, Address: other.Address()}}}
+	configs := staticTracker{wallet.Address(): &updated}
+
+	ok, err = tracker.IsValidSignature(context.Background(), configs, wallet.GetWalletContext(), chainID, wallet.Address(), digest, sig, nil)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	otherWallet, err := sequence.NewWallet(sequence.WalletOptions{Config: updated, Address: wallet.Address()}, other)
+	assert.NoError(t, err)
+	sig, _, err = otherWallet.SignDigest(digest, chainID)
+	assert.NoError(t, err)
+
+	ok, err = tracker.IsValidSignature(context.Background(), configs, wallet.GetWalletContext(), chainID, wallet.Address(), digest, sig, nil)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
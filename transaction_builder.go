@@ -0,0 +1,140 @@
+package sequence
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence/contracts"
+)
+
+// TransactionBuilder builds a bundle of transactions with chained calls, encoding their calldata,
+// ie. to pay a recipient and call a contract:
+//
+//	txns, err := sequence.NewTransactionBuilder().
+//		Transfer(recipient, big.NewInt(1e18)).
+//		ERC20Transfer(usdc, recipient, big.NewInt(1e6)).
+//		Call(market, "buy(uint256,uint256)", tokenID, price).Value(price).
+//		Build()
+//
+// By default the transactions revert the bundle when they fail, so the bundle executes all of
+// them or none, and forward all the gas left to their call, see Wallet.EstimateGasLimits to set
+// their gas limits. The first error of the chain is returned by Build.
+type TransactionBuilder struct {
+	txns Transactions
+	err  error
+}
+
+// NewTransactionBuilder returns an empty builder.
+func NewTransactionBuilder() *TransactionBuilder {
+	return &TransactionBuilder{}
+}
+
+// Call adds a call of the method abiFn of the contract at to, with args, where abiFn is the
+// signature of the method, ie. "transfer(address,uint256)".
+func (b *TransactionBuilder) Call(to common.Address, abiFn string, args ...interface{}) *TransactionBuilder {
+	if b.err != nil {
+		return b
+	}
+	data, err := ethcoder.AbiEncodeMethodCalldata(abiFn, args)
+	if err != nil {
+		b.err = fmt.Errorf("sequence: transaction builder: unable to encode call %v of %v: %w", abiFn, to.Hex(), err)
+		return b
+	}
+	return b.add(&Transaction{To: to, Data: data})
+}
+
+// Data adds a call of the contract at to with calldata data, which is already encoded.
+func (b *TransactionBuilder) Data(to common.Address, data []byte) *TransactionBuilder {
+	return b.add(&Transaction{To: to, Data: data})
+}
+
+// Transfer adds a transfer of value of the native token to to.
+func (b *TransactionBuilder) Transfer(to common.Address, value *big.Int) *TransactionBuilder {
+	if value == nil || value.Sign() < 0 {
+		return b.fail(fmt.Errorf("sequence: transaction builder: invalid value %v of transfer to %v", value, to.Hex()))
+	}
+	return b.add(&Transaction{To: to, Value: new(big.Int).Set(value)})
+}
+
+// ERC20Transfer adds a transfer of amount of the ERC-20 token to to.
+func (b *TransactionBuilder) ERC20Transfer(token common.Address, to common.Address, amount *big.Int) *TransactionBuilder {
+	if amount == nil || amount.Sign() < 0 {
+		return b.fail(fmt.Errorf("sequence: transaction builder: invalid amount %v of transfer of %v", amount, token.Hex()))
+	}
+	if b.err != nil {
+		return b
+	}
+	data, err := contracts.IERC20.Encode("transfer", to, amount)
+	if err != nil {
+		return b.fail(fmt.Errorf("sequence: transaction builder: unable to encode transfer of %v: %w", token.Hex(), err))
+	}
+	return b.add(&Transaction{To: token, Data: data})
+}
+
+// Value sets the value of the native token sent with the last transaction added.
+func (b *TransactionBuilder) Value(value *big.Int) *TransactionBuilder {
+	if value == nil || value.Sign() < 0 {
+		return b.fail(fmt.Errorf("sequence: transaction builder: invalid value %v", value))
+	}
+	return b.last(func(txn *Transaction) { txn.Value = new(big.Int).Set(value) })
+}
+
+// GasLimit sets the gas limit of the last transaction added, 0 to forward all the gas left.
+func (b *TransactionBuilder) GasLimit(gasLimit uint64) *TransactionBuilder {
+	return b.last(func(txn *Transaction) { txn.GasLimit = new(big.Int).SetUint64(gasLimit) })
+}
+
+// RevertOnError sets whether the last transaction added reverts the bundle when it fails.
+// Transactions which don't, ie. best-effort notifications, fail without affecting the others.
+func (b *TransactionBuilder) RevertOnError(revertOnError bool) *TransactionBuilder {
+	return b.last(func(txn *Transaction) { txn.RevertOnError = revertOnError })
+}
+
+// Len returns the number of transactions added.
+func (b *TransactionBuilder) Len() int {
+	return len(b.txns)
+}
+
+// Build returns the transactions added, or the first error of the chain.
+func (b *TransactionBuilder) Build() (Transactions, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.txns) == 0 {
+		return nil, fmt.Errorf("sequence: transaction builder: no transactions")
+	}
+	return b.txns.Clone(), nil
+}
+
+func (b *TransactionBuilder) add(txn *Transaction) *TransactionBuilder {
+	if b.err != nil {
+		return b
+	}
+	txn.RevertOnError = true
+	if txn.Value == nil {
+		txn.Value = big.NewInt(0)
+	}
+	txn.GasLimit = big.NewInt(0)
+	b.txns = append(b.txns, txn)
+	return b
+}
+
+func (b *TransactionBuilder) last(fn func(txn *Transaction)) *TransactionBuilder {
+	if b.err != nil {
+		return b
+	}
+	if len(b.txns) == 0 {
+		return b.fail(fmt.Errorf("sequence: transaction builder: no transaction to modify"))
+	}
+	fn(b.txns[len(b.txns)-1])
+	return b
+}
+
+func (b *TransactionBuilder) fail(err error) *TransactionBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
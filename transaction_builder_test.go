@@ -0,0 +1,76 @@
+package sequence_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionBuilder(t *testing.T) {
+	recipient := common.HexToAddress("0x7a7")
+	token := common.HexToAddress("0x70c3")
+	market := common.HexToAddress("0x3a3")
+
+	builder := sequence.NewTransactionBuilder().
+		Transfer(recipient, big.NewInt(100)).
+		ERC20Transfer(token, recipient, big.NewInt(200)).
+		Call(market, "buy(uint256,uint256)", big.NewInt(1), big.NewInt(300)).Value(big.NewInt(300)).GasLimit(80000).
+		Data(recipient, []byte{0x01}).RevertOnError(false)
+	assert.Equal(t, 4, builder.Len())
+
+	txns, err := builder.Build()
+	assert.NoError(t, err)
+	assert.Len(t, txns, 4)
+
+	assert.Equal(t, recipient, txns[0].To)
+	assert.Equal(t, big.NewInt(100), txns[0].Value)
+	assert.Empty(t, txns[0].Data)
+
+	transfer, err := contracts.IERC20.Encode("transfer", recipient, big.NewInt(200))
+	assert.NoError(t, err)
+	assert.Equal(t, token, txns[1].To)
+	assert.Equal(t, transfer, txns[1].Data)
+	assert.Zero(t, txns[1].Value.Sign())
+
+	buy, err := ethcoder.AbiEncodeMethodCalldata("buy(uint256,uint256)", []interface{}{big.NewInt(1), big.NewInt(300)})
+	assert.NoError(t, err)
+	assert.Equal(t, buy, txns[2].Data)
+	assert.Equal(t, big.NewInt(300), txns[2].Value)
+	assert.Equal(t, big.NewInt(80000), txns[2].GasLimit)
+
+	// by default, transactions revert the bundle and forward all the gas left
+	for _, txn := range txns[:3] {
+		assert.True(t, txn.RevertOnError)
+		assert.False(t, txn.DelegateCall)
+	}
+	assert.Zero(t, txns[0].GasLimit.Sign())
+	assert.False(t, txns[3].RevertOnError)
+
+	// the bundle can be encoded and signed as is
+	_, err = txns.EncodedTransactions()
+	assert.NoError(t, err)
+
+	// the transactions built are not modified by later calls
+	builder.Value(big.NewInt(1))
+	assert.Zero(t, txns[3].Value.Sign())
+
+	// the first error of the chain is returned
+	_, err = sequence.NewTransactionBuilder().Build()
+	assert.Error(t, err)
+	_, err = sequence.NewTransactionBuilder().GasLimit(1).Build()
+	assert.ErrorContains(t, err, "no transaction to modify")
+	_, err = sequence.NewTransactionBuilder().
+		Call(market, "buy(uint256", big.NewInt(1)).
+		Transfer(recipient, big.NewInt(-1)).
+		Build()
+	assert.ErrorContains(t, err, "buy(uint256")
+	_, err = sequence.NewTransactionBuilder().Call(market, "buy(uint256)", "one").Build()
+	assert.Error(t, err)
+	_, err = sequence.NewTransactionBuilder().ERC20Transfer(token, recipient, nil).Build()
+	assert.Error(t, err)
+}
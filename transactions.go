@@ -2,6 +2,7 @@ package sequence
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"fmt"
 	"math/big"
@@ -9,8 +10,10 @@ import (
 	"github.com/0xsequence/ethkit/ethcontract"
 	"github.com/0xsequence/ethkit/ethrpc"
 	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi/bind"
 	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/0xsequence/go-sequence/contracts/gen/walletmain"
 )
 
 // Transaction type for Sequence meta-transaction, with encoded calldata.
@@ -40,11 +43,16 @@ func (t *Transaction) IsEncoded() bool {
 	return t.encoded
 }
 
+// Clone returns a deep copy of the transaction, which can be mutated without affecting t.
 func (t *Transaction) Clone() *Transaction {
+	if t == nil {
+		return nil
+	}
 	clone := Transaction{
 		DelegateCall:  t.DelegateCall,
 		RevertOnError: t.RevertOnError,
 		To:            t.To,
+		encoded:       t.encoded,
 	}
 	if t.GasLimit != nil {
 		clone.GasLimit = new(big.Int).Set(t.GasLimit)
@@ -69,6 +77,23 @@ func (t *Transaction) Clone() *Transaction {
 	return &clone
 }
 
+// Equal reports whether t and other are the same transaction, including their child
+// transactions. Nil and zero values and gas limits are equal, as they encode the same.
+func (t *Transaction) Equal(other *Transaction) bool {
+	if t == nil || other == nil {
+		return t == other
+	}
+	return t.DelegateCall == other.DelegateCall &&
+		t.RevertOnError == other.RevertOnError &&
+		t.To == other.To &&
+		bigIntValueEqual(t.GasLimit, other.GasLimit) &&
+		bigIntValueEqual(t.Value, other.Value) &&
+		bytes.Equal(t.Data, other.Data) &&
+		t.Transactions.Equal(other.Transactions) &&
+		bigIntEqual(t.Nonce, other.Nonce) &&
+		bytes.Equal(t.Signature, other.Signature)
+}
+
 func (t *Transaction) Bundle() Transactions {
 	return Transactions{t}
 }
@@ -105,6 +130,140 @@ func (t *Transaction) IsValid() error {
 	return nil
 }
 
+const (
+	// MaxTransactionDataSize is the largest calldata of a transaction accepted by Validate,
+	// the transaction size limit of geth's txpool.
+	MaxTransactionDataSize = 128 * 1024
+
+	// MaxTransactionGasLimit is the largest gas limit of a transaction accepted by Validate.
+	MaxTransactionGasLimit = 30_000_000
+)
+
+// TransactionLimits are the limits checked by ValidateLimits in addition to the structure of
+// the transactions. The zero value sets no limits.
+type TransactionLimits struct {
+	// MaxDataSize is the largest calldata of a transaction, or unlimited if zero.
+	MaxDataSize int
+
+	// MaxGasLimit is the largest gas limit of a transaction, or unlimited if zero, ie. for
+	// chains with a block gas limit above MaxTransactionGasLimit.
+	MaxGasLimit uint64
+
+	// RejectZeroTo rejects transactions to the zero address. Contracts are deployed by calling
+	// the wallet's createContract, so these are usually mistakes, but they may be burns or
+	// placeholder calls.
+	RejectZeroTo bool
+}
+
+// DefaultTransactionLimits are the limits checked by Validate.
+var DefaultTransactionLimits = TransactionLimits{
+	MaxDataSize:  MaxTransactionDataSize,
+	MaxGasLimit:  MaxTransactionGasLimit,
+	RejectZeroTo: true,
+}
+
+// ErrInvalidTransaction is matched by the errors of Validate, ie. errors.Is(err, ErrInvalidTransaction).
+var ErrInvalidTransaction = fmt.Errorf("invalid transaction")
+
+// TransactionFieldError is returned by Validate for an invalid field of a transaction.
+type TransactionFieldError struct {
+	// Path is the position of the transaction, ie. "transactions[1].transactions[0]".
+	Path string
+
+	// Field is the name of the invalid field, ie. "value".
+	Field string
+
+	Reason string
+}
+
+func (e *TransactionFieldError) Error() string {
+	return fmt.Sprintf("%v: %s.%s %s", ErrInvalidTransaction, e.Path, e.Field, e.Reason)
+}
+
+func (e *TransactionFieldError) Is(target error) bool {
+	return target == ErrInvalidTransaction
+}
+
+// Validate checks the fields of the transaction and of its child transactions against
+// DefaultTransactionLimits, so invalid transactions are rejected before they are signed or
+// reach the chain. It returns a *TransactionFieldError for the first invalid field.
+func (t *Transaction) Validate() error {
+	return t.ValidateLimits(DefaultTransactionLimits)
+}
+
+// ValidateLimits checks the fields of the transaction and of its child transactions, like
+// Validate, against limits.
+func (t *Transaction) ValidateLimits(limits TransactionLimits) error {
+	return t.validate("transaction", limits)
+}
+
+// Validate checks the fields of each transaction, see Transaction.Validate.
+func (t Transactions) Validate() error {
+	return t.ValidateLimits(DefaultTransactionLimits)
+}
+
+// ValidateLimits checks the fields of each transaction against limits, see Transaction.ValidateLimits.
+func (t Transactions) ValidateLimits(limits TransactionLimits) error {
+	return t.validate("transactions", limits)
+}
+
+func (t Transactions) validate(path string, limits TransactionLimits) error {
+	for i, txn := range t {
+		if txn == nil {
+			return &TransactionFieldError{Path: path, Field: fmt.Sprintf("[%d]", i), Reason: "is nil"}
+		}
+		if err := txn.validate(fmt.Sprintf("%s[%d]", path, i), limits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Transaction) validate(path string, limits TransactionLimits) error {
+	fieldError := func(field, reason string) error {
+		return &TransactionFieldError{Path: path, Field: field, Reason: reason}
+	}
+
+	// a nonce without a signature or child transactions only selects the nonce of the
+	// bundle, see Transactions.Nonce
+	if t.Transactions != nil || t.Signature != nil {
+		if err := t.IsValid(); err != nil {
+			return fieldError("data", err.Error())
+		}
+	}
+
+	if limits.RejectZeroTo && t.To == (common.Address{}) {
+		return fieldError("to", "is the zero address")
+	}
+	if t.Value != nil && t.Value.Sign() < 0 {
+		return fieldError("value", "is negative")
+	}
+	if t.Value != nil && t.Value.BitLen() > 256 {
+		return fieldError("value", "exceeds uint256")
+	}
+	if t.DelegateCall && t.Value != nil && t.Value.Sign() > 0 {
+		return fieldError("value", "cannot be sent with a delegate call")
+	}
+	if limits.MaxDataSize > 0 && len(t.Data) > limits.MaxDataSize {
+		return fieldError("data", fmt.Sprintf("is %d bytes, exceeding the maximum of %d", len(t.Data), limits.MaxDataSize))
+	}
+	if t.GasLimit != nil && t.GasLimit.Sign() < 0 {
+		return fieldError("gasLimit", "is negative")
+	}
+	if t.GasLimit != nil && t.GasLimit.BitLen() > 256 {
+		return fieldError("gasLimit", "exceeds uint256")
+	}
+	if limits.MaxGasLimit > 0 && t.GasLimit != nil && t.GasLimit.Cmp(new(big.Int).SetUint64(limits.MaxGasLimit)) > 0 {
+		return fieldError("gasLimit", fmt.Sprintf("exceeds the maximum of %d", limits.MaxGasLimit))
+	}
+	if t.Nonce != nil && (t.Nonce.Sign() < 0 || t.Nonce.BitLen() > 256) {
+		// the space is the high 160 bits of the nonce, and the nonce the low 96 bits
+		return fieldError("nonce", "is not a uint256 of a 160 bit space and 96 bit nonce")
+	}
+
+	return t.Transactions.validate(path+".transactions", limits)
+}
+
 func (t *Transaction) IsBundle() bool {
 	return len(t.Transactions) != 0
 }
@@ -157,15 +316,10 @@ func (t *Transaction) Execdata() ([]byte, error) {
 		return nil, fmt.Errorf("transaction is not a bundle: only bundles have execdata")
 	}
 
-	encodedTxns, err := t.Transactions.EncodedTransactions()
-	if err != nil {
-		return nil, err
-	}
-
 	if t.Signature != nil {
-		return contracts.WalletMainModule.Encode("execute", encodedTxns, t.Nonce, t.Signature)
+		return appendExecute(nil, t.Transactions, t.Nonce, t.Signature)
 	} else {
-		return contracts.WalletMainModule.Encode("selfExecute", encodedTxns)
+		return appendSelfExecute(nil, t.Transactions)
 	}
 }
 
@@ -271,6 +425,28 @@ func (t Transactions) PrependBundle(txns Transactions) {
 	t.Prepend(Transactions{bundleTxn})
 }
 
+// ModuleCalls returns the transactions encoded as the calls of the `execute` method of the
+// main module, ie. for walletmain.WalletMainCalldata.Execute.
+func (t Transactions) ModuleCalls() ([]walletmain.IModuleCallsTransaction, error) {
+	encoded, err := t.EncodedTransactions()
+	if err != nil {
+		return nil, err
+	}
+
+	calls := make([]walletmain.IModuleCallsTransaction, 0, len(encoded))
+	for _, txn := range encoded {
+		calls = append(calls, walletmain.IModuleCallsTransaction{
+			DelegateCall:  txn.DelegateCall,
+			RevertOnError: txn.RevertOnError,
+			GasLimit:      txn.GasLimit,
+			Target:        txn.To,
+			Value:         txn.Value,
+			Data:          txn.Data,
+		})
+	}
+	return calls, nil
+}
+
 func (t Transactions) EncodedTransactions() ([]Transaction, error) {
 	stxns := []Transaction{}
 	for _, txn := range t {
@@ -314,7 +490,11 @@ func (t Transactions) AsValues() []Transaction {
 	return v
 }
 
+// Clone returns a deep copy of the transactions.
 func (t Transactions) Clone() Transactions {
+	if t == nil {
+		return nil
+	}
 	txns := make(Transactions, len(t))
 	for i, txn := range t {
 		txns[i] = txn.Clone()
@@ -334,12 +514,83 @@ type SignedTransactions struct {
 	Signature    []byte       // Signature (encoded as bytes from *Signature) of the txn digest
 }
 
-func (t *SignedTransactions) Execdata() ([]byte, error) {
-	encodedTxns, err := t.Transactions.EncodedTransactions()
+// Equal reports whether t and other are the same transactions, in the same order.
+func (t Transactions) Equal(other Transactions) bool {
+	if len(t) != len(other) {
+		return false
+	}
+	for i := range t {
+		if !t[i].Equal(other[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Clone returns a deep copy of the signed transactions.
+func (t *SignedTransactions) Clone() *SignedTransactions {
+	if t == nil {
+		return nil
+	}
+	clone := &SignedTransactions{
+		WalletConfig:  t.WalletConfig.Clone(),
+		WalletContext: t.WalletContext,
+		Transactions:  t.Transactions.Clone(),
+		Digest:        t.Digest,
+	}
+	if t.ChainID != nil {
+		clone.ChainID = new(big.Int).Set(t.ChainID)
+	}
+	if t.Nonce != nil {
+		clone.Nonce = new(big.Int).Set(t.Nonce)
+	}
+	if t.Signature != nil {
+		clone.Signature = make([]byte, len(t.Signature))
+		copy(clone.Signature, t.Signature)
+	}
+	return clone
+}
+
+// Equal reports whether t and other are the same transactions, signed for the same wallet
+// and chain with the same signature.
+func (t *SignedTransactions) Equal(other *SignedTransactions) bool {
+	if t == nil || other == nil {
+		return t == other
+	}
+	return bigIntEqual(t.ChainID, other.ChainID) &&
+		t.WalletConfig.Equal(other.WalletConfig) &&
+		t.WalletContext == other.WalletContext &&
+		t.Transactions.Equal(other.Transactions) &&
+		bigIntEqual(t.Nonce, other.Nonce) &&
+		t.Digest == other.Digest &&
+		bytes.Equal(t.Signature, other.Signature)
+}
+
+// ErrSignedTransactionsMutated is returned when the transactions of a SignedTransactions no
+// longer hash to the digest they were signed over.
+var ErrSignedTransactionsMutated = fmt.Errorf("signed transactions were modified after signing")
+
+// Verify checks that the transactions and nonce still hash to the digest they were signed
+// over, so a bundle mutated after signing is never relayed with a signature which does
+// not match it.
+func (t *SignedTransactions) Verify() error {
+	bundle := Transaction{Transactions: t.Transactions, Nonce: t.Nonce}
+	digest, err := bundle.Digest()
 	if err != nil {
+		return err
+	}
+	if digest != t.Digest {
+		return fmt.Errorf("%w: digest is %v, but was signed over %v", ErrSignedTransactionsMutated, digest, t.Digest)
+	}
+	return nil
+}
+
+// Execdata encodes the `execute` call of the signed transactions, after verifying them.
+func (t *SignedTransactions) Execdata() ([]byte, error) {
+	if err := t.Verify(); err != nil {
 		return nil, err
 	}
-	return contracts.WalletMainModule.Encode("execute", encodedTxns, t.Nonce, t.Signature)
+	return appendExecute(nil, t.Transactions, t.Nonce, t.Signature)
 }
 
 // Transaction events as defined in wallet-contracts IModuleCalls.sol
@@ -414,12 +665,17 @@ func GetWalletNonce(provider *ethrpc.Provider, walletConfig WalletConfig, wallet
 	if err != nil {
 		return nil, err
 	}
+	return GetWalletAddressNonce(provider, walletAddress, space, blockNum)
+}
 
-	ok, err := IsWalletDeployed(provider, walletAddress)
+// GetWalletAddressNonce is like GetWalletNonce, for a wallet known only by its address.
+// The deployment of the wallet and its nonce are both read at blockNum, the latest block if nil.
+func GetWalletAddressNonce(provider *ethrpc.Provider, walletAddress common.Address, space *big.Int, blockNum *big.Int) (*big.Int, error) {
+	code, err := provider.CodeAt(context.Background(), walletAddress, blockNum)
 	if err != nil {
 		return nil, err
 	}
-	if !ok {
+	if len(code) <= 2 {
 		return big.NewInt(0), nil
 	}
 
@@ -431,7 +687,7 @@ func GetWalletNonce(provider *ethrpc.Provider, walletConfig WalletConfig, wallet
 
 	var nonceResult *big.Int
 	results := []interface{}{&nonceResult}
-	err = contract.Call(nil, &results, "readNonce", space)
+	err = contract.Call(&bind.CallOpts{BlockNumber: blockNum}, &results, "readNonce", space)
 	if err != nil {
 		return nil, err
 	}
@@ -503,3 +759,18 @@ func DecodeExecdata(data []byte) (Transactions, *big.Int, []byte, error) {
 
 	return NewTransactionsFromValues(transactions), nonce, signature, nil
 }
+
+func bigIntEqual(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Cmp(b) == 0
+}
+
+// bigIntValueEqual is like bigIntEqual, with nil equal to zero.
+func bigIntValueEqual(a, b *big.Int) bool {
+	if (a == nil || a.Sign() == 0) && (b == nil || b.Sign() == 0) {
+		return true
+	}
+	return bigIntEqual(a, b)
+}
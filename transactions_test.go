@@ -510,3 +510,111 @@ func TestRawTransactionsEncoding(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, bytes.Equal(data, data2))
 }
+
+func TestTransactionValidate(t *testing.T) {
+	to := common.HexToAddress("0x1234")
+	assert.NoError(t, (&sequence.Transaction{To: to, Value: big.NewInt(1), Data: []byte{1}}).Validate())
+	assert.NoError(t, (&sequence.Transaction{To: to, Data: []byte{1}, Nonce: big.NewInt(999)}).Validate())
+
+	tests := []struct {
+		txn   *sequence.Transaction
+		field string
+	}{
+		{&sequence.Transaction{}, "transaction.to"},
+		{&sequence.Transaction{To: to, Value: big.NewInt(-1)}, "transaction.value"},
+		{&sequence.Transaction{To: to, Value: big.NewInt(1), DelegateCall: true}, "transaction.value"},
+		{&sequence.Transaction{To: to, Data: make([]byte, sequence.MaxTransactionDataSize+1)}, "transaction.data"},
+		{&sequence.Transaction{To: to, GasLimit: big.NewInt(-1)}, "transaction.gasLimit"},
+		{&sequence.Transaction{To: to, GasLimit: big.NewInt(sequence.MaxTransactionGasLimit + 1)}, "transaction.gasLimit"},
+		{&sequence.Transaction{To: to, Nonce: new(big.Int).Lsh(big.NewInt(1), 256), Signature: []byte{1}}, "transaction.nonce"},
+		{&sequence.Transaction{To: to, Data: []byte{1}, Nonce: big.NewInt(1), Signature: []byte{1}}, "transaction.data"},
+		{&sequence.Transaction{To: to, Transactions: sequence.Transactions{{To: to}, {Value: big.NewInt(1)}}}, "transaction.transactions[1].to"},
+	}
+	for _, test := range tests {
+		err := test.txn.Validate()
+		assert.ErrorIs(t, err, sequence.ErrInvalidTransaction)
+
+		var fieldErr *sequence.TransactionFieldError
+		if assert.ErrorAs(t, err, &fieldErr) {
+			assert.Equal(t, test.field, fieldErr.Path+"."+fieldErr.Field)
+		}
+	}
+
+	err := sequence.Transactions{{To: to}, nil}.Validate()
+	assert.ErrorIs(t, err, sequence.ErrInvalidTransaction)
+}
+
+func TestTransactionValidateLimits(t *testing.T) {
+	// the zero limits only check the structure of transactions
+	burn := &sequence.Transaction{Value: big.NewInt(1)}
+	assert.NoError(t, burn.ValidateLimits(sequence.TransactionLimits{}))
+	assert.ErrorIs(t, burn.Validate(), sequence.ErrInvalidTransaction)
+
+	to := common.HexToAddress("0x1234")
+	txn := &sequence.Transaction{To: to, GasLimit: big.NewInt(sequence.MaxTransactionGasLimit + 1)}
+	assert.NoError(t, txn.ValidateLimits(sequence.TransactionLimits{}))
+	assert.NoError(t, txn.ValidateLimits(sequence.TransactionLimits{MaxGasLimit: 60_000_000}))
+	assert.ErrorIs(t, txn.ValidateLimits(sequence.TransactionLimits{MaxGasLimit: 1_000_000}), sequence.ErrInvalidTransaction)
+
+	assert.ErrorIs(t, (&sequence.Transaction{To: to, Value: big.NewInt(-1)}).ValidateLimits(sequence.TransactionLimits{}), sequence.ErrInvalidTransaction)
+	assert.ErrorIs(t, sequence.Transactions{{To: to}, nil}.ValidateLimits(sequence.TransactionLimits{}), sequence.ErrInvalidTransaction)
+}
+
+func TestTransactionCloneEqual(t *testing.T) {
+	txns := sequence.Transactions{
+		{To: common.HexToAddress("0x01"), Value: big.NewInt(1), Data: []byte{1, 2}},
+		{To: common.HexToAddress("0x02"), Transactions: sequence.Transactions{{To: common.HexToAddress("0x03")}}, Nonce: big.NewInt(7), Signature: []byte{3}},
+	}
+	clone := txns.Clone()
+	assert.True(t, txns.Equal(clone))
+
+	// mutating the clone does not affect the original
+	clone[0].Value.SetInt64(2)
+	clone[0].Data[0] = 9
+	clone[1].Transactions[0].To = common.HexToAddress("0x04")
+	assert.False(t, txns.Equal(clone))
+	assert.Equal(t, int64(1), txns[0].Value.Int64())
+	assert.Equal(t, byte(1), txns[0].Data[0])
+	assert.Equal(t, common.HexToAddress("0x03"), txns[1].Transactions[0].To)
+
+	// big.Ints are compared by value, and nil values are zero
+	assert.True(t, (&sequence.Transaction{Value: new(big.Int).Lsh(big.NewInt(1), 100)}).Equal(&sequence.Transaction{Value: new(big.Int).Lsh(big.NewInt(1), 100)}))
+	assert.True(t, (&sequence.Transaction{Value: big.NewInt(0)}).Equal(&sequence.Transaction{}))
+	assert.False(t, (&sequence.Transaction{Nonce: big.NewInt(0)}).Equal(&sequence.Transaction{}))
+	assert.Nil(t, sequence.Transactions(nil).Clone())
+
+	signed := &sequence.SignedTransactions{
+		ChainID:      big.NewInt(1),
+		WalletConfig: sequence.WalletConfig{Threshold: 1, Signers: sequence.WalletConfigSigners{{Weight: 1, Address: common.HexToAddress("0x05")}}},
+		Transactions: txns,
+		Nonce:        big.NewInt(3),
+		Digest:       common.HexToHash("0x06"),
+		Signature:    []byte{7},
+	}
+	signedClone := signed.Clone()
+	assert.True(t, signed.Equal(signedClone))
+	signedClone.WalletConfig.Signers[0].Weight = 2
+	assert.False(t, signed.Equal(signedClone))
+	assert.Equal(t, uint8(1), signed.WalletConfig.Signers[0].Weight)
+}
+
+func TestSignedTransactionsVerify(t *testing.T) {
+	txns := sequence.Transactions{{To: common.HexToAddress("0x01"), Value: big.NewInt(1)}}
+	bundle := sequence.Transaction{Transactions: txns, Nonce: big.NewInt(0)}
+	digest, err := bundle.Digest()
+	assert.NoError(t, err)
+
+	signed := &sequence.SignedTransactions{Transactions: txns.Clone(), Nonce: big.NewInt(0), Digest: digest, Signature: []byte{1}}
+	assert.NoError(t, signed.Verify())
+	_, err = signed.Execdata()
+	assert.NoError(t, err)
+
+	signed.Transactions[0].Value.SetInt64(1000)
+	assert.ErrorIs(t, signed.Verify(), sequence.ErrSignedTransactionsMutated)
+	_, err = signed.Execdata()
+	assert.ErrorIs(t, err, sequence.ErrSignedTransactionsMutated)
+
+	signed.Transactions[0].Value.SetInt64(1)
+	signed.Nonce = big.NewInt(1)
+	assert.ErrorIs(t, signed.Verify(), sequence.ErrSignedTransactionsMutated)
+}
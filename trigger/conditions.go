@@ -0,0 +1,147 @@
+package trigger
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence/contracts"
+)
+
+// Condition is an on-chain condition, checked by an Engine over the new blocks. Conditions
+// may keep state between checks, ie. the last balance observed, so a condition must not be
+// shared by triggers.
+type Condition interface {
+	// Check reports whether the condition fired in the blocks from to to, inclusive.
+	Check(ctx context.Context, provider *ethrpc.Provider, from, to uint64) (bool, error)
+}
+
+// ConditionFunc is a Condition of a func.
+type ConditionFunc func(ctx context.Context, provider *ethrpc.Provider, from, to uint64) (bool, error)
+
+func (f ConditionFunc) Check(ctx context.Context, provider *ethrpc.Provider, from, to uint64) (bool, error) {
+	return f(ctx, provider, from, to)
+}
+
+// EventEmitted fires when a contract emits a log matching topics, as in eth_getLogs: each
+// position lists the topics it may be, empty matching any. A zero Address matches any
+// contract.
+type EventEmitted struct {
+	Address common.Address
+	Topics  [][]common.Hash
+}
+
+var _ Condition = &EventEmitted{}
+
+func (c *EventEmitted) Check(ctx context.Context, provider *ethrpc.Provider, from, to uint64) (bool, error) {
+	query := ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+		Topics:    c.Topics,
+	}
+	if c.Address != (common.Address{}) {
+		query.Addresses = []common.Address{c.Address}
+	}
+	logs, err := provider.FilterLogs(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("trigger: failed to get logs: %w", err)
+	}
+	for _, log := range logs {
+		if !log.Removed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+var latestRoundDataSelector = crypto.Keccak256([]byte("latestRoundData()"))[:4]
+
+// PriceCrosses fires when the answer of a Chainlink price feed crosses Threshold: rises to or
+// above it if Above, or falls to or below it otherwise. It fires on the crossing, and again
+// only once the price crossed back.
+type PriceCrosses struct {
+	Feed      common.Address
+	Threshold *big.Int
+	Above     bool
+
+	crossed *bool
+}
+
+var _ Condition = &PriceCrosses{}
+
+func (c *PriceCrosses) Check(ctx context.Context, provider *ethrpc.Provider, from, to uint64) (bool, error) {
+	res, err := provider.CallContract(ctx, ethereum.CallMsg{To: &c.Feed, Data: latestRoundDataSelector}, new(big.Int).SetUint64(to))
+	if err != nil {
+		return false, fmt.Errorf("trigger: failed to read price feed %v: %w", c.Feed.Hex(), err)
+	}
+	var roundID, answer, startedAt, updatedAt, answeredInRound *big.Int
+	if err := ethcoder.AbiDecoder([]string{"uint80", "int256", "uint256", "uint256", "uint80"}, res, []interface{}{&roundID, &answer, &startedAt, &updatedAt, &answeredInRound}); err != nil {
+		return false, fmt.Errorf("trigger: failed to decode price feed %v: %w", c.Feed.Hex(), err)
+	}
+
+	var crossed bool
+	if c.Above {
+		crossed = answer.Cmp(c.Threshold) >= 0
+	} else {
+		crossed = answer.Cmp(c.Threshold) <= 0
+	}
+	fired := crossed && (c.crossed == nil || !*c.crossed)
+	c.crossed = &crossed
+	return fired, nil
+}
+
+// BalanceChanged fires when the balance of Account changes by at least MinChange, up or down,
+// from the balance of its previous firing, or of its first check. Token is the ERC-20 token,
+// or the zero address for the native currency. MinChange defaults to any change.
+type BalanceChanged struct {
+	Account   common.Address
+	Token     common.Address
+	MinChange *big.Int
+
+	last *big.Int
+}
+
+var _ Condition = &BalanceChanged{}
+
+func (c *BalanceChanged) Check(ctx context.Context, provider *ethrpc.Provider, from, to uint64) (bool, error) {
+	balance, err := c.balance(ctx, provider, new(big.Int).SetUint64(to))
+	if err != nil {
+		return false, fmt.Errorf("trigger: failed to read balance of %v: %w", c.Account.Hex(), err)
+	}
+	if c.last == nil {
+		c.last = balance
+		return false, nil
+	}
+
+	change := new(big.Int).Sub(balance, c.last)
+	change.Abs(change)
+	if change.Sign() == 0 || (c.MinChange != nil && change.Cmp(c.MinChange) < 0) {
+		return false, nil
+	}
+	c.last = balance
+	return true, nil
+}
+
+func (c *BalanceChanged) balance(ctx context.Context, provider *ethrpc.Provider, block *big.Int) (*big.Int, error) {
+	if c.Token == (common.Address{}) {
+		return provider.BalanceAt(ctx, c.Account, block)
+	}
+	data, err := contracts.IERC20.Encode("balanceOf", c.Account)
+	if err != nil {
+		return nil, err
+	}
+	res, err := provider.CallContract(ctx, ethereum.CallMsg{To: &c.Token, Data: data}, block)
+	if err != nil {
+		return nil, err
+	}
+	values, err := contracts.IERC20.ABI.Unpack("balanceOf", res)
+	if err != nil {
+		return nil, err
+	}
+	return values[0].(*big.Int), nil
+}
@@ -0,0 +1,266 @@
+// Package trigger relays pre-signed bundles when on-chain conditions fire, ie. a repayment
+// when a price feed crosses a threshold, or a sweep when a deposit arrives:
+//
+//	engine := trigger.NewEngine(provider, relayer, trigger.Options{Confirmations: 2})
+//	err := engine.Add(&trigger.Trigger{
+//		ID:        "stop-loss",
+//		Condition: &trigger.PriceCrosses{Feed: ethUsdFeed, Threshold: big.NewInt(1500e8)},
+//		Bundles:   []*sequence.SignedTransactions{signed},
+//	})
+//	go engine.Run(ctx)
+package trigger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/go-sequence"
+)
+
+var (
+	ErrTriggerNotFound = errors.New("trigger: trigger not found")
+	ErrTriggerExists   = errors.New("trigger: trigger already exists")
+)
+
+// DefaultMaxBlockRange is the default of the most blocks a condition is checked over at once.
+const DefaultMaxBlockRange = 1000
+
+// Mode is how many times a trigger fires.
+type Mode int
+
+const (
+	// OneShot fires once, relaying the first bundle of the trigger.
+	OneShot Mode = iota
+
+	// Repeating fires each time the condition does, relaying the next bundle of the trigger,
+	// until they are all relayed.
+	Repeating
+)
+
+// Trigger relays its pre-signed bundles when its condition fires.
+type Trigger struct {
+	ID        string
+	Condition Condition
+	Mode      Mode
+
+	// Bundles are relayed in order as the trigger fires. The bundles of a wallet should be
+	// signed in consecutive nonces, or distinct nonce spaces, so each can be executed once the
+	// previous ones are.
+	Bundles []*sequence.SignedTransactions
+}
+
+// Fire reports a bundle relayed by a trigger.
+type Fire struct {
+	TriggerID string
+
+	// Block is the last block of the range the condition fired in.
+	Block uint64
+
+	// Bundle is the index of the bundle in Trigger.Bundles.
+	Bundle    int
+	MetaTxnID sequence.MetaTxnID
+
+	// Err is the error relaying the bundle, which is retried on the next poll.
+	Err error
+}
+
+type Options struct {
+	// PollInterval is the interval the block number is polled at, 2 seconds by default.
+	PollInterval time.Duration
+
+	// Confirmations is the number of blocks the conditions lag behind the head of the chain,
+	// so reorged blocks are not checked.
+	Confirmations uint64
+
+	// FromBlock is the first block checked, by default the first block after Run starts.
+	FromBlock uint64
+
+	// MaxBlockRange is the most blocks a condition is checked over at once,
+	// DefaultMaxBlockRange by default.
+	MaxBlockRange uint64
+
+	// OnFire is called with each bundle relayed, or failed to, one at a time.
+	OnFire func(Fire)
+
+	// OnError is called with the errors checking a condition, whose blocks are checked again
+	// on the next poll.
+	OnError func(triggerID string, err error)
+}
+
+// Engine checks the conditions of its triggers on the new blocks, and relays their bundles
+// with its relayer when they fire.
+type Engine struct {
+	provider *ethrpc.Provider
+	relayer  sequence.Relayer
+	options  Options
+
+	triggers map[string]*triggerState
+	next     uint64
+	mu       sync.Mutex
+}
+
+type triggerState struct {
+	trigger *Trigger
+
+	// from is the next block to check, zero until the trigger is first polled.
+	from uint64
+
+	// next is the index of the next bundle to relay, which is due once the condition fired,
+	// in the range ending at block fired.
+	next  int
+	due   bool
+	fired uint64
+}
+
+func NewEngine(provider *ethrpc.Provider, relayer sequence.Relayer, options Options) *Engine {
+	if options.PollInterval == 0 {
+		options.PollInterval = 2 * time.Second
+	}
+	if options.MaxBlockRange == 0 {
+		options.MaxBlockRange = DefaultMaxBlockRange
+	}
+	return &Engine{
+		provider: provider,
+		relayer:  relayer,
+		options:  options,
+		triggers: map[string]*triggerState{},
+	}
+}
+
+// Add adds trigger, whose condition is checked from the next block checked by the engine.
+func (e *Engine) Add(trigger *Trigger) error {
+	if trigger.ID == "" || trigger.Condition == nil || len(trigger.Bundles) == 0 {
+		return fmt.Errorf("trigger: trigger requires an ID, a condition and a bundle")
+	}
+	for i, bundle := range trigger.Bundles {
+		if err := bundle.Verify(); err != nil {
+			return fmt.Errorf("trigger: bundle %d of %v: %w", i, trigger.ID, err)
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.triggers[trigger.ID]; ok {
+		return fmt.Errorf("%w: %v", ErrTriggerExists, trigger.ID)
+	}
+	e.triggers[trigger.ID] = &triggerState{trigger: trigger}
+	return nil
+}
+
+// Remove removes the trigger of id, whose remaining bundles are not relayed.
+func (e *Engine) Remove(id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.triggers[id]; !ok {
+		return fmt.Errorf("%w: %v", ErrTriggerNotFound, id)
+	}
+	delete(e.triggers, id)
+	return nil
+}
+
+// Armed returns the IDs of the triggers which have bundles left to relay. Triggers are
+// removed once they relayed their bundles, or their first bundle if OneShot.
+func (e *Engine) Armed() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ids := make([]string, 0, len(e.triggers))
+	for id := range e.triggers {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Run checks the conditions of the triggers on the new blocks until ctx is done.
+func (e *Engine) Run(ctx context.Context) error {
+	from := e.options.FromBlock
+	if from == 0 {
+		head, err := e.provider.BlockNumber(ctx)
+		if err != nil {
+			return fmt.Errorf("trigger: failed to get block number: %w", err)
+		}
+		from = head + 1
+	}
+	e.mu.Lock()
+	e.next = from
+	e.mu.Unlock()
+
+	ticker := time.NewTicker(e.options.PollInterval)
+	defer ticker.Stop()
+	for {
+		head, err := e.provider.BlockNumber(ctx)
+		if err == nil && head >= e.options.Confirmations {
+			e.poll(ctx, head-e.options.Confirmations)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll checks the triggers up to block safe, and relays the bundles due.
+func (e *Engine) poll(ctx context.Context, safe uint64) {
+	e.mu.Lock()
+	states := make([]*triggerState, 0, len(e.triggers))
+	for _, state := range e.triggers {
+		if state.from == 0 {
+			state.from = e.next
+		}
+		states = append(states, state)
+	}
+	if safe >= e.next {
+		e.next = safe + 1
+	}
+	e.mu.Unlock()
+
+	for _, state := range states {
+		trigger := state.trigger
+		for !state.due && state.from <= safe {
+			to := state.from + e.options.MaxBlockRange - 1
+			if to > safe {
+				to = safe
+			}
+			fired, err := trigger.Condition.Check(ctx, e.provider, state.from, to)
+			if err != nil {
+				if e.options.OnError != nil && ctx.Err() == nil {
+					e.options.OnError(trigger.ID, err)
+				}
+				break
+			}
+			state.from = to + 1
+			if fired {
+				state.due, state.fired = true, to
+			}
+		}
+
+		if state.due {
+			e.fire(ctx, state)
+		}
+	}
+}
+
+func (e *Engine) fire(ctx context.Context, state *triggerState) {
+	trigger := state.trigger
+	fire := Fire{TriggerID: trigger.ID, Block: state.fired, Bundle: state.next}
+	fire.MetaTxnID, _, _, fire.Err = e.relayer.Relay(ctx, trigger.Bundles[state.next])
+	if fire.Err != nil {
+		fire.Err = fmt.Errorf("trigger: failed to relay bundle %d of %v: %w", state.next, trigger.ID, fire.Err)
+	} else {
+		state.due = false
+		state.next++
+		if trigger.Mode == OneShot || state.next == len(trigger.Bundles) {
+			e.mu.Lock()
+			delete(e.triggers, trigger.ID)
+			e.mu.Unlock()
+		}
+	}
+	if e.options.OnFire != nil {
+		e.options.OnFire(fire)
+	}
+}
@@ -0,0 +1,178 @@
+package trigger_test
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/0xsequence/go-sequence/trigger"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	feed         = common.HexToAddress("0xfeed")
+	depositor    = common.HexToAddress("0xdead")
+	depositTopic = common.HexToHash("0xd0")
+)
+
+// newChain returns a chain at block head, with a price feed answering the price of the test and
+// a depositor emitting the deposit event of each transaction to it.
+func newChain(t *testing.T, head uint64, price *int64) (*memchain.Chain, *ethwallet.Wallet) {
+	chain := memchain.New(memchain.Options{})
+	for chain.Head() < head {
+		chain.Mine()
+	}
+	chain.Deploy(feed, memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		return ethcoder.AbiCoder([]string{"uint80", "int256", "uint256", "uint256", "uint80"}, []interface{}{big.NewInt(1), big.NewInt(atomic.LoadInt64(price)), big.NewInt(0), big.NewInt(0), big.NewInt(1)})
+	}))
+	chain.Deploy(depositor, memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		env.Log([]common.Hash{depositTopic}, nil)
+		return nil, nil
+	}))
+	eoa, err := testutil.MemChainEOA(chain, 2, big.NewInt(1e18))
+	assert.NoError(t, err)
+	return chain, eoa
+}
+
+type fakeRelayer struct {
+	provider *ethrpc.Provider
+	relayed  []*sequence.SignedTransactions
+	mu       sync.Mutex
+}
+
+func (r *fakeRelayer) GetProvider() *ethrpc.Provider { return r.provider }
+
+func (r *fakeRelayer) EstimateGasLimits(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, txns sequence.Transactions) (sequence.Transactions, error) {
+	return txns, nil
+}
+
+func (r *fakeRelayer) GetNonce(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, space *big.Int, blockNum *big.Int) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (r *fakeRelayer) Relay(ctx context.Context, signedTxs *sequence.SignedTransactions) (sequence.MetaTxnID, *types.Transaction, ethtxn.WaitReceipt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.relayed = append(r.relayed, signedTxs)
+	return sequence.MetaTxnID(signedTxs.Digest.Hex()[2:]), nil, nil, nil
+}
+
+func (r *fakeRelayer) Wait(ctx context.Context, metaTxnID sequence.MetaTxnID, optTimeout ...time.Duration) (sequence.MetaTxnStatus, *types.Receipt, error) {
+	return sequence.MetaTxnExecuted, nil, nil
+}
+
+func (r *fakeRelayer) FeeOptions(ctx context.Context, signedTxs *sequence.SignedTransactions) ([]*sequence.FeeOption, error) {
+	return nil, nil
+}
+
+func TestEngine(t *testing.T) {
+	ctx := context.Background()
+
+	price := int64(90)
+	chain, eoa := newChain(t, 10, &price)
+	provider := chain.Provider()
+
+	relayer := &fakeRelayer{provider: provider}
+	key, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(key)
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.Connect(provider, relayer))
+	sign := func(nonce int64) *sequence.SignedTransactions {
+		signed, err := wallet.SignTransactionsWithNonce(ctx, sequence.Transactions{{To: common.HexToAddress("0x01"), Value: big.NewInt(nonce), GasLimit: big.NewInt(50000)}}, big.NewInt(nonce))
+		assert.NoError(t, err)
+		return signed
+	}
+
+	fires := make(chan trigger.Fire, 10)
+	engine := trigger.NewEngine(provider, relayer, trigger.Options{
+		PollInterval: time.Millisecond,
+		OnFire:       func(fire trigger.Fire) { fires <- fire },
+	})
+	assert.NoError(t, engine.Add(&trigger.Trigger{
+		ID:        "deposit",
+		Condition: &trigger.EventEmitted{Topics: [][]common.Hash{{depositTopic}}},
+		Bundles:   []*sequence.SignedTransactions{sign(0)},
+	}))
+	assert.NoError(t, engine.Add(&trigger.Trigger{
+		ID:        "price",
+		Condition: &trigger.PriceCrosses{Feed: feed, Threshold: big.NewInt(100), Above: true},
+		Mode:      trigger.Repeating,
+		Bundles:   []*sequence.SignedTransactions{sign(1), sign(2)},
+	}))
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go engine.Run(runCtx)
+	time.Sleep(10 * time.Millisecond)
+	assert.Len(t, fires, 0)
+
+	// the deposit fires once
+	chain.Mine()
+	txn, err := eoa.NewTransaction(ctx, &ethtxn.TransactionRequest{To: &depositor})
+	assert.NoError(t, err)
+	_, _, err = eoa.SendTransaction(ctx, txn)
+	assert.NoError(t, err)
+	fire := <-fires
+	assert.Equal(t, "deposit", fire.TriggerID)
+	assert.Equal(t, uint64(12), fire.Block)
+	assert.NoError(t, fire.Err)
+	assert.Equal(t, []string{"price"}, engine.Armed())
+
+	// the price fires as it crosses the threshold, not while it stays above it
+	atomic.StoreInt64(&price, 110)
+	chain.Mine()
+	fire = <-fires
+	assert.Equal(t, "price", fire.TriggerID)
+	assert.Equal(t, 0, fire.Bundle)
+	chain.Mine()
+	time.Sleep(10 * time.Millisecond)
+	assert.Len(t, fires, 0)
+
+	atomic.StoreInt64(&price, 90)
+	chain.Mine()
+	time.Sleep(10 * time.Millisecond)
+	atomic.StoreInt64(&price, 120)
+	chain.Mine()
+	fire = <-fires
+	assert.Equal(t, 1, fire.Bundle)
+	assert.Empty(t, engine.Armed())
+
+	relayer.mu.Lock()
+	defer relayer.mu.Unlock()
+	assert.Len(t, relayer.relayed, 3)
+	assert.Equal(t, big.NewInt(2), relayer.relayed[2].Nonce)
+}
+
+func TestBalanceChanged(t *testing.T) {
+	ctx := context.Background()
+
+	chain := memchain.New(memchain.Options{})
+	account := common.HexToAddress("0xa")
+
+	condition := &trigger.BalanceChanged{Account: account, MinChange: big.NewInt(100)}
+	check := func(balance int64) bool {
+		chain.SetBalance(account, big.NewInt(balance))
+		fired, err := condition.Check(ctx, chain.Provider(), 1, 1)
+		assert.NoError(t, err)
+		return fired
+	}
+
+	assert.False(t, check(1000))
+	assert.False(t, check(1050))
+	assert.True(t, check(1100))
+	assert.False(t, check(1150))
+	assert.True(t, check(900))
+}
@@ -0,0 +1,211 @@
+package sequence
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/goware/cachestore"
+	"github.com/goware/cachestore/memlru"
+)
+
+const defaultTokenUnitsCacheSize = 1000
+
+// NativeTokenDecimals are the decimals of the native token of EVM chains.
+const NativeTokenDecimals = 18
+
+// nativeUnits are the units of the native token accepted by TokenUnits.Parse.
+var nativeUnits = map[string]uint8{
+	"eth":   NativeTokenDecimals,
+	"ether": NativeTokenDecimals,
+	"gwei":  9,
+	"wei":   0,
+}
+
+var erc20DecimalsSelector = ethcoder.Keccak256([]byte("decimals()"))[:4]
+
+// FormatUnits formats amount, in the smallest unit of a token with decimals, as a decimal
+// string without trailing zeros, ie. FormatUnits(1500000, 6) is "1.5".
+func FormatUnits(amount *big.Int, decimals uint8) string {
+	if amount == nil {
+		return "0"
+	}
+	sign := ""
+	if amount.Sign() < 0 {
+		sign = "-"
+	}
+	digits := new(big.Int).Abs(amount).String()
+	if decimals == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= int(decimals) {
+		digits = strings.Repeat("0", int(decimals)-len(digits)+1) + digits
+	}
+	whole, frac := digits[:len(digits)-int(decimals)], strings.TrimRight(digits[len(digits)-int(decimals):], "0")
+	if frac == "" {
+		return sign + whole
+	}
+	return sign + whole + "." + frac
+}
+
+// ParseUnits parses the decimal string s, ie. "1.5", into the smallest unit of a token with
+// decimals. Amounts with more fractional digits than decimals are rejected, instead of
+// being rounded.
+func ParseUnits(s string, decimals uint8) (*big.Int, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), "_", "")
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return nil, fmt.Errorf("sequence: invalid amount %q", s)
+	}
+	if len(frac) > int(decimals) {
+		return nil, fmt.Errorf("sequence: amount %q has more than %d decimals", s, decimals)
+	}
+	for _, part := range []string{whole, frac} {
+		if strings.Trim(part, "0123456789") != "" {
+			return nil, fmt.Errorf("sequence: invalid amount %q", s)
+		}
+	}
+
+	amount, ok := new(big.Int).SetString("0"+whole+frac+strings.Repeat("0", int(decimals)-len(frac)), 10)
+	if !ok {
+		return nil, fmt.Errorf("sequence: invalid amount %q", s)
+	}
+	if neg {
+		amount.Neg(amount)
+	}
+	return amount, nil
+}
+
+// TokenUnits formats and parses token amounts, with the decimals of tokens fetched from
+// their contracts and cached. The native token is the zero address.
+type TokenUnits struct {
+	provider *ethrpc.Provider
+	cache    cachestore.Store[[]byte]
+
+	symbols map[string]common.Address
+	names   map[common.Address]string
+	mu      sync.RWMutex
+}
+
+func NewTokenUnits(provider *ethrpc.Provider, opts ...Option) *TokenUnits {
+	options := NewOptions(opts...)
+
+	cache := options.Cache
+	if cache == nil {
+		cache, _ = memlru.NewWithSize[[]byte](defaultTokenUnitsCacheSize)
+	}
+
+	return &TokenUnits{
+		provider: provider,
+		cache:    cache,
+		symbols:  map[string]common.Address{},
+		names:    map[common.Address]string{},
+	}
+}
+
+// RegisterToken lets Parse accept amounts of token by symbol, ie. "250 usdc". Symbols are
+// case-insensitive. Registering the zero address names the native token of the chain.
+func (u *TokenUnits) RegisterToken(symbol string, token common.Address) *TokenUnits {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.symbols[strings.ToLower(symbol)] = token
+	u.names[token] = symbol
+	return u
+}
+
+// Decimals returns the decimals of token, calling its decimals() method once per chain.
+func (u *TokenUnits) Decimals(ctx context.Context, token common.Address) (uint8, error) {
+	if token == (common.Address{}) {
+		return NativeTokenDecimals, nil
+	}
+
+	chainID, err := u.provider.ChainID(ctx)
+	if err != nil {
+		return 0, err
+	}
+	key := fmt.Sprintf("decimals::%d::%v", chainID, token)
+	if val, exists, _ := u.cache.Get(ctx, key); exists && len(val) == 1 {
+		return val[0], nil
+	}
+
+	res, err := u.provider.CallContract(ctx, ethereum.CallMsg{To: &token, Data: erc20DecimalsSelector}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("sequence: failed to get decimals of %v: %w", token, err)
+	}
+	var decimals uint8
+	if err := ethcoder.AbiDecoder([]string{"uint8"}, res, []interface{}{&decimals}); err != nil {
+		return 0, fmt.Errorf("sequence: failed to decode decimals of %v: %w", token, err)
+	}
+
+	_ = u.cache.Set(ctx, key, []byte{decimals})
+	return decimals, nil
+}
+
+// Format formats amount of token, followed by its symbol if it is registered.
+func (u *TokenUnits) Format(ctx context.Context, token common.Address, amount *big.Int) (string, error) {
+	decimals, err := u.Decimals(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	formatted := FormatUnits(amount, decimals)
+
+	u.mu.RLock()
+	symbol, ok := u.names[token]
+	u.mu.RUnlock()
+	if ok {
+		formatted += " " + symbol
+	}
+	return formatted, nil
+}
+
+// Parse parses an amount followed by its unit, ie. "1.5 eth", "10 gwei", "250 usdc" or
+// "250 0x...", returning the token and the amount in its smallest unit.
+func (u *TokenUnits) Parse(ctx context.Context, s string) (common.Address, *big.Int, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return common.Address{}, nil, fmt.Errorf("sequence: amount %q must be a number followed by a unit", s)
+	}
+	value, unit := fields[0], strings.ToLower(fields[1])
+
+	u.mu.RLock()
+	token, registered := u.symbols[unit]
+	u.mu.RUnlock()
+	nativeDecimals, native := nativeUnits[unit]
+
+	var decimals uint8
+	switch {
+	case registered:
+		var err error
+		decimals, err = u.Decimals(ctx, token)
+		if err != nil {
+			return common.Address{}, nil, err
+		}
+	case native:
+		decimals = nativeDecimals
+	case common.IsHexAddress(unit):
+		token = common.HexToAddress(unit)
+		var err error
+		decimals, err = u.Decimals(ctx, token)
+		if err != nil {
+			return common.Address{}, nil, err
+		}
+	default:
+		return common.Address{}, nil, fmt.Errorf("sequence: unknown unit %q", fields[1])
+	}
+
+	amount, err := ParseUnits(value, decimals)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	return token, amount, nil
+}
@@ -0,0 +1,84 @@
+package sequence_test
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatUnits(t *testing.T) {
+	assert.Equal(t, "1.5", sequence.FormatUnits(big.NewInt(1_500_000), 6))
+	assert.Equal(t, "250", sequence.FormatUnits(big.NewInt(250_000_000), 6))
+	assert.Equal(t, "0.000001", sequence.FormatUnits(big.NewInt(1), 6))
+	assert.Equal(t, "-0.01", sequence.FormatUnits(big.NewInt(-10_000), 6))
+	assert.Equal(t, "42", sequence.FormatUnits(big.NewInt(42), 0))
+	assert.Equal(t, "0", sequence.FormatUnits(nil, 18))
+
+	for _, s := range []string{"1.5", "0.000001", "250", "-3.25", ".5", "1_000"} {
+		amount, err := sequence.ParseUnits(s, 6)
+		assert.NoError(t, err, s)
+		parsed, _ := sequence.ParseUnits(sequence.FormatUnits(amount, 6), 6)
+		assert.Equal(t, amount, parsed, s)
+	}
+	amount, err := sequence.ParseUnits("1.5", 18)
+	assert.NoError(t, err)
+	assert.Equal(t, "1500000000000000000", amount.String())
+
+	for _, s := range []string{"", ".", "1.0000001", "1e6", "0x10", "1.2.3", "abc"} {
+		_, err := sequence.ParseUnits(s, 6)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestTokenUnits(t *testing.T) {
+	usdc := common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+	var decimalsCalls int32
+	chain := memchain.New(memchain.Options{ChainID: big.NewInt(1)})
+	chain.Deploy(usdc, memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		atomic.AddInt32(&decimalsCalls, 1)
+		return common.BigToHash(big.NewInt(6)).Bytes(), nil
+	}))
+	provider := chain.Provider()
+
+	units := sequence.NewTokenUnits(provider).RegisterToken("USDC", usdc)
+	ctx := context.Background()
+
+	token, amount, err := units.Parse(ctx, "250 usdc")
+	assert.NoError(t, err)
+	assert.Equal(t, usdc, token)
+	assert.Equal(t, "250000000", amount.String())
+
+	token, amount, err = units.Parse(ctx, "1.5 eth")
+	assert.NoError(t, err)
+	assert.Equal(t, common.Address{}, token)
+	assert.Equal(t, "1500000000000000000", amount.String())
+
+	_, amount, err = units.Parse(ctx, "30 gwei")
+	assert.NoError(t, err)
+	assert.Equal(t, "30000000000", amount.String())
+
+	token, amount, err = units.Parse(ctx, "0.5 "+usdc.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, usdc, token)
+	assert.Equal(t, "500000", amount.String())
+
+	formatted, err := units.Format(ctx, usdc, big.NewInt(1_250_000))
+	assert.NoError(t, err)
+	assert.Equal(t, "1.25 USDC", formatted)
+
+	// decimals are fetched once per token
+	assert.Equal(t, int32(1), atomic.LoadInt32(&decimalsCalls))
+
+	_, _, err = units.Parse(ctx, "1.0000001 usdc")
+	assert.Error(t, err)
+	_, _, err = units.Parse(ctx, "10 dai")
+	assert.Error(t, err)
+	_, _, err = units.Parse(ctx, "10")
+	assert.Error(t, err)
+}
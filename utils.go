@@ -7,7 +7,7 @@ import (
 	"github.com/0xsequence/ethkit/ethwallet"
 	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/0xsequence/ethkit/go-ethereum/core/types"
-	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/0xsequence/go-sequence/contracts/gen/walletfactory"
 )
 
 var zeroAddress = common.Address{}
@@ -57,7 +57,7 @@ func EncodeWalletDeployment(walletConfig WalletConfig, walletContext WalletConte
 		return common.Address{}, common.Address{}, nil, err
 	}
 
-	deployData, err := contracts.WalletFactory.ABI.Pack("deploy", walletContext.MainModuleAddress, common.HexToHash(walletImageHash))
+	deployData, err := walletfactory.WalletFactoryCalldata.Deploy(walletContext.MainModuleAddress, common.HexToHash(walletImageHash))
 	if err != nil {
 		return common.Address{}, common.Address{}, nil, err
 	}
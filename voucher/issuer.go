@@ -0,0 +1,71 @@
+package voucher
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+)
+
+// Issuer signs the vouchers of a wallet for a claim contract.
+type Issuer struct {
+	wallet *sequence.Wallet
+	claim  ClaimContract
+	now    func() time.Time
+}
+
+func NewIssuer(wallet *sequence.Wallet, claim ClaimContract) *Issuer {
+	return &Issuer{wallet: wallet, claim: claim, now: time.Now}
+}
+
+// SetClock replaces the clock vouchers expire by, ie. in tests.
+func (i *Issuer) SetClock(now func() time.Time) *Issuer {
+	i.now = now
+	return i
+}
+
+// Issue signs v. Its Issuer defaults to the wallet, and its Nonce to a random one.
+func (i *Issuer) Issue(v Voucher) (*SignedVoucher, error) {
+	if v.Issuer == (common.Address{}) {
+		v.Issuer = i.wallet.Address()
+	} else if v.Issuer != i.wallet.Address() {
+		return nil, fmt.Errorf("voucher: voucher is issued by %v, not %v", v.Issuer.Hex(), i.wallet.Address().Hex())
+	}
+	if v.Expired(i.now()) {
+		return nil, fmt.Errorf("%w: at %v", ErrExpired, time.Unix(int64(v.Expiry), 0))
+	}
+	if v.Nonce == nil {
+		nonce, err := randomNonce()
+		if err != nil {
+			return nil, err
+		}
+		v.Nonce = nonce
+	}
+
+	sig, _, err := i.wallet.SignDigest(v.Digest(i.claim), i.claim.ChainID)
+	if err != nil {
+		return nil, fmt.Errorf("voucher: failed to sign voucher: %w", err)
+	}
+	return &SignedVoucher{Voucher: v, Signature: sig}, nil
+}
+
+// IssueBatch signs vouchers, ie. the coupons of a campaign, failing if any can't be issued or
+// two are the same voucher, which could only be redeemed once.
+func (i *Issuer) IssueBatch(vouchers []Voucher) ([]*SignedVoucher, error) {
+	signed := make([]*SignedVoucher, 0, len(vouchers))
+	digests := make(map[common.Hash]int, len(vouchers))
+	for n, v := range vouchers {
+		sv, err := i.Issue(v)
+		if err != nil {
+			return nil, fmt.Errorf("voucher: failed to issue voucher %d: %w", n, err)
+		}
+		voucherDigest := sv.Digest(i.claim)
+		if first, ok := digests[voucherDigest]; ok {
+			return nil, fmt.Errorf("voucher: vouchers %d and %d are the same", first, n)
+		}
+		digests[voucherDigest] = n
+		signed = append(signed, sv)
+	}
+	return signed, nil
+}
@@ -0,0 +1,112 @@
+package voucher
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/replayguard"
+)
+
+// Status is the status of a voucher.
+type Status int
+
+const (
+	// StatusIssued vouchers can be redeemed.
+	StatusIssued Status = iota
+	StatusRedeemed
+	StatusExpired
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusIssued:
+		return "issued"
+	case StatusRedeemed:
+		return "redeemed"
+	case StatusExpired:
+		return "expired"
+	default:
+		return fmt.Sprintf("Status(%d)", int(s))
+	}
+}
+
+// Redemption is a voucher redeemed on the claim contract.
+type Redemption struct {
+	Digest   common.Hash
+	Issuer   common.Address
+	Redeemer common.Address
+
+	TxnHash     common.Hash
+	BlockNumber uint64
+}
+
+// Tracker tracks the redemptions of a claim contract by scanning its logs, which it records in
+// a store, ie. a replayguard.SQLStore shared by the backends checking the status of vouchers.
+type Tracker struct {
+	source sequence.BlockSource
+	claim  ClaimContract
+	store  replayguard.Store
+}
+
+func NewTracker(source sequence.BlockSource, claim ClaimContract, store replayguard.Store) *Tracker {
+	return &Tracker{source: source, claim: claim, store: store}
+}
+
+// Scan records the redemptions from block from to block to, inclusive, and returns them.
+// Ranges may be scanned again, ie. after a failure. Removed logs are ignored, so ranges should
+// be scanned once they are final.
+func (t *Tracker) Scan(ctx context.Context, from, to uint64) ([]*Redemption, error) {
+	logs, err := t.source.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+		Addresses: []common.Address{t.claim.Address},
+		Topics:    [][]common.Hash{{VoucherRedeemedEventSig}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("voucher: failed to get logs: %w", err)
+	}
+
+	var redemptions []*Redemption
+	for _, log := range logs {
+		if log.Removed || len(log.Topics) != 4 {
+			continue
+		}
+		redemption := &Redemption{
+			Digest:      log.Topics[1],
+			Issuer:      common.BytesToAddress(log.Topics[2][:]),
+			Redeemer:    common.BytesToAddress(log.Topics[3][:]),
+			TxnHash:     log.TxHash,
+			BlockNumber: log.BlockNumber,
+		}
+		if _, err := t.store.Consume(ctx, redemption.Digest, time.Time{}); err != nil {
+			return nil, err
+		}
+		redemptions = append(redemptions, redemption)
+	}
+	return redemptions, nil
+}
+
+// Redeemed reports whether a redemption of the voucher of voucherDigest was scanned.
+func (t *Tracker) Redeemed(ctx context.Context, voucherDigest common.Hash) (bool, error) {
+	return t.store.Consumed(ctx, voucherDigest)
+}
+
+// Status returns the status of v at now, as of the scanned redemptions.
+func (t *Tracker) Status(ctx context.Context, v *Voucher, now time.Time) (Status, error) {
+	redeemed, err := t.Redeemed(ctx, v.Digest(t.claim))
+	if err != nil {
+		return 0, err
+	}
+	if redeemed {
+		return StatusRedeemed, nil
+	}
+	if v.Expired(now) {
+		return StatusExpired, nil
+	}
+	return StatusIssued, nil
+}
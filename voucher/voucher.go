@@ -0,0 +1,284 @@
+// Package voucher mints vouchers, ie. coupons or claimable drops, which are EIP-712 typed data
+// signed by the Sequence wallet issuing them, and redeemed on-chain by a claim contract which
+// validates the signature of the issuer with ERC-1271 and pays the redeemer.
+//
+// An Issuer signs vouchers, one or in batches, which are handed out off-chain. ClaimTransaction
+// builds the transaction redeeming a voucher, sent in a bundle of any wallet, and a Tracker
+// scans the chain for the redemptions of the claim contract:
+//
+//	issuer := voucher.NewIssuer(wallet, claim)
+//	signed, err := issuer.IssueBatch(vouchers)
+//	...
+//	txn, err := voucher.ClaimTransaction(claim, signed[0])
+//
+// The claim contract is expected to implement:
+//
+//	function claim(Voucher calldata voucher, bytes calldata signature) external;
+//	function redeemed(bytes32 digest) external view returns (bool);
+//	event VoucherRedeemed(bytes32 indexed digest, address indexed issuer, address indexed redeemer);
+//
+// where claim reverts unless the voucher is signed by its issuer, was not redeemed, is redeemed
+// by its recipient if it has one, and block.timestamp is before its expiry if it has one.
+package voucher
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/core/digest"
+	"github.com/0xsequence/go-sequence/replayguard"
+)
+
+var (
+	ErrExpired          = errors.New("voucher: voucher expired")
+	ErrInvalidSignature = errors.New("voucher: invalid signature")
+)
+
+var (
+	// VoucherTypeHash is the EIP-712 type of vouchers.
+	VoucherTypeHash = crypto.Keccak256Hash([]byte(voucherType))
+
+	// VoucherRedeemedEventSig is the topic of the event claim contracts emit when a voucher
+	// is redeemed.
+	VoucherRedeemedEventSig = crypto.Keccak256Hash([]byte("VoucherRedeemed(bytes32,address,address)"))
+
+	domainTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+)
+
+const voucherType = "Voucher(address issuer,address recipient,address token,uint256 tokenId,uint256 amount,uint256 nonce,uint64 expiry)"
+
+var claimABI = mustParseABI(`[
+	{"type":"function","name":"claim","stateMutability":"nonpayable","inputs":[{"name":"voucher","type":"tuple","components":[
+		{"name":"issuer","type":"address"},{"name":"recipient","type":"address"},{"name":"token","type":"address"},
+		{"name":"tokenId","type":"uint256"},{"name":"amount","type":"uint256"},{"name":"nonce","type":"uint256"},
+		{"name":"expiry","type":"uint64"}]},{"name":"signature","type":"bytes"}],"outputs":[]},
+	{"type":"function","name":"redeemed","stateMutability":"view","inputs":[{"name":"digest","type":"bytes32"}],"outputs":[{"name":"","type":"bool"}]}
+]`)
+
+func mustParseABI(s string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(s))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// ClaimContract is the EIP-712 domain of a claim contract.
+type ClaimContract struct {
+	Address common.Address
+	ChainID *big.Int
+
+	// Name and Version are the name and version the contract was deployed with. Version
+	// defaults to "1".
+	Name    string
+	Version string
+}
+
+func (c ClaimContract) version() string {
+	if c.Version == "" {
+		return "1"
+	}
+	return c.Version
+}
+
+func (c ClaimContract) domainSeparator() common.Hash {
+	return crypto.Keccak256Hash(
+		domainTypeHash[:],
+		crypto.Keccak256([]byte(c.Name)),
+		crypto.Keccak256([]byte(c.version())),
+		uint256(c.ChainID),
+		common.LeftPadBytes(c.Address[:], 32),
+	)
+}
+
+// Voucher entitles its redeemer to Amount of TokenID of Token, paid by the claim contract on
+// behalf of Issuer.
+type Voucher struct {
+	Issuer common.Address `json:"issuer"`
+
+	// Recipient is the only address which can redeem the voucher, or anyone if zero.
+	Recipient common.Address `json:"recipient"`
+
+	// Token is the contract of the token paid, or zero for the native token. TokenID is the
+	// id of ERC-721 and ERC-1155 tokens.
+	Token   common.Address `json:"token"`
+	TokenID *big.Int       `json:"tokenId"`
+	Amount  *big.Int       `json:"amount"`
+
+	// Nonce tells apart vouchers which are otherwise the same, which Issuer randomizes if nil.
+	Nonce *big.Int `json:"nonce"`
+
+	// Expiry is the unix time from which the voucher can't be redeemed, or zero if it never
+	// expires.
+	Expiry uint64 `json:"expiry"`
+}
+
+// Digest returns the digest the issuer signs, which identifies the voucher on the claim
+// contract.
+func (v *Voucher) Digest(claim ClaimContract) common.Hash {
+	structHash := crypto.Keccak256Hash(
+		VoucherTypeHash[:],
+		common.LeftPadBytes(v.Issuer[:], 32),
+		common.LeftPadBytes(v.Recipient[:], 32),
+		common.LeftPadBytes(v.Token[:], 32),
+		uint256(v.TokenID),
+		uint256(v.Amount),
+		uint256(v.Nonce),
+		uint256(new(big.Int).SetUint64(v.Expiry)),
+	)
+	return digest.TypedDataDigest(claim.domainSeparator(), structHash)
+}
+
+// TypedData returns the typed data of the voucher, as signed with eth_signTypedData_v4.
+func (v *Voucher) TypedData(claim ClaimContract) *digest.TypedData {
+	return &digest.TypedData{
+		Types: map[string][]digest.TypedDataField{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"Voucher": {
+				{Name: "issuer", Type: "address"},
+				{Name: "recipient", Type: "address"},
+				{Name: "token", Type: "address"},
+				{Name: "tokenId", Type: "uint256"},
+				{Name: "amount", Type: "uint256"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "expiry", Type: "uint64"},
+			},
+		},
+		PrimaryType: "Voucher",
+		Domain: map[string]interface{}{
+			"name":              claim.Name,
+			"version":           claim.version(),
+			"chainId":           bigOrZero(claim.ChainID).String(),
+			"verifyingContract": claim.Address.Hex(),
+		},
+		Message: map[string]interface{}{
+			"issuer":    v.Issuer.Hex(),
+			"recipient": v.Recipient.Hex(),
+			"token":     v.Token.Hex(),
+			"tokenId":   bigOrZero(v.TokenID).String(),
+			"amount":    bigOrZero(v.Amount).String(),
+			"nonce":     bigOrZero(v.Nonce).String(),
+			"expiry":    fmt.Sprint(v.Expiry),
+		},
+	}
+}
+
+// Expired reports whether the voucher can't be redeemed at now anymore.
+func (v *Voucher) Expired(now time.Time) bool {
+	return v.Expiry != 0 && now.Unix() >= int64(v.Expiry)
+}
+
+// SignedVoucher is a voucher with the signature of its issuer.
+type SignedVoucher struct {
+	Voucher
+	Signature []byte `json:"signature"`
+}
+
+// Message returns the signed message of the voucher, so a replayguard.Registry redeems it
+// once, ie. when vouchers are redeemed off-chain by a backend.
+func (v *SignedVoucher) Message(claim ClaimContract) replayguard.Message {
+	msg := replayguard.Message{Wallet: v.Issuer, Digest: v.Digest(claim), Signature: v.Signature}
+	if v.Expiry != 0 {
+		msg.ExpiresAt = time.Unix(int64(v.Expiry), 0)
+	}
+	return msg
+}
+
+// Verify returns ErrInvalidSignature if the voucher is not signed by its issuer, or ErrExpired
+// if it expired at now, without redeeming it. The signature is validated with provider, see
+// sequence.IsValidSignatureWith.
+func Verify(ctx context.Context, provider *ethrpc.Provider, walletContext sequence.WalletContext, claim ClaimContract, v *SignedVoucher, now time.Time) error {
+	if v.Expired(now) {
+		return fmt.Errorf("%w: at %v", ErrExpired, time.Unix(int64(v.Expiry), 0))
+	}
+	valid, err := sequence.IsValidSignatureWith(ctx, provider, walletContext, v.Issuer, v.Digest(claim), v.Signature)
+	if !valid {
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ClaimTransaction returns the Sequence transaction redeeming the voucher on the claim
+// contract, to be sent in a bundle of its recipient, or of any wallet if it has none.
+func ClaimTransaction(claim ClaimContract, v *SignedVoucher) (*sequence.Transaction, error) {
+	type voucherData struct {
+		Issuer    common.Address
+		Recipient common.Address
+		Token     common.Address
+		TokenId   *big.Int
+		Amount    *big.Int
+		Nonce     *big.Int
+		Expiry    uint64
+	}
+	data, err := claimABI.Pack("claim", voucherData{
+		Issuer:    v.Issuer,
+		Recipient: v.Recipient,
+		Token:     v.Token,
+		TokenId:   bigOrZero(v.TokenID),
+		Amount:    bigOrZero(v.Amount),
+		Nonce:     bigOrZero(v.Nonce),
+		Expiry:    v.Expiry,
+	}, v.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("voucher: failed to encode claim: %w", err)
+	}
+	return &sequence.Transaction{
+		To:            claim.Address,
+		Data:          data,
+		RevertOnError: true,
+	}, nil
+}
+
+// IsRedeemed reports whether the voucher of voucherDigest was redeemed on the claim contract.
+func IsRedeemed(ctx context.Context, provider *ethrpc.Provider, claim ClaimContract, voucherDigest common.Hash) (bool, error) {
+	data, err := claimABI.Pack("redeemed", voucherDigest)
+	if err != nil {
+		return false, err
+	}
+	res, err := provider.CallContract(ctx, ethereum.CallMsg{To: &claim.Address, Data: data}, nil)
+	if err != nil {
+		return false, fmt.Errorf("voucher: redeemed failed: %w", err)
+	}
+	if len(res) != 32 {
+		return false, fmt.Errorf("voucher: invalid redeemed result")
+	}
+	return res[31] == 1, nil
+}
+
+func randomNonce() (*big.Int, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, fmt.Errorf("voucher: failed to generate nonce: %w", err)
+	}
+	return new(big.Int).SetBytes(b[:]), nil
+}
+
+func uint256(n *big.Int) []byte {
+	return common.LeftPadBytes(bigOrZero(n).Bytes(), 32)
+}
+
+func bigOrZero(n *big.Int) *big.Int {
+	if n == nil {
+		return new(big.Int)
+	}
+	return n
+}
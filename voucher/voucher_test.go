@@ -0,0 +1,127 @@
+package voucher_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/replayguard"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/0xsequence/go-sequence/voucher"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticLogs []types.Log
+
+func (s staticLogs) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	var logs []types.Log
+	for _, log := range s {
+		if log.BlockNumber >= query.FromBlock.Uint64() && log.BlockNumber <= query.ToBlock.Uint64() {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func TestVoucher(t *testing.T) {
+	ctx := context.Background()
+
+	// a chain on which no wallet is deployed
+	provider := memchain.New(memchain.Options{ChainID: big.NewInt(1)}).Provider()
+
+	owner, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(owner)
+	assert.NoError(t, err)
+
+	claim := voucher.ClaimContract{Address: common.HexToAddress("0xc1a1"), ChainID: big.NewInt(1), Name: "Claims"}
+	now := time.Unix(1700000000, 0)
+	issuer := voucher.NewIssuer(wallet, claim).SetClock(func() time.Time { return now })
+
+	// a batch of vouchers is issued, with random nonces
+	coupon := voucher.Voucher{Token: common.HexToAddress("0x70ce"), Amount: big.NewInt(100), Expiry: uint64(now.Add(time.Hour).Unix())}
+	recipient := coupon
+	recipient.Recipient = common.HexToAddress("0xb0b0")
+	signed, err := issuer.IssueBatch([]voucher.Voucher{coupon, coupon, recipient})
+	assert.NoError(t, err)
+	assert.Len(t, signed, 3)
+	assert.Equal(t, wallet.Address(), signed[0].Issuer)
+	assert.NotEqual(t, signed[0].Digest(claim), signed[1].Digest(claim))
+
+	// the digest is the one of the typed data
+	typedDigest, err := signed[2].TypedData(claim).Digest()
+	assert.NoError(t, err)
+	assert.Equal(t, signed[2].Digest(claim), typedDigest)
+
+	// the vouchers are signed by the wallet
+	for _, sv := range signed {
+		assert.NoError(t, voucher.Verify(ctx, provider, wallet.GetWalletContext(), claim, sv, now))
+	}
+	forged := *signed[0]
+	forged.Amount = big.NewInt(1000)
+	err = voucher.Verify(ctx, provider, wallet.GetWalletContext(), claim, &forged, now)
+	assert.True(t, errors.Is(err, voucher.ErrInvalidSignature), err)
+	err = voucher.Verify(ctx, provider, wallet.GetWalletContext(), claim, signed[0], now.Add(time.Hour))
+	assert.True(t, errors.Is(err, voucher.ErrExpired), err)
+
+	// vouchers which can't be redeemed aren't issued
+	_, err = issuer.Issue(voucher.Voucher{Expiry: uint64(now.Unix())})
+	assert.True(t, errors.Is(err, voucher.ErrExpired), err)
+	_, err = issuer.Issue(voucher.Voucher{Issuer: common.HexToAddress("0x1551e5")})
+	assert.Error(t, err)
+	duplicate := coupon
+	duplicate.Nonce = big.NewInt(7)
+	_, err = issuer.IssueBatch([]voucher.Voucher{duplicate, duplicate})
+	assert.Error(t, err)
+
+	// the claim transaction calls the claim contract
+	txn, err := voucher.ClaimTransaction(claim, signed[0])
+	assert.NoError(t, err)
+	assert.Equal(t, claim.Address, txn.To)
+	assert.True(t, txn.RevertOnError)
+
+	// vouchers redeemed off-chain are redeemed once
+	registry := replayguard.NewRegistry(replayguard.NewMemoryStore(), provider, claim.ChainID, wallet.GetWalletContext()).SetClock(func() time.Time { return now })
+	assert.NoError(t, registry.Verify(ctx, signed[1].Message(claim)))
+	err = registry.Verify(ctx, signed[1].Message(claim))
+	assert.True(t, errors.Is(err, replayguard.ErrReplayed), err)
+
+	// the redemptions on-chain are tracked
+	redeemed := signed[2].Digest(claim)
+	tracker := voucher.NewTracker(staticLogs{
+		{
+			Address:     claim.Address,
+			Topics:      []common.Hash{voucher.VoucherRedeemedEventSig, redeemed, common.BytesToHash(wallet.Address().Bytes()), common.BytesToHash(recipient.Recipient.Bytes())},
+			TxHash:      common.HexToHash("0x7777"),
+			BlockNumber: 10,
+		},
+	}, claim, replayguard.NewMemoryStore())
+
+	status, err := tracker.Status(ctx, &signed[2].Voucher, now)
+	assert.NoError(t, err)
+	assert.Equal(t, voucher.StatusIssued, status)
+
+	redemptions, err := tracker.Scan(ctx, 1, 20)
+	assert.NoError(t, err)
+	if assert.Len(t, redemptions, 1) {
+		assert.Equal(t, redeemed, redemptions[0].Digest)
+		assert.Equal(t, wallet.Address(), redemptions[0].Issuer)
+		assert.Equal(t, recipient.Recipient, redemptions[0].Redeemer)
+		assert.Equal(t, uint64(10), redemptions[0].BlockNumber)
+	}
+
+	status, err = tracker.Status(ctx, &signed[2].Voucher, now)
+	assert.NoError(t, err)
+	assert.Equal(t, voucher.StatusRedeemed, status)
+	status, err = tracker.Status(ctx, &signed[0].Voucher, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, voucher.StatusExpired, status)
+}
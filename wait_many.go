@@ -0,0 +1,287 @@
+package sequence
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethreceipts"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+// WaitResult is the outcome of one of the meta-transactions waited for by WaitForMany.
+type WaitResult struct {
+	MetaTxnID MetaTxnID
+
+	// Result is the status of the meta-transaction, and Receipt the receipt of the native
+	// transaction it was mined in. Both are nil if Err is set.
+	Result  *MetaTxnResult
+	Receipt *ethreceipts.Receipt
+	Err     error
+}
+
+// WaitForMany waits for all of metaTxnIDs with a single subscription to receiptListener,
+// instead of one subscription and goroutine per meta-transaction, and sends the result of
+// each on the returned channel as soon as it is mined. The channel is closed once all of them
+// are resolved. If the wait times out first, the ones still outstanding are sent with the
+// error of ctx.
+//
+// Receipts are confirmed and reorgs are handled as by WaitMetaTransactionReceipt, with all
+// the receipts waiting for confirmations checked together every waitOptions.PollInterval.
+func WaitForMany(ctx context.Context, receiptListener *ethreceipts.ReceiptsListener, provider *ethrpc.Provider, metaTxnIDs []MetaTxnID, waitOptions WaitOptions, optTimeout ...time.Duration) <-chan WaitResult {
+	w := &manyWaiter{
+		provider:    provider,
+		waitOptions: waitOptions,
+		waiting:     map[common.Hash]MetaTxnID{},
+		confirming:  map[common.Hash]*WaitResult{},
+		reorged:     map[common.Hash][]common.Hash{},
+		results:     make(chan WaitResult, len(metaTxnIDs)),
+	}
+	for _, metaTxnID := range metaTxnIDs {
+		w.waiting[common.HexToHash(string(metaTxnID))] = metaTxnID
+	}
+
+	ctx, cancel := withWaitTimeout(ctx, optTimeout)
+	go func() {
+		defer cancel()
+		w.run(ctx, receiptListener)
+	}()
+	return w.results
+}
+
+type manyWaiter struct {
+	provider    *ethrpc.Provider
+	waitOptions WaitOptions
+
+	// waiting are the meta-transactions which are not mined yet, or were reorged out, and
+	// confirming those which are mined and waiting for confirmations. Both are read by the
+	// filter of the subscription, from the goroutine of the receipts listener.
+	waiting    map[common.Hash]MetaTxnID
+	confirming map[common.Hash]*WaitResult
+	reorged    map[common.Hash][]common.Hash
+	mu         sync.Mutex
+
+	results chan WaitResult
+}
+
+func (w *manyWaiter) run(ctx context.Context, receiptListener *ethreceipts.ReceiptsListener) {
+	defer close(w.results)
+
+	if w.outstanding() == 0 {
+		return
+	}
+
+	sub := receiptListener.Subscribe(ethreceipts.FilterLogs(w.match).SearchCache(true).MaxWait(0))
+	defer sub.Unsubscribe()
+
+	var confirm <-chan time.Time
+	if w.waitOptions.Confirmations > 0 {
+		pollInterval := w.waitOptions.PollInterval
+		if pollInterval <= 0 {
+			pollInterval = defaultConfirmationsPollInterval
+		}
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		confirm = ticker.C
+	}
+
+	for w.outstanding() > 0 {
+		select {
+		case <-ctx.Done():
+			w.failAll(ctx.Err())
+			return
+
+		case <-sub.Done():
+			w.failAll(ethreceipts.ErrSubscriptionClosed)
+			return
+
+		case receipt, ok := <-sub.TransactionReceipt():
+			if !ok {
+				w.failAll(ethreceipts.ErrSubscriptionClosed)
+				return
+			}
+			w.onReceipt(receipt)
+
+		case <-confirm:
+			w.confirm(ctx)
+		}
+	}
+}
+
+func (w *manyWaiter) outstanding() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.waiting) + len(w.confirming)
+}
+
+// match is the filter of the subscription, matching the receipts of meta-transactions which
+// are waited for, or whose receipt may be reorged out.
+func (w *manyWaiter) match(logs []*types.Log) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, log := range logs {
+		metaTxnHash, ok := metaTxnHashOfLog(log)
+		if !ok {
+			continue
+		}
+		if _, ok := w.confirming[metaTxnHash]; ok {
+			return true
+		}
+		if _, ok := w.waiting[metaTxnHash]; ok && !w.isReorged(metaTxnHash, log.BlockHash) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *manyWaiter) onReceipt(receipt ethreceipts.Receipt) {
+	var statuses []WaitResult
+
+	w.mu.Lock()
+	seen := map[common.Hash]struct{}{}
+	for _, log := range receipt.Logs() {
+		metaTxnHash, ok := metaTxnHashOfLog(log)
+		if !ok {
+			continue
+		}
+		if _, ok := seen[metaTxnHash]; ok {
+			continue
+		}
+		seen[metaTxnHash] = struct{}{}
+
+		if receipt.Reorged {
+			pending, ok := w.confirming[metaTxnHash]
+			if ok && pending.Receipt.BlockHash() == receipt.BlockHash() {
+				w.reorg(metaTxnHash, pending)
+				statuses = append(statuses, WaitResult{MetaTxnID: pending.MetaTxnID, Result: &MetaTxnResult{MetaTxnID: pending.MetaTxnID, Status: MetaTxnReorged}})
+			}
+			continue
+		}
+
+		metaTxnID, ok := w.waiting[metaTxnHash]
+		if !ok || w.isReorged(metaTxnHash, receipt.BlockHash()) {
+			continue
+		}
+		delete(w.waiting, metaTxnHash)
+
+		receipt := receipt
+		result := WaitResult{
+			MetaTxnID: metaTxnID,
+			Result:    metaTxnResultOf(metaTxnID, metaTxnHash, receipt.Logs()),
+			Receipt:   &receipt,
+		}
+		statuses = append(statuses, result)
+
+		if w.waitOptions.Confirmations == 0 {
+			w.results <- result
+		} else {
+			w.confirming[metaTxnHash] = &result
+		}
+	}
+	w.mu.Unlock()
+
+	w.notify(statuses)
+}
+
+// confirm sends the results of the receipts which have enough confirmations, and resumes
+// waiting for those whose block is no longer canonical.
+func (w *manyWaiter) confirm(ctx context.Context) {
+	head, err := w.provider.BlockNumber(ctx)
+	if err != nil {
+		// retried on the next tick
+		return
+	}
+
+	w.mu.Lock()
+	confirming := make(map[common.Hash]*WaitResult, len(w.confirming))
+	for metaTxnHash, result := range w.confirming {
+		confirming[metaTxnHash] = result
+	}
+	w.mu.Unlock()
+
+	var statuses []WaitResult
+	for metaTxnHash, result := range confirming {
+		receipt := result.Receipt.Receipt()
+		if head < receipt.BlockNumber.Uint64()+w.waitOptions.Confirmations {
+			continue
+		}
+
+		err := checkReceiptCanonical(ctx, w.provider, receipt)
+		if err != nil && !errors.Is(err, ErrReceiptReorged) {
+			continue
+		}
+
+		w.mu.Lock()
+		if w.confirming[metaTxnHash] == result {
+			if err == nil {
+				delete(w.confirming, metaTxnHash)
+				w.results <- *result
+			} else {
+				w.reorg(metaTxnHash, result)
+				statuses = append(statuses, WaitResult{MetaTxnID: result.MetaTxnID, Result: &MetaTxnResult{MetaTxnID: result.MetaTxnID, Status: MetaTxnReorged}})
+			}
+		}
+		w.mu.Unlock()
+	}
+
+	w.notify(statuses)
+}
+
+// reorg moves a mined meta-transaction back to waiting, ignoring the block it was mined in
+// from now on. It must be called with mu held.
+func (w *manyWaiter) reorg(metaTxnHash common.Hash, result *WaitResult) {
+	delete(w.confirming, metaTxnHash)
+	w.waiting[metaTxnHash] = result.MetaTxnID
+	w.reorged[metaTxnHash] = append(w.reorged[metaTxnHash], result.Receipt.BlockHash())
+}
+
+// isReorged returns true if metaTxnHash was reorged out of the block of blockHash. It must be
+// called with mu held.
+func (w *manyWaiter) isReorged(metaTxnHash common.Hash, blockHash common.Hash) bool {
+	for _, reorged := range w.reorged[metaTxnHash] {
+		if reorged == blockHash {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *manyWaiter) notify(statuses []WaitResult) {
+	if w.waitOptions.OnStatus == nil {
+		return
+	}
+	for _, status := range statuses {
+		w.waitOptions.OnStatus(status.MetaTxnID, status.Result.Status)
+	}
+}
+
+func (w *manyWaiter) failAll(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for metaTxnHash, metaTxnID := range w.waiting {
+		w.results <- WaitResult{MetaTxnID: metaTxnID, Err: err}
+		delete(w.waiting, metaTxnHash)
+	}
+	for metaTxnHash, result := range w.confirming {
+		w.results <- WaitResult{MetaTxnID: result.MetaTxnID, Err: err}
+		delete(w.confirming, metaTxnHash)
+	}
+}
+
+// metaTxnHashOfLog returns the metaTxnID of a TxExecuted or TxFailed event, matching the same
+// logs as IsTxExecutedEvent and IsTxFailedEvent do.
+func metaTxnHashOfLog(log *types.Log) (common.Hash, bool) {
+	switch {
+	case len(log.Topics) == 0 && len(log.Data) == 32:
+		return common.BytesToHash(log.Data), true
+	case len(log.Topics) == 1 && log.Topics[0] == TxFailedEventSig && len(log.Data) >= 32:
+		return common.BytesToHash(log.Data[:32]), true
+	default:
+		return common.Hash{}, false
+	}
+}
@@ -27,6 +27,13 @@ type WalletOptions struct {
 	// Address used for the wallet
 	// if this value is defined, the address derived from the sequence config is ignored
 	Address common.Address
+
+	// Options configures the logger, metrics and wait defaults of the wallet.
+	Options []Option
+
+	// Signers are the signers of the wallet whose keys are not held in-process, ie. by a KMS,
+	// in addition to the ethwallet signers passed to NewWallet, see Signer.
+	Signers []Signer
 }
 
 func NewWallet(walletOptions WalletOptions, signers ...*ethwallet.Wallet) (*Wallet, error) {
@@ -67,8 +74,11 @@ func NewWallet(walletOptions WalletOptions, signers ...*ethwallet.Wallet) (*Wall
 		context:         context,
 		address:         address,
 		skipSortSigners: walletOptions.SkipSortSigners,
+		opts:            walletOptions.Options,
+		options:         NewOptions(walletOptions.Options...),
 	}
 	w.signers = signers
+	w.remoteSigners = walletOptions.Signers
 
 	return w, nil
 }
@@ -95,12 +105,21 @@ type Wallet struct {
 	config  WalletConfig
 	signers []*ethwallet.Wallet // NOTE: only supports EOA signers at this time
 
+	// remoteSigners are the EOA signers whose keys are held elsewhere, see Signer
+	remoteSigners []Signer
+
 	provider *ethrpc.Provider
 	relayer  Relayer
 	address  common.Address
 
 	skipSortSigners bool
 
+	// pendingNonces are the nonces imported as pending with the wallet, see ImportWallet
+	pendingNonces []*big.Int
+
+	opts    []Option
+	options Options
+
 	chainID *big.Int
 }
 
@@ -116,11 +135,13 @@ func (w *Wallet) UseConfig(config WalletConfig) (*Wallet, error) {
 		Context:         &w.context,
 		SkipSortSigners: w.skipSortSigners,
 		Address:         w.address,
+		Options:         w.opts,
 	})
 
 	if err != nil {
 		return nil, fmt.Errorf("sequence.Wallet#UseConfig: %w", err)
 	}
+	ww.pendingNonces = w.pendingNonces
 	if w.provider != nil {
 		err = ww.SetProvider(w.provider)
 		if err != nil {
@@ -142,11 +163,13 @@ func (w *Wallet) UseSigners(signers ...*ethwallet.Wallet) (*Wallet, error) {
 		Context:         &w.context,
 		SkipSortSigners: w.skipSortSigners,
 		Address:         w.address,
+		Options:         w.opts,
 	})
 
 	if err != nil {
 		return nil, fmt.Errorf("sequence.Wallet#UseSigners: %w", err)
 	}
+	ww.pendingNonces = w.pendingNonces
 	if w.provider != nil {
 		err = ww.SetProvider(w.provider)
 		if err != nil {
@@ -160,6 +183,7 @@ func (w *Wallet) UseSigners(signers ...*ethwallet.Wallet) (*Wallet, error) {
 		}
 	}
 	ww.signers = signers
+	ww.remoteSigners = w.remoteSigners
 	return ww, nil
 }
 
@@ -233,11 +257,17 @@ func (w *Wallet) GetSignerAddresses() []common.Address {
 	for _, s := range w.signers {
 		as = append(as, s.Address())
 	}
+	for _, s := range w.remoteSigners {
+		as = append(as, s.Address())
+	}
 	return as
 }
 
 func (w *Wallet) IsSignerAvailable(address common.Address) bool {
-	_, ok := w.GetSigner(address)
+	if _, ok := w.GetSigner(address); ok {
+		return true
+	}
+	_, ok := w.getRemoteSigner(address)
 	return ok
 }
 
@@ -264,14 +294,34 @@ func (w *Wallet) GetSignerWeight() *big.Int {
 }
 
 func (w *Wallet) GetNonce(optBlockNum ...*big.Int) (*big.Int, error) {
-	if w.relayer == nil {
-		return nil, ErrRelayerNotSet
+	var blockNum *big.Int
+	if len(optBlockNum) > 0 {
+		blockNum = optBlockNum[0]
 	}
+	return w.getNonce(context.Background(), nil, blockNum)
+}
+
+// GetNonceInSpace returns the nonce of the wallet in the nonce space space, encoded with its
+// space, see EncodeNonce.
+func (w *Wallet) GetNonceInSpace(space *big.Int, optBlockNum ...*big.Int) (*big.Int, error) {
 	var blockNum *big.Int
 	if len(optBlockNum) > 0 {
 		blockNum = optBlockNum[0]
 	}
-	return w.relayer.GetNonce(context.Background(), w.config, w.context, nil, blockNum)
+	return w.getNonce(context.Background(), space, blockNum)
+}
+
+// getNonce returns the nonce of the wallet in space with the relayer of ctx, at blockNum or
+// else the block the call is pinned to, see CallOpts.
+func (w *Wallet) getNonce(ctx context.Context, space *big.Int, blockNum *big.Int) (*big.Int, error) {
+	relayer := w.relayerOf(ctx)
+	if relayer == nil {
+		return nil, ErrRelayerNotSet
+	}
+	if blockNum == nil {
+		blockNum = callBlockNumber(ctx)
+	}
+	return relayer.GetNonce(ctx, w.config, w.context, space, blockNum)
 }
 
 func (w *Wallet) GetTransactionCount(optBlockNum ...*big.Int) (*big.Int, error) {
@@ -285,6 +335,10 @@ func (w *Wallet) SignMessage(msg []byte) ([]byte, *Signature, error) {
 // func (w *Wallet) SignTypedData() // TODO
 
 func (w *Wallet) SignDigest(digest common.Hash, optChainID ...*big.Int) ([]byte, *Signature, error) {
+	return w.signDigest(context.Background(), digest, optChainID...)
+}
+
+func (w *Wallet) signDigest(ctx context.Context, digest common.Hash, optChainID ...*big.Int) ([]byte, *Signature, error) {
 	if (optChainID == nil && len(optChainID) == 0) && w.chainID == nil {
 		return nil, nil, fmt.Errorf("sequence.Wallet#SignDigest: %w", ErrUnknownChainID)
 	}
@@ -307,29 +361,11 @@ func (w *Wallet) SignDigest(digest common.Hash, optChainID ...*big.Int) ([]byte,
 	}
 
 	for _, signerInfo := range w.config.Signers {
-		signer, _ := w.GetSigner(signerInfo.Address)
-
-		if signer == nil {
-			// signer isn't available, just include the config value of address
-			// without it's signature
-			sig.Signers = append(sig.Signers, &SignaturePart{
-				Type: SignaturePartTypeAddress, Weight: signerInfo.Weight, Address: signerInfo.Address,
-			})
-			continue
-		}
-
-		// TODO: in future add support for Sequence Signers, right now we only support EOA
-		// signers in go-sequence
-
-		sigValue, err := signer.SignMessage(subDigest)
+		part, err := w.signPart(ctx, signerInfo, subDigest)
 		if err != nil {
-			return nil, nil, fmt.Errorf("signer.SignMessage subDigest: %w", err)
+			return nil, nil, err
 		}
-		sigValue = append(sigValue, SignatureTypeEthSign)
-
-		sig.Signers = append(sig.Signers, &SignaturePart{
-			Type: SignaturePartTypeEOA, Weight: signerInfo.Weight, Address: signer.Address(), Value: sigValue,
-		})
+		sig.Signers = append(sig.Signers, part)
 	}
 
 	encodedSig, err := sig.Encode()
@@ -340,6 +376,12 @@ func (w *Wallet) SignDigest(digest common.Hash, optChainID ...*big.Int) ([]byte,
 	return encodedSig, sig, nil
 }
 
+// ValidateTransactions checks txns before they are signed by the wallet, against the
+// TransactionLimits of its options.
+func (w *Wallet) ValidateTransactions(txns Transactions) error {
+	return txns.ValidateLimits(w.options.TransactionLimits)
+}
+
 func (w *Wallet) SignTransaction(ctx context.Context, txn *Transaction) (*SignedTransactions, error) {
 	return w.SignTransactions(ctx, Transactions{txn})
 }
@@ -348,7 +390,47 @@ func (w *Wallet) SignTransactions(ctx context.Context, txns Transactions) (*Sign
 	if len(txns) == 0 {
 		return nil, fmt.Errorf("cannot sign an empty set of transactions")
 	}
+	if err := w.ValidateTransactions(txns); err != nil {
+		return nil, err
+	}
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	// load nonce from transactions
+	nonce, err := txns.Nonce()
+	if err != nil {
+		return nil, fmt.Errorf("cannot load nonce from transactions: %w", err)
+	}
 
+	// if nonce is undefined
+	// load latest nonce from wallet
+	if nonce == nil {
+		nonce, err = w.getNonce(ctx, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		nonce = w.nextNonce(nonce)
+	}
+
+	return w.signTransactions(ctx, txns, nonce)
+}
+
+// SignTransactionsWithNonce signs txns as a bundle of the wallet with nonce, encoded with its
+// nonce space, instead of the next nonce of GetNonce. Bundles in distinct nonce spaces can be
+// executed in any order, see GenerateRandomNonce.
+func (w *Wallet) SignTransactionsWithNonce(ctx context.Context, txns Transactions, nonce *big.Int) (*SignedTransactions, error) {
+	if len(txns) == 0 {
+		return nil, fmt.Errorf("cannot sign an empty set of transactions")
+	}
+	if err := w.ValidateTransactions(txns); err != nil {
+		return nil, err
+	}
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+	return w.signTransactions(ctx, txns, nonce)
+}
+
+func (w *Wallet) signTransactions(ctx context.Context, txns Transactions, nonce *big.Int) (*SignedTransactions, error) {
 	var err error
 
 	// If a transaction has 0 gasLimit and not revertOnError
@@ -361,24 +443,13 @@ func (w *Wallet) SignTransactions(ctx context.Context, txns Transactions) (*Sign
 		}
 	}
 	if estimateGas {
-		txns, err = w.relayer.EstimateGasLimits(ctx, w.config, w.context, txns)
-		if err != nil {
-			return nil, fmt.Errorf("estimateGas failed for sequence transactions: %w", err)
+		relayer := w.relayerOf(ctx)
+		if relayer == nil {
+			return nil, ErrRelayerNotSet
 		}
-	}
-
-	// load nonce from transactions
-	nonce, err := txns.Nonce()
-	if err != nil {
-		return nil, fmt.Errorf("cannot load nonce from transactions: %w", err)
-	}
-
-	// if nonce is undefined
-	// load latest nonce from wallet
-	if nonce == nil {
-		nonce, err = w.GetNonce()
+		txns, err = relayer.EstimateGasLimits(ctx, w.config, w.context, txns)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("estimateGas failed for sequence transactions: %w", err)
 		}
 	}
 
@@ -394,16 +465,17 @@ func (w *Wallet) SignTransactions(ctx context.Context, txns Transactions) (*Sign
 	}
 
 	// Sign the transactions
-	sig, _, err := w.SignDigest(digest)
+	sig, _, err := w.signDigest(ctx, digest)
 	if err != nil {
 		return nil, err
 	}
 
+	// the bundle is copied, so the caller mutating txns does not affect what was signed
 	return &SignedTransactions{
 		ChainID:       w.chainID,
-		WalletConfig:  w.config,
+		WalletConfig:  w.config.Clone(),
 		WalletContext: w.context,
-		Transactions:  txns,
+		Transactions:  txns.Clone(),
 		Nonce:         nonce,
 		Digest:        digest,
 		Signature:     sig,
@@ -415,10 +487,56 @@ func (w *Wallet) SendTransaction(ctx context.Context, signedTxns *SignedTransact
 }
 
 func (w *Wallet) SendTransactions(ctx context.Context, signedTxns *SignedTransactions) (MetaTxnID, *types.Transaction, ethtxn.WaitReceipt, error) {
-	if w.relayer == nil {
+	relayer := w.relayerOf(ctx)
+	if relayer == nil {
 		return "", nil, nil, ErrRelayerNotSet
 	}
-	return w.relayer.Relay(ctx, signedTxns)
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	metaTxnID, tx, waitReceipt, err := relayer.Relay(ctx, signedTxns)
+	if err != nil {
+		w.options.Metrics.IncCounter("wallet.send_transactions.error")
+		w.options.Logger.Warnf("sequence.Wallet: failed to relay transactions for %s: %v", w.address.Hex(), err)
+		return metaTxnID, tx, waitReceipt, err
+	}
+	w.options.Metrics.IncCounter("wallet.send_transactions")
+	w.options.Logger.Debugf("sequence.Wallet: relayed metaTxnID %s for %s", metaTxnID, w.address.Hex())
+	return metaTxnID, tx, waitReceipt, nil
+}
+
+// BuildDeployAndExecute returns the `to` address and execdata of signedTxns, ie. to relay them
+// with a native transaction of one's own. While the wallet isn't deployed, they are bundled with
+// its deployment by the guest module, see EncodeDeployAndExecute, else they are sent to the wallet.
+func (w *Wallet) BuildDeployAndExecute(ctx context.Context, signedTxns *SignedTransactions) (common.Address, []byte, error) {
+	provider := w.providerOf(ctx)
+	if provider == nil {
+		return common.Address{}, nil, ErrProviderNotSet
+	}
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	code, err := provider.CodeAt(ctx, w.address, callBlockNumber(ctx))
+	if err != nil {
+		return common.Address{}, nil, fmt.Errorf("sequence.Wallet#BuildDeployAndExecute: %w", err)
+	}
+	if len(code) > 2 {
+		return encodeWalletExecute(w.address, signedTxns.Transactions, signedTxns.Nonce, signedTxns.Signature)
+	}
+
+	initial, err := AddressFromWalletConfig(signedTxns.WalletConfig, signedTxns.WalletContext)
+	if err != nil {
+		return common.Address{}, nil, fmt.Errorf("sequence.Wallet#BuildDeployAndExecute: %w", err)
+	}
+	if initial != w.address {
+		return common.Address{}, nil, fmt.Errorf("sequence.Wallet#BuildDeployAndExecute: the wallet is not deployed, and the transactions are not signed by its initial config")
+	}
+
+	to, execdata, err := EncodeDeployAndExecute(signedTxns.WalletConfig, signedTxns.WalletContext, signedTxns.Transactions, signedTxns.Nonce, signedTxns.Signature)
+	if err != nil {
+		return common.Address{}, nil, fmt.Errorf("sequence.Wallet#BuildDeployAndExecute: %w", err)
+	}
+	return to, execdata, nil
 }
 
 func (w *Wallet) IsDeployed() (bool, error) {
@@ -428,15 +546,11 @@ func (w *Wallet) IsDeployed() (bool, error) {
 	return IsWalletDeployed(w.provider, w.Address())
 }
 
-// func (w *Wallet) UpdateConfig() // TODO in future
-
-// func (w *Wallet) PublishConfig() // TODO in future
-
 func (w *Wallet) IsValidSignature(digest common.Hash, signature []byte) (bool, error) {
 	if w.provider == nil {
 		return false, ErrProviderNotSet
 	}
-	return IsValidSignature(w.Address(), digest, signature, w.context, w.chainID, w.provider)
+	return IsValidSignatureWith(context.Background(), w.provider, w.context, w.Address(), digest, signature)
 }
 
 func IsWalletDeployed(provider *ethrpc.Provider, walletAddress common.Address) (bool, error) {
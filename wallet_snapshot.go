@@ -0,0 +1,156 @@
+package sequence
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+)
+
+// WalletSnapshotVersion is the version of the snapshots written by Wallet.Export.
+const WalletSnapshotVersion = 1
+
+// WalletSnapshot is everything needed to reconstruct a Wallet in another service, except for
+// its signers, provider and relayer. It is written by Wallet.Export and read by ImportWallet.
+type WalletSnapshot struct {
+	Version         int            `json:"version"`
+	Address         common.Address `json:"address"`
+	Config          WalletConfig   `json:"config"`
+	Context         WalletContext  `json:"context"`
+	SkipSortSigners bool           `json:"skipSortSigners,omitempty"`
+
+	// ChainID is the chain the wallet was bound to, if any.
+	ChainID *hexutil.Big `json:"chainId,omitempty"`
+
+	// PendingNonces are the nonces of the bundles of the wallet which were relayed, but not
+	// mined yet, so the imported wallet does not sign other bundles with them.
+	PendingNonces []*hexutil.Big `json:"pendingNonces,omitempty"`
+}
+
+// PendingNonceTracker is implemented by relayers which track the bundles they relayed until
+// they are mined, ie. relayer.LocalRelayer.
+type PendingNonceTracker interface {
+	PendingNonces(wallet common.Address) []*big.Int
+}
+
+// Export serializes the wallet into a WalletSnapshot, with the pending nonces of the wallet
+// and those tracked by its relayer, if it implements PendingNonceTracker.
+func (w *Wallet) Export() ([]byte, error) {
+	snapshot := WalletSnapshot{
+		Version:         WalletSnapshotVersion,
+		Address:         w.address,
+		Config:          w.config.Clone(),
+		Context:         w.context,
+		SkipSortSigners: w.skipSortSigners,
+	}
+	if w.chainID != nil {
+		snapshot.ChainID = (*hexutil.Big)(new(big.Int).Set(w.chainID))
+	}
+	for _, nonce := range w.PendingNonces() {
+		snapshot.PendingNonces = append(snapshot.PendingNonces, (*hexutil.Big)(nonce))
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("sequence.Wallet#Export: %w", err)
+	}
+	return data, nil
+}
+
+// ImportWallet reconstructs a wallet from a snapshot written by Wallet.Export, with signers,
+// which must all be signers of its config. The provider and relayer of the wallet must be set
+// again.
+func ImportWallet(data []byte, signers ...*ethwallet.Wallet) (*Wallet, error) {
+	var snapshot WalletSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("sequence.ImportWallet: %w", err)
+	}
+	if snapshot.Version != WalletSnapshotVersion {
+		return nil, fmt.Errorf("sequence.ImportWallet: unsupported snapshot version %d", snapshot.Version)
+	}
+	if snapshot.Address == (common.Address{}) {
+		return nil, fmt.Errorf("sequence.ImportWallet: snapshot has no address")
+	}
+
+	for _, signer := range signers {
+		found := false
+		for _, s := range snapshot.Config.Signers {
+			if s.Address == signer.Address() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("sequence.ImportWallet: signer %v is not in the wallet config", signer.Address().Hex())
+		}
+	}
+
+	w, err := NewWallet(WalletOptions{
+		Config:          snapshot.Config,
+		Context:         &snapshot.Context,
+		SkipSortSigners: snapshot.SkipSortSigners,
+		Address:         snapshot.Address,
+	}, signers...)
+	if err != nil {
+		return nil, fmt.Errorf("sequence.ImportWallet: %w", err)
+	}
+	if snapshot.ChainID != nil {
+		w.SetChainID(snapshot.ChainID.ToInt())
+	}
+	for _, nonce := range snapshot.PendingNonces {
+		w.pendingNonces = append(w.pendingNonces, nonce.ToInt())
+	}
+	return w, nil
+}
+
+// PendingNonces returns the nonces of the bundles of the wallet which are known to be relayed
+// but not mined, in ascending order: those imported with the wallet, and those tracked by its
+// relayer.
+func (w *Wallet) PendingNonces() []*big.Int {
+	seen := map[string]bool{}
+	var nonces []*big.Int
+	add := func(nonce *big.Int) {
+		if !seen[nonce.String()] {
+			seen[nonce.String()] = true
+			nonces = append(nonces, new(big.Int).Set(nonce))
+		}
+	}
+
+	for _, nonce := range w.pendingNonces {
+		add(nonce)
+	}
+	if tracker, ok := w.relayer.(PendingNonceTracker); ok {
+		for _, nonce := range tracker.PendingNonces(w.address) {
+			add(nonce)
+		}
+	}
+
+	sort.Slice(nonces, func(i, j int) bool {
+		return nonces[i].Cmp(nonces[j]) < 0
+	})
+	return nonces
+}
+
+// nextNonce returns the first nonce from nonce on in its space which was not imported as
+// pending with the wallet. Nonces tracked by the relayer are not skipped, as they are only
+// forgotten once their bundles are mined, after the wallet nonce was already incremented.
+func (w *Wallet) nextNonce(nonce *big.Int) *big.Int {
+	space, _ := DecodeNonce(nonce)
+	next := nonce
+	pending := make([]*big.Int, len(w.pendingNonces))
+	copy(pending, w.pendingNonces)
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Cmp(pending[j]) < 0
+	})
+	for _, p := range pending {
+		pendingSpace, _ := DecodeNonce(p)
+		if pendingSpace.Cmp(space) == 0 && p.Cmp(next) == 0 {
+			next = new(big.Int).Add(p, big.NewInt(1))
+		}
+	}
+	return next
+}
@@ -0,0 +1,75 @@
+package sequence_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/stretchr/testify/assert"
+)
+
+// nonceRelayer is a relayer which only serves the nonce of wallets, and the nonces of the
+// bundles it relayed which are pending.
+type nonceRelayer struct {
+	sequence.Relayer
+	nonce   *big.Int
+	pending []*big.Int
+}
+
+func (r *nonceRelayer) GetNonce(ctx context.Context, walletConfig sequence.WalletConfig, walletContext sequence.WalletContext, space *big.Int, blockNum *big.Int) (*big.Int, error) {
+	return r.nonce, nil
+}
+
+func (r *nonceRelayer) PendingNonces(wallet common.Address) []*big.Int {
+	return r.pending
+}
+
+func TestWalletExportImport(t *testing.T) {
+	owner1, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+	owner2, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+	other, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+
+	wallet, err := sequence.NewWallet(sequence.WalletOptions{
+		Config: sequence.WalletConfig{
+			Threshold: 2,
+			Signers: sequence.WalletConfigSigners{
+				{Weight: 1, Address: owner1.Address()},
+				{Weight: 1, Address: owner2.Address()},
+			},
+		},
+	}, owner1, owner2)
+	assert.NoError(t, err)
+	wallet.SetChainID(big.NewInt(137))
+	assert.NoError(t, wallet.SetRelayer(&nonceRelayer{nonce: big.NewInt(5), pending: []*big.Int{big.NewInt(6), big.NewInt(5)}}))
+	assert.Equal(t, []*big.Int{big.NewInt(5), big.NewInt(6)}, wallet.PendingNonces())
+
+	data, err := wallet.Export()
+	assert.NoError(t, err)
+
+	imported, err := sequence.ImportWallet(data, owner2)
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.Address(), imported.Address())
+	assert.Equal(t, wallet.GetWalletConfig(), imported.GetWalletConfig())
+	assert.Equal(t, wallet.GetWalletContext(), imported.GetWalletContext())
+	assert.Equal(t, big.NewInt(137), imported.GetChainID())
+	assert.Equal(t, []common.Address{owner2.Address()}, imported.GetSignerAddresses())
+	assert.Equal(t, []*big.Int{big.NewInt(5), big.NewInt(6)}, imported.PendingNonces())
+
+	// bundles are not signed with the nonces which were pending when the wallet was exported
+	assert.NoError(t, imported.SetRelayer(&nonceRelayer{nonce: big.NewInt(5)}))
+	signed, err := imported.SignTransactions(context.Background(), sequence.Transactions{{
+		To:            common.HexToAddress("0xb0b0"),
+		RevertOnError: true,
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(7), signed.Nonce)
+
+	_, err = sequence.ImportWallet(data, other)
+	assert.ErrorContains(t, err, "is not in the wallet config")
+}
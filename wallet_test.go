@@ -1,6 +1,7 @@
 package sequence_test
 
 import (
+	"context"
 	"math/big"
 	"sort"
 	"testing"
@@ -266,3 +267,20 @@ func TestWalletWithNonDeterministicConfig(t *testing.T) {
 	assert.Equal(t, nwallet.Address(), randomAddr)
 	assert.Equal(t, nwallet.GetWalletConfig(), newConfig)
 }
+
+func TestWalletSignTransactionsCopiesBundle(t *testing.T) {
+	eoa, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(eoa)
+	assert.NoError(t, err)
+	wallet.SetChainID(big.NewInt(1))
+
+	txns := sequence.Transactions{{To: common.HexToAddress("0x01"), Value: big.NewInt(1), GasLimit: big.NewInt(21000), Nonce: big.NewInt(0)}}
+	signed, err := wallet.SignTransactions(context.Background(), txns)
+	assert.NoError(t, err)
+
+	// mutating the transactions after signing does not change the signed bundle
+	txns[0].Value.SetInt64(1000)
+	assert.NoError(t, signed.Verify())
+	assert.Equal(t, int64(1), signed.Transactions[0].Value.Int64())
+}
@@ -0,0 +1,166 @@
+package sequence
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/0xsequence/go-sequence/contracts/gen/walletutils"
+)
+
+// The wallet utils contract of a wallet context (SequenceUtils) combines RequireUtils, which
+// publishes wallet configs on-chain and asserts on the state of a wallet, and MultiCallUtils,
+// which batches reads into a single eth_call.
+
+// PublishConfigTransaction returns a transaction which publishes the config of the wallet on
+// the wallet utils contract, so it can be recovered from the chain with its image hash. If
+// index is set, the signers of the config are also indexed, so the wallets of a signer can be
+// looked up.
+func (w *Wallet) PublishConfigTransaction(index bool) (*Transaction, error) {
+	members := make([]walletutils.RequireUtilsMember, 0, len(w.config.Signers))
+	for _, signer := range w.config.Signers {
+		members = append(members, walletutils.RequireUtilsMember{
+			Weight: big.NewInt(int64(signer.Weight)),
+			Signer: signer.Address,
+		})
+	}
+
+	data, err := walletutils.WalletUtilsCalldata.PublishConfig(w.address, big.NewInt(int64(w.config.Threshold)), members, index)
+	if err != nil {
+		return nil, fmt.Errorf("sequence.Wallet#PublishConfigTransaction: %w", err)
+	}
+	return &Transaction{To: w.context.UtilsAddress, Data: data, RevertOnError: true}, nil
+}
+
+// PublishInitialSignersTransaction returns a transaction which publishes the signers of the
+// counterfactual config of the wallet, ie. before it is deployed, proven by a signature of the
+// wallet. The wallet must have signers for enough weight to sign.
+func (w *Wallet) PublishInitialSignersTransaction(index bool) (*Transaction, error) {
+	imageHash, err := w.ImageHash()
+	if err != nil {
+		return nil, fmt.Errorf("sequence.Wallet#PublishInitialSignersTransaction: %w", err)
+	}
+
+	// any digest will do, as long as the wallet signs it
+	digest := MessageDigest(imageHash.Bytes())
+	signature, _, err := w.SignDigest(digest)
+	if err != nil {
+		return nil, fmt.Errorf("sequence.Wallet#PublishInitialSignersTransaction: %w", err)
+	}
+
+	data, err := walletutils.WalletUtilsCalldata.PublishInitialSigners(w.address, digest, big.NewInt(int64(len(w.config.Signers))), signature, index)
+	if err != nil {
+		return nil, fmt.Errorf("sequence.Wallet#PublishInitialSignersTransaction: %w", err)
+	}
+	return &Transaction{To: w.context.UtilsAddress, Data: data, RevertOnError: true}, nil
+}
+
+// RequireNonExpired returns a transaction of the wallet which reverts after expiration.
+func (w *Wallet) RequireNonExpired(expiration time.Time) (*Transaction, error) {
+	return RequireNonExpired(w.context, expiration)
+}
+
+// RequireMinNonce returns a transaction of the wallet which reverts if its nonce, with its
+// encoded space, is below nonce.
+func (w *Wallet) RequireMinNonce(nonce *big.Int) (*Transaction, error) {
+	return RequireMinNonce(w.context, w.address, nonce)
+}
+
+// PublishedImageHash returns the last image hash of the wallet published on the wallet utils
+// contract, or the zero hash if none was.
+func (w *Wallet) PublishedImageHash(ctx context.Context) (common.Hash, error) {
+	provider := w.providerOf(ctx)
+	if provider == nil {
+		return common.Hash{}, ErrProviderNotSet
+	}
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	data, err := walletutils.WalletUtilsCalldata.KnownImageHashes(w.address)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	var imageHash common.Hash
+	if err := callWalletUtils(ctx, provider, w.context, data, &imageHash, "knownImageHashes"); err != nil {
+		return common.Hash{}, fmt.Errorf("sequence.Wallet#PublishedImageHash: %w", err)
+	}
+	return imageHash, nil
+}
+
+// LastConfigUpdate returns the block number of the last config of the wallet published on the
+// wallet utils contract, or zero if none was.
+func (w *Wallet) LastConfigUpdate(ctx context.Context) (*big.Int, error) {
+	provider := w.providerOf(ctx)
+	if provider == nil {
+		return nil, ErrProviderNotSet
+	}
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	data, err := walletutils.WalletUtilsCalldata.LastWalletUpdate(w.address)
+	if err != nil {
+		return nil, err
+	}
+	var block *big.Int
+	if err := callWalletUtils(ctx, provider, w.context, data, &block, "lastWalletUpdate"); err != nil {
+		return nil, fmt.Errorf("sequence.Wallet#LastConfigUpdate: %w", err)
+	}
+	return block, nil
+}
+
+// MultiCallResult is the result of a call of MultiCall.
+type MultiCallResult struct {
+	Success bool
+	Result  []byte
+}
+
+// MultiCall reads the results of calls in a single eth_call, with the wallet utils contract of
+// walletContext. The calls can't be delegate calls, and a call which reverts fails alone, with
+// its revert data as its result.
+func MultiCall(ctx context.Context, provider *ethrpc.Provider, walletContext WalletContext, calls Transactions) ([]MultiCallResult, error) {
+	moduleCalls, err := calls.ModuleCalls()
+	if err != nil {
+		return nil, fmt.Errorf("sequence.MultiCall: %w", err)
+	}
+	multiCalls := make([]walletutils.IModuleCallsTransaction, 0, len(moduleCalls))
+	for _, call := range moduleCalls {
+		if call.DelegateCall {
+			return nil, fmt.Errorf("sequence.MultiCall: delegate calls are not supported")
+		}
+		multiCalls = append(multiCalls, walletutils.IModuleCallsTransaction(call))
+	}
+
+	data, err := walletutils.WalletUtilsCalldata.MultiCall(multiCalls)
+	if err != nil {
+		return nil, fmt.Errorf("sequence.MultiCall: %w", err)
+	}
+	var output struct {
+		Successes []bool
+		Results   [][]byte
+	}
+	if err := callWalletUtils(ctx, provider, walletContext, data, &output, "multiCall"); err != nil {
+		return nil, fmt.Errorf("sequence.MultiCall: %w", err)
+	}
+	if len(output.Successes) != len(calls) || len(output.Results) != len(calls) {
+		return nil, fmt.Errorf("sequence.MultiCall: got %d results for %d calls", len(output.Results), len(calls))
+	}
+
+	results := make([]MultiCallResult, len(calls))
+	for i := range results {
+		results[i] = MultiCallResult{Success: output.Successes[i], Result: output.Results[i]}
+	}
+	return results, nil
+}
+
+func callWalletUtils(ctx context.Context, provider *ethrpc.Provider, walletContext WalletContext, data []byte, result interface{}, method string) error {
+	output, err := provider.CallContract(ctx, ethereum.CallMsg{To: &walletContext.UtilsAddress, Data: data}, callBlockNumber(ctx))
+	if err != nil {
+		return err
+	}
+	return contracts.WalletUtils.ABI.UnpackIntoInterface(result, method, output)
+}
@@ -0,0 +1,87 @@
+package sequence_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/contracts"
+	"github.com/0xsequence/go-sequence/testutil"
+	"github.com/0xsequence/go-sequence/testutil/memchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalletUtils(t *testing.T) {
+	imageHash := common.HexToHash("0x1234")
+
+	chain := memchain.New(memchain.Options{ChainID: big.NewInt(1)})
+	chain.Deploy(sequence.SequenceContext().UtilsAddress, memchain.ContractFunc(func(env *memchain.Env, input []byte) ([]byte, error) {
+		method, err := contracts.WalletUtils.ABI.MethodById(input)
+		if err != nil {
+			return nil, err
+		}
+		switch method.Name {
+		case "knownImageHashes":
+			return method.Outputs.Pack(imageHash)
+		case "multiCall":
+			return method.Outputs.Pack([]bool{true, false}, [][]byte{{0x01}, {0x02}})
+		}
+		return nil, memchain.Revert("")
+	}))
+	provider := chain.Provider()
+
+	owner, err := ethwallet.NewWalletFromPrivateKey(testutil.DummyPrivateKey(1))
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(owner)
+	assert.NoError(t, err)
+	assert.NoError(t, wallet.SetProvider(provider))
+
+	// publishing the config
+	txn, err := wallet.PublishConfigTransaction(true)
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.GetWalletContext().UtilsAddress, txn.To)
+	args, err := contracts.WalletUtils.ABI.Methods["publishConfig"].Inputs.Unpack(txn.Data[4:])
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.Address(), args[0])
+	assert.Equal(t, big.NewInt(1), args[1])
+	members, ok := args[2].([]struct {
+		Weight *big.Int       `json:"weight"`
+		Signer common.Address `json:"signer"`
+	})
+	assert.True(t, ok)
+	assert.Equal(t, owner.Address(), members[0].Signer)
+	assert.Equal(t, true, args[3])
+
+	// publishing the initial signers, with a signature of the wallet
+	txn, err = wallet.PublishInitialSignersTransaction(false)
+	assert.NoError(t, err)
+	args, err = contracts.WalletUtils.ABI.Methods["publishInitialSigners"].Inputs.Unpack(txn.Data[4:])
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1), args[2])
+	digest := common.Hash(args[1].([32]byte))
+	subDigest, err := sequence.SubDigest(wallet.GetChainID(), wallet.Address(), digest)
+	assert.NoError(t, err)
+	config, err := sequence.RecoverWalletConfigFromDigest(subDigest, args[3].([]byte), wallet.GetWalletContext(), wallet.GetChainID(), provider)
+	assert.NoError(t, err)
+	signer, err := sequence.AddressFromWalletConfig(config, wallet.GetWalletContext())
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.Address(), signer)
+
+	// reads
+	published, err := wallet.PublishedImageHash(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, imageHash, published)
+
+	results, err := sequence.MultiCall(context.Background(), provider, wallet.GetWalletContext(), sequence.Transactions{
+		{To: common.HexToAddress("0x01"), Data: []byte{0x01}},
+		{To: common.HexToAddress("0x02"), Data: []byte{0x02}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []sequence.MultiCallResult{{Success: true, Result: []byte{0x01}}, {Success: false, Result: []byte{0x02}}}, results)
+
+	_, err = sequence.MultiCall(context.Background(), provider, wallet.GetWalletContext(), sequence.Transactions{{To: common.HexToAddress("0x01"), DelegateCall: true}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,181 @@
+package walletconnect
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Envelope types of encrypted messages. Type 0 messages are encrypted with a key both
+// peers know, type 1 messages also carry the public key of the sender, so the receiver can
+// derive the key.
+const (
+	envelopeType0 = 0
+	envelopeType1 = 1
+)
+
+// SymKey is a symmetric key encrypting the messages of a topic.
+type SymKey [32]byte
+
+// Topic returns the topic of the messages encrypted with k.
+func (k SymKey) Topic() string {
+	hash := sha256.Sum256(k[:])
+	return hex.EncodeToString(hash[:])
+}
+
+// KeyPair is an X25519 key pair used to agree on the key of a session.
+type KeyPair struct {
+	PrivateKey [32]byte
+	PublicKey  [32]byte
+}
+
+func GenerateKeyPair() (*KeyPair, error) {
+	var keyPair KeyPair
+	if _, err := io.ReadFull(rand.Reader, keyPair.PrivateKey[:]); err != nil {
+		return nil, fmt.Errorf("walletconnect: failed to generate key: %w", err)
+	}
+	publicKey, err := curve25519.X25519(keyPair.PrivateKey[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, fmt.Errorf("walletconnect: failed to generate key: %w", err)
+	}
+	copy(keyPair.PublicKey[:], publicKey)
+	return &keyPair, nil
+}
+
+// DeriveSymKey derives the key shared by k and the owner of peerPublicKey.
+func (k *KeyPair) DeriveSymKey(peerPublicKey [32]byte) (SymKey, error) {
+	sharedSecret, err := curve25519.X25519(k.PrivateKey[:], peerPublicKey[:])
+	if err != nil {
+		return SymKey{}, fmt.Errorf("walletconnect: key agreement failed: %w", err)
+	}
+	var symKey SymKey
+	if _, err := io.ReadFull(hkdf.New(sha256.New, sharedSecret, nil, nil), symKey[:]); err != nil {
+		return SymKey{}, fmt.Errorf("walletconnect: key derivation failed: %w", err)
+	}
+	return symKey, nil
+}
+
+// Encrypt seals message into a base64 encoded type 0 envelope.
+func Encrypt(symKey SymKey, message []byte) (string, error) {
+	aead, err := chacha20poly1305.New(symKey[:])
+	if err != nil {
+		return "", fmt.Errorf("walletconnect: %w", err)
+	}
+	envelope := make([]byte, 1+aead.NonceSize(), 1+aead.NonceSize()+len(message)+aead.Overhead())
+	envelope[0] = envelopeType0
+	if _, err := io.ReadFull(rand.Reader, envelope[1:]); err != nil {
+		return "", fmt.Errorf("walletconnect: failed to generate iv: %w", err)
+	}
+	envelope = aead.Seal(envelope, envelope[1:], message, nil)
+	return base64.StdEncoding.EncodeToString(envelope), nil
+}
+
+// Decrypt opens a base64 encoded envelope sealed with symKey.
+func Decrypt(symKey SymKey, encoded string) ([]byte, error) {
+	envelope, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("walletconnect: invalid envelope: %w", err)
+	}
+	if len(envelope) == 0 {
+		return nil, fmt.Errorf("walletconnect: invalid envelope: empty")
+	}
+
+	switch envelope[0] {
+	case envelopeType0:
+		envelope = envelope[1:]
+	case envelopeType1:
+		// the public key of the sender is not needed to open the envelope with a known key
+		if len(envelope) < 33 {
+			return nil, fmt.Errorf("walletconnect: invalid envelope: too short")
+		}
+		envelope = envelope[33:]
+	default:
+		return nil, fmt.Errorf("walletconnect: unsupported envelope type %d", envelope[0])
+	}
+
+	aead, err := chacha20poly1305.New(symKey[:])
+	if err != nil {
+		return nil, fmt.Errorf("walletconnect: %w", err)
+	}
+	if len(envelope) < aead.NonceSize() {
+		return nil, fmt.Errorf("walletconnect: invalid envelope: too short")
+	}
+	message, err := aead.Open(nil, envelope[:aead.NonceSize()], envelope[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("walletconnect: failed to decrypt message: %w", err)
+	}
+	return message, nil
+}
+
+// PairingURI is a pairing shared by a dApp, usually as a QR code, ie.
+// wc:7f6e504bfad60b485450578e05678ed3e8e8c4751d3c6160be17160d63ec90f9@2?relay-protocol=irn&symKey=587d5484ce2a2a6ee3ba1962fdd7e8588e06200c46823bd18fbd67def96ad303
+type PairingURI struct {
+	Topic         string
+	Version       int
+	RelayProtocol string
+	SymKey        SymKey
+	Expiry        time.Time
+}
+
+func ParsePairingURI(uri string) (*PairingURI, error) {
+	if !strings.HasPrefix(uri, "wc:") {
+		return nil, fmt.Errorf("walletconnect: invalid pairing uri: missing wc: scheme")
+	}
+	path, query, _ := strings.Cut(strings.TrimPrefix(uri, "wc:"), "?")
+	topic, version, ok := strings.Cut(path, "@")
+	if !ok || topic == "" {
+		return nil, fmt.Errorf("walletconnect: invalid pairing uri: missing topic")
+	}
+
+	var pairing PairingURI
+	pairing.Topic = topic
+	var err error
+	pairing.Version, err = strconv.Atoi(version)
+	if err != nil || pairing.Version != 2 {
+		return nil, fmt.Errorf("walletconnect: unsupported pairing uri version %q", version)
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("walletconnect: invalid pairing uri: %w", err)
+	}
+	pairing.RelayProtocol = values.Get("relay-protocol")
+	if pairing.RelayProtocol != "irn" {
+		return nil, fmt.Errorf("walletconnect: unsupported relay protocol %q", pairing.RelayProtocol)
+	}
+
+	symKey, err := hex.DecodeString(values.Get("symKey"))
+	if err != nil || len(symKey) != len(pairing.SymKey) {
+		return nil, fmt.Errorf("walletconnect: invalid pairing uri: invalid symKey")
+	}
+	copy(pairing.SymKey[:], symKey)
+
+	if expiry := values.Get("expiryTimestamp"); expiry != "" {
+		seconds, err := strconv.ParseInt(expiry, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("walletconnect: invalid pairing uri: invalid expiryTimestamp")
+		}
+		pairing.Expiry = time.Unix(seconds, 0)
+	}
+
+	return &pairing, nil
+}
+
+func (p *PairingURI) String() string {
+	uri := fmt.Sprintf("wc:%s@%d?relay-protocol=%s&symKey=%s", p.Topic, p.Version, p.RelayProtocol, hex.EncodeToString(p.SymKey[:]))
+	if !p.Expiry.IsZero() {
+		uri += fmt.Sprintf("&expiryTimestamp=%d", p.Expiry.Unix())
+	}
+	return uri
+}
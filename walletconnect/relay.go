@@ -0,0 +1,300 @@
+package walletconnect
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultRelayURL is the url of the WalletConnect relay.
+const DefaultRelayURL = "wss://relay.walletconnect.com"
+
+// Relay publishes messages to topics, and delivers the messages of subscribed topics.
+type Relay interface {
+	Subscribe(ctx context.Context, topic string) error
+	Unsubscribe(ctx context.Context, topic string) error
+	Publish(ctx context.Context, topic, message string, ttl time.Duration, tag int) error
+
+	// SetMessageHandler sets the handler of messages of subscribed topics. Messages are
+	// delivered one at a time, in order.
+	SetMessageHandler(handler func(topic, message string))
+}
+
+// RelayClient is a Relay connected to a WalletConnect relay server over a websocket.
+type RelayClient struct {
+	conn *websocket.Conn
+
+	writeMu sync.Mutex
+
+	mu            sync.Mutex
+	handler       func(topic, message string)
+	pending       map[int64]chan *relayMessage
+	subscriptions map[string]string
+	err           error
+
+	closed chan struct{}
+}
+
+var _ Relay = &RelayClient{}
+
+type relayMessage struct {
+	ID      int64           `json:"id"`
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method,omitempty"`
+	Params  interface{}     `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *relayError     `json:"error,omitempty"`
+}
+
+type relayError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type relaySubscription struct {
+	ID   string `json:"id"`
+	Data struct {
+		Topic   string `json:"topic"`
+		Message string `json:"message"`
+	} `json:"data"`
+}
+
+// DialRelay connects to the relay server at relayURL, authenticating with a key generated
+// for the connection and the WalletConnect projectID.
+func DialRelay(ctx context.Context, relayURL, projectID string) (*RelayClient, error) {
+	auth, err := relayAuthToken(relayURL)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(relayURL)
+	if err != nil {
+		return nil, fmt.Errorf("walletconnect: invalid relay url: %w", err)
+	}
+	query := u.Query()
+	query.Set("auth", auth)
+	query.Set("projectId", projectID)
+	query.Set("ua", "wc-2/go-sequence")
+	u.RawQuery = query.Encode()
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("walletconnect: failed to connect to relay: %w", err)
+	}
+
+	r := &RelayClient{
+		conn:          conn,
+		pending:       map[int64]chan *relayMessage{},
+		subscriptions: map[string]string{},
+		closed:        make(chan struct{}),
+	}
+	go r.read()
+	return r, nil
+}
+
+func (r *RelayClient) SetMessageHandler(handler func(topic, message string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handler = handler
+}
+
+func (r *RelayClient) Subscribe(ctx context.Context, topic string) error {
+	var subscriptionID string
+	if err := r.call(ctx, "irn_subscribe", map[string]interface{}{"topic": topic}, &subscriptionID); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.subscriptions[topic] = subscriptionID
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *RelayClient) Unsubscribe(ctx context.Context, topic string) error {
+	r.mu.Lock()
+	subscriptionID, ok := r.subscriptions[topic]
+	delete(r.subscriptions, topic)
+	r.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return r.call(ctx, "irn_unsubscribe", map[string]interface{}{"topic": topic, "id": subscriptionID}, nil)
+}
+
+func (r *RelayClient) Publish(ctx context.Context, topic, message string, ttl time.Duration, tag int) error {
+	return r.call(ctx, "irn_publish", map[string]interface{}{
+		"topic":   topic,
+		"message": message,
+		"ttl":     int64(ttl / time.Second),
+		"tag":     tag,
+		"prompt":  false,
+	}, nil)
+}
+
+// Close closes the connection to the relay server.
+func (r *RelayClient) Close() error {
+	return r.conn.Close()
+}
+
+// Done is closed when the connection to the relay server is lost.
+func (r *RelayClient) Done() <-chan struct{} {
+	return r.closed
+}
+
+// Err returns the error which closed the connection, after Done is closed.
+func (r *RelayClient) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *RelayClient) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	id := newMessageID()
+	ch := make(chan *relayMessage, 1)
+	r.mu.Lock()
+	r.pending[id] = ch
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.pending, id)
+		r.mu.Unlock()
+	}()
+
+	if err := r.write(&relayMessage{ID: id, JSONRPC: "2.0", Method: method, Params: params}); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-r.closed:
+		return fmt.Errorf("walletconnect: relay connection closed: %w", r.Err())
+	case resp := <-ch:
+		if resp.Error != nil {
+			return fmt.Errorf("walletconnect: relay %s failed: %s (%d)", method, resp.Error.Message, resp.Error.Code)
+		}
+		if result != nil {
+			if err := json.Unmarshal(resp.Result, result); err != nil {
+				return fmt.Errorf("walletconnect: invalid relay %s response: %w", method, err)
+			}
+		}
+		return nil
+	}
+}
+
+func (r *RelayClient) write(message *relayMessage) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	if err := r.conn.WriteJSON(message); err != nil {
+		return fmt.Errorf("walletconnect: failed to write to relay: %w", err)
+	}
+	return nil
+}
+
+func (r *RelayClient) read() {
+	defer close(r.closed)
+	for {
+		var message struct {
+			relayMessage
+			Params json.RawMessage `json:"params,omitempty"`
+		}
+		if err := r.conn.ReadJSON(&message); err != nil {
+			r.mu.Lock()
+			r.err = err
+			r.mu.Unlock()
+			return
+		}
+
+		if message.Method == "" {
+			r.mu.Lock()
+			ch, ok := r.pending[message.ID]
+			r.mu.Unlock()
+			if ok {
+				ch <- &message.relayMessage
+			}
+			continue
+		}
+
+		if message.Method != "irn_subscription" {
+			continue
+		}
+		var subscription relaySubscription
+		if err := json.Unmarshal(message.Params, &subscription); err != nil {
+			continue
+		}
+		// messages are acknowledged, otherwise the relay delivers them again
+		_ = r.write(&relayMessage{ID: message.ID, JSONRPC: "2.0", Result: json.RawMessage("true")})
+
+		r.mu.Lock()
+		handler := r.handler
+		r.mu.Unlock()
+		if handler != nil {
+			handler(subscription.Data.Topic, subscription.Data.Message)
+		}
+	}
+}
+
+// relayAuthToken returns a JWT signed by a new ed25519 key, identifying the client to the
+// relay server at aud.
+func relayAuthToken(aud string) (string, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("walletconnect: failed to generate relay key: %w", err)
+	}
+	sub := make([]byte, 32)
+	if _, err := rand.Read(sub); err != nil {
+		return "", fmt.Errorf("walletconnect: failed to generate relay key: %w", err)
+	}
+
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "EdDSA", "typ": "JWT"})
+	payload, _ := json.Marshal(map[string]interface{}{
+		"iss": didKey(publicKey),
+		"sub": hex.EncodeToString(sub),
+		"aud": aud,
+		"iat": now.Unix(),
+		"exp": now.Add(24 * time.Hour).Unix(),
+	})
+
+	token := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sig := ed25519.Sign(privateKey, []byte(token))
+	return token + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// didKey encodes an ed25519 public key as a did:key, the multicodec prefix 0xed01 and the
+// key in base58btc.
+func didKey(publicKey ed25519.PublicKey) string {
+	return "did:key:z" + base58Encode(append([]byte{0xed, 0x01}, publicKey...))
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+func base58Encode(data []byte) string {
+	n := new(big.Int).SetBytes(data)
+	base, mod := big.NewInt(58), new(big.Int)
+
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, base, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
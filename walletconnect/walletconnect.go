@@ -0,0 +1,571 @@
+// Package walletconnect connects a Sequence wallet to dApps over WalletConnect v2, as the
+// wallet side of the protocol. Session proposals and dApp requests are surfaced to
+// programmable approval callbacks, so bots and automation agents can use dApps without a
+// user interface. Approved requests are served by a walletrpc.Handler, which signs with the
+// wallet and sends transactions through its relayer.
+package walletconnect
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/walletrpc"
+)
+
+// Tags and ttls of the messages of the WalletConnect v2 sign protocol.
+const (
+	tagPairingDelete         = 1000
+	tagPairingDeleteResponse = 1001
+	tagPairingPing           = 1002
+	tagPairingPingResponse   = 1003
+	tagSessionPropose        = 1100
+	tagSessionProposeResp    = 1101
+	tagSessionSettle         = 1102
+	tagSessionUpdate         = 1104
+	tagSessionExtend         = 1106
+	tagSessionRequest        = 1108
+	tagSessionRequestResp    = 1109
+	tagSessionEvent          = 1110
+	tagSessionDelete         = 1112
+	tagSessionDeleteResp     = 1113
+	tagSessionPing           = 1114
+	tagSessionPingResp       = 1115
+
+	ttlDefault = 5 * time.Minute
+	ttlPing    = 30 * time.Second
+	ttlDelete  = 24 * time.Hour
+
+	// DefaultSessionExpiry is how long sessions last.
+	DefaultSessionExpiry = 7 * 24 * time.Hour
+)
+
+// Error codes of the WalletConnect v2 sign protocol.
+const (
+	CodeUserRejected             = 5000
+	CodeUnsupportedChains        = 5100
+	CodeUnsupportedMethods       = 5101
+	CodeUnsupportedNamespaceKey  = 5104
+	CodeUserDisconnected         = 6000
+	CodeSessionSettlementFailed  = 7000
+	CodeUnauthorizedChain        = 3005
+	CodeUnauthorizedMethod       = 3001
+	CodeSessionNotFoundOrExpired = 7001
+)
+
+// SupportedMethods are the dApp request methods served by a Client.
+var SupportedMethods = []string{
+	"eth_sendTransaction",
+	"eth_sign",
+	"personal_sign",
+	"eth_signTypedData_v4",
+	"eth_accounts",
+	"eth_requestAccounts",
+	"eth_chainId",
+}
+
+// SupportedEvents are the session events announced by a Client.
+var SupportedEvents = []string{"chainChanged", "accountsChanged"}
+
+// Metadata describes a peer to the other.
+type Metadata struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	URL         string   `json:"url"`
+	Icons       []string `json:"icons"`
+}
+
+// Namespace is a set of chains, methods and events of a session, ie. the eip155 namespace.
+type Namespace struct {
+	Chains   []string `json:"chains,omitempty"`
+	Accounts []string `json:"accounts,omitempty"`
+	Methods  []string `json:"methods"`
+	Events   []string `json:"events"`
+}
+
+// Proposal is a session proposed by a dApp.
+type Proposal struct {
+	ID                 int64
+	PairingTopic       string
+	Proposer           Metadata
+	RequiredNamespaces map[string]Namespace
+	OptionalNamespaces map[string]Namespace
+}
+
+// Session is an approved session with a dApp.
+type Session struct {
+	Topic      string
+	Peer       Metadata
+	Namespaces map[string]Namespace
+	Expiry     time.Time
+
+	symKey SymKey
+}
+
+// ProposalApproveFunc approves a session proposal. Returning an error rejects it.
+type ProposalApproveFunc func(ctx context.Context, proposal *Proposal) error
+
+// RequestApproveFunc approves a dApp request which signs or sends with the wallet, ie.
+// eth_sendTransaction. Returning an error rejects it.
+type RequestApproveFunc func(ctx context.Context, session *Session, method string, params json.RawMessage) error
+
+// Client is the wallet side of WalletConnect v2 for a Sequence wallet.
+type Client struct {
+	wallet   *sequence.Wallet
+	relay    Relay
+	handler  *walletrpc.Handler
+	metadata Metadata
+	options  sequence.Options
+
+	approveProposal ProposalApproveFunc
+	approveRequest  RequestApproveFunc
+
+	mu       sync.Mutex
+	keys     map[string]SymKey
+	sessions map[string]*Session
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+type sessionCtxKey struct{}
+
+// NewClient returns a client serving wallet to the dApps paired through relay. Proposals
+// and requests are rejected until approval callbacks are set.
+func NewClient(wallet *sequence.Wallet, relay Relay, metadata Metadata, opts ...sequence.Option) (*Client, error) {
+	if wallet.GetChainID() == nil {
+		return nil, fmt.Errorf("walletconnect: chain id of the wallet is unknown")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Client{
+		wallet:   wallet,
+		relay:    relay,
+		handler:  walletrpc.NewHandler(wallet, opts...),
+		metadata: metadata,
+		options:  sequence.NewOptions(opts...),
+		keys:     map[string]SymKey{},
+		sessions: map[string]*Session{},
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	c.handler.SetApprove(c.approve)
+	relay.SetMessageHandler(c.handleMessage)
+	return c, nil
+}
+
+func (c *Client) SetProposalApprove(approve ProposalApproveFunc) *Client {
+	c.approveProposal = approve
+	return c
+}
+
+func (c *Client) SetRequestApprove(approve RequestApproveFunc) *Client {
+	c.approveRequest = approve
+	return c
+}
+
+// Pair subscribes to the pairing of uri, on which the dApp proposes sessions.
+func (c *Client) Pair(ctx context.Context, uri string) error {
+	pairing, err := ParsePairingURI(uri)
+	if err != nil {
+		return err
+	}
+	if !pairing.Expiry.IsZero() && time.Now().After(pairing.Expiry) {
+		return fmt.Errorf("walletconnect: pairing expired at %v", pairing.Expiry)
+	}
+
+	c.mu.Lock()
+	c.keys[pairing.Topic] = pairing.SymKey
+	c.mu.Unlock()
+
+	if err := c.relay.Subscribe(ctx, pairing.Topic); err != nil {
+		c.mu.Lock()
+		delete(c.keys, pairing.Topic)
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Sessions returns the sessions which have not expired.
+func (c *Client) Sessions() []*Session {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sessions := make([]*Session, 0, len(c.sessions))
+	for _, session := range c.sessions {
+		if time.Now().Before(session.Expiry) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions
+}
+
+// Disconnect deletes the session of topic.
+func (c *Client) Disconnect(ctx context.Context, topic string) error {
+	c.mu.Lock()
+	_, ok := c.sessions[topic]
+	c.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("walletconnect: session %s not found", topic)
+	}
+
+	err := c.request(ctx, topic, "wc_sessionDelete", map[string]interface{}{
+		"code":    CodeUserDisconnected,
+		"message": "User disconnected.",
+	}, ttlDelete, tagSessionDelete)
+	c.removeTopic(ctx, topic)
+	return err
+}
+
+// Close stops serving requests. The relay is not closed.
+func (c *Client) Close() {
+	c.cancel()
+}
+
+// SessionFromContext returns the session of a request, from the ctx passed to the
+// approval callback of the walletrpc.Handler.
+func SessionFromContext(ctx context.Context) (*Session, bool) {
+	session, ok := ctx.Value(sessionCtxKey{}).(*Session)
+	return session, ok
+}
+
+type rpcMessage struct {
+	ID      int64            `json:"id"`
+	JSONRPC string           `json:"jsonrpc"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *walletrpc.Error `json:"error,omitempty"`
+}
+
+func (c *Client) handleMessage(topic, encrypted string) {
+	c.mu.Lock()
+	symKey, ok := c.keys[topic]
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	data, err := Decrypt(symKey, encrypted)
+	if err != nil {
+		c.options.Logger.Warnf("walletconnect: dropped message on %s: %v", topic, err)
+		return
+	}
+	var message rpcMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		c.options.Logger.Warnf("walletconnect: dropped message on %s: %v", topic, err)
+		return
+	}
+
+	// responses to our requests, ie. the acknowledgement of a settlement
+	if message.Method == "" {
+		if message.Error != nil {
+			c.options.Logger.Warnf("walletconnect: request %d on %s failed: %v", message.ID, topic, message.Error)
+		}
+		return
+	}
+
+	c.options.Metrics.IncCounter("walletconnect." + message.Method)
+
+	// requests may wait on approval, so they are served without blocking the relay
+	go c.handleRequest(topic, &message)
+}
+
+func (c *Client) handleRequest(topic string, message *rpcMessage) {
+	ctx := c.ctx
+
+	switch message.Method {
+	case "wc_sessionPropose":
+		c.handleProposal(ctx, topic, message)
+
+	case "wc_sessionRequest":
+		c.handleSessionRequest(ctx, topic, message)
+
+	case "wc_sessionPing":
+		c.respond(ctx, topic, message.ID, true, nil, ttlPing, tagSessionPingResp)
+	case "wc_pairingPing":
+		c.respond(ctx, topic, message.ID, true, nil, ttlPing, tagPairingPingResponse)
+
+	case "wc_sessionDelete":
+		c.respond(ctx, topic, message.ID, true, nil, ttlDelete, tagSessionDeleteResp)
+		c.removeTopic(ctx, topic)
+	case "wc_pairingDelete":
+		c.respond(ctx, topic, message.ID, true, nil, ttlDelete, tagPairingDeleteResponse)
+		c.removeTopic(ctx, topic)
+
+	case "wc_sessionExtend", "wc_sessionUpdate", "wc_sessionEvent":
+		// only the wallet controls the session
+		c.respond(ctx, topic, message.ID, nil, &walletrpc.Error{Code: CodeUnauthorizedMethod, Message: "Unauthorized method."}, ttlDefault, tagForResponse(message.Method))
+
+	default:
+		c.options.Logger.Debugf("walletconnect: ignored %s on %s", message.Method, topic)
+	}
+}
+
+func (c *Client) handleProposal(ctx context.Context, pairingTopic string, message *rpcMessage) {
+	var params struct {
+		Proposer struct {
+			PublicKey string   `json:"publicKey"`
+			Metadata  Metadata `json:"metadata"`
+		} `json:"proposer"`
+		RequiredNamespaces map[string]Namespace `json:"requiredNamespaces"`
+		OptionalNamespaces map[string]Namespace `json:"optionalNamespaces"`
+	}
+	var peerPublicKey [32]byte
+	if err := json.Unmarshal(message.Params, &params); err != nil {
+		c.respond(ctx, pairingTopic, message.ID, nil, &walletrpc.Error{Code: walletrpc.CodeInvalidParams, Message: "Invalid session proposal."}, ttlDefault, tagSessionProposeResp)
+		return
+	}
+	publicKey, err := hex.DecodeString(strings.TrimPrefix(params.Proposer.PublicKey, "0x"))
+	if err != nil || len(publicKey) != len(peerPublicKey) {
+		c.respond(ctx, pairingTopic, message.ID, nil, &walletrpc.Error{Code: walletrpc.CodeInvalidParams, Message: "Invalid proposer public key."}, ttlDefault, tagSessionProposeResp)
+		return
+	}
+	copy(peerPublicKey[:], publicKey)
+
+	proposal := &Proposal{
+		ID:                 message.ID,
+		PairingTopic:       pairingTopic,
+		Proposer:           params.Proposer.Metadata,
+		RequiredNamespaces: params.RequiredNamespaces,
+		OptionalNamespaces: params.OptionalNamespaces,
+	}
+	if rpcErr := c.checkNamespaces(proposal.RequiredNamespaces); rpcErr != nil {
+		c.respond(ctx, pairingTopic, message.ID, nil, rpcErr, ttlDefault, tagSessionProposeResp)
+		return
+	}
+	if c.approveProposal == nil {
+		c.respond(ctx, pairingTopic, message.ID, nil, &walletrpc.Error{Code: CodeUserRejected, Message: "User rejected."}, ttlDefault, tagSessionProposeResp)
+		return
+	}
+	if err := c.approveProposal(ctx, proposal); err != nil {
+		c.respond(ctx, pairingTopic, message.ID, nil, &walletrpc.Error{Code: CodeUserRejected, Message: fmt.Sprintf("User rejected: %v", err)}, ttlDefault, tagSessionProposeResp)
+		return
+	}
+
+	// the session key is agreed from the keys of both peers
+	keyPair, err := GenerateKeyPair()
+	if err != nil {
+		c.options.Logger.Errorf("%v", err)
+		return
+	}
+	symKey, err := keyPair.DeriveSymKey(peerPublicKey)
+	if err != nil {
+		c.respond(ctx, pairingTopic, message.ID, nil, &walletrpc.Error{Code: walletrpc.CodeInvalidParams, Message: "Invalid proposer public key."}, ttlDefault, tagSessionProposeResp)
+		return
+	}
+
+	session := &Session{
+		Topic:  symKey.Topic(),
+		Peer:   proposal.Proposer,
+		Expiry: time.Now().Add(DefaultSessionExpiry),
+		Namespaces: map[string]Namespace{
+			"eip155": {
+				Chains:   []string{c.chain()},
+				Accounts: []string{c.chain() + ":" + c.wallet.Address().Hex()},
+				Methods:  SupportedMethods,
+				Events:   SupportedEvents,
+			},
+		},
+		symKey: symKey,
+	}
+
+	c.mu.Lock()
+	c.keys[session.Topic] = symKey
+	c.mu.Unlock()
+	if err := c.relay.Subscribe(ctx, session.Topic); err != nil {
+		c.options.Logger.Errorf("walletconnect: failed to subscribe to session %s: %v", session.Topic, err)
+		c.removeTopic(ctx, session.Topic)
+		c.respond(ctx, pairingTopic, message.ID, nil, &walletrpc.Error{Code: CodeSessionSettlementFailed, Message: "Session settlement failed."}, ttlDefault, tagSessionProposeResp)
+		return
+	}
+
+	c.respond(ctx, pairingTopic, message.ID, map[string]interface{}{
+		"relay":              map[string]string{"protocol": "irn"},
+		"responderPublicKey": hex.EncodeToString(keyPair.PublicKey[:]),
+	}, nil, ttlDefault, tagSessionProposeResp)
+
+	err = c.request(ctx, session.Topic, "wc_sessionSettle", map[string]interface{}{
+		"relay":      map[string]string{"protocol": "irn"},
+		"namespaces": session.Namespaces,
+		"controller": map[string]interface{}{
+			"publicKey": hex.EncodeToString(keyPair.PublicKey[:]),
+			"metadata":  c.metadata,
+		},
+		"expiry": session.Expiry.Unix(),
+	}, ttlDefault, tagSessionSettle)
+	if err != nil {
+		c.options.Logger.Errorf("walletconnect: failed to settle session %s: %v", session.Topic, err)
+		c.removeTopic(ctx, session.Topic)
+		return
+	}
+
+	c.mu.Lock()
+	c.sessions[session.Topic] = session
+	c.mu.Unlock()
+	c.options.Logger.Infof("walletconnect: session %s settled with %s", session.Topic, session.Peer.URL)
+}
+
+func (c *Client) handleSessionRequest(ctx context.Context, topic string, message *rpcMessage) {
+	c.mu.Lock()
+	session, ok := c.sessions[topic]
+	c.mu.Unlock()
+	if !ok || time.Now().After(session.Expiry) {
+		c.respond(ctx, topic, message.ID, nil, &walletrpc.Error{Code: CodeSessionNotFoundOrExpired, Message: "Session not found or expired."}, ttlDefault, tagSessionRequestResp)
+		return
+	}
+
+	var params struct {
+		Request struct {
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		} `json:"request"`
+		ChainID string `json:"chainId"`
+	}
+	if err := json.Unmarshal(message.Params, &params); err != nil {
+		c.respond(ctx, topic, message.ID, nil, &walletrpc.Error{Code: walletrpc.CodeInvalidParams, Message: "Invalid session request."}, ttlDefault, tagSessionRequestResp)
+		return
+	}
+	if params.ChainID != c.chain() {
+		c.respond(ctx, topic, message.ID, nil, &walletrpc.Error{Code: CodeUnauthorizedChain, Message: "Unauthorized chain."}, ttlDefault, tagSessionRequestResp)
+		return
+	}
+	if !contains(SupportedMethods, params.Request.Method) {
+		c.respond(ctx, topic, message.ID, nil, &walletrpc.Error{Code: CodeUnauthorizedMethod, Message: "Unauthorized method."}, ttlDefault, tagSessionRequestResp)
+		return
+	}
+
+	result, err := c.handler.Call(context.WithValue(ctx, sessionCtxKey{}, session), params.Request.Method, params.Request.Params)
+	if err != nil {
+		rpcErr, ok := err.(*walletrpc.Error)
+		if !ok {
+			rpcErr = &walletrpc.Error{Code: walletrpc.CodeInternalError, Message: err.Error()}
+		}
+		c.respond(ctx, topic, message.ID, nil, rpcErr, ttlDefault, tagSessionRequestResp)
+		return
+	}
+	c.respond(ctx, topic, message.ID, result, nil, ttlDefault, tagSessionRequestResp)
+}
+
+// approve is the approval callback of the walletrpc.Handler serving session requests.
+func (c *Client) approve(ctx context.Context, method string, params json.RawMessage) error {
+	session, _ := SessionFromContext(ctx)
+	if c.approveRequest == nil {
+		return fmt.Errorf("no approval callback is set")
+	}
+	return c.approveRequest(ctx, session, method, params)
+}
+
+func (c *Client) checkNamespaces(namespaces map[string]Namespace) *walletrpc.Error {
+	for key, namespace := range namespaces {
+		if key != "eip155" && !strings.HasPrefix(key, "eip155:") {
+			return &walletrpc.Error{Code: CodeUnsupportedNamespaceKey, Message: fmt.Sprintf("Unsupported namespace key %s.", key)}
+		}
+		chains := namespace.Chains
+		if strings.HasPrefix(key, "eip155:") {
+			chains = append(chains, key)
+		}
+		for _, chain := range chains {
+			if chain != c.chain() {
+				return &walletrpc.Error{Code: CodeUnsupportedChains, Message: fmt.Sprintf("Unsupported chain %s.", chain)}
+			}
+		}
+		for _, method := range namespace.Methods {
+			if !contains(SupportedMethods, method) {
+				return &walletrpc.Error{Code: CodeUnsupportedMethods, Message: fmt.Sprintf("Unsupported method %s.", method)}
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Client) chain() string {
+	return "eip155:" + c.wallet.GetChainID().String()
+}
+
+func (c *Client) removeTopic(ctx context.Context, topic string) {
+	c.mu.Lock()
+	delete(c.keys, topic)
+	delete(c.sessions, topic)
+	c.mu.Unlock()
+	if err := c.relay.Unsubscribe(ctx, topic); err != nil {
+		c.options.Logger.Warnf("walletconnect: failed to unsubscribe from %s: %v", topic, err)
+	}
+}
+
+func (c *Client) request(ctx context.Context, topic, method string, params interface{}, ttl time.Duration, tag int) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("walletconnect: failed to encode %s: %w", method, err)
+	}
+	return c.publish(ctx, topic, &rpcMessage{ID: newMessageID(), JSONRPC: "2.0", Method: method, Params: data}, ttl, tag)
+}
+
+func (c *Client) respond(ctx context.Context, topic string, id int64, result interface{}, rpcErr *walletrpc.Error, ttl time.Duration, tag int) {
+	message := &rpcMessage{ID: id, JSONRPC: "2.0", Error: rpcErr}
+	if rpcErr == nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			c.options.Logger.Errorf("walletconnect: failed to encode response: %v", err)
+			return
+		}
+		message.Result = data
+	}
+	if err := c.publish(ctx, topic, message, ttl, tag); err != nil {
+		c.options.Logger.Errorf("walletconnect: failed to respond to %d on %s: %v", id, topic, err)
+	}
+}
+
+func (c *Client) publish(ctx context.Context, topic string, message *rpcMessage, ttl time.Duration, tag int) error {
+	c.mu.Lock()
+	symKey, ok := c.keys[topic]
+	c.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("walletconnect: no key for topic %s", topic)
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("walletconnect: failed to encode message: %w", err)
+	}
+	encrypted, err := Encrypt(symKey, data)
+	if err != nil {
+		return err
+	}
+	return c.relay.Publish(ctx, topic, encrypted, ttl, tag)
+}
+
+func tagForResponse(method string) int {
+	switch method {
+	case "wc_sessionExtend":
+		return tagSessionExtend + 1
+	case "wc_sessionUpdate":
+		return tagSessionUpdate + 1
+	case "wc_sessionEvent":
+		return tagSessionEvent + 1
+	default:
+		return tagSessionRequestResp
+	}
+}
+
+// newMessageID returns an id in the format of the WalletConnect SDKs, the time in
+// milliseconds followed by 3 random digits.
+func newMessageID() int64 {
+	return time.Now().UnixMilli()*1000 + rand.Int63n(1000)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
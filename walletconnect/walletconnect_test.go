@@ -0,0 +1,218 @@
+package walletconnect_test
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/walletconnect"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryRelay delivers the messages published by one peer to the other. Like the relay
+// server, messages published before the peer subscribes are delivered on subscription.
+type memoryRelay struct {
+	mu      sync.Mutex
+	topics  map[string]bool
+	queued  map[string][]string
+	handler func(topic, message string)
+	peer    *memoryRelay
+}
+
+func newMemoryRelays() (*memoryRelay, *memoryRelay) {
+	a := &memoryRelay{topics: map[string]bool{}, queued: map[string][]string{}}
+	b := &memoryRelay{topics: map[string]bool{}, queued: map[string][]string{}}
+	a.peer, b.peer = b, a
+	return a, b
+}
+
+func (r *memoryRelay) Subscribe(ctx context.Context, topic string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.topics[topic] = true
+	for _, message := range r.queued[topic] {
+		go r.handler(topic, message)
+	}
+	delete(r.queued, topic)
+	return nil
+}
+
+func (r *memoryRelay) Unsubscribe(ctx context.Context, topic string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.topics, topic)
+	return nil
+}
+
+func (r *memoryRelay) Publish(ctx context.Context, topic, message string, ttl time.Duration, tag int) error {
+	r.peer.mu.Lock()
+	defer r.peer.mu.Unlock()
+	if !r.peer.topics[topic] {
+		r.peer.queued[topic] = append(r.peer.queued[topic], message)
+		return nil
+	}
+	go r.peer.handler(topic, message)
+	return nil
+}
+
+func (r *memoryRelay) SetMessageHandler(handler func(topic, message string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handler = handler
+}
+
+// dApp is the dApp side of a session, receiving the messages of its topics.
+type dApp struct {
+	relay    *memoryRelay
+	messages chan map[string]json.RawMessage
+	keys     map[string]walletconnect.SymKey
+}
+
+func (d *dApp) publish(t *testing.T, topic string, message interface{}) {
+	data, err := json.Marshal(message)
+	assert.NoError(t, err)
+	encrypted, err := walletconnect.Encrypt(d.keys[topic], data)
+	assert.NoError(t, err)
+	assert.NoError(t, d.relay.Publish(context.Background(), topic, encrypted, time.Minute, 0))
+}
+
+func (d *dApp) receive(t *testing.T) map[string]json.RawMessage {
+	select {
+	case message := <-d.messages:
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a message")
+		return nil
+	}
+}
+
+func TestClient(t *testing.T) {
+	eoa, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+	wallet, err := sequence.NewWalletSingleOwner(eoa)
+	assert.NoError(t, err)
+	wallet.SetChainID(big.NewInt(137))
+
+	walletRelay, dAppRelay := newMemoryRelays()
+	client, err := walletconnect.NewClient(wallet, walletRelay, walletconnect.Metadata{Name: "bot"})
+	assert.NoError(t, err)
+	defer client.Close()
+
+	var approvedMethods []string
+	client.SetProposalApprove(func(ctx context.Context, proposal *walletconnect.Proposal) error {
+		if proposal.Proposer.Name != "dApp" {
+			return errors.New("unknown dApp")
+		}
+		return nil
+	})
+	client.SetRequestApprove(func(ctx context.Context, session *walletconnect.Session, method string, params json.RawMessage) error {
+		approvedMethods = append(approvedMethods, method)
+		if method == "eth_sendTransaction" {
+			return errors.New("transactions are not allowed")
+		}
+		return nil
+	})
+
+	// the dApp shares a pairing uri
+	var pairingKey walletconnect.SymKey
+	pairingKey[0] = 1
+	pairing := &walletconnect.PairingURI{Topic: pairingKey.Topic(), Version: 2, RelayProtocol: "irn", SymKey: pairingKey}
+	parsed, err := walletconnect.ParsePairingURI(pairing.String())
+	assert.NoError(t, err)
+	assert.Equal(t, pairing, parsed)
+
+	app := &dApp{relay: dAppRelay, messages: make(chan map[string]json.RawMessage, 10), keys: map[string]walletconnect.SymKey{pairing.Topic: pairingKey}}
+	dAppRelay.SetMessageHandler(func(topic, message string) {
+		data, err := walletconnect.Decrypt(app.keys[topic], message)
+		assert.NoError(t, err)
+		var out map[string]json.RawMessage
+		assert.NoError(t, json.Unmarshal(data, &out))
+		app.messages <- out
+	})
+	assert.NoError(t, dAppRelay.Subscribe(context.Background(), pairing.Topic))
+	assert.NoError(t, client.Pair(context.Background(), pairing.String()))
+
+	// proposals of other chains are rejected
+	keyPair, err := walletconnect.GenerateKeyPair()
+	assert.NoError(t, err)
+	propose := func(id int, chain string) map[string]json.RawMessage {
+		app.publish(t, pairing.Topic, map[string]interface{}{
+			"id": id, "jsonrpc": "2.0", "method": "wc_sessionPropose",
+			"params": map[string]interface{}{
+				"relays":             []interface{}{map[string]string{"protocol": "irn"}},
+				"proposer":           map[string]interface{}{"publicKey": hex.EncodeToString(keyPair.PublicKey[:]), "metadata": map[string]string{"name": "dApp"}},
+				"requiredNamespaces": map[string]interface{}{"eip155": map[string]interface{}{"chains": []string{chain}, "methods": []string{"personal_sign"}, "events": []string{}}},
+			},
+		})
+		return app.receive(t)
+	}
+	resp := propose(1, "eip155:1")
+	assert.Contains(t, string(resp["error"]), "5100")
+
+	resp = propose(2, "eip155:137")
+	assert.Nil(t, resp["error"])
+	var result struct {
+		ResponderPublicKey string `json:"responderPublicKey"`
+	}
+	assert.NoError(t, json.Unmarshal(resp["result"], &result))
+
+	// the dApp derives the session key from the public key of the wallet
+	var responderPublicKey [32]byte
+	b, err := hex.DecodeString(result.ResponderPublicKey)
+	assert.NoError(t, err)
+	copy(responderPublicKey[:], b)
+	sessionKey, err := keyPair.DeriveSymKey(responderPublicKey)
+	assert.NoError(t, err)
+	sessionTopic := sessionKey.Topic()
+	app.keys[sessionTopic] = sessionKey
+	assert.NoError(t, dAppRelay.Subscribe(context.Background(), sessionTopic))
+
+	settle := app.receive(t)
+	assert.Equal(t, `"wc_sessionSettle"`, string(settle["method"]))
+	assert.Contains(t, string(settle["params"]), "eip155:137:"+wallet.Address().Hex())
+	assert.Eventually(t, func() bool { return len(client.Sessions()) == 1 }, time.Second, 10*time.Millisecond)
+
+	request := func(id int, method string, params interface{}) map[string]json.RawMessage {
+		app.publish(t, sessionTopic, map[string]interface{}{
+			"id": id, "jsonrpc": "2.0", "method": "wc_sessionRequest",
+			"params": map[string]interface{}{"chainId": "eip155:137", "request": map[string]interface{}{"method": method, "params": params}},
+		})
+		return app.receive(t)
+	}
+
+	resp = request(3, "personal_sign", []string{hexutil.Encode([]byte("hello")), wallet.Address().Hex()})
+	assert.Nil(t, resp["error"])
+	var sig hexutil.Bytes
+	assert.NoError(t, json.Unmarshal(resp["result"], &sig))
+	assert.NotEmpty(t, sig)
+
+	resp = request(4, "eth_sendTransaction", []interface{}{map[string]string{"from": wallet.Address().Hex(), "to": wallet.Address().Hex()}})
+	assert.Contains(t, string(resp["error"]), "4001")
+	assert.Equal(t, []string{"personal_sign", "eth_sendTransaction"}, approvedMethods)
+
+	resp = request(5, "eth_getBalance", []string{wallet.Address().Hex(), "latest"})
+	assert.Contains(t, string(resp["error"]), "3001")
+
+	// the dApp disconnects
+	app.publish(t, sessionTopic, map[string]interface{}{"id": 6, "jsonrpc": "2.0", "method": "wc_sessionDelete", "params": map[string]interface{}{"code": 6000, "message": "bye"}})
+	app.receive(t)
+	assert.Eventually(t, func() bool { return len(client.Sessions()) == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestParsePairingURI(t *testing.T) {
+	pairing, err := walletconnect.ParsePairingURI("wc:7f6e504bfad60b485450578e05678ed3e8e8c4751d3c6160be17160d63ec90f9@2?relay-protocol=irn&symKey=587d5484ce2a2a6ee3ba1962fdd7e8588e06200c46823bd18fbd67def96ad303&expiryTimestamp=1705000000")
+	assert.NoError(t, err)
+	assert.Equal(t, "7f6e504bfad60b485450578e05678ed3e8e8c4751d3c6160be17160d63ec90f9", pairing.Topic)
+	assert.Equal(t, int64(1705000000), pairing.Expiry.Unix())
+
+	_, err = walletconnect.ParsePairingURI("wc:00e46b69-d0cc-4b3e-b6a2-cee442f97188@1?bridge=https%3A%2F%2Fbridge.walletconnect.org&key=91303dedf64285cbbaf9120f6e9d160a5c8aa3deb67017a3874cd272323f48ae")
+	assert.Error(t, err)
+}
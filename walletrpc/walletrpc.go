@@ -0,0 +1,369 @@
+// Package walletrpc serves a Sequence wallet over the standard Ethereum wallet JSON-RPC
+// methods, so tools which speak them, ie. scripts using eth_sendTransaction, can drive a
+// wallet hosted in a Go service. Transactions are signed by the wallet and sent through its
+// relayer, and messages are signed with signatures valid under ERC-1271.
+package walletrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/core/digest"
+)
+
+// maxRequestBodySize limits the size of request bodies.
+const maxRequestBodySize = 1 << 20
+
+// JSON-RPC and EIP-1193 error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeUserRejected   = 4001
+	CodeUnauthorized   = 4100
+)
+
+// Error is a JSON-RPC error.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("walletrpc: %s (%d)", e.Message, e.Code)
+}
+
+func errorf(code int, format string, a ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, a...)}
+}
+
+// ApproveFunc approves a request before the wallet signs or sends anything. Returning an
+// error rejects the request, with CodeUserRejected unless the error is an *Error.
+type ApproveFunc func(ctx context.Context, method string, params json.RawMessage) error
+
+// Handler is an http.Handler serving the wallet methods eth_accounts, eth_requestAccounts,
+// eth_chainId, eth_sendTransaction, eth_sign, personal_sign and eth_signTypedData_v4 for a
+// wallet. Other methods are forwarded to the provider of the wallet, if it has one.
+type Handler struct {
+	wallet  *sequence.Wallet
+	approve ApproveFunc
+	options sequence.Options
+}
+
+var _ http.Handler = &Handler{}
+
+func NewHandler(wallet *sequence.Wallet, opts ...sequence.Option) *Handler {
+	return &Handler{
+		wallet:  wallet,
+		options: sequence.NewOptions(opts...),
+	}
+}
+
+// SetApprove sets the callback approving transactions and signatures. Without one, every
+// request is approved.
+func (h *Handler) SetApprove(approve ApproveFunc) *Handler {
+	h.approve = approve
+	return h
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBodySize))
+	if err != nil {
+		writeJSON(w, &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: errorf(CodeParseError, "failed to read request")})
+		return
+	}
+
+	// batches are served in order
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		var reqs []*request
+		if err := json.Unmarshal(body, &reqs); err != nil || len(reqs) == 0 {
+			writeJSON(w, &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: errorf(CodeParseError, "invalid batch")})
+			return
+		}
+		resps := make([]*response, 0, len(reqs))
+		for _, req := range reqs {
+			resps = append(resps, h.serve(r.Context(), req))
+		}
+		writeJSON(w, resps)
+		return
+	}
+
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeJSON(w, &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: errorf(CodeParseError, "invalid request")})
+		return
+	}
+	writeJSON(w, h.serve(r.Context(), &req))
+}
+
+func (h *Handler) serve(ctx context.Context, req *request) *response {
+	resp := &response{JSONRPC: "2.0", ID: req.ID}
+	if resp.ID == nil {
+		resp.ID = json.RawMessage("null")
+	}
+	if req == nil || req.Method == "" {
+		resp.Error = errorf(CodeInvalidRequest, "method is required")
+		return resp
+	}
+
+	result, err := h.Call(ctx, req.Method, req.Params)
+	if err != nil {
+		rpcErr, ok := err.(*Error)
+		if !ok {
+			rpcErr = errorf(CodeInternalError, "%v", err)
+		}
+		resp.Error = rpcErr
+		return resp
+	}
+	resp.Result = result
+	return resp
+}
+
+// Call serves a single request of method with its JSON encoded params array. Errors are
+// of type *Error.
+func (h *Handler) Call(ctx context.Context, method string, params json.RawMessage) (interface{}, error) {
+	h.options.Metrics.IncCounter("walletrpc." + method)
+
+	switch method {
+	case "eth_accounts", "eth_requestAccounts":
+		return []string{h.wallet.Address().Hex()}, nil
+
+	case "eth_chainId":
+		chainID := h.wallet.GetChainID()
+		if chainID == nil {
+			return nil, errorf(CodeInternalError, "chain id of the wallet is unknown")
+		}
+		return (*hexutil.Big)(chainID), nil
+
+	case "eth_sendTransaction":
+		return h.sendTransaction(ctx, params)
+
+	case "eth_sign":
+		// eth_sign(address, message)
+		var args []json.RawMessage
+		if err := json.Unmarshal(params, &args); err != nil || len(args) != 2 {
+			return nil, errorf(CodeInvalidParams, "expected [address, message]")
+		}
+		return h.signMessage(ctx, method, params, args[0], args[1])
+
+	case "personal_sign":
+		// personal_sign(message, address)
+		var args []json.RawMessage
+		if err := json.Unmarshal(params, &args); err != nil || len(args) < 2 {
+			return nil, errorf(CodeInvalidParams, "expected [message, address]")
+		}
+		return h.signMessage(ctx, method, params, args[1], args[0])
+
+	case "eth_signTypedData_v4":
+		return h.signTypedData(ctx, params)
+
+	default:
+		return h.forward(ctx, method, params)
+	}
+}
+
+type transactionArgs struct {
+	From  *common.Address `json:"from"`
+	To    *common.Address `json:"to"`
+	Value *hexutil.Big    `json:"value"`
+	Data  *hexutil.Bytes  `json:"data"`
+	Input *hexutil.Bytes  `json:"input"`
+	Gas   *hexutil.Uint64 `json:"gas"`
+}
+
+func (h *Handler) sendTransaction(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var args []transactionArgs
+	if err := json.Unmarshal(params, &args); err != nil || len(args) != 1 {
+		return nil, errorf(CodeInvalidParams, "expected [transaction]")
+	}
+	txArgs := args[0]
+
+	if err := h.checkAccount(txArgs.From); err != nil {
+		return nil, err
+	}
+	if txArgs.To == nil {
+		return nil, errorf(CodeInvalidParams, "to is required, contracts are deployed through the wallet")
+	}
+
+	txn := &sequence.Transaction{To: *txArgs.To, RevertOnError: true}
+	if txArgs.Value != nil {
+		txn.Value = txArgs.Value.ToInt()
+	}
+	if txArgs.Input != nil {
+		txn.Data = *txArgs.Input
+	} else if txArgs.Data != nil {
+		txn.Data = *txArgs.Data
+	}
+	if txArgs.Gas != nil {
+		txn.GasLimit = new(big.Int).SetUint64(uint64(*txArgs.Gas))
+	}
+	if err := h.wallet.ValidateTransactions(sequence.Transactions{txn}); err != nil {
+		return nil, errorf(CodeInvalidParams, "%v", err)
+	}
+
+	if err := h.approveRequest(ctx, "eth_sendTransaction", params); err != nil {
+		return nil, err
+	}
+
+	signedTxs, err := h.wallet.SignTransaction(ctx, txn)
+	if err != nil {
+		return nil, errorf(CodeInternalError, "failed to sign transaction: %v", err)
+	}
+	metaTxnID, tx, _, err := h.wallet.SendTransaction(ctx, signedTxs)
+	if err != nil {
+		return nil, errorf(CodeInternalError, "failed to relay transaction: %v", err)
+	}
+
+	// the hash of the native transaction, which tools poll receipts of
+	if tx != nil {
+		return tx.Hash(), nil
+	}
+	return common.HexToHash(string(metaTxnID)), nil
+}
+
+func (h *Handler) signMessage(ctx context.Context, method string, params, addressArg, messageArg json.RawMessage) (interface{}, error) {
+	var address common.Address
+	if err := json.Unmarshal(addressArg, &address); err != nil {
+		return nil, errorf(CodeInvalidParams, "invalid address")
+	}
+	if err := h.checkAccount(&address); err != nil {
+		return nil, err
+	}
+
+	var message string
+	if err := json.Unmarshal(messageArg, &message); err != nil {
+		return nil, errorf(CodeInvalidParams, "invalid message")
+	}
+	// messages are hex encoded bytes, but personal_sign is also sent plain text
+	data, err := hexutil.Decode(message)
+	if err != nil {
+		data = []byte(message)
+	}
+
+	if err := h.approveRequest(ctx, method, params); err != nil {
+		return nil, err
+	}
+
+	sig, _, err := h.wallet.SignDigest(digest.EthSignDigest(data))
+	if err != nil {
+		return nil, errorf(CodeInternalError, "failed to sign message: %v", err)
+	}
+	return hexutil.Bytes(sig), nil
+}
+
+func (h *Handler) signTypedData(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil || len(args) != 2 {
+		return nil, errorf(CodeInvalidParams, "expected [address, typedData]")
+	}
+
+	var address common.Address
+	if err := json.Unmarshal(args[0], &address); err != nil {
+		return nil, errorf(CodeInvalidParams, "invalid address")
+	}
+	if err := h.checkAccount(&address); err != nil {
+		return nil, err
+	}
+
+	// the typed data is either an object, or a string of its JSON
+	typedDataJSON := []byte(args[1])
+	var s string
+	if err := json.Unmarshal(args[1], &s); err == nil {
+		typedDataJSON = []byte(s)
+	}
+	typedData, err := digest.ParseTypedData(typedDataJSON)
+	if err != nil {
+		return nil, errorf(CodeInvalidParams, "%v", err)
+	}
+	typedDataDigest, err := typedData.Digest()
+	if err != nil {
+		return nil, errorf(CodeInvalidParams, "%v", err)
+	}
+
+	if err := h.approveRequest(ctx, "eth_signTypedData_v4", params); err != nil {
+		return nil, err
+	}
+
+	sig, _, err := h.wallet.SignDigest(typedDataDigest)
+	if err != nil {
+		return nil, errorf(CodeInternalError, "failed to sign typed data: %v", err)
+	}
+	return hexutil.Bytes(sig), nil
+}
+
+func (h *Handler) forward(ctx context.Context, method string, params json.RawMessage) (interface{}, error) {
+	provider := h.wallet.GetProvider()
+	if provider == nil || !strings.HasPrefix(method, "eth_") && !strings.HasPrefix(method, "net_") && !strings.HasPrefix(method, "web3_") {
+		return nil, errorf(CodeMethodNotFound, "method %s is not supported", method)
+	}
+
+	var args []interface{}
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, errorf(CodeInvalidParams, "params must be an array")
+		}
+	}
+
+	var result json.RawMessage
+	if err := provider.Do(ctx, ethrpc.NewCallBuilder[json.RawMessage](method, nil, args...).Into(&result)); err != nil {
+		return nil, errorf(CodeInternalError, "%v", err)
+	}
+	return result, nil
+}
+
+func (h *Handler) checkAccount(address *common.Address) error {
+	if address != nil && *address != h.wallet.Address() {
+		return errorf(CodeUnauthorized, "account %v is not the wallet %v", address, h.wallet.Address())
+	}
+	return nil
+}
+
+func (h *Handler) approveRequest(ctx context.Context, method string, params json.RawMessage) error {
+	if h.approve == nil {
+		return nil
+	}
+	if err := h.approve(ctx, method, params); err != nil {
+		if rpcErr, ok := err.(*Error); ok {
+			return rpcErr
+		}
+		return errorf(CodeUserRejected, "request rejected: %v", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, out interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(out)
+}
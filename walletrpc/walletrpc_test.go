@@ -0,0 +1,103 @@
+package walletrpc_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/core/digest"
+	"github.com/0xsequence/go-sequence/walletrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+type rpcResponse struct {
+	ID     json.RawMessage  `json:"id"`
+	Result json.RawMessage  `json:"result"`
+	Error  *walletrpc.Error `json:"error"`
+}
+
+func TestHandler(t *testing.T) {
+	eoa, err := ethwallet.NewWalletFromRandomEntropy()
+	assert.NoError(t, err)
+
+	wallet, err := sequence.NewWalletSingleOwner(eoa)
+	assert.NoError(t, err)
+	wallet.SetChainID(big.NewInt(1))
+
+	handler := walletrpc.NewHandler(wallet)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	call := func(body string) rpcResponse {
+		resp, err := http.Post(ts.URL, "application/json", bytes.NewReader([]byte(body)))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		var out rpcResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		return out
+	}
+
+	resp := call(`{"jsonrpc":"2.0","id":1,"method":"eth_accounts","params":[]}`)
+	assert.Nil(t, resp.Error)
+	assert.JSONEq(t, `["`+wallet.Address().Hex()+`"]`, string(resp.Result))
+	assert.Equal(t, "1", string(resp.ID))
+
+	resp = call(`{"jsonrpc":"2.0","id":2,"method":"eth_chainId","params":[]}`)
+	assert.Nil(t, resp.Error)
+	assert.Equal(t, `"0x1"`, string(resp.Result))
+
+	// personal_sign signatures are valid for the eth_sign digest of the message
+	message := []byte("hello")
+	resp = call(`{"jsonrpc":"2.0","id":3,"method":"personal_sign","params":["` + hexutil.Encode(message) + `","` + wallet.Address().Hex() + `"]}`)
+	assert.Nil(t, resp.Error)
+	var sig hexutil.Bytes
+	assert.NoError(t, json.Unmarshal(resp.Result, &sig))
+
+	subDigest, err := sequence.SubDigest(big.NewInt(1), wallet.Address(), digest.EthSignDigest(message))
+	assert.NoError(t, err)
+	config, err := sequence.RecoverWalletConfigFromDigest(subDigest, sig, sequence.SequenceContext(), big.NewInt(1), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, eoa.Address(), config.Signers[0].Address)
+
+	// eth_signTypedData_v4 accepts the typed data as a JSON string
+	typedData := `{"types":{"EIP712Domain":[{"name":"name","type":"string"},{"name":"chainId","type":"uint256"}],"Mail":[{"name":"contents","type":"string"}]},"primaryType":"Mail","domain":{"name":"Test","chainId":1},"message":{"contents":"hi"}}`
+	typedDataString, _ := json.Marshal(typedData)
+	resp = call(`{"jsonrpc":"2.0","id":4,"method":"eth_signTypedData_v4","params":["` + wallet.Address().Hex() + `",` + string(typedDataString) + `]}`)
+	assert.Nil(t, resp.Error)
+
+	// other accounts are not authorized
+	resp = call(`{"jsonrpc":"2.0","id":5,"method":"eth_sign","params":["` + eoa.Address().Hex() + `","0x01"]}`)
+	assert.Equal(t, walletrpc.CodeUnauthorized, resp.Error.Code)
+
+	// without a provider, other methods are not found
+	resp = call(`{"jsonrpc":"2.0","id":6,"method":"eth_blockNumber","params":[]}`)
+	assert.Equal(t, walletrpc.CodeMethodNotFound, resp.Error.Code)
+
+	resp = call(`{"jsonrpc":"2.0","id":7,"method":"eth_sendTransaction","params":[{"from":"` + wallet.Address().Hex() + `"}]}`)
+	assert.Equal(t, walletrpc.CodeInvalidParams, resp.Error.Code)
+
+	// requests can be rejected
+	handler.SetApprove(func(ctx context.Context, method string, params json.RawMessage) error {
+		return errors.New("denied")
+	})
+	resp = call(`{"jsonrpc":"2.0","id":8,"method":"eth_sign","params":["` + wallet.Address().Hex() + `","0x01"]}`)
+	assert.Equal(t, walletrpc.CodeUserRejected, resp.Error.Code)
+
+	// batches are answered in order
+	httpResp, err := http.Post(ts.URL, "application/json", bytes.NewReader([]byte(`[{"jsonrpc":"2.0","id":1,"method":"eth_accounts"},{"jsonrpc":"2.0","id":2,"method":"unknown"}]`)))
+	assert.NoError(t, err)
+	defer httpResp.Body.Close()
+	var batch []rpcResponse
+	assert.NoError(t, json.NewDecoder(httpResp.Body).Decode(&batch))
+	assert.Len(t, batch, 2)
+	assert.Nil(t, batch[0].Error)
+	assert.Equal(t, walletrpc.CodeMethodNotFound, batch[1].Error.Code)
+}
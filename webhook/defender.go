@@ -0,0 +1,202 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+// Defender exports activities as the webhook notifications of OpenZeppelin Defender monitors,
+// ie. a body of the events of the activities, each matched by the wallet address.
+type Defender struct {
+	// Monitor is the monitor reported by the events, ie. the monitor the rules of the pipeline
+	// are written for.
+	Monitor DefenderMonitor
+
+	// BatchSize is the most events of a body, 0 for a single body.
+	BatchSize int
+}
+
+var _ Exporter = Defender{}
+
+// DefenderMonitor is the monitor of the events of a Defender notification. Network and
+// ChainID default to the chain of the activity.
+type DefenderMonitor struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Network string `json:"network"`
+	ChainID uint64 `json:"chainId"`
+
+	Addresses         []string `json:"addresses"`
+	ConfirmBlockDepth int      `json:"confirmBlockDepth"`
+}
+
+// DefenderEvent is an event of a Defender monitor notification.
+type DefenderEvent struct {
+	Type                     string                 `json:"type"`
+	Hash                     string                 `json:"hash"`
+	Timestamp                int64                  `json:"timestamp"`
+	BlockHash                string                 `json:"blockHash"`
+	BlockNumber              string                 `json:"blockNumber"`
+	Transaction              DefenderTransaction    `json:"transaction"`
+	MatchReasons             []DefenderMatchReason  `json:"matchReasons"`
+	MatchedAddresses         []string               `json:"matchedAddresses"`
+	MatchedChecksumAddresses []string               `json:"matchedChecksumAddresses"`
+	Monitor                  DefenderMonitor        `json:"monitor"`
+	Value                    string                 `json:"value"`
+	Metadata                 map[string]interface{} `json:"metadata"`
+}
+
+// DefenderTransaction is the receipt of the transaction of a DefenderEvent.
+type DefenderTransaction struct {
+	TransactionHash  string        `json:"transactionHash"`
+	TransactionIndex string        `json:"transactionIndex"`
+	BlockHash        string        `json:"blockHash"`
+	BlockNumber      string        `json:"blockNumber"`
+	To               string        `json:"to"`
+	Status           string        `json:"status"`
+	GasUsed          string        `json:"gasUsed"`
+	Logs             []DefenderLog `json:"logs"`
+}
+
+// DefenderLog is a log of a DefenderTransaction.
+type DefenderLog struct {
+	Address         string   `json:"address"`
+	Topics          []string `json:"topics"`
+	Data            string   `json:"data"`
+	LogIndex        string   `json:"logIndex"`
+	TransactionHash string   `json:"transactionHash"`
+	BlockHash       string   `json:"blockHash"`
+	BlockNumber     string   `json:"blockNumber"`
+}
+
+// DefenderMatchReason is why a DefenderEvent matched. The activities of wallets match by a
+// "transaction" condition on the status of their meta-transaction, with its fields as params.
+type DefenderMatchReason struct {
+	Type      string                 `json:"type"`
+	Condition string                 `json:"condition,omitempty"`
+	Address   string                 `json:"address,omitempty"`
+	Params    map[string]interface{} `json:"params,omitempty"`
+}
+
+// Export returns the bodies of the notifications of activities, {"events": [...]}.
+func (d Defender) Export(activities []*Activity) ([][]byte, error) {
+	events := make([]*DefenderEvent, 0, len(activities))
+	for _, activity := range activities {
+		events = append(events, d.Event(activity))
+	}
+
+	batchSize := d.BatchSize
+	if batchSize <= 0 {
+		batchSize = len(events)
+	}
+	var payloads [][]byte
+	for start := 0; start < len(events); start += batchSize {
+		end := start + batchSize
+		if end > len(events) {
+			end = len(events)
+		}
+		payload, err := json.Marshal(map[string]interface{}{"events": events[start:end]})
+		if err != nil {
+			return nil, fmt.Errorf("webhook: defender: %w", err)
+		}
+		payloads = append(payloads, payload)
+	}
+	return payloads, nil
+}
+
+// Event returns the event of activity.
+func (d Defender) Event(activity *Activity) *DefenderEvent {
+	entry := activity.Entry
+	blockNumber := hexutil.EncodeUint64(entry.BlockNumber)
+
+	monitor := d.Monitor
+	if activity.ChainID != nil {
+		if monitor.ChainID == 0 {
+			monitor.ChainID = activity.ChainID.Uint64()
+		}
+		if monitor.Network == "" {
+			monitor.Network = activity.ChainID.String()
+		}
+	}
+	if monitor.Addresses == nil {
+		monitor.Addresses = []string{strings.ToLower(activity.Wallet.Hex())}
+	}
+
+	event := &DefenderEvent{
+		Type:        "BLOCK",
+		Hash:        entry.TxnHash.Hex(),
+		BlockNumber: blockNumber,
+		Transaction: DefenderTransaction{
+			TransactionHash:  entry.TxnHash.Hex(),
+			TransactionIndex: hexutil.EncodeUint64(uint64(entry.TransactionIndex)),
+			BlockNumber:      blockNumber,
+			To:               strings.ToLower(activity.Wallet.Hex()),
+			Status:           "0x1",
+			GasUsed:          "0x0",
+			Logs:             []DefenderLog{},
+		},
+		MatchedAddresses:         []string{strings.ToLower(activity.Wallet.Hex())},
+		MatchedChecksumAddresses: []string{activity.Wallet.Hex()},
+		Monitor:                  monitor,
+		Value:                    hexutil.EncodeBig(activity.Value()),
+		Metadata:                 activity.metadata(),
+	}
+	if !entry.Timestamp.IsZero() {
+		event.Timestamp = entry.Timestamp.Unix()
+	}
+
+	if receipt := activity.nativeReceipt(); receipt != nil {
+		event.BlockHash = receipt.BlockHash.Hex()
+		event.Transaction.BlockHash = event.BlockHash
+		event.Transaction.GasUsed = hexutil.EncodeUint64(receipt.GasUsed)
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			event.Transaction.Status = "0x0"
+		}
+	}
+	for _, log := range activity.Logs() {
+		event.Transaction.Logs = append(event.Transaction.Logs, defenderLog(log))
+	}
+
+	params := map[string]interface{}{
+		"metaTxnID": string(entry.MetaTxnID),
+		"status":    entry.Status.String(),
+	}
+	if entry.Reason != "" {
+		params["reason"] = entry.Reason
+	}
+	event.MatchReasons = append(event.MatchReasons, DefenderMatchReason{
+		Type:      "transaction",
+		Condition: fmt.Sprintf("status == %q", entry.Status.String()),
+		Address:   activity.Wallet.Hex(),
+		Params:    params,
+	})
+	for _, target := range activity.Targets() {
+		if target == activity.Wallet {
+			continue
+		}
+		event.MatchedAddresses = append(event.MatchedAddresses, strings.ToLower(target.Hex()))
+		event.MatchedChecksumAddresses = append(event.MatchedChecksumAddresses, target.Hex())
+	}
+
+	return event
+}
+
+func defenderLog(log *types.Log) DefenderLog {
+	topics := make([]string, 0, len(log.Topics))
+	for _, topic := range log.Topics {
+		topics = append(topics, topic.Hex())
+	}
+	return DefenderLog{
+		Address:         strings.ToLower(log.Address.Hex()),
+		Topics:          topics,
+		Data:            hexutil.Encode(log.Data),
+		LogIndex:        hexutil.EncodeUint64(uint64(log.Index)),
+		TransactionHash: log.TxHash.Hex(),
+		BlockHash:       log.BlockHash.Hex(),
+		BlockNumber:     hexutil.EncodeUint64(log.BlockNumber),
+	}
+}
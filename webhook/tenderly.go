@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+// Tenderly exports activities as the webhooks of Tenderly alerts, ie. a body per activity,
+// as Tenderly posts an alert per transaction.
+type Tenderly struct {
+	// AlertID and AlertName are the alert reported by the webhooks.
+	AlertID   string
+	AlertName string
+
+	// Network is the network of the transactions, which defaults to the chain ID of the activity.
+	Network string
+}
+
+var _ Exporter = Tenderly{}
+
+// TenderlyWebhook is the body of a Tenderly alert webhook.
+type TenderlyWebhook struct {
+	ID          string                 `json:"id"`
+	EventType   string                 `json:"event_type"`
+	Alert       TenderlyAlert          `json:"alert"`
+	Transaction TenderlyTransaction    `json:"transaction"`
+	Metadata    map[string]interface{} `json:"metadata"`
+}
+
+// TenderlyAlert is the alert of a TenderlyWebhook.
+type TenderlyAlert struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// TenderlyTransaction is the transaction of a TenderlyWebhook.
+type TenderlyTransaction struct {
+	Network          string        `json:"network"`
+	Hash             string        `json:"hash"`
+	BlockHash        string        `json:"block_hash"`
+	BlockNumber      uint64        `json:"block_number"`
+	TransactionIndex uint          `json:"transaction_index"`
+	To               string        `json:"to"`
+	Value            string        `json:"value"`
+	Status           bool          `json:"status"`
+	GasUsed          uint64        `json:"gas_used"`
+	Timestamp        string        `json:"timestamp,omitempty"`
+	Addresses        []string      `json:"addresses"`
+	Logs             []TenderlyLog `json:"logs"`
+}
+
+// TenderlyLog is a log of a TenderlyTransaction.
+type TenderlyLog struct {
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    string   `json:"data"`
+}
+
+// Export returns the bodies of the webhooks of activities, one per activity.
+func (t Tenderly) Export(activities []*Activity) ([][]byte, error) {
+	payloads := make([][]byte, 0, len(activities))
+	for _, activity := range activities {
+		payload, err := json.Marshal(t.Webhook(activity))
+		if err != nil {
+			return nil, fmt.Errorf("webhook: tenderly: %w", err)
+		}
+		payloads = append(payloads, payload)
+	}
+	return payloads, nil
+}
+
+// Webhook returns the webhook of activity. Its ID is the meta-transaction ID, so a consumer
+// deduplicates the deliveries of an activity.
+func (t Tenderly) Webhook(activity *Activity) *TenderlyWebhook {
+	entry := activity.Entry
+
+	network := t.Network
+	if network == "" && activity.ChainID != nil {
+		network = activity.ChainID.String()
+	}
+
+	webhook := &TenderlyWebhook{
+		ID:        string(entry.MetaTxnID),
+		EventType: "ALERT",
+		Alert:     TenderlyAlert{ID: t.AlertID, Name: t.AlertName},
+		Transaction: TenderlyTransaction{
+			Network:          network,
+			Hash:             entry.TxnHash.Hex(),
+			BlockNumber:      entry.BlockNumber,
+			TransactionIndex: entry.TransactionIndex,
+			To:               strings.ToLower(activity.Wallet.Hex()),
+			Value:            activity.Value().String(),
+			Status:           true,
+			Addresses:        []string{strings.ToLower(activity.Wallet.Hex())},
+			Logs:             []TenderlyLog{},
+		},
+		Metadata: activity.metadata(),
+	}
+	if !entry.Timestamp.IsZero() {
+		webhook.Transaction.Timestamp = entry.Timestamp.UTC().Format(time.RFC3339)
+	}
+
+	if receipt := activity.nativeReceipt(); receipt != nil {
+		webhook.Transaction.BlockHash = receipt.BlockHash.Hex()
+		webhook.Transaction.GasUsed = receipt.GasUsed
+		webhook.Transaction.Status = receipt.Status == types.ReceiptStatusSuccessful
+	}
+	for _, target := range activity.Targets() {
+		if target != activity.Wallet {
+			webhook.Transaction.Addresses = append(webhook.Transaction.Addresses, strings.ToLower(target.Hex()))
+		}
+	}
+	for _, log := range activity.Logs() {
+		topics := make([]string, 0, len(log.Topics))
+		for _, topic := range log.Topics {
+			topics = append(topics, topic.Hex())
+		}
+		webhook.Transaction.Logs = append(webhook.Transaction.Logs, TenderlyLog{
+			Address: strings.ToLower(log.Address.Hex()),
+			Topics:  topics,
+			Data:    hexutil.Encode(log.Data),
+		})
+	}
+
+	return webhook
+}
@@ -0,0 +1,145 @@
+// Package webhook translates the meta-transactions of wallets into the webhook payloads of
+// monitoring stacks, ie. OpenZeppelin Defender monitors and Tenderly alerts, so the pipelines
+// consuming them handle the activity of Sequence wallets as any other:
+//
+//	page, _ := wallet.History(ctx, sequence.HistoryOptions{})
+//	activities := webhook.Activities(chainID, wallet.Address(), page.Entries...)
+//	payloads, _ := webhook.Defender{Monitor: webhook.DefenderMonitor{Name: "treasury"}}.Export(activities)
+//	err := webhook.Deliver(ctx, http.DefaultClient, url, payloads)
+//
+// The payloads have the fields of the formats which alerting rules and notifications read,
+// and carry the meta-transaction ID, status and revert reason of each bundle, which native
+// transactions lack, in their metadata.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+)
+
+// Activity is a meta-transaction executed by a wallet.
+type Activity struct {
+	ChainID *big.Int
+	Wallet  common.Address
+	Entry   *sequence.HistoryEntry
+}
+
+// Activities returns the activities of the entries of the history of wallet.
+func Activities(chainID *big.Int, wallet common.Address, entries ...*sequence.HistoryEntry) []*Activity {
+	activities := make([]*Activity, 0, len(entries))
+	for _, entry := range entries {
+		activities = append(activities, &Activity{ChainID: chainID, Wallet: wallet, Entry: entry})
+	}
+	return activities
+}
+
+// Exporter translates activities into the bodies of the webhooks of a monitoring stack.
+type Exporter interface {
+	Export(activities []*Activity) ([][]byte, error)
+}
+
+// ExporterFunc is an Exporter of a func.
+type ExporterFunc func(activities []*Activity) ([][]byte, error)
+
+func (f ExporterFunc) Export(activities []*Activity) ([][]byte, error) {
+	return f(activities)
+}
+
+// Deliver posts the payloads to the webhook at url, in order, and stops at the first which
+// isn't accepted.
+func Deliver(ctx context.Context, client *http.Client, url string, payloads [][]byte) error {
+	for i, payload := range payloads {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("webhook: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("webhook: payload %v: %w", i, err)
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook: payload %v: status %v: %s", i, resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+	}
+	return nil
+}
+
+// nativeReceipt returns the receipt of the native transaction of the activity, or nil if
+// the entry wasn't decoded from it.
+func (a *Activity) nativeReceipt() *types.Receipt {
+	for _, receipt := range a.Entry.Receipts {
+		if receipt.Receipt != nil {
+			return receipt.Receipt
+		}
+	}
+	return nil
+}
+
+// Logs returns the logs emitted by the transactions of the bundle, not those of other
+// bundles of the native transaction.
+func (a *Activity) Logs() []*types.Log {
+	var logs []*types.Log
+	for _, receipt := range a.Entry.Receipts {
+		logs = appendReceiptLogs(logs, receipt)
+	}
+	return logs
+}
+
+func appendReceiptLogs(logs []*types.Log, receipt *sequence.Receipt) []*types.Log {
+	logs = append(logs, receipt.Logs...)
+	for _, child := range receipt.Receipts {
+		logs = appendReceiptLogs(logs, child)
+	}
+	return logs
+}
+
+// Targets returns the contracts called by the bundle, in order of their first call.
+func (a *Activity) Targets() []common.Address {
+	var targets []common.Address
+	seen := map[common.Address]bool{}
+	for _, txn := range a.Entry.Transactions {
+		if txn != nil && !seen[txn.To] {
+			seen[txn.To] = true
+			targets = append(targets, txn.To)
+		}
+	}
+	return targets
+}
+
+// Value returns the total value of the native token sent by the bundle.
+func (a *Activity) Value() *big.Int {
+	value := new(big.Int)
+	for _, txn := range a.Entry.Transactions {
+		if txn != nil && txn.Value != nil {
+			value.Add(value, txn.Value)
+		}
+	}
+	return value
+}
+
+// metadata returns the fields of the meta-transaction of the activity.
+func (a *Activity) metadata() map[string]interface{} {
+	metadata := map[string]interface{}{
+		"metaTxnID":    string(a.Entry.MetaTxnID),
+		"status":       a.Entry.Status.String(),
+		"wallet":       a.Wallet.Hex(),
+		"transactions": len(a.Entry.Transactions),
+	}
+	if a.Entry.Reason != "" {
+		metadata["reason"] = a.Entry.Reason
+	}
+	return metadata
+}
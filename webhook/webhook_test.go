@@ -0,0 +1,136 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/go-sequence"
+	"github.com/0xsequence/go-sequence/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func activities() []*webhook.Activity {
+	wallet := common.HexToAddress("0xf0ba65550f2d1dccf4b131b774844dc3d801d886")
+	token := common.HexToAddress("0x70c3")
+	native := &types.Receipt{Status: types.ReceiptStatusSuccessful, GasUsed: 90000, BlockHash: common.HexToHash("0xb10c")}
+	transfer := &types.Log{Address: token, Topics: []common.Hash{sequence.ERC20TransferEventSig}, Data: common.HexToHash("0x64").Bytes(), Index: 3}
+
+	executed := &sequence.HistoryEntry{
+		MetaTxnID: "aa01",
+		Status:    sequence.MetaTxnExecuted,
+		Transactions: sequence.Transactions{
+			{To: common.HexToAddress("0x7a7"), Value: big.NewInt(100)},
+			{To: token, Data: []byte{0x01}},
+		},
+		Receipts: []*sequence.Receipt{
+			{Receipt: native, Status: sequence.MetaTxnExecuted},
+			{Receipt: native, Status: sequence.MetaTxnExecuted, Logs: []*types.Log{transfer}},
+		},
+		TxnHash:     common.HexToHash("0x7e"),
+		BlockNumber: 255,
+		Timestamp:   time.Unix(1700000000, 0),
+	}
+	failed := &sequence.HistoryEntry{
+		MetaTxnID:    "aa02",
+		Status:       sequence.MetaTxnFailed,
+		Reason:       "insufficient balance",
+		Transactions: sequence.Transactions{{To: token, Data: []byte{0x02}}},
+		TxnHash:      common.HexToHash("0x7f"),
+		BlockNumber:  256,
+	}
+	return webhook.Activities(big.NewInt(137), wallet, executed, failed)
+}
+
+func TestDefender(t *testing.T) {
+	payloads, err := webhook.Defender{Monitor: webhook.DefenderMonitor{ID: "m1", Name: "treasury"}}.Export(activities())
+	assert.NoError(t, err)
+	assert.Len(t, payloads, 1)
+
+	var body struct {
+		Events []*webhook.DefenderEvent `json:"events"`
+	}
+	assert.NoError(t, json.Unmarshal(payloads[0], &body))
+	assert.Len(t, body.Events, 2)
+
+	event := body.Events[0]
+	assert.Equal(t, "BLOCK", event.Type)
+	assert.Equal(t, common.HexToHash("0x7e").Hex(), event.Hash)
+	assert.Equal(t, "0xff", event.BlockNumber)
+	assert.Equal(t, int64(1700000000), event.Timestamp)
+	assert.Equal(t, "0x64", event.Value)
+	assert.Equal(t, "0x15f90", event.Transaction.GasUsed)
+	assert.Equal(t, "0x1", event.Transaction.Status)
+	assert.Equal(t, common.HexToHash("0xb10c").Hex(), event.BlockHash)
+	assert.Len(t, event.Transaction.Logs, 1)
+	assert.Equal(t, sequence.ERC20TransferEventSig.Hex(), event.Transaction.Logs[0].Topics[0])
+	assert.Equal(t, "0x3", event.Transaction.Logs[0].LogIndex)
+	assert.Equal(t, []string{"0xf0ba65550f2d1dccf4b131b774844dc3d801d886", "0x00000000000000000000000000000000000007a7", "0x00000000000000000000000000000000000070c3"}, event.MatchedAddresses)
+	assert.Equal(t, "treasury", event.Monitor.Name)
+	assert.Equal(t, uint64(137), event.Monitor.ChainID)
+	assert.Equal(t, "137", event.Monitor.Network)
+	assert.Equal(t, "aa01", event.Metadata["metaTxnID"])
+	assert.Equal(t, "executed", event.MatchReasons[0].Params["status"])
+
+	// the status of the bundle is reported, with its revert reason
+	event = body.Events[1]
+	assert.Equal(t, "failed", event.Metadata["status"])
+	assert.Equal(t, "insufficient balance", event.MatchReasons[0].Params["reason"])
+	assert.Empty(t, event.Transaction.Logs)
+
+	payloads, err = webhook.Defender{BatchSize: 1}.Export(activities())
+	assert.NoError(t, err)
+	assert.Len(t, payloads, 2)
+}
+
+func TestTenderly(t *testing.T) {
+	payloads, err := webhook.Tenderly{AlertID: "a1", AlertName: "treasury"}.Export(activities())
+	assert.NoError(t, err)
+	assert.Len(t, payloads, 2)
+
+	var hook webhook.TenderlyWebhook
+	assert.NoError(t, json.Unmarshal(payloads[0], &hook))
+	assert.Equal(t, "aa01", hook.ID)
+	assert.Equal(t, "ALERT", hook.EventType)
+	assert.Equal(t, "treasury", hook.Alert.Name)
+	assert.Equal(t, "137", hook.Transaction.Network)
+	assert.Equal(t, uint64(255), hook.Transaction.BlockNumber)
+	assert.Equal(t, uint64(90000), hook.Transaction.GasUsed)
+	assert.Equal(t, "100", hook.Transaction.Value)
+	assert.Equal(t, "2023-11-14T22:13:20Z", hook.Transaction.Timestamp)
+	assert.True(t, hook.Transaction.Status)
+	assert.Len(t, hook.Transaction.Logs, 1)
+	assert.Len(t, hook.Transaction.Addresses, 3)
+
+	assert.NoError(t, json.Unmarshal(payloads[1], &hook))
+	assert.Equal(t, "aa02", hook.ID)
+	assert.Equal(t, "insufficient balance", hook.Metadata["reason"])
+}
+
+func TestDeliver(t *testing.T) {
+	var received []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, string(body))
+		if len(received) > 2 {
+			http.Error(w, "rate limited", http.StatusTooManyRequests)
+		}
+	}))
+	defer ts.Close()
+
+	payloads, err := webhook.Tenderly{}.Export(activities())
+	assert.NoError(t, err)
+	assert.NoError(t, webhook.Deliver(context.Background(), ts.Client(), ts.URL, payloads))
+	assert.Len(t, received, 2)
+
+	err = webhook.Deliver(context.Background(), ts.Client(), ts.URL, payloads)
+	assert.ErrorContains(t, err, "rate limited")
+	assert.Len(t, received, 3)
+}